@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/keep-network/keep-core/config"
+	"github.com/keep-network/keep-core/pkg/storage"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+var (
+	auditActionFlagName = "action"
+	auditSinceFlagName  = "since"
+	auditLimitFlagName  = "limit"
+)
+
+// AuditCommand contains the definition of the audit command-line subcommand.
+var AuditCommand = &cobra.Command{
+	Use:              "audit",
+	Short:            "Prints the audit log of tBTC wallet actions",
+	Long:             auditDescription,
+	TraverseChildren: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := clientConfig.ReadConfig(
+			configFilePath,
+			cmd.Flags(),
+			config.General, config.Ethereum, config.Storage,
+		); err != nil {
+			logger.Fatalf("error reading config: %v", err)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wallet, err := cmd.Flags().GetString(walletFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find wallet flag: [%v]", err)
+		}
+
+		action, err := cmd.Flags().GetString(auditActionFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find action flag: [%v]", err)
+		}
+
+		since, err := cmd.Flags().GetDuration(auditSinceFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find since flag: [%v]", err)
+		}
+
+		limit, err := cmd.Flags().GetInt(auditLimitFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find limit flag: [%v]", err)
+		}
+
+		filter := &tbtc.WalletActionAuditFilter{
+			Action: action,
+			Limit:  limit,
+		}
+
+		if len(wallet) > 0 {
+			walletPublicKeyHash, err := newWalletPublicKeyHash(
+				wallet,
+				clientConfig.Bitcoin.Network,
+			)
+			if err != nil {
+				return fmt.Errorf(
+					"failed to extract wallet public key hash: [%v]",
+					err,
+				)
+			}
+
+			filter.WalletPublicKeyHash = &walletPublicKeyHash
+		}
+
+		if since > 0 {
+			filter.Since = time.Now().Add(-since)
+		}
+
+		storage, err := storage.Initialize(
+			clientConfig.Storage,
+			clientConfig.Ethereum.KeyFilePassword,
+		)
+		if err != nil {
+			return fmt.Errorf("cannot initialize storage: [%w]", err)
+		}
+
+		auditPersistence, err := storage.InitializeWorkPersistence(
+			tbtc.WalletActionAuditDirectory,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"cannot initialize wallet action audit persistence: [%w]",
+				err,
+			)
+		}
+
+		entries, err := tbtc.ReadWalletActionAudit(auditPersistence, filter)
+		if err != nil {
+			return fmt.Errorf("cannot read wallet action audit log: [%v]", err)
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return fmt.Errorf("cannot print audit entry: [%v]", err)
+			}
+		}
+
+		return nil
+	},
+}
+
+var auditDescription = `The audit command prints entries of the audit log of
+   wallet actions executed by the tBTC client, oldest first, as JSON lines.
+   Each entry describes the coordination proposal, the coordination window
+   and leader, the proposal validation, signing attempts, the produced
+   Bitcoin transaction and its broadcast. The audit log is read from the
+   work storage of the client. Its segments are encrypted with the same
+   password as the rest of the client storage so the command requires
+   the operator's key file password.`
+
+func init() {
+	initFlags(
+		AuditCommand,
+		&configFilePath,
+		clientConfig,
+		config.General, config.Ethereum, config.Storage,
+	)
+
+	AuditCommand.Flags().String(
+		walletFlagName,
+		"",
+		"(optional) wallet public key hash (hex)",
+	)
+
+	AuditCommand.Flags().String(
+		auditActionFlagName,
+		"",
+		"(optional) wallet action type, e.g. DepositSweep",
+	)
+
+	AuditCommand.Flags().Duration(
+		auditSinceFlagName,
+		0,
+		"(optional) print only actions dispatched within the given period, e.g. 24h",
+	)
+
+	AuditCommand.Flags().Int(
+		auditLimitFlagName,
+		0,
+		"(optional) maximum number of the most recent entries to print",
+	)
+}
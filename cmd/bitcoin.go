@@ -0,0 +1,446 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/config"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/bitcoin/bitcoind"
+	"github.com/keep-network/keep-core/pkg/bitcoin/composite"
+	"github.com/keep-network/keep-core/pkg/bitcoin/electrum"
+	"github.com/keep-network/keep-core/pkg/bitcoin/fee"
+	"github.com/keep-network/keep-core/pkg/bitcoin/walletindex"
+	"github.com/keep-network/keep-core/pkg/clientinfo"
+	"github.com/keep-network/keep-core/pkg/storage"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+	"github.com/keep-network/keep-core/pkg/tbtcpg"
+)
+
+// connectBitcoin connects to the Bitcoin chain using the backend selected
+// in the configuration. A bitcoind node is used if its URL is configured,
+// otherwise the client connects to an Electrum server. If additional
+// Electrum servers or bitcoind nodes are configured, all backends are
+// combined into a single composite chain. Apart from the chain, the
+// connection to the primary bitcoind node, or the first additional one if
+// the primary backend is an Electrum server, is returned so features
+// requiring bitcoind-specific data, like block transaction lists, can reuse
+// it instead of opening another connection. It is nil if no bitcoind node
+// is configured.
+func connectBitcoin(
+	ctx context.Context,
+	bitcoinConfig config.BitcoinConfig,
+) (btcChain bitcoin.Chain, bitcoindChain bitcoin.Chain, err error) {
+	primaryName, primaryChain, err := connectPrimaryBitcoin(ctx, bitcoinConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if bitcoinConfig.UseBitcoind() {
+		bitcoindChain = primaryChain
+	}
+
+	compositeConfig := bitcoinConfig.Composite
+	if len(compositeConfig.AdditionalElectrumURLs) == 0 &&
+		len(compositeConfig.AdditionalBitcoindURLs) == 0 {
+		return primaryChain, bitcoindChain, nil
+	}
+
+	backends := []*composite.Backend{
+		{Name: primaryName, Chain: primaryChain},
+	}
+
+	for _, url := range compositeConfig.AdditionalElectrumURLs {
+		electrumConfig := bitcoinConfig.Electrum
+		electrumConfig.URL = url
+
+		logger.Infof("connecting to additional Electrum server: [%s]", url)
+
+		chain, err := electrum.Connect(ctx, electrumConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"could not connect to additional Electrum server [%s]: [%w]",
+				url,
+				err,
+			)
+		}
+
+		backends = append(backends, &composite.Backend{Name: url, Chain: chain})
+	}
+
+	for _, url := range compositeConfig.AdditionalBitcoindURLs {
+		bitcoindConfig := bitcoinConfig.Bitcoind
+		bitcoindConfig.URL = url
+
+		logger.Infof("connecting to additional bitcoind node: [%s]", url)
+
+		chain, err := bitcoind.Connect(ctx, bitcoindConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"could not connect to additional bitcoind node [%s]: [%w]",
+				url,
+				err,
+			)
+		}
+
+		backends = append(backends, &composite.Backend{Name: url, Chain: chain})
+
+		if bitcoindChain == nil {
+			bitcoindChain = chain
+		}
+	}
+
+	quorum := compositeConfig.Quorum
+	if quorum == 0 {
+		quorum = composite.DefaultQuorum(len(backends))
+	}
+
+	if quorum < composite.DefaultQuorum(len(backends)) {
+		logger.Warnf(
+			"quorum of [%d] is not a strict majority of [%d] Bitcoin chain "+
+				"backends; a minority of backends can decide on results of "+
+				"security-critical requests",
+			quorum,
+			len(backends),
+		)
+	}
+
+	logger.Infof(
+		"using [%d] Bitcoin chain backends with quorum of [%d]",
+		len(backends),
+		quorum,
+	)
+
+	compositeChain, err := composite.NewChain(backends, quorum)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return compositeChain, bitcoindChain, nil
+}
+
+func connectPrimaryBitcoin(
+	ctx context.Context,
+	bitcoinConfig config.BitcoinConfig,
+) (string, bitcoin.Chain, error) {
+	if bitcoinConfig.UseBitcoind() {
+		logger.Infof(
+			"connecting to bitcoind node: [%s]",
+			bitcoinConfig.Bitcoind.URL,
+		)
+		chain, err := bitcoind.Connect(ctx, bitcoinConfig.Bitcoind)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return bitcoinConfig.Bitcoind.URL, chain, nil
+	}
+
+	chain, err := electrum.Connect(ctx, bitcoinConfig.Electrum)
+	return bitcoinConfig.Electrum.URL, chain, err
+}
+
+// newBitcoinFeeEstimator creates a fee rate estimator combining fee rate
+// estimations of the given Bitcoin chain with fee rates derived from the
+// mempool fee histogram, if the chain provides one.
+func newBitcoinFeeEstimator(
+	btcChain bitcoin.Chain,
+	bitcoinConfig config.BitcoinConfig,
+) (*fee.Estimator, error) {
+	sources := []*fee.Source{
+		{Name: "chain", FeeRateSource: btcChain},
+	}
+
+	if histogramProvider, ok := btcChain.(fee.HistogramProvider); ok {
+		sources = append(sources, &fee.Source{
+			Name:          "mempool histogram",
+			FeeRateSource: fee.NewHistogramSource(histogramProvider),
+		})
+	}
+
+	logger.Infof("using [%d] Bitcoin fee rate sources", len(sources))
+
+	return fee.NewEstimator(sources, bitcoinConfig.Fee)
+}
+
+// verifyBitcoinHeaders wraps the given Bitcoin chain with a local header
+// chain verifying block headers, if the header chain verification is enabled
+// in the configuration. The verified headers are kept in the given Bitcoin
+// data persistence returned by initializeBitcoinPersistence. The header chain
+// is synced in the background until the given context is done.
+func verifyBitcoinHeaders(
+	ctx context.Context,
+	btcChain bitcoin.Chain,
+	bitcoinPersistence persistence.BasicHandle,
+	clientConfig *config.Config,
+) (bitcoin.Chain, error) {
+	if !clientConfig.Bitcoin.HeaderChain.IsEnabled() {
+		return btcChain, nil
+	}
+
+	headersPersistence, err := requireBitcoinPersistence(
+		bitcoinPersistence,
+		"header chain verification",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	headerChain, err := bitcoin.NewHeaderChain(
+		btcChain,
+		clientConfig.Bitcoin.Network,
+		headersPersistence,
+		clientConfig.Bitcoin.HeaderChain,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create header chain: [%w]", err)
+	}
+
+	headerChain.Observe(ctx, bitcoin.DefaultHeaderChainSyncInterval)
+
+	return headerChain, nil
+}
+
+// indexWalletTransactions wraps the given Bitcoin chain with a local index
+// of transactions made by live wallets, if the wallet index is enabled in
+// the configuration. The indexed transactions are kept in the given Bitcoin
+// data persistence returned by initializeBitcoinPersistence.
+func indexWalletTransactions(
+	ctx context.Context,
+	btcChain bitcoin.Chain,
+	tbtcChain tbtcpg.Chain,
+	bitcoinPersistence persistence.BasicHandle,
+	clientConfig *config.Config,
+) (bitcoin.Chain, error) {
+	indexConfig := clientConfig.Bitcoin.WalletIndex
+	if !indexConfig.Enabled {
+		return btcChain, nil
+	}
+
+	walletsPersistence, err := requireBitcoinPersistence(
+		bitcoinPersistence,
+		"wallet index",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	index := walletindex.NewIndex(
+		btcChain,
+		walletsPersistence,
+		newLiveWalletSource(tbtcChain),
+	)
+
+	syncInterval := indexConfig.SyncInterval
+	if syncInterval == 0 {
+		syncInterval = walletindex.DefaultSyncInterval
+	}
+
+	index.Observe(ctx, syncInterval)
+
+	return index, nil
+}
+
+// assembleSpvProofsLocally wraps the given Bitcoin chain with an SPV proof
+// assembler building proofs from block headers of the local header chain and
+// transaction lists of blocks fetched from the given bitcoind node, if the
+// local assembly is enabled in the configuration. The given headers chain
+// must be the one returned by verifyBitcoinHeaders and the bitcoind chain
+// must be the one returned by connectBitcoin. Assembled proofs are cached in
+// the given Bitcoin data persistence returned by initializeBitcoinPersistence.
+func assembleSpvProofsLocally(
+	btcChain bitcoin.Chain,
+	headersChain bitcoin.Chain,
+	bitcoindChain bitcoin.Chain,
+	bitcoinPersistence persistence.BasicHandle,
+	clientConfig *config.Config,
+) (bitcoin.Chain, error) {
+	spvProofConfig := clientConfig.Bitcoin.SpvProof
+	if !spvProofConfig.LocalAssembly {
+		return btcChain, nil
+	}
+
+	headerChain, ok := headersChain.(*bitcoin.HeaderChain)
+	if !ok {
+		return nil, fmt.Errorf(
+			"local SPV proof assembly requires the local header chain; " +
+				"set bitcoin.headerChain.checkpointHash",
+		)
+	}
+
+	if bitcoindChain == nil {
+		return nil, fmt.Errorf(
+			"local SPV proof assembly requires a bitcoind node; " +
+				"set bitcoin.bitcoind.url",
+		)
+	}
+
+	blocks, ok := bitcoindChain.(bitcoin.BlockTxHashesSource)
+	if !ok {
+		return nil, fmt.Errorf(
+			"bitcoind node does not provide block transaction lists",
+		)
+	}
+
+	proofsPersistence, err := requireBitcoinPersistence(
+		bitcoinPersistence,
+		"local SPV proof assembly",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := bitcoin.NewSpvProofCache(
+		proofsPersistence,
+		spvProofConfig.CacheCapacity,
+	)
+
+	return bitcoin.NewLocalSpvProofAssembler(
+		btcChain,
+		headerChain,
+		blocks,
+		cache,
+	), nil
+}
+
+// newLiveWalletSource returns a wallet source providing public key hashes
+// of wallets that can still make Bitcoin transactions, i.e. wallets in the
+// Live or MovingFunds state. Wallets are discovered using new wallet
+// registered events. Wallets that reached a state they cannot leave are
+// remembered so their state is not checked again.
+func newLiveWalletSource(tbtcChain tbtcpg.Chain) walletindex.WalletSource {
+	var (
+		mutex           sync.Mutex
+		nextBlock       uint64
+		publicKeyHashes [][20]byte
+		inactive        = make(map[[20]byte]bool)
+	)
+
+	return func() ([][20]byte, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		events, err := tbtcChain.PastNewWalletRegisteredEvents(
+			&tbtc.NewWalletRegisteredEventFilter{StartBlock: nextBlock},
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get new wallet registered events: [%w]",
+				err,
+			)
+		}
+
+		for _, event := range events {
+			publicKeyHashes = append(publicKeyHashes, event.WalletPublicKeyHash)
+			nextBlock = event.BlockNumber + 1
+		}
+
+		var live [][20]byte
+		for _, publicKeyHash := range publicKeyHashes {
+			if inactive[publicKeyHash] {
+				continue
+			}
+
+			wallet, err := tbtcChain.GetWallet(publicKeyHash)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"cannot get wallet [0x%x]: [%w]",
+					publicKeyHash,
+					err,
+				)
+			}
+
+			switch wallet.State {
+			case tbtc.StateLive, tbtc.StateMovingFunds:
+				live = append(live, publicKeyHash)
+			case tbtc.StateClosing, tbtc.StateClosed, tbtc.StateTerminated:
+				inactive[publicKeyHash] = true
+			}
+		}
+
+		return live, nil
+	}
+}
+
+// initializeBitcoinPersistence initializes the work persistence holding
+// Bitcoin data in the given storage. The returned handle is meant to be
+// shared by all features keeping Bitcoin data locally.
+func initializeBitcoinPersistence(
+	storage storage.Storage,
+) (persistence.BasicHandle, error) {
+	handle, err := storage.InitializeWorkPersistence("bitcoin")
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot initialize bitcoin data persistence: [%w]",
+			err,
+		)
+	}
+
+	return handle, nil
+}
+
+// initializeOptionalBitcoinPersistence initializes the storage and the work
+// persistence holding Bitcoin data for commands that do not require the
+// storage otherwise. The returned handle is nil if the storage directory is
+// not configured.
+func initializeOptionalBitcoinPersistence(
+	clientConfig *config.Config,
+) (persistence.BasicHandle, error) {
+	if clientConfig.Storage.Dir == "" {
+		return nil, nil
+	}
+
+	storage, err := storage.Initialize(
+		clientConfig.Storage,
+		clientConfig.Ethereum.KeyFilePassword,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot initialize storage: [%w]", err)
+	}
+
+	return initializeBitcoinPersistence(storage)
+}
+
+// requireBitcoinPersistence returns the given Bitcoin data persistence or
+// an error if it is not set because the storage directory is not configured.
+// The given feature name is used in the returned error.
+func requireBitcoinPersistence(
+	bitcoinPersistence persistence.BasicHandle,
+	feature string,
+) (persistence.BasicHandle, error) {
+	if bitcoinPersistence == nil {
+		return nil, fmt.Errorf(
+			"missing value for storage.dir; required by %s",
+			feature,
+		)
+	}
+
+	return bitcoinPersistence, nil
+}
+
+// observeBitcoinMetrics triggers an observation process of metrics specific
+// to the given Bitcoin chain implementation, if any.
+func observeBitcoinMetrics(
+	clientInfoRegistry *clientinfo.Registry,
+	btcChain bitcoin.Chain,
+) {
+	compositeChain, ok := btcChain.(*composite.Chain)
+	if !ok || clientInfoRegistry == nil {
+		return
+	}
+
+	clientInfoRegistry.ObserveApplicationSource(
+		"btc",
+		map[string]clientinfo.Source{
+			"quorum_disagreements_count": func() float64 {
+				return float64(compositeChain.DisagreementsCount())
+			},
+			"backend_failures_count": func() float64 {
+				return float64(compositeChain.BackendFailuresCount())
+			},
+		},
+	)
+}
@@ -37,6 +37,9 @@ func init() {
 		EthereumCommand,
 		MaintainerCommand,
 		MaintainerCliCommand,
+		OfflineSigningCommand,
+		KeyStoreCommand,
+		AuditCommand,
 	)
 }
 
@@ -12,12 +12,19 @@ import (
 	"github.com/keep-network/keep-common/pkg/rate"
 	"github.com/keep-network/keep-core/config"
 	"github.com/keep-network/keep-core/config/network"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/bitcoin/bitcoind"
 	"github.com/keep-network/keep-core/pkg/bitcoin/electrum"
+	"github.com/keep-network/keep-core/pkg/bitcoin/fee"
+	"github.com/keep-network/keep-core/pkg/bitcoin/walletindex"
 	chainEthereum "github.com/keep-network/keep-core/pkg/chain/ethereum"
 	"github.com/keep-network/keep-core/pkg/clientinfo"
 	"github.com/keep-network/keep-core/pkg/maintainer/spv"
+	"github.com/keep-network/keep-core/pkg/maintainer/submission"
 	"github.com/keep-network/keep-core/pkg/net/libp2p"
+	"github.com/keep-network/keep-core/pkg/storage"
 	"github.com/keep-network/keep-core/pkg/tbtc"
+	"github.com/keep-network/keep-core/pkg/tbtcpg"
 )
 
 func initGlobalFlags(
@@ -40,6 +47,12 @@ func initFlags(
 			initEthereumFlags(cmd, cfg)
 		case config.BitcoinElectrum:
 			initBitcoinElectrumFlags(cmd, cfg)
+			initBitcoindFlags(cmd, cfg)
+			initBitcoinCompositeFlags(cmd, cfg)
+			initBitcoinHeaderChainFlags(cmd, cfg)
+			initBitcoinFeeFlags(cmd, cfg)
+			initBitcoinWalletIndexFlags(cmd, cfg)
+			initBitcoinSpvProofFlags(cmd, cfg)
 		case config.Network:
 			initNetworkFlags(cmd, cfg)
 		case config.Storage:
@@ -200,6 +213,157 @@ func initBitcoinElectrumFlags(cmd *cobra.Command, cfg *config.Config) {
 	)
 }
 
+// Initialize flags for Bitcoin bitcoind configuration.
+func initBitcoindFlags(cmd *cobra.Command, cfg *config.Config) {
+	cmd.Flags().StringVar(
+		&cfg.Bitcoin.Bitcoind.URL,
+		"bitcoin.bitcoind.url",
+		"",
+		"URL to the bitcoind JSON-RPC endpoint in format: `scheme://hostname:port`. If set, the bitcoind node is used instead of Electrum.",
+	)
+
+	cmd.Flags().StringVar(
+		&cfg.Bitcoin.Bitcoind.Username,
+		"bitcoin.bitcoind.username",
+		"",
+		"Username used to authenticate against the bitcoind JSON-RPC endpoint.",
+	)
+
+	cmd.Flags().StringVar(
+		&cfg.Bitcoin.Bitcoind.Password,
+		"bitcoin.bitcoind.password",
+		"",
+		"Password used to authenticate against the bitcoind JSON-RPC endpoint.",
+	)
+
+	cmd.Flags().DurationVar(
+		&cfg.Bitcoin.Bitcoind.RequestTimeout,
+		"bitcoin.bitcoind.requestTimeout",
+		bitcoind.DefaultRequestTimeout,
+		"Timeout for a single attempt of bitcoind JSON-RPC request.",
+	)
+
+	cmd.Flags().DurationVar(
+		&cfg.Bitcoin.Bitcoind.RequestRetryTimeout,
+		"bitcoin.bitcoind.requestRetryTimeout",
+		bitcoind.DefaultRequestRetryTimeout,
+		"Timeout for bitcoind JSON-RPC request retries.",
+	)
+
+	cmd.Flags().DurationVar(
+		&cfg.Bitcoin.Bitcoind.ScanTimeout,
+		"bitcoin.bitcoind.scanTimeout",
+		bitcoind.DefaultScanTimeout,
+		"Timeout for a single attempt of bitcoind UTXO set and block filter scans.",
+	)
+
+	cmd.Flags().UintVar(
+		&cfg.Bitcoin.Bitcoind.ScanStartHeight,
+		"bitcoin.bitcoind.scanStartHeight",
+		0,
+		"Height of the block the transaction history scans start from.",
+	)
+}
+
+// Initialize flags for Bitcoin composite chain configuration.
+func initBitcoinCompositeFlags(cmd *cobra.Command, cfg *config.Config) {
+	cmd.Flags().StringSliceVar(
+		&cfg.Bitcoin.Composite.AdditionalElectrumURLs,
+		"bitcoin.composite.additionalElectrumURLs",
+		[]string{},
+		"URLs to additional Electrum servers used as Bitcoin chain backends next to the primary backend.",
+	)
+
+	cmd.Flags().StringSliceVar(
+		&cfg.Bitcoin.Composite.AdditionalBitcoindURLs,
+		"bitcoin.composite.additionalBitcoindURLs",
+		[]string{},
+		"URLs to additional bitcoind JSON-RPC endpoints used as Bitcoin chain backends next to the primary backend.",
+	)
+
+	cmd.Flags().IntVar(
+		&cfg.Bitcoin.Composite.Quorum,
+		"bitcoin.composite.quorum",
+		0,
+		"Number of Bitcoin chain backends that must agree on the result of a security-critical request. A strict majority of backends is required if not set.",
+	)
+}
+
+// Initialize flags for Bitcoin header chain configuration.
+func initBitcoinHeaderChainFlags(cmd *cobra.Command, cfg *config.Config) {
+	cmd.Flags().UintVar(
+		&cfg.Bitcoin.HeaderChain.CheckpointHeight,
+		"bitcoin.headerChain.checkpointHeight",
+		0,
+		"Height of the trusted block the local header chain starts from. Must be the first block of a difficulty epoch.",
+	)
+
+	cmd.Flags().StringVar(
+		&cfg.Bitcoin.HeaderChain.CheckpointHash,
+		"bitcoin.headerChain.checkpointHash",
+		"",
+		"Hash of the trusted block the local header chain starts from. If set, block headers are verified locally.",
+	)
+}
+
+// Initialize flags for Bitcoin fee estimation configuration.
+func initBitcoinFeeFlags(cmd *cobra.Command, cfg *config.Config) {
+	cmd.Flags().Int64Var(
+		&cfg.Bitcoin.Fee.MinSatPerVByte,
+		"bitcoin.fee.minSatPerVByte",
+		0,
+		"Floor of the estimated Bitcoin fee rate in satoshi per vbyte. Zero disables the floor.",
+	)
+
+	cmd.Flags().Int64Var(
+		&cfg.Bitcoin.Fee.MaxSatPerVByte,
+		"bitcoin.fee.maxSatPerVByte",
+		0,
+		"Ceiling of the estimated Bitcoin fee rate in satoshi per vbyte. Zero disables the ceiling.",
+	)
+
+	cmd.Flags().DurationVar(
+		&cfg.Bitcoin.Fee.HistoryWindow,
+		"bitcoin.fee.historyWindow",
+		fee.DefaultHistoryWindow,
+		"Period of recent fee rate estimations used to smooth out short fee spikes.",
+	)
+}
+
+// Initialize flags for Bitcoin wallet index configuration.
+func initBitcoinWalletIndexFlags(cmd *cobra.Command, cfg *config.Config) {
+	cmd.Flags().BoolVar(
+		&cfg.Bitcoin.WalletIndex.Enabled,
+		"bitcoin.walletIndex.enabled",
+		false,
+		"Index transactions of live wallets locally instead of fetching them from the Bitcoin chain backend on each use.",
+	)
+
+	cmd.Flags().DurationVar(
+		&cfg.Bitcoin.WalletIndex.SyncInterval,
+		"bitcoin.walletIndex.syncInterval",
+		walletindex.DefaultSyncInterval,
+		"Interval at which the wallet index is synced with the Bitcoin chain.",
+	)
+}
+
+// Initialize flags for Bitcoin SPV proof assembly configuration.
+func initBitcoinSpvProofFlags(cmd *cobra.Command, cfg *config.Config) {
+	cmd.Flags().BoolVar(
+		&cfg.Bitcoin.SpvProof.LocalAssembly,
+		"bitcoin.spvProof.localAssembly",
+		false,
+		"Assemble SPV proofs from the local header chain and block transaction lists fetched from the bitcoind node.",
+	)
+
+	cmd.Flags().IntVar(
+		&cfg.Bitcoin.SpvProof.CacheCapacity,
+		"bitcoin.spvProof.cacheCapacity",
+		bitcoin.DefaultSpvProofCacheCapacity,
+		"Maximum number of locally assembled SPV proofs kept in the cache.",
+	)
+}
+
 // Initialize flags for Network configuration.
 func initNetworkFlags(cmd *cobra.Command, cfg *config.Config) {
 	cmd.Flags().BoolVar(
@@ -247,6 +411,41 @@ func initStorageFlags(cmd *cobra.Command, cfg *config.Config) {
 		"",
 		"Location to store the Keep client key shares and other sensitive data.",
 	)
+
+	cmd.Flags().StringVar(
+		&cfg.Storage.KeyStoreBackend,
+		"storage.keyStoreBackend",
+		storage.KeyStoreBackendFile,
+		"Backend keeping the Keep client key shares. Supported values are `file` and `remote`.",
+	)
+
+	cmd.Flags().StringVar(
+		&cfg.Storage.RemoteKeyStore.URL,
+		"storage.remoteKeyStore.url",
+		"",
+		"URL of the remote secrets store used by the `remote` key store backend.",
+	)
+
+	cmd.Flags().StringVar(
+		&cfg.Storage.RemoteKeyStore.Token,
+		"storage.remoteKeyStore.token",
+		"",
+		"Bearer token used to authenticate to the remote secrets store.",
+	)
+
+	cmd.Flags().DurationVar(
+		&cfg.Storage.RemoteKeyStore.RequestTimeout,
+		"storage.remoteKeyStore.requestTimeout",
+		storage.DefaultRemoteKeyStoreRequestTimeout,
+		"Timeout for a single request to the remote secrets store.",
+	)
+
+	cmd.Flags().DurationVar(
+		&cfg.Storage.RemoteKeyStore.RequestRetryTimeout,
+		"storage.remoteKeyStore.requestRetryTimeout",
+		storage.DefaultRemoteKeyStoreRequestRetryTimeout,
+		"Timeout for all retries of a request to the remote secrets store.",
+	)
 }
 
 // Initialize flags for ClientInfo configuration.
@@ -308,6 +507,92 @@ func initTbtcFlags(cmd *cobra.Command, cfg *config.Config) {
 		tbtc.DefaultKeyGenerationConcurrency,
 		"tECDSA key generation concurrency.",
 	)
+
+	cmd.Flags().StringVar(
+		&cfg.Tbtc.WalletHealthWebhookURL,
+		"tbtc.walletHealthWebhookURL",
+		"",
+		"URL of the webhook receiving wallet health alerts. "+
+			"Empty disables the alerts.",
+	)
+
+	cmd.Flags().IntVar(
+		&cfg.Tbtc.WalletActionAuditSegmentSize,
+		"tbtc.walletActionAuditSegmentSize",
+		tbtc.DefaultWalletActionAuditSegmentSize,
+		"Size in bytes above which a new wallet action audit log segment is started.",
+	)
+
+	cmd.Flags().IntVar(
+		&cfg.Tbtc.WalletActionAuditMaxSegments,
+		"tbtc.walletActionAuditMaxSegments",
+		tbtc.DefaultWalletActionAuditMaxSegments,
+		"Number of the most recent wallet action audit log segments that are kept.",
+	)
+
+	cmd.Flags().Uint32Var(
+		&cfg.ProposalGenerator.DepositSweepConfirmationTarget,
+		"proposalGenerator.depositSweepConfirmationTarget",
+		tbtcpg.DefaultConfirmationTarget,
+		"Number of Bitcoin blocks within which proposed deposit sweep transactions should be confirmed.",
+	)
+
+	cmd.Flags().Uint32Var(
+		&cfg.ProposalGenerator.RedemptionConfirmationTarget,
+		"proposalGenerator.redemptionConfirmationTarget",
+		tbtcpg.DefaultConfirmationTarget,
+		"Number of Bitcoin blocks within which proposed redemption transactions should be confirmed.",
+	)
+
+	cmd.Flags().Uint32Var(
+		&cfg.ProposalGenerator.MovingFundsConfirmationTarget,
+		"proposalGenerator.movingFundsConfirmationTarget",
+		tbtcpg.DefaultConfirmationTarget,
+		"Number of Bitcoin blocks within which proposed moving funds transactions should be confirmed.",
+	)
+
+	cmd.Flags().Uint32Var(
+		&cfg.ProposalGenerator.MovedFundsSweepConfirmationTarget,
+		"proposalGenerator.movedFundsSweepConfirmationTarget",
+		tbtcpg.DefaultConfirmationTarget,
+		"Number of Bitcoin blocks within which proposed moved funds sweep transactions should be confirmed.",
+	)
+
+	cmd.Flags().Uint32Var(
+		&cfg.ProposalGenerator.FeeBumpConfirmationTarget,
+		"proposalGenerator.feeBumpConfirmationTarget",
+		tbtcpg.DefaultConfirmationTarget,
+		"Number of Bitcoin blocks within which stuck transactions should be confirmed after a fee bump.",
+	)
+
+	cmd.Flags().Float64Var(
+		&cfg.ProposalGenerator.DepositSweepMaxFeeShare,
+		"proposalGenerator.depositSweepMaxFeeShare",
+		tbtcpg.DefaultDepositSweepMaxFeeShare,
+		"Maximum share of the swept amount the deposit sweep fee can consume for a non-urgent sweep to be proposed.",
+	)
+
+	cmd.Flags().DurationVar(
+		&cfg.ProposalGenerator.DepositSweepMaxWait,
+		"proposalGenerator.depositSweepMaxWait",
+		tbtcpg.DefaultDepositSweepMaxWait,
+		"Maximum time a deposit should wait for a sweep before it is proposed regardless of the fee.",
+	)
+
+	cmd.Flags().DurationVar(
+		&cfg.ProposalGenerator.DepositRefundSafetyMargin,
+		"proposalGenerator.depositRefundSafetyMargin",
+		tbtcpg.DefaultDepositRefundSafetyMargin,
+		"Minimum time that must remain until the deposit refund locktime for the deposit to be swept.",
+	)
+
+	cmd.Flags().BoolVar(
+		&cfg.ProposalGenerator.RedemptionFeeSharesEnabled,
+		"proposalGenerator.redemptionFeeSharesEnabled",
+		false,
+		"Split redemption fees over requests proportionally to their maximum fees. "+
+			"Enable only once all operators of the wallet run a client version supporting fee shares.",
+	)
 }
 
 // Initialize flags for Maintainer configuration.
@@ -363,6 +648,58 @@ func initMaintainerFlags(command *cobra.Command, cfg *config.Config) {
 		"The wait time which should be applied when there are no more "+
 			"transaction proofs to submit.",
 	)
+
+	command.Flags().DurationVar(
+		&cfg.Maintainer.Spv.RetryBackoffTime,
+		"spv.retryBackoffTime",
+		spv.DefaultRetryBackoffTime,
+		"The initial wait time before the proof of a transaction is retried "+
+			"after a failure. Doubles with each consecutive failure. "+
+			"Backoffs are kept in the work storage, if configured.",
+	)
+
+	command.Flags().DurationVar(
+		&cfg.Maintainer.Spv.MaxRetryBackoffTime,
+		"spv.maxRetryBackoffTime",
+		spv.DefaultMaxRetryBackoffTime,
+		"The maximum wait time before the proof of a transaction is retried "+
+			"after a failure.",
+	)
+
+	command.Flags().StringSliceVar(
+		&cfg.Maintainer.Spv.Maintainers,
+		"spv.maintainers",
+		[]string{},
+		"Addresses of all cooperating SPV maintainers, including this one. "+
+			"If set, maintainers coordinate to avoid submitting the same proofs.",
+	)
+
+	command.Flags().Uint64Var(
+		&cfg.Maintainer.Spv.ClaimPeriod,
+		"spv.claimPeriod",
+		spv.DefaultClaimPeriod,
+		"Number of blocks for which a maintainer claims the proof of a "+
+			"transaction before the next maintainer takes it over.",
+	)
+
+	flag.WeiVarFlag(
+		command.Flags(),
+		&cfg.Maintainer.Submission.MaxGasPrice,
+		"submission.maxGasPrice",
+		*commonEthereum.WrapWei(big.NewInt(0)),
+		"The maximum gas price at which maintainers submit transactions that "+
+			"are not urgent. If exceeded, such transactions are deferred. "+
+			"Urgent transactions are always submitted. No limit if not set.",
+	)
+
+	command.Flags().DurationVar(
+		&cfg.Maintainer.Submission.UrgencyWindow,
+		"submission.urgencyWindow",
+		submission.DefaultUrgencyWindow,
+		"The time before a deadline, or after the work became ready, since "+
+			"which maintainer transactions are submitted regardless of the "+
+			"gas price.",
+	)
 }
 
 // Initialize flags for Developer configuration.
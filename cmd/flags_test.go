@@ -122,6 +122,145 @@ var cmdFlagsTests = map[string]struct {
 		expectedValueFromFlag: 660 * time.Second,
 		defaultValue:          300 * time.Second,
 	},
+	"bitcoin.bitcoind.url": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Bitcoind.URL },
+		flagName:              "--bitcoin.bitcoind.url",
+		flagValue:             "http://url.to.bitcoind:8332",
+		expectedValueFromFlag: "http://url.to.bitcoind:8332",
+		defaultValue:          "",
+	},
+	"bitcoin.bitcoind.username": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Bitcoind.Username },
+		flagName:              "--bitcoin.bitcoind.username",
+		flagValue:             "rpcuser",
+		expectedValueFromFlag: "rpcuser",
+		defaultValue:          "",
+	},
+	"bitcoin.bitcoind.password": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Bitcoind.Password },
+		flagName:              "--bitcoin.bitcoind.password",
+		flagValue:             "rpcpassword",
+		expectedValueFromFlag: "rpcpassword",
+		defaultValue:          "",
+	},
+	"bitcoin.bitcoind.requestTimeout": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Bitcoind.RequestTimeout },
+		flagName:              "--bitcoin.bitcoind.requestTimeout",
+		flagValue:             "47s",
+		expectedValueFromFlag: 47 * time.Second,
+		defaultValue:          30 * time.Second,
+	},
+	"bitcoin.bitcoind.requestRetryTimeout": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Bitcoind.RequestRetryTimeout },
+		flagName:              "--bitcoin.bitcoind.requestRetryTimeout",
+		flagValue:             "7m",
+		expectedValueFromFlag: 420 * time.Second,
+		defaultValue:          120 * time.Second,
+	},
+	"bitcoin.bitcoind.scanTimeout": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Bitcoind.ScanTimeout },
+		flagName:              "--bitcoin.bitcoind.scanTimeout",
+		flagValue:             "25m",
+		expectedValueFromFlag: 1500 * time.Second,
+		defaultValue:          600 * time.Second,
+	},
+	"bitcoin.bitcoind.scanStartHeight": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Bitcoind.ScanStartHeight },
+		flagName:              "--bitcoin.bitcoind.scanStartHeight",
+		flagValue:             "800000",
+		expectedValueFromFlag: uint(800000),
+		defaultValue:          uint(0),
+	},
+	"bitcoin.composite.additionalElectrumURLs": {
+		readValueFunc: func(c *config.Config) interface{} { return c.Bitcoin.Composite.AdditionalElectrumURLs },
+		flagName:      "--bitcoin.composite.additionalElectrumURLs",
+		flagValue:     `"tcp://url.to.electrum:50001","ssl://url.to.electrum:50002"`,
+		expectedValueFromFlag: []string{
+			"tcp://url.to.electrum:50001",
+			"ssl://url.to.electrum:50002",
+		},
+		defaultValue: []string{},
+	},
+	"bitcoin.composite.additionalBitcoindURLs": {
+		readValueFunc: func(c *config.Config) interface{} { return c.Bitcoin.Composite.AdditionalBitcoindURLs },
+		flagName:      "--bitcoin.composite.additionalBitcoindURLs",
+		flagValue:     `"http://url.to.bitcoind.2:8332","http://url.to.bitcoind.3:8332"`,
+		expectedValueFromFlag: []string{
+			"http://url.to.bitcoind.2:8332",
+			"http://url.to.bitcoind.3:8332",
+		},
+		defaultValue: []string{},
+	},
+	"bitcoin.composite.quorum": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Composite.Quorum },
+		flagName:              "--bitcoin.composite.quorum",
+		flagValue:             "2",
+		expectedValueFromFlag: 2,
+		defaultValue:          0,
+	},
+	"bitcoin.headerChain.checkpointHeight": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.HeaderChain.CheckpointHeight },
+		flagName:              "--bitcoin.headerChain.checkpointHeight",
+		flagValue:             "822528",
+		expectedValueFromFlag: uint(822528),
+		defaultValue:          uint(0),
+	},
+	"bitcoin.headerChain.checkpointHash": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.HeaderChain.CheckpointHash },
+		flagName:              "--bitcoin.headerChain.checkpointHash",
+		flagValue:             "00000000000000000001e4ac8e3fc4f7b4e0bb1b4c64c9dc4d36a8c1a3e7f2d9",
+		expectedValueFromFlag: "00000000000000000001e4ac8e3fc4f7b4e0bb1b4c64c9dc4d36a8c1a3e7f2d9",
+		defaultValue:          "",
+	},
+	"bitcoin.fee.minSatPerVByte": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Fee.MinSatPerVByte },
+		flagName:              "--bitcoin.fee.minSatPerVByte",
+		flagValue:             "3",
+		expectedValueFromFlag: int64(3),
+		defaultValue:          int64(0),
+	},
+	"bitcoin.fee.maxSatPerVByte": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Fee.MaxSatPerVByte },
+		flagName:              "--bitcoin.fee.maxSatPerVByte",
+		flagValue:             "300",
+		expectedValueFromFlag: int64(300),
+		defaultValue:          int64(0),
+	},
+	"bitcoin.fee.historyWindow": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.Fee.HistoryWindow },
+		flagName:              "--bitcoin.fee.historyWindow",
+		flagValue:             "1h",
+		expectedValueFromFlag: time.Hour,
+		defaultValue:          30 * time.Minute,
+	},
+	"bitcoin.walletIndex.enabled": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.WalletIndex.Enabled },
+		flagName:              "--bitcoin.walletIndex.enabled",
+		flagValue:             "", // don't provide any value
+		expectedValueFromFlag: true,
+		defaultValue:          false,
+	},
+	"bitcoin.walletIndex.syncInterval": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.WalletIndex.SyncInterval },
+		flagName:              "--bitcoin.walletIndex.syncInterval",
+		flagValue:             "5m",
+		expectedValueFromFlag: 5 * time.Minute,
+		defaultValue:          10 * time.Minute,
+	},
+	"bitcoin.spvProof.localAssembly": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.SpvProof.LocalAssembly },
+		flagName:              "--bitcoin.spvProof.localAssembly",
+		flagValue:             "", // don't provide any value
+		expectedValueFromFlag: true,
+		defaultValue:          false,
+	},
+	"bitcoin.spvProof.cacheCapacity": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Bitcoin.SpvProof.CacheCapacity },
+		flagName:              "--bitcoin.spvProof.cacheCapacity",
+		flagValue:             "250",
+		expectedValueFromFlag: 250,
+		defaultValue:          1000,
+	},
 	"network.bootstrap": {
 		readValueFunc:         func(c *config.Config) interface{} { return c.LibP2P.Bootstrap },
 		flagName:              "--network.bootstrap",
@@ -169,6 +308,41 @@ var cmdFlagsTests = map[string]struct {
 		flagValue:     "./flagged/location/dude",
 		defaultValue:  "",
 	},
+	"storage.keyStoreBackend": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Storage.KeyStoreBackend },
+		flagName:              "--storage.keyStoreBackend",
+		flagValue:             "remote",
+		expectedValueFromFlag: "remote",
+		defaultValue:          "file",
+	},
+	"storage.remoteKeyStore.url": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Storage.RemoteKeyStore.URL },
+		flagName:              "--storage.remoteKeyStore.url",
+		flagValue:             "https://url.to.secrets:8200/v1/keep",
+		expectedValueFromFlag: "https://url.to.secrets:8200/v1/keep",
+		defaultValue:          "",
+	},
+	"storage.remoteKeyStore.token": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Storage.RemoteKeyStore.Token },
+		flagName:              "--storage.remoteKeyStore.token",
+		flagValue:             "s3cr3t",
+		expectedValueFromFlag: "s3cr3t",
+		defaultValue:          "",
+	},
+	"storage.remoteKeyStore.requestTimeout": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Storage.RemoteKeyStore.RequestTimeout },
+		flagName:              "--storage.remoteKeyStore.requestTimeout",
+		flagValue:             "45s",
+		expectedValueFromFlag: 45 * time.Second,
+		defaultValue:          30 * time.Second,
+	},
+	"storage.remoteKeyStore.requestRetryTimeout": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Storage.RemoteKeyStore.RequestRetryTimeout },
+		flagName:              "--storage.remoteKeyStore.requestRetryTimeout",
+		flagValue:             "5m",
+		expectedValueFromFlag: 5 * time.Minute,
+		defaultValue:          2 * time.Minute,
+	},
 	"clientInfo.port": {
 		readValueFunc:         func(c *config.Config) interface{} { return c.ClientInfo.Port },
 		flagName:              "--clientInfo.port",
@@ -225,6 +399,90 @@ var cmdFlagsTests = map[string]struct {
 		expectedValueFromFlag: 101,
 		defaultValue:          runtime.GOMAXPROCS(0),
 	},
+	"tbtc.walletHealthWebhookURL": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Tbtc.WalletHealthWebhookURL },
+		flagName:              "--tbtc.walletHealthWebhookURL",
+		flagValue:             "https://alerts.example.com/keep",
+		expectedValueFromFlag: "https://alerts.example.com/keep",
+		defaultValue:          "",
+	},
+	"tbtc.walletActionAuditSegmentSize": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Tbtc.WalletActionAuditSegmentSize },
+		flagName:              "--tbtc.walletActionAuditSegmentSize",
+		flagValue:             "1048576",
+		expectedValueFromFlag: 1048576,
+		defaultValue:          1048576,
+	},
+	"tbtc.walletActionAuditMaxSegments": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Tbtc.WalletActionAuditMaxSegments },
+		flagName:              "--tbtc.walletActionAuditMaxSegments",
+		flagValue:             "25",
+		expectedValueFromFlag: 25,
+		defaultValue:          10,
+	},
+	"proposalGenerator.depositSweepConfirmationTarget": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.ProposalGenerator.DepositSweepConfirmationTarget },
+		flagName:              "--proposalGenerator.depositSweepConfirmationTarget",
+		flagValue:             "6",
+		expectedValueFromFlag: uint32(6),
+		defaultValue:          uint32(1),
+	},
+	"proposalGenerator.redemptionConfirmationTarget": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.ProposalGenerator.RedemptionConfirmationTarget },
+		flagName:              "--proposalGenerator.redemptionConfirmationTarget",
+		flagValue:             "2",
+		expectedValueFromFlag: uint32(2),
+		defaultValue:          uint32(1),
+	},
+	"proposalGenerator.movingFundsConfirmationTarget": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.ProposalGenerator.MovingFundsConfirmationTarget },
+		flagName:              "--proposalGenerator.movingFundsConfirmationTarget",
+		flagValue:             "3",
+		expectedValueFromFlag: uint32(3),
+		defaultValue:          uint32(1),
+	},
+	"proposalGenerator.movedFundsSweepConfirmationTarget": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.ProposalGenerator.MovedFundsSweepConfirmationTarget },
+		flagName:              "--proposalGenerator.movedFundsSweepConfirmationTarget",
+		flagValue:             "4",
+		expectedValueFromFlag: uint32(4),
+		defaultValue:          uint32(1),
+	},
+	"proposalGenerator.feeBumpConfirmationTarget": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.ProposalGenerator.FeeBumpConfirmationTarget },
+		flagName:              "--proposalGenerator.feeBumpConfirmationTarget",
+		flagValue:             "2",
+		expectedValueFromFlag: uint32(2),
+		defaultValue:          uint32(1),
+	},
+	"proposalGenerator.depositSweepMaxFeeShare": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.ProposalGenerator.DepositSweepMaxFeeShare },
+		flagName:              "--proposalGenerator.depositSweepMaxFeeShare",
+		flagValue:             "0.02",
+		expectedValueFromFlag: 0.02,
+		defaultValue:          0.01,
+	},
+	"proposalGenerator.depositSweepMaxWait": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.ProposalGenerator.DepositSweepMaxWait },
+		flagName:              "--proposalGenerator.depositSweepMaxWait",
+		flagValue:             "12h",
+		expectedValueFromFlag: 12 * time.Hour,
+		defaultValue:          24 * time.Hour,
+	},
+	"proposalGenerator.depositRefundSafetyMargin": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.ProposalGenerator.DepositRefundSafetyMargin },
+		flagName:              "--proposalGenerator.depositRefundSafetyMargin",
+		flagValue:             "36h",
+		expectedValueFromFlag: 36 * time.Hour,
+		defaultValue:          24 * time.Hour,
+	},
+	"proposalGenerator.redemptionFeeSharesEnabled": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.ProposalGenerator.RedemptionFeeSharesEnabled },
+		flagName:              "--proposalGenerator.redemptionFeeSharesEnabled",
+		flagValue:             "", // don't provide any value
+		expectedValueFromFlag: true,
+		defaultValue:          false,
+	},
 	"maintainer.bitcoinDifficulty": {
 		readValueFunc:         func(c *config.Config) interface{} { return c.Maintainer.BitcoinDifficulty.Enabled },
 		flagName:              "--bitcoinDifficulty",
@@ -274,6 +532,51 @@ var cmdFlagsTests = map[string]struct {
 		expectedValueFromFlag: 20 * time.Minute,
 		defaultValue:          10 * time.Minute,
 	},
+	"maintainer.spv.retryBackoffTime": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Maintainer.Spv.RetryBackoffTime },
+		flagName:              "--spv.retryBackoffTime",
+		flagValue:             "5m",
+		expectedValueFromFlag: 5 * time.Minute,
+		defaultValue:          10 * time.Minute,
+	},
+	"maintainer.spv.maxRetryBackoffTime": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Maintainer.Spv.MaxRetryBackoffTime },
+		flagName:              "--spv.maxRetryBackoffTime",
+		flagValue:             "12h",
+		expectedValueFromFlag: 12 * time.Hour,
+		defaultValue:          4 * time.Hour,
+	},
+	"maintainer.spv.maintainers": {
+		readValueFunc: func(c *config.Config) interface{} { return c.Maintainer.Spv.Maintainers },
+		flagName:      "--spv.maintainers",
+		flagValue:     `"0x9A5a6b80b1Ba6A6f9F3bE61fF1b3A4e3bb7D4b6c","0xE7e7B9EDd5AE4d3c5bF2E8A84A0e32Dc2d4FCfC5"`,
+		expectedValueFromFlag: []string{
+			"0x9A5a6b80b1Ba6A6f9F3bE61fF1b3A4e3bb7D4b6c",
+			"0xE7e7B9EDd5AE4d3c5bF2E8A84A0e32Dc2d4FCfC5",
+		},
+		defaultValue: []string{},
+	},
+	"maintainer.spv.claimPeriod": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Maintainer.Spv.ClaimPeriod },
+		flagName:              "--spv.claimPeriod",
+		flagValue:             "300",
+		expectedValueFromFlag: uint64(300),
+		defaultValue:          uint64(100),
+	},
+	"maintainer.submission.maxGasPrice": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Maintainer.Submission.MaxGasPrice.Int },
+		flagName:              "--submission.maxGasPrice",
+		flagValue:             "30 Gwei",
+		expectedValueFromFlag: big.NewInt(30000000000),
+		defaultValue:          big.NewInt(0),
+	},
+	"maintainer.submission.urgencyWindow": {
+		readValueFunc:         func(c *config.Config) interface{} { return c.Maintainer.Submission.UrgencyWindow },
+		flagName:              "--submission.urgencyWindow",
+		flagValue:             "12h",
+		expectedValueFromFlag: 12 * time.Hour,
+		defaultValue:          24 * time.Hour,
+	},
 	"developer.randomBeaconAddress": {
 		readValueFunc: func(c *config.Config) interface{} {
 			address, _ := c.Ethereum.ContractAddress(chainEthereum.RandomBeaconContractName)
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+	"github.com/spf13/cobra"
+
+	"github.com/keep-network/keep-core/config"
+	"github.com/keep-network/keep-core/pkg/storage"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+var (
+	bundleOutputFlagName = "output"
+	bundleInputFlagName  = "input"
+)
+
+// KeyStoreCommand contains the definition of the keystore command-line
+// subcommand and its own subcommands.
+var KeyStoreCommand = &cobra.Command{
+	Use:              "keystore",
+	Short:            "Backs up, verifies and restores tBTC key shares",
+	Long:             keyStoreDescription,
+	TraverseChildren: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := clientConfig.ReadConfig(
+			configFilePath,
+			cmd.Flags(),
+			config.General, config.Ethereum, config.Storage,
+		); err != nil {
+			logger.Fatalf("error reading config: %v", err)
+		}
+	},
+}
+
+var keyStoreDescription = `The keystore command manages backups of the tBTC
+   wallet key shares held in the keystore storage directory. Losing the
+   keystore data is a serious protocol violation so the key shares should
+   be backed up every time the client joins a new wallet. Backup bundles are
+   encrypted with the Ethereum key file password, same as the keystore.`
+
+var keyStoreExportCommand = cobra.Command{
+	Use:              "export",
+	Short:            "exports key shares to a backup bundle",
+	Long:             "Exports all tBTC key shares held by the keystore to an encrypted, versioned backup bundle file.",
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString(bundleOutputFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find output flag: [%v]", err)
+		}
+
+		keyStorePersistence, _, err := initializeTbtcKeyStore()
+		if err != nil {
+			return err
+		}
+
+		bundle, err := tbtc.ExportKeyShares(
+			keyStorePersistence,
+			clientConfig.Ethereum.KeyFilePassword,
+		)
+		if err != nil {
+			return fmt.Errorf("cannot export key shares: [%v]", err)
+		}
+
+		// Never overwrite an existing file as it may be a previous backup.
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return fmt.Errorf("cannot create bundle file: [%v]", err)
+		}
+		defer file.Close()
+
+		if _, err := file.Write(bundle); err != nil {
+			return fmt.Errorf("cannot write bundle file: [%v]", err)
+		}
+
+		logger.Infof("exported key shares to [%s]", output)
+
+		return nil
+	},
+}
+
+var keyStoreVerifyCommand = cobra.Command{
+	Use:              "verify",
+	Short:            "verifies key shares",
+	Long:             keyStoreVerifyDescription,
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, err := cmd.Flags().GetString(bundleInputFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find input flag: [%v]", err)
+		}
+
+		keyStorePersistence, keyStoreArchive, err := initializeTbtcKeyStore()
+		if err != nil {
+			return err
+		}
+
+		var verifications []*tbtc.KeyShareVerification
+		if len(input) > 0 {
+			bundle, err := os.ReadFile(input)
+			if err != nil {
+				return fmt.Errorf("cannot read bundle file: [%v]", err)
+			}
+
+			verifications, err = tbtc.VerifyKeyShareBundle(
+				bundle,
+				clientConfig.Ethereum.KeyFilePassword,
+				keyStoreArchive,
+			)
+			if err != nil {
+				return fmt.Errorf("cannot verify bundle: [%v]", err)
+			}
+		} else {
+			verifications, err = tbtc.VerifyKeyStore(
+				keyStorePersistence,
+				keyStoreArchive,
+			)
+			if err != nil {
+				return fmt.Errorf("cannot verify keystore: [%v]", err)
+			}
+		}
+
+		if err := printKeyShareVerifications(verifications); err != nil {
+			return err
+		}
+
+		for _, verification := range verifications {
+			if !verification.ShareValid {
+				return fmt.Errorf("invalid key shares found")
+			}
+		}
+
+		return nil
+	},
+}
+
+var keyStoreVerifyDescription = "Verifies tBTC key shares held by the " +
+	"backup bundle file passed with the --input flag or, if the flag is " +
+	"not set, by the keystore. Checks whether each key share matches its " +
+	"wallet public key and whether the wallet was archived in the keystore."
+
+var keyStoreImportCommand = cobra.Command{
+	Use:              "import",
+	Short:            "imports key shares from a backup bundle",
+	Long:             keyStoreImportDescription,
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, err := cmd.Flags().GetString(bundleInputFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find input flag: [%v]", err)
+		}
+
+		bundle, err := os.ReadFile(input)
+		if err != nil {
+			return fmt.Errorf("cannot read bundle file: [%v]", err)
+		}
+
+		keyStorePersistence, keyStoreArchive, err := initializeTbtcKeyStore()
+		if err != nil {
+			return err
+		}
+
+		verifications, err := tbtc.ImportKeyShares(
+			keyStorePersistence,
+			keyStoreArchive,
+			bundle,
+			clientConfig.Ethereum.KeyFilePassword,
+		)
+		if err != nil {
+			return fmt.Errorf("cannot import key shares: [%v]", err)
+		}
+
+		if err := printKeyShareVerifications(verifications); err != nil {
+			return err
+		}
+
+		logger.Infof("imported key shares from [%s]", input)
+
+		return nil
+	},
+}
+
+var keyStoreImportDescription = "Restores tBTC key shares from the backup " +
+	"bundle file. The keystore must be empty. Nothing is restored if any " +
+	"of the key shares does not match its wallet public key. Key shares of " +
+	"wallets archived in the keystore are skipped."
+
+func init() {
+	initFlags(
+		KeyStoreCommand,
+		&configFilePath,
+		clientConfig,
+		config.General, config.Ethereum, config.Storage,
+	)
+
+	keyStoreExportCommand.Flags().String(
+		bundleOutputFlagName,
+		"",
+		"path to the backup bundle file to create",
+	)
+
+	if err := keyStoreExportCommand.MarkFlagRequired(
+		bundleOutputFlagName,
+	); err != nil {
+		logger.Fatalf("failed to mark output flag required: [%v]", err)
+	}
+
+	KeyStoreCommand.AddCommand(&keyStoreExportCommand)
+
+	keyStoreVerifyCommand.Flags().String(
+		bundleInputFlagName,
+		"",
+		"(optional) path to the backup bundle file to verify",
+	)
+
+	KeyStoreCommand.AddCommand(&keyStoreVerifyCommand)
+
+	keyStoreImportCommand.Flags().String(
+		bundleInputFlagName,
+		"",
+		"path to the backup bundle file to import",
+	)
+
+	if err := keyStoreImportCommand.MarkFlagRequired(
+		bundleInputFlagName,
+	); err != nil {
+		logger.Fatalf("failed to mark input flag required: [%v]", err)
+	}
+
+	KeyStoreCommand.AddCommand(&keyStoreImportCommand)
+}
+
+// initializeTbtcKeyStore initializes the tBTC keystore persistence along with
+// the handle to the data archived in it.
+func initializeTbtcKeyStore() (
+	persistence.ProtectedHandle,
+	persistence.RWHandle,
+	error,
+) {
+	storage, err := storage.Initialize(
+		clientConfig.Storage,
+		clientConfig.Ethereum.KeyFilePassword,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot initialize storage: [%w]", err)
+	}
+
+	keyStorePersistence, err := storage.InitializeKeyStorePersistence("tbtc")
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"cannot initialize tbtc keystore persistence: [%w]",
+			err,
+		)
+	}
+
+	keyStoreArchive, err := storage.InitializeKeyStoreArchivePersistence("tbtc")
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"cannot initialize tbtc keystore archive persistence: [%w]",
+			err,
+		)
+	}
+
+	return keyStorePersistence, keyStoreArchive, nil
+}
+
+func printKeyShareVerifications(
+	verifications []*tbtc.KeyShareVerification,
+) error {
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "wallet\tmember index\tshare valid\twallet archived\t\n")
+
+	for _, verification := range verifications {
+		fmt.Fprintf(w, "0x%x\t%d\t%t\t%t\t\n",
+			verification.WalletPublicKeyHash,
+			verification.MemberIndex,
+			verification.ShareValid,
+			verification.WalletArchived,
+		)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush the writer: %v", err)
+	}
+
+	return nil
+}
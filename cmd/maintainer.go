@@ -7,8 +7,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/keep-network/keep-core/config"
-	"github.com/keep-network/keep-core/pkg/bitcoin/electrum"
 	"github.com/keep-network/keep-core/pkg/chain/ethereum"
+	"github.com/keep-network/keep-core/pkg/clientinfo"
 	"github.com/keep-network/keep-core/pkg/maintainer"
 )
 
@@ -44,9 +44,28 @@ func init() {
 func maintainers(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
-	btcChain, err := electrum.Connect(ctx, clientConfig.Bitcoin.Electrum)
+	btcChain, bitcoindChain, err := connectBitcoin(ctx, clientConfig.Bitcoin)
 	if err != nil {
-		return fmt.Errorf("could not connect to Electrum chain: [%v]", err)
+		return fmt.Errorf("could not connect to Bitcoin chain: [%v]", err)
+	}
+
+	// Bitcoin data, like the retry state of SPV proofs, is persisted only
+	// if the work storage is configured; it is not required to run the
+	// maintainer unless a feature keeping Bitcoin data locally is enabled.
+	bitcoinPersistence, err := initializeOptionalBitcoinPersistence(
+		clientConfig,
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"cannot initialize bitcoin data persistence: [%v]",
+			err,
+		)
+	}
+	if bitcoinPersistence == nil {
+		logger.Warnf(
+			"storage.dir is not set; SPV proof retry state " +
+				"will not survive restarts",
+		)
 	}
 
 	btcDiffChain, err := ethereum.ConnectBitcoinDifficulty(
@@ -72,12 +91,70 @@ func maintainers(cmd *cobra.Command, args []string) error {
 		)
 	}
 
+	btcChain, err = verifyBitcoinHeaders(
+		ctx,
+		btcChain,
+		bitcoinPersistence,
+		clientConfig,
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"cannot initialize Bitcoin header chain verification: [%v]",
+			err,
+		)
+	}
+	headersChain := btcChain
+
+	btcChain, err = indexWalletTransactions(
+		ctx,
+		btcChain,
+		tbtcChain,
+		bitcoinPersistence,
+		clientConfig,
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"cannot initialize Bitcoin wallet index: [%v]",
+			err,
+		)
+	}
+
+	btcChain, err = assembleSpvProofsLocally(
+		btcChain,
+		headersChain,
+		bitcoindChain,
+		bitcoinPersistence,
+		clientConfig,
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"cannot initialize local SPV proof assembly: [%v]",
+			err,
+		)
+	}
+
+	clientInfoRegistry, isConfigured := clientinfo.Initialize(
+		ctx,
+		clientConfig.ClientInfo.Port,
+	)
+	if isConfigured {
+		logger.Infof(
+			"enabled client info endpoint on port [%v]",
+			clientConfig.ClientInfo.Port,
+		)
+	} else {
+		logger.Infof("client info endpoint not configured")
+	}
+
 	maintainer.Initialize(
 		ctx,
 		clientConfig.Maintainer,
 		btcChain,
 		btcDiffChain,
 		tbtcChain,
+		tbtcChain,
+		clientInfoRegistry,
+		bitcoinPersistence,
 	)
 
 	<-ctx.Done()
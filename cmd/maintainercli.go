@@ -1,19 +1,27 @@
 package cmd
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/btcsuite/btcutil"
 	"github.com/spf13/cobra"
 
 	"github.com/keep-network/keep-core/config"
 	"github.com/keep-network/keep-core/internal/hexutils"
 	"github.com/keep-network/keep-core/pkg/bitcoin"
-	"github.com/keep-network/keep-core/pkg/bitcoin/electrum"
+	"github.com/keep-network/keep-core/pkg/chain"
 	"github.com/keep-network/keep-core/pkg/chain/ethereum"
 	"github.com/keep-network/keep-core/pkg/maintainer/spv"
+	"github.com/keep-network/keep-core/pkg/tbtc"
 	"github.com/keep-network/keep-core/pkg/tbtcpg"
 )
 
@@ -32,6 +40,22 @@ var (
 	// submitRedemptionProofCommand:
 	transactionHashFlagName = "transaction-hash"
 	confirmationsFlagName   = "confirmations"
+
+	// buildDepositRefundCommand:
+	fundingTxHashFlagName      = "funding-tx-hash"
+	fundingOutputIndexFlagName = "funding-output-index"
+	depositorFlagName          = "depositor"
+	blindingFactorFlagName     = "blinding-factor"
+	refundLocktimeFlagName     = "refund-locktime"
+	extraDataFlagName          = "extra-data"
+	refundKeyFileFlagName      = "refund-key-file"
+	recipientFlagName          = "recipient"
+	feeFlagName                = "fee"
+	broadcastFlagName          = "broadcast"
+
+	// simulateProposalsCommand:
+	startBlockFlagName = "start-block"
+	endBlockFlagName   = "end-block"
 )
 
 // MaintainerCliCommand contains the definition of tools associated with maintainers
@@ -86,15 +110,18 @@ var listDepositsCommand = cobra.Command{
 			)
 		}
 
-		btcChain, err := electrum.Connect(ctx, clientConfig.Bitcoin.Electrum)
+		btcChain, _, err := connectBitcoin(ctx, clientConfig.Bitcoin)
 		if err != nil {
-			return fmt.Errorf("could not connect to Electrum chain: [%v]", err)
+			return fmt.Errorf("could not connect to Bitcoin chain: [%v]", err)
 		}
 
 		var walletPublicKeyHash [20]byte
 		if len(wallet) > 0 {
 			var err error
-			walletPublicKeyHash, err = newWalletPublicKeyHash(wallet)
+			walletPublicKeyHash, err = newWalletPublicKeyHash(
+				wallet,
+				clientConfig.Bitcoin.Network,
+			)
 			if err != nil {
 				return fmt.Errorf(
 					"failed to extract wallet public key hash: %v",
@@ -122,7 +149,10 @@ var listDepositsCommand = cobra.Command{
 			return fmt.Errorf("no deposits found")
 		}
 
-		if err := printDepositsTable(deposits); err != nil {
+		if err := printDepositsTable(
+			deposits,
+			clientConfig.Bitcoin.Network,
+		); err != nil {
 			return fmt.Errorf("failed to print deposits table: %v", err)
 		}
 
@@ -130,14 +160,17 @@ var listDepositsCommand = cobra.Command{
 	},
 }
 
-func printDepositsTable(deposits []*tbtcpg.Deposit) error {
+func printDepositsTable(
+	deposits []*tbtcpg.Deposit,
+	network bitcoin.Network,
+) error {
 	w := tabwriter.NewWriter(os.Stdout, 2, 4, 1, ' ', tabwriter.AlignRight)
 	fmt.Fprintf(w, "index\twallet\tvalue (BTC)\tdeposit key\trevealed deposit data\tconfirmations\tswept\t\n")
 
 	for i, deposit := range deposits {
 		fmt.Fprintf(w, "%d\t%s\t%.5f\t%s\t%s\t%d\t%t\t\n",
 			i,
-			hexutils.Encode(deposit.WalletPublicKeyHash[:]),
+			walletAddress(deposit.WalletPublicKeyHash, network),
 			deposit.AmountBtc,
 			deposit.DepositKey,
 			fmt.Sprintf(
@@ -179,14 +212,25 @@ var estimateDepositsSweepFeeCommand = cobra.Command{
 			)
 		}
 
-		btcChain, err := electrum.Connect(ctx, clientConfig.Bitcoin.Electrum)
+		btcChain, _, err := connectBitcoin(ctx, clientConfig.Bitcoin)
+		if err != nil {
+			return fmt.Errorf("could not connect to Bitcoin chain: [%v]", err)
+		}
+
+		feeEstimator, err := newBitcoinFeeEstimator(
+			btcChain,
+			clientConfig.Bitcoin,
+		)
 		if err != nil {
-			return fmt.Errorf("could not connect to Electrum chain: [%v]", err)
+			return fmt.Errorf("cannot create Bitcoin fee estimator: [%v]", err)
 		}
 
 		fees, err := tbtcpg.EstimateDepositsSweepFee(
 			tbtcChain,
-			btcChain,
+			tbtcpg.NewFeeEstimator(
+				feeEstimator,
+				clientConfig.ProposalGenerator.ConfirmationTargets(),
+			),
 			depositsCount,
 		)
 		if err != nil {
@@ -296,9 +340,21 @@ var submitDepositSweepProofCommand = cobra.Command{
 			)
 		}
 
-		btcChain, err := electrum.Connect(ctx, clientConfig.Bitcoin.Electrum)
+		btcChain, bitcoindChain, err := connectBitcoin(
+			ctx,
+			clientConfig.Bitcoin,
+		)
 		if err != nil {
-			return fmt.Errorf("could not connect to Electrum chain: [%v]", err)
+			return fmt.Errorf("could not connect to Bitcoin chain: [%v]", err)
+		}
+
+		btcChain, err = withLocalSpvProofAssembly(
+			ctx,
+			btcChain,
+			bitcoindChain,
+		)
+		if err != nil {
+			return err
 		}
 
 		transactionHashFlag, err := cmd.Flags().GetString(transactionHashFlagName)
@@ -386,9 +442,21 @@ var submitRedemptionProofCommand = cobra.Command{
 			)
 		}
 
-		btcChain, err := electrum.Connect(ctx, clientConfig.Bitcoin.Electrum)
+		btcChain, bitcoindChain, err := connectBitcoin(
+			ctx,
+			clientConfig.Bitcoin,
+		)
+		if err != nil {
+			return fmt.Errorf("could not connect to Bitcoin chain: [%v]", err)
+		}
+
+		btcChain, err = withLocalSpvProofAssembly(
+			ctx,
+			btcChain,
+			bitcoindChain,
+		)
 		if err != nil {
-			return fmt.Errorf("could not connect to Electrum chain: [%v]", err)
+			return err
 		}
 
 		transactionHashFlag, err := cmd.Flags().GetString(transactionHashFlagName)
@@ -457,6 +525,565 @@ var submitRedemptionProofCommand = cobra.Command{
 	},
 }
 
+// withLocalSpvProofAssembly wraps the given Bitcoin chain so SPV proofs are
+// assembled locally, if enabled in the configuration. The local assembly
+// reads block headers from the local header chain so the header chain is
+// synced first, before the command proceeds. The given bitcoind chain must be
+// the one returned by connectBitcoin.
+func withLocalSpvProofAssembly(
+	ctx context.Context,
+	btcChain bitcoin.Chain,
+	bitcoindChain bitcoin.Chain,
+) (bitcoin.Chain, error) {
+	if !clientConfig.Bitcoin.SpvProof.LocalAssembly {
+		return btcChain, nil
+	}
+
+	bitcoinPersistence, err := initializeOptionalBitcoinPersistence(
+		clientConfig,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	headersChain, err := verifyBitcoinHeaders(
+		ctx,
+		btcChain,
+		bitcoinPersistence,
+		clientConfig,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot initialize Bitcoin header chain verification: [%v]",
+			err,
+		)
+	}
+
+	// The command needs the headers right away so it does not wait for the
+	// background sync.
+	if headerChain, ok := headersChain.(*bitcoin.HeaderChain); ok {
+		if err := headerChain.Sync(); err != nil {
+			return nil, fmt.Errorf(
+				"cannot sync Bitcoin header chain: [%v]",
+				err,
+			)
+		}
+	}
+
+	btcChain, err = assembleSpvProofsLocally(
+		headersChain,
+		headersChain,
+		bitcoindChain,
+		bitcoinPersistence,
+		clientConfig,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot initialize local SPV proof assembly: [%v]",
+			err,
+		)
+	}
+
+	return btcChain, nil
+}
+
+var buildDepositRefundCommand = cobra.Command{
+	Use:              "build-deposit-refund",
+	Short:            "builds deposit refund transaction",
+	Long:             buildDepositRefundCommandDescription,
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		btcChain, _, err := connectBitcoin(ctx, clientConfig.Bitcoin)
+		if err != nil {
+			return fmt.Errorf("could not connect to Bitcoin chain: [%v]", err)
+		}
+
+		refundKeyFile, err := cmd.Flags().GetString(refundKeyFileFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find refund key file flag: [%v]", err)
+		}
+
+		refundPrivateKey, err := readRefundPrivateKey(refundKeyFile)
+		if err != nil {
+			return fmt.Errorf("cannot read refund private key: [%v]", err)
+		}
+
+		deposit, err := newDepositFromFlags(
+			cmd,
+			btcChain,
+			bitcoin.PublicKeyHash(&refundPrivateKey.PublicKey),
+		)
+		if err != nil {
+			return fmt.Errorf("cannot parse deposit data: [%v]", err)
+		}
+
+		recipient, err := cmd.Flags().GetString(recipientFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find recipient flag: [%v]", err)
+		}
+
+		recipientOutputScript, err := bitcoin.DecodeAddress(
+			recipient,
+			clientConfig.Bitcoin.Network,
+		)
+		if err != nil {
+			return fmt.Errorf("cannot decode recipient address: [%v]", err)
+		}
+
+		fee, err := cmd.Flags().GetInt64(feeFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find fee flag: [%v]", err)
+		}
+
+		// If the fee is not set, estimate it based on the size of the refund
+		// transaction paying a minimal fee.
+		if fee == 0 {
+			transaction, err := tbtc.BuildDepositRefundTransaction(
+				btcChain,
+				deposit,
+				refundPrivateKey,
+				recipientOutputScript,
+				1,
+			)
+			if err != nil {
+				return fmt.Errorf(
+					"cannot build deposit refund transaction: [%v]",
+					err,
+				)
+			}
+
+			feeEstimator, err := newBitcoinFeeEstimator(
+				btcChain,
+				clientConfig.Bitcoin,
+			)
+			if err != nil {
+				return fmt.Errorf("cannot create Bitcoin fee estimator: [%v]", err)
+			}
+
+			fee, err = bitcoin.NewTransactionFeeEstimator(feeEstimator).EstimateFee(
+				transaction.VirtualSize(),
+				tbtcpg.DefaultConfirmationTarget,
+			)
+			if err != nil {
+				return fmt.Errorf("cannot estimate fee: [%v]", err)
+			}
+		}
+
+		transaction, err := tbtc.BuildDepositRefundTransaction(
+			btcChain,
+			deposit,
+			refundPrivateKey,
+			recipientOutputScript,
+			fee,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"cannot build deposit refund transaction: [%v]",
+				err,
+			)
+		}
+
+		refundLocktime := time.Unix(int64(tbtc.DepositRefundLocktime(deposit)), 0)
+
+		logger.Infof(
+			"built deposit refund transaction [%s] paying fee of [%v] satoshi; "+
+				"the transaction can be broadcast once the refund locktime "+
+				"[%s] passes",
+			transaction.Hash().Hex(bitcoin.ReversedByteOrder),
+			fee,
+			refundLocktime.UTC(),
+		)
+
+		broadcast, err := cmd.Flags().GetBool(broadcastFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find broadcast flag: [%v]", err)
+		}
+
+		if !broadcast {
+			fmt.Println(hex.EncodeToString(transaction.Serialize()))
+			return nil
+		}
+
+		if err := tbtc.CheckDepositRefundLocktime(btcChain, deposit); err != nil {
+			return fmt.Errorf(
+				"cannot broadcast deposit refund transaction: [%v]",
+				err,
+			)
+		}
+
+		if err := btcChain.BroadcastTransaction(transaction); err != nil {
+			return fmt.Errorf(
+				"cannot broadcast deposit refund transaction: [%v]",
+				err,
+			)
+		}
+
+		logger.Infof(
+			"successfully broadcast deposit refund transaction [%s]",
+			transaction.Hash().Hex(bitcoin.ReversedByteOrder),
+		)
+
+		return nil
+	},
+}
+
+var buildDepositRefundCommandDescription = "Builds a Bitcoin transaction " +
+	"returning the funds of a deposit to the given recipient address, using " +
+	"the refund path of the deposit script. The deposit is identified by " +
+	"its funding transaction output and the deposit script is reconstructed " +
+	"from the deposit data passed with flags, in the same form as revealed " +
+	"to the Bridge. The refund public key hash is derived from the refund " +
+	"private key, read in the WIF format from the given file. The " +
+	"transaction pays the fee passed with the --fee flag or, if not set, " +
+	"the fee estimated for the next block confirmation. The signed " +
+	"transaction is printed in the hex format, ready to be broadcast once " +
+	"the deposit refund locktime passes. The command fails if the funding " +
+	"output is not locked by the reconstructed deposit script, which means " +
+	"the deposit data is wrong. Use the --broadcast flag to broadcast the " +
+	"transaction right away; the broadcast is refused until the median time " +
+	"past of the Bitcoin chain exceeds the refund locktime"
+
+// newDepositFromFlags constructs the deposit to refund from the command
+// flags. The deposit funding output value is taken from the Bitcoin chain.
+func newDepositFromFlags(
+	cmd *cobra.Command,
+	btcChain bitcoin.Chain,
+	refundPublicKeyHash [20]byte,
+) (*tbtc.Deposit, error) {
+	fundingTxHashFlag, err := cmd.Flags().GetString(fundingTxHashFlagName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find funding tx hash flag: [%v]", err)
+	}
+
+	fundingTxHash, err := bitcoin.NewHashFromString(
+		fundingTxHashFlag,
+		bitcoin.ReversedByteOrder,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse funding tx hash: [%v]", err)
+	}
+
+	fundingOutputIndex, err := cmd.Flags().GetUint32(fundingOutputIndexFlagName)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to find funding output index flag: [%v]",
+			err,
+		)
+	}
+
+	fundingTx, err := btcChain.GetTransaction(fundingTxHash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get funding transaction: [%v]", err)
+	}
+
+	if int(fundingOutputIndex) >= len(fundingTx.Outputs) {
+		return nil, fmt.Errorf(
+			"funding transaction has no output with index [%v]",
+			fundingOutputIndex,
+		)
+	}
+
+	depositor, err := cmd.Flags().GetString(depositorFlagName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find depositor flag: [%v]", err)
+	}
+
+	wallet, err := cmd.Flags().GetString(walletFlagName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find wallet flag: [%v]", err)
+	}
+
+	walletPublicKeyHash, err := newWalletPublicKeyHash(
+		wallet,
+		clientConfig.Bitcoin.Network,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to extract wallet public key hash: [%v]",
+			err,
+		)
+	}
+
+	deposit := &tbtc.Deposit{
+		Utxo: &bitcoin.UnspentTransactionOutput{
+			Outpoint: &bitcoin.TransactionOutpoint{
+				TransactionHash: fundingTxHash,
+				OutputIndex:     fundingOutputIndex,
+			},
+			Value: fundingTx.Outputs[fundingOutputIndex].Value,
+		},
+		Depositor:           chain.Address(depositor),
+		WalletPublicKeyHash: walletPublicKeyHash,
+		RefundPublicKeyHash: refundPublicKeyHash,
+	}
+
+	hexFlags := []struct {
+		name     string
+		target   []byte
+		optional bool
+	}{
+		{name: blindingFactorFlagName, target: deposit.BlindingFactor[:]},
+		{name: refundLocktimeFlagName, target: deposit.RefundLocktime[:]},
+		{name: extraDataFlagName, target: make([]byte, 32), optional: true},
+	}
+
+	for _, hexFlag := range hexFlags {
+		value, err := cmd.Flags().GetString(hexFlag.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s flag: [%v]", hexFlag.name, err)
+		}
+
+		if hexFlag.optional && len(value) == 0 {
+			continue
+		}
+
+		decoded, err := hexutils.Decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: [%v]", hexFlag.name, err)
+		}
+
+		if len(decoded) != len(hexFlag.target) {
+			return nil, fmt.Errorf(
+				"invalid %s bytes length: [%d], expected: [%d]",
+				hexFlag.name,
+				len(decoded),
+				len(hexFlag.target),
+			)
+		}
+
+		copy(hexFlag.target, decoded)
+
+		if hexFlag.name == extraDataFlagName {
+			var extraData [32]byte
+			copy(extraData[:], decoded)
+			deposit.ExtraData = &extraData
+		}
+	}
+
+	return deposit, nil
+}
+
+// readRefundPrivateKey reads the WIF-encoded refund private key from the
+// given file.
+func readRefundPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file: [%v]", err)
+	}
+
+	wif, err := btcutil.DecodeWIF(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode WIF: [%v]", err)
+	}
+
+	return wif.PrivKey.ToECDSA(), nil
+}
+
+var simulateProposalsCommand = cobra.Command{
+	Use:              "simulate-proposals",
+	Short:            "simulates wallet coordination proposals",
+	Long:             simulateProposalsCommandDescription,
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		wallet, err := cmd.Flags().GetString(walletFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find wallet flag: [%v]", err)
+		}
+
+		startBlock, err := cmd.Flags().GetUint64(startBlockFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find start block flag: [%v]", err)
+		}
+
+		endBlock, err := cmd.Flags().GetUint64(endBlockFlagName)
+		if err != nil {
+			return fmt.Errorf("failed to find end block flag: [%v]", err)
+		}
+
+		walletPublicKeyHash, err := newWalletPublicKeyHash(
+			wallet,
+			clientConfig.Bitcoin.Network,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to extract wallet public key hash: [%v]",
+				err,
+			)
+		}
+
+		_, tbtcChain, _, _, _, err := ethereum.Connect(ctx, clientConfig.Ethereum)
+		if err != nil {
+			return fmt.Errorf(
+				"could not connect to Ethereum chain: [%v]",
+				err,
+			)
+		}
+
+		if endBlock == 0 {
+			blockCounter, err := tbtcChain.BlockCounter()
+			if err != nil {
+				return fmt.Errorf("cannot get block counter: [%v]", err)
+			}
+
+			endBlock, err = blockCounter.CurrentBlock()
+			if err != nil {
+				return fmt.Errorf("cannot get current block: [%v]", err)
+			}
+		}
+
+		btcChain, _, err := connectBitcoin(ctx, clientConfig.Bitcoin)
+		if err != nil {
+			return fmt.Errorf("could not connect to Bitcoin chain: [%v]", err)
+		}
+
+		feeEstimator, err := newBitcoinFeeEstimator(
+			btcChain,
+			clientConfig.Bitcoin,
+		)
+		if err != nil {
+			return fmt.Errorf("cannot create Bitcoin fee estimator: [%v]", err)
+		}
+
+		simulations, err := tbtcpg.SimulateProposals(
+			&simulationTbtcChain{tbtcChain},
+			btcChain,
+			tbtcpg.NewFeeEstimator(
+				feeEstimator,
+				clientConfig.ProposalGenerator.ConfirmationTargets(),
+			),
+			&clientConfig.ProposalGenerator,
+			walletPublicKeyHash,
+			startBlock,
+			endBlock,
+		)
+		if err != nil {
+			return fmt.Errorf("cannot simulate proposals: [%v]", err)
+		}
+
+		err = printProposalSimulationsTable(simulations)
+		if err != nil {
+			return fmt.Errorf("cannot print simulations table: [%v]", err)
+		}
+
+		return nil
+	},
+}
+
+// simulationTbtcChain adapts the Ethereum TBTC chain handle to the
+// tbtcpg.SimulationChain interface.
+type simulationTbtcChain struct {
+	*ethereum.TbtcChain
+}
+
+func (stc *simulationTbtcChain) ChainAtBlock(
+	blockNumber uint64,
+) (tbtcpg.Chain, error) {
+	return stc.TbtcChain.AtBlock(blockNumber)
+}
+
+// printProposalSimulationsTable prints results of simulated proposals to the
+// standard output. For example:
+//
+// ------------------------------------------------------------------------------
+// coordination block                checklist   proposal fee (satoshis) validation error
+// 900                              [Heartbeat]          -              -         -     -
+// 1800                      [Redemption DepositSweep] Redemption           2500      valid     -
+// ------------------------------------------------------------------------------
+func printProposalSimulationsTable(
+	simulations []*tbtcpg.ProposalSimulation,
+) error {
+	writer := tabwriter.NewWriter(
+		os.Stdout,
+		2,
+		4,
+		1,
+		' ',
+		tabwriter.AlignRight,
+	)
+
+	_, err := fmt.Fprintf(
+		writer,
+		"coordination block\tchecklist\tproposal\tfee (satoshis)\tvalidation\terror\t\n",
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, simulation := range simulations {
+		checklist := make([]string, len(simulation.ActionsChecklist))
+		for i, action := range simulation.ActionsChecklist {
+			checklist[i] = action.String()
+		}
+
+		proposal := "-"
+		if simulation.Proposal != nil {
+			proposal = simulation.Proposal.ActionType().String()
+		}
+
+		fee := "-"
+		if simulation.EstimatedFee != nil {
+			fee = simulation.EstimatedFee.String()
+		}
+
+		validation := "-"
+		if simulation.Proposal != nil {
+			switch {
+			case simulation.ValidationError == nil:
+				validation = "valid"
+			case errors.Is(
+				simulation.ValidationError,
+				tbtcpg.ErrValidationNotSimulated,
+			):
+				validation = "skipped"
+			default:
+				validation = simulation.ValidationError.Error()
+			}
+		}
+
+		generationError := "-"
+		if simulation.GenerationError != nil {
+			generationError = simulation.GenerationError.Error()
+		}
+
+		_, err := fmt.Fprintf(
+			writer,
+			"%v\t[%v]\t%v\t%v\t%v\t%v\t\n",
+			simulation.CoordinationBlock,
+			strings.Join(checklist, " "),
+			proposal,
+			fee,
+			validation,
+			generationError,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush the writer: %v", err)
+	}
+
+	return nil
+}
+
+var simulateProposalsCommandDescription = "Simulates the proposal " +
+	"generation for the given wallet in all coordination windows starting " +
+	"between the --start-block and --end-block blocks. For each window, " +
+	"prints the checklist of actions, the generated proposal, the estimated " +
+	"fee of the proposed Bitcoin transaction and the result of the proposal " +
+	"validation. If --end-block is not set, the current block is used. The " +
+	"simulation is read-only: nothing is signed, broadcasted, or submitted " +
+	"to the chain. Each window is simulated against the Ethereum chain " +
+	"state as of its coordination block, which requires the connected " +
+	"Ethereum client to be an archive node for older windows. The Bitcoin " +
+	"chain and fee estimates are read as of now so Bitcoin confirmations " +
+	"and estimated fees of older windows are an approximation"
+
 func init() {
 	initFlags(
 		MaintainerCliCommand,
@@ -469,7 +1096,7 @@ func init() {
 	listDepositsCommand.Flags().String(
 		walletFlagName,
 		"",
-		"wallet public key hash",
+		"wallet public key hash (hex) or wallet Bitcoin address",
 	)
 
 	listDepositsCommand.Flags().Bool(
@@ -548,14 +1175,147 @@ func init() {
 	)
 
 	MaintainerCliCommand.AddCommand(&submitRedemptionProofCommand)
+
+	// Build Deposit Refund Subcommand.
+
+	buildDepositRefundCommand.Flags().String(
+		fundingTxHashFlagName,
+		"",
+		"hash of the deposit funding transaction (the format should be the "+
+			"same as in Bitcoin explorers)",
+	)
+
+	buildDepositRefundCommand.Flags().Uint32(
+		fundingOutputIndexFlagName,
+		0,
+		"index of the deposit funding transaction output",
+	)
+
+	buildDepositRefundCommand.Flags().String(
+		depositorFlagName,
+		"",
+		"depositor address on the host chain",
+	)
+
+	buildDepositRefundCommand.Flags().String(
+		blindingFactorFlagName,
+		"",
+		"8-byte blinding factor of the deposit (hex)",
+	)
+
+	buildDepositRefundCommand.Flags().String(
+		walletFlagName,
+		"",
+		"wallet public key hash (hex) or wallet Bitcoin address",
+	)
+
+	buildDepositRefundCommand.Flags().String(
+		refundLocktimeFlagName,
+		"",
+		"4-byte refund locktime of the deposit, in the form embedded in the "+
+			"deposit script (hex)",
+	)
+
+	buildDepositRefundCommand.Flags().String(
+		extraDataFlagName,
+		"",
+		"(optional) 32-byte extra data of the deposit (hex)",
+	)
+
+	buildDepositRefundCommand.Flags().String(
+		refundKeyFileFlagName,
+		"",
+		"path to the file holding the WIF-encoded refund private key",
+	)
+
+	buildDepositRefundCommand.Flags().String(
+		recipientFlagName,
+		"",
+		"Bitcoin address receiving the refunded funds",
+	)
+
+	buildDepositRefundCommand.Flags().Int64(
+		feeFlagName,
+		0,
+		"(optional) transaction fee in satoshi; estimated if not set",
+	)
+
+	buildDepositRefundCommand.Flags().Bool(
+		broadcastFlagName,
+		false,
+		"broadcast the transaction instead of printing it",
+	)
+
+	for _, flagName := range []string{
+		fundingTxHashFlagName,
+		depositorFlagName,
+		blindingFactorFlagName,
+		walletFlagName,
+		refundLocktimeFlagName,
+		refundKeyFileFlagName,
+		recipientFlagName,
+	} {
+		if err := buildDepositRefundCommand.MarkFlagRequired(
+			flagName,
+		); err != nil {
+			logger.Fatalf("failed to mark flag required: [%v]", err)
+		}
+	}
+
+	MaintainerCliCommand.AddCommand(&buildDepositRefundCommand)
+
+	// Simulate Proposals Subcommand.
+
+	simulateProposalsCommand.Flags().String(
+		walletFlagName,
+		"",
+		"wallet public key hash (hex) or wallet Bitcoin address",
+	)
+
+	if err := simulateProposalsCommand.MarkFlagRequired(
+		walletFlagName,
+	); err != nil {
+		logger.Fatalf("failed to mark flag required: [%v]", err)
+	}
+
+	simulateProposalsCommand.Flags().Uint64(
+		startBlockFlagName,
+		0,
+		"first block of the simulated range",
+	)
+
+	simulateProposalsCommand.Flags().Uint64(
+		endBlockFlagName,
+		0,
+		"(optional) last block of the simulated range; the current block "+
+			"if not set",
+	)
+
+	MaintainerCliCommand.AddCommand(&simulateProposalsCommand)
 }
 
-func newWalletPublicKeyHash(str string) ([20]byte, error) {
+// newWalletPublicKeyHash parses the wallet public key hash from the given
+// string. The string can be either a hex-encoded 20-byte public key hash or
+// a P2PKH/P2WPKH Bitcoin address of the given network.
+func newWalletPublicKeyHash(
+	str string,
+	network bitcoin.Network,
+) ([20]byte, error) {
 	var result [20]byte
 
 	walletHex, err := hexutils.Decode(str)
 	if err != nil {
-		return result, err
+		// Not a hex string; try to interpret it as a Bitcoin address.
+		script, addressErr := bitcoin.DecodeAddress(str, network)
+		if addressErr != nil {
+			return result, fmt.Errorf(
+				"not a hex public key hash [%v] nor a Bitcoin address [%v]",
+				err,
+				addressErr,
+			)
+		}
+
+		return bitcoin.ExtractPublicKeyHash(script)
 	}
 
 	if len(walletHex) != 20 {
@@ -566,3 +1326,20 @@ func newWalletPublicKeyHash(str string) ([20]byte, error) {
 
 	return result, nil
 }
+
+// walletAddress returns the P2WPKH address of the wallet with the given
+// public key hash. If the address cannot be encoded, the hex-encoded public
+// key hash is returned instead.
+func walletAddress(walletPublicKeyHash [20]byte, network bitcoin.Network) string {
+	script, err := bitcoin.PayToWitnessPublicKeyHash(walletPublicKeyHash)
+	if err != nil {
+		return hexutils.Encode(walletPublicKeyHash[:])
+	}
+
+	address, err := bitcoin.EncodeAddress(script, network)
+	if err != nil {
+		return hexutils.Encode(walletPublicKeyHash[:])
+	}
+
+	return address
+}
@@ -6,15 +6,19 @@ import (
 	"testing"
 
 	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
 )
 
 var walletPublicKeyHashTests = []struct {
 	input          string
+	network        bitcoin.Network
 	expectedResult [20]byte
 	wantErr        error // if set, decoding must fail
 }{
 	// invalid
-	{input: ``, wantErr: fmt.Errorf("empty hex string")},
+	{input: ``, network: bitcoin.Mainnet, wantErr: fmt.Errorf("not a hex public key hash [empty hex string] nor a Bitcoin address [cannot decode address: [decoded address is of unknown format]]")},
+	{input: `bc1qfzuguyr5cv784y600qfzpcdy2gl3w6xqn0x74q`, network: bitcoin.Testnet, wantErr: fmt.Errorf("not a hex public key hash [failed to decode string [bc1qfzuguyr5cv784y600qfzpcdy2gl3w6xqn0x74q]] nor a Bitcoin address [address is not for [testnet] network]")},
+	{input: `3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy`, network: bitcoin.Mainnet, wantErr: fmt.Errorf("not a P2WPKH or P2PKH script")},
 	{input: `01`, wantErr: fmt.Errorf("invalid bytes length: [1], expected: [20]")},
 	{input: `0x01`, wantErr: fmt.Errorf("invalid bytes length: [1], expected: [20]")},
 	{input: `5bee2805df9fcea4691c442fe4c1a33f7288e2`, wantErr: fmt.Errorf("invalid bytes length: [19], expected: [20]")},
@@ -26,12 +30,14 @@ var walletPublicKeyHashTests = []struct {
 	{input: `0x48b88e1074c33c7a934f781220e1a4523f1768c0`, expectedResult: [20]byte{72, 184, 142, 16, 116, 195, 60, 122, 147, 79, 120, 18, 32, 225, 164, 82, 63, 23, 104, 192}},
 	{input: `0x00008e1074c33c7a934f781220e1a4523f1768c0`, expectedResult: [20]byte{00, 00, 142, 16, 116, 195, 60, 122, 147, 79, 120, 18, 32, 225, 164, 82, 63, 23, 104, 192}},
 	{input: `0x48b88e1074c33c7a934f781220e1a4523f000000`, expectedResult: [20]byte{72, 184, 142, 16, 116, 195, 60, 122, 147, 79, 120, 18, 32, 225, 164, 82, 63, 00, 00, 00}},
+	{input: `bc1qfzuguyr5cv784y600qfzpcdy2gl3w6xqn0x74q`, network: bitcoin.Mainnet, expectedResult: [20]byte{72, 184, 142, 16, 116, 195, 60, 122, 147, 79, 120, 18, 32, 225, 164, 82, 63, 23, 104, 192}},
+	{input: `mn9U2y5m9wcjbXz2s2pDidHTkdFp6pig3G`, network: bitcoin.Testnet, expectedResult: [20]byte{72, 184, 142, 16, 116, 195, 60, 122, 147, 79, 120, 18, 32, 225, 164, 82, 63, 23, 104, 192}},
 }
 
 func TestNewWalletPublicKeyHash(t *testing.T) {
 	for _, test := range walletPublicKeyHashTests {
 		t.Run(test.input, func(t *testing.T) {
-			actualResult, err := newWalletPublicKeyHash(test.input)
+			actualResult, err := newWalletPublicKeyHash(test.input, test.network)
 			if !reflect.DeepEqual(err, test.wantErr) {
 				t.Fatalf("unexpected error\nexpected: %v\nactual:   %v", test.wantErr, err)
 			}
@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/keep-network/keep-core/config"
+	"github.com/keep-network/keep-core/internal/hexutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/chain/ethereum"
+	"github.com/keep-network/keep-core/pkg/net/offline"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+var (
+	proposalActionFlagName  = "proposal-action"
+	proposalFlagName        = "proposal"
+	sessionIDFlagName       = "session-id"
+	excludedMembersFlagName = "excluded-members"
+	exportDirFlagName       = "export-dir"
+	importDirFlagName       = "import-dir"
+	importIntervalFlagName  = "import-interval"
+	timeoutFlagName         = "timeout"
+)
+
+// defaultOfflineSigningTimeout is the default time after which the offline
+// signing is given up. Messages are exchanged manually so the timeout is
+// much longer than for the regular signing.
+const defaultOfflineSigningTimeout = 24 * time.Hour
+
+// OfflineSigningCommand contains the definition of the offline-signing
+// command-line subcommand.
+var OfflineSigningCommand = &cobra.Command{
+	Use:              "offline-signing",
+	Short:            "Signs a proposed transaction without the network",
+	Long:             offlineSigningDescription,
+	TraverseChildren: true,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if err := clientConfig.ReadConfig(
+			configFilePath,
+			cmd.Flags(),
+			config.General,
+			config.Ethereum,
+			config.BitcoinElectrum,
+			config.Storage,
+		); err != nil {
+			logger.Fatalf("error reading config: %v", err)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return signOffline(cmd)
+	},
+}
+
+var offlineSigningDescription = `The offline-signing command is meant to be
+   used only during incident recovery when the wallet signing group cannot
+   complete signing through the peer-to-peer network. The command takes a
+   wallet action proposal, encoded the same way as in the coordination
+   messages, validates it against the Ethereum and Bitcoin chains, and
+   assembles the Bitcoin transaction the proposal describes. Deposit sweep,
+   redemption, moving funds, and moved funds sweep proposals are supported.
+   It then runs the signing protocol for all transaction inputs with all
+   wallet members controlled by the operator, exporting the protocol
+   messages to files in the export directory. Members of the signing group
+   must exchange the exported files by some other means and place files
+   received from other members in the import directory. The signing
+   completes once all participating members receive all messages and the
+   signed transaction is printed out. All participating members must use the
+   same proposal, session ID, and set of excluded members. The session ID
+   must be unique for each signing attempt.`
+
+func init() {
+	initFlags(
+		OfflineSigningCommand,
+		&configFilePath,
+		clientConfig,
+		config.General,
+		config.Ethereum,
+		config.BitcoinElectrum,
+		config.Storage,
+	)
+
+	OfflineSigningCommand.Flags().String(
+		walletFlagName,
+		"",
+		"wallet public key hash (hex)",
+	)
+
+	OfflineSigningCommand.Flags().Uint8(
+		proposalActionFlagName,
+		0,
+		"wallet action type of the proposal, e.g. 3 for redemption",
+	)
+
+	OfflineSigningCommand.Flags().String(
+		proposalFlagName,
+		"",
+		"marshaled proposal to sign the transaction for (hex)",
+	)
+
+	OfflineSigningCommand.Flags().String(
+		sessionIDFlagName,
+		"",
+		"signing session identifier, common for all participating members",
+	)
+
+	OfflineSigningCommand.Flags().UintSlice(
+		excludedMembersFlagName,
+		[]uint{},
+		"indexes of signing group members not participating in signing",
+	)
+
+	OfflineSigningCommand.Flags().String(
+		exportDirFlagName,
+		"",
+		"directory the protocol messages of the operator's members are "+
+			"exported to",
+	)
+
+	OfflineSigningCommand.Flags().String(
+		importDirFlagName,
+		"",
+		"directory the protocol messages of other members are imported from",
+	)
+
+	OfflineSigningCommand.Flags().Duration(
+		importIntervalFlagName,
+		offline.DefaultImportInterval,
+		"interval at which the import directory is scanned for new messages",
+	)
+
+	OfflineSigningCommand.Flags().Duration(
+		timeoutFlagName,
+		defaultOfflineSigningTimeout,
+		"time after which the signing is given up",
+	)
+
+	for _, flagName := range []string{
+		walletFlagName,
+		proposalActionFlagName,
+		proposalFlagName,
+		sessionIDFlagName,
+		exportDirFlagName,
+		importDirFlagName,
+	} {
+		if err := OfflineSigningCommand.MarkFlagRequired(
+			flagName,
+		); err != nil {
+			logger.Fatalf("failed to mark flag required: [%v]", err)
+		}
+	}
+}
+
+func signOffline(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+
+	wallet, err := flags.GetString(walletFlagName)
+	if err != nil {
+		return fmt.Errorf("failed to find wallet flag: [%v]", err)
+	}
+
+	walletPublicKeyHashBytes, err := hexutils.Decode(wallet)
+	if err != nil {
+		return fmt.Errorf("failed to parse wallet public key hash: [%v]", err)
+	}
+
+	if len(walletPublicKeyHashBytes) != 20 {
+		return fmt.Errorf(
+			"invalid wallet public key hash length: [%v]",
+			len(walletPublicKeyHashBytes),
+		)
+	}
+
+	var walletPublicKeyHash [20]byte
+	copy(walletPublicKeyHash[:], walletPublicKeyHashBytes)
+
+	proposalAction, err := flags.GetUint8(proposalActionFlagName)
+	if err != nil {
+		return fmt.Errorf("failed to find proposal action flag: [%v]", err)
+	}
+
+	proposalHex, err := flags.GetString(proposalFlagName)
+	if err != nil {
+		return fmt.Errorf("failed to find proposal flag: [%v]", err)
+	}
+
+	proposalBytes, err := hexutils.Decode(proposalHex)
+	if err != nil {
+		return fmt.Errorf("failed to parse proposal: [%v]", err)
+	}
+
+	proposal, err := tbtc.UnmarshalCoordinationProposal(
+		uint32(proposalAction),
+		proposalBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal proposal: [%v]", err)
+	}
+
+	sessionID, err := flags.GetString(sessionIDFlagName)
+	if err != nil {
+		return fmt.Errorf("failed to find session ID flag: [%v]", err)
+	}
+
+	excludedMembers, err := flags.GetUintSlice(excludedMembersFlagName)
+	if err != nil {
+		return fmt.Errorf("failed to find excluded members flag: [%v]", err)
+	}
+
+	excludedMembersIndexes := make([]group.MemberIndex, len(excludedMembers))
+	for i, excludedMember := range excludedMembers {
+		excludedMembersIndexes[i] = group.MemberIndex(excludedMember)
+	}
+
+	exportDir, err := flags.GetString(exportDirFlagName)
+	if err != nil {
+		return fmt.Errorf("failed to find export directory flag: [%v]", err)
+	}
+
+	importDir, err := flags.GetString(importDirFlagName)
+	if err != nil {
+		return fmt.Errorf("failed to find import directory flag: [%v]", err)
+	}
+
+	importInterval, err := flags.GetDuration(importIntervalFlagName)
+	if err != nil {
+		return fmt.Errorf("failed to find import interval flag: [%v]", err)
+	}
+
+	timeout, err := flags.GetDuration(timeoutFlagName)
+	if err != nil {
+		return fmt.Errorf("failed to find timeout flag: [%v]", err)
+	}
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), timeout)
+	defer cancelCtx()
+
+	_, tbtcChain, _, signing, _, err := ethereum.Connect(
+		ctx,
+		clientConfig.Ethereum,
+	)
+	if err != nil {
+		return fmt.Errorf("error connecting to Ethereum node: [%v]", err)
+	}
+
+	btcChain, _, err := connectBitcoin(ctx, clientConfig.Bitcoin)
+	if err != nil {
+		return fmt.Errorf("could not connect to Bitcoin chain: [%v]", err)
+	}
+
+	_, tbtcKeyStorePersistence, _, _, _, err := initializePersistence()
+	if err != nil {
+		return fmt.Errorf("cannot initialize persistence: [%w]", err)
+	}
+
+	broadcastChannel, err := offline.NewBroadcastChannel(
+		ctx,
+		tbtc.OfflineSigningChannelName(walletPublicKeyHash),
+		signing,
+		exportDir,
+		importDir,
+		importInterval,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot create offline broadcast channel: [%v]", err)
+	}
+
+	logger.Infof(
+		"starting offline signing session [%v] of [%v] proposal for "+
+			"wallet [0x%x]; exporting messages to [%v] and importing from [%v]",
+		sessionID,
+		proposal.ActionType(),
+		walletPublicKeyHash,
+		exportDir,
+		importDir,
+	)
+
+	transaction, err := tbtc.SignProposalOffline(
+		ctx,
+		tbtcChain,
+		btcChain,
+		tbtcKeyStorePersistence,
+		walletPublicKeyHash,
+		proposal,
+		sessionID,
+		excludedMembersIndexes,
+		broadcastChannel,
+	)
+	if err != nil {
+		return fmt.Errorf("offline signing failed: [%v]", err)
+	}
+
+	logger.Infof(
+		"signed transaction [%s]",
+		transaction.Hash().Hex(bitcoin.ReversedByteOrder),
+	)
+
+	fmt.Println(hex.EncodeToString(transaction.Serialize()))
+
+	return nil
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/keep-network/keep-common/pkg/persistence"
 	"github.com/keep-network/keep-core/build"
-	"github.com/keep-network/keep-core/pkg/bitcoin/electrum"
 	"github.com/keep-network/keep-core/pkg/operator"
 	"github.com/keep-network/keep-core/pkg/storage"
 
@@ -91,14 +90,24 @@ func start(cmd *cobra.Command) error {
 	// Skip initialization for bootstrap nodes as they are only used for network
 	// discovery.
 	if !isBootstrap() {
-		btcChain, err := electrum.Connect(ctx, clientConfig.Bitcoin.Electrum)
+		btcChain, _, err := connectBitcoin(ctx, clientConfig.Bitcoin)
 		if err != nil {
-			return fmt.Errorf("could not connect to Electrum chain: [%v]", err)
+			return fmt.Errorf("could not connect to Bitcoin chain: [%v]", err)
+		}
+
+		feeEstimator, err := newBitcoinFeeEstimator(
+			btcChain,
+			clientConfig.Bitcoin,
+		)
+		if err != nil {
+			return fmt.Errorf("cannot create Bitcoin fee estimator: [%v]", err)
 		}
 
 		beaconKeyStorePersistence,
 			tbtcKeyStorePersistence,
 			tbtcDataPersistence,
+			walletActionAuditPersistence,
+			bitcoinPersistence,
 			err := initializePersistence()
 		if err != nil {
 			return fmt.Errorf("cannot initialize persistence: [%w]", err)
@@ -113,6 +122,35 @@ func start(cmd *cobra.Command) error {
 
 		clientInfoRegistry.RegisterBtcChainInfoSource(btcChain)
 
+		observeBitcoinMetrics(clientInfoRegistry, btcChain)
+
+		btcChain, err = verifyBitcoinHeaders(
+			ctx,
+			btcChain,
+			bitcoinPersistence,
+			clientConfig,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"cannot initialize Bitcoin header chain verification: [%v]",
+				err,
+			)
+		}
+
+		btcChain, err = indexWalletTransactions(
+			ctx,
+			btcChain,
+			tbtcChain,
+			bitcoinPersistence,
+			clientConfig,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"cannot initialize Bitcoin wallet index: [%v]",
+				err,
+			)
+		}
+
 		err = beacon.Initialize(
 			ctx,
 			beaconChain,
@@ -127,6 +165,11 @@ func start(cmd *cobra.Command) error {
 		proposalGenerator := tbtcpg.NewProposalGenerator(
 			tbtcChain,
 			btcChain,
+			tbtcpg.NewFeeEstimator(
+				feeEstimator,
+				clientConfig.ProposalGenerator.ConfirmationTargets(),
+			),
+			&clientConfig.ProposalGenerator,
 		)
 
 		err = tbtc.Initialize(
@@ -136,6 +179,7 @@ func start(cmd *cobra.Command) error {
 			netProvider,
 			tbtcKeyStorePersistence,
 			tbtcDataPersistence,
+			walletActionAuditPersistence,
 			scheduler,
 			proposalGenerator,
 			clientConfig.Tbtc,
@@ -250,6 +294,8 @@ func initializePersistence() (
 	beaconKeyStorePersistence persistence.ProtectedHandle,
 	tbtcKeyStorePersistence persistence.ProtectedHandle,
 	tbtcDataPersistence persistence.BasicHandle,
+	walletActionAuditPersistence persistence.BasicHandle,
+	bitcoinPersistence persistence.BasicHandle,
 	err error,
 ) {
 	storage, err := storage.Initialize(
@@ -257,14 +303,14 @@ func initializePersistence() (
 		clientConfig.Ethereum.KeyFilePassword,
 	)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("cannot initialize storage: [%w]", err)
+		return nil, nil, nil, nil, nil, fmt.Errorf("cannot initialize storage: [%w]", err)
 	}
 
 	beaconKeyStorePersistence, err = storage.InitializeKeyStorePersistence(
 		"beacon",
 	)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf(
+		return nil, nil, nil, nil, nil, fmt.Errorf(
 			"cannot initialize beacon keystore persistence: [%w]",
 			err,
 		)
@@ -274,7 +320,7 @@ func initializePersistence() (
 		"tbtc",
 	)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf(
+		return nil, nil, nil, nil, nil, fmt.Errorf(
 			"cannot initialize tbtc keystore persistence: [%w]",
 			err,
 		)
@@ -282,11 +328,26 @@ func initializePersistence() (
 
 	tbtcDataPersistence, err = storage.InitializeWorkPersistence("tbtc")
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf(
+		return nil, nil, nil, nil, nil, fmt.Errorf(
 			"cannot initialize tbtc data persistence: [%w]",
 			err,
 		)
 	}
 
+	walletActionAuditPersistence, err = storage.InitializeWorkPersistence(
+		tbtc.WalletActionAuditDirectory,
+	)
+	if err != nil {
+		return nil, nil, nil, nil, nil, fmt.Errorf(
+			"cannot initialize wallet action audit persistence: [%w]",
+			err,
+		)
+	}
+
+	bitcoinPersistence, err = initializeBitcoinPersistence(storage)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
 	return
 }
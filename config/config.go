@@ -19,12 +19,17 @@ import (
 	"golang.org/x/term"
 
 	commonEthereum "github.com/keep-network/keep-common/pkg/chain/ethereum"
+	"github.com/keep-network/keep-core/pkg/bitcoin/bitcoind"
+	"github.com/keep-network/keep-core/pkg/bitcoin/composite"
 	"github.com/keep-network/keep-core/pkg/bitcoin/electrum"
+	"github.com/keep-network/keep-core/pkg/bitcoin/fee"
+	"github.com/keep-network/keep-core/pkg/bitcoin/walletindex"
 	"github.com/keep-network/keep-core/pkg/clientinfo"
 	"github.com/keep-network/keep-core/pkg/maintainer"
 	"github.com/keep-network/keep-core/pkg/net/libp2p"
 	"github.com/keep-network/keep-core/pkg/storage"
 	"github.com/keep-network/keep-core/pkg/tbtc"
+	"github.com/keep-network/keep-core/pkg/tbtcpg"
 )
 
 var logger = log.Logger("keep-config")
@@ -52,6 +57,9 @@ type Config struct {
 	ClientInfo clientinfo.Config
 	Maintainer maintainer.Config
 	Tbtc       tbtc.Config
+	// ProposalGenerator defines the configuration of the wallet coordination
+	// proposal generator.
+	ProposalGenerator tbtcpg.Config
 }
 
 // BitcoinConfig defines the configuration for Bitcoin.
@@ -59,6 +67,32 @@ type BitcoinConfig struct {
 	bitcoin.Network
 	// Electrum defines the configuration for the Electrum client.
 	Electrum electrum.Config
+	// Bitcoind defines the configuration for the bitcoind JSON-RPC client.
+	// If the bitcoind URL is set, the bitcoind node is used as the Bitcoin
+	// chain backend instead of Electrum.
+	Bitcoind bitcoind.Config
+	// Composite defines the configuration of additional Bitcoin chain
+	// backends. If additional backends are set, requests are spread across
+	// all backends and security-critical results must be confirmed by a
+	// quorum of them.
+	Composite composite.Config
+	// HeaderChain defines the configuration of the local header chain
+	// verifying block headers returned by the Bitcoin chain backend.
+	HeaderChain bitcoin.HeaderChainConfig
+	// Fee defines the configuration of the Bitcoin fee estimation combining
+	// fee rates provided by all available sources.
+	Fee fee.Config
+	// WalletIndex defines the configuration of the local index of
+	// transactions made by live wallets.
+	WalletIndex walletindex.Config
+	// SpvProof defines the configuration of the SPV proof assembly.
+	SpvProof bitcoin.SpvProofConfig
+}
+
+// UseBitcoind determines whether the bitcoind node should be used as the
+// Bitcoin chain backend instead of Electrum.
+func (bc *BitcoinConfig) UseBitcoind() bool {
+	return len(bc.Bitcoind.URL) > 0
 }
 
 // Bind the flags to the viper configuration. Viper reads configuration from
@@ -210,9 +244,9 @@ func validateConfig(config *Config, categories ...Category) error {
 				))
 			}
 		case BitcoinElectrum:
-			if config.Bitcoin.Electrum.URL == "" {
+			if config.Bitcoin.Electrum.URL == "" && !config.Bitcoin.UseBitcoind() {
 				result = multierror.Append(result, fmt.Errorf(
-					"missing value for bitcoin.electrum.url; see bitcoin electrum section in configuration",
+					"missing value for bitcoin.electrum.url or bitcoin.bitcoind.url; see bitcoin section in configuration",
 				))
 			}
 		case Network:
@@ -227,6 +261,12 @@ func validateConfig(config *Config, categories ...Category) error {
 					"missing value for storage.dir; see storage section in configuration",
 				))
 			}
+			if config.Storage.KeyStoreBackend == storage.KeyStoreBackendRemote &&
+				config.Storage.RemoteKeyStore.URL == "" {
+				result = multierror.Append(result, fmt.Errorf(
+					"missing value for storage.remoteKeyStore.url; see storage section in configuration",
+				))
+			}
 		}
 	}
 
@@ -161,6 +161,87 @@ func TestReadConfigFromFile(t *testing.T) {
 			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Electrum.KeepAliveInterval },
 			expectedValue: 720 * time.Second,
 		},
+		"Bitcoin.Bitcoind.URL": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Bitcoind.URL },
+			expectedValue: "http://url.to.bitcoind:18332",
+		},
+		"Bitcoin.Bitcoind.Username": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Bitcoind.Username },
+			expectedValue: "rpcuser",
+		},
+		"Bitcoin.Bitcoind.Password": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Bitcoind.Password },
+			expectedValue: "rpcpassword",
+		},
+		"Bitcoin.Bitcoind.RequestTimeout": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Bitcoind.RequestTimeout },
+			expectedValue: 41 * time.Second,
+		},
+		"Bitcoin.Bitcoind.RequestRetryTimeout": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Bitcoind.RequestRetryTimeout },
+			expectedValue: 240 * time.Second,
+		},
+		"Bitcoin.Bitcoind.ScanTimeout": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Bitcoind.ScanTimeout },
+			expectedValue: 900 * time.Second,
+		},
+		"Bitcoin.Bitcoind.ScanStartHeight": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Bitcoind.ScanStartHeight },
+			expectedValue: uint(800000),
+		},
+		"Bitcoin.Composite.AdditionalElectrumURLs": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Composite.AdditionalElectrumURLs },
+			expectedValue: []string{
+				"tcp://url.to.electrum.2:50001",
+				"tcp://url.to.electrum.3:50001",
+			},
+		},
+		"Bitcoin.Composite.AdditionalBitcoindURLs": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Composite.AdditionalBitcoindURLs },
+			expectedValue: []string{
+				"http://url.to.bitcoind.2:8332",
+			},
+		},
+		"Bitcoin.Composite.Quorum": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Composite.Quorum },
+			expectedValue: 2,
+		},
+		"Bitcoin.HeaderChain.CheckpointHeight": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.HeaderChain.CheckpointHeight },
+			expectedValue: uint(806400),
+		},
+		"Bitcoin.HeaderChain.CheckpointHash": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.HeaderChain.CheckpointHash },
+			expectedValue: "00000000000000000001e4ac8e3fc4f7b4e0bb1b4c64c9dc4d36a8c1a3e7f2d9",
+		},
+		"Bitcoin.Fee.MinSatPerVByte": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Fee.MinSatPerVByte },
+			expectedValue: int64(2),
+		},
+		"Bitcoin.Fee.MaxSatPerVByte": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Fee.MaxSatPerVByte },
+			expectedValue: int64(250),
+		},
+		"Bitcoin.Fee.HistoryWindow": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.Fee.HistoryWindow },
+			expectedValue: 45 * time.Minute,
+		},
+		"Bitcoin.WalletIndex.Enabled": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.WalletIndex.Enabled },
+			expectedValue: true,
+		},
+		"Bitcoin.WalletIndex.SyncInterval": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.WalletIndex.SyncInterval },
+			expectedValue: 15 * time.Minute,
+		},
+		"Bitcoin.SpvProof.LocalAssembly": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.SpvProof.LocalAssembly },
+			expectedValue: true,
+		},
+		"Bitcoin.SpvProof.CacheCapacity": {
+			readValueFunc: func(c *Config) interface{} { return c.Bitcoin.SpvProof.CacheCapacity },
+			expectedValue: 500,
+		},
 		"Network.Port": {
 			readValueFunc: func(c *Config) interface{} { return c.LibP2P.Port },
 			expectedValue: 27001,
@@ -187,6 +268,26 @@ func TestReadConfigFromFile(t *testing.T) {
 			readValueFunc: func(c *Config) interface{} { return c.Storage.Dir },
 			expectedValue: "/my/secure/location",
 		},
+		"Storage.KeyStoreBackend": {
+			readValueFunc: func(c *Config) interface{} { return c.Storage.KeyStoreBackend },
+			expectedValue: "remote",
+		},
+		"Storage.RemoteKeyStore.URL": {
+			readValueFunc: func(c *Config) interface{} { return c.Storage.RemoteKeyStore.URL },
+			expectedValue: "https://url.to.secrets:8200/v1/keep",
+		},
+		"Storage.RemoteKeyStore.Token": {
+			readValueFunc: func(c *Config) interface{} { return c.Storage.RemoteKeyStore.Token },
+			expectedValue: "s3cr3t",
+		},
+		"Storage.RemoteKeyStore.RequestTimeout": {
+			readValueFunc: func(c *Config) interface{} { return c.Storage.RemoteKeyStore.RequestTimeout },
+			expectedValue: 12 * time.Second,
+		},
+		"Storage.RemoteKeyStore.RequestRetryTimeout": {
+			readValueFunc: func(c *Config) interface{} { return c.Storage.RemoteKeyStore.RequestRetryTimeout },
+			expectedValue: 3 * time.Minute,
+		},
 		"ClientInfo.Port": {
 			readValueFunc: func(c *Config) interface{} { return c.ClientInfo.Port },
 			expectedValue: 3498,
@@ -227,6 +328,33 @@ func TestReadConfigFromFile(t *testing.T) {
 			readValueFunc: func(c *Config) interface{} { return c.Maintainer.Spv.IdleBackoffTime },
 			expectedValue: 15 * time.Minute,
 		},
+		"Maintainer.Spv.RetryBackoffTime": {
+			readValueFunc: func(c *Config) interface{} { return c.Maintainer.Spv.RetryBackoffTime },
+			expectedValue: 20 * time.Minute,
+		},
+		"Maintainer.Spv.MaxRetryBackoffTime": {
+			readValueFunc: func(c *Config) interface{} { return c.Maintainer.Spv.MaxRetryBackoffTime },
+			expectedValue: 6 * time.Hour,
+		},
+		"Maintainer.Spv.Maintainers": {
+			readValueFunc: func(c *Config) interface{} { return c.Maintainer.Spv.Maintainers },
+			expectedValue: []string{
+				"0x9A5a6b80b1Ba6A6f9F3bE61fF1b3A4e3bb7D4b6c",
+				"0xE7e7B9EDd5AE4d3c5bF2E8A84A0e32Dc2d4FCfC5",
+			},
+		},
+		"Maintainer.Spv.ClaimPeriod": {
+			readValueFunc: func(c *Config) interface{} { return c.Maintainer.Spv.ClaimPeriod },
+			expectedValue: uint64(200),
+		},
+		"Maintainer.Submission.MaxGasPrice": {
+			readValueFunc: func(c *Config) interface{} { return c.Maintainer.Submission.MaxGasPrice.Int },
+			expectedValue: big.NewInt(45000000000),
+		},
+		"Maintainer.Submission.UrgencyWindow": {
+			readValueFunc: func(c *Config) interface{} { return c.Maintainer.Submission.UrgencyWindow },
+			expectedValue: 8 * time.Hour,
+		},
 	}
 
 	for _, filePath := range filePaths {
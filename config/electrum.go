@@ -30,12 +30,13 @@ func readElectrumUrls(network bitcoin.Network) (
 
 // resolveElectrum checks if Electrum is already configured. If the Electrum URL
 // is empty it reads the Electrum configs from the embedded list for the given
-// network and picks up one randomly.
+// network and picks up one randomly. Electrum is not resolved if a bitcoind
+// node is configured as the Bitcoin chain backend.
 func (c *Config) resolveElectrum(rng *rand.Rand) error {
 	network := c.Bitcoin.Network
 
-	// Return if Electrum is already set.
-	if len(c.Bitcoin.Electrum.URL) > 0 {
+	// Return if Electrum is already set or is not used at all.
+	if len(c.Bitcoin.Electrum.URL) > 0 || c.Bitcoin.UseBitcoind() {
 		return nil
 	}
 
@@ -0,0 +1,86 @@
+package bitcoin
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// EncodeAddress encodes the given standard Script into a human-readable
+// address of the given Bitcoin network. P2PKH and P2SH scripts are encoded
+// as Base58Check addresses, P2WPKH and P2WSH scripts as Bech32 addresses and
+// P2TR scripts as Bech32m addresses. Non-standard scripts cannot be encoded.
+func EncodeAddress(script Script, network Network) (string, error) {
+	params, err := network.params()
+	if err != nil {
+		return "", err
+	}
+
+	var address btcutil.Address
+
+	switch GetScriptType(script) {
+	case P2PKHScript:
+		// Omit the first three 0x76a914 bytes and last two 0x88ac bytes.
+		address, err = btcutil.NewAddressPubKeyHash(script[3:23], params)
+	case P2WPKHScript:
+		// Omit the first two 0x0014 bytes.
+		address, err = btcutil.NewAddressWitnessPubKeyHash(script[2:], params)
+	case P2SHScript:
+		// Omit the first two 0xa914 bytes and the last 0x87 byte.
+		address, err = btcutil.NewAddressScriptHashFromHash(script[2:22], params)
+	case P2WSHScript:
+		// Omit the first two 0x0020 bytes.
+		address, err = btcutil.NewAddressWitnessScriptHash(script[2:], params)
+	case P2TRScript:
+		// Omit the first two 0x5120 bytes.
+		address, err = btcutil.NewAddressTaproot(script[2:], params)
+	default:
+		return "", fmt.Errorf("non-standard script cannot be encoded")
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot create address: [%v]", err)
+	}
+
+	return address.EncodeAddress(), nil
+}
+
+// DecodeAddress decodes the given human-readable address of the given
+// Bitcoin network into the corresponding standard Script. Returns an error
+// if the address is malformed, belongs to another network or does not
+// represent a P2PKH, P2WPKH, P2SH, P2WSH or P2TR script.
+func DecodeAddress(address string, network Network) (Script, error) {
+	params, err := network.params()
+	if err != nil {
+		return nil, err
+	}
+
+	decodedAddress, err := btcutil.DecodeAddress(address, params)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode address: [%v]", err)
+	}
+
+	if !decodedAddress.IsForNet(params) {
+		return nil, fmt.Errorf("address is not for [%v] network", network)
+	}
+
+	switch addr := decodedAddress.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return PayToPublicKeyHash(*addr.Hash160())
+	case *btcutil.AddressWitnessPubKeyHash:
+		var publicKeyHash [20]byte
+		copy(publicKeyHash[:], addr.WitnessProgram())
+		return PayToWitnessPublicKeyHash(publicKeyHash)
+	case *btcutil.AddressScriptHash:
+		return PayToScriptHash(*addr.Hash160())
+	case *btcutil.AddressWitnessScriptHash:
+		var witnessScriptHash [32]byte
+		copy(witnessScriptHash[:], addr.WitnessProgram())
+		return PayToWitnessScriptHash(witnessScriptHash)
+	case *btcutil.AddressTaproot:
+		var outputKey [32]byte
+		copy(outputKey[:], addr.WitnessProgram())
+		return PayToTaproot(outputKey)
+	default:
+		return nil, fmt.Errorf("unsupported address type")
+	}
+}
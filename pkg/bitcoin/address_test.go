@@ -0,0 +1,138 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+func TestEncodeDecodeAddress(t *testing.T) {
+	var tests = map[string]struct {
+		script  string
+		network Network
+		address string
+	}{
+		"p2pkh mainnet": {
+			script:  "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac",
+			network: Mainnet,
+			address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		},
+		"p2pkh testnet": {
+			script:  "76a9148db50eb52063ea9d98b3eac91489a90f738986f688ac",
+			network: Testnet,
+			address: "mtSEUCE7G8om9zJttG9twtjoiSsUz7QnY9",
+		},
+		// BIP-173 test vector.
+		"p2wpkh mainnet": {
+			script:  "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+			network: Mainnet,
+			address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		},
+		"p2wpkh regtest": {
+			script:  "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+			network: Regtest,
+			address: "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080",
+		},
+		"p2sh mainnet": {
+			script:  "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87",
+			network: Mainnet,
+			address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+		},
+		// BIP-173 test vector.
+		"p2wsh testnet": {
+			script:  "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262",
+			network: Testnet,
+			address: "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7",
+		},
+		// BIP-86 test vector.
+		"p2tr mainnet": {
+			script:  "5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+			network: Mainnet,
+			address: "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			script, err := hex.DecodeString(test.script)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			address, err := EncodeAddress(script, test.network)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertStringsEqual(t, "address", test.address, address)
+
+			decodedScript, err := DecodeAddress(test.address, test.network)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertBytesEqual(t, script, decodedScript)
+		})
+	}
+}
+
+func TestEncodeAddress_NonStandardScript(t *testing.T) {
+	script, err := hex.DecodeString("6a0474657374")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = EncodeAddress(script, Mainnet)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDecodeAddress_Errors(t *testing.T) {
+	var tests = map[string]struct {
+		address     string
+		network     Network
+		expectedErr string
+	}{
+		"testnet address on mainnet": {
+			address:     "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7",
+			network:     Mainnet,
+			expectedErr: "address is not for [mainnet] network",
+		},
+		"mainnet base58 address on testnet": {
+			address:     "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+			network:     Testnet,
+			expectedErr: "cannot decode address",
+		},
+		"invalid checksum": {
+			address:     "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5",
+			network:     Mainnet,
+			expectedErr: "cannot decode address",
+		},
+		"public key address": {
+			address:     "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+			network:     Mainnet,
+			expectedErr: "unsupported address type",
+		},
+		"unknown network": {
+			address:     "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			network:     Unknown,
+			expectedErr: "unsupported Bitcoin network",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := DecodeAddress(test.address, test.network)
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf(
+					"unexpected error\nexpected to contain: %s\nactual: %v",
+					test.expectedErr,
+					err,
+				)
+			}
+		})
+	}
+}
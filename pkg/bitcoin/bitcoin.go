@@ -9,9 +9,15 @@ package bitcoin
 
 import (
 	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/ipfs/go-log"
 )
 
+var logger = log.Logger("keep-bitcoin")
+
 // CompactSizeUint is a documentation type that is supposed to capture the
 // details of the Bitcoin's CompactSize Unsigned Integer. It represents a
 // number value encoded to bytes according to the following rules:
@@ -94,3 +100,17 @@ const (
 func (n Network) String() string {
 	return []string{"unknown", "mainnet", "testnet", "regtest"}[n]
 }
+
+// params returns the chain parameters of the network.
+func (n Network) params() (*chaincfg.Params, error) {
+	switch n {
+	case Mainnet:
+		return &chaincfg.MainNetParams, nil
+	case Testnet:
+		return &chaincfg.TestNet3Params, nil
+	case Regtest:
+		return &chaincfg.RegressionNetParams, nil
+	default:
+		return nil, fmt.Errorf("unsupported Bitcoin network [%v]", n)
+	}
+}
@@ -0,0 +1,712 @@
+package bitcoind
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/ipfs/go-log"
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+var logger = log.Logger("keep-bitcoind")
+
+// Connection is a handle for interactions with a bitcoind node over its
+// JSON-RPC interface. The node is expected to run with `txindex=1` so
+// arbitrary transactions can be fetched and with `blockfilterindex=1` so
+// transaction history of a script can be reconstructed using block filters.
+// The connection is refused if any of those indexes is missing.
+type Connection struct {
+	parentCtx  context.Context
+	httpClient *http.Client
+	config     Config
+
+	requestIDMutex sync.Mutex
+	requestID      uint64
+
+	mempoolIndex *mempoolIndex
+	historyCache *historyCache
+}
+
+// Connect initializes handle with provided Config.
+func Connect(parentCtx context.Context, config Config) (bitcoin.Chain, error) {
+	if config.RequestTimeout == 0 {
+		config.RequestTimeout = DefaultRequestTimeout
+	}
+	if config.RequestRetryTimeout == 0 {
+		config.RequestRetryTimeout = DefaultRequestRetryTimeout
+	}
+	if config.ScanTimeout == 0 {
+		config.ScanTimeout = DefaultScanTimeout
+	}
+
+	c := &Connection{
+		parentCtx:    parentCtx,
+		httpClient:   &http.Client{},
+		config:       config,
+		mempoolIndex: newMempoolIndex(),
+		historyCache: newHistoryCache(historyCacheCapacity),
+	}
+
+	if err := c.verifyServer(); err != nil {
+		return nil, fmt.Errorf("failed to verify bitcoind node: [%w]", err)
+	}
+
+	return c, nil
+}
+
+// GetTransaction gets the transaction with the given transaction hash.
+// If the transaction with the given hash was not found on the chain,
+// this function returns an error.
+func (c *Connection) GetTransaction(
+	transactionHash bitcoin.Hash,
+) (*bitcoin.Transaction, error) {
+	txID := transactionHash.Hex(bitcoin.ReversedByteOrder)
+
+	rawTransaction, err := requestWithRetry[string](
+		c,
+		c.config.RequestTimeout,
+		"getrawtransaction",
+		txID,
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get raw transaction with ID [%s]: [%w]",
+			txID,
+			err,
+		)
+	}
+
+	result, err := convertRawTransaction(rawTransaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert transaction: [%w]", err)
+	}
+
+	return result, nil
+}
+
+// GetTransactionConfirmations gets the number of confirmations for the
+// transaction with the given transaction hash. If the transaction with the
+// given hash was not found on the chain, this function returns an error.
+func (c *Connection) GetTransactionConfirmations(
+	transactionHash bitcoin.Hash,
+) (uint, error) {
+	txID := transactionHash.Hex(bitcoin.ReversedByteOrder)
+
+	// The `confirmations` field is omitted for transactions living in the
+	// mempool so it is decoded as zero in that case.
+	verboseTransaction, err := requestWithRetry[*struct {
+		Confirmations uint `json:"confirmations"`
+	}](
+		c,
+		c.config.RequestTimeout,
+		"getrawtransaction",
+		txID,
+		true,
+	)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"failed to get transaction with ID [%s]: [%w]",
+			txID,
+			err,
+		)
+	}
+
+	return verboseTransaction.Confirmations, nil
+}
+
+// BroadcastTransaction broadcasts the given transaction over the
+// network of the Bitcoin chain nodes. If the broadcast action could not be
+// done, this function returns an error. This function does not give any
+// guarantees regarding transaction mining. The transaction may be mined or
+// rejected eventually.
+func (c *Connection) BroadcastTransaction(
+	transaction *bitcoin.Transaction,
+) error {
+	rawTx := hex.EncodeToString(transaction.Serialize())
+
+	rawTxLogger := logger.With(
+		zap.String("rawTx", rawTx),
+	)
+	rawTxLogger.Debugf("broadcasting transaction")
+
+	response, err := requestWithRetry[string](
+		c,
+		c.config.RequestTimeout,
+		"sendrawtransaction",
+		rawTx,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to broadcast the transaction: [%w]", err)
+	}
+
+	rawTxLogger.Infof("transaction broadcast successful: [%s]", response)
+
+	return nil
+}
+
+// GetLatestBlockHeight gets the height of the latest block (tip). If the
+// latest block was not determined, this function returns an error.
+func (c *Connection) GetLatestBlockHeight() (uint, error) {
+	blockHeight, err := requestWithRetry[uint](
+		c,
+		c.config.RequestTimeout,
+		"getblockcount",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block count: [%w]", err)
+	}
+
+	return blockHeight, nil
+}
+
+// GetBlockHeader gets the block header for the given block height. If the
+// block with the given height was not found on the chain, this function
+// returns an error.
+func (c *Connection) GetBlockHeader(
+	blockHeight uint,
+) (*bitcoin.BlockHeader, error) {
+	blockHash, err := c.getBlockHash(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	rawBlockHeader, err := requestWithRetry[string](
+		c,
+		c.config.RequestTimeout,
+		"getblockheader",
+		blockHash,
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block header: [%w]", err)
+	}
+
+	blockHeader, err := convertBlockHeader(rawBlockHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert block header: [%w]", err)
+	}
+
+	return blockHeader, nil
+}
+
+// GetTransactionMerkleProof gets the Merkle proof for a given transaction.
+// The transaction's hash and the block the transaction was included in the
+// blockchain need to be provided.
+func (c *Connection) GetTransactionMerkleProof(
+	transactionHash bitcoin.Hash,
+	blockHeight uint,
+) (*bitcoin.TransactionMerkleProof, error) {
+	txHashes, err := c.GetBlockTxHashes(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	position := -1
+	for i, txHash := range txHashes {
+		if txHash == transactionHash {
+			position = i
+			break
+		}
+	}
+	if position < 0 {
+		return nil, fmt.Errorf(
+			"transaction [%s] not found in block at height [%v]",
+			transactionHash.Hex(bitcoin.ReversedByteOrder),
+			blockHeight,
+		)
+	}
+
+	return &bitcoin.TransactionMerkleProof{
+		BlockHeight: blockHeight,
+		MerkleNodes: computeMerkleBranch(txHashes, uint(position)),
+		Position:    uint(position),
+	}, nil
+}
+
+// GetTransactionsForPublicKeyHash gets confirmed transactions that pays the
+// given public key hash using either a P2PKH or P2WPKH script. The returned
+// transactions are ordered by block height in the ascending order, i.e.
+// the latest transaction is at the end of the list. The returned list does
+// not contain unconfirmed transactions living in the mempool at the moment
+// of request. The returned transactions list can be limited using the
+// `limit` parameter. For example, if `limit` is set to `5`, only the
+// latest five transactions will be returned. Note that taking an unlimited
+// transaction history may be time-consuming as this function fetches
+// complete transactions with all necessary data.
+func (c *Connection) GetTransactionsForPublicKeyHash(
+	publicKeyHash [20]byte,
+	limit int,
+) ([]*bitcoin.Transaction, error) {
+	items, err := c.getPublicKeyHashHistory(publicKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) > limit {
+		items = items[len(items)-limit:]
+	}
+
+	transactions := make([]*bitcoin.Transaction, len(items))
+	for i, item := range items {
+		transactions[i] = item.transaction
+	}
+
+	return transactions, nil
+}
+
+// GetTxHashesForPublicKeyHash gets hashes of confirmed transactions that pays
+// the given public key hash using either a P2PKH or P2WPKH script. The returned
+// transactions hashes are ordered by block height in the ascending order, i.e.
+// the latest transaction hash is at the end of the list. The returned list does
+// not contain unconfirmed transactions hashes living in the mempool at the
+// moment of request.
+func (c *Connection) GetTxHashesForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]bitcoin.Hash, error) {
+	items, err := c.getPublicKeyHashHistory(publicKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	txHashes := make([]bitcoin.Hash, len(items))
+	for i, item := range items {
+		txHashes[i] = item.transaction.Hash()
+	}
+
+	return txHashes, nil
+}
+
+// GetMempoolForPublicKeyHash gets the unconfirmed mempool transactions
+// that pays the given public key hash using either a P2PKH or P2WPKH script.
+// The returned transactions are in an indefinite order.
+func (c *Connection) GetMempoolForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.Transaction, error) {
+	scripts, err := publicKeyHashScripts(publicKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := c.getMempoolTransactionsForScripts(scripts)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot get mempool transactions for public key hash [0x%x]: [%v]",
+			publicKeyHash,
+			err,
+		)
+	}
+
+	return transactions, nil
+}
+
+// GetUtxosForPublicKeyHash gets unspent outputs of confirmed transactions that
+// are controlled by the given public key hash (either a P2PKH or P2WPKH script).
+// The returned UTXOs are ordered by block height in the ascending order, i.e.
+// the latest UTXO is at the end of the list. The returned list does not contain
+// unspent outputs of unconfirmed transactions living in the mempool at the
+// moment of request. Outputs used as inputs of confirmed or mempool
+// transactions are not returned as well because they are no longer UTXOs.
+func (c *Connection) GetUtxosForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.UnspentTransactionOutput, error) {
+	scripts, err := publicKeyHashScripts(publicKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// The `scantxoutset` call scans the confirmed UTXO set only so outputs
+	// spent by mempool transactions must be filtered out separately.
+	scanResult, err := requestWithRetry[*struct {
+		Success  bool `json:"success"`
+		Unspents []struct {
+			TxID   string  `json:"txid"`
+			Vout   uint32  `json:"vout"`
+			Amount float64 `json:"amount"`
+			Height uint    `json:"height"`
+		} `json:"unspents"`
+	}](
+		c,
+		c.config.ScanTimeout,
+		"scantxoutset",
+		"start",
+		scanDescriptors(scripts),
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to scan UTXO set for public key hash [0x%x]: [%v]",
+			publicKeyHash,
+			err,
+		)
+	}
+	if !scanResult.Success {
+		return nil, fmt.Errorf(
+			"UTXO set scan for public key hash [0x%x] did not succeed",
+			publicKeyHash,
+		)
+	}
+
+	unspents := scanResult.Unspents
+	sort.SliceStable(
+		unspents,
+		func(i, j int) bool {
+			return unspents[i].Height < unspents[j].Height
+		},
+	)
+
+	utxos := make([]*bitcoin.UnspentTransactionOutput, 0, len(unspents))
+	for _, unspent := range unspents {
+		txHash, err := bitcoin.NewHashFromString(
+			unspent.TxID,
+			bitcoin.ReversedByteOrder,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot parse hash [%s]: [%v]",
+				unspent.TxID,
+				err,
+			)
+		}
+
+		isUnspent, err := c.isOutputUnspent(txHash, unspent.Vout)
+		if err != nil {
+			return nil, err
+		}
+		if !isUnspent {
+			continue
+		}
+
+		utxos = append(utxos, &bitcoin.UnspentTransactionOutput{
+			Outpoint: &bitcoin.TransactionOutpoint{
+				TransactionHash: txHash,
+				OutputIndex:     unspent.Vout,
+			},
+			Value: convertBtcToSat(unspent.Amount),
+		})
+	}
+
+	return utxos, nil
+}
+
+// GetMempoolUtxosForPublicKeyHash gets unspent outputs of unconfirmed transactions
+// that are controlled by the given public key hash (either a P2PKH or P2WPKH script).
+// The returned UTXOs are in an indefinite order. The returned list does not
+// contain unspent outputs of confirmed transactions. Outputs used as inputs of
+// confirmed or mempool transactions are not returned as well because they are
+// no longer UTXOs.
+func (c *Connection) GetMempoolUtxosForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.UnspentTransactionOutput, error) {
+	scripts, err := publicKeyHashScripts(publicKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := c.getMempoolTransactionsForScripts(scripts)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot get mempool transactions for public key hash [0x%x]: [%v]",
+			publicKeyHash,
+			err,
+		)
+	}
+
+	utxos := make([]*bitcoin.UnspentTransactionOutput, 0)
+	for _, transaction := range transactions {
+		txHash := transaction.Hash()
+
+		for outputIndex, output := range transaction.Outputs {
+			if !matchesAnyScript(output.PublicKeyScript, scripts) {
+				continue
+			}
+
+			isUnspent, err := c.isOutputUnspent(txHash, uint32(outputIndex))
+			if err != nil {
+				return nil, err
+			}
+			if !isUnspent {
+				continue
+			}
+
+			utxos = append(utxos, &bitcoin.UnspentTransactionOutput{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: txHash,
+					OutputIndex:     uint32(outputIndex),
+				},
+				Value: output.Value,
+			})
+		}
+	}
+
+	return utxos, nil
+}
+
+// isOutputUnspent checks whether the given output is still unspent, taking
+// both confirmed and mempool transactions into account.
+func (c *Connection) isOutputUnspent(
+	txHash bitcoin.Hash,
+	outputIndex uint32,
+) (bool, error) {
+	txID := txHash.Hex(bitcoin.ReversedByteOrder)
+
+	// The `gettxout` call returns `null` if the output is spent.
+	txOut, err := requestWithRetry[*struct{}](
+		c,
+		c.config.RequestTimeout,
+		"gettxout",
+		txID,
+		outputIndex,
+		true,
+	)
+	if err != nil {
+		return false, fmt.Errorf(
+			"failed to get output [%s:%d]: [%w]",
+			txID,
+			outputIndex,
+			err,
+		)
+	}
+
+	return txOut != nil, nil
+}
+
+// EstimateSatPerVByteFee returns the estimated sat/vbyte fee for a
+// transaction to be confirmed within the given number of blocks.
+func (c *Connection) EstimateSatPerVByteFee(blocks uint32) (int64, error) {
+	// According to bitcoind docs, the returned fee rate is BTC/kvB.
+	estimate, err := requestWithRetry[*struct {
+		FeeRate *float64 `json:"feerate"`
+		Errors  []string `json:"errors"`
+	}](
+		c,
+		c.config.RequestTimeout,
+		"estimatesmartfee",
+		blocks,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate smart fee: [%v]", err)
+	}
+
+	// The fee rate is omitted if the node does not have enough information
+	// to make an estimate.
+	if estimate.FeeRate == nil || *estimate.FeeRate < 0 {
+		return 0, fmt.Errorf(
+			"node does not have enough information to make an estimate: [%v]",
+			estimate.Errors,
+		)
+	}
+
+	return convertBtcKbToSatVByte(*estimate.FeeRate), nil
+}
+
+// GetCoinbaseTxHash gets the hash of the coinbase transaction for the given
+// block height.
+func (c *Connection) GetCoinbaseTxHash(blockHeight uint) (bitcoin.Hash, error) {
+	txHashes, err := c.GetBlockTxHashes(blockHeight)
+	if err != nil {
+		return bitcoin.Hash{}, err
+	}
+
+	if len(txHashes) == 0 {
+		return bitcoin.Hash{}, fmt.Errorf(
+			"block at height [%v] has no transactions",
+			blockHeight,
+		)
+	}
+
+	return txHashes[0], nil
+}
+
+// getBlockHash gets the hash of the block at the given height, in the
+// reversed byte order used by the bitcoind JSON-RPC interface.
+func (c *Connection) getBlockHash(blockHeight uint) (string, error) {
+	blockHash, err := requestWithRetry[string](
+		c,
+		c.config.RequestTimeout,
+		"getblockhash",
+		blockHeight,
+	)
+	if err != nil {
+		return "", fmt.Errorf(
+			"failed to get hash of block at height [%v]: [%w]",
+			blockHeight,
+			err,
+		)
+	}
+
+	return blockHash, nil
+}
+
+// GetBlockTxHashes gets hashes of all transactions included in the block at
+// the given height, in the order they appear in the block.
+func (c *Connection) GetBlockTxHashes(blockHeight uint) ([]bitcoin.Hash, error) {
+	blockHash, err := c.getBlockHash(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := requestWithRetry[*struct {
+		Tx []string `json:"tx"`
+	}](
+		c,
+		c.config.RequestTimeout,
+		"getblock",
+		blockHash,
+		1,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get block [%s]: [%w]",
+			blockHash,
+			err,
+		)
+	}
+
+	txHashes := make([]bitcoin.Hash, len(block.Tx))
+	for i, txID := range block.Tx {
+		txHash, err := bitcoin.NewHashFromString(txID, bitcoin.ReversedByteOrder)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse hash [%s]: [%v]", txID, err)
+		}
+
+		txHashes[i] = txHash
+	}
+
+	return txHashes, nil
+}
+
+// verboseBlock is a block returned by `getblock` with verbosity 2, i.e.
+// including raw transactions.
+type verboseBlock struct {
+	Height uint `json:"height"`
+	Tx     []struct {
+		TxID string `json:"txid"`
+		Hex  string `json:"hex"`
+	} `json:"tx"`
+}
+
+// getBlock gets the block with the given hash along with all its raw
+// transactions.
+func (c *Connection) getBlock(blockHash string) (*verboseBlock, error) {
+	block, err := requestWithRetry[*verboseBlock](
+		c,
+		c.config.RequestTimeout,
+		"getblock",
+		blockHash,
+		2,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get block [%s]: [%w]",
+			blockHash,
+			err,
+		)
+	}
+
+	return block, nil
+}
+
+func (c *Connection) verifyServer() error {
+	networkInfo, err := requestWithRetry[*struct {
+		Version    int    `json:"version"`
+		Subversion string `json:"subversion"`
+	}](
+		c,
+		c.config.RequestTimeout,
+		"getnetworkinfo",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get network info: [%w]", err)
+	}
+
+	logger.Infof(
+		"connected to bitcoind node [version: [%d], subversion: [%s]]",
+		networkInfo.Version,
+		networkInfo.Subversion,
+	)
+
+	indexInfo, err := requestWithRetry[map[string]struct {
+		Synced bool `json:"synced"`
+	}](
+		c,
+		c.config.RequestTimeout,
+		"getindexinfo",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get index info: [%w]", err)
+	}
+
+	// The indexes are required to serve all requests. Indexes that are
+	// still being built are only reported as they become usable once synced.
+	for _, index := range []string{"txindex", "basic block filter index"} {
+		info, ok := indexInfo[index]
+		if !ok {
+			return fmt.Errorf(
+				"bitcoind node [%s] does not maintain [%s]; "+
+					"run the node with txindex=1 and blockfilterindex=1",
+				c.config.URL,
+				index,
+			)
+		}
+
+		if !info.Synced {
+			logger.Warnf(
+				"bitcoind node [%s] has not synced [%s] yet",
+				c.config.URL,
+				index,
+			)
+		}
+	}
+
+	return nil
+}
+
+// publicKeyHashScripts builds the P2PKH and P2WPKH scripts for the given
+// public key hash.
+func publicKeyHashScripts(publicKeyHash [20]byte) ([]bitcoin.Script, error) {
+	p2pkh, err := bitcoin.PayToPublicKeyHash(publicKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot build P2PKH for public key hash [0x%x]: [%v]",
+			publicKeyHash,
+			err,
+		)
+	}
+
+	p2wpkh, err := bitcoin.PayToWitnessPublicKeyHash(publicKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot build P2WPKH for public key hash [0x%x]: [%v]",
+			publicKeyHash,
+			err,
+		)
+	}
+
+	return []bitcoin.Script{p2pkh, p2wpkh}, nil
+}
+
+// scanDescriptors converts the given scripts to output descriptors accepted
+// by `scantxoutset` and `scanblocks` calls.
+func scanDescriptors(scripts []bitcoin.Script) []string {
+	descriptors := make([]string, len(scripts))
+	for i, script := range scripts {
+		descriptors[i] = fmt.Sprintf("raw(%s)", hex.EncodeToString(script))
+	}
+	return descriptors
+}
+
+func matchesAnyScript(script bitcoin.Script, scripts []bitcoin.Script) bool {
+	for _, s := range scripts {
+		if bytes.Equal(script, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,1283 @@
+package bitcoind
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+const (
+	testUsername = "alice"
+	testPassword = "s3cr3t"
+)
+
+// https://blockstream.info/testnet/api/tx/44c568bc0eac07a2a9c2b46829be5b5d46e7d00e17bfb613f506a75ccf86a473
+const testTransactionHex = "01000000000101672ae7c34d6a225797f0e005f6ed53ee40252811a37e90f62b68eb5e587be68e0000000000ffffffff01d0200000000000001600148db50eb52063ea9d98b3eac91489a90f738986f603483045022100b12afadf68ad9781600f065e0b09e22058ca2293aa86ac38add3ca7cfb01b3b7022009ecce0c1c3ebd26569c6b0d60e15b4675860737487d1b7c782439acf4709bdf012103989d253b17a6a0f41838b84ff0d20e8898f9d7b1a98f2564da4cc29dcf8581d95c14934b98637ca318a4d6e7ca6ffd1690b8e77df6377508f9f0c90d000395237576a9148db50eb52063ea9d98b3eac91489a90f738986f68763ac6776a914e257eccafbc07c381642ce6e7e55120fb077fbed8804e0250162b175ac6800000000"
+
+const testTransactionID = "44c568bc0eac07a2a9c2b46829be5b5d46e7d00e17bfb613f506a75ccf86a473"
+
+var testPublicKeyHash = [20]byte{
+	0x8d, 0xb5, 0x0e, 0xb5, 0x20, 0x63, 0xea, 0x9d, 0x98, 0xb3,
+	0xea, 0xc9, 0x14, 0x89, 0xa9, 0x0f, 0x73, 0x89, 0x86, 0xf6,
+}
+
+// recordedServer is a stand-in bitcoind JSON-RPC server that replies with
+// responses recorded for specific method and parameters combinations.
+type recordedServer struct {
+	t *testing.T
+
+	mutex      sync.Mutex
+	recordings map[string][]string
+	calls      map[string]int
+}
+
+// record registers responses for the given method and parameters. The
+// responses are returned in order for subsequent calls; the last one is
+// repeated once the others are exhausted. Each response is a JSON object
+// holding either `result` or `error`.
+func (rs *recordedServer) record(
+	method string,
+	params string,
+	responses ...string,
+) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	rs.recordings[method+params] = responses
+}
+
+func (rs *recordedServer) callsCount(method string, params string) int {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	return rs.calls[method+params]
+}
+
+func (rs *recordedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok || username != testUsername || password != testPassword {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rs.t.Fatal(err)
+	}
+
+	// Batch requests are answered with an array of responses and the OK
+	// status, as bitcoind does.
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var requests []json.RawMessage
+		if err := json.Unmarshal(body, &requests); err != nil {
+			rs.t.Fatal(err)
+		}
+
+		responses := make([]string, len(requests))
+		for i, request := range requests {
+			responses[i], _ = rs.respond(request)
+		}
+
+		fmt.Fprintf(w, "[%s]", strings.Join(responses, ","))
+		return
+	}
+
+	response, status := rs.respond(body)
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
+
+	fmt.Fprint(w, response)
+}
+
+// respond returns the recorded response for the given single request along
+// with the HTTP status bitcoind would reply with.
+func (rs *recordedServer) respond(body []byte) (string, int) {
+	var request struct {
+		ID     uint64          `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		rs.t.Fatal(err)
+	}
+
+	var params bytes.Buffer
+	if err := json.Compact(&params, request.Params); err != nil {
+		rs.t.Fatal(err)
+	}
+
+	key := request.Method + params.String()
+
+	rs.mutex.Lock()
+	responses, ok := rs.recordings[key]
+	callIndex := rs.calls[key]
+	rs.calls[key]++
+	rs.mutex.Unlock()
+
+	if !ok {
+		rs.t.Errorf("unexpected request: [%s]", key)
+		return fmt.Sprintf(
+			`{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":%d}`,
+			request.ID,
+		), http.StatusNotFound
+	}
+
+	if callIndex >= len(responses) {
+		callIndex = len(responses) - 1
+	}
+	response := responses[callIndex]
+
+	status := http.StatusOK
+	if strings.Contains(response, `"error":{`) {
+		status = http.StatusInternalServerError
+	}
+
+	return fmt.Sprintf(
+		`{%s,"id":%d}`,
+		strings.TrimSuffix(strings.TrimPrefix(response, "{"), "}"),
+		request.ID,
+	), status
+}
+
+func newTestConnection(t *testing.T) (*Connection, *recordedServer) {
+	server := &recordedServer{
+		t:          t,
+		recordings: make(map[string][]string),
+		calls:      make(map[string]int),
+	}
+
+	server.record(
+		"getnetworkinfo",
+		"[]",
+		`{"result":{"version":250000,"subversion":"/Satoshi:25.0.0/"},"error":null}`,
+	)
+	server.record(
+		"getindexinfo",
+		"[]",
+		`{"result":{"txindex":{"synced":true,"best_block_height":2164160},`+
+			`"basic block filter index":{"synced":true,"best_block_height":2164160}},`+
+			`"error":null}`,
+	)
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	chain, err := Connect(
+		context.Background(),
+		Config{
+			URL:                 httpServer.URL,
+			Username:            testUsername,
+			Password:            testPassword,
+			RequestRetryTimeout: 5 * time.Second,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return chain.(*Connection), server
+}
+
+func TestGetTransaction(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	server.record(
+		"getrawtransaction",
+		fmt.Sprintf(`["%s",false]`, testTransactionID),
+		fmt.Sprintf(`{"result":"%s","error":null}`, testTransactionHex),
+	)
+
+	transaction, err := connection.GetTransaction(
+		hashFromString(testTransactionID),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"transaction hash",
+		testTransactionID,
+		transaction.Hash().Hex(bitcoin.ReversedByteOrder),
+	)
+	testutils.AssertStringsEqual(
+		t,
+		"serialized transaction",
+		testTransactionHex,
+		hex.EncodeToString(transaction.Serialize()),
+	)
+}
+
+func TestGetTransaction_NotFound(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	params := fmt.Sprintf(`["%s",false]`, testTransactionID)
+
+	server.record(
+		"getrawtransaction",
+		params,
+		`{"result":null,"error":{"code":-5,"message":"No such mempool or blockchain transaction"}}`,
+	)
+
+	_, err := connection.GetTransaction(hashFromString(testTransactionID))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !isNotFoundErr(err) {
+		t.Errorf("expected not found error; got: [%v]", err)
+	}
+
+	// Definitive errors returned by the node must not be retried.
+	testutils.AssertIntsEqual(
+		t,
+		"getrawtransaction calls",
+		1,
+		server.callsCount("getrawtransaction", params),
+	)
+}
+
+func TestGetTransaction_RetryWhenWarmingUp(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	params := fmt.Sprintf(`["%s",false]`, testTransactionID)
+
+	server.record(
+		"getrawtransaction",
+		params,
+		`{"result":null,"error":{"code":-28,"message":"Loading block index..."}}`,
+		fmt.Sprintf(`{"result":"%s","error":null}`, testTransactionHex),
+	)
+
+	transaction, err := connection.GetTransaction(
+		hashFromString(testTransactionID),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"transaction hash",
+		testTransactionID,
+		transaction.Hash().Hex(bitcoin.ReversedByteOrder),
+	)
+	testutils.AssertIntsEqual(
+		t,
+		"getrawtransaction calls",
+		2,
+		server.callsCount("getrawtransaction", params),
+	)
+}
+
+func TestGetTransactionConfirmations(t *testing.T) {
+	var tests = map[string]struct {
+		response              string
+		expectedConfirmations uint
+	}{
+		"confirmed transaction": {
+			response:              `{"result":{"txid":"aa","confirmations":7},"error":null}`,
+			expectedConfirmations: 7,
+		},
+		"mempool transaction": {
+			response:              `{"result":{"txid":"aa"},"error":null}`,
+			expectedConfirmations: 0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			connection, server := newTestConnection(t)
+
+			server.record(
+				"getrawtransaction",
+				fmt.Sprintf(`["%s",true]`, testTransactionID),
+				test.response,
+			)
+
+			confirmations, err := connection.GetTransactionConfirmations(
+				hashFromString(testTransactionID),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertUintsEqual(
+				t,
+				"confirmations",
+				uint64(test.expectedConfirmations),
+				uint64(confirmations),
+			)
+		})
+	}
+}
+
+func TestBroadcastTransaction(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	transaction := new(bitcoin.Transaction)
+	if err := transaction.Deserialize(decodeString(testTransactionHex)); err != nil {
+		t.Fatal(err)
+	}
+
+	params := fmt.Sprintf(`["%s"]`, testTransactionHex)
+
+	server.record(
+		"sendrawtransaction",
+		params,
+		fmt.Sprintf(`{"result":"%s","error":null}`, testTransactionID),
+	)
+
+	if err := connection.BroadcastTransaction(transaction); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"sendrawtransaction calls",
+		1,
+		server.callsCount("sendrawtransaction", params),
+	)
+}
+
+func TestGetLatestBlockHeight(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	server.record("getblockcount", "[]", `{"result":2164160,"error":null}`)
+
+	blockHeight, err := connection.GetLatestBlockHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertUintsEqual(t, "block height", 2164160, uint64(blockHeight))
+}
+
+func TestGetBlockHeader(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	// https://blockstream.info/testnet/block/00000000000013e457bd86d1b6f0b933c2c9500e08dd3eef862ec4e5238b316c
+	blockHash := "00000000000013e457bd86d1b6f0b933c2c9500e08dd3eef862ec4e5238b316c"
+	expectedBlockHeader := &bitcoin.BlockHeader{
+		Version: 536928260,
+		PreviousBlockHeaderHash: hashFromString(
+			"0000000000005fc4fcdd302209885dfd2a700d4cd6f5cf88942fd635ea332d73",
+		),
+		MerkleRootHash: hashFromString(
+			"4ba0b0e57f3747049ae392132c4f934c216daa3853f91ed9baf5a324ba836219",
+		),
+		Time:  1646051559,
+		Bits:  486604799,
+		Nonce: 655015664,
+	}
+
+	server.record(
+		"getblockhash",
+		"[2164152]",
+		fmt.Sprintf(`{"result":"%s","error":null}`, blockHash),
+	)
+	server.record(
+		"getblockheader",
+		fmt.Sprintf(`["%s",false]`, blockHash),
+		`{"result":"04e00020732d33ea35d62f9488cff5d64c0d702afd5d88092230ddfcc45`+
+			`f000000000000196283ba24a3f5bad91ef95338aa6d214c934f2c1392e39a0447`+
+			`377fe5b0a04be7c01c62ffff001df0be0a27","error":null}`,
+	)
+
+	blockHeader, err := connection.GetBlockHeader(2164152)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expectedBlockHeader, blockHeader) {
+		t.Errorf(
+			"unexpected block header\nexpected: %+v\nactual:   %+v",
+			expectedBlockHeader,
+			blockHeader,
+		)
+	}
+}
+
+// Transactions of the mainnet block 100000 whose Merkle root is
+// f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766.
+var block100000TxIDs = []string{
+	"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+	"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+	"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+	"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+}
+
+const block100000Hash = "000000000003ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506"
+
+func recordBlock100000(server *recordedServer) {
+	server.record(
+		"getblockhash",
+		"[100000]",
+		fmt.Sprintf(`{"result":"%s","error":null}`, block100000Hash),
+	)
+	server.record(
+		"getblock",
+		fmt.Sprintf(`["%s",1]`, block100000Hash),
+		fmt.Sprintf(
+			`{"result":{"height":100000,"tx":["%s"]},"error":null}`,
+			strings.Join(block100000TxIDs, `","`),
+		),
+	)
+}
+
+func TestGetTransactionMerkleProof(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	recordBlock100000(server)
+
+	expectedMerkleRoot := "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766"
+
+	for position, txID := range block100000TxIDs {
+		t.Run(txID, func(t *testing.T) {
+			proof, err := connection.GetTransactionMerkleProof(
+				hashFromString(txID),
+				100000,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertUintsEqual(
+				t,
+				"block height",
+				100000,
+				uint64(proof.BlockHeight),
+			)
+			testutils.AssertUintsEqual(
+				t,
+				"position",
+				uint64(position),
+				uint64(proof.Position),
+			)
+			testutils.AssertIntsEqual(
+				t,
+				"merkle nodes count",
+				2,
+				len(proof.MerkleNodes),
+			)
+
+			// Fold the branch and make sure it leads to the block's Merkle root.
+			current := hashFromString(txID)
+			index := proof.Position
+			for _, node := range proof.MerkleNodes {
+				sibling := hashFromString(node)
+				if index%2 == 0 {
+					current = bitcoin.ComputeHash(append(current[:], sibling[:]...))
+				} else {
+					current = bitcoin.ComputeHash(append(sibling[:], current[:]...))
+				}
+				index /= 2
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"merkle root",
+				expectedMerkleRoot,
+				current.Hex(bitcoin.ReversedByteOrder),
+			)
+		})
+	}
+}
+
+func TestGetTransactionMerkleProof_NotInBlock(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	recordBlock100000(server)
+
+	_, err := connection.GetTransactionMerkleProof(
+		hashFromString(testTransactionID),
+		100000,
+	)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGetCoinbaseTxHash(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	recordBlock100000(server)
+
+	coinbaseTxHash, err := connection.GetCoinbaseTxHash(100000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"coinbase transaction hash",
+		block100000TxIDs[0],
+		coinbaseTxHash.Hex(bitcoin.ReversedByteOrder),
+	)
+}
+
+func TestComputeMerkleBranch_OddTransactionsCount(t *testing.T) {
+	txHashes := []bitcoin.Hash{
+		bitcoin.ComputeHash([]byte{0x01}),
+		bitcoin.ComputeHash([]byte{0x02}),
+		bitcoin.ComputeHash([]byte{0x03}),
+	}
+
+	// The last hash of a level with an odd number of elements is paired
+	// with itself.
+	branch := computeMerkleBranch(txHashes, 2)
+
+	expectedBranch := []string{
+		txHashes[2].Hex(bitcoin.ReversedByteOrder),
+		bitcoin.ComputeHash(
+			append(txHashes[0][:], txHashes[1][:]...),
+		).Hex(bitcoin.ReversedByteOrder),
+	}
+
+	if !reflect.DeepEqual(expectedBranch, branch) {
+		t.Errorf(
+			"unexpected branch\nexpected: %v\nactual:   %v",
+			expectedBranch,
+			branch,
+		)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"single transaction branch length",
+		0,
+		len(computeMerkleBranch(txHashes[:1], 0)),
+	)
+}
+
+func TestGetUtxosForPublicKeyHash(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	server.record(
+		"scantxoutset",
+		`["start",["raw(76a9148db50eb52063ea9d98b3eac91489a90f738986f688ac)",`+
+			`"raw(00148db50eb52063ea9d98b3eac91489a90f738986f6)"]]`,
+		`{"result":{"success":true,"unspents":[`+
+			`{"txid":"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4","vout":1,"amount":0.00012351,"height":2164160},`+
+			`{"txid":"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87","vout":0,"amount":1.5,"height":2164152},`+
+			`{"txid":"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4","vout":3,"amount":0.1,"height":2164155}`+
+			`]},"error":null}`,
+	)
+	server.record(
+		"gettxout",
+		`["fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",1,true]`,
+		`{"result":{"value":0.00012351},"error":null}`,
+	)
+	server.record(
+		"gettxout",
+		`["8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",0,true]`,
+		`{"result":{"value":1.5},"error":null}`,
+	)
+	// This output is already spent by a mempool transaction.
+	server.record(
+		"gettxout",
+		`["6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",3,true]`,
+		`{"result":null,"error":null}`,
+	)
+
+	utxos, err := connection.GetUtxosForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedUtxos := []*bitcoin.UnspentTransactionOutput{
+		{
+			Outpoint: &bitcoin.TransactionOutpoint{
+				TransactionHash: hashFromString(
+					"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+				),
+				OutputIndex: 0,
+			},
+			Value: 150000000,
+		},
+		{
+			Outpoint: &bitcoin.TransactionOutpoint{
+				TransactionHash: hashFromString(
+					"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+				),
+				OutputIndex: 1,
+			},
+			Value: 12351,
+		},
+	}
+
+	if !reflect.DeepEqual(expectedUtxos, utxos) {
+		t.Errorf(
+			"unexpected UTXOs\nexpected: %v\nactual:   %v",
+			expectedUtxos,
+			utxos,
+		)
+	}
+}
+
+func TestGetTxHashesForPublicKeyHash(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	p2wpkh, err := bitcoin.PayToWitnessPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherScript, err := bitcoin.PayToWitnessPublicKeyHash([20]byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Funds the wallet.
+	fundingTx := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: bitcoin.ComputeHash([]byte{0xaa}),
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 5000, PublicKeyScript: otherScript},
+			{Value: 10000, PublicKeyScript: p2wpkh},
+		},
+	}
+	// Spends the wallet's output somewhere else.
+	spendingTx := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: fundingTx.Hash(),
+					OutputIndex:     1,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 9000, PublicKeyScript: otherScript},
+		},
+	}
+	// Unrelated transaction matched by the block filter as a false positive.
+	unrelatedTx := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: fundingTx.Hash(),
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 4000, PublicKeyScript: otherScript},
+		},
+	}
+
+	verboseTx := func(tx *bitcoin.Transaction) string {
+		return fmt.Sprintf(
+			`{"txid":"%s","hex":"%s"}`,
+			tx.Hash().Hex(bitcoin.ReversedByteOrder),
+			hex.EncodeToString(tx.Serialize()),
+		)
+	}
+
+	server.record(
+		"getblockcount",
+		"[]",
+		`{"result":300,"error":null}`,
+	)
+	server.record(
+		"getblockhash",
+		"[294]",
+		`{"result":"ee","error":null}`,
+	)
+	// Relevant blocks are deliberately returned in the descending order.
+	server.record(
+		"scanblocks",
+		`["start",["raw(76a9148db50eb52063ea9d98b3eac91489a90f738986f688ac)",`+
+			`"raw(00148db50eb52063ea9d98b3eac91489a90f738986f6)"],0,294]`,
+		`{"result":{"from_height":0,"to_height":294,"relevant_blocks":["bb","aa"]},"error":null}`,
+	)
+	server.record(
+		"scanblocks",
+		`["start",["raw(76a9148db50eb52063ea9d98b3eac91489a90f738986f688ac)",`+
+			`"raw(00148db50eb52063ea9d98b3eac91489a90f738986f6)"],295,300]`,
+		`{"result":{"from_height":295,"to_height":300,"relevant_blocks":[]},"error":null}`,
+	)
+	server.record(
+		"getblock",
+		`["aa",2]`,
+		fmt.Sprintf(
+			`{"result":{"height":200,"tx":[%s]},"error":null}`,
+			verboseTx(fundingTx),
+		),
+	)
+	server.record(
+		"getblock",
+		`["bb",2]`,
+		fmt.Sprintf(
+			`{"result":{"height":250,"tx":[%s,%s]},"error":null}`,
+			verboseTx(unrelatedTx),
+			verboseTx(spendingTx),
+		),
+	)
+
+	txHashes, err := connection.GetTxHashesForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTxHashes := []bitcoin.Hash{fundingTx.Hash(), spendingTx.Hash()}
+	if !reflect.DeepEqual(expectedTxHashes, txHashes) {
+		t.Errorf(
+			"unexpected transaction hashes\nexpected: %v\nactual:   %v",
+			expectedTxHashes,
+			txHashes,
+		)
+	}
+
+	transactions, err := connection.GetTransactionsForPublicKeyHash(
+		testPublicKeyHash,
+		1,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "transactions count", 1, len(transactions))
+	testutils.AssertStringsEqual(
+		t,
+		"latest transaction hash",
+		spendingTx.Hash().String(),
+		transactions[0].Hash().String(),
+	)
+}
+
+func TestGetTxHashesForPublicKeyHash_IncrementalScan(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	p2wpkh, err := bitcoin.PayToWitnessPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherScript, err := bitcoin.PayToWitnessPublicKeyHash([20]byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Funds the wallet.
+	fundingTx := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: bitcoin.ComputeHash([]byte{0xaa}),
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 10000, PublicKeyScript: p2wpkh},
+		},
+	}
+	// Spends the wallet's output somewhere else.
+	spendingTx := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: fundingTx.Hash(),
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 9000, PublicKeyScript: otherScript},
+		},
+	}
+
+	verboseTx := func(tx *bitcoin.Transaction) string {
+		return fmt.Sprintf(
+			`{"txid":"%s","hex":"%s"}`,
+			tx.Hash().Hex(bitcoin.ReversedByteOrder),
+			hex.EncodeToString(tx.Serialize()),
+		)
+	}
+
+	descriptors := `["start",["raw(76a9148db50eb52063ea9d98b3eac91489a90f738986f688ac)",` +
+		`"raw(00148db50eb52063ea9d98b3eac91489a90f738986f6)"],`
+
+	connection.config.ScanStartHeight = 100
+
+	server.record(
+		"getblockcount",
+		"[]",
+		`{"result":300,"error":null}`,
+		`{"result":310,"error":null}`,
+	)
+	server.record(
+		"getblockhash",
+		"[294]",
+		`{"result":"ee","error":null}`,
+	)
+	server.record(
+		"getblockhash",
+		"[304]",
+		`{"result":"ff","error":null}`,
+	)
+	server.record(
+		"scanblocks",
+		descriptors+"100,294]",
+		`{"result":{"from_height":100,"to_height":294,"relevant_blocks":[]},"error":null}`,
+	)
+	// The funding transaction is mined in one of the most recent blocks
+	// so it is scanned again in the second call.
+	server.record(
+		"scanblocks",
+		descriptors+"295,300]",
+		`{"result":{"from_height":295,"to_height":300,"relevant_blocks":["aa"]},"error":null}`,
+	)
+	server.record(
+		"scanblocks",
+		descriptors+"295,304]",
+		`{"result":{"from_height":295,"to_height":304,"relevant_blocks":["aa"]},"error":null}`,
+	)
+	server.record(
+		"scanblocks",
+		descriptors+"305,310]",
+		`{"result":{"from_height":305,"to_height":310,"relevant_blocks":["bb"]},"error":null}`,
+	)
+	server.record(
+		"getblock",
+		`["aa",2]`,
+		fmt.Sprintf(
+			`{"result":{"height":296,"tx":[%s]},"error":null}`,
+			verboseTx(fundingTx),
+		),
+	)
+	server.record(
+		"getblock",
+		`["bb",2]`,
+		fmt.Sprintf(
+			`{"result":{"height":308,"tx":[%s]},"error":null}`,
+			verboseTx(spendingTx),
+		),
+	)
+
+	txHashes, err := connection.GetTxHashesForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTxHashes := []bitcoin.Hash{fundingTx.Hash()}
+	if !reflect.DeepEqual(expectedTxHashes, txHashes) {
+		t.Errorf(
+			"unexpected transaction hashes\nexpected: %v\nactual:   %v",
+			expectedTxHashes,
+			txHashes,
+		)
+	}
+
+	txHashes, err = connection.GetTxHashesForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTxHashes = []bitcoin.Hash{fundingTx.Hash(), spendingTx.Hash()}
+	if !reflect.DeepEqual(expectedTxHashes, txHashes) {
+		t.Errorf(
+			"unexpected transaction hashes\nexpected: %v\nactual:   %v",
+			expectedTxHashes,
+			txHashes,
+		)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"scans of cached blocks",
+		1,
+		server.callsCount("scanblocks", descriptors+"100,294]"),
+	)
+}
+
+func TestGetTxHashesForPublicKeyHash_Reorg(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	p2wpkh, err := bitcoin.PayToWitnessPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Funds the wallet in a block that is reorganized later.
+	fundingTx := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: bitcoin.ComputeHash([]byte{0xaa}),
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 10000, PublicKeyScript: p2wpkh},
+		},
+	}
+
+	descriptors := `["start",["raw(76a9148db50eb52063ea9d98b3eac91489a90f738986f688ac)",` +
+		`"raw(00148db50eb52063ea9d98b3eac91489a90f738986f6)"],`
+
+	connection.config.ScanStartHeight = 100
+
+	server.record(
+		"getblockcount",
+		"[]",
+		`{"result":300,"error":null}`,
+	)
+	// The last cached block is replaced by a reorganization deeper than
+	// the safety margin before the second call.
+	server.record(
+		"getblockhash",
+		"[294]",
+		`{"result":"ee","error":null}`,
+		`{"result":"ee","error":null}`,
+		`{"result":"ef","error":null}`,
+	)
+	server.record(
+		"scanblocks",
+		descriptors+"100,294]",
+		`{"result":{"from_height":100,"to_height":294,"relevant_blocks":["aa"]},"error":null}`,
+		`{"result":{"from_height":100,"to_height":294,"relevant_blocks":[]},"error":null}`,
+	)
+	server.record(
+		"scanblocks",
+		descriptors+"295,300]",
+		`{"result":{"from_height":295,"to_height":300,"relevant_blocks":[]},"error":null}`,
+	)
+	server.record(
+		"getblock",
+		`["aa",2]`,
+		fmt.Sprintf(
+			`{"result":{"height":200,"tx":[{"txid":"%s","hex":"%s"}]},"error":null}`,
+			fundingTx.Hash().Hex(bitcoin.ReversedByteOrder),
+			hex.EncodeToString(fundingTx.Serialize()),
+		),
+	)
+
+	txHashes, err := connection.GetTxHashesForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "transactions count", 1, len(txHashes))
+
+	txHashes, err = connection.GetTxHashesForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"transactions count after reorg",
+		0,
+		len(txHashes),
+	)
+
+	testutils.AssertIntsEqual(
+		t,
+		"scans of reorganized blocks",
+		2,
+		server.callsCount("scanblocks", descriptors+"100,294]"),
+	)
+}
+
+func TestHistoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newHistoryCache(2)
+
+	history1 := cache.get([20]byte{0x01}, 100)
+	history1.nextHeight = 200
+	cache.get([20]byte{0x02}, 100)
+	cache.get([20]byte{0x01}, 100)
+	cache.get([20]byte{0x03}, 100)
+
+	testutils.AssertIntsEqual(t, "cached histories", 2, len(cache.histories))
+
+	if _, ok := cache.histories[[20]byte{0x02}]; ok {
+		t.Errorf("least recently used history was not evicted")
+	}
+
+	testutils.AssertUintsEqual(
+		t,
+		"next height of the retained history",
+		200,
+		uint64(cache.get([20]byte{0x01}, 100).nextHeight),
+	)
+}
+
+func TestConnect_MissingIndex(t *testing.T) {
+	server := &recordedServer{
+		t:          t,
+		recordings: make(map[string][]string),
+		calls:      make(map[string]int),
+	}
+
+	server.record(
+		"getnetworkinfo",
+		"[]",
+		`{"result":{"version":250000,"subversion":"/Satoshi:25.0.0/"},"error":null}`,
+	)
+	server.record(
+		"getindexinfo",
+		"[]",
+		`{"result":{"txindex":{"synced":true,"best_block_height":2164160}},`+
+			`"error":null}`,
+	)
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	_, err := Connect(
+		context.Background(),
+		Config{
+			URL:                 httpServer.URL,
+			Username:            testUsername,
+			Password:            testPassword,
+			RequestRetryTimeout: 5 * time.Second,
+		},
+	)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		fmt.Sprintf(
+			"failed to verify bitcoind node: [bitcoind node [%s] does not "+
+				"maintain [basic block filter index]; run the node with "+
+				"txindex=1 and blockfilterindex=1]",
+			httpServer.URL,
+		),
+		err.Error(),
+	)
+}
+
+func TestGetMempoolUtxosForPublicKeyHash(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	// The test transaction pays 8400 satoshis to the test public key hash
+	// using its first output.
+	server.record(
+		"getrawmempool",
+		"[false,true]",
+		fmt.Sprintf(
+			`{"result":{"txids":["%s","%s"],"mempool_sequence":7},"error":null}`,
+			testTransactionID,
+			block100000TxIDs[1],
+		),
+	)
+	server.record(
+		"getrawtransaction",
+		fmt.Sprintf(`["%s",false]`, testTransactionID),
+		fmt.Sprintf(`{"result":"%s","error":null}`, testTransactionHex),
+	)
+	// This transaction has been evicted from the mempool in the meantime.
+	server.record(
+		"getrawtransaction",
+		fmt.Sprintf(`["%s",false]`, block100000TxIDs[1]),
+		`{"result":null,"error":{"code":-5,"message":"No such mempool or blockchain transaction"}}`,
+	)
+	server.record(
+		"gettxout",
+		fmt.Sprintf(`["%s",0,true]`, testTransactionID),
+		`{"result":{"value":0.000084},"error":null}`,
+	)
+
+	utxos, err := connection.GetMempoolUtxosForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedUtxos := []*bitcoin.UnspentTransactionOutput{
+		{
+			Outpoint: &bitcoin.TransactionOutpoint{
+				TransactionHash: hashFromString(testTransactionID),
+				OutputIndex:     0,
+			},
+			Value: 8400,
+		},
+	}
+
+	if !reflect.DeepEqual(expectedUtxos, utxos) {
+		t.Errorf(
+			"unexpected UTXOs\nexpected: %v\nactual:   %v",
+			expectedUtxos,
+			utxos,
+		)
+	}
+
+	transactions, err := connection.GetMempoolForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "transactions count", 1, len(transactions))
+
+	// The mempool sequence did not change so the mempool index is not
+	// synced again and only the matching transaction is fetched.
+	testutils.AssertIntsEqual(
+		t,
+		"evicted transaction fetches",
+		1,
+		server.callsCount(
+			"getrawtransaction",
+			fmt.Sprintf(`["%s",false]`, block100000TxIDs[1]),
+		),
+	)
+	testutils.AssertIntsEqual(
+		t,
+		"matching transaction fetches",
+		3,
+		server.callsCount(
+			"getrawtransaction",
+			fmt.Sprintf(`["%s",false]`, testTransactionID),
+		),
+	)
+}
+
+func TestGetMempoolForPublicKeyHash_IncrementalSync(t *testing.T) {
+	connection, server := newTestConnection(t)
+
+	server.record(
+		"getrawmempool",
+		"[false,true]",
+		fmt.Sprintf(
+			`{"result":{"txids":["%s"],"mempool_sequence":1},"error":null}`,
+			block100000TxIDs[1],
+		),
+		fmt.Sprintf(
+			`{"result":{"txids":["%s"],"mempool_sequence":2},"error":null}`,
+			testTransactionID,
+		),
+	)
+	server.record(
+		"getrawtransaction",
+		fmt.Sprintf(`["%s",false]`, testTransactionID),
+		fmt.Sprintf(`{"result":"%s","error":null}`, testTransactionHex),
+	)
+	server.record(
+		"getrawtransaction",
+		fmt.Sprintf(`["%s",false]`, block100000TxIDs[1]),
+		`{"result":null,"error":{"code":-5,"message":"No such mempool or blockchain transaction"}}`,
+	)
+
+	transactions, err := connection.GetMempoolForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "transactions count", 0, len(transactions))
+
+	// The transaction paying the public key hash entered the mempool while
+	// the other one left it.
+	transactions, err = connection.GetMempoolForPublicKeyHash(testPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "transactions count", 1, len(transactions))
+	testutils.AssertIntsEqual(
+		t,
+		"indexed transactions count",
+		1,
+		len(connection.mempoolIndex.scripts),
+	)
+}
+
+func TestEstimateSatPerVByteFee(t *testing.T) {
+	var tests = map[string]struct {
+		response               string
+		expectedSatPerVByteFee int64
+		expectedError          bool
+	}{
+		"estimate available": {
+			response:               `{"result":{"feerate":0.0012351,"blocks":6},"error":null}`,
+			expectedSatPerVByteFee: 124,
+		},
+		"estimate below minimum": {
+			response:               `{"result":{"feerate":0.000001,"blocks":6},"error":null}`,
+			expectedSatPerVByteFee: 1,
+		},
+		"estimate unavailable": {
+			response:      `{"result":{"errors":["Insufficient data or no feerate found"],"blocks":0},"error":null}`,
+			expectedError: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			connection, server := newTestConnection(t)
+
+			server.record("estimatesmartfee", "[6]", test.response)
+
+			satPerVByteFee, err := connection.EstimateSatPerVByteFee(6)
+			if test.expectedError {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"sat/vbyte fee",
+				int(test.expectedSatPerVByteFee),
+				int(satPerVByteFee),
+			)
+		})
+	}
+}
+
+func hashFromString(s string) bitcoin.Hash {
+	hash, err := bitcoin.NewHashFromString(
+		s,
+		bitcoin.ReversedByteOrder,
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return hash
+}
+
+func decodeString(s string) []byte {
+	bytes, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return bytes
+}
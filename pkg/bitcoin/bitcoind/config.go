@@ -0,0 +1,35 @@
+package bitcoind
+
+import "time"
+
+const (
+	// DefaultRequestTimeout is a default timeout used for a single attempt of
+	// bitcoind JSON-RPC request.
+	DefaultRequestTimeout = 30 * time.Second
+	// DefaultRequestRetryTimeout is a default timeout used for bitcoind
+	// JSON-RPC request retries.
+	DefaultRequestRetryTimeout = 2 * time.Minute
+	// DefaultScanTimeout is a default timeout used for a single attempt of
+	// bitcoind UTXO set and block filter scans. Scans are considerably
+	// slower than regular requests so they use a separate timeout.
+	DefaultScanTimeout = 10 * time.Minute
+)
+
+// Config holds configurable properties.
+type Config struct {
+	// URL to the bitcoind JSON-RPC endpoint in format: `scheme://hostname:port`.
+	URL string
+	// Username used to authenticate against the bitcoind JSON-RPC endpoint.
+	Username string
+	// Password used to authenticate against the bitcoind JSON-RPC endpoint.
+	Password string
+	// Timeout for a single attempt of bitcoind JSON-RPC request.
+	RequestTimeout time.Duration
+	// Timeout for bitcoind JSON-RPC request retries.
+	RequestRetryTimeout time.Duration
+	// Timeout for a single attempt of bitcoind UTXO set and block filter scans.
+	ScanTimeout time.Duration
+	// Height of the block the transaction history scans start from. Blocks
+	// below that height are never scanned.
+	ScanStartHeight uint
+}
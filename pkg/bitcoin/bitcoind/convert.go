@@ -0,0 +1,83 @@
+package bitcoind
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+// convertRawTransaction transforms a transaction provided in the hexadecimal
+// serialized string to the format expected by the bitcoin.Chain interface.
+func convertRawTransaction(rawTx string) (*bitcoin.Transaction, error) {
+	txBytes, err := hex.DecodeString(rawTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode a hex string: [%w]", err)
+	}
+
+	result := new(bitcoin.Transaction)
+	if err := result.Deserialize(txBytes); err != nil {
+		return nil, fmt.Errorf("failed to deserialize a transaction: [%w]", err)
+	}
+
+	return result, nil
+}
+
+// convertBlockHeader transforms a block header provided in the hexadecimal
+// serialized string to the format expected by the bitcoin.Chain interface.
+func convertBlockHeader(rawBlockHeader string) (*bitcoin.BlockHeader, error) {
+	headerBytes, err := hex.DecodeString(rawBlockHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode a hex string: [%w]", err)
+	}
+
+	if len(headerBytes) != bitcoin.BlockHeaderByteLength {
+		return nil, fmt.Errorf(
+			"wrong block header length; expected [%v], got [%v]",
+			bitcoin.BlockHeaderByteLength,
+			len(headerBytes),
+		)
+	}
+
+	var serializedHeader [bitcoin.BlockHeaderByteLength]byte
+	copy(serializedHeader[:], headerBytes)
+
+	result := new(bitcoin.BlockHeader)
+	result.Deserialize(serializedHeader)
+
+	return result, nil
+}
+
+// computeMerkleBranch computes the Merkle branch leading to the transaction
+// at the given position, using hashes of all transactions included in the
+// block. The branch is returned in the same format Electrum servers use,
+// i.e. as a list of hexadecimal hashes in the reversed byte order, deepest
+// pairing first.
+func computeMerkleBranch(txHashes []bitcoin.Hash, position uint) []string {
+	branch := bitcoin.ComputeMerkleBranch(txHashes, position)
+
+	nodes := make([]string, len(branch))
+	for i, node := range branch {
+		nodes[i] = node.Hex(bitcoin.ReversedByteOrder)
+	}
+
+	return nodes
+}
+
+// convertBtcToSat converts the given BTC amount returned by bitcoind into
+// satoshis.
+func convertBtcToSat(btcAmount float64) int64 {
+	return int64(math.Round(btcAmount * 1e8))
+}
+
+// convertBtcKbToSatVByte converts the given BTC/kvB fee rate returned by
+// bitcoind into the sat/vbyte fee rate.
+func convertBtcKbToSatVByte(btcPerKbFee float64) int64 {
+	// To convert from BTC/KB to sat/vbyte, we need to multiply by 1e8/1e3.
+	satPerVByte := (1e8 / 1e3) * btcPerKbFee
+	// Make sure the minimum returned sat/vbyte fee is always 1.
+	satPerVByte = math.Max(satPerVByte, 1)
+	// Round the returned fee to be an integer.
+	return int64(math.Round(satPerVByte))
+}
@@ -0,0 +1,378 @@
+package bitcoind
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+const (
+	// historyReorgSafetyMargin is the number of the most recent blocks whose
+	// scan results are never cached. Those blocks are likely to be
+	// reorganized so they are scanned again upon each history request.
+	historyReorgSafetyMargin = 6
+	// historyCacheCapacity is the maximum number of public key hashes whose
+	// histories are cached. The least recently used history is evicted
+	// once the capacity is exceeded.
+	historyCacheCapacity = 500
+)
+
+type scriptHistoryItem struct {
+	transaction *bitcoin.Transaction
+	blockHeight uint
+}
+
+// scriptHistory is the transaction history of a public key hash
+// reconstructed from blocks below nextHeight. The history is extended with
+// newer blocks incrementally so the same blocks are never scanned twice.
+type scriptHistory struct {
+	mutex sync.Mutex
+
+	// nextHeight is the height of the first block not scanned yet.
+	nextHeight uint
+	// lastBlockHash is the hash of the last scanned block, i.e. the block
+	// at nextHeight-1. Empty if no blocks were scanned yet. It is used to
+	// detect reorganizations of scanned blocks.
+	lastBlockHash string
+	// lastUsed is the value of the history cache's usage counter upon the
+	// most recent use of the history.
+	lastUsed uint64
+	// items holds transactions of the history found so far, in the
+	// ascending order of their block heights.
+	items []*scriptHistoryItem
+	// ownedOutpoints holds outputs locked by the public key hash scripts
+	// not spent in scanned blocks. They are tracked in order to find
+	// transactions spending them in subsequent blocks.
+	ownedOutpoints map[bitcoin.TransactionOutpoint]bool
+}
+
+// reset drops all scanned blocks of the history so it is scanned again
+// from the given height.
+func (sh *scriptHistory) reset(startHeight uint) {
+	sh.nextHeight = startHeight
+	sh.lastBlockHash = ""
+	sh.items = make([]*scriptHistoryItem, 0)
+	sh.ownedOutpoints = make(map[bitcoin.TransactionOutpoint]bool)
+}
+
+// historyCache holds transaction histories of at most the given capacity of
+// public key hashes scanned so far. The least recently used histories are
+// evicted first.
+type historyCache struct {
+	mutex sync.Mutex
+
+	capacity   int
+	usageCount uint64
+	histories  map[[20]byte]*scriptHistory
+}
+
+func newHistoryCache(capacity int) *historyCache {
+	return &historyCache{
+		capacity:  capacity,
+		histories: make(map[[20]byte]*scriptHistory),
+	}
+}
+
+// get returns the cached history of the given public key hash. If the public
+// key hash was not scanned yet or its history was evicted, an empty history
+// starting at the given height is returned.
+func (hc *historyCache) get(
+	publicKeyHash [20]byte,
+	startHeight uint,
+) *scriptHistory {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	hc.usageCount++
+
+	history, ok := hc.histories[publicKeyHash]
+	if !ok {
+		if len(hc.histories) >= hc.capacity {
+			hc.evictLeastRecentlyUsed()
+		}
+
+		history = &scriptHistory{}
+		history.reset(startHeight)
+		hc.histories[publicKeyHash] = history
+	}
+
+	history.lastUsed = hc.usageCount
+
+	return history
+}
+
+// evictLeastRecentlyUsed removes the least recently used history from the
+// cache. Must be called with the cache mutex held.
+func (hc *historyCache) evictLeastRecentlyUsed() {
+	var evicted [20]byte
+	var evictedLastUsed uint64
+	found := false
+
+	for publicKeyHash, history := range hc.histories {
+		if !found || history.lastUsed < evictedLastUsed {
+			evicted = publicKeyHash
+			evictedLastUsed = history.lastUsed
+			found = true
+		}
+	}
+
+	if found {
+		delete(hc.histories, evicted)
+	}
+}
+
+// getPublicKeyHashHistory returns a history of confirmed transactions for
+// the given public key hash. A transaction is part of the history if it
+// either funds the P2PKH/P2WPKH script of the public key hash or spends
+// an output locked by one of those scripts. The returned list is sorted by
+// the block height in the ascending order, i.e. the latest transaction is at
+// the end of the list.
+//
+// The history is reconstructed using the `scanblocks` call that requires
+// bitcoind to run with `blockfilterindex=1`. Block filters can yield false
+// positives so each relevant block is fetched and its transactions are
+// matched against the scripts again.
+//
+// Scans start at the configured scan start height and their results are
+// cached so subsequent calls scan only blocks mined in the meantime. The
+// last historyReorgSafetyMargin blocks are not cached and are scanned upon
+// each call as they are likely to be reorganized. Deeper reorganizations are
+// detected by checking whether the last cached block is still part of the
+// best chain. If it is not, the cached history is dropped and scanned again.
+func (c *Connection) getPublicKeyHashHistory(
+	publicKeyHash [20]byte,
+) ([]*scriptHistoryItem, error) {
+	scripts, err := publicKeyHashScripts(publicKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	tipHeight, err := c.GetLatestBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	history := c.historyCache.get(publicKeyHash, c.config.ScanStartHeight)
+
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	if history.lastBlockHash != "" {
+		lastBlockHash, err := c.getBlockHash(history.nextHeight - 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if lastBlockHash != history.lastBlockHash {
+			logger.Warnf(
+				"block [%s] at height [%v] is no longer part of the best "+
+					"chain; scanning history of public key hash [0x%x] again",
+				history.lastBlockHash,
+				history.nextHeight-1,
+				publicKeyHash,
+			)
+
+			history.reset(c.config.ScanStartHeight)
+		}
+	}
+
+	if tipHeight >= historyReorgSafetyMargin {
+		finalHeight := tipHeight - historyReorgSafetyMargin
+
+		if finalHeight >= history.nextHeight {
+			finalBlockHash, err := c.getBlockHash(finalHeight)
+			if err != nil {
+				return nil, err
+			}
+
+			blocks, err := c.scanBlocks(
+				publicKeyHash,
+				scripts,
+				history.nextHeight,
+				finalHeight,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			// Update the cache only once all blocks are processed.
+			ownedOutpoints := copyOutpoints(history.ownedOutpoints)
+			items, err := appendHistoryItems(
+				copyHistoryItems(history.items),
+				ownedOutpoints,
+				blocks,
+				scripts,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			// Make sure the scanned blocks were not reorganized during
+			// the scan before they are cached.
+			currentFinalBlockHash, err := c.getBlockHash(finalHeight)
+			if err != nil {
+				return nil, err
+			}
+			if currentFinalBlockHash != finalBlockHash {
+				return nil, fmt.Errorf(
+					"block at height [%v] was reorganized during the scan",
+					finalHeight,
+				)
+			}
+
+			history.items = items
+			history.ownedOutpoints = ownedOutpoints
+			history.nextHeight = finalHeight + 1
+			history.lastBlockHash = finalBlockHash
+		}
+	}
+
+	if tipHeight < history.nextHeight {
+		return copyHistoryItems(history.items), nil
+	}
+
+	blocks, err := c.scanBlocks(
+		publicKeyHash,
+		scripts,
+		history.nextHeight,
+		tipHeight,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The most recent blocks are not cached so they are processed on top
+	// of copies of the cached history.
+	return appendHistoryItems(
+		copyHistoryItems(history.items),
+		copyOutpoints(history.ownedOutpoints),
+		blocks,
+		scripts,
+	)
+}
+
+// scanBlocks returns blocks between the given heights, inclusive, that
+// contain transactions paying to or spending from the given scripts,
+// sorted by their heights in the ascending order. The returned blocks may
+// contain false positives.
+func (c *Connection) scanBlocks(
+	publicKeyHash [20]byte,
+	scripts []bitcoin.Script,
+	startHeight uint,
+	stopHeight uint,
+) ([]*verboseBlock, error) {
+	scanResult, err := requestWithRetry[*struct {
+		RelevantBlocks []string `json:"relevant_blocks"`
+	}](
+		c,
+		c.config.ScanTimeout,
+		"scanblocks",
+		"start",
+		scanDescriptors(scripts),
+		startHeight,
+		stopHeight,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to scan blocks [%v-%v] for public key hash [0x%x]: [%v]",
+			startHeight,
+			stopHeight,
+			publicKeyHash,
+			err,
+		)
+	}
+
+	blocks := make([]*verboseBlock, 0, len(scanResult.RelevantBlocks))
+	for _, blockHash := range scanResult.RelevantBlocks {
+		block, err := c.getBlock(blockHash)
+		if err != nil {
+			return nil, err
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	// The outputs locked by the scripts are tracked in order to find
+	// transactions spending them. That requires processing the blocks in
+	// the ascending order.
+	sort.SliceStable(
+		blocks,
+		func(i, j int) bool {
+			return blocks[i].Height < blocks[j].Height
+		},
+	)
+
+	return blocks, nil
+}
+
+// appendHistoryItems appends transactions of the given blocks that pay to
+// the given scripts or spend the given owned outpoints to the given history
+// items. The owned outpoints are updated with outputs created and spent by
+// those transactions. Blocks must be sorted by their heights in the
+// ascending order.
+func appendHistoryItems(
+	items []*scriptHistoryItem,
+	ownedOutpoints map[bitcoin.TransactionOutpoint]bool,
+	blocks []*verboseBlock,
+	scripts []bitcoin.Script,
+) ([]*scriptHistoryItem, error) {
+	for _, block := range blocks {
+		for _, blockTx := range block.Tx {
+			transaction, err := convertRawTransaction(blockTx.Hex)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"failed to convert transaction [%s]: [%w]",
+					blockTx.TxID,
+					err,
+				)
+			}
+
+			isRelevant := false
+
+			for _, input := range transaction.Inputs {
+				if ownedOutpoints[*input.Outpoint] {
+					delete(ownedOutpoints, *input.Outpoint)
+					isRelevant = true
+				}
+			}
+
+			txHash := transaction.Hash()
+			for outputIndex, output := range transaction.Outputs {
+				if matchesAnyScript(output.PublicKeyScript, scripts) {
+					ownedOutpoints[bitcoin.TransactionOutpoint{
+						TransactionHash: txHash,
+						OutputIndex:     uint32(outputIndex),
+					}] = true
+					isRelevant = true
+				}
+			}
+
+			if isRelevant {
+				items = append(items, &scriptHistoryItem{
+					transaction: transaction,
+					blockHeight: block.Height,
+				})
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func copyHistoryItems(items []*scriptHistoryItem) []*scriptHistoryItem {
+	itemsCopy := make([]*scriptHistoryItem, len(items))
+	copy(itemsCopy, items)
+	return itemsCopy
+}
+
+func copyOutpoints(
+	outpoints map[bitcoin.TransactionOutpoint]bool,
+) map[bitcoin.TransactionOutpoint]bool {
+	outpointsCopy := make(map[bitcoin.TransactionOutpoint]bool, len(outpoints))
+	for outpoint := range outpoints {
+		outpointsCopy[outpoint] = true
+	}
+	return outpointsCopy
+}
@@ -0,0 +1,236 @@
+package bitcoind
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+// mempoolBatchSize is the maximum number of transactions fetched from the
+// bitcoind node with a single batch request while syncing the mempool index.
+const mempoolBatchSize = 500
+
+// mempoolIndex indexes unconfirmed mempool transactions by scripts of their
+// outputs. bitcoind has no script index for the mempool so the index is kept
+// locally and synced with the node's mempool incrementally: only
+// transactions that entered the mempool since the previous sync are fetched
+// and transactions that left the mempool are dropped. The sync is skipped
+// altogether if the mempool sequence of the node has not changed.
+type mempoolIndex struct {
+	mutex sync.Mutex
+
+	synced   bool
+	sequence uint64
+	// scripts holds output scripts of indexed transactions by transaction
+	// IDs. Transactions evicted from the mempool before they were fetched
+	// are indexed with no scripts so they are not fetched again.
+	scripts map[string][]string
+	// transactions holds IDs of indexed transactions by their output
+	// scripts.
+	transactions map[string]map[string]bool
+}
+
+func newMempoolIndex() *mempoolIndex {
+	return &mempoolIndex{
+		scripts:      make(map[string][]string),
+		transactions: make(map[string]map[string]bool),
+	}
+}
+
+// add indexes the transaction with the given ID paying to the given output
+// scripts.
+func (mi *mempoolIndex) add(txID string, scripts []string) {
+	mi.scripts[txID] = scripts
+
+	for _, script := range scripts {
+		txIDs, ok := mi.transactions[script]
+		if !ok {
+			txIDs = make(map[string]bool)
+			mi.transactions[script] = txIDs
+		}
+
+		txIDs[txID] = true
+	}
+}
+
+// remove drops the transaction with the given ID from the index.
+func (mi *mempoolIndex) remove(txID string) {
+	for _, script := range mi.scripts[txID] {
+		delete(mi.transactions[script], txID)
+		if len(mi.transactions[script]) == 0 {
+			delete(mi.transactions, script)
+		}
+	}
+
+	delete(mi.scripts, txID)
+}
+
+// syncMempoolIndex syncs the mempool index with the current mempool of the
+// bitcoind node.
+func (c *Connection) syncMempoolIndex() error {
+	mempool, err := requestWithRetry[*struct {
+		TxIDs           []string `json:"txids"`
+		MempoolSequence uint64   `json:"mempool_sequence"`
+	}](
+		c,
+		c.config.RequestTimeout,
+		"getrawmempool",
+		false,
+		true,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get raw mempool: [%w]", err)
+	}
+
+	index := c.mempoolIndex
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if index.synced && index.sequence == mempool.MempoolSequence {
+		return nil
+	}
+
+	inMempool := make(map[string]bool, len(mempool.TxIDs))
+	newTxIDs := make([]string, 0)
+	for _, txID := range mempool.TxIDs {
+		inMempool[txID] = true
+
+		if _, ok := index.scripts[txID]; !ok {
+			newTxIDs = append(newTxIDs, txID)
+		}
+	}
+
+	for txID := range index.scripts {
+		if !inMempool[txID] {
+			index.remove(txID)
+		}
+	}
+
+	for start := 0; start < len(newTxIDs); start += mempoolBatchSize {
+		end := start + mempoolBatchSize
+		if end > len(newTxIDs) {
+			end = len(newTxIDs)
+		}
+
+		if err := c.indexMempoolTransactions(newTxIDs[start:end]); err != nil {
+			return err
+		}
+	}
+
+	index.synced = true
+	index.sequence = mempool.MempoolSequence
+
+	logger.Debugf(
+		"synced mempool index with [%d] new transactions; "+
+			"[%d] transactions indexed",
+		len(newTxIDs),
+		len(index.scripts),
+	)
+
+	return nil
+}
+
+// indexMempoolTransactions fetches mempool transactions with the given IDs
+// using a single batch request and adds them to the mempool index. Must be
+// called with the index mutex held.
+func (c *Connection) indexMempoolTransactions(txIDs []string) error {
+	paramsList := make([][]interface{}, len(txIDs))
+	for i, txID := range txIDs {
+		paramsList[i] = []interface{}{txID, false}
+	}
+
+	rawTransactions, errs, err := batchRequestWithRetry[string](
+		c,
+		c.config.RequestTimeout,
+		"getrawtransaction",
+		paramsList,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get raw mempool transactions: [%w]", err)
+	}
+
+	for i, txID := range txIDs {
+		if errs[i] != nil {
+			if isNotFoundErr(errs[i]) {
+				// The transaction has been evicted from the mempool in the
+				// meantime.
+				c.mempoolIndex.add(txID, nil)
+				continue
+			}
+
+			return fmt.Errorf(
+				"failed to get raw transaction with ID [%s]: [%w]",
+				txID,
+				errs[i],
+			)
+		}
+
+		transaction, err := convertRawTransaction(rawTransactions[i])
+		if err != nil {
+			return fmt.Errorf("failed to convert transaction: [%w]", err)
+		}
+
+		scripts := make([]string, len(transaction.Outputs))
+		for j, output := range transaction.Outputs {
+			scripts[j] = string(output.PublicKeyScript)
+		}
+
+		c.mempoolIndex.add(txID, scripts)
+	}
+
+	return nil
+}
+
+// getMempoolTransactionsForScripts returns unconfirmed mempool transactions
+// having at least one output locked by one of the given scripts. The mempool
+// index is synced first so only matching transactions are fetched from the
+// node. Transactions evicted from the mempool in the meantime are skipped.
+func (c *Connection) getMempoolTransactionsForScripts(
+	scripts []bitcoin.Script,
+) ([]*bitcoin.Transaction, error) {
+	if err := c.syncMempoolIndex(); err != nil {
+		return nil, err
+	}
+
+	c.mempoolIndex.mutex.Lock()
+	matchingTxIDs := make(map[string]bool)
+	for _, script := range scripts {
+		for txID := range c.mempoolIndex.transactions[string(script)] {
+			matchingTxIDs[txID] = true
+		}
+	}
+	c.mempoolIndex.mutex.Unlock()
+
+	transactions := make([]*bitcoin.Transaction, 0, len(matchingTxIDs))
+	for txID := range matchingTxIDs {
+		rawTransaction, err := requestWithRetry[string](
+			c,
+			c.config.RequestTimeout,
+			"getrawtransaction",
+			txID,
+			false,
+		)
+		if err != nil {
+			if isNotFoundErr(err) {
+				continue
+			}
+
+			return nil, fmt.Errorf(
+				"failed to get raw transaction with ID [%s]: [%w]",
+				txID,
+				err,
+			)
+		}
+
+		transaction, err := convertRawTransaction(rawTransaction)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert transaction: [%w]", err)
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
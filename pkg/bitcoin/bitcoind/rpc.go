@@ -0,0 +1,361 @@
+package bitcoind
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/keep-network/keep-common/pkg/wrappers"
+)
+
+// Error codes returned by bitcoind JSON-RPC interface. For reference, see:
+// https://github.com/bitcoin/bitcoin/blob/master/src/rpc/protocol.h
+const (
+	// rpcInvalidAddressOrKeyErrorCode is returned when the requested
+	// transaction or block does not exist.
+	rpcInvalidAddressOrKeyErrorCode = -5
+	// rpcInWarmupErrorCode is returned when the node is still starting up
+	// and is not yet able to serve requests.
+	rpcInWarmupErrorCode = -28
+)
+
+// rpcRequest represents a bitcoind JSON-RPC request.
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// rpcResponse represents a bitcoind JSON-RPC response.
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+	ID     uint64          `json:"id"`
+}
+
+// rpcError represents an error returned by the bitcoind JSON-RPC interface.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (re *rpcError) Error() string {
+	return fmt.Sprintf("rpc error [code: [%d], message: [%s]]", re.Code, re.Message)
+}
+
+// isTransient determines whether the request that failed with the given
+// error is worth retrying. Errors returned by bitcoind are deterministic so
+// there is no point in retrying them, except the case when the node is
+// still warming up.
+func (re *rpcError) isTransient() bool {
+	return re.Code == rpcInWarmupErrorCode
+}
+
+// isNotFoundErr checks whether the given error denotes a transaction or block
+// that could not be found by bitcoind.
+func isNotFoundErr(err error) bool {
+	var re *rpcError
+	return errors.As(err, &re) && re.Code == rpcInvalidAddressOrKeyErrorCode
+}
+
+// call performs a single JSON-RPC request against the bitcoind node.
+func (c *Connection) call(
+	ctx context.Context,
+	method string,
+	params []interface{},
+) (json.RawMessage, error) {
+	body, err := json.Marshal(c.newRequest(method, params))
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal request: [%w]", err)
+	}
+
+	status, responseBody, err := c.post(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// bitcoind responds with non-2xx status codes when the request fails
+	// but still puts the error details into the JSON body, so we try to
+	// decode the body first and fall back to the status code only if the body
+	// is not a valid JSON-RPC response.
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(responseBody, &rpcResp); err != nil {
+		return nil, fmt.Errorf(
+			"unexpected response with status [%s]: [%w]",
+			statusText(status),
+			err,
+		)
+	}
+
+	if rpcResp.Error != nil {
+		return nil, rpcResp.Error
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf(
+			"unexpected response status [%s]",
+			statusText(status),
+		)
+	}
+
+	return rpcResp.Result, nil
+}
+
+// callBatch performs a batch of JSON-RPC requests of the same method in
+// a single round trip to the bitcoind node. Responses are returned in the
+// order of the given parameters. Errors of individual requests are set in
+// the respective responses; the returned error denotes a failure of the
+// whole batch.
+func (c *Connection) callBatch(
+	ctx context.Context,
+	method string,
+	paramsList [][]interface{},
+) ([]*rpcResponse, error) {
+	requests := make([]*rpcRequest, len(paramsList))
+	positions := make(map[uint64]int, len(paramsList))
+	for i, params := range paramsList {
+		requests[i] = c.newRequest(method, params)
+		positions[requests[i].ID] = i
+	}
+
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal batch request: [%w]", err)
+	}
+
+	status, responseBody, err := c.post(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var rpcResps []*rpcResponse
+	if err := json.Unmarshal(responseBody, &rpcResps); err != nil {
+		return nil, fmt.Errorf(
+			"unexpected batch response with status [%s]: [%w]",
+			statusText(status),
+			err,
+		)
+	}
+
+	// Responses to batch requests may come in any order so they are
+	// matched with the requests by their identifiers.
+	responses := make([]*rpcResponse, len(paramsList))
+	for _, rpcResp := range rpcResps {
+		position, ok := positions[rpcResp.ID]
+		if !ok {
+			return nil, fmt.Errorf(
+				"unexpected response with ID [%d] in batch",
+				rpcResp.ID,
+			)
+		}
+
+		responses[position] = rpcResp
+	}
+
+	for i, response := range responses {
+		if response == nil {
+			return nil, fmt.Errorf(
+				"missing response for request with ID [%d] in batch",
+				requests[i].ID,
+			)
+		}
+	}
+
+	return responses, nil
+}
+
+// newRequest creates a new JSON-RPC request with a unique identifier.
+func (c *Connection) newRequest(
+	method string,
+	params []interface{},
+) *rpcRequest {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	c.requestIDMutex.Lock()
+	c.requestID++
+	requestID := c.requestID
+	c.requestIDMutex.Unlock()
+
+	return &rpcRequest{
+		JSONRPC: "1.0",
+		ID:      requestID,
+		Method:  method,
+		Params:  params,
+	}
+}
+
+// post sends the given JSON-RPC request body to the bitcoind node and
+// returns the response status code and body.
+func (c *Connection) post(
+	ctx context.Context,
+	body []byte,
+) (int, []byte, error) {
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		c.config.URL,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return 0, nil, fmt.Errorf("cannot create request: [%w]", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	if len(c.config.Username) > 0 || len(c.config.Password) > 0 {
+		request.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return 0, nil, fmt.Errorf("cannot send request: [%w]", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("cannot read response: [%w]", err)
+	}
+
+	return response.StatusCode, responseBody, nil
+}
+
+// requestWithRetry performs the given JSON-RPC request and decodes its result.
+// The request is retried on transport errors and transient bitcoind errors
+// until the configured retry timeout is hit. The requestTimeout bounds
+// a single attempt.
+func requestWithRetry[K interface{}](
+	c *Connection,
+	requestTimeout time.Duration,
+	method string,
+	params ...interface{},
+) (K, error) {
+	startTime := time.Now()
+	logger.Debugf("starting [%s] request to bitcoind", method)
+
+	var result K
+	var finalErr error
+
+	err := wrappers.DoWithDefaultRetry(
+		c.parentCtx,
+		c.config.RequestRetryTimeout,
+		func(ctx context.Context) error {
+			requestCtx, requestCancel := context.WithTimeout(ctx, requestTimeout)
+			defer requestCancel()
+
+			rawResult, err := c.call(requestCtx, method, params)
+			if err != nil {
+				var re *rpcError
+				if errors.As(err, &re) && !re.isTransient() {
+					// The node gave a definitive answer. There is no point
+					// in retrying the request and losing time.
+					finalErr = err
+					return nil
+				}
+
+				return fmt.Errorf("request failed: [%w]", err)
+			}
+
+			var r K
+			if err := json.Unmarshal(rawResult, &r); err != nil {
+				finalErr = fmt.Errorf("cannot decode result: [%w]", err)
+				return nil
+			}
+
+			result = r
+			return nil
+		},
+	)
+	if err == nil {
+		err = finalErr
+	}
+
+	solveRequestOutcome := func(err error) string {
+		if err != nil {
+			return fmt.Sprintf("error: [%v]", err)
+		}
+		return "success"
+	}
+
+	logger.Debugf("[%s] request to bitcoind completed with [%s] after [%s]",
+		method,
+		solveRequestOutcome(err),
+		time.Since(startTime),
+	)
+
+	return result, err
+}
+
+// statusText formats the given HTTP status code the same way as the status
+// of an HTTP response, e.g. `200 OK`.
+func statusText(status int) string {
+	return fmt.Sprintf("%d %s", status, http.StatusText(status))
+}
+
+// batchRequestWithRetry performs a batch of JSON-RPC requests of the same
+// method and decodes their results. The whole batch is retried on transport
+// errors until the configured retry timeout is hit. Results and errors of
+// individual requests are returned in the order of the given parameters.
+func batchRequestWithRetry[K interface{}](
+	c *Connection,
+	requestTimeout time.Duration,
+	method string,
+	paramsList [][]interface{},
+) ([]K, []error, error) {
+	startTime := time.Now()
+	logger.Debugf(
+		"starting batch of [%d] [%s] requests to bitcoind",
+		len(paramsList),
+		method,
+	)
+
+	results := make([]K, len(paramsList))
+	errs := make([]error, len(paramsList))
+
+	err := wrappers.DoWithDefaultRetry(
+		c.parentCtx,
+		c.config.RequestRetryTimeout,
+		func(ctx context.Context) error {
+			requestCtx, requestCancel := context.WithTimeout(ctx, requestTimeout)
+			defer requestCancel()
+
+			responses, err := c.callBatch(requestCtx, method, paramsList)
+			if err != nil {
+				return fmt.Errorf("batch request failed: [%w]", err)
+			}
+
+			for i, response := range responses {
+				if response.Error != nil {
+					errs[i] = response.Error
+					continue
+				}
+
+				var r K
+				if err := json.Unmarshal(response.Result, &r); err != nil {
+					errs[i] = fmt.Errorf("cannot decode result: [%w]", err)
+					continue
+				}
+
+				results[i], errs[i] = r, nil
+			}
+
+			return nil
+		},
+	)
+
+	logger.Debugf(
+		"batch of [%d] [%s] requests to bitcoind completed after [%s]",
+		len(paramsList),
+		method,
+		time.Since(startTime),
+	)
+
+	return results, errs, err
+}
@@ -2,7 +2,9 @@ package bitcoin
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/btcsuite/btcd/blockchain"
 )
@@ -10,6 +12,10 @@ import (
 // BlockHeaderByteLength is the byte length of a serialized block header.
 const BlockHeaderByteLength = 80
 
+// medianTimePastBlocksCount is the number of the latest blocks whose median
+// time is the median time past of the chain.
+const medianTimePastBlocksCount = 11
+
 // BlockHeader represents the header of a Bitcoin block. For reference, see:
 // https://developer.bitcoin.org/reference/block_chain.html#block-headers
 type BlockHeader struct {
@@ -101,11 +107,8 @@ func (bh *BlockHeader) Deserialize(rawBlockHeader [BlockHeaderByteLength]byte) {
 // block header serialization format:
 // [Version][PreviousBlockHeaderHash][MerkleRootHash][Time][Bits][Nonce].
 func (bh *BlockHeader) Hash() Hash {
-	// TODO: Implementation of the Hash function that consists of the following:
-	//       1. Call bh.Serialize() to get the serialized block hash.
-	//       2. Compute the double SHA-256 over the serialized  block hash.
-	//       3. Construct the Hash instance appropriately.
-	return Hash{}
+	serializedBlockHeader := bh.Serialize()
+	return ComputeHash(serializedBlockHeader[:])
 }
 
 // Target calculates the difficulty target of a block header. A Bitcoin block
@@ -133,3 +136,39 @@ func (bh *BlockHeader) Difficulty() *big.Int {
 
 	return difficulty
 }
+
+// MedianTimePast returns the median time past of the given chain, that is,
+// the median of times of the last 11 blocks, ending with the chain tip. Since
+// BIP 113, Bitcoin nodes accept a transaction with a time-based locktime only
+// if the locktime is lower than the median time past.
+func MedianTimePast(chain Chain) (uint32, error) {
+	latestBlockHeight, err := chain.GetLatestBlockHeight()
+	if err != nil {
+		return 0, fmt.Errorf("cannot get latest block height: [%v]", err)
+	}
+
+	blocksCount := uint(medianTimePastBlocksCount)
+	if latestBlockHeight+1 < blocksCount {
+		blocksCount = latestBlockHeight + 1
+	}
+
+	times := make([]uint32, 0, blocksCount)
+	for height := latestBlockHeight + 1 - blocksCount; height <= latestBlockHeight; height++ {
+		blockHeader, err := chain.GetBlockHeader(height)
+		if err != nil {
+			return 0, fmt.Errorf(
+				"cannot get block header at height [%v]: [%v]",
+				height,
+				err,
+			)
+		}
+
+		times = append(times, blockHeader.Time)
+	}
+
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] < times[j]
+	})
+
+	return times[len(times)/2], nil
+}
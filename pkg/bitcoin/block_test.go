@@ -107,6 +107,44 @@ func TestBlockHeaderDeserialize(t *testing.T) {
 	}
 }
 
+func TestBlockHeaderHash(t *testing.T) {
+	// Test data comes from a Bitcoin testnet block:
+	// https://live.blockcypher.com/btc-testnet/block/000000000000002af10911b8db32ed34dc6ea6515f84af5f7b82973c9a839e6d/
+	previousBlockHeaderHash, err := NewHashFromString(
+		"000000000066450030efdf72f233ed2495547a32295deea1e2f3a16b1e50a3a5",
+		ReversedByteOrder,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merkleRootHash, err := NewHashFromString(
+		"1251774996b446f85462d5433f7a3e384ac1569072e617ab31e86da31c247de2",
+		ReversedByteOrder,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockHeader := BlockHeader{
+		Version:                 536870916,
+		PreviousBlockHeaderHash: previousBlockHeaderHash,
+		MerkleRootHash:          merkleRootHash,
+		Time:                    1641914003,
+		Bits:                    436256810,
+		Nonce:                   778087099,
+	}
+
+	actualHash := blockHeader.Hash()
+
+	testutils.AssertStringsEqual(
+		t,
+		"block header hash",
+		"000000000000002af10911b8db32ed34dc6ea6515f84af5f7b82973c9a839e6d",
+		actualHash.Hex(ReversedByteOrder),
+	)
+}
+
 func TestBlockHeaderTarget(t *testing.T) {
 	// Test data comes from a Bitcoin testnet block:
 	// https://live.blockcypher.com/btc-testnet/block/000000000000002af10911b8db32ed34dc6ea6515f84af5f7b82973c9a839e6d/
@@ -226,3 +264,49 @@ func TestBlockHeaderDifficulty_LowestDifficulty(t *testing.T) {
 		actualDifficulty,
 	)
 }
+
+func TestMedianTimePast(t *testing.T) {
+	var tests = map[string]struct {
+		blockTimes         map[uint]uint32
+		expectedMedianTime uint32
+	}{
+		"more blocks than the median time span": {
+			// Block times are not monotonic. The block at height 100 is
+			// out of the span.
+			blockTimes: map[uint]uint32{
+				100: 9999,
+				101: 1010, 102: 1020, 103: 1030, 104: 1040, 105: 1050,
+				106: 1005, 107: 1070, 108: 1080, 109: 1090, 110: 1100,
+				111: 1001,
+			},
+			expectedMedianTime: 1040,
+		},
+		"fewer blocks than the median time span": {
+			blockTimes: map[uint]uint32{
+				0: 1000, 1: 1030, 2: 1010,
+			},
+			expectedMedianTime: 1010,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			chain := newLocalChain()
+			for height, time := range test.blockTimes {
+				chain.blockHeaders[height] = &BlockHeader{Time: time}
+			}
+
+			medianTime, err := MedianTimePast(chain)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"median time past",
+				int(test.expectedMedianTime),
+				int(medianTime),
+			)
+		})
+	}
+}
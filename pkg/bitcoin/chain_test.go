@@ -248,3 +248,20 @@ func (lc *localChain) addBlockHeader(
 
 	return nil
 }
+
+func (lc *localChain) setBlockHeader(
+	blockNumber uint,
+	blockHeader *BlockHeader,
+) {
+	lc.blockHeadersMutex.Lock()
+	defer lc.blockHeadersMutex.Unlock()
+
+	lc.blockHeaders[blockNumber] = blockHeader
+}
+
+func (lc *localChain) removeBlockHeader(blockNumber uint) {
+	lc.blockHeadersMutex.Lock()
+	defer lc.blockHeadersMutex.Unlock()
+
+	delete(lc.blockHeaders, blockNumber)
+}
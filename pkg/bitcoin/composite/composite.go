@@ -0,0 +1,627 @@
+// Package composite provides a bitcoin.Chain implementation combining
+// multiple Bitcoin chain backends. Regular requests fail over between the
+// backends while security-critical requests require a configurable quorum
+// of backends to agree on the result.
+package composite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/ipfs/go-log"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+var logger = log.Logger("keep-bitcoin-composite")
+
+// Backend is a single Bitcoin chain backend combined by the composite chain.
+type Backend struct {
+	// Name identifies the backend in logs, e.g. the server URL.
+	Name string
+	// Chain is the backend's handle to the Bitcoin chain.
+	Chain bitcoin.Chain
+}
+
+// Chain is a bitcoin.Chain implementation that wraps multiple backends.
+type Chain struct {
+	backends []*Backend
+	quorum   int
+
+	preferredBackendMutex sync.Mutex
+	preferredBackend      int
+
+	metricsMutex    sync.Mutex
+	disagreements   uint64
+	backendFailures uint64
+}
+
+// NewChain creates a new composite chain wrapping the given backends.
+// The quorum determines how many backends must return the same result for
+// security-critical requests and must be between one and the number of
+// backends.
+func NewChain(backends []*Backend, quorum int) (*Chain, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("at least one backend is required")
+	}
+
+	if quorum < 1 || quorum > len(backends) {
+		return nil, fmt.Errorf(
+			"quorum must be between [1] and [%v]; got [%v]",
+			len(backends),
+			quorum,
+		)
+	}
+
+	return &Chain{
+		backends: backends,
+		quorum:   quorum,
+	}, nil
+}
+
+// DisagreementsCount returns the number of security-critical requests for
+// which the backends returned different results.
+func (c *Chain) DisagreementsCount() uint64 {
+	c.metricsMutex.Lock()
+	defer c.metricsMutex.Unlock()
+
+	return c.disagreements
+}
+
+// BackendFailuresCount returns the number of failed requests to individual
+// backends.
+func (c *Chain) BackendFailuresCount() uint64 {
+	c.metricsMutex.Lock()
+	defer c.metricsMutex.Unlock()
+
+	return c.backendFailures
+}
+
+func (c *Chain) recordDisagreement() {
+	c.metricsMutex.Lock()
+	defer c.metricsMutex.Unlock()
+
+	c.disagreements++
+}
+
+func (c *Chain) recordBackendFailure() {
+	c.metricsMutex.Lock()
+	defer c.metricsMutex.Unlock()
+
+	c.backendFailures++
+}
+
+// GetTransaction gets the transaction with the given transaction hash.
+// If the transaction with the given hash was not found on the chain,
+// this function returns an error. Transactions whose hash does not match
+// the requested one are rejected and the next backend is asked.
+func (c *Chain) GetTransaction(
+	transactionHash bitcoin.Hash,
+) (*bitcoin.Transaction, error) {
+	return withFailover(
+		c,
+		"GetTransaction",
+		func(chain bitcoin.Chain) (*bitcoin.Transaction, error) {
+			transaction, err := chain.GetTransaction(transactionHash)
+			if err != nil {
+				return nil, err
+			}
+
+			if transaction.Hash() != transactionHash {
+				return nil, fmt.Errorf(
+					"returned transaction has hash [%s] instead of [%s]",
+					transaction.Hash().Hex(bitcoin.ReversedByteOrder),
+					transactionHash.Hex(bitcoin.ReversedByteOrder),
+				)
+			}
+
+			return transaction, nil
+		},
+	)
+}
+
+// GetTransactionConfirmations gets the number of confirmations for the
+// transaction with the given transaction hash. If the transaction with the
+// given hash was not found on the chain, this function returns an error.
+//
+// Backends may be at slightly different chain tips so the result is the
+// highest number of confirmations reported by at least a quorum of backends.
+func (c *Chain) GetTransactionConfirmations(
+	transactionHash bitcoin.Hash,
+) (uint, error) {
+	results, err := fromAll(
+		c,
+		"GetTransactionConfirmations",
+		func(chain bitcoin.Chain) (uint, error) {
+			return chain.GetTransactionConfirmations(transactionHash)
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(results) < c.quorum {
+		return 0, fmt.Errorf(
+			"[GetTransactionConfirmations] request did not reach quorum of "+
+				"[%v] backends; only [%v] backends responded",
+			c.quorum,
+			len(results),
+		)
+	}
+
+	confirmations := make([]uint, 0, len(results))
+	for _, result := range results {
+		confirmations = append(confirmations, result.value)
+	}
+
+	sort.Slice(
+		confirmations,
+		func(i, j int) bool {
+			return confirmations[i] > confirmations[j]
+		},
+	)
+
+	if confirmations[0] != confirmations[len(confirmations)-1] {
+		c.recordDisagreement()
+		logger.Warnf(
+			"backends disagree on confirmations of transaction [%s]: [%s]",
+			transactionHash.Hex(bitcoin.ReversedByteOrder),
+			describeResults(results, func(value uint) string {
+				return fmt.Sprintf("%d", value)
+			}),
+		)
+	}
+
+	return confirmations[c.quorum-1], nil
+}
+
+// BroadcastTransaction broadcasts the given transaction over the
+// network of the Bitcoin chain nodes. If the broadcast action could not be
+// done, this function returns an error. This function does not give any
+// guarantees regarding transaction mining. The transaction may be mined or
+// rejected eventually.
+//
+// The transaction is broadcast through all backends to speed up its
+// propagation. The broadcast is considered successful if at least one
+// backend accepted the transaction.
+func (c *Chain) BroadcastTransaction(
+	transaction *bitcoin.Transaction,
+) error {
+	_, err := fromAll(
+		c,
+		"BroadcastTransaction",
+		func(chain bitcoin.Chain) (struct{}, error) {
+			return struct{}{}, chain.BroadcastTransaction(transaction)
+		},
+	)
+
+	return err
+}
+
+// GetLatestBlockHeight gets the height of the latest block (tip). If the
+// latest block was not determined, this function returns an error.
+func (c *Chain) GetLatestBlockHeight() (uint, error) {
+	return withFailover(
+		c,
+		"GetLatestBlockHeight",
+		func(chain bitcoin.Chain) (uint, error) {
+			return chain.GetLatestBlockHeight()
+		},
+	)
+}
+
+// GetBlockHeader gets the block header for the given block height. If the
+// block with the given height was not found on the chain, this function
+// returns an error. A quorum of backends must return the same header.
+func (c *Chain) GetBlockHeader(
+	blockHeight uint,
+) (*bitcoin.BlockHeader, error) {
+	return withQuorum(
+		c,
+		"GetBlockHeader",
+		func(chain bitcoin.Chain) (*bitcoin.BlockHeader, error) {
+			return chain.GetBlockHeader(blockHeight)
+		},
+		func(blockHeader *bitcoin.BlockHeader) string {
+			serialized := blockHeader.Serialize()
+			return fmt.Sprintf("%x", serialized[:])
+		},
+	)
+}
+
+// GetTransactionMerkleProof gets the Merkle proof for a given transaction.
+// The transaction's hash and the block the transaction was included in the
+// blockchain need to be provided. A quorum of backends must return the same
+// proof.
+func (c *Chain) GetTransactionMerkleProof(
+	transactionHash bitcoin.Hash,
+	blockHeight uint,
+) (*bitcoin.TransactionMerkleProof, error) {
+	return withQuorum(
+		c,
+		"GetTransactionMerkleProof",
+		func(chain bitcoin.Chain) (*bitcoin.TransactionMerkleProof, error) {
+			return chain.GetTransactionMerkleProof(transactionHash, blockHeight)
+		},
+		func(proof *bitcoin.TransactionMerkleProof) string {
+			return fmt.Sprintf(
+				"%d:%d:%s",
+				proof.BlockHeight,
+				proof.Position,
+				strings.ToLower(strings.Join(proof.MerkleNodes, ",")),
+			)
+		},
+	)
+}
+
+// GetTransactionsForPublicKeyHash gets confirmed transactions that pays the
+// given public key hash using either a P2PKH or P2WPKH script. The returned
+// transactions are ordered by block height in the ascending order, i.e.
+// the latest transaction is at the end of the list. The returned list does
+// not contain unconfirmed transactions living in the mempool at the moment
+// of request. The returned transactions list can be limited using the
+// `limit` parameter. For example, if `limit` is set to `5`, only the
+// latest five transactions will be returned. Note that taking an unlimited
+// transaction history may be time-consuming as this function fetches
+// complete transactions with all necessary data. A quorum of backends must
+// return the same set of transactions.
+func (c *Chain) GetTransactionsForPublicKeyHash(
+	publicKeyHash [20]byte,
+	limit int,
+) ([]*bitcoin.Transaction, error) {
+	return withQuorum(
+		c,
+		"GetTransactionsForPublicKeyHash",
+		func(chain bitcoin.Chain) ([]*bitcoin.Transaction, error) {
+			return chain.GetTransactionsForPublicKeyHash(publicKeyHash, limit)
+		},
+		transactionsSetKey,
+	)
+}
+
+// GetTxHashesForPublicKeyHash gets hashes of confirmed transactions that pays
+// the given public key hash using either a P2PKH or P2WPKH script. The returned
+// transactions hashes are ordered by block height in the ascending order, i.e.
+// the latest transaction hash is at the end of the list. The returned list does
+// not contain unconfirmed transactions hashes living in the mempool at the
+// moment of request.
+func (c *Chain) GetTxHashesForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]bitcoin.Hash, error) {
+	return withFailover(
+		c,
+		"GetTxHashesForPublicKeyHash",
+		func(chain bitcoin.Chain) ([]bitcoin.Hash, error) {
+			return chain.GetTxHashesForPublicKeyHash(publicKeyHash)
+		},
+	)
+}
+
+// GetMempoolForPublicKeyHash gets the unconfirmed mempool transactions
+// that pays the given public key hash using either a P2PKH or P2WPKH script.
+// The returned transactions are in an indefinite order. A quorum of backends
+// must return the same set of transactions.
+func (c *Chain) GetMempoolForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.Transaction, error) {
+	return withQuorum(
+		c,
+		"GetMempoolForPublicKeyHash",
+		func(chain bitcoin.Chain) ([]*bitcoin.Transaction, error) {
+			return chain.GetMempoolForPublicKeyHash(publicKeyHash)
+		},
+		transactionsSetKey,
+	)
+}
+
+// transactionsSetKey returns the key comparing the given transactions
+// returned by different backends. Backends may order transactions confirmed
+// in the same block, as well as mempool transactions, differently so the
+// set of transaction hashes is compared.
+func transactionsSetKey(transactions []*bitcoin.Transaction) string {
+	keys := make([]string, len(transactions))
+	for i, transaction := range transactions {
+		keys[i] = transaction.Hash().Hex(bitcoin.InternalByteOrder)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// GetUtxosForPublicKeyHash gets unspent outputs of confirmed transactions that
+// are controlled by the given public key hash (either a P2PKH or P2WPKH script).
+// The returned UTXOs are ordered by block height in the ascending order, i.e.
+// the latest UTXO is at the end of the list. The returned list does not contain
+// unspent outputs of unconfirmed transactions living in the mempool at the
+// moment of request. Outputs used as inputs of confirmed or mempool
+// transactions are not returned as well because they are no longer UTXOs.
+// A quorum of backends must return the same set of UTXOs.
+func (c *Chain) GetUtxosForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.UnspentTransactionOutput, error) {
+	return withQuorum(
+		c,
+		"GetUtxosForPublicKeyHash",
+		func(chain bitcoin.Chain) ([]*bitcoin.UnspentTransactionOutput, error) {
+			return chain.GetUtxosForPublicKeyHash(publicKeyHash)
+		},
+		func(utxos []*bitcoin.UnspentTransactionOutput) string {
+			// Backends may order UTXOs confirmed in the same block
+			// differently so the set of UTXOs is compared.
+			keys := make([]string, len(utxos))
+			for i, utxo := range utxos {
+				keys[i] = fmt.Sprintf(
+					"%s:%d:%d",
+					utxo.Outpoint.TransactionHash.Hex(bitcoin.InternalByteOrder),
+					utxo.Outpoint.OutputIndex,
+					utxo.Value,
+				)
+			}
+			sort.Strings(keys)
+			return strings.Join(keys, ",")
+		},
+	)
+}
+
+// GetMempoolUtxosForPublicKeyHash gets unspent outputs of unconfirmed transactions
+// that are controlled by the given public key hash (either a P2PKH or P2WPKH script).
+// The returned UTXOs are in an indefinite order. The returned list does not
+// contain unspent outputs of confirmed transactions. Outputs used as inputs of
+// confirmed or mempool transactions are not returned as well because they are
+// no longer UTXOs.
+func (c *Chain) GetMempoolUtxosForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.UnspentTransactionOutput, error) {
+	return withFailover(
+		c,
+		"GetMempoolUtxosForPublicKeyHash",
+		func(chain bitcoin.Chain) ([]*bitcoin.UnspentTransactionOutput, error) {
+			return chain.GetMempoolUtxosForPublicKeyHash(publicKeyHash)
+		},
+	)
+}
+
+// EstimateSatPerVByteFee returns the estimated sat/vbyte fee for a
+// transaction to be confirmed within the given number of blocks.
+func (c *Chain) EstimateSatPerVByteFee(blocks uint32) (int64, error) {
+	return withFailover(
+		c,
+		"EstimateSatPerVByteFee",
+		func(chain bitcoin.Chain) (int64, error) {
+			return chain.EstimateSatPerVByteFee(blocks)
+		},
+	)
+}
+
+// GetFeeHistogram returns a histogram of fee rates paid by transactions
+// living in the mempool. Keys of the returned map are fee rates expressed in
+// sat/vbyte and values are total virtual sizes of mempool transactions paying
+// the given fee rate. Only backends able to provide the histogram, e.g.
+// Electrum servers, are asked.
+func (c *Chain) GetFeeHistogram() (map[uint32]uint64, error) {
+	return withFailover(
+		c,
+		"GetFeeHistogram",
+		func(chain bitcoin.Chain) (map[uint32]uint64, error) {
+			histogramProvider, ok := chain.(interface {
+				GetFeeHistogram() (map[uint32]uint64, error)
+			})
+			if !ok {
+				return nil, fmt.Errorf("backend does not provide fee histogram")
+			}
+
+			return histogramProvider.GetFeeHistogram()
+		},
+	)
+}
+
+// GetCoinbaseTxHash gets the hash of the coinbase transaction for the given
+// block height.
+func (c *Chain) GetCoinbaseTxHash(blockHeight uint) (bitcoin.Hash, error) {
+	return withFailover(
+		c,
+		"GetCoinbaseTxHash",
+		func(chain bitcoin.Chain) (bitcoin.Hash, error) {
+			return chain.GetCoinbaseTxHash(blockHeight)
+		},
+	)
+}
+
+// withFailover executes the request against the preferred backend and
+// fails over to the next backends, in order, if the request fails. The
+// backend that served the request successfully becomes the preferred one.
+func withFailover[K interface{}](
+	c *Chain,
+	requestName string,
+	requestFn func(chain bitcoin.Chain) (K, error),
+) (K, error) {
+	c.preferredBackendMutex.Lock()
+	preferredBackend := c.preferredBackend
+	c.preferredBackendMutex.Unlock()
+
+	var result K
+	var errs *multierror.Error
+
+	for i := 0; i < len(c.backends); i++ {
+		index := (preferredBackend + i) % len(c.backends)
+		backend := c.backends[index]
+
+		r, err := requestFn(backend.Chain)
+		if err != nil {
+			c.recordBackendFailure()
+			logger.Warnf(
+				"[%s] request to backend [%s] failed: [%v]",
+				requestName,
+				backend.Name,
+				err,
+			)
+			errs = multierror.Append(
+				errs,
+				fmt.Errorf("backend [%s]: [%w]", backend.Name, err),
+			)
+			continue
+		}
+
+		if index != preferredBackend {
+			logger.Infof(
+				"failed over from backend [%s] to backend [%s]",
+				c.backends[preferredBackend].Name,
+				backend.Name,
+			)
+
+			c.preferredBackendMutex.Lock()
+			c.preferredBackend = index
+			c.preferredBackendMutex.Unlock()
+		}
+
+		return r, nil
+	}
+
+	return result, fmt.Errorf(
+		"[%s] request failed for all backends: [%w]",
+		requestName,
+		errs,
+	)
+}
+
+// backendResult is a successful result returned by a single backend.
+type backendResult[K interface{}] struct {
+	backend *Backend
+	value   K
+}
+
+// fromAll executes the request against all backends concurrently and
+// returns successful results in the order of backends. An error is returned
+// only if the request failed for all backends.
+func fromAll[K interface{}](
+	c *Chain,
+	requestName string,
+	requestFn func(chain bitcoin.Chain) (K, error),
+) ([]*backendResult[K], error) {
+	values := make([]K, len(c.backends))
+	errs := make([]error, len(c.backends))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(c.backends))
+
+	for i, backend := range c.backends {
+		go func(i int, backend *Backend) {
+			defer wg.Done()
+			values[i], errs[i] = requestFn(backend.Chain)
+		}(i, backend)
+	}
+
+	wg.Wait()
+
+	var resultErr *multierror.Error
+	results := make([]*backendResult[K], 0, len(c.backends))
+
+	for i, backend := range c.backends {
+		if errs[i] != nil {
+			c.recordBackendFailure()
+			logger.Warnf(
+				"[%s] request to backend [%s] failed: [%v]",
+				requestName,
+				backend.Name,
+				errs[i],
+			)
+			resultErr = multierror.Append(
+				resultErr,
+				fmt.Errorf("backend [%s]: [%w]", backend.Name, errs[i]),
+			)
+			continue
+		}
+
+		results = append(results, &backendResult[K]{backend, values[i]})
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf(
+			"[%s] request failed for all backends: [%w]",
+			requestName,
+			resultErr,
+		)
+	}
+
+	return results, nil
+}
+
+// withQuorum executes the request against all backends and returns the
+// result returned by the largest group of agreeing backends, provided the
+// group has at least a quorum of backends. If several groups are equally
+// large, the one including the backend configured first wins. Results are
+// compared using the keys produced by the keyFn. Disagreements between
+// backends are recorded and logged.
+func withQuorum[K interface{}](
+	c *Chain,
+	requestName string,
+	requestFn func(chain bitcoin.Chain) (K, error),
+	keyFn func(K) string,
+) (K, error) {
+	var zero K
+
+	results, err := fromAll(c, requestName, requestFn)
+	if err != nil {
+		return zero, err
+	}
+
+	// Results are ordered by the backend order so groups are ordered by
+	// their first backend as well, which makes the choice between equally
+	// large groups deterministic.
+	groups := make(map[string][]*backendResult[K])
+	keys := make([]string, 0)
+	for _, result := range results {
+		key := keyFn(result.value)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], result)
+	}
+
+	if len(groups) > 1 {
+		c.recordDisagreement()
+		logger.Warnf(
+			"backends disagree on [%s] request result: [%s]",
+			requestName,
+			describeResults(results, keyFn),
+		)
+	}
+
+	var largest []*backendResult[K]
+	for _, key := range keys {
+		if len(groups[key]) > len(largest) {
+			largest = groups[key]
+		}
+	}
+
+	if len(largest) >= c.quorum {
+		return largest[0].value, nil
+	}
+
+	return zero, fmt.Errorf(
+		"[%s] request did not reach quorum of [%v] backends; "+
+			"[%v] backends responded with [%v] distinct results",
+		requestName,
+		c.quorum,
+		len(results),
+		len(groups),
+	)
+}
+
+func describeResults[K interface{}](
+	results []*backendResult[K],
+	describeFn func(K) string,
+) string {
+	descriptions := make([]string, len(results))
+	for i, result := range results {
+		descriptions[i] = fmt.Sprintf(
+			"%s: %s",
+			result.backend.Name,
+			describeFn(result.value),
+		)
+	}
+
+	return strings.Join(descriptions, "; ")
+}
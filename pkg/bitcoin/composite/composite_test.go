@@ -0,0 +1,608 @@
+package composite
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+// localBackend is a bitcoin.Chain stub returning preconfigured results.
+type localBackend struct {
+	err error
+
+	transactions  map[bitcoin.Hash]*bitcoin.Transaction
+	confirmations uint
+	blockHeight   uint
+	blockHeader   *bitcoin.BlockHeader
+	merkleProof   *bitcoin.TransactionMerkleProof
+	utxos         []*bitcoin.UnspentTransactionOutput
+	history       []*bitcoin.Transaction
+	mempool       []*bitcoin.Transaction
+	fee           int64
+
+	broadcasts int
+	calls      int
+}
+
+func (lb *localBackend) GetTransaction(
+	transactionHash bitcoin.Hash,
+) (*bitcoin.Transaction, error) {
+	lb.calls++
+	if lb.err != nil {
+		return nil, lb.err
+	}
+
+	transaction, ok := lb.transactions[transactionHash]
+	if !ok {
+		return nil, fmt.Errorf("transaction not found")
+	}
+
+	return transaction, nil
+}
+
+func (lb *localBackend) GetTransactionConfirmations(
+	transactionHash bitcoin.Hash,
+) (uint, error) {
+	return lb.confirmations, lb.err
+}
+
+func (lb *localBackend) BroadcastTransaction(
+	transaction *bitcoin.Transaction,
+) error {
+	if lb.err != nil {
+		return lb.err
+	}
+
+	lb.broadcasts++
+	return nil
+}
+
+func (lb *localBackend) GetLatestBlockHeight() (uint, error) {
+	lb.calls++
+	return lb.blockHeight, lb.err
+}
+
+func (lb *localBackend) GetBlockHeader(
+	blockHeight uint,
+) (*bitcoin.BlockHeader, error) {
+	return lb.blockHeader, lb.err
+}
+
+func (lb *localBackend) GetTransactionMerkleProof(
+	transactionHash bitcoin.Hash,
+	blockHeight uint,
+) (*bitcoin.TransactionMerkleProof, error) {
+	return lb.merkleProof, lb.err
+}
+
+func (lb *localBackend) GetTransactionsForPublicKeyHash(
+	publicKeyHash [20]byte,
+	limit int,
+) ([]*bitcoin.Transaction, error) {
+	return lb.history, lb.err
+}
+
+func (lb *localBackend) GetTxHashesForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]bitcoin.Hash, error) {
+	panic("not implemented")
+}
+
+func (lb *localBackend) GetMempoolForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.Transaction, error) {
+	return lb.mempool, lb.err
+}
+
+func (lb *localBackend) GetUtxosForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.UnspentTransactionOutput, error) {
+	return lb.utxos, lb.err
+}
+
+func (lb *localBackend) GetMempoolUtxosForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.UnspentTransactionOutput, error) {
+	panic("not implemented")
+}
+
+func (lb *localBackend) EstimateSatPerVByteFee(blocks uint32) (int64, error) {
+	lb.calls++
+	return lb.fee, lb.err
+}
+
+func (lb *localBackend) GetCoinbaseTxHash(blockHeight uint) (bitcoin.Hash, error) {
+	panic("not implemented")
+}
+
+func newTestChain(t *testing.T, quorum int, backends ...*localBackend) *Chain {
+	compositeBackends := make([]*Backend, len(backends))
+	for i, backend := range backends {
+		compositeBackends[i] = &Backend{
+			Name:  fmt.Sprintf("backend-%d", i),
+			Chain: backend,
+		}
+	}
+
+	chain, err := NewChain(compositeBackends, quorum)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return chain
+}
+
+func TestNewChain_InvalidQuorum(t *testing.T) {
+	backends := []*Backend{
+		{Name: "first", Chain: &localBackend{}},
+		{Name: "second", Chain: &localBackend{}},
+	}
+
+	for _, quorum := range []int{0, 3} {
+		t.Run(fmt.Sprintf("quorum %d", quorum), func(t *testing.T) {
+			_, err := NewChain(backends, quorum)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	if _, err := NewChain(nil, 1); err == nil {
+		t.Fatal("expected error for no backends")
+	}
+}
+
+func TestFailover(t *testing.T) {
+	failing := &localBackend{err: fmt.Errorf("connection refused")}
+	healthy := &localBackend{fee: 12}
+
+	chain := newTestChain(t, 1, failing, healthy)
+
+	fee, err := chain.EstimateSatPerVByteFee(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "fee", 12, int(fee))
+	testutils.AssertUintsEqual(
+		t,
+		"backend failures",
+		1,
+		chain.BackendFailuresCount(),
+	)
+
+	// The healthy backend became the preferred one so the failing backend
+	// is not asked anymore.
+	_, err = chain.EstimateSatPerVByteFee(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "failing backend calls", 1, failing.calls)
+	testutils.AssertIntsEqual(t, "healthy backend calls", 2, healthy.calls)
+}
+
+func TestFailover_AllBackendsFail(t *testing.T) {
+	chain := newTestChain(
+		t,
+		1,
+		&localBackend{err: fmt.Errorf("connection refused")},
+		&localBackend{err: fmt.Errorf("timeout")},
+	)
+
+	_, err := chain.GetLatestBlockHeight()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertUintsEqual(
+		t,
+		"backend failures",
+		2,
+		chain.BackendFailuresCount(),
+	)
+}
+
+// histogramBackend is a localBackend able to provide the fee histogram.
+type histogramBackend struct {
+	*localBackend
+	histogram map[uint32]uint64
+}
+
+func (hb *histogramBackend) GetFeeHistogram() (map[uint32]uint64, error) {
+	return hb.histogram, nil
+}
+
+func TestGetFeeHistogram_SkipsBackendsWithoutHistogram(t *testing.T) {
+	withoutHistogram := &localBackend{}
+	withHistogram := &histogramBackend{
+		localBackend: &localBackend{},
+		histogram:    map[uint32]uint64{10: 1000},
+	}
+
+	chain, err := NewChain(
+		[]*Backend{
+			{Name: "without-histogram", Chain: withoutHistogram},
+			{Name: "with-histogram", Chain: withHistogram},
+		},
+		1,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	histogram, err := chain.GetFeeHistogram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(withHistogram.histogram, histogram) {
+		t.Errorf(
+			"unexpected histogram\nexpected: %v\nactual:   %v",
+			withHistogram.histogram,
+			histogram,
+		)
+	}
+}
+
+func TestGetTransaction_RejectsMismatchedTransaction(t *testing.T) {
+	requested := &bitcoin.Transaction{Version: 1, Locktime: 1}
+	forged := &bitcoin.Transaction{Version: 1, Locktime: 2}
+
+	lying := &localBackend{
+		transactions: map[bitcoin.Hash]*bitcoin.Transaction{
+			requested.Hash(): forged,
+		},
+	}
+	honest := &localBackend{
+		transactions: map[bitcoin.Hash]*bitcoin.Transaction{
+			requested.Hash(): requested,
+		},
+	}
+
+	chain := newTestChain(t, 1, lying, honest)
+
+	transaction, err := chain.GetTransaction(requested.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(requested, transaction) {
+		t.Errorf(
+			"unexpected transaction\nexpected: %+v\nactual:   %+v",
+			requested,
+			transaction,
+		)
+	}
+}
+
+func TestGetBlockHeader_Quorum(t *testing.T) {
+	header := &bitcoin.BlockHeader{Version: 1, Nonce: 100}
+	forgedHeader := &bitcoin.BlockHeader{Version: 1, Nonce: 200}
+
+	var tests = map[string]struct {
+		quorum                int
+		backends              []*localBackend
+		expectedHeader        *bitcoin.BlockHeader
+		expectedDisagreements uint64
+	}{
+		"all backends agree": {
+			quorum: 3,
+			backends: []*localBackend{
+				{blockHeader: header},
+				{blockHeader: header},
+				{blockHeader: header},
+			},
+			expectedHeader:        header,
+			expectedDisagreements: 0,
+		},
+		"quorum reached despite disagreement": {
+			quorum: 2,
+			backends: []*localBackend{
+				{blockHeader: forgedHeader},
+				{blockHeader: header},
+				{blockHeader: header},
+			},
+			expectedHeader:        header,
+			expectedDisagreements: 1,
+		},
+		"quorum reached despite failure": {
+			quorum: 2,
+			backends: []*localBackend{
+				{err: fmt.Errorf("timeout")},
+				{blockHeader: header},
+				{blockHeader: header},
+			},
+			expectedHeader:        header,
+			expectedDisagreements: 0,
+		},
+		"largest agreeing group wins": {
+			quorum: 1,
+			backends: []*localBackend{
+				{blockHeader: forgedHeader},
+				{blockHeader: header},
+				{blockHeader: header},
+			},
+			expectedHeader:        header,
+			expectedDisagreements: 1,
+		},
+		"tie broken by backend order": {
+			quorum: 1,
+			backends: []*localBackend{
+				{blockHeader: header},
+				{blockHeader: forgedHeader},
+				{err: fmt.Errorf("timeout")},
+			},
+			expectedHeader:        header,
+			expectedDisagreements: 1,
+		},
+		"quorum not reached due to disagreement": {
+			quorum: 2,
+			backends: []*localBackend{
+				{blockHeader: forgedHeader},
+				{blockHeader: header},
+				{err: fmt.Errorf("timeout")},
+			},
+			expectedHeader:        nil,
+			expectedDisagreements: 1,
+		},
+		"quorum not reached due to failures": {
+			quorum: 2,
+			backends: []*localBackend{
+				{err: fmt.Errorf("timeout")},
+				{blockHeader: header},
+				{err: fmt.Errorf("timeout")},
+			},
+			expectedHeader:        nil,
+			expectedDisagreements: 0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			chain := newTestChain(t, test.quorum, test.backends...)
+
+			blockHeader, err := chain.GetBlockHeader(100)
+
+			if test.expectedHeader == nil {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(test.expectedHeader, blockHeader) {
+					t.Errorf(
+						"unexpected header\nexpected: %+v\nactual:   %+v",
+						test.expectedHeader,
+						blockHeader,
+					)
+				}
+			}
+
+			testutils.AssertUintsEqual(
+				t,
+				"disagreements",
+				test.expectedDisagreements,
+				chain.DisagreementsCount(),
+			)
+		})
+	}
+}
+
+func TestGetTransactionMerkleProof_Quorum(t *testing.T) {
+	proof := &bitcoin.TransactionMerkleProof{
+		BlockHeight: 100,
+		MerkleNodes: []string{"aa", "bb"},
+		Position:    2,
+	}
+	forgedProof := &bitcoin.TransactionMerkleProof{
+		BlockHeight: 100,
+		MerkleNodes: []string{"aa", "cc"},
+		Position:    2,
+	}
+
+	chain := newTestChain(
+		t,
+		2,
+		&localBackend{merkleProof: forgedProof},
+		&localBackend{merkleProof: proof},
+	)
+
+	_, err := chain.GetTransactionMerkleProof(bitcoin.Hash{}, 100)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertUintsEqual(t, "disagreements", 1, chain.DisagreementsCount())
+}
+
+func TestGetUtxosForPublicKeyHash_IgnoresOrder(t *testing.T) {
+	utxo1 := &bitcoin.UnspentTransactionOutput{
+		Outpoint: &bitcoin.TransactionOutpoint{
+			TransactionHash: bitcoin.Hash{0x01},
+			OutputIndex:     0,
+		},
+		Value: 1000,
+	}
+	utxo2 := &bitcoin.UnspentTransactionOutput{
+		Outpoint: &bitcoin.TransactionOutpoint{
+			TransactionHash: bitcoin.Hash{0x02},
+			OutputIndex:     1,
+		},
+		Value: 2000,
+	}
+
+	chain := newTestChain(
+		t,
+		2,
+		&localBackend{utxos: []*bitcoin.UnspentTransactionOutput{utxo1, utxo2}},
+		&localBackend{utxos: []*bitcoin.UnspentTransactionOutput{utxo2, utxo1}},
+	)
+
+	utxos, err := chain.GetUtxosForPublicKeyHash([20]byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "UTXOs count", 2, len(utxos))
+	testutils.AssertUintsEqual(t, "disagreements", 0, chain.DisagreementsCount())
+}
+
+func TestGetTransactionsForPublicKeyHash_Quorum(t *testing.T) {
+	transaction1 := &bitcoin.Transaction{Version: 1, Locktime: 1}
+	transaction2 := &bitcoin.Transaction{Version: 1, Locktime: 2}
+	forgedTransaction := &bitcoin.Transaction{Version: 1, Locktime: 3}
+
+	chain := newTestChain(
+		t,
+		2,
+		&localBackend{history: []*bitcoin.Transaction{forgedTransaction}},
+		&localBackend{history: []*bitcoin.Transaction{transaction1, transaction2}},
+		&localBackend{history: []*bitcoin.Transaction{transaction2, transaction1}},
+	)
+
+	transactions, err := chain.GetTransactionsForPublicKeyHash([20]byte{}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(
+		[]*bitcoin.Transaction{transaction1, transaction2},
+		transactions,
+	) {
+		t.Errorf("unexpected transactions")
+	}
+
+	testutils.AssertUintsEqual(t, "disagreements", 1, chain.DisagreementsCount())
+}
+
+func TestGetMempoolForPublicKeyHash_Quorum(t *testing.T) {
+	transaction := &bitcoin.Transaction{Version: 1, Locktime: 1}
+
+	chain := newTestChain(
+		t,
+		2,
+		&localBackend{mempool: []*bitcoin.Transaction{}},
+		&localBackend{mempool: []*bitcoin.Transaction{transaction}},
+	)
+
+	_, err := chain.GetMempoolForPublicKeyHash([20]byte{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"[GetMempoolForPublicKeyHash] request did not reach quorum of [2] "+
+			"backends; [2] backends responded with [2] distinct results",
+		err.Error(),
+	)
+
+	testutils.AssertUintsEqual(t, "disagreements", 1, chain.DisagreementsCount())
+}
+
+func TestGetTransactionConfirmations_Quorum(t *testing.T) {
+	var tests = map[string]struct {
+		quorum                int
+		confirmations         []uint
+		expectedConfirmations uint
+		expectedDisagreements uint64
+	}{
+		"all backends agree": {
+			quorum:                2,
+			confirmations:         []uint{6, 6, 6},
+			expectedConfirmations: 6,
+			expectedDisagreements: 0,
+		},
+		"backend ahead": {
+			quorum:                2,
+			confirmations:         []uint{7, 6, 6},
+			expectedConfirmations: 6,
+			expectedDisagreements: 1,
+		},
+		"backend behind": {
+			quorum:                2,
+			confirmations:         []uint{7, 7, 6},
+			expectedConfirmations: 7,
+			expectedDisagreements: 1,
+		},
+		"backend inflating confirmations": {
+			quorum:                2,
+			confirmations:         []uint{100, 1, 0},
+			expectedConfirmations: 1,
+			expectedDisagreements: 1,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			backends := make([]*localBackend, len(test.confirmations))
+			for i, confirmations := range test.confirmations {
+				backends[i] = &localBackend{confirmations: confirmations}
+			}
+
+			chain := newTestChain(t, test.quorum, backends...)
+
+			confirmations, err := chain.GetTransactionConfirmations(bitcoin.Hash{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertUintsEqual(
+				t,
+				"confirmations",
+				uint64(test.expectedConfirmations),
+				uint64(confirmations),
+			)
+			testutils.AssertUintsEqual(
+				t,
+				"disagreements",
+				test.expectedDisagreements,
+				chain.DisagreementsCount(),
+			)
+		})
+	}
+}
+
+func TestBroadcastTransaction(t *testing.T) {
+	failing := &localBackend{err: fmt.Errorf("connection refused")}
+	healthy1 := &localBackend{}
+	healthy2 := &localBackend{}
+
+	chain := newTestChain(t, 1, failing, healthy1, healthy2)
+
+	err := chain.BroadcastTransaction(&bitcoin.Transaction{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "first healthy broadcasts", 1, healthy1.broadcasts)
+	testutils.AssertIntsEqual(t, "second healthy broadcasts", 1, healthy2.broadcasts)
+
+	chain = newTestChain(t, 1, failing)
+
+	err = chain.BroadcastTransaction(&bitcoin.Transaction{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDefaultQuorum(t *testing.T) {
+	expectedQuorums := map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 5: 3}
+
+	for backendsCount, expectedQuorum := range expectedQuorums {
+		testutils.AssertIntsEqual(
+			t,
+			fmt.Sprintf("quorum for %d backends", backendsCount),
+			expectedQuorum,
+			DefaultQuorum(backendsCount),
+		)
+	}
+}
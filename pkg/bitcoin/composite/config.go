@@ -0,0 +1,29 @@
+package composite
+
+// DefaultQuorum returns the default number of backends that must agree on
+// the result of a security-critical request, i.e. a strict majority of the
+// given number of backends. With a strict majority, backends that agree on
+// a result cannot be outvoted by the remaining ones.
+func DefaultQuorum(backendsCount int) int {
+	return backendsCount/2 + 1
+}
+
+// Config holds configurable properties.
+type Config struct {
+	// AdditionalElectrumURLs holds URLs of Electrum servers used as Bitcoin
+	// chain backends next to the primary backend, in format:
+	// `scheme://hostname:port`. The additional servers share connection
+	// settings with the primary Electrum server.
+	AdditionalElectrumURLs []string
+	// AdditionalBitcoindURLs holds URLs of bitcoind JSON-RPC endpoints used
+	// as Bitcoin chain backends next to the primary backend, in format:
+	// `scheme://hostname:port`. The additional nodes share connection
+	// settings, including credentials, with the primary bitcoind node.
+	AdditionalBitcoindURLs []string
+	// Quorum is the number of backends that must return the same result
+	// for security-critical requests: transaction confirmations, transaction
+	// Merkle proofs, block headers, as well as UTXOs, confirmed transactions
+	// and mempool transactions related to a public key hash. If not set,
+	// a strict majority of backends is required.
+	Quorum int
+}
@@ -994,6 +994,28 @@ func (c *Connection) EstimateSatPerVByteFee(blocks uint32) (int64, error) {
 	return convertBtcKbToSatVByte(btcPerKbFee), nil
 }
 
+// GetFeeHistogram returns a histogram of fee rates paid by transactions
+// living in the mempool of the Electrum server. Keys of the returned map are
+// fee rates expressed in sat/vbyte and values are total virtual sizes of
+// mempool transactions paying the given fee rate.
+func (c *Connection) GetFeeHistogram() (map[uint32]uint64, error) {
+	histogram, err := requestWithRetry(
+		c,
+		func(
+			ctx context.Context,
+			client *electrum.Client,
+		) (map[uint32]uint64, error) {
+			return client.GetFeeHistogram(ctx)
+		},
+		"GetFeeHistogram",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee histogram: [%v]", err)
+	}
+
+	return histogram, nil
+}
+
 func convertBtcKbToSatVByte(btcPerKbFee float32) int64 {
 	// To convert from BTC/KB to sat/vbyte, we need to multiply by 1e8/1e3.
 	satPerVByte := (1e8 / 1e3) * float64(btcPerKbFee)
@@ -201,6 +201,31 @@ func (tse *TransactionSizeEstimator) AddScriptHashOutputs(
 	return tse
 }
 
+// AddTaprootOutputs adds the provided count of P2TR outputs to the
+// estimation. If the estimator already errored out during previous actions,
+// this method does nothing.
+func (tse *TransactionSizeEstimator) AddTaprootOutputs(
+	count int,
+) *TransactionSizeEstimator {
+	if tse.err != nil {
+		return tse
+	}
+
+	scriptPlaceholder, err := PayToTaproot([32]byte{})
+	if err != nil {
+		tse.err = err
+		return tse
+	}
+
+	for i := 0; i < count; i++ {
+		tse.internal.AddTxOut(
+			wire.NewTxOut(0, scriptPlaceholder),
+		)
+	}
+
+	return tse
+}
+
 // VirtualSize returns the virtual size of the transaction whose shape was
 // provided to the estimator. If any errors occurred while building the
 // transaction shape, the first error will be returned.
@@ -218,14 +243,22 @@ func (tse *TransactionSizeEstimator) VirtualSize() (int64, error) {
 	return mempool.GetTxVirtualSize(btcutil.NewTx(tse.internal.MsgTx)), nil
 }
 
+// FeeRateSource is a source of fee rate estimations. Each Chain is a fee
+// rate source as well.
+type FeeRateSource interface {
+	// EstimateSatPerVByteFee returns the estimated sat/vbyte fee for a
+	// transaction to be confirmed within the given number of blocks.
+	EstimateSatPerVByteFee(blocks uint32) (int64, error)
+}
+
 // TransactionFeeEstimator is a component allowing to estimate the total fee
 // for the given transaction virtual size.
 type TransactionFeeEstimator struct {
-	chain Chain
+	source FeeRateSource
 }
 
-func NewTransactionFeeEstimator(chain Chain) *TransactionFeeEstimator {
-	return &TransactionFeeEstimator{chain: chain}
+func NewTransactionFeeEstimator(source FeeRateSource) *TransactionFeeEstimator {
+	return &TransactionFeeEstimator{source: source}
 }
 
 // EstimateFee estimates the total fee for the given transaction virtual size,
@@ -242,7 +275,7 @@ func (tfe *TransactionFeeEstimator) EstimateFee(
 		resolvedBlocks = blocks[0]
 	}
 
-	satPerVByteFee, err := tfe.chain.EstimateSatPerVByteFee(resolvedBlocks)
+	satPerVByteFee, err := tfe.source.EstimateSatPerVByteFee(resolvedBlocks)
 	if err != nil {
 		return 0, fmt.Errorf("cannot get estimated sat/vbyte fee: [%v]", err)
 	}
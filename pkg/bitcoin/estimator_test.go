@@ -63,6 +63,13 @@ func TestTransactionSizeEstimator_VirtualSize(t *testing.T) {
 				AddScriptHashOutputs(1, true),
 			expectedVirtualSize: 250,
 		},
+		"1 P2WPKH input and 2 outputs (1 P2WPKH, 1 P2TR)": {
+			estimator: NewTransactionSizeEstimator().
+				AddPublicKeyHashInputs(1, true).
+				AddPublicKeyHashOutputs(1, true).
+				AddTaprootOutputs(1),
+			expectedVirtualSize: 153,
+		},
 	}
 
 	for testName, test := range tests {
@@ -0,0 +1,22 @@
+package fee
+
+import "time"
+
+// DefaultHistoryWindow is a default time window of the fee rate estimations
+// history used to smooth out spikes.
+const DefaultHistoryWindow = 30 * time.Minute
+
+// Config holds configurable properties.
+type Config struct {
+	// MinSatPerVByte is the floor of estimated fee rates, in sat/vbyte.
+	// The floor is not applied if the value is zero.
+	MinSatPerVByte int64
+	// MaxSatPerVByte is the ceiling of estimated fee rates, in sat/vbyte.
+	// The ceiling is not applied if the value is zero.
+	MaxSatPerVByte int64
+	// HistoryWindow is the time window of the fee rate estimations history.
+	// The returned estimation is the median of estimations made within this
+	// window so short-living spikes are smoothed out. The history is not used
+	// if the value is zero.
+	HistoryWindow time.Duration
+}
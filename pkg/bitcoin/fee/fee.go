@@ -0,0 +1,200 @@
+// Package fee provides a fee rate estimator combining multiple fee rate
+// sources, e.g. Bitcoin node estimations and mempool fee histograms. The
+// estimator smooths out spikes using a rolling history of estimations and
+// applies a static floor and ceiling policy.
+package fee
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/ipfs/go-log"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+var logger = log.Logger("keep-bitcoin-fee")
+
+// Source is a single fee rate source combined by the estimator.
+type Source struct {
+	// Name identifies the source in logs.
+	Name string
+	// FeeRateSource is the source's handle to fee rate estimations.
+	FeeRateSource bitcoin.FeeRateSource
+}
+
+// estimation is a fee rate estimation kept in the estimator's history.
+type estimation struct {
+	satPerVByteFee int64
+	madeAt         time.Time
+}
+
+// Estimator is a bitcoin.FeeRateSource implementation combining multiple
+// fee rate sources. Each estimation is the median of estimations returned
+// by sources that responded successfully, smoothed out using the history of
+// estimations made within the configured window, and bounded by the
+// configured floor and ceiling. All functions of the estimator are safe for
+// concurrent use.
+type Estimator struct {
+	sources []*Source
+	config  Config
+
+	historyMutex sync.Mutex
+	// history holds estimations made for specific confirmation targets,
+	// sorted by the estimation time in the ascending order.
+	history map[uint32][]*estimation
+
+	timeNow func() time.Time
+}
+
+// NewEstimator creates a new fee rate estimator combining the given sources.
+func NewEstimator(sources []*Source, config Config) (*Estimator, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("at least one fee rate source is required")
+	}
+
+	if config.MinSatPerVByte < 0 || config.MaxSatPerVByte < 0 {
+		return nil, fmt.Errorf("fee rate floor and ceiling cannot be negative")
+	}
+
+	if config.MaxSatPerVByte > 0 && config.MinSatPerVByte > config.MaxSatPerVByte {
+		return nil, fmt.Errorf(
+			"fee rate floor [%v] is greater than the ceiling [%v]",
+			config.MinSatPerVByte,
+			config.MaxSatPerVByte,
+		)
+	}
+
+	return &Estimator{
+		sources: sources,
+		config:  config,
+		history: make(map[uint32][]*estimation),
+		timeNow: time.Now,
+	}, nil
+}
+
+// EstimateSatPerVByteFee returns the estimated sat/vbyte fee for a
+// transaction to be confirmed within the given number of blocks. If all
+// sources fail, the estimation is based on the history only. An error is
+// returned if the history is empty as well.
+func (e *Estimator) EstimateSatPerVByteFee(blocks uint32) (int64, error) {
+	var estimations []int64
+	var errs *multierror.Error
+
+	for _, source := range e.sources {
+		satPerVByteFee, err := source.FeeRateSource.EstimateSatPerVByteFee(blocks)
+		if err == nil && satPerVByteFee <= 0 {
+			err = fmt.Errorf("non-positive estimation [%v]", satPerVByteFee)
+		}
+		if err != nil {
+			logger.Warnf(
+				"cannot get fee rate estimation from source [%s]: [%v]",
+				source.Name,
+				err,
+			)
+			errs = multierror.Append(
+				errs,
+				fmt.Errorf("source [%s]: [%w]", source.Name, err),
+			)
+			continue
+		}
+
+		estimations = append(estimations, satPerVByteFee)
+	}
+
+	satPerVByteFee, ok := e.smooth(blocks, estimations)
+	if !ok {
+		return 0, fmt.Errorf(
+			"cannot get fee rate estimation from any source: [%w]",
+			errs,
+		)
+	}
+
+	if len(estimations) == 0 {
+		logger.Warnf(
+			"all fee rate sources failed; using estimation [%v] sat/vbyte "+
+				"based on the history",
+			satPerVByteFee,
+		)
+	}
+
+	return e.applyPolicy(satPerVByteFee), nil
+}
+
+// smooth records the median of the given current estimations in the history
+// of the given confirmation target and returns the median of all estimations
+// kept in the history window. If the history is not used, the median of the
+// current estimations is returned. The returned boolean flag is false if
+// there are no estimations to compute the median from.
+func (e *Estimator) smooth(blocks uint32, estimations []int64) (int64, bool) {
+	if e.config.HistoryWindow <= 0 {
+		if len(estimations) == 0 {
+			return 0, false
+		}
+
+		return median(estimations), true
+	}
+
+	e.historyMutex.Lock()
+	defer e.historyMutex.Unlock()
+
+	now := e.timeNow()
+
+	history := e.history[blocks]
+	if len(estimations) > 0 {
+		history = append(history, &estimation{
+			satPerVByteFee: median(estimations),
+			madeAt:         now,
+		})
+	}
+
+	// The history is sorted by the estimation time so it is enough to
+	// find the first estimation made within the window.
+	firstIndex := sort.Search(len(history), func(i int) bool {
+		return now.Sub(history[i].madeAt) <= e.config.HistoryWindow
+	})
+	history = history[firstIndex:]
+
+	e.history[blocks] = history
+
+	if len(history) == 0 {
+		return 0, false
+	}
+
+	values := make([]int64, len(history))
+	for i, entry := range history {
+		values[i] = entry.satPerVByteFee
+	}
+
+	return median(values), true
+}
+
+// applyPolicy bounds the given fee rate by the configured floor and ceiling.
+func (e *Estimator) applyPolicy(satPerVByteFee int64) int64 {
+	if e.config.MinSatPerVByte > 0 && satPerVByteFee < e.config.MinSatPerVByte {
+		return e.config.MinSatPerVByte
+	}
+
+	if e.config.MaxSatPerVByte > 0 && satPerVByteFee > e.config.MaxSatPerVByte {
+		return e.config.MaxSatPerVByte
+	}
+
+	return satPerVByteFee
+}
+
+// median returns the median of the given non-empty values. For an even
+// count of values, the higher of the two middle values is returned so the
+// estimation is rather overpaid than underpaid.
+func median(values []int64) int64 {
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted[len(sorted)/2]
+}
@@ -0,0 +1,235 @@
+package fee
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+type localFeeRateSource struct {
+	satPerVByteFee int64
+	err            error
+}
+
+func (lfrs *localFeeRateSource) EstimateSatPerVByteFee(blocks uint32) (int64, error) {
+	if lfrs.err != nil {
+		return 0, lfrs.err
+	}
+
+	return lfrs.satPerVByteFee * int64(blocks), nil
+}
+
+func TestNewEstimator_InvalidConfig(t *testing.T) {
+	sources := []*Source{{Name: "source", FeeRateSource: &localFeeRateSource{}}}
+
+	var tests = map[string]struct {
+		sources     []*Source
+		config      Config
+		expectedErr error
+	}{
+		"no sources": {
+			sources:     []*Source{},
+			expectedErr: fmt.Errorf("at least one fee rate source is required"),
+		},
+		"negative floor": {
+			sources:     sources,
+			config:      Config{MinSatPerVByte: -1},
+			expectedErr: fmt.Errorf("fee rate floor and ceiling cannot be negative"),
+		},
+		"floor greater than ceiling": {
+			sources:     sources,
+			config:      Config{MinSatPerVByte: 10, MaxSatPerVByte: 5},
+			expectedErr: fmt.Errorf("fee rate floor [10] is greater than the ceiling [5]"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := NewEstimator(test.sources, test.config)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"error",
+				test.expectedErr.Error(),
+				err.Error(),
+			)
+		})
+	}
+}
+
+func TestEstimator_EstimateSatPerVByteFee(t *testing.T) {
+	var tests = map[string]struct {
+		sources             []*localFeeRateSource
+		config              Config
+		blocks              uint32
+		expectedEstimation  int64
+		expectedErrorPrefix string
+	}{
+		"single source": {
+			sources:            []*localFeeRateSource{{satPerVByteFee: 20}},
+			blocks:             1,
+			expectedEstimation: 20,
+		},
+		"confirmation target passed to sources": {
+			sources:            []*localFeeRateSource{{satPerVByteFee: 20}},
+			blocks:             3,
+			expectedEstimation: 60,
+		},
+		"median of odd sources count": {
+			sources: []*localFeeRateSource{
+				{satPerVByteFee: 100},
+				{satPerVByteFee: 10},
+				{satPerVByteFee: 20},
+			},
+			blocks:             1,
+			expectedEstimation: 20,
+		},
+		"higher middle value for even sources count": {
+			sources: []*localFeeRateSource{
+				{satPerVByteFee: 10},
+				{satPerVByteFee: 20},
+			},
+			blocks:             1,
+			expectedEstimation: 20,
+		},
+		"failing sources ignored": {
+			sources: []*localFeeRateSource{
+				{err: fmt.Errorf("unavailable")},
+				{satPerVByteFee: 15},
+				{satPerVByteFee: -1},
+			},
+			blocks:             1,
+			expectedEstimation: 15,
+		},
+		"floor applied": {
+			sources:            []*localFeeRateSource{{satPerVByteFee: 2}},
+			config:             Config{MinSatPerVByte: 5},
+			blocks:             1,
+			expectedEstimation: 5,
+		},
+		"ceiling applied": {
+			sources:            []*localFeeRateSource{{satPerVByteFee: 500}},
+			config:             Config{MaxSatPerVByte: 200},
+			blocks:             1,
+			expectedEstimation: 200,
+		},
+		"all sources failing": {
+			sources: []*localFeeRateSource{
+				{err: fmt.Errorf("unavailable")},
+			},
+			blocks:              1,
+			expectedErrorPrefix: "cannot get fee rate estimation from any source",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			sources := make([]*Source, len(test.sources))
+			for i, source := range test.sources {
+				sources[i] = &Source{
+					Name:          fmt.Sprintf("source-%v", i),
+					FeeRateSource: source,
+				}
+			}
+
+			estimator, err := NewEstimator(sources, test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			estimation, err := estimator.EstimateSatPerVByteFee(test.blocks)
+
+			if test.expectedErrorPrefix != "" {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				testutils.AssertStringsEqual(
+					t,
+					"error prefix",
+					test.expectedErrorPrefix,
+					err.Error()[:len(test.expectedErrorPrefix)],
+				)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"estimation",
+				int(test.expectedEstimation),
+				int(estimation),
+			)
+		})
+	}
+}
+
+func TestEstimator_History(t *testing.T) {
+	source := &localFeeRateSource{}
+
+	estimator, err := NewEstimator(
+		[]*Source{{Name: "source", FeeRateSource: source}},
+		Config{HistoryWindow: 30 * time.Minute},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	estimator.timeNow = func() time.Time {
+		return now
+	}
+
+	estimate := func(satPerVByteFee int64, err error) int64 {
+		source.satPerVByteFee = satPerVByteFee
+		source.err = err
+
+		estimation, err := estimator.EstimateSatPerVByteFee(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		now = now.Add(10 * time.Minute)
+
+		return estimation
+	}
+
+	testutils.AssertIntsEqual(t, "first estimation", 10, int(estimate(10, nil)))
+	testutils.AssertIntsEqual(t, "second estimation", 12, int(estimate(12, nil)))
+	// The spike is smoothed out by the median of the history.
+	testutils.AssertIntsEqual(t, "spike estimation", 12, int(estimate(300, nil)))
+	// The history is used if the source fails.
+	testutils.AssertIntsEqual(
+		t,
+		"failed source estimation",
+		12,
+		int(estimate(0, fmt.Errorf("unavailable"))),
+	)
+	// The first estimation is outside the window now.
+	testutils.AssertIntsEqual(t, "later estimation", 14, int(estimate(14, nil)))
+
+	// Once the window passes, the history is empty and failing sources
+	// cannot be compensated anymore.
+	now = now.Add(time.Hour)
+	source.err = fmt.Errorf("unavailable")
+	if _, err := estimator.EstimateSatPerVByteFee(1); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Histories of different confirmation targets are independent.
+	source.err = nil
+	source.satPerVByteFee = 5
+	estimation, err := estimator.EstimateSatPerVByteFee(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutils.AssertIntsEqual(t, "other target estimation", 10, int(estimation))
+}
@@ -0,0 +1,85 @@
+package fee
+
+import (
+	"fmt"
+	"sort"
+)
+
+// blockVirtualSize is the maximum virtual size of a Bitcoin block.
+const blockVirtualSize = 1_000_000
+
+// minSatPerVByteFee is the minimum fee rate accepted by Bitcoin nodes with
+// the default relay policy.
+const minSatPerVByteFee = 1
+
+// HistogramProvider is a provider of the mempool fee histogram, e.g. an
+// Electrum server.
+type HistogramProvider interface {
+	// GetFeeHistogram returns a histogram of fee rates paid by transactions
+	// living in the mempool. Keys of the returned map are fee rates expressed
+	// in sat/vbyte and values are total virtual sizes of mempool transactions
+	// paying the given fee rate.
+	GetFeeHistogram() (map[uint32]uint64, error)
+}
+
+// HistogramSource is a fee rate source basing on the mempool fee histogram.
+// Unlike node-side estimations relying on the history of confirmed blocks,
+// the histogram reflects the current state of the mempool.
+type HistogramSource struct {
+	provider HistogramProvider
+}
+
+// NewHistogramSource creates a new fee rate source using the given mempool
+// fee histogram provider.
+func NewHistogramSource(provider HistogramProvider) *HistogramSource {
+	return &HistogramSource{provider: provider}
+}
+
+// EstimateSatPerVByteFee returns the estimated sat/vbyte fee for a
+// transaction to be confirmed within the given number of blocks. The
+// estimation is the lowest fee rate that still places the transaction
+// within the first `blocks` blocks worth of mempool transactions ordered
+// by the fee rate. It assumes no new transactions enter the mempool.
+func (hs *HistogramSource) EstimateSatPerVByteFee(blocks uint32) (int64, error) {
+	if blocks == 0 {
+		return 0, fmt.Errorf("blocks count must be greater than zero")
+	}
+
+	histogram, err := hs.provider.GetFeeHistogram()
+	if err != nil {
+		return 0, fmt.Errorf("cannot get fee histogram: [%v]", err)
+	}
+
+	return estimateFromHistogram(histogram, blocks), nil
+}
+
+func estimateFromHistogram(histogram map[uint32]uint64, blocks uint32) int64 {
+	feeRates := make([]uint32, 0, len(histogram))
+	for feeRate := range histogram {
+		feeRates = append(feeRates, feeRate)
+	}
+
+	// Miners pick transactions paying the highest fee rates first.
+	sort.Slice(feeRates, func(i, j int) bool {
+		return feeRates[i] > feeRates[j]
+	})
+
+	targetVirtualSize := uint64(blocks) * blockVirtualSize
+	cumulativeVirtualSize := uint64(0)
+
+	for _, feeRate := range feeRates {
+		cumulativeVirtualSize += histogram[feeRate]
+
+		if cumulativeVirtualSize >= targetVirtualSize {
+			if feeRate < minSatPerVByteFee {
+				return minSatPerVByteFee
+			}
+
+			return int64(feeRate)
+		}
+	}
+
+	// The mempool is shallower than the target so any transaction paying
+	// the minimum fee rate should be confirmed in time.
+	return minSatPerVByteFee
+}
@@ -0,0 +1,115 @@
+package fee
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+type localHistogramProvider struct {
+	histogram map[uint32]uint64
+	err       error
+}
+
+func (lhp *localHistogramProvider) GetFeeHistogram() (map[uint32]uint64, error) {
+	return lhp.histogram, lhp.err
+}
+
+func TestHistogramSource_EstimateSatPerVByteFee(t *testing.T) {
+	histogram := map[uint32]uint64{
+		100: 200_000,
+		50:  500_000,
+		20:  400_000,
+		10:  1_000_000,
+		2:   300_000,
+	}
+
+	var tests = map[string]struct {
+		histogram          map[uint32]uint64
+		blocks             uint32
+		expectedEstimation int64
+	}{
+		"next block": {
+			histogram:          histogram,
+			blocks:             1,
+			expectedEstimation: 20,
+		},
+		"two blocks": {
+			histogram:          histogram,
+			blocks:             2,
+			expectedEstimation: 10,
+		},
+		"mempool shallower than the target": {
+			histogram:          histogram,
+			blocks:             3,
+			expectedEstimation: 1,
+		},
+		"empty mempool": {
+			histogram:          map[uint32]uint64{},
+			blocks:             1,
+			expectedEstimation: 1,
+		},
+		"full block of zero fee rate transactions": {
+			histogram:          map[uint32]uint64{0: 2_000_000},
+			blocks:             1,
+			expectedEstimation: 1,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			source := NewHistogramSource(
+				&localHistogramProvider{histogram: test.histogram},
+			)
+
+			estimation, err := source.EstimateSatPerVByteFee(test.blocks)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"estimation",
+				int(test.expectedEstimation),
+				int(estimation),
+			)
+		})
+	}
+}
+
+func TestHistogramSource_EstimateSatPerVByteFee_Errors(t *testing.T) {
+	source := NewHistogramSource(
+		&localHistogramProvider{err: fmt.Errorf("unavailable")},
+	)
+
+	var tests = map[string]struct {
+		blocks      uint32
+		expectedErr error
+	}{
+		"provider failure": {
+			blocks:      1,
+			expectedErr: fmt.Errorf("cannot get fee histogram: [unavailable]"),
+		},
+		"zero blocks": {
+			blocks:      0,
+			expectedErr: fmt.Errorf("blocks count must be greater than zero"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := source.EstimateSatPerVByteFee(test.blocks)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"error",
+				test.expectedErr.Error(),
+				err.Error(),
+			)
+		})
+	}
+}
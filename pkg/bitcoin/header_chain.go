@@ -0,0 +1,764 @@
+package bitcoin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/keep-network/keep-common/pkg/persistence"
+	"github.com/keep-network/keep-core/pkg/internal/byteutils"
+)
+
+// headerChainDirectory is the name of the persistence directory holding
+// the verified block headers.
+const headerChainDirectory = "headers"
+
+// medianTimeBlocks is the number of previous block headers used to calculate
+// the median time past a new block header must be greater than.
+const medianTimeBlocks = 11
+
+const (
+	// DefaultHeaderChainSyncInterval is the default interval at which the
+	// local header chain is synced with the underlying chain.
+	DefaultHeaderChainSyncInterval = 1 * time.Minute
+	// headerSyncBatchSize is the number of block headers fetched from the
+	// underlying chain before they are verified and added to the local
+	// header chain.
+	headerSyncBatchSize = 500
+	// headerSyncConcurrency is the maximum number of concurrent requests
+	// fetching block headers of a single batch.
+	headerSyncConcurrency = 10
+)
+
+// HeaderChainConfig holds configurable properties of the local header chain.
+type HeaderChainConfig struct {
+	// CheckpointHeight is the height of the trusted block the local header
+	// chain starts from. It must be the first block of a difficulty epoch
+	// so the following difficulty retargets can be verified.
+	CheckpointHeight uint
+	// CheckpointHash is the hash of the trusted block the local header chain
+	// starts from, in the reversed byte order used by block explorers.
+	// Local header chain verification is disabled if the hash is not set.
+	CheckpointHash string
+}
+
+// IsEnabled determines whether the local header chain verification is
+// enabled.
+func (hcc *HeaderChainConfig) IsEnabled() bool {
+	return len(hcc.CheckpointHash) > 0
+}
+
+// HeaderChain is a light client keeping a local chain of verified Bitcoin
+// block headers. The headers are synced from the underlying Bitcoin chain
+// starting from a trusted checkpoint. Each header is checked for the
+// linkage with the previous header, sufficient proof of work, correct
+// difficulty target and timestamp. The local chain follows the branch with
+// the most cumulative work. HeaderChain implements the Chain interface and
+// serves block headers only from the local verified chain. All other calls
+// are delegated to the underlying chain. The local chain is synced in the
+// background, see Observe, so reads never wait for the sync.
+type HeaderChain struct {
+	Chain
+
+	params *chaincfg.Params
+	// noRetargeting determines whether the network keeps the difficulty
+	// unchanged across epochs, as regtest does.
+	noRetargeting bool
+	persistence   persistence.BasicHandle
+	// syncBatchSize is the number of block headers fetched from the
+	// underlying chain in a single batch.
+	syncBatchSize uint
+
+	// syncMutex serializes syncs. Headers are modified only by the sync,
+	// with both syncMutex and mutex held, so the sync can read them
+	// holding syncMutex only.
+	syncMutex sync.Mutex
+
+	mutex            sync.Mutex
+	checkpointHeight uint
+	// headers holds the verified headers; the first header is the header
+	// of the checkpoint block.
+	headers []*BlockHeader
+	// caughtUp determines whether the local header chain caught up with
+	// the underlying chain at least once.
+	caughtUp bool
+}
+
+// NewHeaderChain creates a new HeaderChain on top of the given chain for the
+// given Bitcoin network. Headers verified in the past are loaded from the
+// given persistence handle. If there are no such headers, the local header
+// chain starts from the configured checkpoint.
+func NewHeaderChain(
+	chain Chain,
+	network Network,
+	persistence persistence.BasicHandle,
+	config HeaderChainConfig,
+) (*HeaderChain, error) {
+	params, err := network.params()
+	if err != nil {
+		return nil, err
+	}
+
+	// Regtest keeps the difficulty unchanged across epochs.
+	noRetargeting := network == Regtest
+
+	return newHeaderChain(chain, params, noRetargeting, persistence, config)
+}
+
+func newHeaderChain(
+	chain Chain,
+	params *chaincfg.Params,
+	noRetargeting bool,
+	persistence persistence.BasicHandle,
+	config HeaderChainConfig,
+) (*HeaderChain, error) {
+	checkpointHash, err := NewHashFromString(
+		config.CheckpointHash,
+		ReversedByteOrder,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint hash: [%w]", err)
+	}
+
+	hc := &HeaderChain{
+		Chain:            chain,
+		params:           params,
+		noRetargeting:    noRetargeting,
+		persistence:      persistence,
+		syncBatchSize:    headerSyncBatchSize,
+		checkpointHeight: config.CheckpointHeight,
+	}
+
+	if config.CheckpointHeight%hc.epochLength() != 0 {
+		return nil, fmt.Errorf(
+			"checkpoint height [%v] is not a difficulty epoch start",
+			config.CheckpointHeight,
+		)
+	}
+
+	hc.load(checkpointHash)
+
+	if len(hc.headers) == 0 {
+		checkpointHeader, err := chain.GetBlockHeader(config.CheckpointHeight)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get checkpoint block header: [%w]",
+				err,
+			)
+		}
+
+		if checkpointHeader.Hash() != checkpointHash {
+			return nil, fmt.Errorf(
+				"checkpoint block header at height [%v] has hash [%s] "+
+					"instead of the configured [%s]",
+				config.CheckpointHeight,
+				checkpointHeader.Hash().Hex(ReversedByteOrder),
+				config.CheckpointHash,
+			)
+		}
+
+		hc.headers = []*BlockHeader{checkpointHeader}
+
+		if err := hc.save(config.CheckpointHeight, config.CheckpointHeight); err != nil {
+			return nil, fmt.Errorf("cannot save checkpoint header: [%w]", err)
+		}
+	}
+
+	logger.Infof(
+		"local header chain starts at checkpoint [%v] and has tip at [%v]",
+		hc.checkpointHeight,
+		hc.tipHeight(),
+	)
+
+	return hc, nil
+}
+
+// Observe syncs the local header chain with the underlying chain right away
+// and then at the given interval, until the context is done. The sync runs
+// in the background; the latest block height is served only once the local
+// header chain caught up with the underlying chain.
+func (hc *HeaderChain) Observe(ctx context.Context, syncInterval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := hc.Sync(); err != nil {
+				logger.Warnf("cannot sync local header chain: [%v]", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// GetLatestBlockHeight returns the height of the verified chain tip. An error
+// is returned until the local header chain catches up with the underlying
+// chain for the first time. The returned height may lag behind the
+// underlying chain by the blocks mined since the last sync.
+func (hc *HeaderChain) GetLatestBlockHeight() (uint, error) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	if !hc.caughtUp {
+		return 0, fmt.Errorf(
+			"local header chain is still syncing; verified tip is at [%v]",
+			hc.tipHeight(),
+		)
+	}
+
+	return hc.tipHeight(), nil
+}
+
+// GetBlockHeader gets the verified block header for the given block height.
+// Heights above the tip of the local header chain are not verified yet and
+// heights below the checkpoint are not supported.
+func (hc *HeaderChain) GetBlockHeader(blockHeight uint) (*BlockHeader, error) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	if blockHeight < hc.checkpointHeight {
+		return nil, fmt.Errorf(
+			"block height [%v] is below the local header chain "+
+				"checkpoint at height [%v]",
+			blockHeight,
+			hc.checkpointHeight,
+		)
+	}
+
+	if blockHeight > hc.tipHeight() {
+		return nil, fmt.Errorf(
+			"block header at height [%v] is not verified; "+
+				"local header chain tip is at [%v]",
+			blockHeight,
+			hc.tipHeight(),
+		)
+	}
+
+	blockHeader := *hc.headerAt(blockHeight)
+	return &blockHeader, nil
+}
+
+// Sync syncs the local header chain with the underlying chain. Headers that
+// fail the verification are rejected and the error is returned. If the
+// underlying chain reports a competing branch, the local chain switches to
+// it only if the branch has more cumulative work. Headers are fetched in
+// batches without blocking reads of the local header chain and each batch
+// extending the chain with the most work is added to the local chain right
+// away.
+func (hc *HeaderChain) Sync() error {
+	hc.syncMutex.Lock()
+	defer hc.syncMutex.Unlock()
+
+	return hc.sync()
+}
+
+func (hc *HeaderChain) sync() error {
+	remoteTipHeight, err := hc.Chain.GetLatestBlockHeight()
+	if err != nil {
+		return fmt.Errorf("cannot get latest block height: [%w]", err)
+	}
+
+	if remoteTipHeight < hc.checkpointHeight {
+		return fmt.Errorf(
+			"latest block height [%v] is below the checkpoint at height [%v]",
+			remoteTipHeight,
+			hc.checkpointHeight,
+		)
+	}
+
+	// Find the last block common for the local and remote chains.
+	forkHeight := hc.tipHeight()
+	if remoteTipHeight < forkHeight {
+		forkHeight = remoteTipHeight
+	}
+	for {
+		remoteHeader, err := hc.Chain.GetBlockHeader(forkHeight)
+		if err != nil {
+			return fmt.Errorf(
+				"cannot get block header at height [%v]: [%w]",
+				forkHeight,
+				err,
+			)
+		}
+
+		if remoteHeader.Hash() == hc.headerAt(forkHeight).Hash() {
+			break
+		}
+
+		if forkHeight == hc.checkpointHeight {
+			return fmt.Errorf(
+				"remote chain does not contain the checkpoint block",
+			)
+		}
+
+		forkHeight--
+	}
+
+	branch := make([]*BlockHeader, 0)
+	headerAt := func(height uint) *BlockHeader {
+		if height <= forkHeight {
+			return hc.headerAt(height)
+		}
+
+		if index := height - forkHeight - 1; index < uint(len(branch)) {
+			return branch[index]
+		}
+
+		return nil
+	}
+
+	var branchErr error
+	for height := forkHeight + 1; height <= remoteTipHeight && branchErr == nil; {
+		batchEndHeight := height + hc.syncBatchSize - 1
+		if batchEndHeight > remoteTipHeight {
+			batchEndHeight = remoteTipHeight
+		}
+
+		headers, err := hc.fetchHeaders(height, batchEndHeight)
+		branchErr = err
+
+		for _, header := range headers {
+			if err := hc.validateHeader(header, height, headerAt); err != nil {
+				branchErr = fmt.Errorf(
+					"rejected block header [%s] at height [%v]: [%w]",
+					header.Hash().Hex(ReversedByteOrder),
+					height,
+					err,
+				)
+				break
+			}
+
+			branch = append(branch, header)
+			height++
+		}
+
+		forkIndex := forkHeight - hc.checkpointHeight + 1
+
+		// Keep fetching the competing branch until it has more work than
+		// the local one or there is nothing more to fetch.
+		if len(branch) == 0 ||
+			chainWork(branch).Cmp(chainWork(hc.headers[forkIndex:])) <= 0 {
+			continue
+		}
+
+		if err := hc.commit(forkHeight, branch); err != nil {
+			return err
+		}
+
+		forkHeight += uint(len(branch))
+		branch = make([]*BlockHeader, 0)
+	}
+
+	if len(branch) > 0 {
+		logger.Warnf(
+			"ignoring competing branch of [%v] headers forking at "+
+				"height [%v]; the branch does not have more work",
+			len(branch),
+			forkHeight,
+		)
+	}
+
+	if branchErr == nil && len(branch) == 0 {
+		hc.mutex.Lock()
+		if !hc.caughtUp {
+			logger.Infof(
+				"local header chain caught up at height [%v]",
+				hc.tipHeight(),
+			)
+		}
+		hc.caughtUp = true
+		hc.mutex.Unlock()
+	}
+
+	return branchErr
+}
+
+// fetchHeaders fetches block headers at the given heights, inclusive, from
+// the underlying chain using concurrent requests. If a request fails,
+// headers preceding the failed one are returned along with the error.
+func (hc *HeaderChain) fetchHeaders(
+	fromHeight uint,
+	toHeight uint,
+) ([]*BlockHeader, error) {
+	count := toHeight - fromHeight + 1
+	headers := make([]*BlockHeader, count)
+	errs := make([]error, count)
+
+	semaphore := make(chan struct{}, headerSyncConcurrency)
+	var wg sync.WaitGroup
+	wg.Add(int(count))
+
+	for i := uint(0); i < count; i++ {
+		semaphore <- struct{}{}
+		go func(i uint) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			headers[i], errs[i] = hc.Chain.GetBlockHeader(fromHeight + i)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return headers[:i], fmt.Errorf(
+				"cannot get block header at height [%v]: [%w]",
+				fromHeight+uint(i),
+				err,
+			)
+		}
+	}
+
+	return headers, nil
+}
+
+// commit replaces local headers above the given fork height with the given
+// branch and persists the change. Must be called with syncMutex held.
+func (hc *HeaderChain) commit(forkHeight uint, branch []*BlockHeader) error {
+	previousTipHeight := hc.tipHeight()
+
+	if forkHeight < previousTipHeight {
+		logger.Warnf(
+			"reorganizing local header chain at height [%v]; "+
+				"[%v] headers replaced with [%v] headers",
+			forkHeight,
+			previousTipHeight-forkHeight,
+			len(branch),
+		)
+	}
+
+	forkIndex := forkHeight - hc.checkpointHeight + 1
+
+	hc.mutex.Lock()
+	hc.headers = append(hc.headers[:forkIndex], branch...)
+	hc.mutex.Unlock()
+
+	if err := hc.save(forkHeight+1, previousTipHeight); err != nil {
+		return fmt.Errorf("cannot save block headers: [%w]", err)
+	}
+
+	return nil
+}
+
+// validateHeader checks whether the given header can extend the chain whose
+// headers are returned by the headerAt function, at the given height.
+func (hc *HeaderChain) validateHeader(
+	header *BlockHeader,
+	height uint,
+	headerAt func(height uint) *BlockHeader,
+) error {
+	previousHeader := headerAt(height - 1)
+	if header.PreviousBlockHeaderHash != previousHeader.Hash() {
+		return fmt.Errorf("header does not link to the previous header")
+	}
+
+	target := header.Target()
+	if target.Sign() <= 0 || target.Cmp(hc.params.PowLimit) > 0 {
+		return fmt.Errorf("target [%x] is out of range", target)
+	}
+
+	if hashToBig(header.Hash()).Cmp(target) > 0 {
+		return fmt.Errorf("hash is above the target [%x]", target)
+	}
+
+	requiredBits := hc.requiredBits(header, height, headerAt)
+	if header.Bits != requiredBits {
+		return fmt.Errorf(
+			"difficulty bits [%08x] do not match the required [%08x]",
+			header.Bits,
+			requiredBits,
+		)
+	}
+
+	if medianTime, ok := medianTimePast(height, headerAt); ok &&
+		header.Time <= medianTime {
+		return fmt.Errorf(
+			"time [%v] is not after the median time past [%v]",
+			header.Time,
+			medianTime,
+		)
+	}
+
+	return nil
+}
+
+// requiredBits calculates the difficulty bits the given header must have
+// at the given height, according to the difficulty retarget rules.
+func (hc *HeaderChain) requiredBits(
+	header *BlockHeader,
+	height uint,
+	headerAt func(height uint) *BlockHeader,
+) uint32 {
+	epochLength := hc.epochLength()
+	previousHeader := headerAt(height - 1)
+
+	if height%epochLength != 0 {
+		if !hc.params.ReduceMinDifficulty {
+			return previousHeader.Bits
+		}
+
+		// Networks allowing minimum difficulty blocks accept such a block
+		// if it comes long enough after the previous one. Otherwise, the
+		// block must have the difficulty of the last regular block.
+		reductionTime := uint32(hc.params.MinDiffReductionTime / time.Second)
+		if header.Time > previousHeader.Time+reductionTime {
+			return hc.params.PowLimitBits
+		}
+
+		lastRegularHeight := height - 1
+		for lastRegularHeight%epochLength != 0 &&
+			headerAt(lastRegularHeight).Bits == hc.params.PowLimitBits {
+			lastRegularHeight--
+		}
+
+		return headerAt(lastRegularHeight).Bits
+	}
+
+	if hc.noRetargeting {
+		return previousHeader.Bits
+	}
+
+	epochStartHeader := headerAt(height - epochLength)
+
+	targetTimespan := int64(hc.params.TargetTimespan / time.Second)
+	adjustmentFactor := hc.params.RetargetAdjustmentFactor
+
+	actualTimespan := int64(previousHeader.Time) - int64(epochStartHeader.Time)
+	if actualTimespan < targetTimespan/adjustmentFactor {
+		actualTimespan = targetTimespan / adjustmentFactor
+	}
+	if actualTimespan > targetTimespan*adjustmentFactor {
+		actualTimespan = targetTimespan * adjustmentFactor
+	}
+
+	newTarget := new(big.Int).Mul(
+		previousHeader.Target(),
+		big.NewInt(actualTimespan),
+	)
+	newTarget.Div(newTarget, big.NewInt(targetTimespan))
+
+	if newTarget.Cmp(hc.params.PowLimit) > 0 {
+		newTarget.Set(hc.params.PowLimit)
+	}
+
+	return blockchain.BigToCompact(newTarget)
+}
+
+// medianTimePast calculates the median time of the headers preceding the
+// given height. Returns false if there are not enough preceding headers.
+func medianTimePast(
+	height uint,
+	headerAt func(height uint) *BlockHeader,
+) (uint32, bool) {
+	if height < medianTimeBlocks {
+		return 0, false
+	}
+
+	times := make([]uint32, 0, medianTimeBlocks)
+	for i := uint(1); i <= medianTimeBlocks; i++ {
+		header := headerAt(height - i)
+		if header == nil {
+			return 0, false
+		}
+
+		times = append(times, header.Time)
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	return times[medianTimeBlocks/2], true
+}
+
+// chainWork calculates the cumulative work of the given headers.
+func chainWork(headers []*BlockHeader) *big.Int {
+	work := big.NewInt(0)
+	for _, header := range headers {
+		work.Add(work, blockchain.CalcWork(header.Bits))
+	}
+	return work
+}
+
+// hashToBig converts the given hash to a number that can be compared with
+// the target.
+func hashToBig(hash Hash) *big.Int {
+	return new(big.Int).SetBytes(byteutils.Reverse(hash[:]))
+}
+
+// epochLength returns the number of blocks in a difficulty epoch.
+func (hc *HeaderChain) epochLength() uint {
+	return uint(hc.params.TargetTimespan / hc.params.TargetTimePerBlock)
+}
+
+func (hc *HeaderChain) tipHeight() uint {
+	return hc.checkpointHeight + uint(len(hc.headers)) - 1
+}
+
+func (hc *HeaderChain) headerAt(height uint) *BlockHeader {
+	if height < hc.checkpointHeight || height > hc.tipHeight() {
+		return nil
+	}
+
+	return hc.headers[height-hc.checkpointHeight]
+}
+
+// save persists headers of all epochs from the one containing the given
+// height up to the epoch of the current tip. Files of epochs beyond the
+// current tip but not beyond the previous tip are deleted.
+func (hc *HeaderChain) save(fromHeight uint, previousTipHeight uint) error {
+	epochLength := hc.epochLength()
+	tipHeight := hc.tipHeight()
+
+	for epoch := fromHeight / epochLength; epoch <= tipHeight/epochLength; epoch++ {
+		endHeight := (epoch+1)*epochLength - 1
+		if endHeight > tipHeight {
+			endHeight = tipHeight
+		}
+
+		var buffer bytes.Buffer
+		for height := epoch * epochLength; height <= endHeight; height++ {
+			serializedHeader := hc.headerAt(height).Serialize()
+			buffer.Write(serializedHeader[:])
+		}
+
+		err := hc.persistence.Save(
+			buffer.Bytes(),
+			headerChainDirectory,
+			epochFileName(epoch),
+		)
+		if err != nil {
+			return fmt.Errorf("cannot save epoch [%v]: [%w]", epoch, err)
+		}
+	}
+
+	for epoch := tipHeight/epochLength + 1; epoch <= previousTipHeight/epochLength; epoch++ {
+		err := hc.persistence.Delete(headerChainDirectory, epochFileName(epoch))
+		if err != nil {
+			return fmt.Errorf("cannot delete epoch [%v]: [%w]", epoch, err)
+		}
+	}
+
+	return nil
+}
+
+// load loads the headers persisted in the past. The loaded headers are
+// verified again and the ones following an invalid header are dropped.
+// Nothing is loaded if the persisted headers do not start with the given
+// checkpoint.
+func (hc *HeaderChain) load(checkpointHash Hash) {
+	epochs := make(map[uint][]byte)
+
+	descriptorsChan, errorsChan := hc.persistence.ReadAll()
+
+	// Two goroutines read from descriptors and errors channels. The reason
+	// for using two goroutines at the same time - one for descriptors and
+	// one for errors - is that channels do not have to be buffered, and we
+	// do not know in what order the information is written to channels.
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for descriptor := range descriptorsChan {
+			if descriptor.Directory() != headerChainDirectory {
+				continue
+			}
+
+			var epoch uint
+			_, err := fmt.Sscanf(
+				strings.TrimPrefix(descriptor.Name(), "/"),
+				"epoch_%d",
+				&epoch,
+			)
+			if err != nil {
+				continue
+			}
+
+			content, err := descriptor.Content()
+			if err != nil {
+				logger.Errorf(
+					"could not read headers from file [%s]: [%v]",
+					descriptor.Name(),
+					err,
+				)
+				continue
+			}
+
+			epochs[epoch] = content
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for err := range errorsChan {
+			logger.Errorf("could not load headers: [%v]", err)
+		}
+	}()
+
+	wg.Wait()
+
+	epochLength := hc.epochLength()
+
+	for epoch := hc.checkpointHeight / epochLength; ; epoch++ {
+		content, ok := epochs[epoch]
+		if !ok || len(content)%BlockHeaderByteLength != 0 {
+			return
+		}
+
+		for offset := 0; offset < len(content); offset += BlockHeaderByteLength {
+			var serializedHeader [BlockHeaderByteLength]byte
+			copy(serializedHeader[:], content[offset:])
+
+			header := &BlockHeader{}
+			header.Deserialize(serializedHeader)
+
+			height := epoch*epochLength + uint(offset/BlockHeaderByteLength)
+
+			if height == hc.checkpointHeight {
+				if header.Hash() != checkpointHash {
+					logger.Warnf(
+						"persisted headers do not start with the " +
+							"configured checkpoint; syncing from scratch",
+					)
+					return
+				}
+
+				hc.headers = []*BlockHeader{header}
+				continue
+			}
+
+			if err := hc.validateHeader(header, height, hc.headerAt); err != nil {
+				logger.Warnf(
+					"dropping persisted headers starting at height [%v]: [%v]",
+					height,
+					err,
+				)
+				return
+			}
+
+			hc.headers = append(hc.headers, header)
+		}
+
+		if len(content) < int(epochLength)*BlockHeaderByteLength {
+			return
+		}
+	}
+}
+
+func epochFileName(epoch uint) string {
+	return fmt.Sprintf("/epoch_%d", epoch)
+}
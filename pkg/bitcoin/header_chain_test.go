@@ -0,0 +1,596 @@
+package bitcoin
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+const (
+	testEpochLength      = 10
+	testCheckpointHeight = 10
+	testBlockSpacing     = 1
+	// testInitialBits represents a target allowing to mine a test block
+	// header in a few hundred attempts.
+	testInitialBits = 0x1f7fffff
+)
+
+// testHeaderChainParams are parameters of a network with short difficulty
+// epochs and a low proof of work limit so block headers can be mined in tests.
+var testHeaderChainParams = func() *chaincfg.Params {
+	params := chaincfg.RegressionNetParams
+	params.TargetTimePerBlock = 10 * time.Minute
+	params.TargetTimespan = testEpochLength * params.TargetTimePerBlock
+	params.ReduceMinDifficulty = false
+	return &params
+}()
+
+func TestHeaderChain_Sync(t *testing.T) {
+	btcChain := newLocalChain()
+	headers := buildTestHeaders(t, btcChain, nil, testCheckpointHeight, 25)
+	persistenceHandle := newLocalPersistenceHandle()
+
+	headerChain := newTestHeaderChain(t, btcChain, persistenceHandle, headers[0])
+	// Headers are fetched in several batches.
+	headerChain.syncBatchSize = 4
+
+	_, err := headerChain.GetLatestBlockHeight()
+	if err == nil {
+		t.Fatal("expected error before the local header chain caught up")
+	}
+
+	if err := headerChain.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	latestBlockHeight, err := headerChain.GetLatestBlockHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertUintsEqual(t, "latest block height", 34, uint64(latestBlockHeight))
+
+	blockHeader, err := headerChain.GetBlockHeader(25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(headers[15], blockHeader) {
+		t.Errorf(
+			"unexpected block header\nexpected: %+v\nactual:   %+v",
+			headers[15],
+			blockHeader,
+		)
+	}
+
+	// Block headers at heights 10-34 belong to epochs 1, 2 and 3.
+	testutils.AssertIntsEqual(
+		t,
+		"persisted epochs count",
+		3,
+		len(persistenceHandle.files),
+	)
+}
+
+func TestHeaderChain_Sync_Retarget(t *testing.T) {
+	btcChain := newLocalChain()
+	headers := buildTestHeaders(t, btcChain, nil, testCheckpointHeight, 11)
+
+	// Blocks came much faster than expected so the target decreases by the
+	// maximum factor at the epoch start.
+	expectedBits := blockchain.BigToCompact(
+		new(big.Int).Div(blockchain.CompactToBig(testInitialBits), big.NewInt(4)),
+	)
+
+	testutils.AssertUintsEqual(
+		t,
+		"retarget bits",
+		uint64(expectedBits),
+		uint64(headers[10].Bits),
+	)
+
+	headerChain := newTestHeaderChain(
+		t,
+		btcChain,
+		newLocalPersistenceHandle(),
+		headers[0],
+	)
+
+	if err := headerChain.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertUintsEqual(t, "tip height", 20, uint64(headerChain.tipHeight()))
+}
+
+func TestHeaderChain_Sync_RejectsInvalidHeaders(t *testing.T) {
+	var tests = map[string]struct {
+		height       uint
+		modifyHeader func(header *BlockHeader)
+		expectedErr  string
+	}{
+		"broken linkage": {
+			height: 15,
+			modifyHeader: func(header *BlockHeader) {
+				header.PreviousBlockHeaderHash = Hash{0x01}
+			},
+			expectedErr: "header does not link to the previous header",
+		},
+		"easier difficulty": {
+			height: 15,
+			modifyHeader: func(header *BlockHeader) {
+				header.Bits = 0x2000ffff
+			},
+			expectedErr: "difficulty bits [2000ffff] do not match the required [1f7fffff]",
+		},
+		"target above limit": {
+			height: 15,
+			modifyHeader: func(header *BlockHeader) {
+				header.Bits = 0x21008000
+			},
+			expectedErr: "is out of range",
+		},
+		"missing retarget": {
+			height: 20,
+			modifyHeader: func(header *BlockHeader) {
+				header.Bits = testInitialBits
+			},
+			expectedErr: "difficulty bits [1f7fffff] do not match the required",
+		},
+		"time not after median time past": {
+			height: 25,
+			modifyHeader: func(header *BlockHeader) {
+				header.Time -= 10
+			},
+			expectedErr: "is not after the median time past",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			btcChain := newLocalChain()
+			headers := buildTestHeaders(t, btcChain, nil, testCheckpointHeight, 21)
+
+			invalidHeader := *headers[test.height-testCheckpointHeight]
+			test.modifyHeader(&invalidHeader)
+			mineTestHeader(&invalidHeader)
+			btcChain.setBlockHeader(test.height, &invalidHeader)
+
+			headerChain := newTestHeaderChain(
+				t,
+				btcChain,
+				newLocalPersistenceHandle(),
+				headers[0],
+			)
+
+			err := headerChain.Sync()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf(
+					"unexpected error\nexpected to contain: %s\nactual: %v",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			// The chain must be verified up to the invalid header.
+			testutils.AssertUintsEqual(
+				t,
+				"tip height",
+				uint64(test.height-1),
+				uint64(headerChain.tipHeight()),
+			)
+
+			_, err = headerChain.GetBlockHeader(test.height)
+			if err == nil {
+				t.Fatal("expected error for the invalid header")
+			}
+		})
+	}
+}
+
+func TestHeaderChain_Sync_RejectsInsufficientProofOfWork(t *testing.T) {
+	btcChain := newLocalChain()
+	headers := buildTestHeaders(t, btcChain, nil, testCheckpointHeight, 6)
+
+	invalidHeader := *headers[5]
+	for hashToBig(invalidHeader.Hash()).Cmp(invalidHeader.Target()) <= 0 {
+		invalidHeader.Nonce++
+	}
+	btcChain.setBlockHeader(15, &invalidHeader)
+
+	headerChain := newTestHeaderChain(
+		t,
+		btcChain,
+		newLocalPersistenceHandle(),
+		headers[0],
+	)
+
+	err := headerChain.Sync()
+	if err == nil || !strings.Contains(err.Error(), "hash is above the target") {
+		t.Fatalf("unexpected error: [%v]", err)
+	}
+
+	testutils.AssertUintsEqual(t, "tip height", 14, uint64(headerChain.tipHeight()))
+}
+
+func TestHeaderChain_Sync_Reorganization(t *testing.T) {
+	btcChain := newLocalChain()
+	headers := buildTestHeaders(t, btcChain, nil, testCheckpointHeight, 11)
+
+	persistenceHandle := newLocalPersistenceHandle()
+	headerChain := newTestHeaderChain(t, btcChain, persistenceHandle, headers[0])
+
+	if err := headerChain.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A competing branch with more work replaces the local headers above
+	// height 15.
+	for height := uint(16); height <= 20; height++ {
+		btcChain.removeBlockHeader(height)
+	}
+	branch := buildTestHeaders(t, btcChain, headers[5], 16, 7)
+
+	if err := headerChain.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertUintsEqual(t, "tip height", 22, uint64(headerChain.tipHeight()))
+
+	blockHeader, err := headerChain.GetBlockHeader(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(branch[0], blockHeader) {
+		t.Errorf("block header at height 16 should come from the branch")
+	}
+
+	// A competing branch with less work is ignored.
+	for height := uint(18); height <= 22; height++ {
+		btcChain.removeBlockHeader(height)
+	}
+	buildTestHeaders(t, btcChain, branch[1], 18, 1)
+
+	if err := headerChain.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertUintsEqual(t, "tip height", 22, uint64(headerChain.tipHeight()))
+
+	// Headers of the reorganized chain are persisted.
+	reloadedChain := newTestHeaderChain(
+		t,
+		newLocalChain(),
+		persistenceHandle,
+		headers[0],
+	)
+
+	testutils.AssertUintsEqual(t, "reloaded tip height", 22, uint64(reloadedChain.tipHeight()))
+	if !reflect.DeepEqual(branch[6], reloadedChain.headerAt(22)) {
+		t.Errorf("reloaded block header at height 22 should come from the branch")
+	}
+}
+
+func TestHeaderChain_GetBlockHeader(t *testing.T) {
+	btcChain := newLocalChain()
+	headers := buildTestHeaders(t, btcChain, nil, testCheckpointHeight, 3)
+
+	headerChain := newTestHeaderChain(
+		t,
+		btcChain,
+		newLocalPersistenceHandle(),
+		headers[0],
+	)
+
+	_, err := headerChain.GetBlockHeader(testCheckpointHeight - 1)
+	if err == nil {
+		t.Fatal("expected error for height below the checkpoint")
+	}
+
+	// Heights above the local tip are not served until synced.
+	_, err = headerChain.GetBlockHeader(12)
+	if err == nil {
+		t.Fatal("expected error for height above the local tip")
+	}
+
+	if err := headerChain.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	blockHeader, err := headerChain.GetBlockHeader(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(headers[2], blockHeader) {
+		t.Errorf("unexpected block header at height 12")
+	}
+
+	_, err = headerChain.GetBlockHeader(13)
+	if err == nil {
+		t.Fatal("expected error for height above the remote tip")
+	}
+}
+
+func TestHeaderChain_Observe(t *testing.T) {
+	btcChain := newLocalChain()
+	headers := buildTestHeaders(t, btcChain, nil, testCheckpointHeight, 5)
+
+	headerChain := newTestHeaderChain(
+		t,
+		btcChain,
+		newLocalPersistenceHandle(),
+		headers[0],
+	)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	headerChain.Observe(ctx, 10*time.Millisecond)
+
+	// New blocks are picked up by the background sync.
+	buildTestHeaders(t, btcChain, headers[4], 15, 3)
+
+	var latestBlockHeight uint
+	for i := 0; i < 100; i++ {
+		height, err := headerChain.GetLatestBlockHeight()
+		if err == nil && height == 17 {
+			latestBlockHeight = height
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	testutils.AssertUintsEqual(t, "latest block height", 17, uint64(latestBlockHeight))
+}
+
+func TestHeaderChain_Load(t *testing.T) {
+	btcChain := newLocalChain()
+	headers := buildTestHeaders(t, btcChain, nil, testCheckpointHeight, 15)
+
+	persistenceHandle := newLocalPersistenceHandle()
+	headerChain := newTestHeaderChain(t, btcChain, persistenceHandle, headers[0])
+	if err := headerChain.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reloaded chain serves verified headers without the remote chain.
+	reloadedChain := newTestHeaderChain(
+		t,
+		newLocalChain(),
+		persistenceHandle,
+		headers[0],
+	)
+
+	blockHeader, err := reloadedChain.GetBlockHeader(24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(headers[14], blockHeader) {
+		t.Errorf("unexpected block header at height 24")
+	}
+
+	// Tampered persisted headers are dropped starting at the first invalid
+	// one.
+	file := persistenceHandle.files[headerChainDirectory+epochFileName(2)]
+	file.content[3*BlockHeaderByteLength+4] ^= 0xff
+
+	tamperedChain := newTestHeaderChain(
+		t,
+		newLocalChain(),
+		persistenceHandle,
+		headers[0],
+	)
+
+	testutils.AssertUintsEqual(t, "tampered tip height", 22, uint64(tamperedChain.tipHeight()))
+}
+
+func TestNewHeaderChain_InvalidCheckpoint(t *testing.T) {
+	btcChain := newLocalChain()
+	headers := buildTestHeaders(t, btcChain, nil, testCheckpointHeight, 2)
+
+	_, err := newHeaderChain(
+		btcChain,
+		testHeaderChainParams,
+		false,
+		newLocalPersistenceHandle(),
+		HeaderChainConfig{
+			CheckpointHeight: testCheckpointHeight + 1,
+			CheckpointHash:   headers[1].Hash().Hex(ReversedByteOrder),
+		},
+	)
+	if err == nil || !strings.Contains(err.Error(), "is not a difficulty epoch start") {
+		t.Fatalf("unexpected error: [%v]", err)
+	}
+
+	_, err = newHeaderChain(
+		btcChain,
+		testHeaderChainParams,
+		false,
+		newLocalPersistenceHandle(),
+		HeaderChainConfig{
+			CheckpointHeight: testCheckpointHeight,
+			CheckpointHash:   headers[1].Hash().Hex(ReversedByteOrder),
+		},
+	)
+	if err == nil || !strings.Contains(err.Error(), "instead of the configured") {
+		t.Fatalf("unexpected error: [%v]", err)
+	}
+}
+
+func newTestHeaderChain(
+	t *testing.T,
+	btcChain Chain,
+	persistenceHandle persistence.BasicHandle,
+	checkpointHeader *BlockHeader,
+) *HeaderChain {
+	headerChain, err := newHeaderChain(
+		btcChain,
+		testHeaderChainParams,
+		false,
+		persistenceHandle,
+		HeaderChainConfig{
+			CheckpointHeight: testCheckpointHeight,
+			CheckpointHash:   checkpointHeader.Hash().Hex(ReversedByteOrder),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return headerChain
+}
+
+// buildTestHeaders mines the given count of block headers on top of the
+// given previous header, starting at the given height, and adds them to the
+// local chain. If the previous header is nil, the first header is a
+// checkpoint header not linked with any previous one. Blocks are mined much
+// faster than expected so the difficulty raises in each epoch.
+func buildTestHeaders(
+	t *testing.T,
+	btcChain *localChain,
+	previous *BlockHeader,
+	height uint,
+	count int,
+) []*BlockHeader {
+	headers := make([]*BlockHeader, 0, count)
+
+	for i := 0; i < count; i++ {
+		header := &BlockHeader{
+			Version:        4,
+			MerkleRootHash: Hash{byte(height), 0x01},
+			Time:           1600000000,
+			Bits:           testInitialBits,
+		}
+
+		if previous != nil {
+			header.PreviousBlockHeaderHash = previous.Hash()
+			header.Time = previous.Time + testBlockSpacing
+			header.Bits = previous.Bits
+
+			if height%testEpochLength == 0 {
+				header.Bits = blockchain.BigToCompact(
+					new(big.Int).Div(previous.Target(), big.NewInt(4)),
+				)
+			}
+		}
+
+		mineTestHeader(header)
+
+		if err := btcChain.addBlockHeader(height, header); err != nil {
+			t.Fatal(err)
+		}
+
+		headers = append(headers, header)
+		previous = header
+		height++
+	}
+
+	return headers
+}
+
+// mineTestHeader changes the nonce of the given header until the header hash
+// meets the target.
+func mineTestHeader(header *BlockHeader) {
+	target := header.Target()
+	if target.Cmp(testHeaderChainParams.PowLimit) > 0 {
+		return
+	}
+
+	for hashToBig(header.Hash()).Cmp(target) > 0 {
+		header.Nonce++
+	}
+}
+
+type localPersistenceHandle struct {
+	mutex sync.Mutex
+	// files holds persisted files by their directory and name.
+	files map[string]*localDescriptor
+}
+
+func newLocalPersistenceHandle() *localPersistenceHandle {
+	return &localPersistenceHandle{
+		files: make(map[string]*localDescriptor),
+	}
+}
+
+func (lph *localPersistenceHandle) Save(
+	data []byte,
+	directory string,
+	name string,
+) error {
+	lph.mutex.Lock()
+	defer lph.mutex.Unlock()
+
+	lph.files[directory+name] = &localDescriptor{
+		name:      name,
+		directory: directory,
+		content:   append([]byte{}, data...),
+	}
+
+	return nil
+}
+
+func (lph *localPersistenceHandle) ReadAll() (
+	<-chan persistence.DataDescriptor,
+	<-chan error,
+) {
+	lph.mutex.Lock()
+	defer lph.mutex.Unlock()
+
+	outputData := make(chan persistence.DataDescriptor, len(lph.files))
+	outputErrors := make(chan error)
+
+	for _, file := range lph.files {
+		outputData <- &localDescriptor{
+			name:      file.name,
+			directory: file.directory,
+			content:   append([]byte{}, file.content...),
+		}
+	}
+
+	close(outputData)
+	close(outputErrors)
+
+	return outputData, outputErrors
+}
+
+func (lph *localPersistenceHandle) Delete(directory string, name string) error {
+	lph.mutex.Lock()
+	defer lph.mutex.Unlock()
+
+	delete(lph.files, directory+name)
+
+	return nil
+}
+
+type localDescriptor struct {
+	name      string
+	directory string
+	content   []byte
+}
+
+func (ld *localDescriptor) Name() string {
+	return ld.name
+}
+
+func (ld *localDescriptor) Directory() string {
+	return ld.directory
+}
+
+func (ld *localDescriptor) Content() ([]byte, error) {
+	return ld.content, nil
+}
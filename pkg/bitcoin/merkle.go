@@ -0,0 +1,61 @@
+package bitcoin
+
+// ComputeMerkleBranch computes the Merkle branch leading to the transaction
+// at the given position, using hashes of all transactions included in the
+// block. The hashes are in the InternalByteOrder. The branch is ordered
+// from the deepest pairing, i.e. the sibling of the transaction goes first.
+func ComputeMerkleBranch(txHashes []Hash, position uint) []Hash {
+	branch := make([]Hash, 0)
+
+	level := make([]Hash, len(txHashes))
+	copy(level, txHashes)
+
+	index := position
+	for len(level) > 1 {
+		level = padMerkleLevel(level)
+		branch = append(branch, level[index^1])
+		level = nextMerkleLevel(level)
+		index /= 2
+	}
+
+	return branch
+}
+
+// ComputeMerkleRoot computes the Merkle root of the block including
+// transactions with the given hashes. The hashes are in the
+// InternalByteOrder, the same as the Merkle root hash of the block header.
+func ComputeMerkleRoot(txHashes []Hash) Hash {
+	if len(txHashes) == 0 {
+		return Hash{}
+	}
+
+	level := make([]Hash, len(txHashes))
+	copy(level, txHashes)
+
+	for len(level) > 1 {
+		level = nextMerkleLevel(padMerkleLevel(level))
+	}
+
+	return level[0]
+}
+
+// padMerkleLevel duplicates the last hash of a Merkle tree level that has
+// an odd number of elements, as Bitcoin does.
+func padMerkleLevel(level []Hash) []Hash {
+	if len(level)%2 == 1 {
+		level = append(level, level[len(level)-1])
+	}
+
+	return level
+}
+
+// nextMerkleLevel computes the parent level of the given Merkle tree level
+// that has an even number of elements.
+func nextMerkleLevel(level []Hash) []Hash {
+	nextLevel := make([]Hash, len(level)/2)
+	for i := range nextLevel {
+		nextLevel[i] = ComputeHash(append(level[2*i][:], level[2*i+1][:]...))
+	}
+
+	return nextLevel
+}
@@ -0,0 +1,69 @@
+package bitcoin
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+func TestComputeMerkleRoot(t *testing.T) {
+	// Transactions of the block 100000:
+	// https://blockstream.info/block/000000000003ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506
+	txHashes := []Hash{
+		hashFromString("8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87"),
+		hashFromString("fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4"),
+		hashFromString("6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4"),
+		hashFromString("e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d"),
+	}
+
+	expectedMerkleRoot := hashFromString(
+		"f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766",
+	)
+
+	merkleRoot := ComputeMerkleRoot(txHashes)
+
+	testutils.AssertStringsEqual(
+		t,
+		"Merkle root",
+		expectedMerkleRoot.Hex(ReversedByteOrder),
+		merkleRoot.Hex(ReversedByteOrder),
+	)
+}
+
+func TestComputeMerkleBranch(t *testing.T) {
+	for transactionsCount := 1; transactionsCount <= 9; transactionsCount++ {
+		t.Run(fmt.Sprintf("%d transactions", transactionsCount), func(t *testing.T) {
+			txHashes := make([]Hash, transactionsCount)
+			for i := range txHashes {
+				txHashes[i] = ComputeHash([]byte{byte(i)})
+			}
+
+			merkleRoot := ComputeMerkleRoot(txHashes)
+
+			// Folding the branch with the transaction hash must give the
+			// Merkle root of the block.
+			for position := range txHashes {
+				branch := ComputeMerkleBranch(txHashes, uint(position))
+
+				hash := txHashes[position]
+				index := position
+				for _, node := range branch {
+					if index%2 == 0 {
+						hash = ComputeHash(append(hash[:], node[:]...))
+					} else {
+						hash = ComputeHash(append(node[:], hash[:]...))
+					}
+					index /= 2
+				}
+
+				testutils.AssertStringsEqual(
+					t,
+					fmt.Sprintf("Merkle root for position %d", position),
+					merkleRoot.Hex(ReversedByteOrder),
+					hash.Hex(ReversedByteOrder),
+				)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ const (
 	P2WPKHScript
 	P2SHScript
 	P2WSHScript
+	P2TRScript
 )
 
 func (st ScriptType) String() string {
@@ -31,6 +32,8 @@ func (st ScriptType) String() string {
 		return "P2SH"
 	case P2WSHScript:
 		return "P2WSH"
+	case P2TRScript:
+		return "P2TR"
 	default:
 		return "NonStandard"
 	}
@@ -147,8 +150,31 @@ func PayToScriptHash(scriptHash [20]byte) (Script, error) {
 		Script()
 }
 
+// PayToTaproot constructs a P2TR script for the provided 32-byte x-only
+// taproot output key. The function assumes the provided output key is valid.
+func PayToTaproot(outputKey [32]byte) (Script, error) {
+	return txscript.NewScriptBuilder().
+		AddOp(txscript.OP_1).
+		AddData(outputKey[:]).
+		Script()
+}
+
+// isPayToTaproot determines whether the given Script is a P2TR script, i.e.
+// a witness version 1 program with a 32-byte output key.
+func isPayToTaproot(script Script) bool {
+	return len(script) == 34 &&
+		script[0] == txscript.OP_1 &&
+		script[1] == txscript.OP_DATA_32
+}
+
 // GetScriptType gets the ScriptType of the given Script.
 func GetScriptType(script Script) ScriptType {
+	// The used txscript version does not recognize witness version 1
+	// programs so P2TR scripts must be detected separately.
+	if isPayToTaproot(script) {
+		return P2TRScript
+	}
+
 	switch txscript.GetScriptClass(script) {
 	case txscript.PubKeyHashTy:
 		return P2PKHScript
@@ -189,3 +215,28 @@ func ExtractPublicKeyHash(script Script) ([20]byte, error) {
 
 	return publicKeyHash, nil
 }
+
+// ExtractRedeemScript extracts the plain-text redeem script from a signed
+// transaction input spending a P2SH or P2WSH output. The redeem script is the
+// last item of the witness for P2WSH inputs and the last data push of the
+// signature script for P2SH inputs.
+func ExtractRedeemScript(input *TransactionInput) (Script, error) {
+	if len(input.Witness) > 0 {
+		return input.Witness[len(input.Witness)-1], nil
+	}
+
+	if len(input.SignatureScript) == 0 {
+		return nil, fmt.Errorf("input does not hold any signature data")
+	}
+
+	pushes, err := txscript.PushedData(input.SignatureScript)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse signature script: [%v]", err)
+	}
+
+	if len(pushes) == 0 {
+		return nil, fmt.Errorf("signature script does not push any data")
+	}
+
+	return pushes[len(pushes)-1], nil
+}
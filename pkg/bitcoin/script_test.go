@@ -334,6 +334,33 @@ func TestPayToScriptHash(t *testing.T) {
 	testutils.AssertBytesEqual(t, expectedResult, result[:])
 }
 
+func TestPayToTaproot(t *testing.T) {
+	// The 32-byte output key of the BIP-341 test vector.
+	outputKeyBytes, err := hex.DecodeString(
+		"a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var outputKey [32]byte
+	copy(outputKey[:], outputKeyBytes)
+
+	result, err := PayToTaproot(outputKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedResult, err := hex.DecodeString(
+		"5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertBytesEqual(t, expectedResult, result[:])
+}
+
 func TestGetScriptType(t *testing.T) {
 	fromHex := func(hexString string) []byte {
 		bytes, err := hex.DecodeString(hexString)
@@ -363,6 +390,14 @@ func TestGetScriptType(t *testing.T) {
 			script:       fromHex("002086a303cdd2e2eab1d1679f1a813835dc5a1b65321077cdccaf08f98cbf04ca96"),
 			expectedType: P2WSHScript,
 		},
+		"p2tr script": {
+			script:       fromHex("5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c"),
+			expectedType: P2TRScript,
+		},
+		"witness version 1 program of invalid length": {
+			script:       fromHex("5114a60869f0dbcf1dc659c9cecbaf8050135ea9e8cd"),
+			expectedType: NonStandardScript,
+		},
 		"non-standard script": {
 			script: fromHex(
 				"14934b98637ca318a4d6e7ca6ffd1690b8e77df6377508f9f0c90d0003" +
@@ -440,3 +475,68 @@ func TestExtractPublicKeyHash(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractRedeemScript(t *testing.T) {
+	fromHex := func(hexString string) []byte {
+		bytes, err := hex.DecodeString(hexString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bytes
+	}
+
+	redeemScript := fromHex("14934b98637ca318a4d6e7ca6ffd1690b8e77df6377508f9f0c90d000395237576a9148db50eb52063ea9d98b3eac91489a90f738986f68763ac6776a914e257eccafbc07c381642ce6e7e55120fb077fbed8804e0250162b175ac68")
+
+	var tests = map[string]struct {
+		input                *TransactionInput
+		expectedRedeemScript Script
+		expectedErr          error
+	}{
+		"P2WSH input": {
+			input: &TransactionInput{
+				Witness: [][]byte{
+					fromHex("30440220"),
+					fromHex("03989d253b17a6a0f41838b84ff0d20e8898f9d7b1a98f2564da4cc29dcf8581d9"),
+					redeemScript,
+				},
+			},
+			expectedRedeemScript: redeemScript,
+		},
+		"P2SH input": {
+			input: &TransactionInput{
+				SignatureScript: append(
+					append(
+						fromHex("0430440220"),
+						fromHex("2103989d253b17a6a0f41838b84ff0d20e8898f9d7b1a98f2564da4cc29dcf8581d9")...,
+					),
+					append([]byte{0x4c, byte(len(redeemScript))}, redeemScript...)...,
+				),
+			},
+			expectedRedeemScript: redeemScript,
+		},
+		"input without signature data": {
+			input:       &TransactionInput{},
+			expectedErr: fmt.Errorf("input does not hold any signature data"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actualRedeemScript, err := ExtractRedeemScript(test.input)
+
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Errorf(
+					"unexpected error\nexpected: %+v\nactual:   %+v\n",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			testutils.AssertBytesEqual(
+				t,
+				test.expectedRedeemScript,
+				actualRedeemScript,
+			)
+		})
+	}
+}
@@ -33,11 +33,20 @@ type SpvProof struct {
 
 // AssembleSpvProof assembles a proof that a given transaction was included in
 // the blockchain and has accumulated the required number of confirmations.
+// If the given chain implements the SpvProofAssembler interface, the proof
+// assembly is delegated to it.
 func AssembleSpvProof(
 	transactionHash Hash,
 	requiredConfirmations uint,
 	btcChain Chain,
 ) (*Transaction, *SpvProof, error) {
+	if assembler, ok := btcChain.(SpvProofAssembler); ok {
+		return assembler.AssembleSpvProof(
+			transactionHash,
+			requiredConfirmations,
+		)
+	}
+
 	confirmations, err := btcChain.GetTransactionConfirmations(
 		transactionHash,
 	)
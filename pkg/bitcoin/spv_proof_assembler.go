@@ -0,0 +1,329 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// SpvProofConfig holds configurable properties of the SPV proof assembly.
+type SpvProofConfig struct {
+	// LocalAssembly determines whether SPV proofs should be assembled from
+	// the local header chain and transaction lists of blocks instead of
+	// Merkle proofs and block headers fetched from the Bitcoin chain one by
+	// one. Requires the local header chain and a bitcoind node.
+	LocalAssembly bool
+	// CacheCapacity is the maximum number of locally assembled SPV proofs
+	// kept in the cache.
+	CacheCapacity int
+}
+
+// SpvProofAssembler is implemented by Bitcoin chains that can assemble SPV
+// proofs on their own. AssembleSpvProof uses it, if the given chain
+// implements it.
+type SpvProofAssembler interface {
+	// AssembleSpvProof assembles a proof that a given transaction was
+	// included in the blockchain and has accumulated the required number of
+	// confirmations.
+	AssembleSpvProof(
+		transactionHash Hash,
+		requiredConfirmations uint,
+	) (*Transaction, *SpvProof, error)
+}
+
+// HeaderStore is a source of block headers the SPV proofs are assembled
+// from. HeaderChain is the intended implementation as it serves verified
+// headers from the local storage.
+type HeaderStore interface {
+	// GetBlockHeader gets the block header for the given block height.
+	GetBlockHeader(blockHeight uint) (*BlockHeader, error)
+}
+
+// BlockTxHashesSource is a source of transaction lists of blocks the Merkle
+// proofs are built from.
+type BlockTxHashesSource interface {
+	// GetBlockTxHashes gets hashes of all transactions included in the block
+	// at the given height, in the order they appear in the block.
+	GetBlockTxHashes(blockHeight uint) ([]Hash, error)
+}
+
+// BlockTransactionsSource returns all transactions included in the block at
+// the given height, in the order they appear in the block. It allows using
+// full blocks as a BlockTxHashesSource.
+type BlockTransactionsSource func(blockHeight uint) ([]*Transaction, error)
+
+// GetBlockTxHashes gets hashes of all transactions included in the block at
+// the given height, in the order they appear in the block.
+func (bts BlockTransactionsSource) GetBlockTxHashes(
+	blockHeight uint,
+) ([]Hash, error) {
+	transactions, err := bts(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	txHashes := make([]Hash, len(transactions))
+	for i, transaction := range transactions {
+		txHashes[i] = transaction.Hash()
+	}
+
+	return txHashes, nil
+}
+
+// LocalSpvProofAssembler assembles SPV proofs using block headers from the
+// header store and Merkle proofs computed locally from transaction lists of
+// blocks. Contrary to AssembleSpvProof working on a plain chain, it does not
+// make a call per block header and Merkle proof. Assembled proofs are kept
+// in the cache, if set. LocalSpvProofAssembler implements the Chain
+// interface and delegates all calls to the underlying chain, so
+// AssembleSpvProof uses it when given the assembler as the chain.
+type LocalSpvProofAssembler struct {
+	Chain
+
+	headers HeaderStore
+	blocks  BlockTxHashesSource
+	cache   *SpvProofCache
+}
+
+// NewLocalSpvProofAssembler creates a new LocalSpvProofAssembler on top of
+// the given chain. Block headers are taken from the given header store and
+// transaction lists of blocks from the given source. The cache is optional.
+func NewLocalSpvProofAssembler(
+	chain Chain,
+	headers HeaderStore,
+	blocks BlockTxHashesSource,
+	cache *SpvProofCache,
+) *LocalSpvProofAssembler {
+	return &LocalSpvProofAssembler{
+		Chain:   chain,
+		headers: headers,
+		blocks:  blocks,
+		cache:   cache,
+	}
+}
+
+// AssembleSpvProof assembles a proof that a given transaction was included
+// in the blockchain and has accumulated the required number of
+// confirmations. A cached proof is returned if the transaction is still in
+// the same block and the headers chain of the proof is still the one held by
+// the header store.
+func (lspa *LocalSpvProofAssembler) AssembleSpvProof(
+	transactionHash Hash,
+	requiredConfirmations uint,
+) (*Transaction, *SpvProof, error) {
+	confirmations, err := lspa.GetTransactionConfirmations(transactionHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if confirmations < requiredConfirmations {
+		return nil, nil, fmt.Errorf(
+			"transaction confirmations number[%v] is not enough, required [%v]",
+			confirmations,
+			requiredConfirmations,
+		)
+	}
+
+	latestBlockHeight, err := lspa.GetLatestBlockHeight()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txBlockHeight := latestBlockHeight - confirmations + 1
+
+	if transaction, proof, ok := lspa.getCachedProof(
+		transactionHash,
+		requiredConfirmations,
+		txBlockHeight,
+	); ok {
+		return transaction, proof, nil
+	}
+
+	headers, err := lspa.getHeaders(txBlockHeight, requiredConfirmations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txHashes, err := lspa.blocks.GetBlockTxHashes(txBlockHeight)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"cannot get transactions of block at height [%v]: [%w]",
+			txBlockHeight,
+			err,
+		)
+	}
+
+	// Make sure the transaction list matches the header of the block, so
+	// the Merkle proofs built from it can be verified against the header.
+	if merkleRoot := ComputeMerkleRoot(txHashes); merkleRoot !=
+		headers[0].MerkleRootHash {
+		return nil, nil, fmt.Errorf(
+			"transactions of block at height [%v] have Merkle root [%s] "+
+				"different than the block header's [%s]",
+			txBlockHeight,
+			merkleRoot.Hex(ReversedByteOrder),
+			headers[0].MerkleRootHash.Hex(ReversedByteOrder),
+		)
+	}
+
+	position := -1
+	for i, txHash := range txHashes {
+		if txHash == transactionHash {
+			position = i
+			break
+		}
+	}
+	if position < 0 {
+		return nil, nil, fmt.Errorf(
+			"transaction [%s] not found in block at height [%v]",
+			transactionHash.Hex(ReversedByteOrder),
+			txBlockHeight,
+		)
+	}
+
+	transaction, err := lspa.GetTransaction(transactionHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	coinbaseTx, err := lspa.GetTransaction(txHashes[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get coinbase tx [%w]", err)
+	}
+
+	var headersChain bytes.Buffer
+	for _, header := range headers {
+		serializedHeader := header.Serialize()
+		headersChain.Write(serializedHeader[:])
+	}
+
+	proof := &SpvProof{
+		MerkleProof: concatenateHashes(
+			ComputeMerkleBranch(txHashes, uint(position)),
+		),
+		TxIndexInBlock:   uint(position),
+		BitcoinHeaders:   headersChain.Bytes(),
+		CoinbasePreimage: sha256.Sum256(coinbaseTx.Serialize(Standard)),
+		CoinbaseProof:    concatenateHashes(ComputeMerkleBranch(txHashes, 0)),
+	}
+
+	lspa.cache.put(transactionHash, &cachedSpvProof{
+		RequiredConfirmations: requiredConfirmations,
+		BlockHeight:           txBlockHeight,
+		Transaction:           transaction.Serialize(),
+		Proof:                 proof,
+	})
+
+	return transaction, proof, nil
+}
+
+// getCachedProof returns the cached proof of the given transaction if the
+// last header of its headers chain is still in the header store. As headers
+// are linked, all the preceding headers of the chain are still there as
+// well. A cached proof that is no longer valid is dropped.
+func (lspa *LocalSpvProofAssembler) getCachedProof(
+	transactionHash Hash,
+	requiredConfirmations uint,
+	txBlockHeight uint,
+) (*Transaction, *SpvProof, bool) {
+	cached, ok := lspa.cache.get(
+		transactionHash,
+		requiredConfirmations,
+		txBlockHeight,
+	)
+	if !ok {
+		return nil, nil, false
+	}
+
+	lastHeader, err := cached.lastHeader()
+	if err != nil {
+		logger.Warnf(
+			"dropping cached SPV proof of transaction [%s]: [%v]",
+			transactionHash.Hex(ReversedByteOrder),
+			err,
+		)
+		lspa.cache.remove(transactionHash)
+		return nil, nil, false
+	}
+
+	storedHeader, err := lspa.headers.GetBlockHeader(
+		txBlockHeight + requiredConfirmations - 1,
+	)
+	if err != nil {
+		logger.Warnf(
+			"cannot verify cached SPV proof of transaction [%s]: [%v]",
+			transactionHash.Hex(ReversedByteOrder),
+			err,
+		)
+		return nil, nil, false
+	}
+
+	if storedHeader.Hash() != lastHeader.Hash() {
+		logger.Infof(
+			"dropping cached SPV proof of transaction [%s] "+
+				"as its headers chain was reorganized",
+			transactionHash.Hex(ReversedByteOrder),
+		)
+		lspa.cache.remove(transactionHash)
+		return nil, nil, false
+	}
+
+	transaction := &Transaction{}
+	if err := transaction.Deserialize(cached.Transaction); err != nil {
+		logger.Warnf(
+			"dropping cached SPV proof of transaction [%s]: [%v]",
+			transactionHash.Hex(ReversedByteOrder),
+			err,
+		)
+		lspa.cache.remove(transactionHash)
+		return nil, nil, false
+	}
+
+	return transaction, cached.Proof, true
+}
+
+// getHeaders gets a chain of block headers from the header store that starts
+// at the provided block height and has the specified chain length.
+func (lspa *LocalSpvProofAssembler) getHeaders(
+	blockHeight uint,
+	chainLength uint,
+) ([]*BlockHeader, error) {
+	if chainLength == 0 {
+		return nil, fmt.Errorf("headers chain cannot be empty")
+	}
+
+	headers := make([]*BlockHeader, 0, chainLength)
+	for i := blockHeight; i < blockHeight+chainLength; i++ {
+		header, err := lspa.headers.GetBlockHeader(i)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get block header at height [%v]: [%w]",
+				i,
+				err,
+			)
+		}
+
+		if len(headers) > 0 &&
+			header.PreviousBlockHeaderHash != headers[len(headers)-1].Hash() {
+			return nil, fmt.Errorf(
+				"block header at height [%v] does not link to the previous one",
+				i,
+			)
+		}
+
+		headers = append(headers, header)
+	}
+
+	return headers, nil
+}
+
+// concatenateHashes concatenates the given hashes in the internal byte
+// order, the way SPV proofs expect Merkle proofs.
+func concatenateHashes(hashes []Hash) []byte {
+	var buffer bytes.Buffer
+	for _, hash := range hashes {
+		buffer.Write(hash[:])
+	}
+
+	return buffer.Bytes()
+}
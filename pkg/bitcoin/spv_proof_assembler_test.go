@@ -0,0 +1,415 @@
+package bitcoin
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+const (
+	testProofBlockHeight           = 100
+	testProofRequiredConfirmations = 6
+	testProofConfirmations         = 7
+)
+
+// testProofBlock holds a synthetic block the SPV proofs are assembled for,
+// along with the chain holding the block and its confirmations.
+type testProofBlock struct {
+	btcChain     *localChain
+	transactions []*Transaction
+	headers      []*BlockHeader
+}
+
+// newTestProofBlock creates a block with the given number of transactions at
+// testProofBlockHeight and adds it to a local chain along with the headers
+// of the following blocks. The local chain serves Merkle proofs and block
+// headers the same way Electrum does, so AssembleSpvProof can be used as
+// the reference.
+func newTestProofBlock(t *testing.T, transactionsCount int) *testProofBlock {
+	btcChain := newLocalChain()
+
+	transactions := make([]*Transaction, transactionsCount)
+	txHashes := make([]Hash, transactionsCount)
+	for i := range transactions {
+		transactions[i] = &Transaction{
+			Version: 1,
+			Inputs: []*TransactionInput{
+				{
+					Outpoint: &TransactionOutpoint{
+						TransactionHash: Hash{byte(i), 0x01},
+						OutputIndex:     uint32(i),
+					},
+					Sequence: 0xffffffff,
+				},
+			},
+			Outputs: []*TransactionOutput{
+				{
+					Value:           int64(1000 * (i + 1)),
+					PublicKeyScript: []byte{0x00, 0x14, byte(i)},
+				},
+			},
+		}
+		txHashes[i] = transactions[i].Hash()
+
+		if err := btcChain.addTransaction(transactions[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, txHash := range txHashes {
+		branch := ComputeMerkleBranch(txHashes, uint(i))
+
+		merkleNodes := make([]string, len(branch))
+		for j, node := range branch {
+			merkleNodes[j] = node.Hex(ReversedByteOrder)
+		}
+
+		if err := btcChain.addTransactionMerkleProof(
+			txHash,
+			&TransactionMerkleProof{
+				BlockHeight: testProofBlockHeight,
+				MerkleNodes: merkleNodes,
+				Position:    uint(i),
+			},
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := btcChain.addTransactionConfirmations(
+			txHash,
+			testProofConfirmations,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	btcChain.setCoinbaseTxHash(testProofBlockHeight, txHashes[0])
+
+	headers := make([]*BlockHeader, testProofConfirmations)
+	for i := range headers {
+		headers[i] = &BlockHeader{
+			Version:        4,
+			MerkleRootHash: Hash{byte(i), 0x02},
+			Time:           1600000000 + uint32(i),
+			Bits:           testInitialBits,
+			Nonce:          uint32(i),
+		}
+
+		if i == 0 {
+			headers[i].MerkleRootHash = ComputeMerkleRoot(txHashes)
+		} else {
+			headers[i].PreviousBlockHeaderHash = headers[i-1].Hash()
+		}
+
+		if err := btcChain.addBlockHeader(
+			testProofBlockHeight+uint(i),
+			headers[i],
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &testProofBlock{
+		btcChain:     btcChain,
+		transactions: transactions,
+		headers:      headers,
+	}
+}
+
+// blockTransactions returns a source of block transactions serving the test
+// block and counting the calls.
+func (tpb *testProofBlock) blockTransactions(
+	callsCount *int,
+) BlockTransactionsSource {
+	return func(blockHeight uint) ([]*Transaction, error) {
+		*callsCount++
+
+		if blockHeight != testProofBlockHeight {
+			return nil, fmt.Errorf("block not found")
+		}
+
+		return tpb.transactions, nil
+	}
+}
+
+func TestLocalSpvProofAssembler_AssembleSpvProof(t *testing.T) {
+	for _, transactionsCount := range []int{1, 2, 5, 8} {
+		t.Run(fmt.Sprintf("%d transactions", transactionsCount), func(t *testing.T) {
+			block := newTestProofBlock(t, transactionsCount)
+
+			callsCount := 0
+			assembler := NewLocalSpvProofAssembler(
+				block.btcChain,
+				block.btcChain,
+				block.blockTransactions(&callsCount),
+				nil,
+			)
+
+			for _, transaction := range block.transactions {
+				expectedTransaction, expectedProof, err := AssembleSpvProof(
+					transaction.Hash(),
+					testProofRequiredConfirmations,
+					block.btcChain,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// The assembler is used when passed as the chain.
+				actualTransaction, actualProof, err := AssembleSpvProof(
+					transaction.Hash(),
+					testProofRequiredConfirmations,
+					assembler,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(expectedTransaction, actualTransaction) {
+					t.Errorf(
+						"unexpected transaction\nexpected: %v\nactual:   %v",
+						expectedTransaction,
+						actualTransaction,
+					)
+				}
+
+				if !reflect.DeepEqual(expectedProof, actualProof) {
+					t.Errorf(
+						"unexpected proof\nexpected: %v\nactual:   %v",
+						expectedProof,
+						actualProof,
+					)
+				}
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"block transactions calls",
+				transactionsCount,
+				callsCount,
+			)
+		})
+	}
+}
+
+func TestLocalSpvProofAssembler_AssembleSpvProof_NotEnoughConfirmations(t *testing.T) {
+	block := newTestProofBlock(t, 3)
+
+	callsCount := 0
+	assembler := NewLocalSpvProofAssembler(
+		block.btcChain,
+		block.btcChain,
+		block.blockTransactions(&callsCount),
+		nil,
+	)
+
+	_, _, err := assembler.AssembleSpvProof(
+		block.transactions[1].Hash(),
+		testProofConfirmations+1,
+	)
+
+	expectedError := fmt.Errorf(
+		"transaction confirmations number[7] is not enough, required [8]",
+	)
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"unexpected error\nexpected: %v\nactual:   %v",
+			expectedError,
+			err,
+		)
+	}
+}
+
+func TestLocalSpvProofAssembler_AssembleSpvProof_MerkleRootMismatch(t *testing.T) {
+	block := newTestProofBlock(t, 3)
+
+	callsCount := 0
+	assembler := NewLocalSpvProofAssembler(
+		block.btcChain,
+		block.btcChain,
+		BlockTransactionsSource(func(blockHeight uint) ([]*Transaction, error) {
+			callsCount++
+			// The source misses the last transaction of the block.
+			return block.transactions[:2], nil
+		}),
+		nil,
+	)
+
+	_, _, err := assembler.AssembleSpvProof(
+		block.transactions[1].Hash(),
+		testProofRequiredConfirmations,
+	)
+	if err == nil {
+		t.Fatal("expected Merkle root mismatch error")
+	}
+
+	testutils.AssertIntsEqual(t, "block transactions calls", 1, callsCount)
+}
+
+func TestLocalSpvProofAssembler_AssembleSpvProof_UnlinkedHeaders(t *testing.T) {
+	block := newTestProofBlock(t, 3)
+
+	block.btcChain.setBlockHeader(testProofBlockHeight+3, &BlockHeader{})
+
+	callsCount := 0
+	assembler := NewLocalSpvProofAssembler(
+		block.btcChain,
+		block.btcChain,
+		block.blockTransactions(&callsCount),
+		nil,
+	)
+
+	_, _, err := assembler.AssembleSpvProof(
+		block.transactions[1].Hash(),
+		testProofRequiredConfirmations,
+	)
+
+	expectedError := fmt.Errorf(
+		"block header at height [103] does not link to the previous one",
+	)
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"unexpected error\nexpected: %v\nactual:   %v",
+			expectedError,
+			err,
+		)
+	}
+}
+
+func TestLocalSpvProofAssembler_AssembleSpvProof_Cache(t *testing.T) {
+	block := newTestProofBlock(t, 5)
+	transactionHash := block.transactions[3].Hash()
+
+	persistenceHandle := newLocalPersistenceHandle()
+	cache := NewSpvProofCache(persistenceHandle, 10)
+
+	callsCount := 0
+	assembler := NewLocalSpvProofAssembler(
+		block.btcChain,
+		block.btcChain,
+		block.blockTransactions(&callsCount),
+		cache,
+	)
+
+	assemble := func() (*Transaction, *SpvProof) {
+		transaction, proof, err := assembler.AssembleSpvProof(
+			transactionHash,
+			testProofRequiredConfirmations,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return transaction, proof
+	}
+
+	expectedTransaction, expectedProof := assemble()
+	testutils.AssertIntsEqual(t, "block transactions calls", 1, callsCount)
+	testutils.AssertIntsEqual(t, "cached proofs", 1, cache.len())
+	testutils.AssertIntsEqual(t, "persisted proofs", 1, len(persistenceHandle.files))
+
+	// The cached proof is returned.
+	transaction, proof := assemble()
+	testutils.AssertIntsEqual(t, "block transactions calls", 1, callsCount)
+
+	if !reflect.DeepEqual(expectedTransaction.Hash(), transaction.Hash()) {
+		t.Errorf("unexpected cached transaction")
+	}
+	if !reflect.DeepEqual(expectedProof, proof) {
+		t.Errorf(
+			"unexpected cached proof\nexpected: %v\nactual:   %v",
+			expectedProof,
+			proof,
+		)
+	}
+
+	// The persisted proof is used after restart.
+	assembler.cache = NewSpvProofCache(persistenceHandle, 10)
+
+	transaction, proof = assemble()
+	testutils.AssertIntsEqual(t, "block transactions calls", 1, callsCount)
+
+	if !reflect.DeepEqual(expectedTransaction.Hash(), transaction.Hash()) {
+		t.Errorf("unexpected persisted transaction")
+	}
+	if !reflect.DeepEqual(expectedProof, proof) {
+		t.Errorf(
+			"unexpected persisted proof\nexpected: %v\nactual:   %v",
+			expectedProof,
+			proof,
+		)
+	}
+
+	// The headers chain of the cached proof was reorganized so the proof
+	// is assembled again.
+	reorganizedHeader := *block.headers[testProofRequiredConfirmations-1]
+	reorganizedHeader.Nonce++
+	block.btcChain.setBlockHeader(
+		testProofBlockHeight+testProofRequiredConfirmations-1,
+		&reorganizedHeader,
+	)
+
+	_, proof = assemble()
+	testutils.AssertIntsEqual(t, "block transactions calls", 2, callsCount)
+
+	if reflect.DeepEqual(expectedProof.BitcoinHeaders, proof.BitcoinHeaders) {
+		t.Errorf("expected proof with the reorganized headers chain")
+	}
+
+	// The proof is assembled for a different number of confirmations.
+	_, _, err := assembler.AssembleSpvProof(
+		transactionHash,
+		testProofRequiredConfirmations-1,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutils.AssertIntsEqual(t, "block transactions calls", 3, callsCount)
+}
+
+func TestSpvProofCache_Eviction(t *testing.T) {
+	persistenceHandle := newLocalPersistenceHandle()
+	cache := NewSpvProofCache(persistenceHandle, 2)
+
+	put := func(transactionHash Hash, blockHeight uint) {
+		cache.put(transactionHash, &cachedSpvProof{
+			RequiredConfirmations: 6,
+			BlockHeight:           blockHeight,
+			Proof:                 &SpvProof{},
+		})
+	}
+
+	put(Hash{1}, 102)
+	put(Hash{2}, 100)
+	put(Hash{3}, 101)
+
+	// The proof of the transaction from the oldest block is evicted.
+	testutils.AssertIntsEqual(t, "cached proofs", 2, cache.len())
+	testutils.AssertIntsEqual(t, "persisted proofs", 2, len(persistenceHandle.files))
+
+	_, ok := cache.get(Hash{2}, 6, 100)
+	testutils.AssertBoolsEqual(t, "evicted proof cached", false, ok)
+
+	_, ok = cache.get(Hash{1}, 6, 102)
+	testutils.AssertBoolsEqual(t, "newest proof cached", true, ok)
+
+	// The transaction moved to another block.
+	_, ok = cache.get(Hash{3}, 6, 103)
+	testutils.AssertBoolsEqual(t, "moved transaction proof cached", false, ok)
+
+	// The capacity applies to persisted proofs loaded on restart as well.
+	reloadedCache := NewSpvProofCache(persistenceHandle, 1)
+
+	testutils.AssertIntsEqual(t, "reloaded proofs", 1, reloadedCache.len())
+	testutils.AssertIntsEqual(t, "persisted proofs", 1, len(persistenceHandle.files))
+
+	_, ok = reloadedCache.get(Hash{1}, 6, 102)
+	testutils.AssertBoolsEqual(t, "reloaded proof cached", true, ok)
+
+	reloadedCache.remove(Hash{1})
+
+	testutils.AssertIntsEqual(t, "reloaded proofs", 0, reloadedCache.len())
+	testutils.AssertIntsEqual(t, "persisted proofs", 0, len(persistenceHandle.files))
+}
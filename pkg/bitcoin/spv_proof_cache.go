@@ -0,0 +1,318 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+)
+
+// spvProofsDirectory is the name of the persistence directory holding the
+// cached SPV proofs.
+const spvProofsDirectory = "spv_proofs"
+
+// spvProofFilePrefix is the prefix of names of files holding cached SPV
+// proofs. The prefix is followed by the hex-encoded transaction hash.
+const spvProofFilePrefix = "proof_"
+
+// DefaultSpvProofCacheCapacity is the default number of SPV proofs kept
+// in the cache.
+const DefaultSpvProofCacheCapacity = 1000
+
+// cachedSpvProof is an SPV proof kept in the cache along with the data
+// needed to decide whether it can still be used.
+type cachedSpvProof struct {
+	// RequiredConfirmations is the number of confirmations the proof was
+	// assembled for.
+	RequiredConfirmations uint
+	// BlockHeight is the height of the block including the transaction.
+	BlockHeight uint
+	// Transaction is the proven transaction serialized in the Witness
+	// format.
+	Transaction []byte
+	// Proof is the assembled SPV proof.
+	Proof *SpvProof
+}
+
+// lastHeader returns the last block header of the proof's headers chain.
+func (csp *cachedSpvProof) lastHeader() (*BlockHeader, error) {
+	headers := csp.Proof.BitcoinHeaders
+	if len(headers) == 0 || len(headers)%BlockHeaderByteLength != 0 {
+		return nil, fmt.Errorf(
+			"invalid headers chain length [%v]",
+			len(headers),
+		)
+	}
+
+	var rawHeader [BlockHeaderByteLength]byte
+	copy(rawHeader[:], headers[len(headers)-BlockHeaderByteLength:])
+
+	header := &BlockHeader{}
+	header.Deserialize(rawHeader)
+
+	return header, nil
+}
+
+// SpvProofCache keeps SPV proofs assembled in the past by transaction hash
+// so they are not assembled again when a proof submission is retried. Once
+// the capacity is reached, the proofs of transactions from the oldest blocks
+// are evicted first. If a persistence handle is set, cached proofs are
+// persisted and loaded again on restart.
+type SpvProofCache struct {
+	persistence persistence.BasicHandle
+	capacity    int
+
+	mutex  sync.Mutex
+	proofs map[Hash]*cachedSpvProof
+}
+
+// NewSpvProofCache creates a new SpvProofCache holding up to the given number
+// of proofs. If the capacity is not positive, DefaultSpvProofCacheCapacity
+// is used. Proofs cached in the past are loaded from the given persistence
+// handle. The persistence handle is optional; if it is nil, proofs are kept
+// in memory only.
+func NewSpvProofCache(
+	persistence persistence.BasicHandle,
+	capacity int,
+) *SpvProofCache {
+	if capacity <= 0 {
+		capacity = DefaultSpvProofCacheCapacity
+	}
+
+	spc := &SpvProofCache{
+		persistence: persistence,
+		capacity:    capacity,
+		proofs:      make(map[Hash]*cachedSpvProof),
+	}
+
+	if persistence != nil {
+		spc.load()
+	}
+
+	spc.mutex.Lock()
+	evicted := spc.evict()
+	spc.mutex.Unlock()
+
+	spc.deleteFiles(evicted)
+
+	return spc
+}
+
+// get returns the cached proof of the given transaction assembled for the
+// given number of confirmations, with the transaction included in the block
+// at the given height.
+func (spc *SpvProofCache) get(
+	transactionHash Hash,
+	requiredConfirmations uint,
+	blockHeight uint,
+) (*cachedSpvProof, bool) {
+	if spc == nil {
+		return nil, false
+	}
+
+	spc.mutex.Lock()
+	defer spc.mutex.Unlock()
+
+	proof, ok := spc.proofs[transactionHash]
+	if !ok ||
+		proof.RequiredConfirmations != requiredConfirmations ||
+		proof.BlockHeight != blockHeight {
+		return nil, false
+	}
+
+	return proof, true
+}
+
+// put caches the proof of the given transaction, replacing the proof cached
+// before, if any.
+func (spc *SpvProofCache) put(transactionHash Hash, proof *cachedSpvProof) {
+	if spc == nil {
+		return
+	}
+
+	spc.mutex.Lock()
+	spc.proofs[transactionHash] = proof
+	evicted := spc.evict()
+	spc.mutex.Unlock()
+
+	spc.deleteFiles(evicted)
+
+	if spc.persistence == nil {
+		return
+	}
+
+	content, err := json.Marshal(proof)
+	if err != nil {
+		logger.Errorf(
+			"cannot encode SPV proof of transaction [%s]: [%v]",
+			transactionHash.Hex(ReversedByteOrder),
+			err,
+		)
+		return
+	}
+
+	if err := spc.persistence.Save(
+		content,
+		spvProofsDirectory,
+		spvProofFileName(transactionHash),
+	); err != nil {
+		logger.Errorf(
+			"cannot persist SPV proof of transaction [%s]: [%v]",
+			transactionHash.Hex(ReversedByteOrder),
+			err,
+		)
+	}
+}
+
+// remove drops the cached proof of the given transaction.
+func (spc *SpvProofCache) remove(transactionHash Hash) {
+	if spc == nil {
+		return
+	}
+
+	spc.mutex.Lock()
+	_, ok := spc.proofs[transactionHash]
+	delete(spc.proofs, transactionHash)
+	spc.mutex.Unlock()
+
+	if ok {
+		spc.deleteFiles([]Hash{transactionHash})
+	}
+}
+
+// len returns the number of cached proofs.
+func (spc *SpvProofCache) len() int {
+	spc.mutex.Lock()
+	defer spc.mutex.Unlock()
+
+	return len(spc.proofs)
+}
+
+// evict drops proofs of transactions from the oldest blocks until the cache
+// does not exceed its capacity. Returns hashes of the evicted transactions.
+// Must be called with the mutex held.
+func (spc *SpvProofCache) evict() []Hash {
+	if len(spc.proofs) <= spc.capacity {
+		return nil
+	}
+
+	hashes := make([]Hash, 0, len(spc.proofs))
+	for hash := range spc.proofs {
+		hashes = append(hashes, hash)
+	}
+
+	sort.Slice(hashes, func(i, j int) bool {
+		return spc.proofs[hashes[i]].BlockHeight <
+			spc.proofs[hashes[j]].BlockHeight
+	})
+
+	evicted := hashes[:len(hashes)-spc.capacity]
+	for _, hash := range evicted {
+		delete(spc.proofs, hash)
+	}
+
+	return evicted
+}
+
+// deleteFiles deletes persisted proofs of the given transactions.
+func (spc *SpvProofCache) deleteFiles(hashes []Hash) {
+	if spc.persistence == nil {
+		return
+	}
+
+	for _, hash := range hashes {
+		if err := spc.persistence.Delete(
+			spvProofsDirectory,
+			spvProofFileName(hash),
+		); err != nil {
+			logger.Errorf(
+				"cannot delete SPV proof of transaction [%s]: [%v]",
+				hash.Hex(ReversedByteOrder),
+				err,
+			)
+		}
+	}
+}
+
+func (spc *SpvProofCache) load() {
+	descriptorsChan, errorsChan := spc.persistence.ReadAll()
+
+	// Two goroutines read from descriptors and errors channels. The reason
+	// for using two goroutines at the same time - one for descriptors and
+	// one for errors - is that channels do not have to be buffered, and we
+	// do not know in what order the information is written to channels.
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for descriptor := range descriptorsChan {
+			if descriptor.Directory() != spvProofsDirectory {
+				continue
+			}
+
+			transactionHash, ok := parseSpvProofFileName(descriptor.Name())
+			if !ok {
+				continue
+			}
+
+			content, err := descriptor.Content()
+			if err != nil {
+				logger.Errorf(
+					"could not read SPV proof from file [%s]: [%v]",
+					descriptor.Name(),
+					err,
+				)
+				continue
+			}
+
+			proof := &cachedSpvProof{}
+			if err := json.Unmarshal(content, proof); err != nil ||
+				proof.Proof == nil {
+				logger.Warnf(
+					"dropping cached SPV proof of transaction [%s]: [%v]",
+					transactionHash.Hex(ReversedByteOrder),
+					err,
+				)
+				continue
+			}
+
+			spc.proofs[transactionHash] = proof
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for err := range errorsChan {
+			logger.Errorf("could not load cached SPV proofs: [%v]", err)
+		}
+	}()
+
+	wg.Wait()
+}
+
+func spvProofFileName(transactionHash Hash) string {
+	return spvProofFilePrefix + transactionHash.Hex(InternalByteOrder)
+}
+
+func parseSpvProofFileName(name string) (Hash, bool) {
+	name = strings.TrimPrefix(name, "/")
+	if !strings.HasPrefix(name, spvProofFilePrefix) {
+		return Hash{}, false
+	}
+
+	transactionHash, err := NewHashFromString(
+		strings.TrimPrefix(name, spvProofFilePrefix),
+		InternalByteOrder,
+	)
+	if err != nil {
+		return Hash{}, false
+	}
+
+	return transactionHash, true
+}
@@ -156,6 +156,30 @@ func (tb *TransactionBuilder) AddOutput(output *TransactionOutput) {
 	tb.internal.AddTxOut(wire.NewTxOut(output.Value, output.PublicKeyScript))
 }
 
+// SetLocktime sets the transaction's locktime. The locktime is a component
+// of signature hashes so this function must be called before
+// ComputeSignatureHashes.
+func (tb *TransactionBuilder) SetLocktime(locktime uint32) {
+	tb.internal.LockTime = locktime
+}
+
+// SetInputSequence sets the sequence number of the input with the given
+// index. Inputs are added with the maximum sequence number by default.
+// The sequence number is a component of signature hashes so this function
+// must be called before ComputeSignatureHashes.
+func (tb *TransactionBuilder) SetInputSequence(
+	inputIndex int,
+	sequence uint32,
+) error {
+	if inputIndex < 0 || inputIndex >= len(tb.internal.TxIn) {
+		return fmt.Errorf("input with index [%v] does not exist", inputIndex)
+	}
+
+	tb.internal.TxIn[inputIndex].Sequence = sequence
+
+	return nil
+}
+
 // ComputeSignatureHashes computes the signature hashes for all transaction
 // inputs and stores them into the builder's state. Elements of the returned
 // slice are ordered in the same way as the transaction inputs they correspond
@@ -6,6 +6,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/btcsuite/btcd/wire"
+
 	"github.com/keep-network/keep-core/internal/testutils"
 )
 
@@ -215,6 +217,42 @@ func TestTransactionBuilder_AddOutput(t *testing.T) {
 	assertInternalOutput(t, builder, 0, output)
 }
 
+func TestTransactionBuilder_SetLocktimeAndInputSequence(t *testing.T) {
+	builder := NewTransactionBuilder(nil) // chain is not relevant here
+
+	builder.internal.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+
+	builder.SetLocktime(1700000000)
+
+	if err := builder.SetInputSequence(0, 0xfffffffd); err != nil {
+		t.Fatal(err)
+	}
+
+	err := builder.SetInputSequence(1, 0xfffffffd)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"input with index [1] does not exist",
+		err.Error(),
+	)
+
+	testutils.AssertIntsEqual(
+		t,
+		"locktime",
+		1700000000,
+		int(builder.internal.LockTime),
+	)
+	testutils.AssertIntsEqual(
+		t,
+		"input sequence",
+		0xfffffffd,
+		int(builder.internal.TxIn[0].Sequence),
+	)
+}
+
 // The goal of this test is making sure that the TransactionBuilder can
 // produce proper signature hashes and apply signatures for all input types,
 // i.e. P2PKH, P2WPKH, P2SH, and P2WSH. This test uses transactions that
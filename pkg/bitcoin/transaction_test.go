@@ -165,6 +165,15 @@ func TestTransaction_WitnessHash(t *testing.T) {
 	)
 }
 
+func TestTransaction_VirtualSize(t *testing.T) {
+	virtualSize := transactionFixture(t).VirtualSize()
+
+	// The transaction has 365 bytes in the Standard format and 676 bytes
+	// in the Witness format so its weight is 365 * 3 + 676 = 1771 weight
+	// units. The virtual size is the weight divided by 4 and rounded up.
+	testutils.AssertIntsEqual(t, "virtual size", 443, int(virtualSize))
+}
+
 // transactionFixture returns a real testnet transaction:
 // https://live.blockcypher.com/btc-testnet/tx/435d4aff6d4bc34134877bd3213c17970142fdd04d4113d534120033b9eecb2e.
 //
@@ -0,0 +1,17 @@
+package walletindex
+
+import "time"
+
+// DefaultSyncInterval is the default interval at which the index is synced
+// with the Bitcoin chain.
+const DefaultSyncInterval = 10 * time.Minute
+
+// Config holds configurable properties.
+type Config struct {
+	// Enabled determines whether transactions of wallets are indexed
+	// locally. Requires the storage directory to be configured.
+	Enabled bool
+	// SyncInterval is the interval at which the index is synced with the
+	// Bitcoin chain.
+	SyncInterval time.Duration
+}
@@ -0,0 +1,555 @@
+// Package walletindex provides a local index of Bitcoin transactions made
+// by wallets. The index follows the Bitcoin chain for a set of wallet public
+// key hashes and persists their transaction history so the history does not
+// have to be fetched from the Bitcoin chain backend over and over again.
+package walletindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-log"
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+var logger = log.Logger("keep-bitcoin-walletindex")
+
+// walletsDirectory is the name of the persistence directory holding the
+// indexed wallet transactions.
+const walletsDirectory = "wallets"
+
+// walletFilePrefix is the prefix of names of files holding transactions of
+// specific wallets. The prefix is followed by the hex-encoded wallet public
+// key hash.
+const walletFilePrefix = "wallet_"
+
+// WalletSource returns public key hashes of wallets whose transactions
+// should be indexed.
+type WalletSource func() ([][20]byte, error)
+
+// wallet holds the indexed transaction history of a single wallet.
+type wallet struct {
+	// syncMutex makes sure a single wallet is synced by one caller at a time.
+	syncMutex sync.Mutex
+
+	// hashes holds hashes of the wallet's confirmed transactions ordered by
+	// block height in the ascending order, the same way the Bitcoin chain
+	// returns them.
+	hashes []bitcoin.Hash
+	// transactions holds the wallet's confirmed transactions by their hashes.
+	transactions map[bitcoin.Hash]*bitcoin.Transaction
+}
+
+// Index is a local index of transactions of wallets returned by the wallet
+// source. Index implements the bitcoin.Chain interface and serves the
+// transaction history and unspent outputs of indexed wallets from the index.
+// Before serving a query, the history of the given wallet is synced with the
+// Bitcoin chain by fetching only the transaction hashes and the transactions
+// not seen before. Transactions that disappear from the wallet's history,
+// e.g. as a result of a chain reorganization, are dropped from the index.
+// Queries for other wallets and all other calls are delegated to the
+// underlying chain.
+type Index struct {
+	bitcoin.Chain
+
+	persistence  persistence.BasicHandle
+	walletSource WalletSource
+
+	mutex sync.Mutex
+	// followed holds public key hashes of wallets currently returned by the
+	// wallet source.
+	followed map[[20]byte]bool
+	wallets  map[[20]byte]*wallet
+}
+
+// NewIndex creates a new Index on top of the given chain. Wallet transactions
+// indexed in the past are loaded from the given persistence handle. The
+// index follows wallets returned by the given wallet source once synced.
+func NewIndex(
+	chain bitcoin.Chain,
+	persistence persistence.BasicHandle,
+	walletSource WalletSource,
+) *Index {
+	index := &Index{
+		Chain:        chain,
+		persistence:  persistence,
+		walletSource: walletSource,
+		followed:     make(map[[20]byte]bool),
+		wallets:      make(map[[20]byte]*wallet),
+	}
+
+	index.load()
+
+	logger.Infof(
+		"loaded transactions of [%v] wallets from the local index",
+		len(index.wallets),
+	)
+
+	return index
+}
+
+// Observe syncs the index with the Bitcoin chain right away and then at the
+// given interval, until the context is done.
+func (i *Index) Observe(ctx context.Context, syncInterval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := i.Sync(); err != nil {
+				logger.Warnf("cannot sync wallet index: [%v]", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Sync updates the set of followed wallets using the wallet source and syncs
+// transaction histories of all followed wallets with the Bitcoin chain.
+// Wallets no longer returned by the wallet source are removed from the
+// index. A failure to sync a single wallet does not stop the sync of other
+// wallets.
+func (i *Index) Sync() error {
+	publicKeyHashes, err := i.walletSource()
+	if err != nil {
+		return fmt.Errorf("cannot get wallets to index: [%w]", err)
+	}
+
+	followed := make(map[[20]byte]bool, len(publicKeyHashes))
+	for _, publicKeyHash := range publicKeyHashes {
+		followed[publicKeyHash] = true
+	}
+
+	i.mutex.Lock()
+	var unfollowed [][20]byte
+	for publicKeyHash := range i.wallets {
+		if !followed[publicKeyHash] {
+			unfollowed = append(unfollowed, publicKeyHash)
+			delete(i.wallets, publicKeyHash)
+		}
+	}
+	i.followed = followed
+	i.mutex.Unlock()
+
+	for _, publicKeyHash := range unfollowed {
+		logger.Infof(
+			"removing wallet [0x%x] from the local index",
+			publicKeyHash,
+		)
+
+		err := i.persistence.Delete(
+			walletsDirectory,
+			walletFileName(publicKeyHash),
+		)
+		if err != nil {
+			logger.Errorf(
+				"cannot delete indexed transactions of wallet [0x%x]: [%v]",
+				publicKeyHash,
+				err,
+			)
+		}
+	}
+
+	failedCount := 0
+	for _, publicKeyHash := range publicKeyHashes {
+		if _, _, err := i.syncWallet(publicKeyHash); err != nil {
+			logger.Warnf(
+				"cannot sync indexed transactions of wallet [0x%x]: [%v]",
+				publicKeyHash,
+				err,
+			)
+			failedCount++
+		}
+	}
+
+	if failedCount > 0 {
+		return fmt.Errorf(
+			"[%v] out of [%v] wallets failed to sync",
+			failedCount,
+			len(publicKeyHashes),
+		)
+	}
+
+	return nil
+}
+
+// GetTransaction gets the transaction with the given hash from the index.
+// If the transaction is not indexed, the call is delegated to the
+// underlying chain.
+func (i *Index) GetTransaction(
+	transactionHash bitcoin.Hash,
+) (*bitcoin.Transaction, error) {
+	i.mutex.Lock()
+	for _, wallet := range i.wallets {
+		if transaction, ok := wallet.transactions[transactionHash]; ok {
+			i.mutex.Unlock()
+			return transaction, nil
+		}
+	}
+	i.mutex.Unlock()
+
+	return i.Chain.GetTransaction(transactionHash)
+}
+
+// GetTransactionsForPublicKeyHash gets the confirmed transactions of the
+// given wallet. Transactions of followed wallets are served from the index.
+// See bitcoin.Chain for details.
+func (i *Index) GetTransactionsForPublicKeyHash(
+	publicKeyHash [20]byte,
+	limit int,
+) ([]*bitcoin.Transaction, error) {
+	if !i.isFollowed(publicKeyHash) {
+		return i.Chain.GetTransactionsForPublicKeyHash(publicKeyHash, limit)
+	}
+
+	hashes, transactions, err := i.syncWallet(publicKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sync wallet index: [%w]", err)
+	}
+
+	if len(hashes) > limit {
+		hashes = hashes[len(hashes)-limit:]
+	}
+
+	result := make([]*bitcoin.Transaction, len(hashes))
+	for j, hash := range hashes {
+		result[j] = transactions[hash]
+	}
+
+	return result, nil
+}
+
+// GetTxHashesForPublicKeyHash gets hashes of confirmed transactions of the
+// given wallet. Hashes of followed wallets are served from the index.
+// See bitcoin.Chain for details.
+func (i *Index) GetTxHashesForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]bitcoin.Hash, error) {
+	if !i.isFollowed(publicKeyHash) {
+		return i.Chain.GetTxHashesForPublicKeyHash(publicKeyHash)
+	}
+
+	hashes, _, err := i.syncWallet(publicKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sync wallet index: [%w]", err)
+	}
+
+	return append([]bitcoin.Hash{}, hashes...), nil
+}
+
+// GetUtxosForPublicKeyHash gets unspent outputs of confirmed transactions
+// controlled by the given wallet. Unspent outputs of followed wallets are
+// computed from the indexed transactions. Outputs spent by transactions
+// living in the mempool are taken from the underlying chain and excluded.
+// See bitcoin.Chain for details.
+func (i *Index) GetUtxosForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.UnspentTransactionOutput, error) {
+	if !i.isFollowed(publicKeyHash) {
+		return i.Chain.GetUtxosForPublicKeyHash(publicKeyHash)
+	}
+
+	hashes, transactions, err := i.syncWallet(publicKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sync wallet index: [%w]", err)
+	}
+
+	mempoolTransactions, err := i.Chain.GetMempoolForPublicKeyHash(
+		publicKeyHash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot get mempool transactions: [%w]",
+			err,
+		)
+	}
+
+	spentOutpoints := make(map[bitcoin.TransactionOutpoint]bool)
+	markSpentOutpoints := func(transaction *bitcoin.Transaction) {
+		for _, input := range transaction.Inputs {
+			spentOutpoints[*input.Outpoint] = true
+		}
+	}
+
+	for _, hash := range hashes {
+		markSpentOutpoints(transactions[hash])
+	}
+	for _, transaction := range mempoolTransactions {
+		markSpentOutpoints(transaction)
+	}
+
+	walletP2PKH, err := bitcoin.PayToPublicKeyHash(publicKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot construct P2PKH for wallet: [%w]", err)
+	}
+	walletP2WPKH, err := bitcoin.PayToWitnessPublicKeyHash(publicKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot construct P2WPKH for wallet: [%w]", err)
+	}
+
+	utxos := make([]*bitcoin.UnspentTransactionOutput, 0)
+	for _, hash := range hashes {
+		for outputIndex, output := range transactions[hash].Outputs {
+			script := output.PublicKeyScript
+			if !bytes.Equal(script, walletP2PKH) &&
+				!bytes.Equal(script, walletP2WPKH) {
+				continue
+			}
+
+			outpoint := &bitcoin.TransactionOutpoint{
+				TransactionHash: hash,
+				OutputIndex:     uint32(outputIndex),
+			}
+			if spentOutpoints[*outpoint] {
+				continue
+			}
+
+			utxos = append(utxos, &bitcoin.UnspentTransactionOutput{
+				Outpoint: outpoint,
+				Value:    output.Value,
+			})
+		}
+	}
+
+	return utxos, nil
+}
+
+func (i *Index) isFollowed(publicKeyHash [20]byte) bool {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	return i.followed[publicKeyHash]
+}
+
+// syncWallet syncs the transaction history of the given wallet with the
+// Bitcoin chain and returns the synced history. Only transactions that are
+// not indexed yet are fetched from the chain.
+func (i *Index) syncWallet(publicKeyHash [20]byte) (
+	[]bitcoin.Hash,
+	map[bitcoin.Hash]*bitcoin.Transaction,
+	error,
+) {
+	i.mutex.Lock()
+	w, ok := i.wallets[publicKeyHash]
+	if !ok {
+		w = &wallet{transactions: make(map[bitcoin.Hash]*bitcoin.Transaction)}
+		i.wallets[publicKeyHash] = w
+	}
+	i.mutex.Unlock()
+
+	w.syncMutex.Lock()
+	defer w.syncMutex.Unlock()
+
+	// The wallet's history can only be replaced under the sync mutex so
+	// it is safe to read it without holding the index mutex.
+	indexedHashes, indexedTransactions := w.hashes, w.transactions
+
+	hashes, err := i.Chain.GetTxHashesForPublicKeyHash(publicKeyHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"cannot get transaction hashes: [%w]",
+			err,
+		)
+	}
+
+	changed := len(hashes) != len(indexedHashes)
+	transactions := make(map[bitcoin.Hash]*bitcoin.Transaction, len(hashes))
+
+	for j, hash := range hashes {
+		if transaction, ok := indexedTransactions[hash]; ok {
+			transactions[hash] = transaction
+			changed = changed || indexedHashes[j] != hash
+			continue
+		}
+
+		transaction, err := i.Chain.GetTransaction(hash)
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"cannot get transaction [%s]: [%w]",
+				hash.Hex(bitcoin.ReversedByteOrder),
+				err,
+			)
+		}
+
+		transactions[hash] = transaction
+		changed = true
+	}
+
+	if !changed {
+		return indexedHashes, indexedTransactions, nil
+	}
+
+	for _, hash := range indexedHashes {
+		if _, ok := transactions[hash]; !ok {
+			logger.Warnf(
+				"transaction [%s] of wallet [0x%x] is no longer "+
+					"confirmed; dropping it from the local index",
+				hash.Hex(bitcoin.ReversedByteOrder),
+				publicKeyHash,
+			)
+		}
+	}
+
+	i.mutex.Lock()
+	w.hashes, w.transactions = hashes, transactions
+	i.mutex.Unlock()
+
+	if err := i.save(publicKeyHash, hashes, transactions); err != nil {
+		logger.Errorf(
+			"cannot persist indexed transactions of wallet [0x%x]: [%v]",
+			publicKeyHash,
+			err,
+		)
+	}
+
+	return hashes, transactions, nil
+}
+
+// save persists the transaction history of the given wallet. Transactions
+// are stored in the history order, each one prefixed with its length.
+func (i *Index) save(
+	publicKeyHash [20]byte,
+	hashes []bitcoin.Hash,
+	transactions map[bitcoin.Hash]*bitcoin.Transaction,
+) error {
+	var buffer bytes.Buffer
+	for _, hash := range hashes {
+		serialized := transactions[hash].Serialize()
+
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(serialized)))
+
+		buffer.Write(length[:])
+		buffer.Write(serialized)
+	}
+
+	return i.persistence.Save(
+		buffer.Bytes(),
+		walletsDirectory,
+		walletFileName(publicKeyHash),
+	)
+}
+
+func (i *Index) load() {
+	descriptorsChan, errorsChan := i.persistence.ReadAll()
+
+	// Two goroutines read from descriptors and errors channels. The reason
+	// for using two goroutines at the same time - one for descriptors and
+	// one for errors - is that channels do not have to be buffered, and we
+	// do not know in what order the information is written to channels.
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for descriptor := range descriptorsChan {
+			if descriptor.Directory() != walletsDirectory {
+				continue
+			}
+
+			publicKeyHash, ok := parseWalletFileName(descriptor.Name())
+			if !ok {
+				continue
+			}
+
+			content, err := descriptor.Content()
+			if err != nil {
+				logger.Errorf(
+					"could not read wallet transactions from file [%s]: [%v]",
+					descriptor.Name(),
+					err,
+				)
+				continue
+			}
+
+			w, err := decodeWallet(content)
+			if err != nil {
+				logger.Warnf(
+					"dropping indexed transactions of wallet [0x%x]: [%v]",
+					publicKeyHash,
+					err,
+				)
+				continue
+			}
+
+			i.wallets[publicKeyHash] = w
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for err := range errorsChan {
+			logger.Errorf("could not load wallet transactions: [%v]", err)
+		}
+	}()
+
+	wg.Wait()
+}
+
+func decodeWallet(content []byte) (*wallet, error) {
+	w := &wallet{transactions: make(map[bitcoin.Hash]*bitcoin.Transaction)}
+
+	for offset := 0; offset < len(content); {
+		if len(content)-offset < 4 {
+			return nil, fmt.Errorf("truncated transaction length")
+		}
+
+		length := int(binary.BigEndian.Uint32(content[offset:]))
+		offset += 4
+
+		if len(content)-offset < length {
+			return nil, fmt.Errorf("truncated transaction")
+		}
+
+		transaction := &bitcoin.Transaction{}
+		if err := transaction.Deserialize(
+			content[offset : offset+length],
+		); err != nil {
+			return nil, fmt.Errorf("cannot deserialize transaction: [%w]", err)
+		}
+		offset += length
+
+		hash := transaction.Hash()
+		w.hashes = append(w.hashes, hash)
+		w.transactions[hash] = transaction
+	}
+
+	return w, nil
+}
+
+func walletFileName(publicKeyHash [20]byte) string {
+	return walletFilePrefix + hex.EncodeToString(publicKeyHash[:])
+}
+
+func parseWalletFileName(name string) ([20]byte, bool) {
+	var publicKeyHash [20]byte
+
+	name = strings.TrimPrefix(name, "/")
+	if !strings.HasPrefix(name, walletFilePrefix) {
+		return publicKeyHash, false
+	}
+
+	decoded, err := hex.DecodeString(strings.TrimPrefix(name, walletFilePrefix))
+	if err != nil || len(decoded) != len(publicKeyHash) {
+		return publicKeyHash, false
+	}
+
+	copy(publicKeyHash[:], decoded)
+
+	return publicKeyHash, true
+}
@@ -0,0 +1,542 @@
+package walletindex
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+var (
+	testWalletPublicKeyHash = [20]byte{0x01}
+	testOtherPublicKeyHash  = [20]byte{0x02}
+)
+
+func TestIndex_GetTransactionsForPublicKeyHash(t *testing.T) {
+	chain := newLocalChain()
+	transactions := chain.addHistory(t, testWalletPublicKeyHash, 3)
+
+	index := NewIndex(
+		chain,
+		newLocalPersistenceHandle(),
+		staticWalletSource(testWalletPublicKeyHash),
+	)
+	if err := index.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"transactions fetched by the initial sync",
+		3,
+		chain.getTransactionCalls,
+	)
+
+	// New transaction of the wallet, not indexed yet.
+	transactions = append(
+		transactions,
+		chain.addHistory(t, testWalletPublicKeyHash, 1)...,
+	)
+
+	result, err := index.GetTransactionsForPublicKeyHash(
+		testWalletPublicKeyHash,
+		2,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertTransactions(t, transactions[2:], result)
+
+	testutils.AssertIntsEqual(
+		t,
+		"transactions fetched in total",
+		4,
+		chain.getTransactionCalls,
+	)
+
+	result, err = index.GetTransactionsForPublicKeyHash(
+		testWalletPublicKeyHash,
+		10,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertTransactions(t, transactions, result)
+
+	testutils.AssertIntsEqual(
+		t,
+		"transactions fetched in total",
+		4,
+		chain.getTransactionCalls,
+	)
+}
+
+func TestIndex_Reorg(t *testing.T) {
+	chain := newLocalChain()
+	transactions := chain.addHistory(t, testWalletPublicKeyHash, 3)
+
+	index := NewIndex(
+		chain,
+		newLocalPersistenceHandle(),
+		staticWalletSource(testWalletPublicKeyHash),
+	)
+	if err := index.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last transaction is no longer confirmed and a conflicting one got
+	// confirmed instead.
+	chain.dropLastFromHistory(testWalletPublicKeyHash)
+	replacement := newTestTransaction(
+		t,
+		transactions[1].Hash(),
+		testWalletPublicKeyHash,
+	)
+	replacement.Outputs[0].Value = 900
+	chain.appendToHistory(testWalletPublicKeyHash, replacement)
+
+	result, err := index.GetTransactionsForPublicKeyHash(
+		testWalletPublicKeyHash,
+		10,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertTransactions(
+		t,
+		append(transactions[:2:2], replacement),
+		result,
+	)
+}
+
+func TestIndex_GetUtxosForPublicKeyHash(t *testing.T) {
+	chain := newLocalChain()
+	transactions := chain.addHistory(t, testWalletPublicKeyHash, 3)
+
+	index := NewIndex(
+		chain,
+		newLocalPersistenceHandle(),
+		staticWalletSource(testWalletPublicKeyHash),
+	)
+	if err := index.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each transaction of the history spends the P2WPKH output of the
+	// previous one. The P2WPKH output of the last transaction is spent by
+	// a mempool transaction.
+	chain.mempool[testWalletPublicKeyHash] = []*bitcoin.Transaction{
+		newTestTransaction(t, transactions[2].Hash(), testOtherPublicKeyHash),
+	}
+
+	utxos, err := index.GetUtxosForPublicKeyHash(testWalletPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only P2PKH outputs of the wallet's transactions remain unspent.
+	var expectedUtxos []*bitcoin.UnspentTransactionOutput
+	for _, transaction := range transactions {
+		expectedUtxos = append(expectedUtxos, &bitcoin.UnspentTransactionOutput{
+			Outpoint: &bitcoin.TransactionOutpoint{
+				TransactionHash: transaction.Hash(),
+				OutputIndex:     1,
+			},
+			Value: 2000,
+		})
+	}
+
+	if !reflect.DeepEqual(expectedUtxos, utxos) {
+		t.Errorf(
+			"unexpected UTXOs\nexpected: %v\nactual:   %v",
+			expectedUtxos,
+			utxos,
+		)
+	}
+}
+
+func TestIndex_UnfollowedWallet(t *testing.T) {
+	chain := newLocalChain()
+	chain.addHistory(t, testWalletPublicKeyHash, 2)
+	chain.addHistory(t, testOtherPublicKeyHash, 2)
+
+	persistenceHandle := newLocalPersistenceHandle()
+
+	wallets := [][20]byte{testWalletPublicKeyHash, testOtherPublicKeyHash}
+	index := NewIndex(
+		chain,
+		persistenceHandle,
+		func() ([][20]byte, error) {
+			return wallets, nil
+		},
+	)
+	if err := index.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "persisted wallets", 2, len(persistenceHandle.files))
+
+	// The other wallet is no longer returned by the wallet source.
+	wallets = [][20]byte{testWalletPublicKeyHash}
+	if err := index.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "persisted wallets", 1, len(persistenceHandle.files))
+
+	callsBefore := chain.getTransactionCalls
+
+	_, err := index.GetTransactionsForPublicKeyHash(testOtherPublicKeyHash, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"transactions fetched for the unfollowed wallet",
+		2,
+		chain.getTransactionCalls-callsBefore,
+	)
+}
+
+func TestIndex_Persistence(t *testing.T) {
+	chain := newLocalChain()
+	transactions := chain.addHistory(t, testWalletPublicKeyHash, 3)
+
+	persistenceHandle := newLocalPersistenceHandle()
+
+	index := NewIndex(
+		chain,
+		persistenceHandle,
+		staticWalletSource(testWalletPublicKeyHash),
+	)
+	if err := index.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file that is not a wallet file should be ignored.
+	persistenceHandle.files["unknown"] = []byte{0x01}
+
+	chain.getTransactionCalls = 0
+
+	restoredIndex := NewIndex(
+		chain,
+		persistenceHandle,
+		staticWalletSource(testWalletPublicKeyHash),
+	)
+	if err := restoredIndex.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := restoredIndex.GetTransactionsForPublicKeyHash(
+		testWalletPublicKeyHash,
+		10,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertTransactions(t, transactions, result)
+
+	testutils.AssertIntsEqual(
+		t,
+		"transactions fetched by the restored index",
+		0,
+		chain.getTransactionCalls,
+	)
+}
+
+func TestIndex_SyncFailure(t *testing.T) {
+	chain := newLocalChain()
+	chain.addHistory(t, testWalletPublicKeyHash, 1)
+	chain.historyErr = fmt.Errorf("unavailable")
+
+	index := NewIndex(
+		chain,
+		newLocalPersistenceHandle(),
+		staticWalletSource(testWalletPublicKeyHash, testOtherPublicKeyHash),
+	)
+
+	err := index.Sync()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"[2] out of [2] wallets failed to sync",
+		err.Error(),
+	)
+
+	_, err = index.GetTransactionsForPublicKeyHash(testWalletPublicKeyHash, 1)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func staticWalletSource(publicKeyHashes ...[20]byte) WalletSource {
+	return func() ([][20]byte, error) {
+		return publicKeyHashes, nil
+	}
+}
+
+func assertTransactions(
+	t *testing.T,
+	expected []*bitcoin.Transaction,
+	actual []*bitcoin.Transaction,
+) {
+	testutils.AssertIntsEqual(t, "transactions count", len(expected), len(actual))
+
+	for i := range expected {
+		if expected[i].Hash() != actual[i].Hash() {
+			t.Errorf(
+				"unexpected transaction [%v]\nexpected: %s\nactual:   %s",
+				i,
+				expected[i].Hash().Hex(bitcoin.ReversedByteOrder),
+				actual[i].Hash().Hex(bitcoin.ReversedByteOrder),
+			)
+		}
+	}
+}
+
+// newTestTransaction creates a transaction spending the first output of the
+// given transaction and paying to P2WPKH and P2PKH outputs of the given
+// public key hash.
+func newTestTransaction(
+	t *testing.T,
+	previousTransactionHash bitcoin.Hash,
+	publicKeyHash [20]byte,
+) *bitcoin.Transaction {
+	p2wpkh, err := bitcoin.PayToWitnessPublicKeyHash(publicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2pkh, err := bitcoin.PayToPublicKeyHash(publicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: previousTransactionHash,
+					OutputIndex:     0,
+				},
+				Witness:  [][]byte{{0x01}},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 1000, PublicKeyScript: p2wpkh},
+			{Value: 2000, PublicKeyScript: p2pkh},
+		},
+	}
+}
+
+// localChain is a bitcoin.Chain stub holding transaction histories of
+// public key hashes.
+type localChain struct {
+	bitcoin.Chain
+
+	transactions map[bitcoin.Hash]*bitcoin.Transaction
+	histories    map[[20]byte][]bitcoin.Hash
+	mempool      map[[20]byte][]*bitcoin.Transaction
+	historyErr   error
+
+	getTransactionCalls int
+}
+
+func newLocalChain() *localChain {
+	return &localChain{
+		transactions: make(map[bitcoin.Hash]*bitcoin.Transaction),
+		histories:    make(map[[20]byte][]bitcoin.Hash),
+		mempool:      make(map[[20]byte][]*bitcoin.Transaction),
+	}
+}
+
+// addHistory appends the given number of new transactions to the history of
+// the given public key hash. Each transaction spends the first output of the
+// previous one.
+func (lc *localChain) addHistory(
+	t *testing.T,
+	publicKeyHash [20]byte,
+	count int,
+) []*bitcoin.Transaction {
+	previousTransactionHash := bitcoin.Hash{byte(len(lc.transactions) + 1)}
+	if history := lc.histories[publicKeyHash]; len(history) > 0 {
+		previousTransactionHash = history[len(history)-1]
+	}
+
+	var transactions []*bitcoin.Transaction
+	for i := 0; i < count; i++ {
+		transaction := newTestTransaction(
+			t,
+			previousTransactionHash,
+			publicKeyHash,
+		)
+		lc.appendToHistory(publicKeyHash, transaction)
+
+		transactions = append(transactions, transaction)
+		previousTransactionHash = transaction.Hash()
+	}
+
+	return transactions
+}
+
+func (lc *localChain) appendToHistory(
+	publicKeyHash [20]byte,
+	transaction *bitcoin.Transaction,
+) {
+	hash := transaction.Hash()
+
+	lc.transactions[hash] = transaction
+	lc.histories[publicKeyHash] = append(lc.histories[publicKeyHash], hash)
+}
+
+func (lc *localChain) dropLastFromHistory(publicKeyHash [20]byte) {
+	history := lc.histories[publicKeyHash]
+	lc.histories[publicKeyHash] = history[:len(history)-1]
+}
+
+func (lc *localChain) GetTransaction(
+	transactionHash bitcoin.Hash,
+) (*bitcoin.Transaction, error) {
+	lc.getTransactionCalls++
+
+	transaction, ok := lc.transactions[transactionHash]
+	if !ok {
+		return nil, fmt.Errorf("transaction not found")
+	}
+
+	return transaction, nil
+}
+
+func (lc *localChain) GetTransactionsForPublicKeyHash(
+	publicKeyHash [20]byte,
+	limit int,
+) ([]*bitcoin.Transaction, error) {
+	hashes, err := lc.GetTxHashesForPublicKeyHash(publicKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hashes) > limit {
+		hashes = hashes[len(hashes)-limit:]
+	}
+
+	transactions := make([]*bitcoin.Transaction, len(hashes))
+	for i, hash := range hashes {
+		transactions[i], err = lc.GetTransaction(hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return transactions, nil
+}
+
+func (lc *localChain) GetTxHashesForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]bitcoin.Hash, error) {
+	if lc.historyErr != nil {
+		return nil, lc.historyErr
+	}
+
+	return append([]bitcoin.Hash{}, lc.histories[publicKeyHash]...), nil
+}
+
+func (lc *localChain) GetMempoolForPublicKeyHash(
+	publicKeyHash [20]byte,
+) ([]*bitcoin.Transaction, error) {
+	return lc.mempool[publicKeyHash], nil
+}
+
+type localPersistenceHandle struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+func newLocalPersistenceHandle() *localPersistenceHandle {
+	return &localPersistenceHandle{
+		files: make(map[string][]byte),
+	}
+}
+
+func (lph *localPersistenceHandle) Save(
+	data []byte,
+	directory string,
+	name string,
+) error {
+	lph.mutex.Lock()
+	defer lph.mutex.Unlock()
+
+	if directory != walletsDirectory {
+		return fmt.Errorf("unexpected directory [%v]", directory)
+	}
+
+	lph.files[name] = append([]byte{}, data...)
+
+	return nil
+}
+
+func (lph *localPersistenceHandle) ReadAll() (
+	<-chan persistence.DataDescriptor,
+	<-chan error,
+) {
+	lph.mutex.Lock()
+	defer lph.mutex.Unlock()
+
+	outputData := make(chan persistence.DataDescriptor, len(lph.files))
+	outputErrors := make(chan error)
+
+	for name, content := range lph.files {
+		outputData <- &localDescriptor{
+			name:      name,
+			directory: walletsDirectory,
+			content:   append([]byte{}, content...),
+		}
+	}
+
+	close(outputData)
+	close(outputErrors)
+
+	return outputData, outputErrors
+}
+
+func (lph *localPersistenceHandle) Delete(directory string, name string) error {
+	lph.mutex.Lock()
+	defer lph.mutex.Unlock()
+
+	delete(lph.files, name)
+
+	return nil
+}
+
+type localDescriptor struct {
+	name      string
+	directory string
+	content   []byte
+}
+
+func (ld *localDescriptor) Name() string {
+	return ld.name
+}
+
+func (ld *localDescriptor) Directory() string {
+	return ld.directory
+}
+
+func (ld *localDescriptor) Content() ([]byte, error) {
+	return ld.content, nil
+}
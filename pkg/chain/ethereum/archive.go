@@ -0,0 +1,70 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/keep-network/keep-common/pkg/chain/ethereum/ethutil"
+)
+
+// blockPinnedClient is an Ethereum client executing all contract calls that
+// do not ask for a specific block against the state as of the pinned block.
+// Reading the state of old blocks requires the client to be connected to
+// an archive node.
+type blockPinnedClient struct {
+	ethutil.EthereumClient
+
+	blockNumber *big.Int
+}
+
+func (bpc *blockPinnedClient) CallContract(
+	ctx context.Context,
+	call goethereum.CallMsg,
+	blockNumber *big.Int,
+) ([]byte, error) {
+	if blockNumber == nil {
+		blockNumber = bpc.blockNumber
+	}
+
+	return bpc.EthereumClient.CallContract(ctx, call, blockNumber)
+}
+
+func (bpc *blockPinnedClient) CodeAt(
+	ctx context.Context,
+	contract common.Address,
+	blockNumber *big.Int,
+) ([]byte, error) {
+	if blockNumber == nil {
+		blockNumber = bpc.blockNumber
+	}
+
+	return bpc.EthereumClient.CodeAt(ctx, contract, blockNumber)
+}
+
+// AtBlock returns a TBTC chain handle whose contract calls return the chain
+// state as of the given block. Contracts are resolved as of that block as
+// well. The returned handle is meant to be used for reading only; the
+// connected Ethereum client must be an archive node unless the block is
+// recent.
+func (tc *TbtcChain) AtBlock(blockNumber uint64) (*TbtcChain, error) {
+	pinnedBaseChain := *tc.baseChain
+	pinnedBaseChain.client = &blockPinnedClient{
+		EthereumClient: tc.baseChain.client,
+		blockNumber:    new(big.Int).SetUint64(blockNumber),
+	}
+
+	pinnedChain, err := newTbtcChain(tc.config, &pinnedBaseChain)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot attach to contracts at block [%v]: [%v]",
+			blockNumber,
+			err,
+		)
+	}
+
+	return pinnedChain, nil
+}
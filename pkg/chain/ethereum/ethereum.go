@@ -427,6 +427,28 @@ func (bc *baseChain) GetBlockHashByNumber(blockNumber uint64) (
 	return header.Hash(), nil
 }
 
+// GetBlockTimeByNumber gets the timestamp of the given block number.
+func (bc *baseChain) GetBlockTimeByNumber(blockNumber uint64) (
+	time.Time,
+	error,
+) {
+	header, err := bc.headerByNumber(blockNumber)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot get block header: [%v]", err)
+	}
+
+	return time.Unix(int64(header.Time), 0), nil
+}
+
+// GasPrice returns the gas price suggested by the Ethereum client, in wei.
+// Times out if the underlying client call takes more than 30 seconds.
+func (bc *baseChain) GasPrice() (*big.Int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelCtx()
+
+	return bc.client.SuggestGasPrice(ctx)
+}
+
 // currentBlock fetches the current block.
 func (bc *baseChain) currentBlock() (*types.Block, error) {
 	currentBlockNumber, err := bc.blockCounter.CurrentBlock()
@@ -51,6 +51,8 @@ const (
 type TbtcChain struct {
 	*baseChain
 
+	config ethereum.Config
+
 	bridge                  *tbtccontract.Bridge
 	maintainerProxy         *tbtccontract.MaintainerProxy
 	walletRegistry          *ecdsacontract.WalletRegistry
@@ -240,6 +242,7 @@ func newTbtcChain(
 
 	return &TbtcChain{
 		baseChain:               baseChain,
+		config:                  config,
 		bridge:                  bridge,
 		maintainerProxy:         maintainerProxy,
 		walletRegistry:          walletRegistry,
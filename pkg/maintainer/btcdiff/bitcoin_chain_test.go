@@ -2,6 +2,7 @@ package btcdiff
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/keep-network/keep-core/pkg/bitcoin"
 )
@@ -10,6 +11,8 @@ var errNoBlocksSet = fmt.Errorf("blockchain does not contain any blocks")
 
 // localBitcoinChain represents a local Bitcoin chain.
 type localBitcoinChain struct {
+	mutex sync.Mutex
+
 	blockHeaders map[uint]*bitcoin.BlockHeader
 }
 
@@ -45,6 +48,9 @@ func (lbc *localBitcoinChain) BroadcastTransaction(
 // GetLatestBlockHeight gets the height of the latest block (tip). If the
 // latest block was not determined, this function returns an error.
 func (lbc *localBitcoinChain) GetLatestBlockHeight() (uint, error) {
+	lbc.mutex.Lock()
+	defer lbc.mutex.Unlock()
+
 	blockchainTip := uint(0)
 	for blockHeaderHeight := range lbc.blockHeaders {
 		if blockHeaderHeight > blockchainTip {
@@ -65,6 +71,9 @@ func (lbc *localBitcoinChain) GetLatestBlockHeight() (uint, error) {
 func (lbc *localBitcoinChain) GetBlockHeader(
 	blockNumber uint,
 ) (*bitcoin.BlockHeader, error) {
+	lbc.mutex.Lock()
+	defer lbc.mutex.Unlock()
+
 	blockHeader, found := lbc.blockHeaders[blockNumber]
 	if !found {
 		return nil, fmt.Errorf(
@@ -118,6 +127,9 @@ func (lbc *localBitcoinChain) GetMempoolUtxosForPublicKeyHash(
 func (lbc *localBitcoinChain) SetBlockHeaders(
 	blockHeaders map[uint]*bitcoin.BlockHeader,
 ) {
+	lbc.mutex.Lock()
+	defer lbc.mutex.Unlock()
+
 	lbc.blockHeaders = blockHeaders
 }
 
@@ -8,6 +8,7 @@ import (
 	"github.com/ipfs/go-log/v2"
 
 	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/maintainer/submission"
 )
 
 var logger = log.Logger("keep-maintainer-btcdiff")
@@ -44,6 +45,8 @@ func Initialize(
 	config Config,
 	btcChain bitcoin.Chain,
 	chain Chain,
+	submissionPolicy *submission.Policy,
+	retargetRequests *RetargetRequests,
 ) {
 	if config.RestartBackOffTime == 0 {
 		config.RestartBackOffTime = bitcoinDifficultyDefaultRestartBackoffTime
@@ -53,9 +56,11 @@ func Initialize(
 	}
 
 	bitcoinDifficultyMaintainer := &bitcoinDifficultyMaintainer{
-		config:   config,
-		btcChain: btcChain,
-		chain:    chain,
+		config:           config,
+		btcChain:         btcChain,
+		chain:            chain,
+		submissionPolicy: submissionPolicy,
+		retargetRequests: retargetRequests,
 	}
 
 	go bitcoinDifficultyMaintainer.startControlLoop(ctx)
@@ -67,6 +72,13 @@ type bitcoinDifficultyMaintainer struct {
 	config   Config
 	btcChain bitcoin.Chain
 	chain    Chain
+
+	// submissionPolicy is nil if retargets should be submitted regardless
+	// of the gas price.
+	submissionPolicy *submission.Policy
+	// retargetRequests is nil if no other maintainer can request proving
+	// Bitcoin epochs.
+	retargetRequests *RetargetRequests
 }
 
 // startControlLoop starts the loop responsible for controlling the Bitcoin
@@ -116,10 +128,18 @@ func (bdm *bitcoinDifficultyMaintainer) proveEpochs(ctx context.Context) error {
 		// Sleep for some time if the Bitcoin epoch was not proven (i.e. Bitcoin
 		// difficulty chain is up-to-date or there are not enough block headers
 		// in the new epoch). Do not sleep if a Bitcoin epoch was proven as
-		// there are likely more Bitcoin epochs to prove.
+		// there are likely more Bitcoin epochs to prove. Wake up early if
+		// another maintainer requests proving Bitcoin epochs.
 		if !epochProven {
 			select {
 			case <-time.After(bdm.config.IdleBackOffTime):
+			case <-bdm.retargetRequests.channel():
+				if request := bdm.retargetRequests.Pending(); request != nil {
+					logger.Infof(
+						"received request to prove Bitcoin epochs up to [%d]",
+						request.Epoch,
+					)
+				}
 			case <-ctx.Done():
 				return ctx.Err()
 			}
@@ -253,6 +273,25 @@ func (bdm *bitcoinDifficultyMaintainer) proveNextEpoch(ctx context.Context) (
 			)
 		}
 
+		decision := bdm.submissionPolicy.Decide(
+			bdm.isRetargetUrgent(
+				currentBlockHeight,
+				newEpoch,
+				newEpochHeight,
+				headers,
+			),
+		)
+		if !decision.ShouldSubmit() {
+			logger.Infof(
+				"deferred submitting block headers [%d:%d] to the Bitcoin "+
+					"difficulty chain due to high gas price",
+				firstBlockHeaderHeight,
+				lastBlockHeaderHeight,
+			)
+
+			return false, nil
+		}
+
 		if bdm.config.DisableProxy {
 			if err := bdm.chain.Retarget(headers); err != nil {
 				return false, fmt.Errorf(
@@ -291,6 +330,8 @@ func (bdm *bitcoinDifficultyMaintainer) proveNextEpoch(ctx context.Context) (
 			newEpoch,
 		)
 
+		bdm.retargetRequests.fulfill(uint64(newEpoch))
+
 		return true, nil
 	}
 
@@ -311,6 +352,44 @@ func (bdm *bitcoinDifficultyMaintainer) proveNextEpoch(ctx context.Context) (
 	return false, nil
 }
 
+// isRetargetUrgent returns true if the retarget to the new epoch cannot be
+// deferred due to high gas price. That is the case if the Bitcoin blockchain
+// is already past the new epoch, so the Bitcoin difficulty chain lags more
+// than one epoch behind and recent transactions fall out of the SPV proof
+// range, if the retarget has been pending for longer than the urgency
+// window of the submission policy, or if the retarget is requested by
+// another maintainer for proofs whose deadline, e.g. the redemption timeout,
+// falls within the urgency window.
+func (bdm *bitcoinDifficultyMaintainer) isRetargetUrgent(
+	currentBlockHeight uint,
+	newEpoch uint,
+	newEpochHeight uint,
+	headers []*bitcoin.BlockHeader,
+) bool {
+	if currentBlockHeight >= newEpochHeight+bitcoinDifficultyEpochLength {
+		return true
+	}
+
+	if request := bdm.retargetRequests.Pending(); request != nil &&
+		uint64(newEpoch) <= request.Epoch &&
+		bdm.submissionPolicy.IsUrgent(request.Deadline, time.Now()) {
+		return true
+	}
+
+	if len(headers) == 0 {
+		return false
+	}
+
+	// The retarget became possible once the last header of the retarget
+	// proof was mined.
+	lastHeader := headers[len(headers)-1]
+
+	return bdm.submissionPolicy.IsOverdue(
+		time.Unix(int64(lastHeader.Time), 0),
+		time.Now(),
+	)
+}
+
 // getBlockHeaders returns block headers from the given range.
 func (bdm *bitcoinDifficultyMaintainer) getBlockHeaders(
 	firstHeaderHeight,
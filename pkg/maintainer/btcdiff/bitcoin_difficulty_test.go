@@ -2,13 +2,16 @@ package btcdiff
 
 import (
 	"context"
+	"math/big"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/keep-network/keep-common/pkg/chain/ethereum"
 	"github.com/keep-network/keep-core/internal/testutils"
 	"github.com/keep-network/keep-core/pkg/bitcoin"
 	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/maintainer/submission"
 )
 
 func TestVerifySubmissionEligibility(t *testing.T) {
@@ -275,6 +278,156 @@ func TestProveNextEpoch(t *testing.T) {
 	}
 }
 
+type localGasPriceChain struct {
+	gasPrice *big.Int
+}
+
+func (lgpc *localGasPriceChain) GasPrice() (*big.Int, error) {
+	return lgpc.gasPrice, nil
+}
+
+func TestProveNextEpoch_SubmissionPolicy(t *testing.T) {
+	recentTime := uint32(time.Now().Add(-time.Hour).Unix())
+	oldTime := uint32(time.Now().Add(-48 * time.Hour).Unix())
+
+	tests := map[string]struct {
+		gasPrice           *big.Int
+		lastHeaderTime     uint32
+		latestBlockHeight  uint
+		pendingRequest     *RetargetRequest
+		expectedRetargeted bool
+		expectedEvents     int
+	}{
+		"gas price below maximum": {
+			gasPrice:           big.NewInt(20000000000),
+			lastHeaderTime:     recentTime,
+			latestBlockHeight:  604802,
+			expectedRetargeted: true,
+			expectedEvents:     1,
+		},
+		"gas price above maximum": {
+			gasPrice:           big.NewInt(40000000000),
+			lastHeaderTime:     recentTime,
+			latestBlockHeight:  604802,
+			expectedRetargeted: false,
+			expectedEvents:     0,
+		},
+		"gas price above maximum and retarget overdue": {
+			gasPrice:           big.NewInt(40000000000),
+			lastHeaderTime:     oldTime,
+			latestBlockHeight:  604802,
+			expectedRetargeted: true,
+			expectedEvents:     1,
+		},
+		"gas price above maximum and next epoch started": {
+			gasPrice:           big.NewInt(40000000000),
+			lastHeaderTime:     recentTime,
+			latestBlockHeight:  606816,
+			expectedRetargeted: true,
+			expectedEvents:     1,
+		},
+		"gas price above maximum and requested proofs have close deadline": {
+			gasPrice:          big.NewInt(40000000000),
+			lastHeaderTime:    recentTime,
+			latestBlockHeight: 604802,
+			pendingRequest: &RetargetRequest{
+				Epoch:    300,
+				Deadline: time.Now().Add(time.Hour),
+			},
+			expectedRetargeted: true,
+			expectedEvents:     1,
+		},
+		"gas price above maximum and requested proofs have distant deadline": {
+			gasPrice:          big.NewInt(40000000000),
+			lastHeaderTime:    recentTime,
+			latestBlockHeight: 604802,
+			pendingRequest: &RetargetRequest{
+				Epoch:    300,
+				Deadline: time.Now().Add(48 * time.Hour),
+			},
+			expectedRetargeted: false,
+			expectedEvents:     0,
+		},
+		"gas price above maximum and requested proofs have no deadline": {
+			gasPrice:           big.NewInt(40000000000),
+			lastHeaderTime:     recentTime,
+			latestBlockHeight:  604802,
+			pendingRequest:     &RetargetRequest{Epoch: 300},
+			expectedRetargeted: false,
+			expectedEvents:     0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			blockHeaders := make(map[uint]*bitcoin.BlockHeader)
+			for height := uint(604797); height <= 604802; height++ {
+				blockHeaders[height] = &bitcoin.BlockHeader{
+					Time: test.lastHeaderTime - uint32(604802-height)*600,
+					Bits: 1111111,
+				}
+			}
+			blockHeaders[test.latestBlockHeight] = &bitcoin.BlockHeader{
+				Time: test.lastHeaderTime,
+				Bits: 2222222,
+			}
+
+			btcChain := connectLocalBitcoinChain()
+			btcChain.SetBlockHeaders(blockHeaders)
+
+			difficultyChain := connectLocalBitcoinDifficultyChain()
+			difficultyChain.SetCurrentEpoch(299)
+			difficultyChain.SetProofLength(3)
+
+			retargetRequests := NewRetargetRequests()
+			if test.pendingRequest != nil {
+				retargetRequests.Request(test.pendingRequest)
+			}
+
+			bitcoinDifficultyMaintainer := &bitcoinDifficultyMaintainer{
+				config: Config{
+					DisableProxy:       true,
+					IdleBackOffTime:    bitcoinDifficultyDefaultIdleBackOffTime,
+					RestartBackOffTime: bitcoinDifficultyDefaultRestartBackoffTime,
+				},
+				btcChain: btcChain,
+				chain:    difficultyChain,
+				submissionPolicy: submission.NewPolicy(
+					"btcdiff",
+					submission.Config{
+						MaxGasPrice: *ethereum.WrapWei(
+							big.NewInt(30000000000),
+						),
+						UrgencyWindow: 24 * time.Hour,
+					},
+					&localGasPriceChain{gasPrice: test.gasPrice},
+				),
+				retargetRequests: retargetRequests,
+			}
+
+			result, err := bitcoinDifficultyMaintainer.proveNextEpoch(
+				context.Background(),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertBoolsEqual(
+				t,
+				"result",
+				test.expectedRetargeted,
+				result,
+			)
+			testutils.AssertIntsEqual(
+				t,
+				"retarget events count",
+				test.expectedEvents,
+				len(difficultyChain.RetargetEvents()),
+			)
+		})
+	}
+}
+
 func TestGetBlockHeaders(t *testing.T) {
 	btcChain := connectLocalBitcoinChain()
 
@@ -540,6 +693,144 @@ func TestProveEpochs_Successful(t *testing.T) {
 	testutils.AssertAnyErrorInChainMatchesTarget(t, context.Canceled, err)
 }
 
+func TestProveEpochs_RetargetRequest(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	difficultyChain := connectLocalBitcoinDifficultyChain()
+	maintainerAddress := difficultyChain.Signing().Address()
+
+	difficultyChain.SetReady(true)
+	difficultyChain.SetAuthorizedOperator(
+		maintainerAddress,
+		true,
+	)
+	difficultyChain.SetProofLength(1)
+	difficultyChain.SetCurrentEpoch(299)
+
+	btcChain := connectLocalBitcoinChain()
+
+	// Initially, there is no block of the new epoch so the epoch cannot be
+	// proven. The old epoch number is 299, the new epoch number is 300.
+	btcChain.SetBlockHeaders(map[uint]*bitcoin.BlockHeader{
+		604799: { // Last block of the old epoch (epoch 299)
+			Time: 1000200,
+			Bits: 1111111,
+		},
+	})
+
+	retargetRequests := NewRetargetRequests()
+
+	bitcoinDifficultyMaintainer := &bitcoinDifficultyMaintainer{
+		btcChain: btcChain,
+		chain:    difficultyChain,
+		config: Config{
+			DisableProxy: true,
+			// Long enough to make sure the epoch is proven only thanks to
+			// the request.
+			IdleBackOffTime:    time.Hour,
+			RestartBackOffTime: time.Hour,
+		},
+		retargetRequests: retargetRequests,
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+
+		btcChain.SetBlockHeaders(map[uint]*bitcoin.BlockHeader{
+			604799: { // Last block of the old epoch (epoch 299)
+				Time: 1000200,
+				Bits: 1111111,
+			},
+			604800: { // First block of the new epoch (epoch 300)
+				Time: 1000300,
+				Bits: 2222222,
+			},
+		})
+		retargetRequests.Request(&RetargetRequest{Epoch: 300})
+
+		time.Sleep(500 * time.Millisecond)
+		cancelCtx()
+	}()
+
+	err := bitcoinDifficultyMaintainer.proveEpochs(ctx)
+	testutils.AssertAnyErrorInChainMatchesTarget(t, context.Canceled, err)
+
+	testutils.AssertIntsEqual(
+		t,
+		"retarget events count",
+		1,
+		len(difficultyChain.RetargetEvents()),
+	)
+}
+
+func TestRetargetRequests_Request(t *testing.T) {
+	var nilRetargetRequests *RetargetRequests
+
+	testutils.AssertBoolsEqual(
+		t,
+		"request without Bitcoin difficulty maintainer",
+		false,
+		nilRetargetRequests.Request(&RetargetRequest{Epoch: 300}),
+	)
+
+	retargetRequests := NewRetargetRequests()
+
+	deadline := time.Unix(1700000000, 0)
+
+	testutils.AssertBoolsEqual(
+		t,
+		"first request",
+		true,
+		retargetRequests.Request(&RetargetRequest{Epoch: 300}),
+	)
+	// The request is merged with the pending one instead of being dropped
+	// so its deadline is not lost.
+	testutils.AssertBoolsEqual(
+		t,
+		"request while another one is pending",
+		true,
+		retargetRequests.Request(&RetargetRequest{
+			Epoch:    299,
+			Deadline: deadline.Add(time.Hour),
+		}),
+	)
+	testutils.AssertBoolsEqual(
+		t,
+		"request with earlier deadline",
+		true,
+		retargetRequests.Request(&RetargetRequest{
+			Epoch:    301,
+			Deadline: deadline,
+		}),
+	)
+
+	select {
+	case <-retargetRequests.channel():
+	default:
+		t.Fatal("expected wake-up notification")
+	}
+
+	pending := retargetRequests.Pending()
+	testutils.AssertUintsEqual(t, "requested epoch", 301, pending.Epoch)
+	testutils.AssertBoolsEqual(
+		t,
+		"earliest deadline",
+		true,
+		deadline.Equal(pending.Deadline),
+	)
+
+	retargetRequests.fulfill(300)
+	if retargetRequests.Pending() == nil {
+		t.Fatal("expected the request to be still pending")
+	}
+
+	retargetRequests.fulfill(301)
+	if retargetRequests.Pending() != nil {
+		t.Fatal("expected no pending request")
+	}
+}
+
 func TestBitcoinDifficultyMaintainer_Integration(t *testing.T) {
 	type authorizationFunc func(
 		difficultyChain *localBitcoinDifficultyChain,
@@ -621,6 +912,8 @@ func TestBitcoinDifficultyMaintainer_Integration(t *testing.T) {
 				config,
 				btcChain,
 				difficultyChain,
+				nil,
+				nil,
 			)
 
 			//************ Loop restart on error ************
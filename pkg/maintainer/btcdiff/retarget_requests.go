@@ -0,0 +1,122 @@
+package btcdiff
+
+import (
+	"sync"
+	"time"
+)
+
+// RetargetRequest is a request to prove Bitcoin epochs up to the given one.
+type RetargetRequest struct {
+	// Epoch is the Bitcoin epoch the Bitcoin difficulty chain must reach.
+	Epoch uint64
+	// Deadline is the time by which proofs blocked by the missing epochs
+	// should be submitted, e.g. the timeout of a redemption request. Zero
+	// if the blocked proofs have no deadline.
+	Deadline time.Time
+}
+
+// RetargetRequests lets other maintainers ask the Bitcoin difficulty
+// maintainer to prove new Bitcoin epochs right away, instead of waiting for
+// its idle back off time to elapse. The SPV maintainer uses it when proofs
+// of transactions cannot be built only because the Bitcoin difficulty chain
+// lags behind the Bitcoin blockchain.
+//
+// Requests are passed in memory so they work only if both maintainers run
+// in the same process. A Bitcoin difficulty maintainer running in a separate
+// process never learns about deadlines of blocked proofs and submits
+// retargets according to its own idle back off time and urgency rules.
+//
+// All functions are safe for concurrent use.
+type RetargetRequests struct {
+	mutex sync.Mutex
+	// pending is the merge of all requests received since the requested
+	// epochs were last proven. Nil if there is no such request.
+	pending *RetargetRequest
+	// wakeups notifies the Bitcoin difficulty maintainer about new requests.
+	wakeups chan struct{}
+}
+
+// NewRetargetRequests creates a new channel of retarget requests.
+func NewRetargetRequests() *RetargetRequests {
+	return &RetargetRequests{
+		wakeups: make(chan struct{}, 1),
+	}
+}
+
+// Request asks the Bitcoin difficulty maintainer to prove Bitcoin epochs up
+// to the given one. The call never blocks. The request is merged with the
+// pending one, if any, so the merged request covers the highest requested
+// epoch and carries the earliest deadline. Returns false if there is no
+// Bitcoin difficulty maintainer to handle the request, i.e. the receiver is
+// nil.
+func (rr *RetargetRequests) Request(request *RetargetRequest) bool {
+	if rr == nil {
+		return false
+	}
+
+	rr.mutex.Lock()
+	if rr.pending == nil {
+		rr.pending = &RetargetRequest{}
+	}
+	if request.Epoch > rr.pending.Epoch {
+		rr.pending.Epoch = request.Epoch
+	}
+	if !request.Deadline.IsZero() &&
+		(rr.pending.Deadline.IsZero() ||
+			request.Deadline.Before(rr.pending.Deadline)) {
+		rr.pending.Deadline = request.Deadline
+	}
+	rr.mutex.Unlock()
+
+	// Do not block if the maintainer has not consumed the previous wake-up
+	// yet. It will see the merged request anyway.
+	select {
+	case rr.wakeups <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+// Pending returns a copy of the pending request or nil if there is no
+// pending request.
+func (rr *RetargetRequests) Pending() *RetargetRequest {
+	if rr == nil {
+		return nil
+	}
+
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	if rr.pending == nil {
+		return nil
+	}
+
+	pending := *rr.pending
+	return &pending
+}
+
+// fulfill clears the pending request if the given epoch, just proven, is
+// the requested one or a later one.
+func (rr *RetargetRequests) fulfill(epoch uint64) {
+	if rr == nil {
+		return
+	}
+
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	if rr.pending != nil && epoch >= rr.pending.Epoch {
+		rr.pending = nil
+	}
+}
+
+// channel returns the channel notifying about new requests. A nil receiver
+// returns a nil channel which never delivers any notification.
+func (rr *RetargetRequests) channel() <-chan struct{} {
+	if rr == nil {
+		return nil
+	}
+
+	return rr.wakeups
+}
@@ -3,10 +3,12 @@ package maintainer
 import (
 	"github.com/keep-network/keep-core/pkg/maintainer/btcdiff"
 	"github.com/keep-network/keep-core/pkg/maintainer/spv"
+	"github.com/keep-network/keep-core/pkg/maintainer/submission"
 )
 
 // Config contains maintainer configuration.
 type Config struct {
 	BitcoinDifficulty btcdiff.Config
 	Spv               spv.Config
+	Submission        submission.Config
 }
@@ -3,10 +3,13 @@ package maintainer
 import (
 	"context"
 	"github.com/ipfs/go-log/v2"
+	"github.com/keep-network/keep-common/pkg/persistence"
 
 	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/clientinfo"
 	"github.com/keep-network/keep-core/pkg/maintainer/btcdiff"
 	"github.com/keep-network/keep-core/pkg/maintainer/spv"
+	"github.com/keep-network/keep-core/pkg/maintainer/submission"
 )
 
 var logger = log.Logger("keep-maintainer")
@@ -17,6 +20,9 @@ func Initialize(
 	btcChain bitcoin.Chain,
 	btcDiffChain btcdiff.Chain,
 	spvChain spv.Chain,
+	submissionChain submission.Chain,
+	clientInfo *clientinfo.Registry,
+	spvProofQueuePersistence persistence.BasicHandle,
 ) {
 	// If none of the maintainers was specified in the config (i.e. no option was
 	// provided to the `maintainer` command), all maintainers should be launched.
@@ -27,22 +33,48 @@ func Initialize(
 		logger.Info("initializing all maintainer modules...")
 	}
 
+	// The SPV maintainer can ask the Bitcoin difficulty maintainer to prove
+	// new Bitcoin epochs only if both run in this process.
+	var retargetRequests *btcdiff.RetargetRequests
+	if (config.BitcoinDifficulty.Enabled && config.Spv.Enabled) || launchAll {
+		retargetRequests = btcdiff.NewRetargetRequests()
+	}
+
 	if config.BitcoinDifficulty.Enabled || launchAll {
+		submissionPolicy := submission.NewPolicy(
+			"btcdiff",
+			config.Submission,
+			submissionChain,
+		)
+		observeSubmissionPolicy(clientInfo, submissionPolicy)
+
 		btcdiff.Initialize(
 			ctx,
 			config.BitcoinDifficulty,
 			btcChain,
 			btcDiffChain,
+			submissionPolicy,
+			retargetRequests,
 		)
 	}
 
 	if config.Spv.Enabled || launchAll {
+		submissionPolicy := submission.NewPolicy(
+			"spv",
+			config.Submission,
+			submissionChain,
+		)
+		observeSubmissionPolicy(clientInfo, submissionPolicy)
+
 		spv.Initialize(
 			ctx,
 			config.Spv,
 			spvChain,
 			btcDiffChain,
 			btcChain,
+			submissionPolicy,
+			retargetRequests,
+			spvProofQueuePersistence,
 		)
 	}
 
@@ -52,3 +84,19 @@ func Initialize(
 	//       program. Consider cancelling all maintainers if one maintainer
 	//       cannot ba launched due to a configuration error.
 }
+
+// observeSubmissionPolicy exposes metrics of the given submission policy if
+// the client info endpoint is configured.
+func observeSubmissionPolicy(
+	clientInfo *clientinfo.Registry,
+	submissionPolicy *submission.Policy,
+) {
+	if clientInfo == nil {
+		return
+	}
+
+	clientInfo.ObserveApplicationSource(
+		"maintainer",
+		submissionPolicy.MetricsSources(),
+	)
+}
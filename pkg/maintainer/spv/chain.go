@@ -59,6 +59,19 @@ type Chain interface {
 		movingFundsTxOutpointIndex uint32,
 	) (*tbtc.MovedFundsSweepRequest, bool, error)
 
+	// GetRedemptionParameters gets the current value of parameters relevant
+	// for the redemption process.
+	GetRedemptionParameters() (
+		dustThreshold uint64,
+		treasuryFeeDivisor uint64,
+		txMaxFee uint64,
+		txMaxTotalFee uint64,
+		timeout uint32,
+		timeoutSlashingAmount *big.Int,
+		timeoutNotifierRewardMultiplier uint32,
+		err error,
+	)
+
 	// SubmitRedemptionProofWithReimbursement submits the redemption proof
 	// via MaintainerProxy. The caller is reimbursed.
 	SubmitRedemptionProofWithReimbursement(
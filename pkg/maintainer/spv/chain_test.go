@@ -60,6 +60,7 @@ type localChain struct {
 	pastMovingFundsCommitmentSubmittedEvents map[[32]byte][]*tbtc.MovingFundsCommitmentSubmittedEvent
 
 	txProofDifficultyFactor *big.Int
+	redemptionTimeout       uint32
 	currentEpoch            uint64
 	currentEpochDifficulty  *big.Int
 	previousEpochDifficulty *big.Int
@@ -255,6 +256,29 @@ func buildRedemptionRequestKey(
 	return sha256.Sum256(append(walletPublicKeyHash[:], redeemerOutputScript...))
 }
 
+func (lc *localChain) GetRedemptionParameters() (
+	dustThreshold uint64,
+	treasuryFeeDivisor uint64,
+	txMaxFee uint64,
+	txMaxTotalFee uint64,
+	timeout uint32,
+	timeoutSlashingAmount *big.Int,
+	timeoutNotifierRewardMultiplier uint32,
+	err error,
+) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	return 0, 0, 0, 0, lc.redemptionTimeout, nil, 0, nil
+}
+
+func (lc *localChain) setRedemptionTimeout(timeout uint32) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	lc.redemptionTimeout = timeout
+}
+
 func (lc *localChain) SubmitRedemptionProofWithReimbursement(
 	transaction *bitcoin.Transaction,
 	proof *bitcoin.SpvProof,
@@ -27,6 +27,19 @@ const (
 
 	// DefaultIdleBackOffTime is the default value for idle back-off time.
 	DefaultIdleBackOffTime = 10 * time.Minute
+
+	// DefaultRetryBackoffTime is the default value for retry back-off time.
+	DefaultRetryBackoffTime = 10 * time.Minute
+
+	// DefaultMaxRetryBackoffTime is the default value for maximum retry
+	// back-off time.
+	DefaultMaxRetryBackoffTime = 4 * time.Hour
+
+	// DefaultClaimPeriod is the default value for the claim period. The value
+	// is the approximate number of Ethereum blocks in 20 minutes, assuming
+	// one block is 12s, so that the claimant has at least one proof round
+	// with the default idle back-off time.
+	DefaultClaimPeriod = 100
 )
 
 // Config holds configurable properties.
@@ -65,4 +78,29 @@ type Config struct {
 	// IdleBackoffTime is a wait time which should be applied when there are no
 	// more transaction proofs to submit.
 	IdleBackoffTime time.Duration
+
+	// RetryBackoffTime is the initial wait time before the proof of a given
+	// transaction is attempted again after a failure. The wait time doubles
+	// with each consecutive failure of the given transaction. Failures of
+	// one transaction never delay proofs of other transactions. The retry
+	// state is kept in the work storage, if configured, and survives
+	// restarts of the maintainer.
+	RetryBackoffTime time.Duration
+
+	// MaxRetryBackoffTime is the upper bound of the wait time before the
+	// proof of a given transaction is attempted again after a failure.
+	MaxRetryBackoffTime time.Duration
+
+	// Maintainers is the list of host chain addresses of all cooperating SPV
+	// maintainers, including this one. If set, maintainers coordinate to
+	// avoid submitting the same proofs concurrently: the proof of each
+	// transaction is claimed by one maintainer at a time and other
+	// maintainers back off until the claim expires. If empty, the maintainer
+	// submits all proofs on its own.
+	Maintainers []string
+
+	// ClaimPeriod is the number of host chain blocks for which a maintainer
+	// claims the proof of a transaction. Once the claim expires, the next
+	// maintainer takes the transaction over.
+	ClaimPeriod uint64
 }
@@ -0,0 +1,126 @@
+package spv
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+	"strings"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/chain"
+)
+
+// proofClaim represents the claim of the given maintainer to submit the proof
+// of a transaction.
+type proofClaim struct {
+	maintainer chain.Address
+	// expiresAt is the host chain block at which the claim expires and the
+	// next maintainer takes over the transaction.
+	expiresAt uint64
+}
+
+// proofCoordinator coordinates SPV maintainers to avoid submitting the same
+// proofs concurrently. The coordination does not require any communication
+// between maintainers. Instead, all maintainers know the full list of
+// cooperating maintainers and deterministically compute the claimant of each
+// transaction. For every transaction, maintainers are ordered by the hash of
+// the transaction hash and the maintainer address. The claim is held by the
+// consecutive maintainers of that order, each for the claim period of host
+// chain blocks. If the claimant does not submit the proof before its claim
+// expires, e.g. because it is offline, the next maintainer takes over the
+// transaction.
+type proofCoordinator struct {
+	self        chain.Address
+	maintainers []chain.Address
+	claimPeriod uint64
+}
+
+// newProofCoordinator creates a new proof coordinator for the given
+// maintainer. The maintainers list should contain all cooperating
+// maintainers, including the given one. Returns nil if the coordination is
+// disabled, i.e. the list is empty or does not contain the given maintainer.
+func newProofCoordinator(
+	self chain.Address,
+	maintainers []string,
+	claimPeriod uint64,
+) *proofCoordinator {
+	if len(maintainers) == 0 || claimPeriod == 0 {
+		return nil
+	}
+
+	normalize := func(address string) chain.Address {
+		return chain.Address(strings.ToLower(address))
+	}
+
+	coordinator := &proofCoordinator{
+		self:        normalize(self.String()),
+		claimPeriod: claimPeriod,
+	}
+
+	unique := make(map[chain.Address]bool)
+	selfFound := false
+
+	for _, maintainer := range maintainers {
+		address := normalize(maintainer)
+		if unique[address] {
+			continue
+		}
+		unique[address] = true
+
+		if address == coordinator.self {
+			selfFound = true
+		}
+
+		coordinator.maintainers = append(coordinator.maintainers, address)
+	}
+
+	if !selfFound {
+		return nil
+	}
+
+	return coordinator
+}
+
+// claim returns the claim of the given transaction valid at the given host
+// chain block.
+func (pc *proofCoordinator) claim(
+	transactionHash bitcoin.Hash,
+	block uint64,
+) *proofClaim {
+	type rankedMaintainer struct {
+		address chain.Address
+		rank    [32]byte
+	}
+
+	ranked := make([]rankedMaintainer, len(pc.maintainers))
+	for i, maintainer := range pc.maintainers {
+		ranked[i] = rankedMaintainer{
+			address: maintainer,
+			rank: sha256.Sum256(
+				append(transactionHash[:], []byte(maintainer)...),
+			),
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		return bytes.Compare(ranked[i].rank[:], ranked[j].rank[:]) < 0
+	})
+
+	period := block / pc.claimPeriod
+
+	return &proofClaim{
+		maintainer: ranked[period%uint64(len(ranked))].address,
+		expiresAt:  (period + 1) * pc.claimPeriod,
+	}
+}
+
+// isClaimant returns true if the proof of the given transaction is claimed
+// by this maintainer at the given host chain block. The claim of this
+// maintainer or the claim of the peer maintainer is returned as well.
+func (pc *proofCoordinator) isClaimant(
+	transactionHash bitcoin.Hash,
+	block uint64,
+) (bool, *proofClaim) {
+	claim := pc.claim(transactionHash, block)
+	return claim.maintainer == pc.self, claim
+}
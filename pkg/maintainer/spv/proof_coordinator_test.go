@@ -0,0 +1,247 @@
+package spv
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+var testMaintainers = []string{
+	"0x9A5a6b80b1Ba6A6f9F3bE61fF1b3A4e3bb7D4b6c",
+	"0xE7e7B9EDd5AE4d3c5bF2E8A84A0e32Dc2d4FCfC5",
+	"0x3C2b5fF4D7a8Be1C2b05B4aa1dEc6aCb2Ea2B9e0",
+}
+
+func TestNewProofCoordinator(t *testing.T) {
+	var tests = map[string]struct {
+		self                chain.Address
+		maintainers         []string
+		claimPeriod         uint64
+		expectedMaintainers []chain.Address
+	}{
+		"no maintainers": {
+			self:                chain.Address(testMaintainers[0]),
+			maintainers:         []string{},
+			claimPeriod:         100,
+			expectedMaintainers: nil,
+		},
+		"zero claim period": {
+			self:                chain.Address(testMaintainers[0]),
+			maintainers:         testMaintainers,
+			claimPeriod:         0,
+			expectedMaintainers: nil,
+		},
+		"maintainer not on the list": {
+			self:                chain.Address("0x0000000000000000000000000000000000000001"),
+			maintainers:         testMaintainers,
+			claimPeriod:         100,
+			expectedMaintainers: nil,
+		},
+		"maintainer on the list": {
+			self: chain.Address("0x9a5a6b80b1ba6a6f9f3be61ff1b3a4e3bb7d4b6c"),
+			maintainers: append(
+				[]string{"0xe7e7b9edd5ae4d3c5bf2e8a84a0e32dc2d4fcfc5"},
+				testMaintainers...,
+			),
+			claimPeriod: 100,
+			expectedMaintainers: []chain.Address{
+				"0xe7e7b9edd5ae4d3c5bf2e8a84a0e32dc2d4fcfc5",
+				"0x9a5a6b80b1ba6a6f9f3be61ff1b3a4e3bb7d4b6c",
+				"0x3c2b5ff4d7a8be1c2b05b4aa1dec6acb2ea2b9e0",
+			},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			coordinator := newProofCoordinator(
+				test.self,
+				test.maintainers,
+				test.claimPeriod,
+			)
+
+			if test.expectedMaintainers == nil {
+				if coordinator != nil {
+					t.Fatal("expected coordination to be disabled")
+				}
+				return
+			}
+
+			if coordinator == nil {
+				t.Fatal("expected coordination to be enabled")
+			}
+
+			if !reflect.DeepEqual(
+				test.expectedMaintainers,
+				coordinator.maintainers,
+			) {
+				t.Errorf(
+					"unexpected maintainers\nexpected: [%v]\nactual:   [%v]",
+					test.expectedMaintainers,
+					coordinator.maintainers,
+				)
+			}
+		})
+	}
+}
+
+func TestProofCoordinator_Claim(t *testing.T) {
+	claimPeriod := uint64(100)
+
+	coordinators := make([]*proofCoordinator, len(testMaintainers))
+	for i, maintainer := range testMaintainers {
+		coordinators[i] = newProofCoordinator(
+			chain.Address(maintainer),
+			testMaintainers,
+			claimPeriod,
+		)
+	}
+
+	for _, transactionHash := range []bitcoin.Hash{{1}, {2}, {3}, {4}} {
+		claimants := make(map[chain.Address]bool)
+
+		// Within consecutive claim periods, the claim is held by each of
+		// the maintainers once.
+		for period := uint64(10); period < 10+uint64(len(testMaintainers)); period++ {
+			block := period*claimPeriod + 42
+
+			claimantsCount := 0
+			for _, coordinator := range coordinators {
+				isClaimant, claim := coordinator.isClaimant(transactionHash, block)
+				if isClaimant {
+					claimantsCount++
+				}
+
+				testutils.AssertUintsEqual(
+					t,
+					"claim expiry",
+					(period+1)*claimPeriod,
+					claim.expiresAt,
+				)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				fmt.Sprintf("claimants count at block [%v]", block),
+				1,
+				claimantsCount,
+			)
+
+			claimant := coordinators[0].claim(transactionHash, block).maintainer
+			if claimants[claimant] {
+				t.Errorf(
+					"maintainer [%v] claimed transaction [%v] twice",
+					claimant,
+					transactionHash,
+				)
+			}
+			claimants[claimant] = true
+		}
+	}
+}
+
+func TestSpvMaintainer_ProveQueuedTransactions_Coordination(t *testing.T) {
+	claimPeriod := uint64(100)
+	currentBlock := uint64(1042)
+
+	self := chain.Address(testMaintainers[0])
+	coordinator := newProofCoordinator(self, testMaintainers, claimPeriod)
+
+	blockCounter := newMockBlockCounter()
+	blockCounter.SetCurrentBlock(currentBlock)
+
+	spvChain := newLocalChain()
+	spvChain.setBlockCounter(blockCounter)
+	spvChain.setTxProofDifficultyFactor(big.NewInt(6))
+	spvChain.setCurrentEpoch(392)
+
+	btcChain := newLocalBitcoinChain()
+	btcChain.addBlockHeader(790277, &bitcoin.BlockHeader{})
+
+	deadlines := make(map[bitcoin.Hash]time.Time)
+	var expectedSubmittedProofs []bitcoin.Hash
+
+	for i := byte(1); i <= 10; i++ {
+		transactionHash := bitcoin.Hash{i}
+		btcChain.addTransactionConfirmations(transactionHash, 6)
+		deadlines[transactionHash] = time.Time{}
+
+		if isClaimant, _ := coordinator.isClaimant(
+			transactionHash,
+			currentBlock,
+		); isClaimant {
+			expectedSubmittedProofs = append(
+				expectedSubmittedProofs,
+				transactionHash,
+			)
+		}
+	}
+
+	if len(expectedSubmittedProofs) == 0 ||
+		len(expectedSubmittedProofs) == len(deadlines) {
+		t.Fatal("test transactions should be claimed by several maintainers")
+	}
+
+	var submittedProofs []bitcoin.Hash
+
+	spvMaintainer := &spvMaintainer{
+		spvChain:     spvChain,
+		btcDiffChain: spvChain,
+		btcChain:     btcChain,
+		proofTypes: map[tbtc.WalletActionType]proofType{
+			tbtc.ActionDepositSweep: {
+				transactionProofSubmitter: func(
+					transactionHash bitcoin.Hash,
+					requiredConfirmations uint,
+					btcChain bitcoin.Chain,
+					spvChain Chain,
+				) error {
+					submittedProofs = append(submittedProofs, transactionHash)
+					return nil
+				},
+			},
+		},
+		proofQueue:       newProofQueue(nil, time.Hour, time.Hour),
+		proofCoordinator: coordinator,
+	}
+
+	spvMaintainer.proofQueue.update(
+		tbtc.ActionDepositSweep,
+		deadlines,
+		time.Now(),
+	)
+
+	err := spvMaintainer.proveQueuedTransactions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tasks of the same type and queue time are ordered by hash.
+	if !reflect.DeepEqual(expectedSubmittedProofs, submittedProofs) {
+		t.Errorf(
+			"unexpected submitted proofs\nexpected: [%v]\nactual:   [%v]",
+			expectedSubmittedProofs,
+			submittedProofs,
+		)
+	}
+
+	// Transactions claimed by peers stay in the queue, without any failures
+	// recorded, so they can be taken over once the claims expire.
+	testutils.AssertIntsEqual(
+		t,
+		"queue length",
+		len(deadlines)-len(expectedSubmittedProofs),
+		spvMaintainer.proofQueue.len(),
+	)
+	for _, task := range spvMaintainer.proofQueue.tasks {
+		testutils.AssertUintsEqual(t, "failures", 0, uint64(task.failures))
+	}
+}
@@ -0,0 +1,391 @@
+package spv
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+// proofQueueDirectory is the name of the persistence directory holding
+// the retry state of queued proof tasks. Files are named after the
+// hex-encoded transaction hash.
+const proofQueueDirectory = "spv_proof_queue"
+
+// proofPriorities holds the priorities of proof types used to order tasks
+// that have no proof deadline. Lower value means higher priority. Redemption
+// proofs go first as pending redemptions block wallet funds and can time
+// out. Deposit sweep proofs go last as delaying them only delays minting.
+var proofPriorities = map[tbtc.WalletActionType]int{
+	tbtc.ActionRedemption:      0,
+	tbtc.ActionMovingFunds:     1,
+	tbtc.ActionMovedFundsSweep: 2,
+	tbtc.ActionDepositSweep:    3,
+}
+
+// proofTask represents a single Bitcoin transaction awaiting its SPV proof.
+type proofTask struct {
+	action          tbtc.WalletActionType
+	transactionHash bitcoin.Hash
+	// deadline is the time by which the proof should be submitted, e.g. the
+	// timeout of the oldest redemption request handled by the transaction.
+	// Zero if the proof has no deadline.
+	deadline time.Time
+	// queuedAt is the time the task was added to the queue.
+	queuedAt time.Time
+	// failures is the number of consecutive failed proof attempts.
+	failures uint
+	// nextAttemptAt is the earliest time of the next proof attempt.
+	nextAttemptAt time.Time
+	// lastErr is the error of the last failed proof attempt.
+	lastErr error
+	// relayLag is set if the last proof attempt was blocked by the relay
+	// lag, nil otherwise.
+	relayLag *relayLag
+	// relayLagSince is the time of the first proof attempt blocked by the
+	// relay lag, out of the consecutive blocked attempts.
+	relayLagSince time.Time
+}
+
+// proofQueue is a queue of transactions awaiting their SPV proofs. The queue
+// outlives individual proof rounds and restarts of the maintainer's control
+// loop so the retry state of each transaction is preserved. A failed proof
+// attempt delays further attempts for the given transaction only, using an
+// exponential backoff, and never blocks proofs of other transactions.
+//
+// If a persistence handle is set, the number of failed attempts and the time
+// of the next attempt of each failed task are persisted and loaded again
+// when the maintainer restarts, so backoffs survive restarts. Relay lag
+// records are kept in memory only.
+//
+// The queue is not safe for concurrent use.
+type proofQueue struct {
+	persistence persistence.BasicHandle
+
+	retryBackoffTime    time.Duration
+	maxRetryBackoffTime time.Duration
+
+	tasks map[bitcoin.Hash]*proofTask
+}
+
+// persistedProofTask is the retry state of a proof task kept in the
+// persistence.
+type persistedProofTask struct {
+	Action        tbtc.WalletActionType
+	QueuedAt      time.Time
+	Failures      uint
+	NextAttemptAt time.Time
+}
+
+// newProofQueue creates a new proof queue. Retry state of tasks persisted in
+// the past is loaded from the given persistence handle. The persistence
+// handle is optional; if it is nil, the queue is kept in memory only.
+func newProofQueue(
+	persistence persistence.BasicHandle,
+	retryBackoffTime time.Duration,
+	maxRetryBackoffTime time.Duration,
+) *proofQueue {
+	pq := &proofQueue{
+		persistence:         persistence,
+		retryBackoffTime:    retryBackoffTime,
+		maxRetryBackoffTime: maxRetryBackoffTime,
+		tasks:               make(map[bitcoin.Hash]*proofTask),
+	}
+
+	if persistence != nil {
+		pq.load()
+	}
+
+	return pq
+}
+
+// update replaces queued tasks of the given proof type with the given
+// unproven transactions. Transactions that are already queued keep their
+// retry state. Queued transactions of the given type that are no longer
+// unproven, e.g. were proven by another maintainer, are removed.
+func (pq *proofQueue) update(
+	action tbtc.WalletActionType,
+	deadlines map[bitcoin.Hash]time.Time,
+	now time.Time,
+) {
+	for transactionHash, task := range pq.tasks {
+		if task.action != action {
+			continue
+		}
+
+		if _, ok := deadlines[transactionHash]; !ok {
+			pq.remove(transactionHash)
+		}
+	}
+
+	for transactionHash, deadline := range deadlines {
+		if task, ok := pq.tasks[transactionHash]; ok {
+			task.deadline = deadline
+			continue
+		}
+
+		pq.tasks[transactionHash] = &proofTask{
+			action:          action,
+			transactionHash: transactionHash,
+			deadline:        deadline,
+			queuedAt:        now,
+			nextAttemptAt:   now,
+		}
+	}
+}
+
+// due returns tasks whose next proof attempt is due at the given time,
+// ordered by urgency. Tasks with a deadline go first, the closest deadline
+// first. Tasks without a deadline are ordered by the priority of their proof
+// type and then by the time they were queued.
+func (pq *proofQueue) due(now time.Time) []*proofTask {
+	var tasks []*proofTask
+	for _, task := range pq.tasks {
+		if !task.nextAttemptAt.After(now) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].moreUrgentThan(tasks[j])
+	})
+
+	return tasks
+}
+
+// succeeded removes the task of the given transaction from the queue.
+func (pq *proofQueue) succeeded(transactionHash bitcoin.Hash) {
+	pq.remove(transactionHash)
+}
+
+// remove drops the task of the given transaction from the queue along with
+// its persisted retry state.
+func (pq *proofQueue) remove(transactionHash bitcoin.Hash) {
+	task, ok := pq.tasks[transactionHash]
+	if !ok {
+		return
+	}
+
+	delete(pq.tasks, transactionHash)
+
+	if pq.persistence == nil || task.failures == 0 {
+		return
+	}
+
+	if err := pq.persistence.Delete(
+		proofQueueDirectory,
+		transactionHash.Hex(bitcoin.InternalByteOrder),
+	); err != nil {
+		logger.Errorf(
+			"cannot delete retry state of transaction [%s]: [%v]",
+			transactionHash.Hex(bitcoin.ReversedByteOrder),
+			err,
+		)
+	}
+}
+
+// failed records a failed proof attempt for the given transaction and
+// postpones the next attempt. The backoff time doubles with each consecutive
+// failure, up to the maximum retry backoff time. Returns the time of the
+// next attempt.
+func (pq *proofQueue) failed(
+	transactionHash bitcoin.Hash,
+	err error,
+	now time.Time,
+) time.Time {
+	task, ok := pq.tasks[transactionHash]
+	if !ok {
+		return now
+	}
+
+	task.failures++
+	task.lastErr = err
+
+	backoff := pq.retryBackoffTime
+	for i := uint(1); i < task.failures && backoff < pq.maxRetryBackoffTime; i++ {
+		backoff *= 2
+	}
+	if backoff > pq.maxRetryBackoffTime {
+		backoff = pq.maxRetryBackoffTime
+	}
+
+	task.nextAttemptAt = now.Add(backoff)
+
+	pq.save(task)
+
+	return task.nextAttemptAt
+}
+
+// save persists the retry state of the given task.
+func (pq *proofQueue) save(task *proofTask) {
+	if pq.persistence == nil {
+		return
+	}
+
+	content, err := json.Marshal(&persistedProofTask{
+		Action:        task.action,
+		QueuedAt:      task.queuedAt,
+		Failures:      task.failures,
+		NextAttemptAt: task.nextAttemptAt,
+	})
+	if err != nil {
+		logger.Errorf(
+			"cannot encode retry state of transaction [%s]: [%v]",
+			task.transactionHash.Hex(bitcoin.ReversedByteOrder),
+			err,
+		)
+		return
+	}
+
+	if err := pq.persistence.Save(
+		content,
+		proofQueueDirectory,
+		task.transactionHash.Hex(bitcoin.InternalByteOrder),
+	); err != nil {
+		logger.Errorf(
+			"cannot persist retry state of transaction [%s]: [%v]",
+			task.transactionHash.Hex(bitcoin.ReversedByteOrder),
+			err,
+		)
+	}
+}
+
+// load restores the tasks whose retry state was persisted. Restored tasks
+// have no deadline until the queue is updated with unproven transactions
+// of their type. Tasks of transactions that are no longer unproven are
+// removed by the update.
+func (pq *proofQueue) load() {
+	descriptorsChan, errorsChan := pq.persistence.ReadAll()
+
+	// Two goroutines read from descriptors and errors channels. The reason
+	// for using two goroutines at the same time - one for descriptors and
+	// one for errors - is that channels do not have to be buffered, and we
+	// do not know in what order the information is written to channels.
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for descriptor := range descriptorsChan {
+			if descriptor.Directory() != proofQueueDirectory {
+				continue
+			}
+
+			transactionHash, err := bitcoin.NewHashFromString(
+				descriptor.Name(),
+				bitcoin.InternalByteOrder,
+			)
+			if err != nil {
+				continue
+			}
+
+			content, err := descriptor.Content()
+			if err != nil {
+				logger.Errorf(
+					"could not read retry state from file [%s]: [%v]",
+					descriptor.Name(),
+					err,
+				)
+				continue
+			}
+
+			persisted := &persistedProofTask{}
+			if err := json.Unmarshal(content, persisted); err != nil {
+				logger.Warnf(
+					"dropping retry state of transaction [%s]: [%v]",
+					transactionHash.Hex(bitcoin.ReversedByteOrder),
+					err,
+				)
+				continue
+			}
+
+			if _, ok := proofPriorities[persisted.Action]; !ok {
+				logger.Warnf(
+					"dropping retry state of transaction [%s]: "+
+						"unsupported proof type [%s]",
+					transactionHash.Hex(bitcoin.ReversedByteOrder),
+					persisted.Action,
+				)
+				continue
+			}
+
+			pq.tasks[transactionHash] = &proofTask{
+				action:          persisted.Action,
+				transactionHash: transactionHash,
+				queuedAt:        persisted.QueuedAt,
+				failures:        persisted.Failures,
+				nextAttemptAt:   persisted.NextAttemptAt,
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for err := range errorsChan {
+			logger.Errorf("could not load proof queue: [%v]", err)
+		}
+	}()
+
+	wg.Wait()
+}
+
+// setRelayLag records whether the last proof attempt of the given
+// transaction was blocked by the relay lag. A nil relayLag clears the
+// record.
+func (pq *proofQueue) setRelayLag(
+	transactionHash bitcoin.Hash,
+	relayLag *relayLag,
+	now time.Time,
+) {
+	task, ok := pq.tasks[transactionHash]
+	if !ok {
+		return
+	}
+
+	if relayLag == nil {
+		task.relayLag = nil
+		task.relayLagSince = time.Time{}
+		return
+	}
+
+	if task.relayLag == nil {
+		task.relayLagSince = now
+	}
+	task.relayLag = relayLag
+}
+
+// len returns the number of queued tasks.
+func (pq *proofQueue) len() int {
+	return len(pq.tasks)
+}
+
+func (pt *proofTask) moreUrgentThan(other *proofTask) bool {
+	hasDeadline, otherHasDeadline := !pt.deadline.IsZero(), !other.deadline.IsZero()
+	if hasDeadline != otherHasDeadline {
+		return hasDeadline
+	}
+
+	if !pt.deadline.Equal(other.deadline) {
+		return pt.deadline.Before(other.deadline)
+	}
+
+	priority, otherPriority := proofPriorities[pt.action], proofPriorities[other.action]
+	if priority != otherPriority {
+		return priority < otherPriority
+	}
+
+	if !pt.queuedAt.Equal(other.queuedAt) {
+		return pt.queuedAt.Before(other.queuedAt)
+	}
+
+	// Make the order deterministic.
+	return bytes.Compare(pt.transactionHash[:], other.transactionHash[:]) < 0
+}
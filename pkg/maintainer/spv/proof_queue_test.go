@@ -0,0 +1,353 @@
+package spv
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+func TestProofQueue_Due(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	queue := newProofQueue(nil, time.Minute, time.Hour)
+
+	queue.update(
+		tbtc.ActionDepositSweep,
+		map[bitcoin.Hash]time.Time{
+			{1}: {},
+		},
+		now,
+	)
+	queue.update(
+		tbtc.ActionMovingFunds,
+		map[bitcoin.Hash]time.Time{
+			{2}: {},
+		},
+		now.Add(time.Second),
+	)
+	queue.update(
+		tbtc.ActionRedemption,
+		map[bitcoin.Hash]time.Time{
+			{3}: now.Add(2 * time.Hour),
+			{4}: now.Add(time.Hour),
+			{5}: {},
+		},
+		now.Add(2*time.Second),
+	)
+
+	// The not yet due task should be skipped.
+	queue.update(
+		tbtc.ActionMovedFundsSweep,
+		map[bitcoin.Hash]time.Time{
+			{6}: {},
+		},
+		now.Add(time.Hour),
+	)
+
+	assertTasksOrder(
+		t,
+		[]bitcoin.Hash{{4}, {3}, {5}, {2}, {1}},
+		queue.due(now.Add(time.Minute)),
+	)
+}
+
+func TestProofQueue_Update(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	queue := newProofQueue(nil, time.Minute, time.Hour)
+
+	queue.update(
+		tbtc.ActionRedemption,
+		map[bitcoin.Hash]time.Time{
+			{1}: {},
+			{2}: {},
+		},
+		now,
+	)
+	queue.update(
+		tbtc.ActionDepositSweep,
+		map[bitcoin.Hash]time.Time{
+			{3}: {},
+		},
+		now,
+	)
+
+	queue.failed(bitcoin.Hash{1}, fmt.Errorf("failure"), now)
+
+	// Transaction 2 was proven by someone else and transaction 4 is new.
+	queue.update(
+		tbtc.ActionRedemption,
+		map[bitcoin.Hash]time.Time{
+			{1}: {},
+			{4}: {},
+		},
+		now.Add(time.Second),
+	)
+
+	testutils.AssertIntsEqual(t, "queue length", 3, queue.len())
+
+	// Transaction 1 keeps its retry state and is not due yet. Transaction 3
+	// of another proof type is not affected by the update.
+	assertTasksOrder(
+		t,
+		[]bitcoin.Hash{{4}, {3}},
+		queue.due(now.Add(time.Second)),
+	)
+	testutils.AssertUintsEqual(
+		t,
+		"failures",
+		1,
+		uint64(queue.tasks[bitcoin.Hash{1}].failures),
+	)
+}
+
+func TestProofQueue_Failed(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	queue := newProofQueue(nil, 10*time.Minute, time.Hour)
+
+	queue.update(
+		tbtc.ActionRedemption,
+		map[bitcoin.Hash]time.Time{
+			{1}: {},
+		},
+		now,
+	)
+
+	expectedBackoffs := []time.Duration{
+		10 * time.Minute,
+		20 * time.Minute,
+		40 * time.Minute,
+		time.Hour,
+		time.Hour,
+	}
+
+	for i, expectedBackoff := range expectedBackoffs {
+		failedAt := now.Add(time.Duration(i) * time.Hour)
+
+		nextAttemptAt := queue.failed(
+			bitcoin.Hash{1},
+			fmt.Errorf("failure %v", i),
+			failedAt,
+		)
+
+		testutils.AssertIntsEqual(
+			t,
+			fmt.Sprintf("backoff after failure %v", i+1),
+			int(expectedBackoff.Seconds()),
+			int(nextAttemptAt.Sub(failedAt).Seconds()),
+		)
+
+		testutils.AssertIntsEqual(
+			t,
+			fmt.Sprintf("due tasks before next attempt %v", i+1),
+			0,
+			len(queue.due(nextAttemptAt.Add(-time.Second))),
+		)
+		testutils.AssertIntsEqual(
+			t,
+			fmt.Sprintf("due tasks at next attempt %v", i+1),
+			1,
+			len(queue.due(nextAttemptAt)),
+		)
+	}
+
+	queue.succeeded(bitcoin.Hash{1})
+
+	testutils.AssertIntsEqual(t, "queue length", 0, queue.len())
+}
+
+func TestProofQueue_Persistence(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	persistenceHandle, err := persistence.NewBasicDiskHandle(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queue := newProofQueue(persistenceHandle, 10*time.Minute, time.Hour)
+
+	queue.update(
+		tbtc.ActionRedemption,
+		map[bitcoin.Hash]time.Time{
+			{1}: now.Add(time.Hour),
+			{2}: {},
+			{3}: {},
+		},
+		now,
+	)
+
+	queue.failed(bitcoin.Hash{1}, fmt.Errorf("failure"), now)
+	queue.failed(bitcoin.Hash{1}, fmt.Errorf("failure"), now)
+	queue.failed(bitcoin.Hash{2}, fmt.Errorf("failure"), now)
+	queue.succeeded(bitcoin.Hash{2})
+
+	// Simulate a restart of the maintainer.
+	queue = newProofQueue(persistenceHandle, 10*time.Minute, time.Hour)
+
+	// Only the retry state of failed tasks that are still queued is
+	// restored.
+	testutils.AssertIntsEqual(t, "queue length", 1, queue.len())
+
+	task, ok := queue.tasks[bitcoin.Hash{1}]
+	if !ok {
+		t.Fatal("expected restored task")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"action",
+		tbtc.ActionRedemption.String(),
+		task.action.String(),
+	)
+	testutils.AssertUintsEqual(t, "failures", 2, uint64(task.failures))
+	testutils.AssertIntsEqual(
+		t,
+		"next attempt",
+		int(now.Add(20*time.Minute).Unix()),
+		int(task.nextAttemptAt.Unix()),
+	)
+	testutils.AssertIntsEqual(
+		t,
+		"queued at",
+		int(now.Unix()),
+		int(task.queuedAt.Unix()),
+	)
+
+	// The backoff continues from the restored number of failures.
+	nextAttemptAt := queue.failed(bitcoin.Hash{1}, fmt.Errorf("failure"), now)
+	testutils.AssertIntsEqual(
+		t,
+		"backoff after restart",
+		int((40 * time.Minute).Seconds()),
+		int(nextAttemptAt.Sub(now).Seconds()),
+	)
+
+	// The task is removed along with its retry state once the transaction
+	// is no longer unproven.
+	queue.update(tbtc.ActionRedemption, map[bitcoin.Hash]time.Time{}, now)
+
+	queue = newProofQueue(persistenceHandle, 10*time.Minute, time.Hour)
+
+	testutils.AssertIntsEqual(t, "queue length after removal", 0, queue.len())
+}
+
+func TestSpvMaintainer_ProveQueuedTransactions(t *testing.T) {
+	spvChain := newLocalChain()
+	spvChain.setTxProofDifficultyFactor(big.NewInt(6))
+	spvChain.setCurrentEpoch(392)
+
+	btcChain := newLocalBitcoinChain()
+	btcChain.addBlockHeader(790277, &bitcoin.BlockHeader{})
+
+	poisonTransactionHash := bitcoin.Hash{1}
+	transactionHash := bitcoin.Hash{2}
+
+	for _, hash := range []bitcoin.Hash{poisonTransactionHash, transactionHash} {
+		btcChain.addTransactionConfirmations(hash, 6)
+	}
+
+	var submittedProofs []bitcoin.Hash
+	submitter := func(
+		transactionHash bitcoin.Hash,
+		requiredConfirmations uint,
+		btcChain bitcoin.Chain,
+		spvChain Chain,
+	) error {
+		if transactionHash == poisonTransactionHash {
+			return fmt.Errorf("cannot submit proof")
+		}
+
+		submittedProofs = append(submittedProofs, transactionHash)
+		return nil
+	}
+
+	spvMaintainer := &spvMaintainer{
+		spvChain:     spvChain,
+		btcDiffChain: spvChain,
+		btcChain:     btcChain,
+		proofTypes: map[tbtc.WalletActionType]proofType{
+			tbtc.ActionRedemption: {
+				transactionProofSubmitter: submitter,
+			},
+			tbtc.ActionDepositSweep: {
+				transactionProofSubmitter: submitter,
+			},
+		},
+		proofQueue: newProofQueue(nil, time.Hour, time.Hour),
+	}
+
+	// The poison transaction is the most urgent one so it is proven first.
+	spvMaintainer.proofQueue.update(
+		tbtc.ActionRedemption,
+		map[bitcoin.Hash]time.Time{poisonTransactionHash: time.Now()},
+		time.Now(),
+	)
+	spvMaintainer.proofQueue.update(
+		tbtc.ActionDepositSweep,
+		map[bitcoin.Hash]time.Time{transactionHash: {}},
+		time.Now(),
+	)
+
+	err := spvMaintainer.proveQueuedTransactions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual([]bitcoin.Hash{transactionHash}, submittedProofs) {
+		t.Errorf(
+			"unexpected submitted proofs\nexpected: [%v]\nactual:   [%v]",
+			[]bitcoin.Hash{transactionHash},
+			submittedProofs,
+		)
+	}
+
+	// The proven transaction is removed from the queue while the poison one
+	// stays there, waiting for the retry.
+	testutils.AssertIntsEqual(
+		t,
+		"queue length",
+		1,
+		spvMaintainer.proofQueue.len(),
+	)
+	testutils.AssertIntsEqual(
+		t,
+		"due tasks count",
+		0,
+		len(spvMaintainer.proofQueue.due(time.Now())),
+	)
+	testutils.AssertUintsEqual(
+		t,
+		"poison transaction failures",
+		1,
+		uint64(spvMaintainer.proofQueue.tasks[poisonTransactionHash].failures),
+	)
+}
+
+func assertTasksOrder(
+	t *testing.T,
+	expectedTransactionHashes []bitcoin.Hash,
+	tasks []*proofTask,
+) {
+	actualTransactionHashes := make([]bitcoin.Hash, len(tasks))
+	for i, task := range tasks {
+		actualTransactionHashes[i] = task.transactionHash
+	}
+
+	if !reflect.DeepEqual(expectedTransactionHashes, actualTransactionHashes) {
+		t.Errorf(
+			"unexpected tasks order\nexpected: [%v]\nactual:   [%v]",
+			expectedTransactionHashes,
+			actualTransactionHashes,
+		)
+	}
+}
@@ -3,6 +3,8 @@ package spv
 import (
 	"bytes"
 	"fmt"
+	"time"
+
 	"github.com/keep-network/keep-core/pkg/bitcoin"
 	"github.com/keep-network/keep-core/pkg/tbtc"
 )
@@ -225,6 +227,65 @@ func getUnprovenRedemptionTransactions(
 	return unprovenRedemptionTransactions, nil
 }
 
+// getRedemptionProofDeadline returns the timeout of the oldest pending
+// redemption request handled by the given unproven redemption transaction.
+// The proof should be submitted before that time, otherwise the request can
+// be reported as timed out even though it was handled.
+func getRedemptionProofDeadline(
+	transaction *bitcoin.Transaction,
+	btcChain bitcoin.Chain,
+	spvChain Chain,
+) (time.Time, error) {
+	_, walletPublicKeyHash, err := parseRedemptionTransactionInput(
+		btcChain,
+		transaction,
+	)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"error while parsing transaction inputs: [%v]",
+			err,
+		)
+	}
+
+	_, _, _, _, timeout, _, _, err := spvChain.GetRedemptionParameters()
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"cannot get redemption parameters: [%v]",
+			err,
+		)
+	}
+
+	var oldestRequestedAt time.Time
+
+	for _, output := range transaction.Outputs {
+		request, found, err := spvChain.GetPendingRedemptionRequest(
+			walletPublicKeyHash,
+			output.PublicKeyScript,
+		)
+		if err != nil {
+			return time.Time{}, fmt.Errorf(
+				"failed to get pending redemption request: [%w]",
+				err,
+			)
+		}
+		if !found {
+			// The output is the wallet change.
+			continue
+		}
+
+		if oldestRequestedAt.IsZero() ||
+			request.RequestedAt.Before(oldestRequestedAt) {
+			oldestRequestedAt = request.RequestedAt
+		}
+	}
+
+	if oldestRequestedAt.IsZero() {
+		return time.Time{}, nil
+	}
+
+	return oldestRequestedAt.Add(time.Duration(timeout) * time.Second), nil
+}
+
 func isUnprovenRedemptionTransaction(
 	transaction *bitcoin.Transaction,
 	walletPublicKeyHash [20]byte,
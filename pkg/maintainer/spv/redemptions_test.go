@@ -8,6 +8,7 @@ import (
 	"github.com/keep-network/keep-core/pkg/bitcoin"
 	"github.com/keep-network/keep-core/pkg/tbtc"
 	"testing"
+	"time"
 )
 
 func TestSubmitRedemptionProof(t *testing.T) {
@@ -311,3 +312,79 @@ func TestGetUnprovenRedemptionTransactions(t *testing.T) {
 		t.Errorf("invalid unproven transaction hashes: %v", diff)
 	}
 }
+
+func TestGetRedemptionProofDeadline(t *testing.T) {
+	bytesFromHex := func(str string) []byte {
+		value, err := hex.DecodeString(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return value
+	}
+
+	txFromHex := func(str string) *bitcoin.Transaction {
+		transaction := new(bitcoin.Transaction)
+		err := transaction.Deserialize(bytesFromHex(str))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return transaction
+	}
+
+	btcChain := newLocalBitcoinChain()
+	spvChain := newLocalChain()
+
+	// Take the same redemption transaction as in TestSubmitRedemptionProof.
+	// Its first output is the wallet change and the remaining two outputs
+	// handle redemption requests.
+	redemptionTransaction := txFromHex("0100000000010189a128bbd1fd4626f752aa9036a118b2f4b2363ef409f5b527c69d048214d3130000000000ffffffff039ef9e92e0000000016001403b74d6893ad46dfdd01b9e0e3b3385f4fce2d1e6eed10000000000017a91486884e6be1525dab5ae0b451bd2c72cee67dcf4187791411000000000017a914538e4cc700d6510c8cae5e8b688d65276771e6088702483045022100b2e7fc655e0ddadbfef49201fb5f7046a40b36848c08f17ef2e4483bffb7a29e022024616909a96f8c901572d6a9e19d29d6aee6a835b409d4383a463fe1b338a2940121028ed84936be6a9f594a2dcc636d4bebf132713da3ce4dac5c61afbf8bbb47d6f700000000")
+	redemptionInputTransaction := txFromHex("01000000000101db7aad9f51cffa7cebf5a3b41dc3552e1151d2550d8919a8e13d6bb00e046d5b0000000000ffffffff0333fc0b2f0000000016001403b74d6893ad46dfdd01b9e0e3b3385f4fce2d1e182612000000000017a914538e4cc700d6510c8cae5e8b688d65276771e60887aa9f10000000000017a91486884e6be1525dab5ae0b451bd2c72cee67dcf418702483045022100dded6eeacf49830de6f6b590a56f9b8ba3c2fda0b24e7f51884226a5ee78b5c2022024b1fbf3406716c9f9c5bfe241cfc0766af8209ecf8eb5f3318b407fd41c59ec0121028ed84936be6a9f594a2dcc636d4bebf132713da3ce4dac5c61afbf8bbb47d6f700000000")
+	err := btcChain.BroadcastTransaction(redemptionInputTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var walletPublicKeyHash [20]byte
+	copy(
+		walletPublicKeyHash[:],
+		bytesFromHex("03b74d6893ad46dfdd01b9e0e3b3385f4fce2d1e"),
+	)
+
+	requestedAt := time.Unix(1700000000, 0)
+
+	spvChain.setPendingRedemptionRequest(
+		walletPublicKeyHash,
+		&tbtc.RedemptionRequest{
+			RedeemerOutputScript: redemptionTransaction.Outputs[1].PublicKeyScript,
+			RequestedAt:          requestedAt.Add(time.Hour),
+		},
+	)
+	spvChain.setPendingRedemptionRequest(
+		walletPublicKeyHash,
+		&tbtc.RedemptionRequest{
+			RedeemerOutputScript: redemptionTransaction.Outputs[2].PublicKeyScript,
+			RequestedAt:          requestedAt,
+		},
+	)
+	spvChain.setRedemptionTimeout(432000) // 5 days
+
+	deadline, err := getRedemptionProofDeadline(
+		redemptionTransaction,
+		btcChain,
+		spvChain,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedDeadline := requestedAt.Add(5 * 24 * time.Hour)
+	if !expectedDeadline.Equal(deadline) {
+		t.Errorf(
+			"unexpected deadline\nexpected: [%v]\nactual:   [%v]",
+			expectedDeadline,
+			deadline,
+		)
+	}
+}
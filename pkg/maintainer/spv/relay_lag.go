@@ -0,0 +1,129 @@
+package spv
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/maintainer/btcdiff"
+)
+
+// relayLag describes a transaction whose proof cannot be built only because
+// the Bitcoin difficulty chain (relay) lags behind the Bitcoin blockchain.
+type relayLag struct {
+	// relayEpoch is the current difficulty epoch as seen by the relay.
+	relayEpoch uint64
+	// requiredEpoch is the difficulty epoch the relay must reach so the
+	// proof of the transaction can be built.
+	requiredEpoch uint64
+	// bitcoinEpoch is the difficulty epoch of the Bitcoin blockchain tip.
+	bitcoinEpoch uint64
+}
+
+// getRelayLag checks whether the proof of the given transaction goes outside
+// the previous and current difficulty epochs as seen by the relay only
+// because the relay has not been updated with the recent Bitcoin epochs yet.
+// Returns nil if that is not the case, e.g. the transaction is too old to be
+// proven or the end of its proof has not been mined yet.
+func getRelayLag(
+	transactionHash bitcoin.Hash,
+	btcChain bitcoin.Chain,
+	spvChain Chain,
+	btcDiffChain btcdiff.Chain,
+) (*relayLag, error) {
+	latestBlockHeight, err := btcChain.GetLatestBlockHeight()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get latest block height: [%v]",
+			err,
+		)
+	}
+
+	accumulatedConfirmations, err := btcChain.GetTransactionConfirmations(
+		transactionHash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get transaction confirmations: [%v]",
+			err,
+		)
+	}
+
+	txProofDifficultyFactor, err := spvChain.TxProofDifficultyFactor()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get transaction proof difficulty factor: [%v]",
+			err,
+		)
+	}
+
+	currentEpoch, err := btcDiffChain.CurrentEpoch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current epoch: [%v]", err)
+	}
+
+	proofStartBlock := uint64(latestBlockHeight - accumulatedConfirmations + 1)
+	proofStartEpoch := proofStartBlock / difficultyEpochLength
+
+	proofEndBlock := proofStartBlock + txProofDifficultyFactor.Uint64() - 1
+	proofEndEpoch := proofEndBlock / difficultyEpochLength
+
+	bitcoinEpoch := uint64(latestBlockHeight) / difficultyEpochLength
+
+	// The proof must not begin before the previous epoch as seen by the
+	// relay; such a transaction is too old to be proven at all. The proof
+	// must end after the current epoch as seen by the relay, in an epoch
+	// the Bitcoin blockchain has already reached.
+	if proofStartEpoch+1 < currentEpoch ||
+		proofEndEpoch <= currentEpoch ||
+		bitcoinEpoch <= currentEpoch {
+		return nil, nil
+	}
+
+	return &relayLag{
+		relayEpoch:    currentEpoch,
+		requiredEpoch: proofEndEpoch,
+		bitcoinEpoch:  bitcoinEpoch,
+	}, nil
+}
+
+// reportRelayLag logs queued transactions whose proofs are blocked by the
+// relay lag, along with the time they have been blocked for.
+func (sm *spvMaintainer) reportRelayLag(now time.Time) {
+	var tasks []*proofTask
+	for _, task := range sm.proofQueue.tasks {
+		if task.relayLag != nil {
+			tasks = append(tasks, task)
+		}
+	}
+
+	if len(tasks) == 0 {
+		return
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].relayLagSince.Before(tasks[j].relayLagSince)
+	})
+
+	logger.Warnf(
+		"[%d] transaction(s) cannot be proven due to the relay lag; "+
+			"the relay is at epoch [%d] while Bitcoin is at epoch [%d]; "+
+			"the oldest one is blocked for [%s]",
+		len(tasks),
+		tasks[0].relayLag.relayEpoch,
+		tasks[0].relayLag.bitcoinEpoch,
+		now.Sub(tasks[0].relayLagSince).Truncate(time.Second),
+	)
+
+	for _, task := range tasks {
+		logger.Warnf(
+			"[%s] transaction [%s] requires the relay at epoch [%d]; "+
+				"blocked for [%s]",
+			task.action,
+			task.transactionHash.Hex(bitcoin.ReversedByteOrder),
+			task.relayLag.requiredEpoch,
+			now.Sub(task.relayLagSince).Truncate(time.Second),
+		)
+	}
+}
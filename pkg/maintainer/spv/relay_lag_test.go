@@ -0,0 +1,241 @@
+package spv
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/maintainer/btcdiff"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+func TestGetRelayLag(t *testing.T) {
+	tests := map[string]struct {
+		latestBlockHeight        uint
+		transactionConfirmations uint
+		currentEpoch             uint64
+		expectedRelayLag         *relayLag
+	}{
+		"proof within relay range": {
+			latestBlockHeight:        790277,
+			transactionConfirmations: 6,
+			currentEpoch:             392,
+			expectedRelayLag:         nil,
+		},
+		"relay lags one epoch behind": {
+			latestBlockHeight:        790277,
+			transactionConfirmations: 6,
+			currentEpoch:             391,
+			expectedRelayLag: &relayLag{
+				relayEpoch:    391,
+				requiredEpoch: 392,
+				bitcoinEpoch:  392,
+			},
+		},
+		"relay lags several epochs behind": {
+			latestBlockHeight:        794309,
+			transactionConfirmations: 6,
+			currentEpoch:             392,
+			expectedRelayLag: &relayLag{
+				relayEpoch:    392,
+				requiredEpoch: 394,
+				bitcoinEpoch:  394,
+			},
+		},
+		"proof ends in epoch not reached by Bitcoin yet": {
+			latestBlockHeight:        790271,
+			transactionConfirmations: 1,
+			currentEpoch:             391,
+			expectedRelayLag:         nil,
+		},
+		"transaction too old": {
+			latestBlockHeight:        790300,
+			transactionConfirmations: 4100,
+			currentEpoch:             392,
+			expectedRelayLag:         nil,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			transactionHash := bitcoin.Hash{1}
+
+			localChain := newLocalChain()
+			localChain.setTxProofDifficultyFactor(big.NewInt(6))
+			localChain.setCurrentEpoch(test.currentEpoch)
+
+			btcChain := newLocalBitcoinChain()
+			btcChain.addBlockHeader(
+				test.latestBlockHeight,
+				&bitcoin.BlockHeader{},
+			)
+			btcChain.addTransactionConfirmations(
+				transactionHash,
+				test.transactionConfirmations,
+			)
+
+			relayLag, err := getRelayLag(
+				transactionHash,
+				btcChain,
+				localChain,
+				localChain,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(test.expectedRelayLag, relayLag) {
+				t.Errorf(
+					"unexpected relay lag\nexpected: [%+v]\nactual:   [%+v]",
+					test.expectedRelayLag,
+					relayLag,
+				)
+			}
+		})
+	}
+}
+
+func TestSpvMaintainer_ProveTransaction_RelayLag(t *testing.T) {
+	spvChain := newLocalChain()
+	spvChain.setTxProofDifficultyFactor(big.NewInt(6))
+	spvChain.setCurrentEpoch(391)
+
+	btcChain := newLocalBitcoinChain()
+	btcChain.addBlockHeader(790277, &bitcoin.BlockHeader{})
+
+	transactionHash := bitcoin.Hash{1}
+	btcChain.addTransactionConfirmations(transactionHash, 6)
+
+	proofSubmitted := false
+	submitter := func(
+		transactionHash bitcoin.Hash,
+		requiredConfirmations uint,
+		btcChain bitcoin.Chain,
+		spvChain Chain,
+	) error {
+		proofSubmitted = true
+		return nil
+	}
+
+	retargetRequests := btcdiff.NewRetargetRequests()
+
+	spvMaintainer := &spvMaintainer{
+		spvChain:         spvChain,
+		btcDiffChain:     spvChain,
+		btcChain:         btcChain,
+		proofQueue:       newProofQueue(nil, time.Hour, time.Hour),
+		retargetRequests: retargetRequests,
+	}
+
+	spvMaintainer.proofQueue.update(
+		tbtc.ActionDepositSweep,
+		map[bitcoin.Hash]time.Time{transactionHash: {}},
+		time.Now(),
+	)
+
+	// The relay lags one epoch behind so the proof cannot be built.
+	for i := 0; i < 2; i++ {
+		proven, err := spvMaintainer.proveTransaction(
+			transactionHash,
+			time.Time{},
+			submitter,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testutils.AssertBoolsEqual(t, "proven", false, proven)
+	}
+
+	testutils.AssertBoolsEqual(t, "proof submitted", false, proofSubmitted)
+
+	// The Bitcoin difficulty maintainer should be requested to prove the
+	// epoch required by the proof. The request is still pending as there
+	// is no Bitcoin difficulty maintainer to handle it.
+	pendingRequest := retargetRequests.Pending()
+	if pendingRequest == nil {
+		t.Fatal("expected pending retarget request")
+	}
+	testutils.AssertUintsEqual(
+		t,
+		"requested epoch",
+		392,
+		pendingRequest.Epoch,
+	)
+
+	task := spvMaintainer.proofQueue.tasks[transactionHash]
+	expectedRelayLag := &relayLag{
+		relayEpoch:    391,
+		requiredEpoch: 392,
+		bitcoinEpoch:  392,
+	}
+	if !reflect.DeepEqual(expectedRelayLag, task.relayLag) {
+		t.Errorf(
+			"unexpected relay lag\nexpected: [%+v]\nactual:   [%+v]",
+			expectedRelayLag,
+			task.relayLag,
+		)
+	}
+
+	// Once the relay catches up, the transaction is proven and the relay lag
+	// record is cleared.
+	spvChain.setCurrentEpoch(392)
+
+	proven, err := spvMaintainer.proveTransaction(
+		transactionHash,
+		time.Time{},
+		submitter,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertBoolsEqual(t, "proven", true, proven)
+	testutils.AssertBoolsEqual(t, "proof submitted", true, proofSubmitted)
+
+	if task.relayLag != nil || !task.relayLagSince.IsZero() {
+		t.Error("expected relay lag record to be cleared")
+	}
+}
+
+func TestProofQueue_SetRelayLag(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	queue := newProofQueue(nil, time.Minute, time.Hour)
+	queue.update(
+		tbtc.ActionRedemption,
+		map[bitcoin.Hash]time.Time{{1}: {}},
+		now,
+	)
+
+	task := queue.tasks[bitcoin.Hash{1}]
+
+	queue.setRelayLag(bitcoin.Hash{1}, &relayLag{requiredEpoch: 392}, now)
+	queue.setRelayLag(
+		bitcoin.Hash{1},
+		&relayLag{requiredEpoch: 393},
+		now.Add(time.Hour),
+	)
+
+	// Consecutive blocked attempts keep the time of the first one.
+	testutils.AssertUintsEqual(t, "required epoch", 393, task.relayLag.requiredEpoch)
+	testutils.AssertIntsEqual(
+		t,
+		"blocked since",
+		int(now.Unix()),
+		int(task.relayLagSince.Unix()),
+	)
+
+	queue.setRelayLag(bitcoin.Hash{1}, nil, now.Add(2*time.Hour))
+
+	if task.relayLag != nil || !task.relayLagSince.IsZero() {
+		t.Error("expected relay lag record to be cleared")
+	}
+
+	// Transactions that are not queued are ignored.
+	queue.setRelayLag(bitcoin.Hash{2}, &relayLag{}, now)
+	testutils.AssertIntsEqual(t, "queue length", 1, queue.len())
+}
@@ -11,9 +11,11 @@ import (
 	"github.com/keep-network/keep-core/pkg/tbtc"
 
 	"github.com/ipfs/go-log/v2"
+	"github.com/keep-network/keep-common/pkg/persistence"
 
 	"github.com/keep-network/keep-core/pkg/bitcoin"
 	"github.com/keep-network/keep-core/pkg/maintainer/btcdiff"
+	"github.com/keep-network/keep-core/pkg/maintainer/submission"
 )
 
 var logger = log.Logger("keep-maintainer-spv")
@@ -27,23 +29,55 @@ func Initialize(
 	spvChain Chain,
 	btcDiffChain btcdiff.Chain,
 	btcChain bitcoin.Chain,
+	submissionPolicy *submission.Policy,
+	retargetRequests *btcdiff.RetargetRequests,
+	proofQueuePersistence persistence.BasicHandle,
 ) {
+	proofCoordinator := newProofCoordinator(
+		btcDiffChain.Signing().Address(),
+		config.Maintainers,
+		config.ClaimPeriod,
+	)
+	if proofCoordinator == nil && len(config.Maintainers) > 0 {
+		logger.Warnf(
+			"maintainer [%s] is not on the list of SPV maintainers; "+
+				"proof coordination is disabled",
+			btcDiffChain.Signing().Address(),
+		)
+	}
+
 	spvMaintainer := &spvMaintainer{
 		config:       config,
 		spvChain:     spvChain,
 		btcDiffChain: btcDiffChain,
 		btcChain:     btcChain,
+		proofTypes:   proofTypes,
+		proofQueue: newProofQueue(
+			proofQueuePersistence,
+			config.RetryBackoffTime,
+			config.MaxRetryBackoffTime,
+		),
+		proofCoordinator: proofCoordinator,
+		submissionPolicy: submissionPolicy,
+		retargetRequests: retargetRequests,
 	}
 
 	go spvMaintainer.startControlLoop(ctx)
 }
 
-// proofTypes holds the information about proof types supported by the
-// SPV maintainer.
-var proofTypes = map[tbtc.WalletActionType]struct {
+// proofType holds the functions used to find and prove Bitcoin transactions
+// of the given type.
+type proofType struct {
 	unprovenTransactionsGetter unprovenTransactionsGetter
 	transactionProofSubmitter  transactionProofSubmitter
-}{
+	// proofDeadlineGetter is optional. If not set, proofs of the given type
+	// have no deadline.
+	proofDeadlineGetter proofDeadlineGetter
+}
+
+// proofTypes holds the information about proof types supported by the
+// SPV maintainer.
+var proofTypes = map[tbtc.WalletActionType]proofType{
 	tbtc.ActionDepositSweep: {
 		unprovenTransactionsGetter: getUnprovenDepositSweepTransactions,
 		transactionProofSubmitter:  SubmitDepositSweepProof,
@@ -51,6 +85,7 @@ var proofTypes = map[tbtc.WalletActionType]struct {
 	tbtc.ActionRedemption: {
 		unprovenTransactionsGetter: getUnprovenRedemptionTransactions,
 		transactionProofSubmitter:  SubmitRedemptionProof,
+		proofDeadlineGetter:        getRedemptionProofDeadline,
 	},
 	tbtc.ActionMovingFunds: {
 		unprovenTransactionsGetter: getUnprovenMovingFundsTransactions,
@@ -67,6 +102,18 @@ type spvMaintainer struct {
 	spvChain     Chain
 	btcDiffChain btcdiff.Chain
 	btcChain     bitcoin.Chain
+
+	proofTypes map[tbtc.WalletActionType]proofType
+	proofQueue *proofQueue
+	// proofCoordinator is nil if the coordination with other maintainers
+	// is disabled.
+	proofCoordinator *proofCoordinator
+	// submissionPolicy is nil if proofs should be submitted regardless of
+	// the gas price.
+	submissionPolicy *submission.Policy
+	// retargetRequests is nil if the Bitcoin difficulty maintainer does
+	// not run along with the SPV maintainer.
+	retargetRequests *btcdiff.RetargetRequests
 }
 
 func (sm *spvMaintainer) startControlLoop(ctx context.Context) {
@@ -95,21 +142,27 @@ func (sm *spvMaintainer) startControlLoop(ctx context.Context) {
 
 func (sm *spvMaintainer) maintainSpv(ctx context.Context) error {
 	for {
-		for action, v := range proofTypes {
-			logger.Infof("starting [%s] proof task execution...", action)
+		for action, v := range sm.proofTypes {
+			logger.Infof("looking for unproven [%s] transactions...", action)
 
-			if err := sm.proveTransactions(
+			if err := sm.queueTransactions(
+				action,
 				v.unprovenTransactionsGetter,
-				v.transactionProofSubmitter,
+				v.proofDeadlineGetter,
 			); err != nil {
-				return fmt.Errorf(
-					"error while proving [%s] transactions: [%v]",
+				// Do not abort the round. Transactions of the given type
+				// queued in previous rounds remain in the queue and
+				// transactions of other types are not affected.
+				logger.Errorf(
+					"error while looking for unproven [%s] transactions: [%v]",
 					action,
 					err,
 				)
 			}
+		}
 
-			logger.Infof("[%s] proof task completed", action)
+		if err := sm.proveQueuedTransactions(ctx); err != nil {
+			return err
 		}
 
 		logger.Infof(
@@ -146,12 +199,22 @@ type transactionProofSubmitter func(
 	spvChain Chain,
 ) error
 
-// proveTransactions gets unproven Bitcoin transactions using the provided
-// unprovenTransactionsGetter, build the SPV proofs, and submits them using
-// the provided transactionProofSubmitter.
-func (sm *spvMaintainer) proveTransactions(
+// proofDeadlineGetter is a type representing a function that is used to
+// determine the time by which the proof of the given unproven transaction
+// should be submitted. Zero time means the proof has no deadline.
+type proofDeadlineGetter func(
+	transaction *bitcoin.Transaction,
+	btcChain bitcoin.Chain,
+	spvChain Chain,
+) (time.Time, error)
+
+// queueTransactions gets unproven Bitcoin transactions using the provided
+// unprovenTransactionsGetter and updates the proof queue with them. The
+// optional proofDeadlineGetter is used to determine the urgency of proofs.
+func (sm *spvMaintainer) queueTransactions(
+	action tbtc.WalletActionType,
 	unprovenTransactionsGetter unprovenTransactionsGetter,
-	transactionProofSubmitter transactionProofSubmitter,
+	proofDeadlineGetter proofDeadlineGetter,
 ) error {
 	transactions, err := unprovenTransactionsGetter(
 		sm.config.HistoryDepth,
@@ -163,72 +226,285 @@ func (sm *spvMaintainer) proveTransactions(
 		return fmt.Errorf("failed to get unproven transactions: [%v]", err)
 	}
 
-	logger.Infof("found [%d] unproven transaction(s)", len(transactions))
+	logger.Infof(
+		"found [%d] unproven [%s] transaction(s)",
+		len(transactions),
+		action,
+	)
+
+	deadlines := make(map[bitcoin.Hash]time.Time, len(transactions))
 
 	for _, transaction := range transactions {
+		var deadline time.Time
+
+		if proofDeadlineGetter != nil {
+			deadline, err = proofDeadlineGetter(
+				transaction,
+				sm.btcChain,
+				sm.spvChain,
+			)
+			if err != nil {
+				// The deadline only affects the order of proofs. Queue the
+				// transaction anyway.
+				logger.Warnf(
+					"cannot determine proof deadline for transaction [%s]: [%v]",
+					transaction.Hash().Hex(bitcoin.ReversedByteOrder),
+					err,
+				)
+			}
+		}
+
+		deadlines[transaction.Hash()] = deadline
+	}
+
+	sm.proofQueue.update(action, deadlines, time.Now())
+
+	return nil
+}
+
+// proveQueuedTransactions builds and submits SPV proofs for queued
+// transactions, the most urgent first. A failure to prove a transaction
+// postpones further attempts for that transaction only and does not stop
+// proving the remaining ones.
+func (sm *spvMaintainer) proveQueuedTransactions(ctx context.Context) error {
+	tasks := sm.proofQueue.due(time.Now())
+
+	logger.Infof(
+		"proving [%d/%d] queued transaction(s)",
+		len(tasks),
+		sm.proofQueue.len(),
+	)
+
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		// Print the transaction in the same endianness as block explorers do.
-		transactionHashStr := transaction.Hash().Hex(bitcoin.ReversedByteOrder)
+		transactionHashStr := task.transactionHash.Hex(bitcoin.ReversedByteOrder)
 
-		logger.Infof(
-			"proceeding with proof for transaction [%s]",
-			transactionHashStr,
-		)
+		if sm.proofCoordinator != nil {
+			blockCounter, err := sm.spvChain.BlockCounter()
+			if err != nil {
+				return fmt.Errorf("failed to get block counter: [%v]", err)
+			}
 
-		isProofWithinRelayRange, accumulatedConfirmations, requiredConfirmations, err := getProofInfo(
-			transaction.Hash(),
-			sm.btcChain,
-			sm.spvChain,
-			sm.btcDiffChain,
+			currentBlock, err := blockCounter.CurrentBlock()
+			if err != nil {
+				return fmt.Errorf("failed to get current block: [%v]", err)
+			}
+
+			isClaimant, claim := sm.proofCoordinator.isClaimant(
+				task.transactionHash,
+				currentBlock,
+			)
+			if !isClaimant {
+				// Back off and let the peer maintainer submit the proof. If
+				// the peer does not do it before its claim expires, the
+				// transaction is taken over by the next maintainer.
+				logger.Infof(
+					"skipped proving transaction [%s]; transaction is "+
+						"claimed by maintainer [%s] until block [%d]",
+					transactionHashStr,
+					claim.maintainer,
+					claim.expiresAt,
+				)
+				continue
+			}
+		}
+
+		proven, err := sm.proveTransaction(
+			task.transactionHash,
+			task.deadline,
+			sm.proofTypes[task.action].transactionProofSubmitter,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to get proof info: [%v]", err)
-		}
+			nextAttemptAt := sm.proofQueue.failed(
+				task.transactionHash,
+				err,
+				time.Now(),
+			)
 
-		if !isProofWithinRelayRange {
-			// The required proof goes outside the previous and current
-			// difficulty epochs as seen by the relay. Skip the transaction. It
-			// will most likely be proven later.
-			logger.Warnf(
-				"skipped proving transaction [%s]; the range "+
-					"of the required proof goes outside the previous and "+
-					"current difficulty epochs as seen by the relay",
+			logger.Errorf(
+				"failed to prove [%s] transaction [%s] (failure [%d]): [%v]; "+
+					"next attempt not earlier than [%s]",
+				task.action,
 				transactionHashStr,
+				task.failures,
+				err,
+				nextAttemptAt.Format(time.RFC3339),
 			)
 			continue
 		}
 
-		if accumulatedConfirmations < requiredConfirmations {
-			// Skip the transaction as it has not accumulated enough
-			// confirmations. It will be proven later.
+		if proven {
+			sm.proofQueue.succeeded(task.transactionHash)
+
 			logger.Infof(
-				"skipped proving transaction [%s]; transaction "+
-					"has [%v/%v] confirmations",
+				"successfully submitted proof for transaction [%s]",
 				transactionHashStr,
-				accumulatedConfirmations,
-				requiredConfirmations,
 			)
-			continue
 		}
+	}
 
-		err = transactionProofSubmitter(
-			transaction.Hash(),
-			requiredConfirmations,
+	sm.reportRelayLag(time.Now())
+
+	logger.Infof("finished round of proving transactions")
+
+	return nil
+}
+
+// proveTransaction builds the SPV proof of the given transaction and submits
+// it using the provided transactionProofSubmitter. Returns false if the
+// transaction cannot be proven yet, or its proof was deferred by the
+// submission policy, and should be retried later.
+func (sm *spvMaintainer) proveTransaction(
+	transactionHash bitcoin.Hash,
+	deadline time.Time,
+	transactionProofSubmitter transactionProofSubmitter,
+) (bool, error) {
+	// Print the transaction in the same endianness as block explorers do.
+	transactionHashStr := transactionHash.Hex(bitcoin.ReversedByteOrder)
+
+	logger.Infof(
+		"proceeding with proof for transaction [%s]",
+		transactionHashStr,
+	)
+
+	isProofWithinRelayRange, accumulatedConfirmations, requiredConfirmations, err := getProofInfo(
+		transactionHash,
+		sm.btcChain,
+		sm.spvChain,
+		sm.btcDiffChain,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to get proof info: [%v]", err)
+	}
+
+	if !isProofWithinRelayRange {
+		relayLag, err := getRelayLag(
+			transactionHash,
 			sm.btcChain,
 			sm.spvChain,
+			sm.btcDiffChain,
 		)
 		if err != nil {
-			return err
+			return false, fmt.Errorf("failed to get relay lag: [%v]", err)
 		}
 
+		sm.proofQueue.setRelayLag(transactionHash, relayLag, time.Now())
+
+		if relayLag != nil {
+			// The transaction can be proven as soon as the relay catches
+			// up with the Bitcoin blockchain. Ask the Bitcoin difficulty
+			// maintainer to prove the missing epochs right away.
+			requested := sm.retargetRequests.Request(
+				&btcdiff.RetargetRequest{
+					Epoch:    relayLag.requiredEpoch,
+					Deadline: deadline,
+				},
+			)
+
+			logger.Warnf(
+				"skipped proving transaction [%s]; the relay at epoch "+
+					"[%d] lags behind the epoch [%d] required by the "+
+					"proof; requested proving new epochs: [%v]",
+				transactionHashStr,
+				relayLag.relayEpoch,
+				relayLag.requiredEpoch,
+				requested,
+			)
+			return false, nil
+		}
+
+		// The required proof goes outside the previous and current
+		// difficulty epochs as seen by the relay. Skip the transaction. It
+		// will most likely be proven later.
+		logger.Warnf(
+			"skipped proving transaction [%s]; the range "+
+				"of the required proof goes outside the previous and "+
+				"current difficulty epochs as seen by the relay",
+			transactionHashStr,
+		)
+		return false, nil
+	}
+
+	sm.proofQueue.setRelayLag(transactionHash, nil, time.Now())
+
+	if accumulatedConfirmations < requiredConfirmations {
+		// Skip the transaction as it has not accumulated enough
+		// confirmations. It will be proven later.
 		logger.Infof(
-			"successfully submitted proof for transaction [%s]",
+			"skipped proving transaction [%s]; transaction "+
+				"has [%v/%v] confirmations",
 			transactionHashStr,
+			accumulatedConfirmations,
+			requiredConfirmations,
 		)
+		return false, nil
 	}
 
-	logger.Infof("finished round of proving transactions")
+	if sm.submissionPolicy != nil {
+		urgent, err := sm.isProofUrgent(deadline, accumulatedConfirmations)
+		if err != nil {
+			return false, fmt.Errorf(
+				"failed to determine proof urgency: [%v]",
+				err,
+			)
+		}
 
-	return nil
+		if !sm.submissionPolicy.Decide(urgent).ShouldSubmit() {
+			logger.Infof(
+				"deferred proving transaction [%s] due to high gas price",
+				transactionHashStr,
+			)
+			return false, nil
+		}
+	}
+
+	err = transactionProofSubmitter(
+		transactionHash,
+		requiredConfirmations,
+		sm.btcChain,
+		sm.spvChain,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// isProofUrgent returns true if the proof of a transaction with the given
+// deadline and accumulated confirmations cannot be deferred due to high gas
+// price. That is the case if the deadline is within the urgency window of
+// the submission policy, or if the proof starts in the previous difficulty
+// epoch as seen by the relay and would fall out of the relay range once the
+// next epoch is proven.
+func (sm *spvMaintainer) isProofUrgent(
+	deadline time.Time,
+	accumulatedConfirmations uint,
+) (bool, error) {
+	if sm.submissionPolicy.IsUrgent(deadline, time.Now()) {
+		return true, nil
+	}
+
+	latestBlockHeight, err := sm.btcChain.GetLatestBlockHeight()
+	if err != nil {
+		return false, fmt.Errorf(
+			"failed to get latest block height: [%v]",
+			err,
+		)
+	}
+
+	currentEpoch, err := sm.btcDiffChain.CurrentEpoch()
+	if err != nil {
+		return false, fmt.Errorf("failed to get current epoch: [%v]", err)
+	}
+
+	proofStartBlock := uint64(latestBlockHeight - accumulatedConfirmations + 1)
+
+	return proofStartBlock/difficultyEpochLength < currentEpoch, nil
 }
 
 func isInputCurrentWalletsMainUTXO(
@@ -5,9 +5,12 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/keep-network/keep-common/pkg/chain/ethereum"
 	"github.com/keep-network/keep-core/internal/testutils"
 	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/maintainer/submission"
 	"github.com/keep-network/keep-core/pkg/tbtc"
 )
 
@@ -324,3 +327,117 @@ func TestIsInputCurrentWalletsMainUTXO(t *testing.T) {
 		})
 	}
 }
+
+type localGasPriceChain struct {
+	gasPrice *big.Int
+}
+
+func (lgpc *localGasPriceChain) GasPrice() (*big.Int, error) {
+	return lgpc.gasPrice, nil
+}
+
+func TestSpvMaintainer_ProveTransaction_SubmissionPolicy(t *testing.T) {
+	var tests = map[string]struct {
+		gasPrice               *big.Int
+		deadline               time.Time
+		latestBlockHeight      uint
+		confirmations          uint
+		expectedProofSubmitted bool
+	}{
+		"gas price below maximum": {
+			gasPrice:               big.NewInt(20000000000),
+			latestBlockHeight:      790277,
+			confirmations:          6,
+			expectedProofSubmitted: true,
+		},
+		"gas price above maximum": {
+			gasPrice:               big.NewInt(40000000000),
+			deadline:               time.Now().Add(48 * time.Hour),
+			latestBlockHeight:      790277,
+			confirmations:          6,
+			expectedProofSubmitted: false,
+		},
+		"gas price above maximum and deadline within urgency window": {
+			gasPrice:               big.NewInt(40000000000),
+			deadline:               time.Now().Add(time.Hour),
+			latestBlockHeight:      790277,
+			confirmations:          6,
+			expectedProofSubmitted: true,
+		},
+		"gas price above maximum and proof starting in previous epoch": {
+			gasPrice:               big.NewInt(40000000000),
+			latestBlockHeight:      790300,
+			confirmations:          2041,
+			expectedProofSubmitted: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			spvChain := newLocalChain()
+			spvChain.setTxProofDifficultyFactor(big.NewInt(6))
+			spvChain.setCurrentEpoch(392)
+
+			btcChain := newLocalBitcoinChain()
+			btcChain.addBlockHeader(
+				test.latestBlockHeight,
+				&bitcoin.BlockHeader{},
+			)
+
+			transactionHash := bitcoin.Hash{1}
+			btcChain.addTransactionConfirmations(
+				transactionHash,
+				test.confirmations,
+			)
+
+			proofSubmitted := false
+
+			spvMaintainer := &spvMaintainer{
+				spvChain:     spvChain,
+				btcDiffChain: spvChain,
+				btcChain:     btcChain,
+				proofQueue:   newProofQueue(nil, time.Hour, time.Hour),
+				submissionPolicy: submission.NewPolicy(
+					"spv",
+					submission.Config{
+						MaxGasPrice: *ethereum.WrapWei(
+							big.NewInt(30000000000),
+						),
+						UrgencyWindow: 24 * time.Hour,
+					},
+					&localGasPriceChain{gasPrice: test.gasPrice},
+				),
+			}
+
+			proven, err := spvMaintainer.proveTransaction(
+				transactionHash,
+				test.deadline,
+				func(
+					transactionHash bitcoin.Hash,
+					requiredConfirmations uint,
+					btcChain bitcoin.Chain,
+					spvChain Chain,
+				) error {
+					proofSubmitted = true
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertBoolsEqual(
+				t,
+				"proven",
+				test.expectedProofSubmitted,
+				proven,
+			)
+			testutils.AssertBoolsEqual(
+				t,
+				"proof submitted",
+				test.expectedProofSubmitted,
+				proofSubmitted,
+			)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Package submission provides the policy used by maintainers to decide
+// whether their transactions should be submitted to the host chain given
+// the current gas price.
+package submission
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-log/v2"
+
+	"github.com/keep-network/keep-common/pkg/chain/ethereum"
+	"github.com/keep-network/keep-core/pkg/clientinfo"
+)
+
+var logger = log.Logger("keep-maintainer-submission")
+
+// DefaultUrgencyWindow is the default value for the urgency window.
+const DefaultUrgencyWindow = 24 * time.Hour
+
+// Config holds configurable properties of the submission policy.
+type Config struct {
+	// MaxGasPrice is the maximum gas price at which maintainers submit
+	// transactions that are not urgent. Transactions that are not urgent are
+	// deferred as long as the gas price is higher. Urgent transactions are
+	// always submitted. Zero means there is no limit.
+	MaxGasPrice ethereum.Wei
+
+	// UrgencyWindow determines when a transaction becomes urgent. A
+	// transaction is urgent if its deadline, e.g. the timeout of a redemption
+	// request, is closer than the urgency window, or if the work it submits
+	// has been pending for longer than the urgency window.
+	UrgencyWindow time.Duration
+}
+
+// Chain represents the interface the submission policy expects to interact
+// with the host chain on.
+type Chain interface {
+	// GasPrice returns the current gas price of the host chain, in wei.
+	GasPrice() (*big.Int, error)
+}
+
+// Decision is the decision of the submission policy.
+type Decision int
+
+const (
+	// Submit means the transaction should be submitted as the gas price is
+	// acceptable.
+	Submit Decision = iota
+	// Defer means the transaction should not be submitted yet as the gas
+	// price is too high and the transaction is not urgent.
+	Defer
+	// SubmitUrgent means the transaction should be submitted even though the
+	// gas price is too high, as the transaction is urgent.
+	SubmitUrgent
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Submit:
+		return "Submit"
+	case Defer:
+		return "Defer"
+	case SubmitUrgent:
+		return "SubmitUrgent"
+	default:
+		return fmt.Sprintf("Unknown(%d)", d)
+	}
+}
+
+// ShouldSubmit returns true if the transaction should be submitted.
+func (d Decision) ShouldSubmit() bool {
+	return d != Defer
+}
+
+// Policy decides whether maintainer transactions should be submitted given
+// the current gas price and their urgency. Each maintainer uses its own
+// policy instance so decisions can be reported separately. A nil policy
+// always decides to submit.
+type Policy struct {
+	name   string
+	config Config
+	chain  Chain
+
+	mutex            sync.Mutex
+	lastGasPrice     *big.Int
+	decisionsCount   map[Decision]uint64
+	gasPriceFailures uint64
+}
+
+// NewPolicy creates a new submission policy of the maintainer with the
+// given name.
+func NewPolicy(name string, config Config, chain Chain) *Policy {
+	if config.UrgencyWindow == 0 {
+		config.UrgencyWindow = DefaultUrgencyWindow
+	}
+
+	return &Policy{
+		name:           name,
+		config:         config,
+		chain:          chain,
+		decisionsCount: make(map[Decision]uint64),
+	}
+}
+
+// IsUrgent returns true if the given deadline is closer than the urgency
+// window. A zero deadline is never urgent.
+func (p *Policy) IsUrgent(deadline time.Time, now time.Time) bool {
+	if p == nil || deadline.IsZero() {
+		return false
+	}
+
+	return deadline.Sub(now) <= p.config.UrgencyWindow
+}
+
+// IsOverdue returns true if work that became ready at the given time has
+// been pending for longer than the urgency window.
+func (p *Policy) IsOverdue(readyAt time.Time, now time.Time) bool {
+	if p == nil || readyAt.IsZero() {
+		return false
+	}
+
+	return now.Sub(readyAt) >= p.config.UrgencyWindow
+}
+
+// Decide returns the decision for a transaction of the given urgency. If
+// the gas price cannot be determined, the transaction is submitted as it
+// would have been without the policy.
+func (p *Policy) Decide(urgent bool) Decision {
+	if p == nil {
+		return Submit
+	}
+
+	decision := p.decide(urgent)
+
+	p.mutex.Lock()
+	p.decisionsCount[decision]++
+	p.mutex.Unlock()
+
+	return decision
+}
+
+func (p *Policy) decide(urgent bool) Decision {
+	maxGasPrice := p.config.MaxGasPrice.Int
+	if maxGasPrice == nil || maxGasPrice.Sign() == 0 {
+		return Submit
+	}
+
+	gasPrice, err := p.chain.GasPrice()
+	if err != nil {
+		p.mutex.Lock()
+		p.gasPriceFailures++
+		p.mutex.Unlock()
+
+		logger.Warnf(
+			"[%s] cannot get gas price: [%v]; submitting transaction",
+			p.name,
+			err,
+		)
+		return Submit
+	}
+
+	p.mutex.Lock()
+	p.lastGasPrice = gasPrice
+	p.mutex.Unlock()
+
+	if gasPrice.Cmp(maxGasPrice) <= 0 {
+		return Submit
+	}
+
+	if urgent {
+		logger.Warnf(
+			"[%s] gas price [%v] exceeds the maximum of [%v]; "+
+				"submitting urgent transaction anyway",
+			p.name,
+			formatGwei(gasPrice),
+			formatGwei(maxGasPrice),
+		)
+		return SubmitUrgent
+	}
+
+	logger.Infof(
+		"[%s] gas price [%v] exceeds the maximum of [%v]; "+
+			"deferring transaction",
+		p.name,
+		formatGwei(gasPrice),
+		formatGwei(maxGasPrice),
+	)
+	return Defer
+}
+
+// MetricsSources returns the sources of the policy metrics. Metric names are
+// prefixed with the name of the policy.
+func (p *Policy) MetricsSources() map[string]clientinfo.Source {
+	decisionsCount := func(decision Decision) clientinfo.Source {
+		return func() float64 {
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+
+			return float64(p.decisionsCount[decision])
+		}
+	}
+
+	gwei := func(value func() *big.Int) clientinfo.Source {
+		return func() float64 {
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+
+			wei := value()
+			if wei == nil {
+				return 0
+			}
+
+			result, _ := new(big.Float).Quo(
+				new(big.Float).SetInt(wei),
+				big.NewFloat(1e9),
+			).Float64()
+
+			return result
+		}
+	}
+
+	return map[string]clientinfo.Source{
+		p.name + "_submissions_submitted":        decisionsCount(Submit),
+		p.name + "_submissions_deferred":         decisionsCount(Defer),
+		p.name + "_submissions_submitted_urgent": decisionsCount(SubmitUrgent),
+		p.name + "_gas_price_failures": func() float64 {
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+
+			return float64(p.gasPriceFailures)
+		},
+		p.name + "_gas_price_gwei": gwei(func() *big.Int {
+			return p.lastGasPrice
+		}),
+		p.name + "_max_gas_price_gwei": gwei(func() *big.Int {
+			return p.config.MaxGasPrice.Int
+		}),
+	}
+}
+
+func formatGwei(wei *big.Int) string {
+	gwei := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9))
+	return fmt.Sprintf("%s Gwei", gwei.Text('f', 2))
+}
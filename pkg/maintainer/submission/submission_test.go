@@ -0,0 +1,271 @@
+package submission
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-common/pkg/chain/ethereum"
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+type localChain struct {
+	gasPrice *big.Int
+	err      error
+}
+
+func (lc *localChain) GasPrice() (*big.Int, error) {
+	return lc.gasPrice, lc.err
+}
+
+func TestPolicy_Decide(t *testing.T) {
+	maxGasPrice := big.NewInt(30000000000) // 30 Gwei
+
+	var tests = map[string]struct {
+		maxGasPrice      *big.Int
+		gasPrice         *big.Int
+		gasPriceErr      error
+		urgent           bool
+		expectedDecision Decision
+	}{
+		"no maximum gas price": {
+			maxGasPrice:      nil,
+			gasPrice:         big.NewInt(100000000000),
+			expectedDecision: Submit,
+		},
+		"zero maximum gas price": {
+			maxGasPrice:      big.NewInt(0),
+			gasPrice:         big.NewInt(100000000000),
+			expectedDecision: Submit,
+		},
+		"gas price below maximum": {
+			maxGasPrice:      maxGasPrice,
+			gasPrice:         big.NewInt(20000000000),
+			expectedDecision: Submit,
+		},
+		"gas price equal to maximum": {
+			maxGasPrice:      maxGasPrice,
+			gasPrice:         big.NewInt(30000000000),
+			expectedDecision: Submit,
+		},
+		"gas price above maximum": {
+			maxGasPrice:      maxGasPrice,
+			gasPrice:         big.NewInt(30000000001),
+			expectedDecision: Defer,
+		},
+		"gas price above maximum for urgent transaction": {
+			maxGasPrice:      maxGasPrice,
+			gasPrice:         big.NewInt(30000000001),
+			urgent:           true,
+			expectedDecision: SubmitUrgent,
+		},
+		"gas price cannot be determined": {
+			maxGasPrice:      maxGasPrice,
+			gasPriceErr:      fmt.Errorf("unavailable"),
+			expectedDecision: Submit,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			policy := NewPolicy(
+				"test",
+				Config{MaxGasPrice: *ethereum.WrapWei(test.maxGasPrice)},
+				&localChain{gasPrice: test.gasPrice, err: test.gasPriceErr},
+			)
+
+			decision := policy.Decide(test.urgent)
+
+			if test.expectedDecision != decision {
+				t.Errorf(
+					"unexpected decision\nexpected: [%v]\nactual:   [%v]",
+					test.expectedDecision,
+					decision,
+				)
+			}
+		})
+	}
+}
+
+func TestDecision_String(t *testing.T) {
+	tests := map[string]struct {
+		decision       Decision
+		expectedString string
+	}{
+		"submit": {
+			decision:       Submit,
+			expectedString: "Submit",
+		},
+		"defer": {
+			decision:       Defer,
+			expectedString: "Defer",
+		},
+		"submit urgent": {
+			decision:       SubmitUrgent,
+			expectedString: "SubmitUrgent",
+		},
+		"unknown": {
+			decision:       Decision(7),
+			expectedString: "Unknown(7)",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			testutils.AssertStringsEqual(
+				t,
+				"decision",
+				test.expectedString,
+				test.decision.String(),
+			)
+		})
+	}
+}
+
+func TestPolicy_Nil(t *testing.T) {
+	var policy *Policy
+
+	now := time.Now()
+
+	testutils.AssertBoolsEqual(
+		t,
+		"urgency",
+		false,
+		policy.IsUrgent(now, now),
+	)
+	testutils.AssertBoolsEqual(
+		t,
+		"overdue",
+		false,
+		policy.IsOverdue(now.Add(-365*24*time.Hour), now),
+	)
+
+	if decision := policy.Decide(false); decision != Submit {
+		t.Errorf(
+			"unexpected decision\nexpected: [%v]\nactual:   [%v]",
+			Submit,
+			decision,
+		)
+	}
+}
+
+func TestPolicy_IsUrgent(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	policy := NewPolicy("test", Config{UrgencyWindow: time.Hour}, nil)
+
+	var tests = map[string]struct {
+		deadline       time.Time
+		expectedUrgent bool
+	}{
+		"no deadline": {
+			deadline:       time.Time{},
+			expectedUrgent: false,
+		},
+		"deadline beyond urgency window": {
+			deadline:       now.Add(time.Hour + time.Second),
+			expectedUrgent: false,
+		},
+		"deadline at urgency window": {
+			deadline:       now.Add(time.Hour),
+			expectedUrgent: true,
+		},
+		"deadline passed": {
+			deadline:       now.Add(-time.Minute),
+			expectedUrgent: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			testutils.AssertBoolsEqual(
+				t,
+				"urgency",
+				test.expectedUrgent,
+				policy.IsUrgent(test.deadline, now),
+			)
+		})
+	}
+}
+
+func TestPolicy_IsOverdue(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	// The default urgency window should be used.
+	policy := NewPolicy("test", Config{}, nil)
+
+	testutils.AssertBoolsEqual(
+		t,
+		"zero ready time",
+		false,
+		policy.IsOverdue(time.Time{}, now),
+	)
+	testutils.AssertBoolsEqual(
+		t,
+		"pending within urgency window",
+		false,
+		policy.IsOverdue(now.Add(-DefaultUrgencyWindow+time.Second), now),
+	)
+	testutils.AssertBoolsEqual(
+		t,
+		"pending for urgency window",
+		true,
+		policy.IsOverdue(now.Add(-DefaultUrgencyWindow), now),
+	)
+}
+
+func TestPolicy_MetricsSources(t *testing.T) {
+	chain := &localChain{gasPrice: big.NewInt(45000000000)}
+
+	policy := NewPolicy(
+		"test",
+		Config{MaxGasPrice: *ethereum.WrapWei(big.NewInt(30000000000))},
+		chain,
+	)
+
+	policy.Decide(false)
+	policy.Decide(true)
+	policy.Decide(true)
+
+	chain.gasPrice = big.NewInt(25000000000)
+	policy.Decide(false)
+
+	chain.err = fmt.Errorf("unavailable")
+	policy.Decide(false)
+
+	expectedMetrics := map[string]float64{
+		"test_submissions_submitted":        2,
+		"test_submissions_deferred":         1,
+		"test_submissions_submitted_urgent": 2,
+		"test_gas_price_failures":           1,
+		"test_gas_price_gwei":               25,
+		"test_max_gas_price_gwei":           30,
+	}
+
+	sources := policy.MetricsSources()
+
+	testutils.AssertIntsEqual(
+		t,
+		"metrics count",
+		len(expectedMetrics),
+		len(sources),
+	)
+
+	for name, expectedValue := range expectedMetrics {
+		source, ok := sources[name]
+		if !ok {
+			t.Errorf("missing metric [%v]", name)
+			continue
+		}
+
+		if value := source(); value != expectedValue {
+			t.Errorf(
+				"unexpected value of metric [%v]\nexpected: [%v]\nactual:   [%v]",
+				name,
+				expectedValue,
+				value,
+			)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+// Package offline implements a broadcast channel exchanging messages through
+// files instead of the peer-to-peer network. It is meant to complete
+// interactive protocols, like tECDSA signing, when the network is not
+// available. Messages sent by the local members are exported to a directory
+// and messages of remote members are imported from another directory. It is
+// the operator's responsibility to exchange the exported files with other
+// group members by some other means.
+package offline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfs/go-log"
+
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/net/internal"
+	"github.com/keep-network/keep-core/pkg/operator"
+)
+
+var logger = log.Logger("keep-net-offline")
+
+// DefaultImportInterval is the default interval at which the import
+// directory is scanned for new message files.
+const DefaultImportInterval = 5 * time.Second
+
+// messageFileExtension is the extension of exported message files. Files
+// with other extensions are ignored during the import.
+const messageFileExtension = ".json"
+
+// identifier is the transport identifier of the offline channel members.
+// It is the hexadecimal representation of the member's operator public key.
+type identifier string
+
+func (i identifier) String() string {
+	return string(i)
+}
+
+// envelope is the content of a message file. It holds the marshaled message
+// along with the data required to authenticate its sender.
+type envelope struct {
+	Channel         string `json:"channel"`
+	Type            string `json:"type"`
+	Seqno           uint64 `json:"seqno"`
+	SenderPublicKey []byte `json:"senderPublicKey"`
+	Payload         []byte `json:"payload"`
+	Signature       []byte `json:"signature,omitempty"`
+}
+
+// digest returns the bytes signed by the sender of the envelope.
+func (e *envelope) digest() ([]byte, error) {
+	unsigned := *e
+	unsigned.Signature = nil
+
+	bytes, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(bytes)
+	return digest[:], nil
+}
+
+// broadcastChannel is a net.BroadcastChannel implementation exchanging
+// messages through files. All received messages are kept for the lifetime of
+// the channel and replayed to every newly installed handler so handlers
+// installed after a message arrived still receive it.
+type broadcastChannel struct {
+	name      string
+	signing   chain.Signing
+	exportDir string
+	importDir string
+
+	counter uint64
+
+	unmarshalersMutex  sync.Mutex
+	unmarshalersByType map[string]func() net.TaggedUnmarshaler
+
+	filterMutex sync.Mutex
+	filter      net.BroadcastChannelFilter
+
+	messagesMutex sync.Mutex
+	messages      []net.Message
+	// seenMessages holds digests of all messages delivered so far and is
+	// used to filter out duplicates, e.g. own messages being imported back.
+	seenMessages map[string]bool
+	// newMessages is closed and replaced every time new messages are
+	// delivered in order to notify the handlers.
+	newMessages chan struct{}
+}
+
+// NewBroadcastChannel creates a broadcast channel with the given name,
+// exporting messages sent through it to the export directory and importing
+// messages of other members from the import directory. The import directory
+// is scanned with the given interval for the lifetime of the provided
+// context. Exported messages are signed with the operator key of the given
+// signing and signatures of imported messages are verified against their
+// senders' public keys. All members exchanging messages must use the same
+// channel name.
+func NewBroadcastChannel(
+	ctx context.Context,
+	name string,
+	signing chain.Signing,
+	exportDir string,
+	importDir string,
+	importInterval time.Duration,
+) (net.BroadcastChannel, error) {
+	for _, dir := range []string{exportDir, importDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf(
+				"cannot create directory [%v]: [%v]",
+				dir,
+				err,
+			)
+		}
+	}
+
+	channel := &broadcastChannel{
+		name:               name,
+		signing:            signing,
+		exportDir:          exportDir,
+		importDir:          importDir,
+		unmarshalersByType: make(map[string]func() net.TaggedUnmarshaler),
+		seenMessages:       make(map[string]bool),
+		newMessages:        make(chan struct{}),
+	}
+
+	go func() {
+		ticker := time.NewTicker(importInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				channel.importMessages()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return channel, nil
+}
+
+func (bc *broadcastChannel) Name() string {
+	return bc.name
+}
+
+// Send exports the given message to a file in the export directory and
+// delivers it to the local handlers. The retransmission strategy is ignored
+// as exported messages remain available until they are removed by the
+// operator.
+func (bc *broadcastChannel) Send(
+	ctx context.Context,
+	message net.TaggedMarshaler,
+	retransmissionStrategy ...net.RetransmissionStrategy,
+) error {
+	payload, err := message.Marshal()
+	if err != nil {
+		return fmt.Errorf("cannot marshal message: [%v]", err)
+	}
+
+	envelope := &envelope{
+		Channel:         bc.name,
+		Type:            message.Type(),
+		Seqno:           atomic.AddUint64(&bc.counter, 1),
+		SenderPublicKey: bc.signing.PublicKey(),
+		Payload:         payload,
+	}
+
+	digest, err := envelope.digest()
+	if err != nil {
+		return fmt.Errorf("cannot compute message digest: [%v]", err)
+	}
+
+	envelope.Signature, err = bc.signing.Sign(digest)
+	if err != nil {
+		return fmt.Errorf("cannot sign message: [%v]", err)
+	}
+
+	content, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("cannot marshal message envelope: [%v]", err)
+	}
+
+	path := filepath.Join(
+		bc.exportDir,
+		hex.EncodeToString(digest[:16])+messageFileExtension,
+	)
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("cannot export message: [%v]", err)
+	}
+
+	// Deliver the message locally as the local handlers may belong to
+	// other members controlled by this operator.
+	return bc.deliver(envelope, digest)
+}
+
+// importMessages reads all message files from the import directory and
+// delivers messages that have not been delivered yet. Files that cannot be
+// imported are skipped and reported in logs.
+func (bc *broadcastChannel) importMessages() {
+	entries, err := os.ReadDir(bc.importDir)
+	if err != nil {
+		logger.Errorf(
+			"cannot read import directory [%v]: [%v]",
+			bc.importDir,
+			err,
+		)
+		return
+	}
+
+	// Import files in a deterministic order to make the import process
+	// easier to follow in logs.
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), messageFileExtension) {
+			continue
+		}
+
+		path := filepath.Join(bc.importDir, entry.Name())
+
+		if err := bc.importMessage(path); err != nil {
+			logger.Warnf("cannot import message file [%v]: [%v]", path, err)
+		}
+	}
+}
+
+func (bc *broadcastChannel) importMessage(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read file: [%v]", err)
+	}
+
+	envelope := &envelope{}
+	if err := json.Unmarshal(content, envelope); err != nil {
+		return fmt.Errorf("cannot unmarshal message envelope: [%v]", err)
+	}
+
+	if envelope.Channel != bc.name {
+		// The file belongs to another channel; this is not an error.
+		return nil
+	}
+
+	digest, err := envelope.digest()
+	if err != nil {
+		return fmt.Errorf("cannot compute message digest: [%v]", err)
+	}
+
+	if bc.isSeen(digest) {
+		return nil
+	}
+
+	ok, err := bc.signing.VerifyWithPublicKey(
+		digest,
+		envelope.Signature,
+		envelope.SenderPublicKey,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot verify message signature: [%v]", err)
+	}
+	if !ok {
+		return fmt.Errorf("invalid message signature")
+	}
+
+	senderPublicKey, err := unmarshalOperatorPublicKey(envelope.SenderPublicKey)
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal sender public key: [%v]", err)
+	}
+
+	bc.filterMutex.Lock()
+	filter := bc.filter
+	bc.filterMutex.Unlock()
+
+	if filter != nil && !filter(senderPublicKey) {
+		return fmt.Errorf("message sender rejected by the channel filter")
+	}
+
+	return bc.deliver(envelope, digest)
+}
+
+// deliver unmarshals the message held by the given envelope and makes it
+// available to the handlers, unless a message with the same digest has
+// already been delivered.
+func (bc *broadcastChannel) deliver(envelope *envelope, digest []byte) error {
+	bc.unmarshalersMutex.Lock()
+	unmarshaler, found := bc.unmarshalersByType[envelope.Type]
+	bc.unmarshalersMutex.Unlock()
+
+	if !found {
+		return fmt.Errorf(
+			"could not find unmarshaler for type [%v]",
+			envelope.Type,
+		)
+	}
+
+	payload := unmarshaler()
+	if err := payload.Unmarshal(envelope.Payload); err != nil {
+		return fmt.Errorf("cannot unmarshal message payload: [%v]", err)
+	}
+
+	message := internal.BasicMessage(
+		identifier(hex.EncodeToString(envelope.SenderPublicKey)),
+		payload,
+		envelope.Type,
+		envelope.SenderPublicKey,
+		envelope.Seqno,
+	)
+
+	bc.messagesMutex.Lock()
+	defer bc.messagesMutex.Unlock()
+
+	key := hex.EncodeToString(digest)
+	if bc.seenMessages[key] {
+		return nil
+	}
+	bc.seenMessages[key] = true
+
+	bc.messages = append(bc.messages, message)
+
+	close(bc.newMessages)
+	bc.newMessages = make(chan struct{})
+
+	return nil
+}
+
+func (bc *broadcastChannel) isSeen(digest []byte) bool {
+	bc.messagesMutex.Lock()
+	defer bc.messagesMutex.Unlock()
+
+	return bc.seenMessages[hex.EncodeToString(digest)]
+}
+
+// messagesFrom returns messages delivered so far, starting from the given
+// index, along with a channel that is closed once new messages are
+// delivered.
+func (bc *broadcastChannel) messagesFrom(index int) (
+	[]net.Message,
+	<-chan struct{},
+) {
+	bc.messagesMutex.Lock()
+	defer bc.messagesMutex.Unlock()
+
+	messages := make([]net.Message, len(bc.messages)-index)
+	copy(messages, bc.messages[index:])
+
+	return messages, bc.newMessages
+}
+
+// Recv installs a message handler that receives all messages delivered so
+// far and all messages delivered for the lifetime of the provided context.
+func (bc *broadcastChannel) Recv(
+	ctx context.Context,
+	handler func(m net.Message),
+) {
+	go func() {
+		next := 0
+
+		for {
+			messages, newMessages := bc.messagesFrom(next)
+
+			for _, message := range messages {
+				if ctx.Err() != nil {
+					return
+				}
+
+				handler(message)
+			}
+
+			next += len(messages)
+
+			select {
+			case <-newMessages:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (bc *broadcastChannel) SetUnmarshaler(
+	unmarshaler func() net.TaggedUnmarshaler,
+) {
+	tpe := unmarshaler().Type()
+
+	bc.unmarshalersMutex.Lock()
+	defer bc.unmarshalersMutex.Unlock()
+
+	bc.unmarshalersByType[tpe] = unmarshaler
+}
+
+func (bc *broadcastChannel) SetFilter(filter net.BroadcastChannelFilter) error {
+	bc.filterMutex.Lock()
+	defer bc.filterMutex.Unlock()
+
+	bc.filter = filter
+
+	return nil
+}
+
+// unmarshalOperatorPublicKey converts the given 65-byte uncompressed
+// secp256k1 public key to the operator public key.
+func unmarshalOperatorPublicKey(bytes []byte) (*operator.PublicKey, error) {
+	if len(bytes) != 65 || bytes[0] != 4 {
+		return nil, fmt.Errorf("not an uncompressed public key")
+	}
+
+	return &operator.PublicKey{
+		Curve: operator.Secp256k1,
+		X:     new(big.Int).SetBytes(bytes[1:33]),
+		Y:     new(big.Int).SetBytes(bytes[33:]),
+	}, nil
+}
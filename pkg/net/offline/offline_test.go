@@ -0,0 +1,208 @@
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/chain/local_v1"
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/operator"
+)
+
+func TestBroadcastChannel_ExchangeMessages(t *testing.T) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	dir1 := filepath.Join(t.TempDir(), "1")
+	dir2 := filepath.Join(t.TempDir(), "2")
+
+	signing1 := newTestSigning(t)
+	channel1 := newTestChannel(ctx, t, "test", signing1, dir1, dir2)
+	channel2 := newTestChannel(ctx, t, "test", newTestSigning(t), dir2, dir1)
+
+	// Install the handler after the message is sent to make sure messages
+	// are replayed to handlers installed later.
+	if err := channel1.Send(ctx, &mockMessage{content: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan net.Message, 10)
+	channel2.Recv(ctx, func(message net.Message) {
+		received <- message
+	})
+
+	select {
+	case message := <-received:
+		testutils.AssertStringsEqual(
+			t,
+			"message content",
+			"hello",
+			message.Payload().(*mockMessage).content,
+		)
+		testutils.AssertBytesEqual(
+			t,
+			signing1.PublicKey(),
+			message.SenderPublicKey(),
+		)
+	case <-ctx.Done():
+		t.Fatal("message not received")
+	}
+
+	// The message is delivered to the local handlers of the sender as well.
+	localReceived := make(chan net.Message, 10)
+	channel1.Recv(ctx, func(message net.Message) {
+		localReceived <- message
+	})
+
+	select {
+	case <-localReceived:
+	case <-ctx.Done():
+		t.Fatal("message not received locally")
+	}
+
+	// Importing the same file again must not deliver the message twice.
+	time.Sleep(200 * time.Millisecond)
+
+	testutils.AssertIntsEqual(t, "received messages", 0, len(received))
+}
+
+func TestBroadcastChannel_RejectTamperedMessage(t *testing.T) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	dir1 := filepath.Join(t.TempDir(), "1")
+	dir2 := filepath.Join(t.TempDir(), "2")
+	dir3 := filepath.Join(t.TempDir(), "3")
+
+	channel1 := newTestChannel(ctx, t, "test", newTestSigning(t), dir1, dir3)
+	channel2 := newTestChannel(ctx, t, "test", newTestSigning(t), dir2, dir1)
+
+	if err := channel1.Send(ctx, &mockMessage{content: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutils.AssertIntsEqual(t, "exported files", 1, len(entries))
+
+	path := filepath.Join(dir1, entries[0].Name())
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := &envelope{}
+	if err := json.Unmarshal(content, tampered); err != nil {
+		t.Fatal(err)
+	}
+	tampered.Payload = []byte("bye")
+
+	content, err = json.Marshal(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan net.Message, 10)
+	channel2.Recv(ctx, func(message net.Message) {
+		received <- message
+	})
+
+	time.Sleep(200 * time.Millisecond)
+
+	testutils.AssertIntsEqual(t, "received messages", 0, len(received))
+}
+
+func TestBroadcastChannel_Filter(t *testing.T) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	dir1 := filepath.Join(t.TempDir(), "1")
+	dir2 := filepath.Join(t.TempDir(), "2")
+
+	channel1 := newTestChannel(ctx, t, "test", newTestSigning(t), dir1, dir2)
+	channel2 := newTestChannel(ctx, t, "test", newTestSigning(t), dir2, dir1)
+
+	err := channel2.SetFilter(func(*operator.PublicKey) bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := channel1.Send(ctx, &mockMessage{content: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan net.Message, 10)
+	channel2.Recv(ctx, func(message net.Message) {
+		received <- message
+	})
+
+	time.Sleep(200 * time.Millisecond)
+
+	testutils.AssertIntsEqual(t, "received messages", 0, len(received))
+}
+
+func newTestSigning(t *testing.T) chain.Signing {
+	operatorPrivateKey, _, err := operator.GenerateKeyPair(
+		local_v1.DefaultCurve,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return local_v1.NewSigner(operatorPrivateKey)
+}
+
+func newTestChannel(
+	ctx context.Context,
+	t *testing.T,
+	name string,
+	signing chain.Signing,
+	exportDir string,
+	importDir string,
+) net.BroadcastChannel {
+	channel, err := NewBroadcastChannel(
+		ctx,
+		name,
+		signing,
+		exportDir,
+		importDir,
+		20*time.Millisecond,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	channel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &mockMessage{}
+	})
+
+	return channel
+}
+
+type mockMessage struct {
+	content string
+}
+
+func (mm *mockMessage) Type() string {
+	return "offline/mock_message"
+}
+
+func (mm *mockMessage) Marshal() ([]byte, error) {
+	return []byte(mm.content), nil
+}
+
+func (mm *mockMessage) Unmarshal(bytes []byte) error {
+	mm.content = string(bytes)
+	return nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+)
+
+const (
+	// KeyStoreBackendFile denotes the key store backend keeping data in the
+	// keystore directory on the local disk.
+	KeyStoreBackendFile = "file"
+	// KeyStoreBackendRemote denotes the key store backend keeping data in
+	// a remote secrets store speaking the HTTP key-value protocol.
+	KeyStoreBackendRemote = "remote"
+)
+
+// KeyStoreBackend represents a place where the key store data are kept.
+// Data passed to the backend are already encrypted with the key store
+// encryption password so the backend never sees plain key material.
+type KeyStoreBackend interface {
+	// ProtectedHandle returns a handle to the key store data kept in the
+	// given key store directory.
+	ProtectedHandle(dir string) (persistence.ProtectedHandle, error)
+
+	// ArchiveHandle returns a handle to the data archived in the given key
+	// store directory.
+	ArchiveHandle(dir string) (persistence.BasicHandle, error)
+}
+
+// fileKeyStoreBackend is the key store backend keeping data on the local disk.
+type fileKeyStoreBackend struct {
+	keyStoreDir string
+}
+
+// NewFileKeyStoreBackend creates a key store backend keeping data in the
+// given directory on the local disk. The directory must exist.
+func NewFileKeyStoreBackend(keyStoreDir string) KeyStoreBackend {
+	return &fileKeyStoreBackend{keyStoreDir}
+}
+
+func (fksb *fileKeyStoreBackend) ProtectedHandle(dir string) (
+	persistence.ProtectedHandle,
+	error,
+) {
+	if err := persistence.EnsureDirectoryExists(fksb.keyStoreDir, dir); err != nil {
+		return nil, fmt.Errorf(
+			"cannot create storage directory [%s] in [%s]: [%w]",
+			dir,
+			fksb.keyStoreDir,
+			err,
+		)
+	}
+
+	path := path.Join(fksb.keyStoreDir, dir)
+
+	diskHandle, err := persistence.NewProtectedDiskHandle(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create [%s] disk handle: [%w]", path, err)
+	}
+
+	return diskHandle, nil
+}
+
+func (fksb *fileKeyStoreBackend) ArchiveHandle(dir string) (
+	persistence.BasicHandle,
+	error,
+) {
+	// Make sure the protected disk handle, including its archive directory,
+	// is initialized.
+	if _, err := fksb.ProtectedHandle(dir); err != nil {
+		return nil, err
+	}
+
+	path := path.Join(fksb.keyStoreDir, dir, keyStoreArchiveDirName)
+
+	diskHandle, err := persistence.NewBasicDiskHandle(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create [%s] disk handle: [%w]", path, err)
+	}
+
+	return diskHandle, nil
+}
@@ -0,0 +1,617 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+	"github.com/keep-network/keep-common/pkg/wrappers"
+)
+
+const (
+	// DefaultRemoteKeyStoreRequestTimeout is the default timeout of a single
+	// request to the remote key store.
+	DefaultRemoteKeyStoreRequestTimeout = 30 * time.Second
+	// DefaultRemoteKeyStoreRequestRetryTimeout is the default timeout of
+	// all retries of a request to the remote key store.
+	DefaultRemoteKeyStoreRequestRetryTimeout = 2 * time.Minute
+)
+
+const (
+	// remoteRetryBackoff is the initial backoff between retries of a failed
+	// request to the remote key store. It is doubled upon each retry.
+	remoteRetryBackoff = 1 * time.Second
+	// remoteRetryMaxBackoff is the maximum backoff between retries of a failed
+	// request to the remote key store.
+	remoteRetryMaxBackoff = 15 * time.Second
+)
+
+const (
+	// remoteCurrentDirName is the key prefix of the current data kept in the
+	// given key store directory.
+	remoteCurrentDirName = "current"
+	// remoteArchiveDirName is the key prefix of the archived data kept in the
+	// given key store directory.
+	remoteArchiveDirName = "archive"
+	// remoteSnapshotDirName is the key prefix of the snapshots kept in the
+	// given key store directory.
+	remoteSnapshotDirName = "snapshot"
+	// remoteHealthKey is the key of the health endpoint of the remote
+	// secrets store.
+	remoteHealthKey = "_health"
+)
+
+// RemoteKeyStoreConfig holds the configuration of the remote key store
+// backend.
+type RemoteKeyStoreConfig struct {
+	// URL is the base URL of the remote secrets store.
+	URL string
+	// Token is an optional token sent as a bearer token along with every
+	// request to the remote secrets store.
+	Token string
+	// RequestTimeout is the timeout of a single request to the remote
+	// secrets store.
+	RequestTimeout time.Duration
+	// RequestRetryTimeout is the timeout of all retries of a request to the
+	// remote secrets store. Requests failing at the transport level or with
+	// a 5xx or 429 status are retried with an exponential backoff.
+	RequestRetryTimeout time.Duration
+}
+
+// remoteKeyStoreBackend is the key store backend keeping data in a remote
+// secrets store. The secrets store is expected to speak a minimal HTTP
+// key-value protocol where keys are slash-separated paths relative to
+// the base URL:
+//
+//   - `PUT <url>/<key>` stores the request body under the key,
+//   - `GET <url>/<key>` returns the value stored under the key or
+//     `404 Not Found` if there is no such key,
+//   - `DELETE <url>/<key>` removes the key,
+//   - `GET <url>/<prefix>?list=true` returns a JSON array of all keys stored
+//     under the prefix, relative to the prefix, or `404 Not Found` if there
+//     are no such keys,
+//   - `GET <url>/_health` returns a 2xx status if the store is up and
+//     the base URL points to it.
+//
+// The given key store directory is laid out the same way as on the disk.
+// Current data are kept under `<dir>/current/<directory>/<name>`, archived
+// data under `<dir>/archive/<directory>/<name>` and snapshots under
+// `<dir>/snapshot/<directory>/<name>.<timestamp>`.
+type remoteKeyStoreBackend struct {
+	config     RemoteKeyStoreConfig
+	baseURL    *url.URL
+	httpClient *http.Client
+
+	retryBackoff    time.Duration
+	retryMaxBackoff time.Duration
+}
+
+// NewRemoteKeyStoreBackend creates a key store backend keeping data in
+// a remote secrets store speaking the HTTP key-value protocol.
+func NewRemoteKeyStoreBackend(
+	config RemoteKeyStoreConfig,
+) (KeyStoreBackend, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("remote key store URL is not set")
+	}
+
+	baseURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse remote key store URL: [%w]", err)
+	}
+
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, fmt.Errorf(
+			"unsupported remote key store URL scheme [%s]",
+			baseURL.Scheme,
+		)
+	}
+
+	if baseURL.Scheme == "http" && config.Token != "" {
+		logger.Warnf(
+			"remote key store token is sent over an unencrypted connection; " +
+				"consider using an https URL",
+		)
+	}
+
+	if config.RequestTimeout == 0 {
+		config.RequestTimeout = DefaultRemoteKeyStoreRequestTimeout
+	}
+
+	if config.RequestRetryTimeout == 0 {
+		config.RequestRetryTimeout = DefaultRemoteKeyStoreRequestRetryTimeout
+	}
+
+	return &remoteKeyStoreBackend{
+		config:          config,
+		baseURL:         baseURL,
+		httpClient:      &http.Client{},
+		retryBackoff:    remoteRetryBackoff,
+		retryMaxBackoff: remoteRetryMaxBackoff,
+	}, nil
+}
+
+func (rksb *remoteKeyStoreBackend) ProtectedHandle(dir string) (
+	persistence.ProtectedHandle,
+	error,
+) {
+	handle, err := rksb.newHandle(dir, remoteCurrentDirName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remoteProtectedHandle{handle}, nil
+}
+
+func (rksb *remoteKeyStoreBackend) ArchiveHandle(dir string) (
+	persistence.BasicHandle,
+	error,
+) {
+	return rksb.newHandle(dir, remoteArchiveDirName)
+}
+
+// newHandle creates a handle to the data kept under the given sub-directory
+// of the given key store directory. The health endpoint of the remote secrets
+// store is queried to make sure the store is reachable. A `404 Not Found` of
+// the listing alone does not prove it as a wrong URL or a misconfigured proxy
+// responds the same way. The client would then consider the key store empty.
+func (rksb *remoteKeyStoreBackend) newHandle(
+	dir string,
+	subDir string,
+) (*remoteHandle, error) {
+	if dir == "" || strings.Contains(dir, "/") {
+		return nil, fmt.Errorf("invalid key store directory [%s]", dir)
+	}
+
+	handle := &remoteHandle{
+		backend: rksb,
+		dir:     dir,
+		prefix:  path.Join(dir, subDir),
+	}
+
+	if err := rksb.checkHealth(); err != nil {
+		return nil, fmt.Errorf(
+			"remote key store is not reachable: [%w]",
+			err,
+		)
+	}
+
+	if _, err := rksb.list(handle.prefix); err != nil {
+		return nil, fmt.Errorf(
+			"cannot access remote key store directory [%s]: [%w]",
+			dir,
+			err,
+		)
+	}
+
+	return handle, nil
+}
+
+// checkHealth queries the health endpoint of the remote secrets store.
+// Any status other than 2xx is considered an error.
+func (rksb *remoteKeyStoreBackend) checkHealth() error {
+	status, _, err := rksb.request(http.MethodGet, remoteHealthKey, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if status < 200 || status > 299 {
+		return fmt.Errorf(
+			"unexpected status [%d] from the health endpoint",
+			status,
+		)
+	}
+
+	return nil
+}
+
+// get returns the value stored under the given key. The second return value
+// is false if there is no such key.
+func (rksb *remoteKeyStoreBackend) get(key string) ([]byte, bool, error) {
+	status, body, err := rksb.request(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch status {
+	case http.StatusOK:
+		return body, true, nil
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf(
+			"unexpected status [%d] while getting key [%s]",
+			status,
+			key,
+		)
+	}
+}
+
+// put stores the given value under the given key.
+func (rksb *remoteKeyStoreBackend) put(key string, value []byte) error {
+	status, _, err := rksb.request(http.MethodPut, key, nil, value)
+	if err != nil {
+		return err
+	}
+
+	if status < 200 || status > 299 {
+		return fmt.Errorf(
+			"unexpected status [%d] while putting key [%s]",
+			status,
+			key,
+		)
+	}
+
+	return nil
+}
+
+// delete removes the given key.
+func (rksb *remoteKeyStoreBackend) delete(key string) error {
+	status, _, err := rksb.request(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if status < 200 || status > 299 {
+		return fmt.Errorf(
+			"unexpected status [%d] while deleting key [%s]",
+			status,
+			key,
+		)
+	}
+
+	return nil
+}
+
+// list returns all keys stored under the given prefix, relative to the
+// prefix.
+func (rksb *remoteKeyStoreBackend) list(prefix string) ([]string, error) {
+	status, body, err := rksb.request(
+		http.MethodGet,
+		prefix,
+		url.Values{"list": []string{"true"}},
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case http.StatusOK:
+		var keys []string
+		if err := json.Unmarshal(body, &keys); err != nil {
+			return nil, fmt.Errorf(
+				"cannot unmarshal keys listed under prefix [%s]: [%w]",
+				prefix,
+				err,
+			)
+		}
+		return keys, nil
+	case http.StatusNotFound:
+		return []string{}, nil
+	default:
+		return nil, fmt.Errorf(
+			"unexpected status [%d] while listing prefix [%s]",
+			status,
+			prefix,
+		)
+	}
+}
+
+// request sends a request to the remote secrets store and returns the status
+// and the body of the response. Requests failing at the transport level or
+// with a 5xx or 429 status are retried with an exponential backoff until
+// the retry timeout is hit. In the latter case, the last status and body are
+// returned.
+func (rksb *remoteKeyStoreBackend) request(
+	method string,
+	key string,
+	query url.Values,
+	body []byte,
+) (int, []byte, error) {
+	// The path is escaped by the URL while converting it to a string.
+	requestURL := *rksb.baseURL
+	requestURL.Path = strings.TrimSuffix(requestURL.Path, "/") + "/" + key
+	requestURL.RawPath = ""
+	if query != nil {
+		requestURL.RawQuery = query.Encode()
+	}
+
+	var status int
+	var responseBody []byte
+
+	err := wrappers.DoWithRetry(
+		context.Background(),
+		rksb.retryBackoff,
+		rksb.retryMaxBackoff,
+		rksb.config.RequestRetryTimeout,
+		func(ctx context.Context) error {
+			var err error
+			status, responseBody, err = rksb.doRequest(
+				ctx,
+				method,
+				requestURL.String(),
+				body,
+			)
+			if err != nil {
+				return err
+			}
+
+			if status >= 500 || status == http.StatusTooManyRequests {
+				logger.Warnf(
+					"remote key store responded with status [%d] to [%s] "+
+						"request for key [%s]; retrying",
+					status,
+					method,
+					key,
+				)
+				return fmt.Errorf("unexpected status [%d]", status)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		if status != 0 {
+			// The store kept responding with a retryable status. Let the
+			// caller interpret it.
+			return status, responseBody, nil
+		}
+
+		return 0, nil, err
+	}
+
+	return status, responseBody, nil
+}
+
+// doRequest sends a single request to the remote secrets store and returns
+// the status and the body of the response.
+func (rksb *remoteKeyStoreBackend) doRequest(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	body []byte,
+) (int, []byte, error) {
+	ctx, cancelCtx := context.WithTimeout(ctx, rksb.config.RequestTimeout)
+	defer cancelCtx()
+
+	request, err := http.NewRequestWithContext(
+		ctx,
+		method,
+		requestURL,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return 0, nil, fmt.Errorf("cannot create request: [%w]", err)
+	}
+
+	if rksb.config.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+rksb.config.Token)
+	}
+
+	response, err := rksb.httpClient.Do(request)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request failed: [%w]", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("cannot read response body: [%w]", err)
+	}
+
+	return response.StatusCode, responseBody, nil
+}
+
+// remoteHandle is a handle to the data kept in the remote secrets store
+// under the given key prefix.
+type remoteHandle struct {
+	backend *remoteKeyStoreBackend
+	// dir is the key store directory the handle belongs to.
+	dir string
+	// prefix is the key prefix of all data accessible through the handle.
+	prefix string
+}
+
+func (rh *remoteHandle) Save(data []byte, directory string, name string) error {
+	return rh.backend.put(path.Join(rh.prefix, directory, name), data)
+}
+
+func (rh *remoteHandle) ReadAll() (
+	<-chan persistence.DataDescriptor,
+	<-chan error,
+) {
+	dataChannel := make(chan persistence.DataDescriptor)
+	errorChannel := make(chan error)
+
+	go func() {
+		defer close(dataChannel)
+		defer close(errorChannel)
+
+		keys, err := rh.backend.list(rh.prefix)
+		if err != nil {
+			errorChannel <- fmt.Errorf(
+				"could not list the prefix [%v]: [%v]",
+				rh.prefix,
+				err,
+			)
+			return
+		}
+
+		for _, key := range keys {
+			// The same as the disk persistence, the handle exposes only data
+			// kept in directories.
+			directory, name := path.Split(key)
+			directory = strings.TrimSuffix(directory, "/")
+			if directory == "" || strings.Contains(directory, "/") {
+				continue
+			}
+
+			fullKey := path.Join(rh.prefix, key)
+
+			dataChannel <- &remoteDataDescriptor{
+				name:      name,
+				directory: directory,
+				readFunc: func() ([]byte, error) {
+					value, ok, err := rh.backend.get(fullKey)
+					if err != nil {
+						return nil, err
+					}
+					if !ok {
+						return nil, fmt.Errorf("key [%s] not found", fullKey)
+					}
+					return value, nil
+				},
+			}
+		}
+	}()
+
+	return dataChannel, errorChannel
+}
+
+func (rh *remoteHandle) Delete(directory string, name string) error {
+	return rh.backend.delete(path.Join(rh.prefix, directory, name))
+}
+
+// remoteProtectedHandle is a handle to the current data kept in the given key
+// store directory of the remote secrets store. It does not allow removing
+// data. Archived data are moved under the archive prefix instead.
+type remoteProtectedHandle struct {
+	handle *remoteHandle
+}
+
+func (rph *remoteProtectedHandle) Save(
+	data []byte,
+	directory string,
+	name string,
+) error {
+	return rph.handle.Save(data, directory, name)
+}
+
+func (rph *remoteProtectedHandle) ReadAll() (
+	<-chan persistence.DataDescriptor,
+	<-chan error,
+) {
+	return rph.handle.ReadAll()
+}
+
+// Archive moves all data kept in the given directory under the archive prefix.
+// The secrets store offers no atomic move so each key is copied first and
+// the copy is read back and compared with the original. The original is
+// removed only once the copy is verified. An interrupted archiving may leave
+// the data in both places but never loses them. Archiving the same directory
+// again completes the operation.
+func (rph *remoteProtectedHandle) Archive(directory string) error {
+	backend := rph.handle.backend
+
+	currentPrefix := path.Join(rph.handle.prefix, directory)
+	archivePrefix := path.Join(
+		rph.handle.dir,
+		remoteArchiveDirName,
+		directory,
+	)
+
+	keys, err := backend.list(currentPrefix)
+	if err != nil {
+		return fmt.Errorf("cannot list directory [%s]: [%w]", directory, err)
+	}
+
+	for _, key := range keys {
+		currentKey := path.Join(currentPrefix, key)
+
+		value, ok, err := backend.get(currentKey)
+		if err != nil {
+			return fmt.Errorf("cannot get key [%s]: [%w]", currentKey, err)
+		}
+		if !ok {
+			continue
+		}
+
+		archiveKey := path.Join(archivePrefix, key)
+
+		err = backend.put(archiveKey, value)
+		if err != nil {
+			return fmt.Errorf("cannot archive key [%s]: [%w]", currentKey, err)
+		}
+
+		archivedValue, ok, err := backend.get(archiveKey)
+		if err != nil {
+			return fmt.Errorf(
+				"cannot verify archived key [%s]: [%w]",
+				archiveKey,
+				err,
+			)
+		}
+		if !ok || !bytes.Equal(value, archivedValue) {
+			return fmt.Errorf(
+				"archived key [%s] does not match key [%s]",
+				archiveKey,
+				currentKey,
+			)
+		}
+
+		err = backend.delete(currentKey)
+		if err != nil {
+			return fmt.Errorf("cannot remove key [%s]: [%w]", currentKey, err)
+		}
+	}
+
+	return nil
+}
+
+func (rph *remoteProtectedHandle) Snapshot(
+	data []byte,
+	directory string,
+	name string,
+) error {
+	key := path.Join(
+		rph.handle.dir,
+		remoteSnapshotDirName,
+		directory,
+		fmt.Sprintf("%s.%d", name, time.Now().UnixNano()),
+	)
+
+	backend := rph.handle.backend
+
+	// Very unlikely but better fail than overwrite an existing snapshot.
+	_, exists, err := backend.get(key)
+	if err != nil {
+		return fmt.Errorf("cannot check snapshot [%s]: [%w]", key, err)
+	}
+	if exists {
+		return fmt.Errorf(
+			"could not create unique snapshot; " +
+				"snapshot name collision has been detected",
+		)
+	}
+
+	return backend.put(key, data)
+}
+
+// remoteDataDescriptor is a persistence.DataDescriptor of the data kept in
+// the remote secrets store. The content is fetched lazily.
+type remoteDataDescriptor struct {
+	name      string
+	directory string
+	readFunc  func() ([]byte, error)
+}
+
+func (rdd *remoteDataDescriptor) Name() string {
+	return rdd.name
+}
+
+func (rdd *remoteDataDescriptor) Directory() string {
+	return rdd.directory
+}
+
+func (rdd *remoteDataDescriptor) Content() ([]byte, error) {
+	return rdd.readFunc()
+}
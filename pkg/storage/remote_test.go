@@ -0,0 +1,486 @@
+package storage
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+const testToken = "s3cr3t"
+
+func TestRemoteKeyStoreBackend_SaveAndReadAll(t *testing.T) {
+	store := newLocalSecretsStore(t, testToken)
+
+	handle := newTestRemoteProtectedHandle(t, store.url, testToken)
+
+	if err := handle.Save([]byte{0x01}, "wallet-1", "/membership_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := handle.Save([]byte{0x02}, "wallet-1", "/membership_2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := handle.Save([]byte{0x03}, "wallet-2", "/membership_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"stored keys",
+		"tbtc/current/wallet-1/membership_1,"+
+			"tbtc/current/wallet-1/membership_2,"+
+			"tbtc/current/wallet-2/membership_1",
+		strings.Join(store.keys(), ","),
+	)
+
+	expectedContents := map[string][]byte{
+		"wallet-1/membership_1": {0x01},
+		"wallet-1/membership_2": {0x02},
+		"wallet-2/membership_1": {0x03},
+	}
+
+	if !reflect.DeepEqual(expectedContents, readAllContents(t, handle)) {
+		t.Errorf(
+			"unexpected contents\nexpected: [%v]\nactual:   [%v]",
+			expectedContents,
+			readAllContents(t, handle),
+		)
+	}
+}
+
+func TestRemoteKeyStoreBackend_Archive(t *testing.T) {
+	store := newLocalSecretsStore(t, testToken)
+
+	backend, err := NewRemoteKeyStoreBackend(
+		RemoteKeyStoreConfig{URL: store.url, Token: testToken},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle, err := backend.ProtectedHandle("tbtc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := handle.Save([]byte{0x01}, "wallet-1", "membership_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := handle.Save([]byte{0x02}, "wallet-2", "membership_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := handle.Archive("wallet-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"stored keys",
+		"tbtc/archive/wallet-1/membership_1,"+
+			"tbtc/current/wallet-2/membership_1",
+		strings.Join(store.keys(), ","),
+	)
+
+	expectedCurrentContents := map[string][]byte{
+		"wallet-2/membership_1": {0x02},
+	}
+	if !reflect.DeepEqual(
+		expectedCurrentContents,
+		readAllContents(t, handle),
+	) {
+		t.Errorf("unexpected current contents")
+	}
+
+	archiveHandle, err := backend.ArchiveHandle("tbtc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedArchiveContents := map[string][]byte{
+		"wallet-1/membership_1": {0x01},
+	}
+	if !reflect.DeepEqual(
+		expectedArchiveContents,
+		readAllContents(t, archiveHandle),
+	) {
+		t.Errorf("unexpected archive contents")
+	}
+}
+
+func TestRemoteKeyStoreBackend_Archive_CopyNotVerified(t *testing.T) {
+	store := newLocalSecretsStore(t, testToken)
+
+	handle := newTestRemoteProtectedHandle(t, store.url, testToken)
+
+	if err := handle.Save([]byte{0x01}, "wallet-1", "membership_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The store acknowledges the copy but does not keep it.
+	store.setDroppedPutPrefix("v1/keep/tbtc/archive/")
+
+	err := handle.Archive("wallet-1")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"archived key [tbtc/archive/wallet-1/membership_1] does not "+
+			"match key [tbtc/current/wallet-1/membership_1]",
+		err.Error(),
+	)
+
+	testutils.AssertStringsEqual(
+		t,
+		"stored keys",
+		"tbtc/current/wallet-1/membership_1",
+		strings.Join(store.keys(), ","),
+	)
+}
+
+func TestRemoteKeyStoreBackend_RetryTransientFailures(t *testing.T) {
+	store := newLocalSecretsStore(t, testToken)
+
+	handle := newTestRemoteProtectedHandle(t, store.url, testToken)
+
+	store.failNextRequests(2)
+
+	if err := handle.Save([]byte{0x01}, "wallet-1", "membership_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"stored keys",
+		"tbtc/current/wallet-1/membership_1",
+		strings.Join(store.keys(), ","),
+	)
+}
+
+func TestRemoteKeyStoreBackend_RetryTimeout(t *testing.T) {
+	store := newLocalSecretsStore(t, testToken)
+
+	handle := newTestRemoteProtectedHandle(t, store.url, testToken)
+
+	store.failNextRequests(1000)
+
+	err := handle.Save([]byte{0x01}, "wallet-1", "membership_1")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"unexpected status [503] while putting key "+
+			"[tbtc/current/wallet-1/membership_1]",
+		err.Error(),
+	)
+}
+
+func TestRemoteKeyStoreBackend_Snapshot(t *testing.T) {
+	store := newLocalSecretsStore(t, testToken)
+
+	handle := newTestRemoteProtectedHandle(t, store.url, testToken)
+
+	if err := handle.Snapshot([]byte{0x01}, "wallet-1", "/membership_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := store.keys()
+
+	testutils.AssertIntsEqual(t, "stored keys count", 1, len(keys))
+	if !strings.HasPrefix(keys[0], "tbtc/snapshot/wallet-1/membership_1.") {
+		t.Errorf("unexpected snapshot key [%s]", keys[0])
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"current contents count",
+		0,
+		len(readAllContents(t, handle)),
+	)
+}
+
+func TestRemoteKeyStoreBackend_Unauthorized(t *testing.T) {
+	store := newLocalSecretsStore(t, testToken)
+
+	backend, err := NewRemoteKeyStoreBackend(
+		RemoteKeyStoreConfig{URL: store.url, Token: "wrong"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = backend.ProtectedHandle("tbtc")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"remote key store is not reachable: "+
+			"[unexpected status [401] from the health endpoint]",
+		err.Error(),
+	)
+}
+
+func TestRemoteKeyStoreBackend_WrongURL(t *testing.T) {
+	store := newLocalSecretsStore(t, testToken)
+
+	// The listing of a wrong URL responds with 404 just like the listing
+	// of an empty store. The health endpoint must tell the difference.
+	backend, err := NewRemoteKeyStoreBackend(
+		RemoteKeyStoreConfig{URL: store.url + "/wrong", Token: testToken},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = backend.ProtectedHandle("tbtc")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"remote key store is not reachable: "+
+			"[unexpected status [404] from the health endpoint]",
+		err.Error(),
+	)
+}
+
+func TestNewRemoteKeyStoreBackend_InvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		config      RemoteKeyStoreConfig
+		expectedErr string
+	}{
+		"missing URL": {
+			config:      RemoteKeyStoreConfig{},
+			expectedErr: "remote key store URL is not set",
+		},
+		"unsupported scheme": {
+			config:      RemoteKeyStoreConfig{URL: "ftp://secrets"},
+			expectedErr: "unsupported remote key store URL scheme [ftp]",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := NewRemoteKeyStoreBackend(test.config)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"error",
+				test.expectedErr,
+				err.Error(),
+			)
+		})
+	}
+}
+
+func newTestRemoteProtectedHandle(
+	t *testing.T,
+	url string,
+	token string,
+) persistence.ProtectedHandle {
+	backend, err := NewRemoteKeyStoreBackend(
+		RemoteKeyStoreConfig{
+			URL:                 url,
+			Token:               token,
+			RequestRetryTimeout: 500 * time.Millisecond,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Do not slow down tests retrying failed requests.
+	backend.(*remoteKeyStoreBackend).retryBackoff = 10 * time.Millisecond
+	backend.(*remoteKeyStoreBackend).retryMaxBackoff = 50 * time.Millisecond
+
+	handle, err := backend.ProtectedHandle("tbtc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handle
+}
+
+// readAllContents reads all data available through the given handle and
+// returns them keyed by `<directory>/<name>`.
+func readAllContents(
+	t *testing.T,
+	handle persistence.RWHandle,
+) map[string][]byte {
+	contents := make(map[string][]byte)
+
+	descriptorsChan, errorsChan := handle.ReadAll()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for descriptor := range descriptorsChan {
+			content, err := descriptor.Content()
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			contents[descriptor.Directory()+"/"+descriptor.Name()] = content
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for err := range errorsChan {
+			t.Error(err)
+		}
+	}()
+
+	wg.Wait()
+
+	return contents
+}
+
+// localSecretsStore is a local stand-in of a remote secrets store speaking
+// the HTTP key-value protocol expected by the remote key store backend.
+type localSecretsStore struct {
+	url string
+
+	mutex  sync.Mutex
+	values map[string][]byte
+
+	// failingRequests is the number of the next requests that fail with
+	// a 503 status.
+	failingRequests int
+	// droppedPutPrefix is the key prefix of values that are acknowledged
+	// but not stored, if set.
+	droppedPutPrefix string
+}
+
+func newLocalSecretsStore(t *testing.T, token string) *localSecretsStore {
+	store := &localSecretsStore{
+		values: make(map[string][]byte),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			if request.Header.Get("Authorization") != "Bearer "+token {
+				writer.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			store.mutex.Lock()
+			defer store.mutex.Unlock()
+
+			if store.failingRequests > 0 {
+				store.failingRequests--
+				writer.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			key := strings.TrimPrefix(request.URL.Path, "/")
+
+			switch {
+			case request.Method == http.MethodGet &&
+				key == "v1/keep/_health":
+				writer.WriteHeader(http.StatusOK)
+			case request.Method == http.MethodGet &&
+				request.URL.Query().Get("list") == "true":
+				prefix := key + "/"
+				keys := make([]string, 0)
+				for storedKey := range store.values {
+					if strings.HasPrefix(storedKey, prefix) {
+						keys = append(keys, strings.TrimPrefix(storedKey, prefix))
+					}
+				}
+				if len(keys) == 0 {
+					writer.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_ = json.NewEncoder(writer).Encode(keys)
+			case request.Method == http.MethodGet:
+				value, ok := store.values[key]
+				if !ok {
+					writer.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = writer.Write(value)
+			case request.Method == http.MethodPut:
+				value, err := io.ReadAll(request.Body)
+				if err != nil {
+					writer.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if store.droppedPutPrefix == "" ||
+					!strings.HasPrefix(key, store.droppedPutPrefix) {
+					store.values[key] = value
+				}
+				writer.WriteHeader(http.StatusNoContent)
+			case request.Method == http.MethodDelete:
+				delete(store.values, key)
+				writer.WriteHeader(http.StatusNoContent)
+			default:
+				writer.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		},
+	))
+	t.Cleanup(server.Close)
+
+	store.url = server.URL + "/v1/keep"
+
+	return store
+}
+
+// keys returns all keys held by the store, relative to the store URL,
+// in the alphabetical order.
+func (lss *localSecretsStore) keys() []string {
+	lss.mutex.Lock()
+	defer lss.mutex.Unlock()
+
+	keys := make([]string, 0, len(lss.values))
+	for key := range lss.values {
+		keys = append(keys, strings.TrimPrefix(key, "v1/keep/"))
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// failNextRequests makes the given number of the next requests fail with
+// a 503 status.
+func (lss *localSecretsStore) failNextRequests(count int) {
+	lss.mutex.Lock()
+	defer lss.mutex.Unlock()
+
+	lss.failingRequests = count
+}
+
+// setDroppedPutPrefix makes the store acknowledge values stored under the
+// given key prefix without keeping them.
+func (lss *localSecretsStore) setDroppedPutPrefix(prefix string) {
+	lss.mutex.Lock()
+	defer lss.mutex.Unlock()
+
+	lss.droppedPutPrefix = prefix
+}
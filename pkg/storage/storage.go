@@ -5,13 +5,23 @@ import (
 	"path"
 	"path/filepath"
 
+	"github.com/ipfs/go-log"
+
 	"github.com/keep-network/keep-common/pkg/persistence"
 )
 
+var logger = log.Logger("keep-storage")
+
 // Config stores meta-info about keeping data on disk
 type Config struct {
 	// Path to the persistent storage directory on disk.
 	Dir string
+	// KeyStoreBackend determines where the key store data are kept. Supported
+	// values are `file` (default) and `remote`.
+	KeyStoreBackend string
+	// RemoteKeyStore is the configuration of the remote key store backend.
+	// Used only if KeyStoreBackend is `remote`.
+	RemoteKeyStore RemoteKeyStoreConfig
 }
 
 const (
@@ -25,33 +35,60 @@ const (
 	// lead to losing rewards as a result of inactivity but is not
 	// a protocol violation.
 	workDirName = "work"
+
+	// The directory the keystore persistence moves archived data to. It is
+	// managed by the underlying protected disk persistence.
+	keyStoreArchiveDirName = "archive"
 )
 
-// Storage is a disk persistent storage for the client.
+// Storage is a disk persistent storage for the client. The key store data
+// are kept in the configured key store backend.
 type Storage struct {
-	keystoreDir        string
+	keyStoreBackend    KeyStoreBackend
 	workDir            string
 	encryptionPassword string
 }
 
-// Initialize initializes a disk storage with `keystore` and `work` directories.
-// The provided `encryptionPassword` will be used to encrypt the work persisted
-// to the storage.
+// Initialize initializes a disk storage with `work` directory and the
+// configured key store backend. For the `file` backend, the `keystore`
+// directory is created. The provided `encryptionPassword` will be used to
+// encrypt the work and key material persisted to the storage.
 func Initialize(config Config, encryptionPassword string) (Storage, error) {
 	storage := Storage{}
 
 	storageRootDir := filepath.Clean(config.Dir)
 
-	if err := persistence.EnsureDirectoryExists(
-		storageRootDir,
-		keyStoreDirName,
-	); err != nil {
+	switch config.KeyStoreBackend {
+	case "", KeyStoreBackendFile:
+		if err := persistence.EnsureDirectoryExists(
+			storageRootDir,
+			keyStoreDirName,
+		); err != nil {
+			return storage, fmt.Errorf(
+				"cannot create storage directory for keystore: [%w]",
+				err,
+			)
+		}
+
+		storage.keyStoreBackend = NewFileKeyStoreBackend(
+			filepath.Join(storageRootDir, keyStoreDirName),
+		)
+	case KeyStoreBackendRemote:
+		keyStoreBackend, err := NewRemoteKeyStoreBackend(config.RemoteKeyStore)
+		if err != nil {
+			return storage, fmt.Errorf(
+				"cannot create remote key store backend: [%w]",
+				err,
+			)
+		}
+
+		storage.keyStoreBackend = keyStoreBackend
+	default:
 		return storage, fmt.Errorf(
-			"cannot create storage directory for keystore: [%w]",
-			err,
+			"unsupported key store backend [%s]",
+			config.KeyStoreBackend,
 		)
 	}
-	storage.keystoreDir = filepath.Join(storageRootDir, keyStoreDirName)
 
 	if err := persistence.EnsureDirectoryExists(
 		storageRootDir,
@@ -69,50 +106,49 @@ func Initialize(config Config, encryptionPassword string) (Storage, error) {
 	return storage, nil
 }
 
-// InitializeKeyStorePersistence initializes a disk persistence under keystore parent.
+// InitializeKeyStorePersistence initializes a persistence under keystore
+// parent, kept in the configured key store backend.
 func (s *Storage) InitializeKeyStorePersistence(dir string) (
 	persistence.ProtectedHandle,
 	error,
 ) {
-	return s.initializeKeyStorePersistence(s.keystoreDir, dir)
-}
+	handle, err := s.keyStoreBackend.ProtectedHandle(dir)
+	if err != nil {
+		return nil, err
+	}
 
-// InitializeWorkPersistence initializes a disk persistence under work parent.
-func (s *Storage) InitializeWorkPersistence(dir string) (
-	persistence.BasicHandle,
-	error,
-) {
-	return s.initializeWorkPersistence(s.workDir, dir)
+	return persistence.NewEncryptedProtectedPersistence(
+		handle,
+		s.encryptionPassword,
+	), nil
 }
 
-// initializeKeyStorePersistence creates a persistent directory under a parent directory.
-// It returns an error is the parent directory doesn't exist.
-func (s *Storage) initializeKeyStorePersistence(parentDir string, dir string) (
-	persistence.ProtectedHandle,
+// InitializeKeyStoreArchivePersistence initializes a persistence giving
+// access to the data archived in the given keystore persistence directory.
+// The returned handle is meant to be used only to inspect the archived data.
+func (s *Storage) InitializeKeyStoreArchivePersistence(dir string) (
+	persistence.RWHandle,
 	error,
 ) {
-	if err := persistence.EnsureDirectoryExists(parentDir, dir); err != nil {
-		return nil, fmt.Errorf(
-			"cannot create storage directory [%s] in [%s]: [%w]",
-			dir,
-			parentDir,
-			err,
-		)
-	}
-
-	path := path.Join(parentDir, dir)
-
-	diskHandle, err := persistence.NewProtectedDiskHandle(path)
+	handle, err := s.keyStoreBackend.ArchiveHandle(dir)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create [%s] disk handle: [%w]", path, err)
+		return nil, err
 	}
 
-	return persistence.NewEncryptedProtectedPersistence(
-		diskHandle,
+	return persistence.NewEncryptedBasicPersistence(
+		handle,
 		s.encryptionPassword,
 	), nil
 }
 
+// InitializeWorkPersistence initializes a disk persistence under work parent.
+func (s *Storage) InitializeWorkPersistence(dir string) (
+	persistence.BasicHandle,
+	error,
+) {
+	return s.initializeWorkPersistence(s.workDir, dir)
+}
+
 // initializeWorkPersistence creates a persistent directory under a parent directory.
 // It returns an error is the parent directory doesn't exist.
 func (s *Storage) initializeWorkPersistence(parentDir string, dir string) (
@@ -14,12 +14,21 @@ type localBitcoinChain struct {
 
 	mempoolMutex sync.Mutex
 	mempool      []*bitcoin.Transaction
+
+	blockHeadersMutex sync.Mutex
+	blockHeaders      map[uint]*bitcoin.BlockHeader
+
+	satPerVByteFeeEstimationMutex sync.Mutex
+	satPerVByteFeeEstimation      map[uint32]int64
 }
 
 func newLocalBitcoinChain() *localBitcoinChain {
 	return &localBitcoinChain{
 		transactions: make([]*bitcoin.Transaction, 0),
 		mempool:      make([]*bitcoin.Transaction, 0),
+		blockHeaders: make(map[uint]*bitcoin.BlockHeader),
+
+		satPerVByteFeeEstimation: make(map[uint32]int64),
 	}
 }
 
@@ -48,6 +57,15 @@ func (lbc *localBitcoinChain) GetTransactionConfirmations(
 		}
 	}
 
+	lbc.mempoolMutex.Lock()
+	defer lbc.mempoolMutex.Unlock()
+
+	for _, transaction := range lbc.mempool {
+		if transaction.Hash() == transactionHash {
+			return 0, nil
+		}
+	}
+
 	return 0, fmt.Errorf("transaction not found")
 }
 
@@ -71,13 +89,35 @@ func (lbc *localBitcoinChain) BroadcastTransaction(
 }
 
 func (lbc *localBitcoinChain) GetLatestBlockHeight() (uint, error) {
-	panic("not implemented")
+	lbc.blockHeadersMutex.Lock()
+	defer lbc.blockHeadersMutex.Unlock()
+
+	latestBlockHeight := uint(0)
+	for blockHeight := range lbc.blockHeaders {
+		if blockHeight > latestBlockHeight {
+			latestBlockHeight = blockHeight
+		}
+	}
+
+	if latestBlockHeight == 0 {
+		return 0, fmt.Errorf("block headers not found")
+	}
+
+	return latestBlockHeight, nil
 }
 
 func (lbc *localBitcoinChain) GetBlockHeader(
 	blockNumber uint,
 ) (*bitcoin.BlockHeader, error) {
-	panic("not implemented")
+	lbc.blockHeadersMutex.Lock()
+	defer lbc.blockHeadersMutex.Unlock()
+
+	blockHeader, ok := lbc.blockHeaders[blockNumber]
+	if !ok {
+		return nil, fmt.Errorf("block header not found")
+	}
+
+	return blockHeader, nil
 }
 
 func (lbc *localBitcoinChain) GetTransactionMerkleProof(
@@ -230,7 +270,20 @@ func (lbc *localBitcoinChain) GetMempoolUtxosForPublicKeyHash(
 func (lbc *localBitcoinChain) EstimateSatPerVByteFee(
 	blocks uint32,
 ) (int64, error) {
-	panic("unsupported")
+	lbc.satPerVByteFeeEstimationMutex.Lock()
+	defer lbc.satPerVByteFeeEstimationMutex.Unlock()
+
+	return lbc.satPerVByteFeeEstimation[blocks], nil
+}
+
+func (lbc *localBitcoinChain) setEstimateSatPerVByteFee(
+	blocks uint32,
+	fee int64,
+) {
+	lbc.satPerVByteFeeEstimationMutex.Lock()
+	defer lbc.satPerVByteFeeEstimationMutex.Unlock()
+
+	lbc.satPerVByteFeeEstimation[blocks] = fee
 }
 
 func (lbc *localBitcoinChain) GetCoinbaseTxHash(blockHeight uint) (
@@ -276,6 +276,14 @@ type BridgeChain interface {
 		filter *DepositRevealedEventFilter,
 	) ([]*DepositRevealedEvent, error)
 
+	// PastRedemptionRequestedEvents fetches past redemption requested events according
+	// to the provided filter or unfiltered if the filter is nil. Returned
+	// events are sorted by the block number in the ascending order, i.e. the
+	// latest event is at the end of the slice.
+	PastRedemptionRequestedEvents(
+		filter *RedemptionRequestedEventFilter,
+	) ([]*RedemptionRequestedEvent, error)
+
 	// GetPendingRedemptionRequest gets the on-chain pending redemption request
 	// for the given wallet public key hash and redeemer output script.
 	// The returned bool value indicates whether the request was found or not.
@@ -300,6 +308,42 @@ type BridgeChain interface {
 		movingFundsTxOutpointIndex uint32,
 	) (*MovedFundsSweepRequest, bool, error)
 
+	// GetDepositParameters gets the current value of parameters relevant
+	// for the depositing process.
+	GetDepositParameters() (
+		dustThreshold uint64,
+		treasuryFeeDivisor uint64,
+		txMaxFee uint64,
+		revealAheadPeriod uint32,
+		err error,
+	)
+
+	// GetRedemptionParameters gets the current value of parameters relevant
+	// for the redemption process.
+	GetRedemptionParameters() (
+		dustThreshold uint64,
+		treasuryFeeDivisor uint64,
+		txMaxFee uint64,
+		txMaxTotalFee uint64,
+		timeout uint32,
+		timeoutSlashingAmount *big.Int,
+		timeoutNotifierRewardMultiplier uint32,
+		err error,
+	)
+
+	// GetWalletParameters gets the current value of parameters relevant to
+	// wallet.
+	GetWalletParameters() (
+		creationPeriod uint32,
+		creationMinBtcBalance uint64,
+		creationMaxBtcBalance uint64,
+		closureMinBtcBalance uint64,
+		maxAge uint32,
+		maxBtcTransfer uint64,
+		closingPeriod uint32,
+		err error,
+	)
+
 	// GetMovingFundsParameters gets the current value of parameters relevant
 	// for the moving funds process.
 	GetMovingFundsParameters() (
@@ -474,6 +518,26 @@ type WalletProposalValidatorChain interface {
 	) error
 }
 
+// Clock is an optional interface of chain handles reading the chain state as
+// of a fixed moment in the past, e.g. when coordination proposals are
+// simulated for past coordination windows. Time-dependent checks use the time
+// returned by the clock instead of the current time.
+type Clock interface {
+	// CurrentTime returns the time as of which the chain state is read.
+	CurrentTime() time.Time
+}
+
+// CurrentTime returns the current time as seen by the given chain handle.
+// That is the time returned by the handle if it implements Clock or the
+// current wall-clock time otherwise.
+func CurrentTime(chain interface{}) time.Time {
+	if clock, ok := chain.(Clock); ok {
+		return clock.CurrentTime()
+	}
+
+	return time.Now()
+}
+
 // RedemptionRequestedEvent represents a redemption requested event.
 type RedemptionRequestedEvent struct {
 	WalletPublicKeyHash  [20]byte
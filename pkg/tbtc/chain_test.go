@@ -46,6 +46,33 @@ type movingFundsParameters = struct {
 	sweepTimeoutNotifierRewardMultiplier uint32
 }
 
+type depositParameters = struct {
+	dustThreshold      uint64
+	treasuryFeeDivisor uint64
+	txMaxFee           uint64
+	revealAheadPeriod  uint32
+}
+
+type walletParameters = struct {
+	creationPeriod        uint32
+	creationMinBtcBalance uint64
+	creationMaxBtcBalance uint64
+	closureMinBtcBalance  uint64
+	maxAge                uint32
+	maxBtcTransfer        uint64
+	closingPeriod         uint32
+}
+
+type redemptionParameters = struct {
+	dustThreshold                   uint64
+	treasuryFeeDivisor              uint64
+	txMaxFee                        uint64
+	txMaxTotalFee                   uint64
+	timeout                         uint32
+	timeoutSlashingAmount           *big.Int
+	timeoutNotifierRewardMultiplier uint32
+}
+
 type localChain struct {
 	dkgResultSubmissionHandlersMutex sync.Mutex
 	dkgResultSubmissionHandlers      map[int]func(submission *DKGResultSubmittedEvent)
@@ -84,6 +111,9 @@ type localChain struct {
 	pastMovingFundsCommitmentSubmittedEventsMutex sync.Mutex
 	pastMovingFundsCommitmentSubmittedEvents      map[[32]byte][]*MovingFundsCommitmentSubmittedEvent
 
+	pastRedemptionRequestedEventsMutex sync.Mutex
+	pastRedemptionRequestedEvents      map[[32]byte][]*RedemptionRequestedEvent
+
 	depositSweepProposalValidationsMutex sync.Mutex
 	depositSweepProposalValidations      map[[32]byte]bool
 
@@ -111,6 +141,15 @@ type localChain struct {
 	movingFundsParametersMutex sync.Mutex
 	movingFundsParameters      movingFundsParameters
 
+	depositParametersMutex sync.Mutex
+	depositParameters      depositParameters
+
+	redemptionParametersMutex sync.Mutex
+	redemptionParameters      redemptionParameters
+
+	walletParametersMutex sync.Mutex
+	walletParameters      walletParameters
+
 	eligibleStakesMutex sync.Mutex
 	eligibleStakes      map[chain.Address]*big.Int
 
@@ -149,7 +188,6 @@ func (lc *localChain) GetBlockNumberByTimestamp(timestamp uint64) (
 	return block, nil
 }
 
-//lint:ignore U1000 This function can be useful for future.
 func (lc *localChain) setBlockNumberByTimestamp(timestamp uint64, block uint64) {
 	lc.blocksByTimestampMutex.Lock()
 	defer lc.blocksByTimestampMutex.Unlock()
@@ -764,6 +802,73 @@ func buildPastDepositRevealedEventsKey(
 	return sha256.Sum256(buffer.Bytes()), nil
 }
 
+func (lc *localChain) PastRedemptionRequestedEvents(
+	filter *RedemptionRequestedEventFilter,
+) ([]*RedemptionRequestedEvent, error) {
+	lc.pastRedemptionRequestedEventsMutex.Lock()
+	defer lc.pastRedemptionRequestedEventsMutex.Unlock()
+
+	eventsKey, err := buildPastRedemptionRequestedEventsKey(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	events, ok := lc.pastRedemptionRequestedEvents[eventsKey]
+	if !ok {
+		return nil, fmt.Errorf("no events for given filter")
+	}
+
+	return events, nil
+}
+
+func (lc *localChain) setPastRedemptionRequestedEvents(
+	filter *RedemptionRequestedEventFilter,
+	events []*RedemptionRequestedEvent,
+) error {
+	lc.pastRedemptionRequestedEventsMutex.Lock()
+	defer lc.pastRedemptionRequestedEventsMutex.Unlock()
+
+	eventsKey, err := buildPastRedemptionRequestedEventsKey(filter)
+	if err != nil {
+		return err
+	}
+
+	lc.pastRedemptionRequestedEvents[eventsKey] = events
+
+	return nil
+}
+
+func buildPastRedemptionRequestedEventsKey(
+	filter *RedemptionRequestedEventFilter,
+) ([32]byte, error) {
+	var buffer bytes.Buffer
+
+	startBlock := make([]byte, 8)
+	binary.BigEndian.PutUint64(startBlock, filter.StartBlock)
+	buffer.Write(startBlock)
+
+	if filter.EndBlock != nil {
+		endBlock := make([]byte, 8)
+		binary.BigEndian.PutUint64(endBlock, *filter.EndBlock)
+		buffer.Write(endBlock)
+	}
+
+	for _, walletPublicKeyHash := range filter.WalletPublicKeyHash {
+		buffer.Write(walletPublicKeyHash[:])
+	}
+
+	for _, redeemer := range filter.Redeemer {
+		redeemerBytes, err := hex.DecodeString(redeemer.String())
+		if err != nil {
+			return [32]byte{}, err
+		}
+
+		buffer.Write(redeemerBytes)
+	}
+
+	return sha256.Sum256(buffer.Bytes()), nil
+}
+
 func (lc *localChain) GetPendingRedemptionRequest(
 	walletPublicKeyHash [20]byte,
 	redeemerOutputScript bitcoin.Script,
@@ -978,7 +1083,40 @@ func (lc *localChain) GetWalletParameters() (
 	closingPeriod uint32,
 	err error,
 ) {
-	panic("unsupported")
+	lc.walletParametersMutex.Lock()
+	defer lc.walletParametersMutex.Unlock()
+
+	return lc.walletParameters.creationPeriod,
+		lc.walletParameters.creationMinBtcBalance,
+		lc.walletParameters.creationMaxBtcBalance,
+		lc.walletParameters.closureMinBtcBalance,
+		lc.walletParameters.maxAge,
+		lc.walletParameters.maxBtcTransfer,
+		lc.walletParameters.closingPeriod,
+		nil
+}
+
+func (lc *localChain) setWalletParameters(
+	creationPeriod uint32,
+	creationMinBtcBalance uint64,
+	creationMaxBtcBalance uint64,
+	closureMinBtcBalance uint64,
+	maxAge uint32,
+	maxBtcTransfer uint64,
+	closingPeriod uint32,
+) {
+	lc.walletParametersMutex.Lock()
+	defer lc.walletParametersMutex.Unlock()
+
+	lc.walletParameters = walletParameters{
+		creationPeriod:        creationPeriod,
+		creationMinBtcBalance: creationMinBtcBalance,
+		creationMaxBtcBalance: creationMaxBtcBalance,
+		closureMinBtcBalance:  closureMinBtcBalance,
+		maxAge:                maxAge,
+		maxBtcTransfer:        maxBtcTransfer,
+		closingPeriod:         closingPeriod,
+	}
 }
 
 func (lc *localChain) ValidateDepositSweepProposal(
@@ -1369,6 +1507,86 @@ func (lc *localChain) SetMovingFundsParameters(
 	}
 }
 
+func (lc *localChain) GetDepositParameters() (
+	dustThreshold uint64,
+	treasuryFeeDivisor uint64,
+	txMaxFee uint64,
+	revealAheadPeriod uint32,
+	err error,
+) {
+	lc.depositParametersMutex.Lock()
+	defer lc.depositParametersMutex.Unlock()
+
+	return lc.depositParameters.dustThreshold,
+		lc.depositParameters.treasuryFeeDivisor,
+		lc.depositParameters.txMaxFee,
+		lc.depositParameters.revealAheadPeriod,
+		nil
+}
+
+func (lc *localChain) SetDepositParameters(
+	dustThreshold uint64,
+	treasuryFeeDivisor uint64,
+	txMaxFee uint64,
+	revealAheadPeriod uint32,
+) {
+	lc.depositParametersMutex.Lock()
+	defer lc.depositParametersMutex.Unlock()
+
+	lc.depositParameters = depositParameters{
+		dustThreshold:      dustThreshold,
+		treasuryFeeDivisor: treasuryFeeDivisor,
+		txMaxFee:           txMaxFee,
+		revealAheadPeriod:  revealAheadPeriod,
+	}
+}
+
+func (lc *localChain) GetRedemptionParameters() (
+	dustThreshold uint64,
+	treasuryFeeDivisor uint64,
+	txMaxFee uint64,
+	txMaxTotalFee uint64,
+	timeout uint32,
+	timeoutSlashingAmount *big.Int,
+	timeoutNotifierRewardMultiplier uint32,
+	err error,
+) {
+	lc.redemptionParametersMutex.Lock()
+	defer lc.redemptionParametersMutex.Unlock()
+
+	return lc.redemptionParameters.dustThreshold,
+		lc.redemptionParameters.treasuryFeeDivisor,
+		lc.redemptionParameters.txMaxFee,
+		lc.redemptionParameters.txMaxTotalFee,
+		lc.redemptionParameters.timeout,
+		lc.redemptionParameters.timeoutSlashingAmount,
+		lc.redemptionParameters.timeoutNotifierRewardMultiplier,
+		nil
+}
+
+func (lc *localChain) SetRedemptionParameters(
+	dustThreshold uint64,
+	treasuryFeeDivisor uint64,
+	txMaxFee uint64,
+	txMaxTotalFee uint64,
+	timeout uint32,
+	timeoutSlashingAmount *big.Int,
+	timeoutNotifierRewardMultiplier uint32,
+) {
+	lc.redemptionParametersMutex.Lock()
+	defer lc.redemptionParametersMutex.Unlock()
+
+	lc.redemptionParameters = redemptionParameters{
+		dustThreshold:                   dustThreshold,
+		treasuryFeeDivisor:              treasuryFeeDivisor,
+		txMaxFee:                        txMaxFee,
+		txMaxTotalFee:                   txMaxTotalFee,
+		timeout:                         timeout,
+		timeoutSlashingAmount:           timeoutSlashingAmount,
+		timeoutNotifierRewardMultiplier: timeoutNotifierRewardMultiplier,
+	}
+}
+
 func (lc *localChain) PastMovingFundsCommitmentSubmittedEvents(
 	filter *MovingFundsCommitmentSubmittedEventFilter,
 ) ([]*MovingFundsCommitmentSubmittedEvent, error) {
@@ -1468,6 +1686,7 @@ func ConnectWithKey(
 		blocksHashesByNumber:                     make(map[uint64][32]byte),
 		pastDepositRevealedEvents:                make(map[[32]byte][]*DepositRevealedEvent),
 		pastMovingFundsCommitmentSubmittedEvents: make(map[[32]byte][]*MovingFundsCommitmentSubmittedEvent),
+		pastRedemptionRequestedEvents:            make(map[[32]byte][]*RedemptionRequestedEvent),
 		depositSweepProposalValidations:          make(map[[32]byte]bool),
 		pendingRedemptionRequests:                make(map[[32]byte]*RedemptionRequest),
 		redemptionProposalValidations:            make(map[[32]byte]bool),
@@ -50,6 +50,17 @@ const (
 	// heartbeat action during the coordination procedure, assuming no other
 	// higher-priority action is proposed.
 	coordinationHeartbeatProbability = float64(0.0625)
+	// coordinationKeyShareRefreshProbability is the probability of checking
+	// the key share refresh action during the coordination procedure. The
+	// value of 1/240 means the key shares of a wallet are refreshed roughly
+	// once per 240 coordination windows, i.e. once per 30 days, assuming
+	// 12 seconds per block.
+	coordinationKeyShareRefreshProbability = float64(1) / 240
+	// coordinationRbfFrequencyWindows is the number of coordination windows
+	// between two consecutive checks of the replace-by-fee action. The value
+	// of 2 windows is roughly 6 hours, assuming 12 seconds per block, which
+	// matches the time after which a wallet transaction is considered stuck.
+	coordinationRbfFrequencyWindows = 2
 	// coordinationMessageReceiveBuffer is a buffer for messages received from
 	// the broadcast channel needed when the coordination follower is
 	// temporarily too slow to handle them. Keep in mind that although we
@@ -466,10 +477,22 @@ func (ce *coordinationExecutor) coordinate(
 func (ce *coordinationExecutor) getSeed(
 	coordinationBlock uint64,
 ) ([32]byte, error) {
-	walletPublicKeyHash := ce.walletPublicKeyHash()
+	return computeCoordinationSeed(
+		ce.walletPublicKeyHash(),
+		coordinationBlock,
+		ce.chain.GetBlockHashByNumber,
+	)
+}
 
+// computeCoordinationSeed computes the coordination seed of the given wallet
+// for the coordination window starting at the given coordination block.
+func computeCoordinationSeed(
+	walletPublicKeyHash [20]byte,
+	coordinationBlock uint64,
+	getBlockHashByNumberFn func(blockNumber uint64) ([32]byte, error),
+) ([32]byte, error) {
 	safeBlockNumber := coordinationBlock - coordinationSafeBlockShift
-	safeBlockHash, err := ce.chain.GetBlockHashByNumber(safeBlockNumber)
+	safeBlockHash, err := getBlockHashByNumberFn(safeBlockNumber)
 	if err != nil {
 		return [32]byte{}, fmt.Errorf(
 			"failed to get safe block hash: [%v]",
@@ -486,7 +509,9 @@ func (ce *coordinationExecutor) getSeed(
 }
 
 // getLeader returns the address of the coordination leader for the given
-// coordination seed.
+// coordination seed. The leader is determined using the seed and the
+// on-chain signing group only so all operators of the wallet agree on it.
+// Node-local data, like the coordination fault ledger, must not be used here.
 func (ce *coordinationExecutor) getLeader(seed [32]byte) chain.Address {
 	// First, take all operators backing the wallet.
 	allOperators := chain.Addresses(ce.coordinatedWallet.signingGroupOperators)
@@ -537,6 +562,17 @@ func (ce *coordinationExecutor) getActionsChecklist(
 
 	var actions []WalletActionType
 
+	// Replacing a stuck transaction unblocks all other actions that depend
+	// on the wallet's main UTXO so it is checked first. A transaction is
+	// considered stuck only after several hours so there is no point in
+	// checking it on every coordination window. A stuck transaction that
+	// cannot be replaced within the Bridge's fee limits can still be sped
+	// up by a child transaction spending its change so CPFP is checked
+	// right after.
+	if windowIndex%coordinationRbfFrequencyWindows == 0 {
+		actions = append(actions, ActionRbf, ActionCpfp)
+	}
+
 	// Redemption action is a priority action and should be checked on every
 	// coordination window.
 	actions = append(actions, ActionRedemption)
@@ -558,16 +594,81 @@ func (ce *coordinationExecutor) getActionsChecklist(
 	}
 
 	// #nosec G404 (insecure random number source (rand))
-	// Drawing a decision about heartbeat does not require secure randomness.
-	// Use first 8 bytes of the seed to initialize the RNG.
+	// Drawing decisions about heartbeat and key share refresh does not
+	// require secure randomness. Use first 8 bytes of the seed to initialize
+	// the RNG.
 	rng := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(seed[:8]))))
-	if rng.Float64() < coordinationHeartbeatProbability {
+	heartbeat := rng.Float64() < coordinationHeartbeatProbability
+	// The seed is the same for all members of the signing group so they all
+	// agree whether the key share refresh is checked. The refresh is checked
+	// after all actions moving funds so it never delays them.
+	keyShareRefresh := rng.Float64() < coordinationKeyShareRefreshProbability
+
+	if keyShareRefresh {
+		actions = append(actions, ActionKeyShareRefresh)
+	}
+
+	if heartbeat {
 		actions = append(actions, ActionHeartbeat)
 	}
 
 	return actions
 }
 
+// CoordinationActionsChecklist returns the list of wallet actions the
+// coordination leader of the given wallet checks in the coordination window
+// starting at the given coordination block. The checklist depends on the
+// coordination seed so the given function must return hashes of past blocks.
+func CoordinationActionsChecklist(
+	walletPublicKeyHash [20]byte,
+	coordinationBlock uint64,
+	getBlockHashByNumberFn func(blockNumber uint64) ([32]byte, error),
+) ([]WalletActionType, error) {
+	window := newCoordinationWindow(coordinationBlock)
+	if window.index() == 0 {
+		return nil, fmt.Errorf(
+			"invalid coordination block [%v]",
+			coordinationBlock,
+		)
+	}
+
+	seed, err := computeCoordinationSeed(
+		walletPublicKeyHash,
+		coordinationBlock,
+		getBlockHashByNumberFn,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute coordination seed: [%v]", err)
+	}
+
+	// The checklist does not depend on the state of a specific executor.
+	executor := &coordinationExecutor{}
+
+	return executor.getActionsChecklist(window.index(), seed), nil
+}
+
+// CoordinationBlocks returns coordination blocks of all coordination windows
+// starting within the given block range. Both ends of the range are inclusive.
+func CoordinationBlocks(startBlock uint64, endBlock uint64) []uint64 {
+	coordinationBlocks := make([]uint64, 0)
+
+	// Round the start block up to the nearest coordination block. Block 0
+	// never starts a valid coordination window.
+	coordinationBlock := startBlock
+	if remainder := startBlock % coordinationFrequencyBlocks; remainder != 0 {
+		coordinationBlock += coordinationFrequencyBlocks - remainder
+	}
+	if coordinationBlock == 0 {
+		coordinationBlock = coordinationFrequencyBlocks
+	}
+
+	for ; coordinationBlock <= endBlock; coordinationBlock += coordinationFrequencyBlocks {
+		coordinationBlocks = append(coordinationBlocks, coordinationBlock)
+	}
+
+	return coordinationBlocks
+}
+
 // executeLeaderRoutine executes the leader's routine for the given coordination
 // window. The routine generates a proposal and broadcasts it to the followers.
 // It returns the generated proposal or an error if the routine failed.
@@ -0,0 +1,277 @@
+package tbtc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/clientinfo"
+)
+
+const (
+	// coordinationFaultsDirectory is the name of the work persistence
+	// directory holding the coordination fault ledger.
+	coordinationFaultsDirectory = "coordination_faults"
+	// coordinationFaultRetentionWindows is the number of past coordination
+	// windows whose faults are kept in the ledger. With one coordination
+	// window every 900 blocks, this is roughly one week on Ethereum mainnet.
+	coordinationFaultRetentionWindows = 56
+)
+
+// coordinationFaultPenalty returns the penalty weight of the given fault
+// type, as reported by the ledger's diagnostics. Faults proving the
+// operator's misbehavior weigh more than faults that can be caused by
+// a temporary unavailability.
+func coordinationFaultPenalty(faultType CoordinationFaultType) uint {
+	switch faultType {
+	case FaultLeaderIdleness:
+		return 1
+	case FaultLeaderMistake:
+		return 2
+	case FaultLeaderImpersonation:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// coordinationFaultRecord is a single entry of the coordination fault ledger.
+type coordinationFaultRecord struct {
+	Operator          chain.Address         `json:"operator"`
+	FaultType         CoordinationFaultType `json:"faultType"`
+	CoordinationBlock uint64                `json:"coordinationBlock"`
+}
+
+// coordinationFaultLedger is a persistent, per-wallet ledger of coordination
+// faults observed by the node. Faults are recorded per operator and
+// coordination window and are exposed, along with operators' penalty scores,
+// through the client info diagnostics. The ledger is node-local and serves
+// reporting purposes only. It must not
+// influence any decision all operators of a wallet have to agree on, like
+// the coordination leader selection, as the ledgers of different operators
+// may differ. All functions of the ledger are safe for concurrent use.
+type coordinationFaultLedger struct {
+	mutex sync.Mutex
+
+	persistence persistence.BasicHandle
+
+	// records holds fault records of specific wallets. The map key is the
+	// hex-encoded 20-byte public key hash of the wallet.
+	records map[string][]*coordinationFaultRecord
+}
+
+// newCoordinationFaultLedger creates a new coordination fault ledger and
+// loads the records kept in the given persistence.
+func newCoordinationFaultLedger(
+	persistence persistence.BasicHandle,
+) *coordinationFaultLedger {
+	cfl := &coordinationFaultLedger{
+		persistence: persistence,
+		records:     make(map[string][]*coordinationFaultRecord),
+	}
+
+	cfl.load()
+
+	return cfl
+}
+
+// load reads all fault records from the persistence.
+func (cfl *coordinationFaultLedger) load() {
+	descriptorsChan, errorsChan := cfl.persistence.ReadAll()
+
+	// Two goroutines read from descriptors and errors channels and either
+	// add the records to the ledger or log an error. The reason for using
+	// two goroutines at the same time - one for descriptors and one for
+	// errors - is that channels do not have to be buffered, and we do not
+	// know in what order the information is written to channels.
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for descriptor := range descriptorsChan {
+			if descriptor.Directory() != coordinationFaultsDirectory {
+				continue
+			}
+
+			content, err := descriptor.Content()
+			if err != nil {
+				logger.Errorf(
+					"cannot read coordination faults from file [%s]: [%v]",
+					descriptor.Name(),
+					err,
+				)
+				continue
+			}
+
+			var records []*coordinationFaultRecord
+			if err := json.Unmarshal(content, &records); err != nil {
+				logger.Errorf(
+					"cannot unmarshal coordination faults from file [%s]: [%v]",
+					descriptor.Name(),
+					err,
+				)
+				continue
+			}
+
+			cfl.records[descriptor.Name()] = records
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for err := range errorsChan {
+			logger.Errorf(
+				"cannot load coordination faults from disk: [%v]",
+				err,
+			)
+		}
+	}()
+
+	wg.Wait()
+}
+
+// record adds the given faults observed by the given wallet in the given
+// coordination window to the ledger. Duplicated faults are recorded once.
+// Records older than the retention period are pruned. The wallet's ledger is
+// persisted if it has changed.
+func (cfl *coordinationFaultLedger) record(
+	walletPublicKeyHash [20]byte,
+	window *coordinationWindow,
+	faults []*coordinationFault,
+) error {
+	cfl.mutex.Lock()
+	defer cfl.mutex.Unlock()
+
+	key := hex.EncodeToString(walletPublicKeyHash[:])
+
+	records := cfl.records[key]
+	changed := false
+
+	for _, fault := range faults {
+		record := &coordinationFaultRecord{
+			Operator:          fault.culprit,
+			FaultType:         fault.faultType,
+			CoordinationBlock: window.coordinationBlock,
+		}
+
+		duplicated := false
+		for _, existing := range records {
+			if *existing == *record {
+				duplicated = true
+				break
+			}
+		}
+
+		if !duplicated {
+			records = append(records, record)
+			changed = true
+		}
+	}
+
+	retained := make([]*coordinationFaultRecord, 0, len(records))
+	for _, record := range records {
+		if isWithinFaultRetention(record, window.coordinationBlock) {
+			retained = append(retained, record)
+		} else {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	cfl.records[key] = retained
+
+	content, err := json.Marshal(retained)
+	if err != nil {
+		return fmt.Errorf("cannot marshal coordination faults: [%w]", err)
+	}
+
+	if err := cfl.persistence.Save(
+		content,
+		coordinationFaultsDirectory,
+		key,
+	); err != nil {
+		return fmt.Errorf("cannot save coordination faults: [%w]", err)
+	}
+
+	return nil
+}
+
+// diagnostics returns the ledger's content in a form suitable for the
+// client info diagnostics endpoint.
+func (cfl *coordinationFaultLedger) diagnostics() clientinfo.ApplicationInfo {
+	cfl.mutex.Lock()
+	defer cfl.mutex.Unlock()
+
+	type faultInfo struct {
+		Operator          string `json:"operator"`
+		FaultType         string `json:"fault_type"`
+		CoordinationBlock uint64 `json:"coordination_block"`
+	}
+
+	type walletInfo struct {
+		Faults        []faultInfo     `json:"faults"`
+		PenaltyScores map[string]uint `json:"penalty_scores"`
+	}
+
+	wallets := make(map[string]walletInfo)
+
+	for key, records := range cfl.records {
+		if len(records) == 0 {
+			continue
+		}
+
+		info := walletInfo{
+			Faults:        make([]faultInfo, 0, len(records)),
+			PenaltyScores: make(map[string]uint),
+		}
+
+		for _, record := range records {
+			info.Faults = append(info.Faults, faultInfo{
+				Operator:          record.Operator.String(),
+				FaultType:         record.FaultType.String(),
+				CoordinationBlock: record.CoordinationBlock,
+			})
+
+			// The penalty score of an operator is the sum of penalties of
+			// all their faults kept in the ledger.
+			if penalty := coordinationFaultPenalty(record.FaultType); penalty > 0 {
+				info.PenaltyScores[record.Operator.String()] += penalty
+			}
+		}
+
+		sort.SliceStable(info.Faults, func(i, j int) bool {
+			return info.Faults[i].CoordinationBlock < info.Faults[j].CoordinationBlock
+		})
+
+		wallets["0x"+key] = info
+	}
+
+	return clientinfo.ApplicationInfo{
+		"coordination_faults": wallets,
+	}
+}
+
+// isWithinFaultRetention returns true if the given record is within the
+// retention period counted back from the given coordination block.
+func isWithinFaultRetention(
+	record *coordinationFaultRecord,
+	coordinationBlock uint64,
+) bool {
+	retentionBlocks := uint64(
+		coordinationFaultRetentionWindows * coordinationFrequencyBlocks,
+	)
+
+	return coordinationBlock < retentionBlocks ||
+		record.CoordinationBlock >= coordinationBlock-retentionBlocks
+}
@@ -0,0 +1,188 @@
+package tbtc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+func TestCoordinationFaultLedger_Record(t *testing.T) {
+	persistenceHandle := &mockPersistenceHandle{}
+	ledger := newCoordinationFaultLedger(persistenceHandle)
+
+	walletPublicKeyHash := [20]byte{1}
+
+	faults := []*coordinationFault{
+		{culprit: "operator1", faultType: FaultLeaderIdleness},
+		{culprit: "operator2", faultType: FaultLeaderImpersonation},
+		// Impersonators can send multiple messages in the same window.
+		{culprit: "operator2", faultType: FaultLeaderImpersonation},
+	}
+
+	err := ledger.record(walletPublicKeyHash, newCoordinationWindow(900), faults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ledger.record(
+		walletPublicKeyHash,
+		newCoordinationWindow(1800),
+		[]*coordinationFault{
+			{culprit: "operator1", faultType: FaultLeaderMistake},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"saved files count",
+		2,
+		len(persistenceHandle.saved),
+	)
+
+	lastSaved := persistenceHandle.saved[1]
+	testutils.AssertStringsEqual(
+		t,
+		"directory",
+		coordinationFaultsDirectory,
+		lastSaved.Directory(),
+	)
+	testutils.AssertStringsEqual(
+		t,
+		"file name",
+		"0100000000000000000000000000000000000000",
+		lastSaved.Name(),
+	)
+
+	content, err := lastSaved.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var savedRecords []*coordinationFaultRecord
+	if err := json.Unmarshal(content, &savedRecords); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRecords := []*coordinationFaultRecord{
+		{Operator: "operator1", FaultType: FaultLeaderIdleness, CoordinationBlock: 900},
+		{Operator: "operator2", FaultType: FaultLeaderImpersonation, CoordinationBlock: 900},
+		{Operator: "operator1", FaultType: FaultLeaderMistake, CoordinationBlock: 1800},
+	}
+	if !reflect.DeepEqual(expectedRecords, savedRecords) {
+		t.Errorf(
+			"unexpected saved records\nexpected: %v\nactual:   %v",
+			expectedRecords,
+			savedRecords,
+		)
+	}
+
+	// A fresh ledger should load the same state from the persistence.
+	loadedLedger := newCoordinationFaultLedger(persistenceHandle)
+	loadedRecords := loadedLedger.records[hex.EncodeToString(walletPublicKeyHash[:])]
+	if !reflect.DeepEqual(expectedRecords, loadedRecords) {
+		t.Errorf(
+			"unexpected loaded records\nexpected: %v\nactual:   %v",
+			expectedRecords,
+			loadedRecords,
+		)
+	}
+}
+
+func TestCoordinationFaultLedger_Retention(t *testing.T) {
+	persistenceHandle := &mockPersistenceHandle{}
+	ledger := newCoordinationFaultLedger(persistenceHandle)
+
+	walletPublicKeyHash := [20]byte{1}
+	retentionBlocks := uint64(
+		coordinationFaultRetentionWindows * coordinationFrequencyBlocks,
+	)
+
+	err := ledger.record(
+		walletPublicKeyHash,
+		newCoordinationWindow(900),
+		[]*coordinationFault{
+			{culprit: "operator1", faultType: FaultLeaderIdleness},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recording faults from a window still within the retention period
+	// should keep the old record.
+	err = ledger.record(
+		walletPublicKeyHash,
+		newCoordinationWindow(900+retentionBlocks),
+		[]*coordinationFault{
+			{culprit: "operator2", faultType: FaultLeaderIdleness},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"records count",
+		2,
+		len(ledger.records["0100000000000000000000000000000000000000"]),
+	)
+
+	// Recording faults from a window far ahead should prune the oldest
+	// record.
+	err = ledger.record(
+		walletPublicKeyHash,
+		newCoordinationWindow(1800+retentionBlocks),
+		[]*coordinationFault{
+			{culprit: "operator3", faultType: FaultLeaderIdleness},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := ledger.records["0100000000000000000000000000000000000000"]
+	testutils.AssertIntsEqual(t, "records count", 2, len(records))
+	for _, record := range records {
+		if record.CoordinationBlock == 900 {
+			t.Errorf("record of block 900 should be pruned")
+		}
+	}
+}
+
+func TestCoordinationFaultLedger_Diagnostics(t *testing.T) {
+	ledger := newCoordinationFaultLedger(&mockPersistenceHandle{})
+
+	err := ledger.record(
+		[20]byte{1},
+		newCoordinationWindow(900),
+		[]*coordinationFault{
+			{culprit: "operator1", faultType: FaultLeaderIdleness},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diagnostics, err := json.Marshal(ledger.diagnostics())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedDiagnostics := `{"coordination_faults":{"0x0100000000000000000000000000000000000000":` +
+		`{"faults":[{"operator":"operator1","fault_type":"LeaderIdleness",` +
+		`"coordination_block":900}],"penalty_scores":{"operator1":1}}}}`
+
+	testutils.AssertStringsEqual(
+		t,
+		"diagnostics",
+		expectedDiagnostics,
+		string(diagnostics),
+	)
+}
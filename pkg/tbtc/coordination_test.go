@@ -552,7 +552,7 @@ func TestCoordinationExecutor_GetActionsChecklist(t *testing.T) {
 		},
 		"block 1800": {
 			coordinationBlock: 1800,
-			expectedChecklist: []WalletActionType{ActionRedemption},
+			expectedChecklist: []WalletActionType{ActionRbf, ActionCpfp, ActionRedemption},
 		},
 		"block 2700": {
 			coordinationBlock: 2700,
@@ -562,6 +562,8 @@ func TestCoordinationExecutor_GetActionsChecklist(t *testing.T) {
 		"block 3600": {
 			coordinationBlock: 3600,
 			expectedChecklist: []WalletActionType{
+				ActionRbf,
+				ActionCpfp,
 				ActionRedemption,
 				ActionDepositSweep,
 				ActionMovedFundsSweep,
@@ -576,6 +578,8 @@ func TestCoordinationExecutor_GetActionsChecklist(t *testing.T) {
 		"block 5400": {
 			coordinationBlock: 5400,
 			expectedChecklist: []WalletActionType{
+				ActionRbf,
+				ActionCpfp,
 				ActionRedemption,
 			},
 		},
@@ -586,6 +590,8 @@ func TestCoordinationExecutor_GetActionsChecklist(t *testing.T) {
 		"block 7200": {
 			coordinationBlock: 7200,
 			expectedChecklist: []WalletActionType{
+				ActionRbf,
+				ActionCpfp,
 				ActionRedemption,
 				ActionDepositSweep,
 				ActionMovedFundsSweep,
@@ -598,7 +604,7 @@ func TestCoordinationExecutor_GetActionsChecklist(t *testing.T) {
 		},
 		"block 9000": {
 			coordinationBlock: 9000,
-			expectedChecklist: []WalletActionType{ActionRedemption},
+			expectedChecklist: []WalletActionType{ActionRbf, ActionCpfp, ActionRedemption},
 		},
 		"block 9900": {
 			coordinationBlock: 9900,
@@ -607,6 +613,8 @@ func TestCoordinationExecutor_GetActionsChecklist(t *testing.T) {
 		"block 10800": {
 			coordinationBlock: 10800,
 			expectedChecklist: []WalletActionType{
+				ActionRbf,
+				ActionCpfp,
 				ActionRedemption,
 				ActionDepositSweep,
 				ActionMovedFundsSweep,
@@ -620,6 +628,8 @@ func TestCoordinationExecutor_GetActionsChecklist(t *testing.T) {
 		"block 12600": {
 			coordinationBlock: 12600,
 			expectedChecklist: []WalletActionType{
+				ActionRbf,
+				ActionCpfp,
 				ActionRedemption,
 			},
 		},
@@ -630,6 +640,8 @@ func TestCoordinationExecutor_GetActionsChecklist(t *testing.T) {
 		"block 14400": {
 			coordinationBlock: 14400,
 			expectedChecklist: []WalletActionType{
+				ActionRbf,
+				ActionCpfp,
 				ActionRedemption,
 				ActionDepositSweep,
 				ActionMovedFundsSweep,
@@ -668,6 +680,167 @@ func TestCoordinationExecutor_GetActionsChecklist(t *testing.T) {
 	}
 }
 
+func TestCoordinationExecutor_GetActionsChecklist_KeyShareRefresh(t *testing.T) {
+	tests := map[string]struct {
+		coordinationBlock uint64
+		expectedChecklist []WalletActionType
+	}{
+		// Key share refresh randomly selected for the 42nd coordination window.
+		"block 37800": {
+			coordinationBlock: 37800,
+			expectedChecklist: []WalletActionType{
+				ActionRbf,
+				ActionCpfp,
+				ActionRedemption,
+				ActionKeyShareRefresh,
+			},
+		},
+		// Key share refresh and heartbeat randomly selected for the 595th
+		// coordination window.
+		"block 535500": {
+			coordinationBlock: 535500,
+			expectedChecklist: []WalletActionType{
+				ActionRedemption,
+				ActionKeyShareRefresh,
+				ActionHeartbeat,
+			},
+		},
+	}
+
+	executor := &coordinationExecutor{}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			window := newCoordinationWindow(test.coordinationBlock)
+
+			// Build an arbitrary seed based on the coordination block number.
+			seed := sha256.Sum256(
+				big.NewInt(int64(window.coordinationBlock) + 2).Bytes(),
+			)
+
+			checklist := executor.getActionsChecklist(window.index(), seed)
+
+			if diff := deep.Equal(
+				checklist,
+				test.expectedChecklist,
+			); diff != nil {
+				t.Errorf(
+					"compare failed: %v\nactual: %s\nexpected: %s",
+					diff,
+					checklist,
+					test.expectedChecklist,
+				)
+			}
+		})
+	}
+}
+
+func TestCoordinationActionsChecklist(t *testing.T) {
+	walletPublicKeyHash := [20]byte{1, 2, 3}
+
+	getBlockHashByNumberFn := func(blockNumber uint64) ([32]byte, error) {
+		if blockNumber != 1800-32 {
+			return [32]byte{}, fmt.Errorf("unexpected block [%v]", blockNumber)
+		}
+
+		return [32]byte{4, 5, 6}, nil
+	}
+
+	checklist, err := CoordinationActionsChecklist(
+		walletPublicKeyHash,
+		1800,
+		getBlockHashByNumberFn,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed, err := computeCoordinationSeed(
+		walletPublicKeyHash,
+		1800,
+		getBlockHashByNumberFn,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executor := &coordinationExecutor{}
+
+	expectedChecklist := executor.getActionsChecklist(2, seed)
+	if diff := deep.Equal(checklist, expectedChecklist); diff != nil {
+		t.Errorf(
+			"compare failed: %v\nactual: %s\nexpected: %s",
+			diff,
+			checklist,
+			expectedChecklist,
+		)
+	}
+
+	_, err = CoordinationActionsChecklist(
+		walletPublicKeyHash,
+		1801,
+		getBlockHashByNumberFn,
+	)
+	expectedErr := fmt.Errorf("invalid coordination block [1801]")
+	if !reflect.DeepEqual(expectedErr, err) {
+		t.Errorf(
+			"unexpected error\nexpected: [%v]\nactual:   [%v]",
+			expectedErr,
+			err,
+		)
+	}
+}
+
+func TestCoordinationBlocks(t *testing.T) {
+	var tests = map[string]struct {
+		startBlock                 uint64
+		endBlock                   uint64
+		expectedCoordinationBlocks []uint64
+	}{
+		"range starting at block 0": {
+			startBlock:                 0,
+			endBlock:                   1800,
+			expectedCoordinationBlocks: []uint64{900, 1800},
+		},
+		"range starting at coordination block": {
+			startBlock:                 900,
+			endBlock:                   2699,
+			expectedCoordinationBlocks: []uint64{900, 1800},
+		},
+		"range starting between coordination blocks": {
+			startBlock:                 901,
+			endBlock:                   2700,
+			expectedCoordinationBlocks: []uint64{1800, 2700},
+		},
+		"range without coordination blocks": {
+			startBlock:                 901,
+			endBlock:                   1799,
+			expectedCoordinationBlocks: []uint64{},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			coordinationBlocks := CoordinationBlocks(
+				test.startBlock,
+				test.endBlock,
+			)
+
+			if !reflect.DeepEqual(
+				test.expectedCoordinationBlocks,
+				coordinationBlocks,
+			) {
+				t.Errorf(
+					"unexpected coordination blocks\n"+
+						"expected: [%v]\nactual:   [%v]",
+					test.expectedCoordinationBlocks,
+					coordinationBlocks,
+				)
+			}
+		})
+	}
+}
+
 func TestCoordinationExecutor_ExecuteLeaderRoutine(t *testing.T) {
 	// Uncompressed public key corresponding to the 20-byte public key hash:
 	// aa768412ceed10bd423c025542ca90071f9fb62d.
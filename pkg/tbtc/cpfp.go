@@ -0,0 +1,728 @@
+package tbtc
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ipfs/go-log/v2"
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+const (
+	// cpfpProposalValidityBlocks determines the CPFP proposal validity time
+	// expressed in blocks. In other words, this is the worst-case time for
+	// a child transaction during which the wallet is busy and cannot take
+	// another actions. The child is either a deposit sweep or a redemption
+	// so the value matches the longer validity time of these two, i.e. the
+	// one of deposit sweeps. The value of 1200 blocks is roughly 4 hours,
+	// assuming 12 seconds per block.
+	cpfpProposalValidityBlocks = 1200
+	// cpfpSigningTimeoutSafetyMarginBlocks determines the duration of the
+	// safety margin that must be preserved between the signing timeout and
+	// the timeout of the entire CPFP action. This safety margin prevents
+	// against the case where signing completes late and there is not enough
+	// time to broadcast the child transaction properly. In such a case,
+	// wallet signatures may leak and make the wallet subject of fraud
+	// accusations. Usage of the safety margin ensures there is enough time to
+	// perform post-signing steps of the CPFP action. The value of 300 blocks
+	// is roughly 1 hour, assuming 12 seconds per block.
+	cpfpSigningTimeoutSafetyMarginBlocks = 300
+	// cpfpBroadcastTimeout determines the time window for child transaction
+	// broadcast. It is guaranteed that at least
+	// cpfpSigningTimeoutSafetyMarginBlocks is preserved for the broadcast
+	// step. However, the happy path for the broadcast step is usually quick
+	// and few retries are needed to recover from temporary problems. That
+	// said, if the broadcast step does not succeed in a tight timeframe,
+	// there is no point to retry for the entire possible time window. Hence,
+	// the timeout for broadcast step is set as 25% of the entire time widow
+	// determined by cpfpSigningTimeoutSafetyMarginBlocks.
+	cpfpBroadcastTimeout = 15 * time.Minute
+	// cpfpBroadcastCheckDelay determines the delay that must be preserved
+	// between transaction broadcast and the check that ensures the transaction
+	// is known on the Bitcoin chain. This delay is needed as spreading the
+	// transaction over the Bitcoin network takes time.
+	cpfpBroadcastCheckDelay = 1 * time.Minute
+	// cpfpDepositScriptByteSize is the byte size of a deposit script used
+	// to estimate the virtual size of a child deposit sweep transaction.
+	// This is the worst-case deposit script with embedded extra data so the
+	// child's fee rate is not overestimated.
+	cpfpDepositScriptByteSize = 126
+)
+
+// CpfpPackageConfirmationTarget is the number of Bitcoin blocks within which
+// the package of a parent transaction and its CPFP child is supposed to be
+// confirmed. The package must pay at least the fee rate estimated for this
+// target. Fee rate estimates of wallet operators' Bitcoin nodes differ
+// slightly so the target is more lenient than the default confirmation
+// target the coordination leader estimates the child fee for.
+const CpfpPackageConfirmationTarget = 6
+
+// CpfpProposal represents a child-pays-for-parent proposal issued by
+// a wallet's coordination leader. The proposal asks the wallet to sign
+// a child transaction spending the change output of a parent wallet
+// transaction stuck in the Bitcoin mempool. The child pays a fee rate high
+// enough to pull the parent into a block along with it.
+//
+// The child must be a regular wallet action the Bridge can prove, i.e.
+// a deposit sweep or a redemption. A plain transfer of the change to the
+// wallet itself would not be accepted by the Bridge and would make the
+// wallet subject of fraud accusations. Moreover, the Bridge expects
+// transactions to spend the main UTXO it knows about so the proof of the
+// child transaction is accepted only once the proof of the parent
+// transaction updated the main UTXO to the parent's change.
+type CpfpProposal struct {
+	ParentTransactionHash bitcoin.Hash
+	// ChildProposal is either a *DepositSweepProposal or
+	// a *RedemptionProposal describing the child transaction.
+	ChildProposal CoordinationProposal
+}
+
+func (cp *CpfpProposal) ActionType() WalletActionType {
+	return ActionCpfp
+}
+
+func (cp *CpfpProposal) ValidityBlocks() uint64 {
+	return cpfpProposalValidityBlocks
+}
+
+// CpfpParent holds details of a wallet transaction being the parent of
+// a CPFP child transaction.
+type CpfpParent struct {
+	// Transaction is the parent transaction as seen in the mempool.
+	Transaction *bitcoin.Transaction
+	// Fee is the fee paid by the parent transaction.
+	Fee int64
+	// ChangeUtxo is the parent's output paying back to the wallet. The child
+	// transaction spends it as the wallet's main UTXO.
+	ChangeUtxo *bitcoin.UnspentTransactionOutput
+}
+
+// cpfpChild holds the validated content of a CPFP child transaction.
+type cpfpChild struct {
+	// deposits holds deposits swept by a child deposit sweep transaction.
+	deposits []*Deposit
+	// redemptionRequests holds requests handled by a child redemption
+	// transaction.
+	redemptionRequests []*RedemptionRequest
+}
+
+// cpfpChain represents the chain interface required to validate
+// CPFP proposals.
+type cpfpChain interface {
+	BridgeChain
+
+	// ValidateDepositSweepProposal validates the given deposit sweep proposal
+	// against the chain. It requires some additional data about the deposits
+	// that must be fetched externally. Returns an error if the proposal is
+	// not valid or nil otherwise.
+	ValidateDepositSweepProposal(
+		walletPublicKeyHash [20]byte,
+		proposal *DepositSweepProposal,
+		depositsExtraInfo []struct {
+			*Deposit
+			FundingTx *bitcoin.Transaction
+		},
+	) error
+
+	// ValidateRedemptionProposal validates the given redemption proposal
+	// against the chain. Returns an error if the proposal is not valid or
+	// nil otherwise.
+	ValidateRedemptionProposal(
+		walletPublicKeyHash [20]byte,
+		proposal *RedemptionProposal,
+	) error
+}
+
+// cpfpAction is a walletAction implementation handling child transactions
+// that speed up wallet transactions stuck in the Bitcoin mempool.
+type cpfpAction struct {
+	logger   *zap.SugaredLogger
+	chain    Chain
+	btcChain bitcoin.Chain
+
+	cpfpWallet          wallet
+	transactionExecutor *walletTransactionExecutor
+	transactionTracker  *walletTransactionTracker
+
+	// audit collects the audit trail of the action. Can be nil, in which
+	// case the action is not audited.
+	audit *walletActionAudit
+
+	proposal                     *CpfpProposal
+	proposalProcessingStartBlock uint64
+	proposalExpiryBlock          uint64
+
+	requiredFundingTxConfirmations uint
+
+	signingTimeoutSafetyMarginBlocks uint64
+	broadcastTimeout                 time.Duration
+	broadcastCheckDelay              time.Duration
+}
+
+func newCpfpAction(
+	logger *zap.SugaredLogger,
+	chain Chain,
+	btcChain bitcoin.Chain,
+	cpfpWallet wallet,
+	signingExecutor walletSigningExecutor,
+	transactionTracker *walletTransactionTracker,
+	proposal *CpfpProposal,
+	proposalProcessingStartBlock uint64,
+	proposalExpiryBlock uint64,
+	waitForBlockFn waitForBlockFn,
+	audit *walletActionAudit,
+) *cpfpAction {
+	transactionExecutor := newWalletTransactionExecutor(
+		btcChain,
+		cpfpWallet,
+		signingExecutor,
+		transactionTracker,
+		waitForBlockFn,
+		audit,
+	)
+
+	return &cpfpAction{
+		logger:                           logger,
+		chain:                            chain,
+		btcChain:                         btcChain,
+		cpfpWallet:                       cpfpWallet,
+		transactionExecutor:              transactionExecutor,
+		audit:                            audit,
+		transactionTracker:               transactionTracker,
+		proposal:                         proposal,
+		proposalProcessingStartBlock:     proposalProcessingStartBlock,
+		proposalExpiryBlock:              proposalExpiryBlock,
+		requiredFundingTxConfirmations:   DepositSweepRequiredFundingTxConfirmations,
+		signingTimeoutSafetyMarginBlocks: cpfpSigningTimeoutSafetyMarginBlocks,
+		broadcastTimeout:                 cpfpBroadcastTimeout,
+		broadcastCheckDelay:              cpfpBroadcastCheckDelay,
+	}
+}
+
+func (ca *cpfpAction) execute() error {
+	validateProposalLogger := ca.logger.With(
+		zap.String("step", "validateProposal"),
+	)
+
+	walletPublicKeyHash := bitcoin.PublicKeyHash(ca.wallet().publicKey)
+
+	parent, child, err := validateCpfpProposal(
+		validateProposalLogger,
+		walletPublicKeyHash,
+		ca.proposal,
+		ca.requiredFundingTxConfirmations,
+		ca.chain,
+		ca.btcChain,
+	)
+	ca.audit.recordValidation(err)
+	if err != nil {
+		return fmt.Errorf("validate proposal step failed: [%v]", err)
+	}
+
+	// The parent is not proven to the Bridge yet so the wallet's main UTXO
+	// registered there is still the one spent by the parent. The child
+	// spends the parent's change instead, which becomes the main UTXO once
+	// the parent is proven.
+	var unsignedChildTx *bitcoin.TransactionBuilder
+	switch childProposal := ca.proposal.ChildProposal.(type) {
+	case *DepositSweepProposal:
+		unsignedChildTx, err = assembleDepositSweepTransaction(
+			ca.btcChain,
+			ca.wallet().publicKey,
+			parent.ChangeUtxo,
+			child.deposits,
+			childProposal.SweepTxFee.Int64(),
+		)
+	case *RedemptionProposal:
+		feeDistribution := withRedemptionTotalFee(
+			childProposal.RedemptionTxFee.Int64(),
+		)
+		if len(childProposal.RedemptionTxFeeShares) > 0 {
+			feeDistribution = withRedemptionFeeShares(
+				childProposal.RedemptionTxFeeShares,
+			)
+		}
+
+		unsignedChildTx, err = assembleRedemptionTransaction(
+			ca.btcChain,
+			ca.wallet().publicKey,
+			parent.ChangeUtxo,
+			child.redemptionRequests,
+			feeDistribution,
+			childProposal.TransactionShape,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf(
+			"error while assembling child transaction: [%v]",
+			err,
+		)
+	}
+
+	signTxLogger := ca.logger.With(
+		zap.String("step", "signTransaction"),
+	)
+
+	// Just in case. This should never happen.
+	if ca.proposalExpiryBlock < ca.signingTimeoutSafetyMarginBlocks {
+		return fmt.Errorf("invalid proposal expiry block")
+	}
+
+	childTx, err := ca.transactionExecutor.signTransaction(
+		signTxLogger,
+		unsignedChildTx,
+		ca.proposalProcessingStartBlock,
+		ca.proposalExpiryBlock-ca.signingTimeoutSafetyMarginBlocks,
+	)
+	if err != nil {
+		return fmt.Errorf("sign transaction step failed: [%v]", err)
+	}
+
+	// The child is tracked right after signing, before it is broadcast.
+	// This way, the node refuses to replace the parent by fee even if the
+	// child never reaches the mempool, as the replacement would invalidate
+	// the child already signed by the wallet.
+	if ca.transactionTracker != nil {
+		err := ca.transactionTracker.track(
+			walletPublicKeyHash,
+			ca.proposal.ChildProposal.ActionType(),
+			childTx,
+			time.Now(),
+		)
+		if err != nil {
+			signTxLogger.Warnf("cannot track the child transaction: [%v]", err)
+		}
+	}
+
+	broadcastTxLogger := ca.logger.With(
+		zap.String("step", "broadcastTransaction"),
+		zap.String(
+			"parentTxHash",
+			ca.proposal.ParentTransactionHash.Hex(bitcoin.ReversedByteOrder),
+		),
+		zap.String("childTxHash", childTx.Hash().Hex(bitcoin.ReversedByteOrder)),
+	)
+
+	// The child is tracked under the action type of the child proposal so
+	// it can be fee-bumped like any other transaction of that type.
+	err = ca.transactionExecutor.broadcastTransaction(
+		broadcastTxLogger,
+		ca.proposal.ChildProposal.ActionType(),
+		childTx,
+		ca.broadcastTimeout,
+		ca.broadcastCheckDelay,
+	)
+	if err != nil {
+		return fmt.Errorf("broadcast transaction step failed: [%v]", err)
+	}
+
+	return nil
+}
+
+func (ca *cpfpAction) wallet() wallet {
+	return ca.cpfpWallet
+}
+
+func (ca *cpfpAction) actionType() WalletActionType {
+	return ActionCpfp
+}
+
+// ValidateCpfpProposal checks the CPFP proposal against the parent
+// transaction and validates the child proposal the same way a standalone
+// deposit sweep or redemption proposal is validated. See AnalyzeCpfpParent
+// for requirements the parent must meet. Moreover, the child must not
+// include deposits swept or redemption requests handled by the parent, as
+// they are still pending in the Bridge until the parent is proven, and must
+// pay a higher fee rate than the parent so it actually speeds the parent up.
+// The package of the parent and the child must pay at least the fee
+// returned by ComputeCpfpMinChildFee. A parent that can still be replaced by fee, as determined by
+// IsRbfReplaceable, cannot get a child; RBF and CPFP are never used for the
+// same transaction.
+func ValidateCpfpProposal(
+	validateProposalLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+	proposal *CpfpProposal,
+	requiredFundingTxConfirmations uint,
+	chain cpfpChain,
+	btcChain bitcoin.Chain,
+) error {
+	_, _, err := validateCpfpProposal(
+		validateProposalLogger,
+		walletPublicKeyHash,
+		proposal,
+		requiredFundingTxConfirmations,
+		chain,
+		btcChain,
+	)
+
+	return err
+}
+
+// validateCpfpProposal validates the CPFP proposal and returns details of
+// the parent transaction along with the validated content of the child
+// transaction. See ValidateCpfpProposal for details.
+func validateCpfpProposal(
+	validateProposalLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+	proposal *CpfpProposal,
+	requiredFundingTxConfirmations uint,
+	chain cpfpChain,
+	btcChain bitcoin.Chain,
+) (*CpfpParent, *cpfpChild, error) {
+	validateProposalLogger.Infof("checking the parent transaction")
+
+	parent, err := AnalyzeCpfpParent(
+		walletPublicKeyHash,
+		proposal.ParentTransactionHash,
+		chain,
+		btcChain,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid parent transaction: [%v]", err)
+	}
+
+	validateProposalLogger.Infof(
+		"checking whether the parent transaction can be replaced by fee",
+	)
+
+	// The child spends the parent's change so it would be invalidated by
+	// a replacement of the parent. Parents whose fee can still be bumped
+	// are replaced instead.
+	if IsRbfReplaceable(
+		walletPublicKeyHash,
+		proposal.ParentTransactionHash,
+		chain,
+		btcChain,
+	) {
+		return nil, nil, fmt.Errorf(
+			"parent transaction can still be replaced by fee",
+		)
+	}
+
+	validateProposalLogger.Infof("checking the child proposal")
+
+	child := &cpfpChild{}
+	var childFee *big.Int
+
+	switch childProposal := proposal.ChildProposal.(type) {
+	case *DepositSweepProposal:
+		for i, depositKey := range childProposal.DepositsKeys {
+			for _, input := range parent.Transaction.Inputs {
+				if input.Outpoint.TransactionHash == depositKey.FundingTxHash &&
+					input.Outpoint.OutputIndex == depositKey.FundingOutputIndex {
+					return nil, nil, fmt.Errorf(
+						"deposit [%v] is already swept by the parent "+
+							"transaction",
+						i,
+					)
+				}
+			}
+		}
+
+		child.deposits, err = ValidateDepositSweepProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			childProposal,
+			requiredFundingTxConfirmations,
+			chain,
+			btcChain,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid child proposal: [%v]", err)
+		}
+
+		childFee = childProposal.SweepTxFee
+	case *RedemptionProposal:
+		for i, script := range childProposal.RedeemersOutputScripts {
+			for _, output := range parent.Transaction.Outputs {
+				if bytes.Equal(output.PublicKeyScript, script) {
+					return nil, nil, fmt.Errorf(
+						"redemption request [%v] is already handled by "+
+							"the parent transaction",
+						i,
+					)
+				}
+			}
+		}
+
+		child.redemptionRequests, err = ValidateRedemptionProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			childProposal,
+			chain,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid child proposal: [%v]", err)
+		}
+
+		childFee = childProposal.RedemptionTxFee
+	default:
+		return nil, nil, fmt.Errorf(
+			"child proposal must be a deposit sweep or a redemption",
+		)
+	}
+
+	validateProposalLogger.Infof("checking the child fee rate")
+
+	if childFee == nil || !childFee.IsInt64() {
+		return nil, nil, fmt.Errorf("invalid child fee")
+	}
+
+	childVirtualSize, err := EstimateCpfpChildVirtualSize(
+		proposal.ChildProposal,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"cannot estimate child virtual size: [%v]",
+			err,
+		)
+	}
+
+	// Compare fee rates by cross-multiplication to avoid rounding.
+	parentVirtualSize := parent.Transaction.VirtualSize()
+	if childFee.Int64()*parentVirtualSize <= parent.Fee*childVirtualSize {
+		return nil, nil, fmt.Errorf(
+			"child fee [%v] for [%v] vbytes does not exceed the fee rate "+
+				"of the parent paying [%v] for [%v] vbytes",
+			childFee,
+			childVirtualSize,
+			parent.Fee,
+			parentVirtualSize,
+		)
+	}
+
+	minChildFee, err := ComputeCpfpMinChildFee(
+		btcChain,
+		parent,
+		childVirtualSize,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"cannot compute minimum child fee: [%v]",
+			err,
+		)
+	}
+
+	if childFee.Int64() < minChildFee {
+		return nil, nil, fmt.Errorf(
+			"package of the parent and the child pays [%v] for [%v] vbytes "+
+				"which is less than the estimated fee [%v]",
+			parent.Fee+childFee.Int64(),
+			parentVirtualSize+childVirtualSize,
+			parent.Fee+minChildFee,
+		)
+	}
+
+	validateProposalLogger.Infof("CPFP proposal is valid")
+
+	return parent, child, nil
+}
+
+// ComputeCpfpMinChildFee computes the minimum fee of a child transaction of
+// the given virtual size so the package of the given parent and the child
+// pays, in total, the fee estimated for the package's virtual size and
+// CpfpPackageConfirmationTarget.
+func ComputeCpfpMinChildFee(
+	btcChain bitcoin.Chain,
+	parent *CpfpParent,
+	childVirtualSize int64,
+) (int64, error) {
+	packageFee, err := bitcoin.NewTransactionFeeEstimator(btcChain).EstimateFee(
+		parent.Transaction.VirtualSize()+childVirtualSize,
+		CpfpPackageConfirmationTarget,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("cannot estimate package fee: [%v]", err)
+	}
+
+	return packageFee - parent.Fee, nil
+}
+
+// AnalyzeCpfpParent gathers details of the given wallet transaction being
+// the parent of a CPFP child transaction. The parent must:
+//   - be unconfirmed, i.e. be present in the mempool,
+//   - spend the wallet's main UTXO registered in the Bridge, so it is the
+//     only wallet transaction not proven to the Bridge yet,
+//   - have exactly one output paying to the wallet's P2PKH or P2WPKH
+//     script, i.e. the change becoming the wallet's next main UTXO,
+//   - have its change not spent by another mempool transaction yet.
+func AnalyzeCpfpParent(
+	walletPublicKeyHash [20]byte,
+	parentTransactionHash bitcoin.Hash,
+	chain BridgeChain,
+	btcChain bitcoin.Chain,
+) (*CpfpParent, error) {
+	mempoolTransactions, err := btcChain.GetMempoolForPublicKeyHash(
+		walletPublicKeyHash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get mempool transactions: [%v]", err)
+	}
+
+	var transaction *bitcoin.Transaction
+	for _, mempoolTransaction := range mempoolTransactions {
+		if mempoolTransaction.Hash() == parentTransactionHash {
+			transaction = mempoolTransaction
+			break
+		}
+	}
+	if transaction == nil {
+		return nil, fmt.Errorf("transaction is not in the mempool")
+	}
+
+	walletMainUtxo, err := DetermineWalletMainUtxo(
+		walletPublicKeyHash,
+		chain,
+		btcChain,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot determine wallet's main UTXO: [%v]",
+			err,
+		)
+	}
+
+	if walletMainUtxo == nil {
+		return nil, fmt.Errorf("wallet has no main UTXO")
+	}
+
+	spendsMainUtxo := false
+	inputsValue := int64(0)
+	for i, input := range transaction.Inputs {
+		if *input.Outpoint == *walletMainUtxo.Outpoint {
+			spendsMainUtxo = true
+		}
+
+		previousTransaction, err := btcChain.GetTransaction(
+			input.Outpoint.TransactionHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get transaction spent by input [%v]: [%v]",
+				i,
+				err,
+			)
+		}
+
+		if int(input.Outpoint.OutputIndex) >= len(previousTransaction.Outputs) {
+			return nil, fmt.Errorf(
+				"output spent by input [%v] does not exist",
+				i,
+			)
+		}
+
+		inputsValue += previousTransaction.Outputs[input.Outpoint.OutputIndex].Value
+	}
+
+	if !spendsMainUtxo {
+		return nil, fmt.Errorf("transaction does not spend wallet's main UTXO")
+	}
+
+	var changeUtxo *bitcoin.UnspentTransactionOutput
+	outputsValue := int64(0)
+	for i, output := range transaction.Outputs {
+		outputsValue += output.Value
+
+		publicKeyHash, err := bitcoin.ExtractPublicKeyHash(
+			output.PublicKeyScript,
+		)
+		if err != nil || publicKeyHash != walletPublicKeyHash {
+			continue
+		}
+
+		if changeUtxo != nil {
+			return nil, fmt.Errorf(
+				"transaction has more than one output paying to the wallet",
+			)
+		}
+
+		changeUtxo = &bitcoin.UnspentTransactionOutput{
+			Outpoint: &bitcoin.TransactionOutpoint{
+				TransactionHash: parentTransactionHash,
+				OutputIndex:     uint32(i),
+			},
+			Value: output.Value,
+		}
+	}
+
+	if changeUtxo == nil {
+		return nil, fmt.Errorf("transaction has no output paying to the wallet")
+	}
+
+	for _, mempoolTransaction := range mempoolTransactions {
+		for _, input := range mempoolTransaction.Inputs {
+			if *input.Outpoint == *changeUtxo.Outpoint {
+				return nil, fmt.Errorf(
+					"transaction's change is already spent by transaction [%s]",
+					mempoolTransaction.Hash().Hex(bitcoin.ReversedByteOrder),
+				)
+			}
+		}
+	}
+
+	return &CpfpParent{
+		Transaction: transaction,
+		Fee:         inputsValue - outputsValue,
+		ChangeUtxo:  changeUtxo,
+	}, nil
+}
+
+// EstimateCpfpChildVirtualSize estimates the virtual size of the child
+// transaction described by the given child proposal. The estimate does not
+// depend on the child's fee so the proposal can carry no fee yet. It assumes
+// the child consists of:
+//   - 1 P2WPKH input being the parent's change,
+//   - for deposit sweeps, N P2WSH deposit inputs and 1 P2WPKH output,
+//   - for redemptions, 1 P2WPKH change output and the redemption outputs
+//     of types determined by the redeemers output scripts.
+func EstimateCpfpChildVirtualSize(
+	childProposal CoordinationProposal,
+) (int64, error) {
+	sizeEstimator := bitcoin.NewTransactionSizeEstimator().
+		AddPublicKeyHashInputs(1, true).
+		AddPublicKeyHashOutputs(1, true)
+
+	switch p := childProposal.(type) {
+	case *DepositSweepProposal:
+		if len(p.DepositsKeys) == 0 {
+			return 0, fmt.Errorf("no deposits")
+		}
+
+		sizeEstimator.AddScriptHashInputs(
+			len(p.DepositsKeys),
+			cpfpDepositScriptByteSize,
+			true,
+		)
+	case *RedemptionProposal:
+		if len(p.RedeemersOutputScripts) == 0 {
+			return 0, fmt.Errorf("no redemption requests")
+		}
+
+		for _, script := range p.RedeemersOutputScripts {
+			switch bitcoin.GetScriptType(script) {
+			case bitcoin.P2PKHScript:
+				sizeEstimator.AddPublicKeyHashOutputs(1, false)
+			case bitcoin.P2WPKHScript:
+				sizeEstimator.AddPublicKeyHashOutputs(1, true)
+			case bitcoin.P2SHScript:
+				sizeEstimator.AddScriptHashOutputs(1, false)
+			case bitcoin.P2WSHScript:
+				sizeEstimator.AddScriptHashOutputs(1, true)
+			case bitcoin.P2TRScript:
+				sizeEstimator.AddTaprootOutputs(1)
+			default:
+				return 0, fmt.Errorf("non-standard redeemer output script type")
+			}
+		}
+	default:
+		return 0, fmt.Errorf(
+			"child proposal must be a deposit sweep or a redemption",
+		)
+	}
+
+	return sizeEstimator.VirtualSize()
+}
@@ -0,0 +1,616 @@
+package tbtc
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+// cpfpTestTransactions holds transactions shared by CPFP tests. The parent
+// is a redemption transaction spending the wallet's main UTXO, paying
+// 1000 satoshi of fee and returning the change to the wallet.
+type cpfpTestTransactions struct {
+	walletPublicKeyHash [20]byte
+	walletScript        bitcoin.Script
+	redeemerScript      bitcoin.Script
+	mainUtxo            *bitcoin.UnspentTransactionOutput
+	mainUtxoTransaction *bitcoin.Transaction
+	parentTransaction   *bitcoin.Transaction
+}
+
+func newCpfpTestTransactions(t *testing.T) *cpfpTestTransactions {
+	walletPublicKeyHash := [20]byte{1, 2, 3}
+
+	walletScript, err := bitcoin.PayToWitnessPublicKeyHash(walletPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redeemerScript, err := bitcoin.PayToWitnessPublicKeyHash([20]byte{4, 5, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mainUtxoTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: bitcoin.Hash{0xaa},
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 100000, PublicKeyScript: walletScript},
+		},
+	}
+
+	mainUtxo := &bitcoin.UnspentTransactionOutput{
+		Outpoint: &bitcoin.TransactionOutpoint{
+			TransactionHash: mainUtxoTransaction.Hash(),
+			OutputIndex:     0,
+		},
+		Value: 100000,
+	}
+
+	parentTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: mainUtxo.Outpoint,
+				Witness: [][]byte{
+					bytes.Repeat([]byte{0x30}, 72),
+					bytes.Repeat([]byte{0x02}, 33),
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 60000, PublicKeyScript: walletScript},
+			{Value: 39000, PublicKeyScript: redeemerScript},
+		},
+	}
+
+	return &cpfpTestTransactions{
+		walletPublicKeyHash: walletPublicKeyHash,
+		walletScript:        walletScript,
+		redeemerScript:      redeemerScript,
+		mainUtxo:            mainUtxo,
+		mainUtxoTransaction: mainUtxoTransaction,
+		parentTransaction:   parentTransaction,
+	}
+}
+
+// setup registers the transactions on the given chains. The parent
+// transaction is put into the mempool and the main UTXO is registered
+// in the Bridge.
+func (ctt *cpfpTestTransactions) setup(
+	t *testing.T,
+	hostChain *localChain,
+	bitcoinChain *localBitcoinChain,
+) {
+	err := bitcoinChain.BroadcastTransaction(ctt.mainUtxoTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bitcoinChain.mempool = append(bitcoinChain.mempool, ctt.parentTransaction)
+
+	hostChain.setWallet(
+		ctt.walletPublicKeyHash,
+		&WalletChainData{
+			MainUtxoHash: hostChain.ComputeMainUtxoHash(ctt.mainUtxo),
+			State:        StateLive,
+		},
+	)
+}
+
+func TestAnalyzeCpfpParent(t *testing.T) {
+	transactions := newCpfpTestTransactions(t)
+
+	parentHash := transactions.parentTransaction.Hash()
+
+	// Transaction spending the parent's change.
+	childTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: parentHash,
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 59000, PublicKeyScript: transactions.walletScript},
+		},
+	}
+
+	// Transaction spending the main UTXO and paying to the wallet twice.
+	doubleChangeTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: transactions.mainUtxo.Outpoint,
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 50000, PublicKeyScript: transactions.walletScript},
+			{Value: 49000, PublicKeyScript: transactions.walletScript},
+		},
+	}
+
+	var tests = map[string]struct {
+		parentHash        bitcoin.Hash
+		additionalMempool []*bitcoin.Transaction
+		expectedParent    *CpfpParent
+		expectedErr       error
+	}{
+		"valid parent": {
+			parentHash: parentHash,
+			expectedParent: &CpfpParent{
+				Transaction: transactions.parentTransaction,
+				Fee:         1000,
+				ChangeUtxo: &bitcoin.UnspentTransactionOutput{
+					Outpoint: &bitcoin.TransactionOutpoint{
+						TransactionHash: parentHash,
+						OutputIndex:     0,
+					},
+					Value: 60000,
+				},
+			},
+		},
+		"parent not in the mempool": {
+			parentHash:  bitcoin.Hash{0xbb},
+			expectedErr: fmt.Errorf("transaction is not in the mempool"),
+		},
+		"parent not spending the main UTXO": {
+			parentHash:        childTransaction.Hash(),
+			additionalMempool: []*bitcoin.Transaction{childTransaction},
+			expectedErr: fmt.Errorf(
+				"transaction does not spend wallet's main UTXO",
+			),
+		},
+		"parent change already spent": {
+			parentHash:        parentHash,
+			additionalMempool: []*bitcoin.Transaction{childTransaction},
+			expectedErr: fmt.Errorf(
+				"transaction's change is already spent by transaction [%s]",
+				childTransaction.Hash().Hex(bitcoin.ReversedByteOrder),
+			),
+		},
+		"parent paying to the wallet twice": {
+			parentHash: doubleChangeTransaction.Hash(),
+			additionalMempool: []*bitcoin.Transaction{
+				doubleChangeTransaction,
+			},
+			expectedErr: fmt.Errorf(
+				"transaction has more than one output paying to the wallet",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			hostChain := Connect()
+			bitcoinChain := newLocalBitcoinChain()
+
+			transactions.setup(t, hostChain, bitcoinChain)
+
+			// The child transaction spends the parent so the parent must
+			// be fetchable as a regular transaction.
+			bitcoinChain.transactions = append(
+				bitcoinChain.transactions,
+				transactions.parentTransaction,
+			)
+
+			bitcoinChain.mempool = append(
+				bitcoinChain.mempool,
+				test.additionalMempool...,
+			)
+
+			parent, err := AnalyzeCpfpParent(
+				transactions.walletPublicKeyHash,
+				test.parentHash,
+				hostChain,
+				bitcoinChain,
+			)
+
+			if test.expectedErr != nil {
+				if err == nil {
+					t.Fatalf("expected error: [%v]", test.expectedErr)
+				}
+
+				testutils.AssertStringsEqual(
+					t,
+					"error",
+					test.expectedErr.Error(),
+					err.Error(),
+				)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(test.expectedParent, parent) {
+				t.Errorf(
+					"unexpected parent\nexpected: %+v\nactual:   %+v",
+					test.expectedParent,
+					parent,
+				)
+			}
+		})
+	}
+}
+
+func TestValidateCpfpProposal(t *testing.T) {
+	transactions := newCpfpTestTransactions(t)
+
+	otherRedeemerScript, err := bitcoin.PayToWitnessPublicKeyHash(
+		[20]byte{7, 8, 9},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parentFee := int64(1000)
+	parentVirtualSize := transactions.parentTransaction.VirtualSize()
+
+	childVirtualSize, err := EstimateCpfpChildVirtualSize(
+		&RedemptionProposal{
+			RedeemersOutputScripts: []bitcoin.Script{otherRedeemerScript},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The highest child fee not exceeding the parent's fee rate.
+	parentRateChildFee := parentFee * childVirtualSize / parentVirtualSize
+
+	var tests = map[string]struct {
+		childProposal  CoordinationProposal
+		satPerVByteFee int64
+		expectedErr    error
+	}{
+		"valid proposal": {
+			childProposal: &RedemptionProposal{
+				RedeemersOutputScripts: []bitcoin.Script{otherRedeemerScript},
+				RedemptionTxFee:        big.NewInt(parentRateChildFee + 1),
+			},
+		},
+		"child handling request of the parent": {
+			childProposal: &RedemptionProposal{
+				RedeemersOutputScripts: []bitcoin.Script{
+					transactions.redeemerScript,
+				},
+				RedemptionTxFee: big.NewInt(5000),
+			},
+			expectedErr: fmt.Errorf(
+				"redemption request [0] is already handled by the parent " +
+					"transaction",
+			),
+		},
+		"child fee rate not exceeding the parent's": {
+			childProposal: &RedemptionProposal{
+				RedeemersOutputScripts: []bitcoin.Script{otherRedeemerScript},
+				RedemptionTxFee:        big.NewInt(parentRateChildFee),
+			},
+			expectedErr: fmt.Errorf(
+				"child fee [%v] for [%v] vbytes does not exceed the fee "+
+					"rate of the parent",
+				parentRateChildFee,
+				childVirtualSize,
+			),
+		},
+		"package paying less than the estimated fee": {
+			childProposal: &RedemptionProposal{
+				RedeemersOutputScripts: []bitcoin.Script{otherRedeemerScript},
+				RedemptionTxFee:        big.NewInt(5000),
+			},
+			satPerVByteFee: 50,
+			expectedErr: fmt.Errorf(
+				"package of the parent and the child pays [%v] for [%v] "+
+					"vbytes which is less than the estimated fee [%v]",
+				parentFee+5000,
+				parentVirtualSize+childVirtualSize,
+				50*(parentVirtualSize+childVirtualSize),
+			),
+		},
+		"child of unsupported type": {
+			childProposal: &HeartbeatProposal{},
+			expectedErr: fmt.Errorf(
+				"child proposal must be a deposit sweep or a redemption",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			hostChain := Connect()
+			bitcoinChain := newLocalBitcoinChain()
+
+			transactions.setup(t, hostChain, bitcoinChain)
+
+			satPerVByteFee := test.satPerVByteFee
+			if satPerVByteFee == 0 {
+				satPerVByteFee = 1
+			}
+			bitcoinChain.setEstimateSatPerVByteFee(
+				CpfpPackageConfirmationTarget,
+				satPerVByteFee,
+			)
+
+			for _, script := range []bitcoin.Script{
+				transactions.redeemerScript,
+				otherRedeemerScript,
+			} {
+				hostChain.setPendingRedemptionRequest(
+					transactions.walletPublicKeyHash,
+					&RedemptionRequest{
+						RedeemerOutputScript: script,
+						RequestedAmount:      40000,
+						TxMaxFee:             5000,
+					},
+				)
+			}
+
+			if redemptionProposal, ok := test.childProposal.(*RedemptionProposal); ok {
+				err := hostChain.setRedemptionProposalValidationResult(
+					transactions.walletPublicKeyHash,
+					redemptionProposal,
+					true,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := ValidateCpfpProposal(
+				&testutils.MockLogger{},
+				transactions.walletPublicKeyHash,
+				&CpfpProposal{
+					ParentTransactionHash: transactions.parentTransaction.Hash(),
+					ChildProposal:         test.childProposal,
+				},
+				DepositSweepRequiredFundingTxConfirmations,
+				hostChain,
+				bitcoinChain,
+			)
+
+			if test.expectedErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: [%v]", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error: [%v]", test.expectedErr)
+			}
+
+			if !strings.Contains(err.Error(), test.expectedErr.Error()) {
+				t.Errorf(
+					"unexpected error\nexpected: %v\nactual:   %v",
+					test.expectedErr,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestRbfCpfpInteraction(t *testing.T) {
+	transactions := newCpfpTestTransactions(t)
+
+	parentHash := transactions.parentTransaction.Hash()
+
+	otherRedeemerScript, err := bitcoin.PayToWitnessPublicKeyHash(
+		[20]byte{7, 8, 9},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Child handling the other request and spending the parent's change.
+	childProposal := &RedemptionProposal{
+		RedeemersOutputScripts: []bitcoin.Script{otherRedeemerScript},
+		RedemptionTxFee:        big.NewInt(5000),
+	}
+	childTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: parentHash,
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 20000, PublicKeyScript: transactions.walletScript},
+			{Value: 35000, PublicKeyScript: otherRedeemerScript},
+		},
+	}
+
+	setup := func(
+		t *testing.T,
+		parentTxMaxFee uint64,
+	) (*localChain, *localBitcoinChain) {
+		hostChain := Connect()
+		bitcoinChain := newLocalBitcoinChain()
+
+		transactions.setup(t, hostChain, bitcoinChain)
+		bitcoinChain.setEstimateSatPerVByteFee(CpfpPackageConfirmationTarget, 1)
+
+		hostChain.SetRedemptionParameters(0, 0, 0, 10000, 0, nil, 0)
+		hostChain.setPendingRedemptionRequest(
+			transactions.walletPublicKeyHash,
+			&RedemptionRequest{
+				RedeemerOutputScript: transactions.redeemerScript,
+				RequestedAmount:      40000,
+				TxMaxFee:             parentTxMaxFee,
+			},
+		)
+		hostChain.setPendingRedemptionRequest(
+			transactions.walletPublicKeyHash,
+			&RedemptionRequest{
+				RedeemerOutputScript: otherRedeemerScript,
+				RequestedAmount:      40000,
+				TxMaxFee:             5000,
+			},
+		)
+
+		err := hostChain.setRedemptionProposalValidationResult(
+			transactions.walletPublicKeyHash,
+			childProposal,
+			true,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return hostChain, bitcoinChain
+	}
+
+	validateCpfp := func(
+		hostChain *localChain,
+		bitcoinChain *localBitcoinChain,
+	) error {
+		return ValidateCpfpProposal(
+			&testutils.MockLogger{},
+			transactions.walletPublicKeyHash,
+			&CpfpProposal{
+				ParentTransactionHash: parentHash,
+				ChildProposal:         childProposal,
+			},
+			DepositSweepRequiredFundingTxConfirmations,
+			hostChain,
+			bitcoinChain,
+		)
+	}
+
+	t.Run("no child for parent that can be replaced by fee", func(t *testing.T) {
+		// The parent's request can pay up to 5000 so the parent's fee of
+		// 1000 can still be bumped.
+		hostChain, bitcoinChain := setup(t, 5000)
+
+		testutils.AssertBoolsEqual(
+			t,
+			"replaceable",
+			true,
+			IsRbfReplaceable(
+				transactions.walletPublicKeyHash,
+				parentHash,
+				hostChain,
+				bitcoinChain,
+			),
+		)
+
+		err := validateCpfp(hostChain, bitcoinChain)
+
+		expectedErr := fmt.Errorf(
+			"parent transaction can still be replaced by fee",
+		)
+		if !reflect.DeepEqual(expectedErr, err) {
+			t.Errorf(
+				"unexpected error\nexpected: %v\nactual:   %v",
+				expectedErr,
+				err,
+			)
+		}
+	})
+
+	t.Run("child for parent that cannot be replaced by fee", func(t *testing.T) {
+		// The parent's request already pays its maximum fee.
+		hostChain, bitcoinChain := setup(t, 1000)
+
+		if err := validateCpfp(hostChain, bitcoinChain); err != nil {
+			t.Fatalf("unexpected error: [%v]", err)
+		}
+	})
+
+	t.Run("no replacement of parent with child in the mempool", func(t *testing.T) {
+		hostChain, bitcoinChain := setup(t, 5000)
+
+		bitcoinChain.mempool = append(bitcoinChain.mempool, childTransaction)
+
+		testutils.AssertBoolsEqual(
+			t,
+			"replaceable",
+			false,
+			IsRbfReplaceable(
+				transactions.walletPublicKeyHash,
+				parentHash,
+				hostChain,
+				bitcoinChain,
+			),
+		)
+
+		_, err := FindRbfCandidate(
+			transactions.walletPublicKeyHash,
+			parentHash,
+			0,
+			hostChain,
+			bitcoinChain,
+		)
+
+		expectedErr := fmt.Errorf(
+			"transaction's output is already spent by transaction [%s]",
+			childTransaction.Hash().Hex(bitcoin.ReversedByteOrder),
+		)
+		if !reflect.DeepEqual(expectedErr, err) {
+			t.Errorf(
+				"unexpected error\nexpected: %v\nactual:   %v",
+				expectedErr,
+				err,
+			)
+		}
+	})
+
+	t.Run("no replacement of parent with child signed by the wallet", func(t *testing.T) {
+		tracker := newWalletTransactionTracker(&mockPersistenceHandle{})
+
+		_, ok := tracker.spendingTransaction(
+			transactions.walletPublicKeyHash,
+			parentHash,
+		)
+		testutils.AssertBoolsEqual(t, "child tracked", false, ok)
+
+		err := tracker.track(
+			transactions.walletPublicKeyHash,
+			ActionRedemption,
+			childTransaction,
+			time.Now(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		child, ok := tracker.spendingTransaction(
+			transactions.walletPublicKeyHash,
+			parentHash,
+		)
+		testutils.AssertBoolsEqual(t, "child tracked", true, ok)
+		testutils.AssertStringsEqual(
+			t,
+			"child hash",
+			childTransaction.Hash().String(),
+			child.Transaction.Hash().String(),
+		)
+	})
+}
@@ -0,0 +1,206 @@
+package tbtc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+// depositRefundInputSequence is the sequence number of the deposit refund
+// transaction input. A sequence number lower than the maximum one is
+// required to enforce the transaction locktime the deposit script checks
+// using OP_CHECKLOCKTIMEVERIFY. The used value also signals the transaction
+// is replaceable so the refund fee can be bumped if necessary.
+const depositRefundInputSequence = 0xfffffffd
+
+// DepositRefundLocktime returns the refund locktime of the given deposit as
+// a number that can be used as a Bitcoin transaction locktime. The deposit
+// script stores the locktime as a 4-byte little-endian number.
+func DepositRefundLocktime(deposit *Deposit) uint32 {
+	return binary.LittleEndian.Uint32(deposit.RefundLocktime[:])
+}
+
+// BuildDepositRefundTransaction builds a transaction that returns the funds
+// of the given deposit to the given recipient output script, using the
+// refund branch of the deposit script. The funding output of the deposit
+// must be locked by the P2SH or P2WSH script of the deposit script
+// reconstructed from the given deposit data; otherwise, the deposit data
+// is wrong and the transaction would be rejected by the Bitcoin network.
+// The transaction is signed with the given refund private key which must
+// correspond to the deposit's refund public key hash. The returned transaction can be broadcast once the
+// deposit refund locktime passes, that is, once the median time of the last
+// 11 Bitcoin blocks is greater than the locktime, as checked by
+// CheckDepositRefundLocktime. The given fee is deducted from the deposited
+// amount.
+func BuildDepositRefundTransaction(
+	btcChain bitcoin.Chain,
+	deposit *Deposit,
+	refundPrivateKey *ecdsa.PrivateKey,
+	recipientOutputScript bitcoin.Script,
+	fee int64,
+) (*bitcoin.Transaction, error) {
+	if deposit.Utxo == nil {
+		return nil, fmt.Errorf("deposit UTXO is not set")
+	}
+
+	refundPublicKey := &refundPrivateKey.PublicKey
+	if bitcoin.PublicKeyHash(refundPublicKey) != deposit.RefundPublicKeyHash {
+		return nil, fmt.Errorf(
+			"refund private key does not match the deposit " +
+				"refund public key hash",
+		)
+	}
+
+	if fee <= 0 {
+		return nil, fmt.Errorf("fee must be greater than zero")
+	}
+
+	outputValue := deposit.Utxo.Value - fee
+	if outputValue <= 0 {
+		return nil, fmt.Errorf(
+			"fee [%v] exceeds the deposit value [%v]",
+			fee,
+			deposit.Utxo.Value,
+		)
+	}
+
+	depositScript, err := deposit.Script()
+	if err != nil {
+		return nil, fmt.Errorf("cannot construct deposit script: [%v]", err)
+	}
+
+	if err := verifyDepositFundingOutput(
+		btcChain,
+		deposit.Utxo.Outpoint,
+		depositScript,
+	); err != nil {
+		return nil, err
+	}
+
+	builder := bitcoin.NewTransactionBuilder(btcChain)
+
+	if err := builder.AddScriptHashInput(deposit.Utxo, depositScript); err != nil {
+		return nil, fmt.Errorf("cannot add deposit input: [%v]", err)
+	}
+
+	builder.AddOutput(&bitcoin.TransactionOutput{
+		Value:           outputValue,
+		PublicKeyScript: recipientOutputScript,
+	})
+
+	builder.SetLocktime(DepositRefundLocktime(deposit))
+	if err := builder.SetInputSequence(0, depositRefundInputSequence); err != nil {
+		return nil, fmt.Errorf("cannot set deposit input sequence: [%v]", err)
+	}
+
+	sigHashes, err := builder.ComputeSignatureHashes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot compute signature hashes: [%v]", err)
+	}
+
+	// The signature hash must be signed as a 32-byte digest, including
+	// leading zeros, if any.
+	signature, err := (*btcec.PrivateKey)(refundPrivateKey).Sign(
+		sigHashes[0].FillBytes(make([]byte, 32)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign deposit input: [%v]", err)
+	}
+
+	transaction, err := builder.AddSignatures(
+		[]*bitcoin.SignatureContainer{
+			{
+				R:         signature.R,
+				S:         signature.S,
+				PublicKey: refundPublicKey,
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot add signature: [%v]", err)
+	}
+
+	return transaction, nil
+}
+
+// verifyDepositFundingOutput checks whether the given deposit funding output
+// is locked by the P2SH or P2WSH script of the given deposit script.
+func verifyDepositFundingOutput(
+	btcChain bitcoin.Chain,
+	fundingOutpoint *bitcoin.TransactionOutpoint,
+	depositScript bitcoin.Script,
+) error {
+	fundingTransaction, err := btcChain.GetTransaction(
+		fundingOutpoint.TransactionHash,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot get deposit funding transaction: [%v]", err)
+	}
+
+	if int(fundingOutpoint.OutputIndex) >= len(fundingTransaction.Outputs) {
+		return fmt.Errorf(
+			"deposit funding output [%v] does not exist",
+			fundingOutpoint.OutputIndex,
+		)
+	}
+
+	fundingOutputScript :=
+		fundingTransaction.Outputs[fundingOutpoint.OutputIndex].PublicKeyScript
+
+	p2shScript, err := bitcoin.PayToScriptHash(
+		bitcoin.ScriptHash(depositScript),
+	)
+	if err != nil {
+		return fmt.Errorf("cannot build P2SH deposit script: [%v]", err)
+	}
+
+	p2wshScript, err := bitcoin.PayToWitnessScriptHash(
+		bitcoin.WitnessScriptHash(depositScript),
+	)
+	if err != nil {
+		return fmt.Errorf("cannot build P2WSH deposit script: [%v]", err)
+	}
+
+	if !bytes.Equal(fundingOutputScript, p2shScript) &&
+		!bytes.Equal(fundingOutputScript, p2wshScript) {
+		return fmt.Errorf(
+			"deposit funding output script [0x%x] does not match the "+
+				"deposit script reconstructed from the deposit data",
+			[]byte(fundingOutputScript),
+		)
+	}
+
+	return nil
+}
+
+// CheckDepositRefundLocktime checks whether the refund locktime of the given
+// deposit has passed, that is, whether the median time past of the Bitcoin
+// chain is greater than the locktime. Bitcoin nodes reject deposit refund
+// transactions broadcast before that moment.
+func CheckDepositRefundLocktime(
+	btcChain bitcoin.Chain,
+	deposit *Deposit,
+) error {
+	medianTimePast, err := bitcoin.MedianTimePast(btcChain)
+	if err != nil {
+		return fmt.Errorf("cannot get median time past: [%v]", err)
+	}
+
+	refundLocktime := DepositRefundLocktime(deposit)
+	if medianTimePast <= refundLocktime {
+		return fmt.Errorf(
+			"deposit refund locktime [%s] has not passed yet; "+
+				"median time past is [%s]",
+			time.Unix(int64(refundLocktime), 0).UTC(),
+			time.Unix(int64(medianTimePast), 0).UTC(),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,333 @@
+package tbtc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/chain"
+)
+
+func TestBuildDepositRefundTransaction(t *testing.T) {
+	var tests = map[string]struct {
+		witness bool
+	}{
+		"P2WSH deposit": {
+			witness: true,
+		},
+		"P2SH deposit": {
+			witness: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			btcChain := newLocalBitcoinChain()
+			refundPrivateKey := newTestRefundPrivateKey(t)
+
+			deposit, fundingTransaction := newTestRefundableDeposit(
+				t,
+				btcChain,
+				refundPrivateKey,
+				test.witness,
+			)
+
+			recipientOutputScript, err := bitcoin.PayToWitnessPublicKeyHash(
+				[20]byte{0x05},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			transaction, err := BuildDepositRefundTransaction(
+				btcChain,
+				deposit,
+				refundPrivateKey,
+				recipientOutputScript,
+				1000,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"locktime",
+				1700000000,
+				int(transaction.Locktime),
+			)
+			testutils.AssertIntsEqual(
+				t,
+				"input sequence",
+				depositRefundInputSequence,
+				int(transaction.Inputs[0].Sequence),
+			)
+			testutils.AssertIntsEqual(
+				t,
+				"output value",
+				int(deposit.Utxo.Value-1000),
+				int(transaction.Outputs[0].Value),
+			)
+			testutils.AssertBytesEqual(
+				t,
+				recipientOutputScript,
+				transaction.Outputs[0].PublicKeyScript,
+			)
+
+			// Execute the deposit script to make sure the refund branch
+			// unlocks the deposit, including the locktime check.
+			msgTx := wire.NewMsgTx(wire.TxVersion)
+			if err := msgTx.Deserialize(
+				bytes.NewReader(transaction.Serialize()),
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			engine, err := txscript.NewEngine(
+				fundingTransaction.Outputs[0].PublicKeyScript,
+				msgTx,
+				0,
+				txscript.StandardVerifyFlags,
+				nil,
+				txscript.NewTxSigHashes(msgTx),
+				deposit.Utxo.Value,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := engine.Execute(); err != nil {
+				t.Fatalf("deposit script execution failed: [%v]", err)
+			}
+		})
+	}
+}
+
+func TestBuildDepositRefundTransaction_Errors(t *testing.T) {
+	btcChain := newLocalBitcoinChain()
+	refundPrivateKey := newTestRefundPrivateKey(t)
+
+	deposit, fundingTransaction := newTestRefundableDeposit(
+		t,
+		btcChain,
+		refundPrivateKey,
+		true,
+	)
+
+	var tests = map[string]struct {
+		refundPrivateKey *ecdsa.PrivateKey
+		fee              int64
+		modifyDeposit    func(deposit *Deposit)
+		expectedErr      string
+	}{
+		"wrong refund key": {
+			refundPrivateKey: newTestRefundPrivateKey(t),
+			fee:              1000,
+			expectedErr: "refund private key does not match the deposit " +
+				"refund public key hash",
+		},
+		"zero fee": {
+			refundPrivateKey: refundPrivateKey,
+			fee:              0,
+			expectedErr:      "fee must be greater than zero",
+		},
+		"fee exceeding deposit value": {
+			refundPrivateKey: refundPrivateKey,
+			fee:              100000,
+			expectedErr:      "fee [100000] exceeds the deposit value [100000]",
+		},
+		"deposit data not matching the funding output": {
+			refundPrivateKey: refundPrivateKey,
+			fee:              1000,
+			modifyDeposit: func(deposit *Deposit) {
+				deposit.BlindingFactor[0]++
+			},
+			expectedErr: fmt.Sprintf(
+				"deposit funding output script [0x%x] does not match "+
+					"the deposit script reconstructed from the deposit data",
+				[]byte(fundingTransaction.Outputs[0].PublicKeyScript),
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			testDeposit := *deposit
+			if test.modifyDeposit != nil {
+				test.modifyDeposit(&testDeposit)
+			}
+
+			_, err := BuildDepositRefundTransaction(
+				btcChain,
+				&testDeposit,
+				test.refundPrivateKey,
+				bitcoin.Script{},
+				test.fee,
+			)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"error",
+				test.expectedErr,
+				err.Error(),
+			)
+		})
+	}
+}
+
+func TestCheckDepositRefundLocktime(t *testing.T) {
+	deposit := &Deposit{
+		// Little-endian encoding of 1700000000.
+		RefundLocktime: [4]byte{0x00, 0xf1, 0x53, 0x65},
+	}
+
+	var tests = map[string]struct {
+		// Times of the last 11 blocks. Their median is the median time past.
+		blockTimes  []uint32
+		expectedErr string
+	}{
+		"median time past after the locktime": {
+			blockTimes: []uint32{
+				1699999000, 1699999100, 1699999200, 1699999300, 1699999400,
+				1700000001,
+				1700000100, 1700000200, 1700000300, 1700000400, 1700000500,
+			},
+		},
+		"median time past equal to the locktime": {
+			blockTimes: []uint32{
+				1699999000, 1699999100, 1699999200, 1699999300, 1699999400,
+				1700000000,
+				1700000100, 1700000200, 1700000300, 1700000400, 1700000500,
+			},
+			expectedErr: "deposit refund locktime [2023-11-14 22:13:20 +0000 UTC] " +
+				"has not passed yet; median time past is " +
+				"[2023-11-14 22:13:20 +0000 UTC]",
+		},
+		"latest block time after the locktime": {
+			blockTimes: []uint32{
+				1699999000, 1699999100, 1699999200, 1699999300, 1699999400,
+				1699999500,
+				1699999600, 1699999700, 1699999800, 1699999900, 1700000500,
+			},
+			expectedErr: "deposit refund locktime [2023-11-14 22:13:20 +0000 UTC] " +
+				"has not passed yet; median time past is " +
+				"[2023-11-14 22:05:00 +0000 UTC]",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			btcChain := newLocalBitcoinChain()
+			for i, blockTime := range test.blockTimes {
+				btcChain.blockHeaders[uint(800000+i)] = &bitcoin.BlockHeader{
+					Time: blockTime,
+				}
+			}
+
+			err := CheckDepositRefundLocktime(btcChain, deposit)
+
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: [%v]", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"error",
+				test.expectedErr,
+				err.Error(),
+			)
+		})
+	}
+}
+
+func newTestRefundPrivateKey(t *testing.T) *ecdsa.PrivateKey {
+	privateKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return privateKey.ToECDSA()
+}
+
+// newTestRefundableDeposit creates a deposit whose refund public key hash
+// corresponds to the given refund private key and adds its funding
+// transaction to the given chain.
+func newTestRefundableDeposit(
+	t *testing.T,
+	btcChain *localBitcoinChain,
+	refundPrivateKey *ecdsa.PrivateKey,
+	witness bool,
+) (*Deposit, *bitcoin.Transaction) {
+	deposit := &Deposit{
+		Depositor:           chain.Address("934b98637ca318a4d6e7ca6ffd1690b8e77df637"),
+		BlindingFactor:      [8]byte{0xf9, 0xf0, 0xc9, 0x0d},
+		WalletPublicKeyHash: [20]byte{0x8d, 0xb5, 0x0e},
+		RefundPublicKeyHash: bitcoin.PublicKeyHash(&refundPrivateKey.PublicKey),
+		// Little-endian encoding of 1700000000.
+		RefundLocktime: [4]byte{0x00, 0xf1, 0x53, 0x65},
+	}
+
+	depositScript, err := deposit.Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var depositOutputScript bitcoin.Script
+	if witness {
+		depositOutputScript, err = bitcoin.PayToWitnessScriptHash(
+			bitcoin.WitnessScriptHash(depositScript),
+		)
+	} else {
+		depositOutputScript, err = bitcoin.PayToScriptHash(
+			bitcoin.ScriptHash(depositScript),
+		)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fundingTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: bitcoin.Hash{0x01},
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 100000, PublicKeyScript: depositOutputScript},
+		},
+	}
+
+	btcChain.transactions = append(btcChain.transactions, fundingTransaction)
+
+	deposit.Utxo = &bitcoin.UnspentTransactionOutput{
+		Outpoint: &bitcoin.TransactionOutpoint{
+			TransactionHash: fundingTransaction.Hash(),
+			OutputIndex:     0,
+		},
+		Value: 100000,
+	}
+
+	return deposit, fundingTransaction
+}
@@ -79,6 +79,10 @@ type depositSweepAction struct {
 	sweepingWallet      wallet
 	transactionExecutor *walletTransactionExecutor
 
+	// audit collects the audit trail of the action. Can be nil, in which
+	// case the action is not audited.
+	audit *walletActionAudit
+
 	proposal                     *DepositSweepProposal
 	proposalProcessingStartBlock uint64
 	proposalExpiryBlock          uint64
@@ -95,16 +99,20 @@ func newDepositSweepAction(
 	btcChain bitcoin.Chain,
 	sweepingWallet wallet,
 	signingExecutor walletSigningExecutor,
+	transactionTracker *walletTransactionTracker,
 	proposal *DepositSweepProposal,
 	proposalProcessingStartBlock uint64,
 	proposalExpiryBlock uint64,
 	waitForBlockFn waitForBlockFn,
+	audit *walletActionAudit,
 ) *depositSweepAction {
 	transactionExecutor := newWalletTransactionExecutor(
 		btcChain,
 		sweepingWallet,
 		signingExecutor,
+		transactionTracker,
 		waitForBlockFn,
+		audit,
 	)
 
 	return &depositSweepAction{
@@ -113,6 +121,7 @@ func newDepositSweepAction(
 		btcChain:                         btcChain,
 		sweepingWallet:                   sweepingWallet,
 		transactionExecutor:              transactionExecutor,
+		audit:                            audit,
 		proposal:                         proposal,
 		proposalProcessingStartBlock:     proposalProcessingStartBlock,
 		proposalExpiryBlock:              proposalExpiryBlock,
@@ -138,6 +147,7 @@ func (dsa *depositSweepAction) execute() error {
 		dsa.chain,
 		dsa.btcChain,
 	)
+	dsa.audit.recordValidation(err)
 	if err != nil {
 		return fmt.Errorf("validate proposal step failed: [%v]", err)
 	}
@@ -208,6 +218,7 @@ func (dsa *depositSweepAction) execute() error {
 
 	err = dsa.transactionExecutor.broadcastTransaction(
 		broadcastTxLogger,
+		ActionDepositSweep,
 		sweepTx,
 		dsa.broadcastTimeout,
 		dsa.broadcastCheckDelay,
@@ -93,6 +93,10 @@ type heartbeatAction struct {
 	expiryBlock uint64
 
 	waitForBlockFn waitForBlockFn
+
+	// audit collects the audit trail of the action. Can be nil, in which
+	// case the action is not audited.
+	audit *walletActionAudit
 }
 
 func newHeartbeatAction(
@@ -106,6 +110,7 @@ func newHeartbeatAction(
 	startBlock uint64,
 	expiryBlock uint64,
 	waitForBlockFn waitForBlockFn,
+	audit *walletActionAudit,
 ) *heartbeatAction {
 	return &heartbeatAction{
 		logger:                  logger,
@@ -118,6 +123,7 @@ func newHeartbeatAction(
 		startBlock:              startBlock,
 		expiryBlock:             expiryBlock,
 		waitForBlockFn:          waitForBlockFn,
+		audit:                   audit,
 	}
 }
 
@@ -149,6 +155,7 @@ func (ha *heartbeatAction) execute() error {
 	walletKey := hex.EncodeToString(walletPublicKeyBytes)
 
 	err = ha.chain.ValidateHeartbeatProposal(walletPublicKeyHash, ha.proposal)
+	ha.audit.recordValidation(err)
 	if err != nil {
 		return fmt.Errorf("heartbeat proposal is invalid: [%v]", err)
 	}
@@ -173,6 +180,11 @@ func (ha *heartbeatAction) execute() error {
 		messageToSign,
 		ha.startBlock,
 	)
+	ha.audit.recordSigning(
+		[]*big.Int{messageToSign},
+		[]*signingActivityReport{activityReport},
+		err,
+	)
 	if err != nil {
 		// Do not count this error as heartbeat inactivity failure. If the
 		// process returned an error here, that likely means the group signing
@@ -71,6 +71,7 @@ func TestHeartbeatAction_HappyPath(t *testing.T) {
 		func(ctx context.Context, blockHeight uint64) error {
 			return nil
 		},
+		nil,
 	)
 
 	err = action.execute()
@@ -150,6 +151,7 @@ func TestHeartbeatAction_OperatorUnstaking(t *testing.T) {
 		func(ctx context.Context, blockHeight uint64) error {
 			return nil
 		},
+		nil,
 	)
 
 	err = action.execute()
@@ -213,6 +215,7 @@ func TestHeartbeatAction_Failure_SigningError(t *testing.T) {
 		func(ctx context.Context, blockHeight uint64) error {
 			return nil
 		},
+		nil,
 	)
 
 	// Do not expect the execution to result in an error. Signing error does not
@@ -292,6 +295,7 @@ func TestHeartbeatAction_Failure_TooFewActiveOperators(t *testing.T) {
 		func(ctx context.Context, blockHeight uint64) error {
 			return nil
 		},
+		nil,
 	)
 
 	// Do not expect the execution to result in an error. Signing error does not
@@ -372,6 +376,7 @@ func TestHeartbeatAction_Failure_CounterExceeded(t *testing.T) {
 		func(ctx context.Context, blockHeight uint64) error {
 			return nil
 		},
+		nil,
 	)
 
 	// Do not expect the execution to result in an error. Signing error does not
@@ -453,6 +458,7 @@ func TestHeartbeatAction_Failure_InactivityExecutionFailure(t *testing.T) {
 		func(ctx context.Context, blockHeight uint64) error {
 			return nil
 		},
+		nil,
 	)
 
 	err = action.execute()
@@ -177,6 +177,7 @@ func setupInactivityClaimExecutorScenario(t *testing.T) (
 		localProvider,
 		keyStorePersistence,
 		&mockPersistenceHandle{},
+		nil,
 		generator.StartScheduler(),
 		&mockCoordinationProposalGenerator{},
 		Config{},
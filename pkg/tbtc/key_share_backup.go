@@ -0,0 +1,378 @@
+package tbtc
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/tss-lib/crypto"
+	"github.com/keep-network/keep-common/pkg/encryption"
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+)
+
+// KeyShareBundleVersion is the version of the key share bundle format
+// produced by ExportKeyShares.
+const KeyShareBundleVersion = 1
+
+// keyShareBundle is the outer, unencrypted structure of a key share bundle.
+// The version is kept in plain text so the bundle format can be determined
+// before the decryption.
+type keyShareBundle struct {
+	Version   int   `json:"version"`
+	CreatedAt int64 `json:"createdAt"`
+	// Signers holds the encrypted JSON array of marshaled signers.
+	Signers []byte `json:"signers"`
+}
+
+// KeyShareVerification is the verification outcome of a single signer held
+// by a key store or a key share bundle.
+type KeyShareVerification struct {
+	WalletPublicKeyHash [20]byte
+	MemberIndex         group.MemberIndex
+	// ShareValid is true if the secret of the private key share matches the
+	// member's public key share and the share's group public key matches the
+	// wallet public key.
+	ShareValid bool
+	// WalletArchived is true if the wallet was archived in the key store.
+	// Signers of archived wallets must not be restored.
+	WalletArchived bool
+}
+
+// ExportKeyShares produces an encrypted, versioned bundle of all signers
+// held by the given key store persistence. The bundle is encrypted with
+// the given password. The export fails if any of the signers cannot be
+// read, so a successfully produced bundle is a complete backup of the key
+// store.
+func ExportKeyShares(
+	keyStorePersistence persistence.ProtectedHandle,
+	password string,
+) ([]byte, error) {
+	signers, err := readSigners(keyStorePersistence)
+	if err != nil {
+		return nil, err
+	}
+
+	signersBytes := make([][]byte, len(signers))
+	for i, signer := range signers {
+		signersBytes[i], err = signer.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal %s: [%v]", signer, err)
+		}
+	}
+
+	plaintext, err := json.Marshal(signersBytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal signers: [%v]", err)
+	}
+
+	ciphertext, err := newKeyShareBundleBox(password).Encrypt(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encrypt signers: [%v]", err)
+	}
+
+	return json.Marshal(&keyShareBundle{
+		Version:   KeyShareBundleVersion,
+		CreatedAt: time.Now().Unix(),
+		Signers:   ciphertext,
+	})
+}
+
+// VerifyKeyShareBundle decrypts the given key share bundle and verifies all
+// signers it holds. Signers whose wallets were archived in the given key
+// store archive are reported as well.
+func VerifyKeyShareBundle(
+	bundle []byte,
+	password string,
+	keyStoreArchive persistence.RWHandle,
+) ([]*KeyShareVerification, error) {
+	signers, err := openKeyShareBundle(bundle, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifySigners(signers, keyStoreArchive)
+}
+
+// VerifyKeyStore verifies all signers held by the given key store
+// persistence.
+func VerifyKeyStore(
+	keyStorePersistence persistence.ProtectedHandle,
+	keyStoreArchive persistence.RWHandle,
+) ([]*KeyShareVerification, error) {
+	signers, err := readSigners(keyStorePersistence)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifySigners(signers, keyStoreArchive)
+}
+
+// ImportKeyShares restores signers from the given key share bundle into the
+// given key store persistence. The key store must be empty. Nothing is
+// restored if any of the bundled private key shares does not match its
+// wallet public key. Signers of wallets archived in the given key store
+// archive are skipped. The returned verification outcomes describe all
+// bundled signers.
+func ImportKeyShares(
+	keyStorePersistence persistence.ProtectedHandle,
+	keyStoreArchive persistence.RWHandle,
+	bundle []byte,
+	password string,
+) ([]*KeyShareVerification, error) {
+	existingSigners, err := readSigners(keyStorePersistence)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key store: [%v]", err)
+	}
+
+	if len(existingSigners) > 0 {
+		return nil, fmt.Errorf(
+			"key store is not empty; it holds [%v] signers",
+			len(existingSigners),
+		)
+	}
+
+	signers, err := openKeyShareBundle(bundle, password)
+	if err != nil {
+		return nil, err
+	}
+
+	verifications, err := verifySigners(signers, keyStoreArchive)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, verification := range verifications {
+		if !verification.ShareValid {
+			return nil, fmt.Errorf(
+				"private key share of member [%v] does not match "+
+					"wallet [0x%x]",
+				verification.MemberIndex,
+				verification.WalletPublicKeyHash,
+			)
+		}
+	}
+
+	walletStorage := newWalletStorage(keyStorePersistence)
+
+	for i, signer := range signers {
+		if verifications[i].WalletArchived {
+			logger.Warnf("skipping %s as its wallet was archived", signer)
+			continue
+		}
+
+		if err := walletStorage.saveSigner(signer); err != nil {
+			return nil, fmt.Errorf("cannot save %s: [%v]", signer, err)
+		}
+	}
+
+	return verifications, nil
+}
+
+// readSigners reads all signers held by the given key store persistence.
+// In contrast to walletStorage.loadSigners, it fails if any of the signers
+// cannot be read.
+func readSigners(
+	keyStorePersistence persistence.ProtectedHandle,
+) ([]*signer, error) {
+	descriptorsChan, errorsChan := keyStorePersistence.ReadAll()
+
+	var signers []*signer
+	var errs, readErrs []error
+
+	// Read from both channels at the same time as they are not buffered.
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for err := range errorsChan {
+			readErrs = append(readErrs, err)
+		}
+	}()
+
+	for descriptor := range descriptorsChan {
+		content, err := descriptor.Content()
+		if err != nil {
+			errs = append(errs, fmt.Errorf(
+				"could not get content from file [%v] in directory [%v]: [%v]",
+				descriptor.Name(),
+				descriptor.Directory(),
+				err,
+			))
+			continue
+		}
+
+		signer := &signer{}
+		if err := signer.Unmarshal(content); err != nil {
+			errs = append(errs, fmt.Errorf(
+				"could not unmarshal signer from file [%v] "+
+					"in directory [%v]: [%v]",
+				descriptor.Name(),
+				descriptor.Directory(),
+				err,
+			))
+			continue
+		}
+
+		signers = append(signers, signer)
+	}
+
+	wg.Wait()
+
+	errs = append(errs, readErrs...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("cannot read signers: %v", errs)
+	}
+
+	return signers, nil
+}
+
+// verifySigners checks whether private key shares of the given signers
+// match their wallet public keys and whether their wallets were archived
+// in the given key store archive.
+func verifySigners(
+	signers []*signer,
+	keyStoreArchive persistence.RWHandle,
+) ([]*KeyShareVerification, error) {
+	archivedWallets, err := readArchivedWallets(keyStoreArchive)
+	if err != nil {
+		return nil, err
+	}
+
+	verifications := make([]*KeyShareVerification, len(signers))
+	for i, signer := range signers {
+		walletPublicKey := signer.wallet.publicKey
+
+		verifications[i] = &KeyShareVerification{
+			WalletPublicKeyHash: bitcoin.PublicKeyHash(walletPublicKey),
+			MemberIndex:         signer.signingGroupMemberIndex,
+			ShareValid:          isPrivateKeyShareValid(signer),
+			WalletArchived:      archivedWallets[getWalletStorageKey(walletPublicKey)],
+		}
+	}
+
+	return verifications, nil
+}
+
+// isPrivateKeyShareValid checks whether the secret of the given signer's
+// private key share corresponds to the signer's public key share agreed
+// by the signing group during DKG, i.e. whether Xi·G equals the BigXj
+// entry of the signer. The group public key kept in the share must also
+// match the wallet public key. Comparing just the group public keys is not
+// enough as they are stored alongside the secret and stay intact if the
+// secret gets corrupted.
+func isPrivateKeyShareValid(signer *signer) bool {
+	data := signer.privateKeyShare.Data()
+	walletPublicKey := signer.wallet.publicKey
+
+	if data.ECDSAPub == nil ||
+		data.ECDSAPub.X().Cmp(walletPublicKey.X) != 0 ||
+		data.ECDSAPub.Y().Cmp(walletPublicKey.Y) != 0 {
+		return false
+	}
+
+	if data.Xi == nil || data.ShareID == nil ||
+		len(data.Ks) != len(data.BigXj) {
+		return false
+	}
+
+	ownIndex := -1
+	for j, kj := range data.Ks {
+		if kj != nil && kj.Cmp(data.ShareID) == 0 {
+			ownIndex = j
+			break
+		}
+	}
+
+	if ownIndex < 0 || data.BigXj[ownIndex] == nil {
+		return false
+	}
+
+	ownPublicKeyShare := crypto.ScalarBaseMult(tecdsa.Curve, data.Xi)
+
+	return ownPublicKeyShare.Equals(data.BigXj[ownIndex])
+}
+
+// readArchivedWallets returns storage keys of all wallets archived in the
+// given key store archive.
+func readArchivedWallets(
+	keyStoreArchive persistence.RWHandle,
+) (map[string]bool, error) {
+	descriptorsChan, errorsChan := keyStoreArchive.ReadAll()
+
+	archivedWallets := make(map[string]bool)
+	var errs []error
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for err := range errorsChan {
+			errs = append(errs, err)
+		}
+	}()
+
+	for descriptor := range descriptorsChan {
+		archivedWallets[descriptor.Directory()] = true
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("cannot read archived wallets: %v", errs)
+	}
+
+	return archivedWallets, nil
+}
+
+// openKeyShareBundle decrypts the given key share bundle and returns the
+// signers it holds.
+func openKeyShareBundle(bundle []byte, password string) ([]*signer, error) {
+	outer := &keyShareBundle{}
+	if err := json.Unmarshal(bundle, outer); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal key share bundle: [%v]", err)
+	}
+
+	if outer.Version != KeyShareBundleVersion {
+		return nil, fmt.Errorf(
+			"unsupported key share bundle version [%v]",
+			outer.Version,
+		)
+	}
+
+	plaintext, err := newKeyShareBundleBox(password).Decrypt(outer.Signers)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt key share bundle: [%v]", err)
+	}
+
+	var signersBytes [][]byte
+	if err := json.Unmarshal(plaintext, &signersBytes); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal signers: [%v]", err)
+	}
+
+	signers := make([]*signer, len(signersBytes))
+	for i, signerBytes := range signersBytes {
+		signers[i] = &signer{}
+		if err := signers[i].Unmarshal(signerBytes); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal signer [%v]: [%v]", i, err)
+		}
+	}
+
+	return signers, nil
+}
+
+// newKeyShareBundleBox creates the encryption box for key share bundles.
+// The key is derived from the password the same way as for the encrypted
+// persistence.
+func newKeyShareBundleBox(password string) encryption.Box {
+	return encryption.NewBox(sha256.Sum256([]byte(password)))
+}
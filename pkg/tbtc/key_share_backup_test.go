@@ -0,0 +1,334 @@
+package tbtc
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/bnb-chain/tss-lib/crypto"
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+)
+
+func TestExportImportKeyShares(t *testing.T) {
+	signer := createMockSigner(t)
+
+	keyStorePersistence := createMockKeyStorePersistence(t, signer)
+
+	bundle, err := ExportKeyShares(keyStorePersistence, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifications, err := VerifyKeyShareBundle(
+		bundle,
+		"password",
+		&mockPersistenceHandle{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "verifications", 1, len(verifications))
+	assertKeyShareVerification(
+		t,
+		&KeyShareVerification{
+			WalletPublicKeyHash: bitcoin.PublicKeyHash(signer.wallet.publicKey),
+			MemberIndex:         signer.signingGroupMemberIndex,
+			ShareValid:          true,
+			WalletArchived:      false,
+		},
+		verifications[0],
+	)
+
+	restoredKeyStore := &mockPersistenceHandle{}
+
+	_, err = ImportKeyShares(
+		restoredKeyStore,
+		&mockPersistenceHandle{},
+		bundle,
+		"password",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restoredSigners, err := readSigners(restoredKeyStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "restored signers", 1, len(restoredSigners))
+
+	if !signer.wallet.publicKey.Equal(restoredSigners[0].wallet.publicKey) {
+		t.Errorf("unexpected restored signer wallet")
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"restored signer member index",
+		int(signer.signingGroupMemberIndex),
+		int(restoredSigners[0].signingGroupMemberIndex),
+	)
+
+	// Importing into a non-empty key store must not be possible.
+	_, err = ImportKeyShares(
+		restoredKeyStore,
+		&mockPersistenceHandle{},
+		bundle,
+		"password",
+	)
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"key store is not empty; it holds [1] signers",
+		err.Error(),
+	)
+}
+
+func TestImportKeyShares_ArchivedWallet(t *testing.T) {
+	signer := createMockSigner(t)
+
+	bundle, err := ExportKeyShares(
+		createMockKeyStorePersistence(t, signer),
+		"password",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyStoreArchive := &mockPersistenceHandle{
+		saved: []persistence.DataDescriptor{
+			&mockDescriptor{
+				name:      "membership_1",
+				directory: getWalletStorageKey(signer.wallet.publicKey),
+			},
+		},
+	}
+
+	restoredKeyStore := &mockPersistenceHandle{}
+
+	verifications, err := ImportKeyShares(
+		restoredKeyStore,
+		keyStoreArchive,
+		bundle,
+		"password",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "verifications", 1, len(verifications))
+	assertKeyShareVerification(
+		t,
+		&KeyShareVerification{
+			WalletPublicKeyHash: bitcoin.PublicKeyHash(signer.wallet.publicKey),
+			MemberIndex:         signer.signingGroupMemberIndex,
+			ShareValid:          true,
+			WalletArchived:      true,
+		},
+		verifications[0],
+	)
+
+	testutils.AssertIntsEqual(
+		t,
+		"restored signers",
+		0,
+		len(restoredKeyStore.saved),
+	)
+}
+
+func TestImportKeyShares_InvalidShare(t *testing.T) {
+	signer := createMockSigner(t)
+
+	// Replace the wallet public key so it no longer matches the share.
+	otherKey, err := ecdsa.GenerateKey(tecdsa.Curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.wallet.publicKey = &otherKey.PublicKey
+
+	bundle, err := ExportKeyShares(
+		createMockKeyStorePersistence(t, signer),
+		"password",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifications, err := VerifyKeyStore(
+		createMockKeyStorePersistence(t, signer),
+		&mockPersistenceHandle{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "verifications", 1, len(verifications))
+	if verifications[0].ShareValid {
+		t.Errorf("share should be reported as invalid")
+	}
+
+	restoredKeyStore := &mockPersistenceHandle{}
+
+	_, err = ImportKeyShares(
+		restoredKeyStore,
+		&mockPersistenceHandle{},
+		bundle,
+		"password",
+	)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"restored signers",
+		0,
+		len(restoredKeyStore.saved),
+	)
+}
+
+func TestIsPrivateKeyShareValid(t *testing.T) {
+	var tests = map[string]struct {
+		modifySigner  func(signer *signer)
+		expectedValid bool
+	}{
+		"valid share": {
+			modifySigner:  func(signer *signer) {},
+			expectedValid: true,
+		},
+		"corrupted secret": {
+			modifySigner: func(signer *signer) {
+				data := signer.privateKeyShare.Data()
+				data.Xi = new(big.Int).Add(data.Xi, big.NewInt(1))
+				signer.privateKeyShare = tecdsa.NewPrivateKeyShare(data)
+			},
+			expectedValid: false,
+		},
+		"corrupted own public key share": {
+			modifySigner: func(signer *signer) {
+				data := signer.privateKeyShare.Data()
+				bigXj := make([]*crypto.ECPoint, len(data.BigXj))
+				copy(bigXj, data.BigXj)
+				for j, kj := range data.Ks {
+					if kj.Cmp(data.ShareID) == 0 {
+						bigXj[j] = crypto.ScalarBaseMult(
+							tecdsa.Curve,
+							big.NewInt(1),
+						)
+					}
+				}
+				data.BigXj = bigXj
+				signer.privateKeyShare = tecdsa.NewPrivateKeyShare(data)
+			},
+			expectedValid: false,
+		},
+		"share of another wallet": {
+			modifySigner: func(signer *signer) {
+				otherKey, err := ecdsa.GenerateKey(tecdsa.Curve, rand.Reader)
+				if err != nil {
+					t.Fatal(err)
+				}
+				signer.wallet.publicKey = &otherKey.PublicKey
+			},
+			expectedValid: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			signer := createMockSigner(t)
+			test.modifySigner(signer)
+
+			testutils.AssertBoolsEqual(
+				t,
+				"share validity",
+				test.expectedValid,
+				isPrivateKeyShareValid(signer),
+			)
+		})
+	}
+}
+
+func TestVerifyKeyShareBundle_Errors(t *testing.T) {
+	bundle, err := ExportKeyShares(
+		createMockKeyStorePersistence(t, createMockSigner(t)),
+		"password",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		bundle      []byte
+		password    string
+		expectedErr string
+	}{
+		"wrong password": {
+			bundle:   bundle,
+			password: "wrong",
+			expectedErr: "cannot decrypt key share bundle: " +
+				"[symmetric key decryption failed]",
+		},
+		"unsupported version": {
+			bundle:      []byte(`{"version":2,"signers":""}`),
+			password:    "password",
+			expectedErr: "unsupported key share bundle version [2]",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := VerifyKeyShareBundle(
+				test.bundle,
+				test.password,
+				&mockPersistenceHandle{},
+			)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"error",
+				test.expectedErr,
+				err.Error(),
+			)
+		})
+	}
+}
+
+func assertKeyShareVerification(
+	t *testing.T,
+	expected *KeyShareVerification,
+	actual *KeyShareVerification,
+) {
+	testutils.AssertBytesEqual(
+		t,
+		expected.WalletPublicKeyHash[:],
+		actual.WalletPublicKeyHash[:],
+	)
+	testutils.AssertIntsEqual(
+		t,
+		"member index",
+		int(expected.MemberIndex),
+		int(actual.MemberIndex),
+	)
+	testutils.AssertBoolsEqual(
+		t,
+		"share valid",
+		expected.ShareValid,
+		actual.ShareValid,
+	)
+	testutils.AssertBoolsEqual(
+		t,
+		"wallet archived",
+		expected.WalletArchived,
+		actual.WalletArchived,
+	)
+}
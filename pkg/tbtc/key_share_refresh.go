@@ -0,0 +1,646 @@
+package tbtc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/bnb-chain/tss-lib/ecdsa/keygen"
+	"github.com/ipfs/go-log/v2"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/generator"
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/protocol/announcer"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+	"github.com/keep-network/keep-core/pkg/tecdsa/resharing"
+)
+
+const (
+	// keyShareRefreshProposalValidityBlocks determines the key share refresh
+	// proposal validity time expressed in blocks. In other words, this is the
+	// worst-case time for a key share refresh during which the wallet is busy
+	// and cannot take another actions. It includes the resharing,
+	// the confirmation of refreshed key shares, and the agreement on them. Resharing is significantly
+	// more expensive than signing as every member runs two TSS parties and
+	// generates new Paillier proofs. The value of 1200 blocks is roughly
+	// 4 hours, assuming 12 seconds per block.
+	keyShareRefreshProposalValidityBlocks = 1200
+	// keyShareRefreshConfirmationBlocks determines the duration of the
+	// confirmation phase that follows the resharing. During that phase,
+	// members sign a heartbeat message with refreshed key shares to prove
+	// they are usable. The duration must be enough for all signing attempts
+	// of the signing executor. The value of 250 blocks is roughly 50 minutes,
+	// assuming 12 seconds per block.
+	keyShareRefreshConfirmationBlocks = 250
+	// keyShareRefreshAgreementBlocks determines the duration of the agreement
+	// phase that follows the confirmation. During that phase, members
+	// broadcast the heartbeat signature they saw and wait for the signature
+	// from all other members. The value of 25 blocks is roughly 5 minutes,
+	// assuming 12 seconds per block.
+	keyShareRefreshAgreementBlocks = 25
+	// keyShareRefreshTimeoutSafetyMarginBlocks determines the duration of the
+	// safety margin that must be preserved between the agreement timeout
+	// and the timeout of the entire key share refresh action. This safety
+	// margin gives enough time to persist refreshed key shares before the
+	// wallet takes another action using them. The value of 25 blocks is
+	// roughly 5 minutes, assuming 12 seconds per block.
+	keyShareRefreshTimeoutSafetyMarginBlocks = 25
+	// keyShareRefreshRollbackSigningFailures determines the number of
+	// consecutive signing failures of refreshed key shares after which the
+	// wallet rolls back to its previous key shares. Refreshed key shares
+	// are considered healthy once they produce the first signature.
+	keyShareRefreshRollbackSigningFailures = 3
+)
+
+// errKeyShareRefreshExecutorBusy is an error returned when the key share
+// refresh executor cannot execute the refresh due to another refresh
+// execution in progress.
+var errKeyShareRefreshExecutorBusy = fmt.Errorf(
+	"key share refresh executor is busy",
+)
+
+// KeyShareRefreshProposal represents a key share refresh proposal issued by
+// a wallet's coordination leader. The proposal asks the wallet signing group
+// to replace their private key shares with fresh ones corresponding to the
+// same wallet public key. The proposal does not carry any data.
+type KeyShareRefreshProposal struct{}
+
+func (ksrp *KeyShareRefreshProposal) ActionType() WalletActionType {
+	return ActionKeyShareRefresh
+}
+
+func (ksrp *KeyShareRefreshProposal) ValidityBlocks() uint64 {
+	return keyShareRefreshProposalValidityBlocks
+}
+
+// keyShareRefreshExecutor encapsulates the logic of refreshing private key
+// shares of wallet signers controlled by the node.
+type keyShareRefreshExecutor struct {
+	lock *semaphore.Weighted
+
+	signers             []*signer
+	broadcastChannel    net.BroadcastChannel
+	membershipValidator *group.MembershipValidator
+	groupParameters     *GroupParameters
+	protocolLatch       *generator.ProtocolLatch
+
+	// preParamsFn generates fresh TSS pre-parameters required by the new
+	// committee party of the resharing protocol.
+	preParamsFn func(ctx context.Context) (*keygen.LocalPreParams, error)
+
+	getCurrentBlockFn getCurrentBlockFn
+	waitForBlockFn    waitForBlockFn
+}
+
+func newKeyShareRefreshExecutor(
+	signers []*signer,
+	broadcastChannel net.BroadcastChannel,
+	membershipValidator *group.MembershipValidator,
+	groupParameters *GroupParameters,
+	protocolLatch *generator.ProtocolLatch,
+	preParamsFn func(ctx context.Context) (*keygen.LocalPreParams, error),
+	getCurrentBlockFn getCurrentBlockFn,
+	waitForBlockFn waitForBlockFn,
+) *keyShareRefreshExecutor {
+	return &keyShareRefreshExecutor{
+		lock:                semaphore.NewWeighted(1),
+		signers:             signers,
+		broadcastChannel:    broadcastChannel,
+		membershipValidator: membershipValidator,
+		groupParameters:     groupParameters,
+		protocolLatch:       protocolLatch,
+		preParamsFn:         preParamsFn,
+		getCurrentBlockFn:   getCurrentBlockFn,
+		waitForBlockFn:      waitForBlockFn,
+	}
+}
+
+// refresh runs the resharing protocol for all signers controlled by the node,
+// starting at the given block. The protocol is retried until it succeeds or
+// the given context is done. It returns new signers holding refreshed
+// private key shares. New signers are returned only if the protocol
+// succeeded for all controlled signers; the executor does not persist them.
+func (ksre *keyShareRefreshExecutor) refresh(
+	ctx context.Context,
+	startBlock uint64,
+) ([]*signer, error) {
+	if lockAcquired := ksre.lock.TryAcquire(1); !lockAcquired {
+		return nil, errKeyShareRefreshExecutorBusy
+	}
+	defer ksre.lock.Release(1)
+
+	wallet := ksre.wallet()
+
+	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal wallet public key: [%v]", err)
+	}
+
+	// The session ID must be unique for the given wallet so the start block
+	// of the action is a good ingredient.
+	sessionID := fmt.Sprintf(
+		"%x-%v",
+		bitcoin.PublicKeyHash(wallet.publicKey),
+		startBlock,
+	)
+
+	execLogger := logger.With(
+		zap.String("wallet", fmt.Sprintf("0x%x", walletPublicKeyBytes)),
+		zap.String("sessionID", sessionID),
+	)
+
+	// Generate fresh pre-parameters upfront. There is no point to start the
+	// protocol if some of the controlled signers cannot participate.
+	// Pre-parameters are not taken from the DKG pool as they are scarce and
+	// their lack prevents the node from joining DKG. Attempts whose
+	// announcement phase passes during the generation are just skipped
+	// by the retry loop.
+	preParams := make([]*keygen.LocalPreParams, len(ksre.signers))
+	for i, signer := range ksre.signers {
+		preParams[i], err = ksre.preParamsFn(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot generate pre-parameters for signer [%v]: [%w]",
+				signer.signingGroupMemberIndex,
+				err,
+			)
+		}
+	}
+
+	var (
+		resultsMutex sync.Mutex
+		newSigners   []*signer
+		errs         []error
+	)
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(ksre.signers))
+
+	for i, currentSigner := range ksre.signers {
+		go func(signer *signer, preParams *keygen.LocalPreParams) {
+			ksre.protocolLatch.Lock()
+			defer ksre.protocolLatch.Unlock()
+
+			defer wg.Done()
+
+			announcer := announcer.New(
+				fmt.Sprintf("%v-%v", ProtocolName, "refresh"),
+				ksre.broadcastChannel,
+				ksre.membershipValidator,
+			)
+
+			retryLoop := newKeyShareRefreshRetryLoop(
+				execLogger,
+				sessionID,
+				startBlock,
+				signer.signingGroupMemberIndex,
+				wallet.signingGroupOperators,
+				ksre.groupParameters,
+				announcer,
+			)
+
+			result, err := retryLoop.start(
+				ctx,
+				ksre.waitForBlockFn,
+				ksre.getCurrentBlockFn,
+				func(attempt *keyShareRefreshAttemptParams) (*resharing.Result, error) {
+					attemptLogger := execLogger.With(
+						zap.Uint("attemptNumber", attempt.number),
+						zap.Uint64("attemptStartBlock", attempt.startBlock),
+						zap.Uint64("attemptTimeoutBlock", attempt.timeoutBlock),
+					)
+
+					attemptLogger.Infof(
+						"[member:%v] starting key share refresh with "+
+							"old committee [%v]",
+						signer.signingGroupMemberIndex,
+						attempt.oldCommitteeMembersIndexes,
+					)
+
+					// Set up the attempt timeout signal. The context is
+					// not canceled earlier, even if the execution
+					// succeeded. This is needed to ensure all protocol
+					// participants, even the slowest one, have a chance
+					// to receive all messages sent by this member,
+					// especially the result commitment.
+					attemptCtx, _ := withCancelOnBlock(
+						ctx,
+						attempt.timeoutBlock,
+						ksre.waitForBlockFn,
+					)
+
+					return resharing.Execute(
+						attemptCtx,
+						attemptLogger,
+						fmt.Sprintf("%v-%v", sessionID, attempt.number),
+						signer.signingGroupMemberIndex,
+						attempt.oldCommitteeMembersIndexes,
+						signer.privateKeyShare,
+						preParams,
+						wallet.groupSize(),
+						wallet.groupDishonestThreshold(
+							ksre.groupParameters.HonestThreshold,
+						),
+						ksre.broadcastChannel,
+						ksre.membershipValidator,
+					)
+				},
+			)
+
+			resultsMutex.Lock()
+			defer resultsMutex.Unlock()
+
+			if err != nil {
+				execLogger.Errorf(
+					"[member:%v] all retries for the key share refresh "+
+						"failed; giving up: [%v]",
+					signer.signingGroupMemberIndex,
+					err,
+				)
+
+				errs = append(
+					errs,
+					fmt.Errorf(
+						"member [%v]: [%w]",
+						signer.signingGroupMemberIndex,
+						err,
+					),
+				)
+				return
+			}
+
+			execLogger.Infof(
+				"[member:%v] key share refresh completed",
+				signer.signingGroupMemberIndex,
+			)
+
+			newSigners = append(
+				newSigners,
+				newSigner(
+					wallet.publicKey,
+					wallet.signingGroupOperators,
+					signer.signingGroupMemberIndex,
+					result.PrivateKeyShare,
+				),
+			)
+		}(currentSigner, preParams[i])
+	}
+
+	// Wait until all controlled signers complete their routine.
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf(
+			"key share refresh failed for [%v] out of [%v] signers; "+
+				"first error: [%w]",
+			len(errs),
+			len(ksre.signers),
+			errs[0],
+		)
+	}
+
+	sort.Slice(newSigners, func(i, j int) bool {
+		return newSigners[i].signingGroupMemberIndex <
+			newSigners[j].signingGroupMemberIndex
+	})
+
+	return newSigners, nil
+}
+
+// agree broadcasts the given heartbeat signature of the given message on
+// behalf of all signers controlled by the node and waits until all signing
+// group members broadcast the same. The function blocks until the given
+// context is done. It returns an error if not all members confirmed they
+// saw the signature.
+func (ksre *keyShareRefreshExecutor) agree(
+	ctx context.Context,
+	message *big.Int,
+	signature *tecdsa.Signature,
+) error {
+	wallet := ksre.wallet()
+
+	memberIndexes := make([]group.MemberIndex, len(ksre.signers))
+	for i, signer := range ksre.signers {
+		memberIndexes[i] = signer.signingGroupMemberIndex
+	}
+
+	confirmationCheck := newKeyShareRefreshConfirmationCheck(
+		wallet.publicKey,
+		wallet.groupSize(),
+		ksre.broadcastChannel,
+		ksre.membershipValidator,
+	)
+
+	return confirmationCheck.run(ctx, memberIndexes, message, signature)
+}
+
+// generateKeyShareRefreshPreParams generates fresh TSS pre-parameters for
+// the new committee party of the resharing protocol.
+func generateKeyShareRefreshPreParams(
+	ctx context.Context,
+) (*keygen.LocalPreParams, error) {
+	return keygen.GeneratePreParamsWithContext(ctx)
+}
+
+func (ksre *keyShareRefreshExecutor) wallet() wallet {
+	// All signers belong to one wallet. Take that wallet from the
+	// first signer.
+	return ksre.signers[0].wallet
+}
+
+// walletKeyShareRefreshExecutor is an interface meant to decouple the specific
+// implementation of the key share refresh executor from the key share
+// refresh action.
+type walletKeyShareRefreshExecutor interface {
+	refresh(ctx context.Context, startBlock uint64) ([]*signer, error)
+	agree(
+		ctx context.Context,
+		message *big.Int,
+		signature *tecdsa.Signature,
+	) error
+}
+
+// keyShareRefreshSigningExecutor is an interface meant to decouple the
+// specific implementation of the signing executor from the key share refresh
+// action.
+type keyShareRefreshSigningExecutor interface {
+	sign(
+		ctx context.Context,
+		message *big.Int,
+		startBlock uint64,
+	) (*tecdsa.Signature, *signingActivityReport, uint64, error)
+}
+
+// newSigningExecutorFn represents a function creating a signing executor
+// operating on the given signers.
+type newSigningExecutorFn func(
+	signers []*signer,
+) (keyShareRefreshSigningExecutor, error)
+
+// replaceSignersFn represents a function replacing signers of the given
+// wallet with the given ones.
+type replaceSignersFn func(wallet wallet, signers []*signer) error
+
+// keyShareRefreshAction is a walletAction implementation handling key share
+// refresh requests from the wallet coordinator.
+//
+// Resharing alone does not guarantee all members obtained refreshed key
+// shares; a member may miss some protocol messages before its attempt times
+// out. That is why refreshed key shares are kept aside of the current ones
+// until they are confirmed by signing a heartbeat message with them. Such
+// a signature can be produced only if at least the honest threshold of
+// members holds refreshed key shares. However, some members may not see
+// the signature, for example, if they miss signing done messages. Switching
+// key shares only on some members would split the signing group so, members
+// broadcast the signature they saw and replace the current key shares only
+// if the signature is seen by all members. Otherwise, refreshed key
+// shares are dropped and the wallet keeps using the current ones.
+//
+// Replaced key shares are still kept by the wallet registry until refreshed
+// ones produce their first signature. This lets members roll back together
+// if refreshed key shares turn out to be unusable; see
+// keyShareRefreshRollbackSigningFailures.
+type keyShareRefreshAction struct {
+	logger log.StandardLogger
+	chain  Chain
+
+	executingWallet      wallet
+	refreshExecutor      walletKeyShareRefreshExecutor
+	newSigningExecutorFn newSigningExecutorFn
+	replaceSignersFn     replaceSignersFn
+
+	proposal *KeyShareRefreshProposal
+
+	startBlock  uint64
+	expiryBlock uint64
+
+	waitForBlockFn waitForBlockFn
+
+	// audit collects the audit trail of the action. Can be nil, in which
+	// case the action is not audited.
+	audit *walletActionAudit
+}
+
+func newKeyShareRefreshAction(
+	logger log.StandardLogger,
+	chain Chain,
+	executingWallet wallet,
+	refreshExecutor walletKeyShareRefreshExecutor,
+	newSigningExecutorFn newSigningExecutorFn,
+	replaceSignersFn replaceSignersFn,
+	proposal *KeyShareRefreshProposal,
+	startBlock uint64,
+	expiryBlock uint64,
+	waitForBlockFn waitForBlockFn,
+	audit *walletActionAudit,
+) *keyShareRefreshAction {
+	return &keyShareRefreshAction{
+		logger:               logger,
+		chain:                chain,
+		executingWallet:      executingWallet,
+		refreshExecutor:      refreshExecutor,
+		newSigningExecutorFn: newSigningExecutorFn,
+		replaceSignersFn:     replaceSignersFn,
+		proposal:             proposal,
+		startBlock:           startBlock,
+		expiryBlock:          expiryBlock,
+		waitForBlockFn:       waitForBlockFn,
+		audit:                audit,
+	}
+}
+
+func (ksra *keyShareRefreshAction) execute() error {
+	walletPublicKeyHash := bitcoin.PublicKeyHash(ksra.wallet().publicKey)
+
+	walletChainData, err := ksra.chain.GetWallet(walletPublicKeyHash)
+	if err != nil {
+		return fmt.Errorf("cannot get wallet's chain data: [%v]", err)
+	}
+
+	if walletChainData.State != StateLive {
+		err := fmt.Errorf(
+			"wallet is in [%v] state while key share refresh requires "+
+				"the Live state",
+			walletChainData.State,
+		)
+		ksra.audit.recordValidation(err)
+		return err
+	}
+
+	ksra.audit.recordValidation(nil)
+
+	// Just in case. This should never happen.
+	if ksra.expiryBlock < keyShareRefreshTimeoutSafetyMarginBlocks+
+		keyShareRefreshAgreementBlocks+
+		keyShareRefreshConfirmationBlocks {
+		return fmt.Errorf("invalid proposal expiry block")
+	}
+
+	agreementTimeoutBlock := ksra.expiryBlock -
+		keyShareRefreshTimeoutSafetyMarginBlocks
+	agreementStartBlock := agreementTimeoutBlock -
+		keyShareRefreshAgreementBlocks
+	confirmationStartBlock := agreementStartBlock -
+		keyShareRefreshConfirmationBlocks
+
+	refreshCtx, cancelRefreshCtx := withCancelOnBlock(
+		context.Background(),
+		confirmationStartBlock,
+		ksra.waitForBlockFn,
+	)
+	defer cancelRefreshCtx()
+
+	signers, err := ksra.refreshExecutor.refresh(refreshCtx, ksra.startBlock)
+	if err != nil {
+		return fmt.Errorf("key share refresh process errored out: [%v]", err)
+	}
+
+	message, signature, err := ksra.confirm(
+		signers,
+		confirmationStartBlock,
+		agreementStartBlock,
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"refreshed key shares not confirmed; dropping them and "+
+				"keeping the current ones: [%v]",
+			err,
+		)
+	}
+
+	agreementCtx, cancelAgreementCtx := withCancelOnBlock(
+		context.Background(),
+		agreementTimeoutBlock,
+		ksra.waitForBlockFn,
+	)
+	defer cancelAgreementCtx()
+
+	err = ksra.refreshExecutor.agree(agreementCtx, message, signature)
+	if err != nil {
+		return fmt.Errorf(
+			"refreshed key shares not confirmed by all members; dropping "+
+				"them and keeping the current ones: [%v]",
+			err,
+		)
+	}
+
+	err = ksra.replaceSignersFn(ksra.wallet(), signers)
+	if err != nil {
+		return fmt.Errorf("cannot replace wallet signers: [%v]", err)
+	}
+
+	ksra.logger.Infof(
+		"key shares of [%v] signers refreshed successfully",
+		len(signers),
+	)
+
+	return nil
+}
+
+// confirm signs a heartbeat message with the given signers holding refreshed
+// key shares, starting at the given block and timing out at the given
+// timeout block. The signing protocol produces a signature matching the
+// wallet public key only if at least the honest threshold of members holds
+// refreshed key shares. Members who did not participate in signing learn
+// about the signature from signing done messages. The signed message and
+// the signature are returned so members can agree on them.
+func (ksra *keyShareRefreshAction) confirm(
+	signers []*signer,
+	startBlock uint64,
+	timeoutBlock uint64,
+) (*big.Int, *tecdsa.Signature, error) {
+	signingExecutor, err := ksra.newSigningExecutorFn(signers)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"cannot create signing executor: [%v]",
+			err,
+		)
+	}
+
+	message := keyShareRefreshConfirmationMessage(
+		bitcoin.PublicKeyHash(ksra.wallet().publicKey),
+		startBlock,
+	)
+	messageBytes := bitcoin.ComputeHash(message[:])
+	messageToSign := new(big.Int).SetBytes(messageBytes[:])
+
+	confirmationCtx, cancelConfirmationCtx := withCancelOnBlock(
+		context.Background(),
+		timeoutBlock,
+		ksra.waitForBlockFn,
+	)
+	defer cancelConfirmationCtx()
+
+	signature, activityReport, _, err := signingExecutor.sign(
+		confirmationCtx,
+		messageToSign,
+		startBlock,
+	)
+	ksra.audit.recordSigning(
+		[]*big.Int{messageToSign},
+		[]*signingActivityReport{activityReport},
+		err,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"cannot sign heartbeat message: [%v]",
+			err,
+		)
+	}
+
+	if !ecdsa.Verify(
+		ksra.wallet().publicKey,
+		messageBytes[:],
+		signature.R,
+		signature.S,
+	) {
+		return nil, nil, fmt.Errorf(
+			"heartbeat signature does not match the wallet public key",
+		)
+	}
+
+	ksra.logger.Infof(
+		"refreshed key shares confirmed with signature [%s] "+
+			"for heartbeat message [0x%x]",
+		signature,
+		message[:],
+	)
+
+	return messageToSign, signature, nil
+}
+
+// keyShareRefreshConfirmationMessage builds the heartbeat message signed
+// with refreshed key shares of the given wallet to confirm them. The message
+// has the same format as the one of the heartbeat action so, it is
+// recognized as a valid heartbeat by the Bridge.
+func keyShareRefreshConfirmationMessage(
+	walletPublicKeyHash [20]byte,
+	startBlock uint64,
+) [16]byte {
+	blockBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockBytes, startBlock)
+
+	hash := sha256.Sum256(append(walletPublicKeyHash[:], blockBytes...))
+
+	return [16]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		hash[0], hash[1], hash[2], hash[3], hash[4], hash[5], hash[6], hash[7],
+	}
+}
+
+func (ksra *keyShareRefreshAction) wallet() wallet {
+	return ksra.executingWallet
+}
+
+func (ksra *keyShareRefreshAction) actionType() WalletActionType {
+	return ActionKeyShareRefresh
+}
@@ -0,0 +1,163 @@
+package tbtc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+)
+
+// keyShareRefreshConfirmationReceiveBuffer is a buffer for messages received
+// from the broadcast channel needed when the confirmation check's consumer
+// is temporarily too slow to handle them. The check expects one message per
+// signing group member but retransmissions of resharing messages may be
+// buffered before they are filtered out.
+const keyShareRefreshConfirmationReceiveBuffer = 512
+
+// keyShareRefreshConfirmedMessage is a message used to signal that the sender
+// holds refreshed key shares confirmed with a heartbeat signature.
+type keyShareRefreshConfirmedMessage struct {
+	senderID  group.MemberIndex
+	message   *big.Int
+	signature *tecdsa.Signature
+}
+
+func (ksrcm *keyShareRefreshConfirmedMessage) Type() string {
+	return "tbtc/key_share_refresh_confirmed_message"
+}
+
+// keyShareRefreshConfirmationCheck is a component responsible for agreeing
+// on refreshed key shares across all signing group members. A heartbeat
+// signature produced with refreshed key shares proves that at least the
+// honest threshold of members holds them. It does not prove all members
+// saw the signature though; a member may miss signing done messages. That
+// is why each member broadcasts the signature it saw and replaces its key
+// shares only after it receives the signature from all members.
+type keyShareRefreshConfirmationCheck struct {
+	walletPublicKey     *ecdsa.PublicKey
+	groupSize           int
+	broadcastChannel    net.BroadcastChannel
+	membershipValidator *group.MembershipValidator
+}
+
+func newKeyShareRefreshConfirmationCheck(
+	walletPublicKey *ecdsa.PublicKey,
+	groupSize int,
+	broadcastChannel net.BroadcastChannel,
+	membershipValidator *group.MembershipValidator,
+) *keyShareRefreshConfirmationCheck {
+	return &keyShareRefreshConfirmationCheck{
+		walletPublicKey:     walletPublicKey,
+		groupSize:           groupSize,
+		broadcastChannel:    broadcastChannel,
+		membershipValidator: membershipValidator,
+	}
+}
+
+// run broadcasts the given heartbeat signature of the given message on
+// behalf of the given members and waits for valid signatures of the same
+// message from all signing group members. The function blocks until the
+// given context is done so the signatures of the given members are
+// retransmitted to members that have not received them yet. It returns
+// an error if not all members confirmed refreshed key shares before
+// the context is done.
+func (ksrcc *keyShareRefreshConfirmationCheck) run(
+	ctx context.Context,
+	memberIndexes []group.MemberIndex,
+	message *big.Int,
+	signature *tecdsa.Signature,
+) error {
+	messagesChan := make(
+		chan net.Message,
+		keyShareRefreshConfirmationReceiveBuffer,
+	)
+	ksrcc.broadcastChannel.Recv(ctx, func(message net.Message) {
+		messagesChan <- message
+	})
+
+	for _, memberIndex := range memberIndexes {
+		err := ksrcc.broadcastChannel.Send(
+			ctx,
+			&keyShareRefreshConfirmedMessage{
+				senderID:  memberIndex,
+				message:   message,
+				signature: signature,
+			},
+			net.BackoffRetransmissionStrategy,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"cannot send confirmation of member [%v]: [%v]",
+				memberIndex,
+				err,
+			)
+		}
+	}
+
+	confirmedMembers := make(map[group.MemberIndex]bool)
+
+	for {
+		select {
+		case netMessage := <-messagesChan:
+			confirmedMessage, ok := netMessage.Payload().(*keyShareRefreshConfirmedMessage)
+			if !ok {
+				continue
+			}
+
+			if !ksrcc.isValidConfirmedMessage(
+				confirmedMessage,
+				netMessage.SenderPublicKey(),
+				message,
+			) {
+				continue
+			}
+
+			confirmedMembers[confirmedMessage.senderID] = true
+
+		case <-ctx.Done():
+			if len(confirmedMembers) != ksrcc.groupSize {
+				return fmt.Errorf(
+					"received confirmations from [%v] out of [%v] members",
+					len(confirmedMembers),
+					ksrcc.groupSize,
+				)
+			}
+
+			return nil
+		}
+	}
+}
+
+// isValidConfirmedMessage validates the given keyShareRefreshConfirmedMessage
+// in the context of the given heartbeat message.
+func (ksrcc *keyShareRefreshConfirmationCheck) isValidConfirmedMessage(
+	confirmedMessage *keyShareRefreshConfirmedMessage,
+	senderPublicKey []byte,
+	message *big.Int,
+) bool {
+	if !ksrcc.membershipValidator.IsValidMembership(
+		confirmedMessage.senderID,
+		senderPublicKey,
+	) {
+		return false
+	}
+
+	if confirmedMessage.message.Cmp(message) != 0 {
+		return false
+	}
+
+	if confirmedMessage.signature == nil {
+		return false
+	}
+
+	return ecdsa.Verify(
+		ksrcc.walletPublicKey,
+		message.Bytes(),
+		confirmedMessage.signature.R,
+		confirmedMessage.signature.S,
+	)
+}
@@ -0,0 +1,218 @@
+package tbtc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/chain/local_v1"
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/net/local"
+	"github.com/keep-network/keep-core/pkg/operator"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+)
+
+func TestKeyShareRefreshConfirmationCheck(t *testing.T) {
+	groupParameters := &GroupParameters{
+		GroupSize:       5,
+		GroupQuorum:     4,
+		HonestThreshold: 3,
+	}
+
+	walletPrivateKey, err := ecdsa.GenerateKey(tecdsa.Curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherPrivateKey, err := ecdsa.GenerateKey(tecdsa.Curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := big.NewInt(100)
+
+	walletSignature := signKeyShareRefreshConfirmation(
+		t,
+		walletPrivateKey,
+		message,
+	)
+	otherSignature := signKeyShareRefreshConfirmation(
+		t,
+		otherPrivateKey,
+		message,
+	)
+
+	tests := map[string]struct {
+		// signatures holds the signature broadcast by the given member.
+		// Members without a signature do not run the check at all.
+		signatures  map[group.MemberIndex]*tecdsa.Signature
+		expectedErr error
+	}{
+		"all members confirmed": {
+			signatures: map[group.MemberIndex]*tecdsa.Signature{
+				1: walletSignature,
+				2: walletSignature,
+				3: walletSignature,
+				4: walletSignature,
+				5: walletSignature,
+			},
+		},
+		"one member did not confirm": {
+			signatures: map[group.MemberIndex]*tecdsa.Signature{
+				1: walletSignature,
+				2: walletSignature,
+				3: walletSignature,
+				4: walletSignature,
+			},
+			expectedErr: fmt.Errorf(
+				"received confirmations from [4] out of [5] members",
+			),
+		},
+		"one member confirmed with invalid signature": {
+			signatures: map[group.MemberIndex]*tecdsa.Signature{
+				1: walletSignature,
+				2: walletSignature,
+				3: walletSignature,
+				4: walletSignature,
+				5: otherSignature,
+			},
+			expectedErr: fmt.Errorf(
+				"received confirmations from [4] out of [5] members",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			confirmationCheck := setupKeyShareRefreshConfirmationCheck(
+				t,
+				groupParameters,
+				&walletPrivateKey.PublicKey,
+			)
+
+			ctx, cancelCtx := context.WithTimeout(
+				context.Background(),
+				2*time.Second,
+			)
+			defer cancelCtx()
+
+			type outcome struct {
+				memberIndex group.MemberIndex
+				err         error
+			}
+
+			wg := sync.WaitGroup{}
+			wg.Add(len(test.signatures))
+			outcomesChan := make(chan *outcome, len(test.signatures))
+
+			for memberIndex, signature := range test.signatures {
+				go func(
+					memberIndex group.MemberIndex,
+					signature *tecdsa.Signature,
+				) {
+					defer wg.Done()
+
+					err := confirmationCheck.run(
+						ctx,
+						[]group.MemberIndex{memberIndex},
+						message,
+						signature,
+					)
+
+					outcomesChan <- &outcome{
+						memberIndex: memberIndex,
+						err:         err,
+					}
+				}(memberIndex, signature)
+			}
+
+			wg.Wait()
+			close(outcomesChan)
+
+			for outcome := range outcomesChan {
+				if !reflect.DeepEqual(test.expectedErr, outcome.err) {
+					t.Errorf(
+						"unexpected error for member [%v]\n"+
+							"expected: [%v]\nactual:   [%v]",
+						outcome.memberIndex,
+						test.expectedErr,
+						outcome.err,
+					)
+				}
+			}
+		})
+	}
+}
+
+func signKeyShareRefreshConfirmation(
+	t *testing.T,
+	privateKey *ecdsa.PrivateKey,
+	message *big.Int,
+) *tecdsa.Signature {
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, message.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tecdsa.Signature{R: r, S: s}
+}
+
+func setupKeyShareRefreshConfirmationCheck(
+	t *testing.T,
+	groupParameters *GroupParameters,
+	walletPublicKey *ecdsa.PublicKey,
+) *keyShareRefreshConfirmationCheck {
+	operatorPrivateKey, operatorPublicKey, err := operator.GenerateKeyPair(
+		local_v1.DefaultCurve,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	localChain := ConnectWithKey(operatorPrivateKey)
+
+	localProvider := local.ConnectWithKey(operatorPublicKey)
+
+	operatorAddress, err := localChain.Signing().PublicKeyToAddress(
+		operatorPublicKey,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var operators []chain.Address
+	for i := 0; i < groupParameters.GroupSize; i++ {
+		operators = append(operators, operatorAddress)
+	}
+
+	broadcastChannel, err := localProvider.BroadcastChannelFor("channel")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broadcastChannel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &keyShareRefreshConfirmedMessage{}
+	})
+
+	membershipValidator := group.NewMembershipValidator(
+		&testutils.MockLogger{},
+		operators,
+		localChain.Signing(),
+	)
+
+	return newKeyShareRefreshConfirmationCheck(
+		walletPublicKey,
+		groupParameters.GroupSize,
+		broadcastChannel,
+		membershipValidator,
+	)
+}
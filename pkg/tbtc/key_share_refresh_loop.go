@@ -0,0 +1,317 @@
+package tbtc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ipfs/go-log/v2"
+
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/protocol/announcer"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa/resharing"
+	"github.com/keep-network/keep-core/pkg/tecdsa/retry"
+)
+
+const (
+	// keyShareRefreshAttemptAnnouncementDelayBlocks determines the duration
+	// of the announcement phase delay that is preserved before starting the
+	// announcement phase.
+	keyShareRefreshAttemptAnnouncementDelayBlocks = 1
+	// keyShareRefreshAttemptAnnouncementActiveBlocks determines the duration
+	// of the announcement phase that is performed at the beginning of each
+	// key share refresh attempt.
+	keyShareRefreshAttemptAnnouncementActiveBlocks = 5
+	// keyShareRefreshAttemptMaximumProtocolBlocks determines the maximum block
+	// duration of the actual protocol computations. Resharing is more
+	// expensive than signing as every member verifies Paillier proofs of
+	// all other members so the value is significantly larger than the one
+	// used for signing.
+	keyShareRefreshAttemptMaximumProtocolBlocks = 100
+	// keyShareRefreshAttemptCoolDownBlocks determines the duration of the
+	// cool down period that is preserved between subsequent key share
+	// refresh attempts.
+	keyShareRefreshAttemptCoolDownBlocks = 5
+)
+
+// keyShareRefreshAttemptMaximumBlocks returns the maximum block duration of
+// a single key share refresh attempt.
+func keyShareRefreshAttemptMaximumBlocks() uint {
+	return keyShareRefreshAttemptAnnouncementDelayBlocks +
+		keyShareRefreshAttemptAnnouncementActiveBlocks +
+		keyShareRefreshAttemptMaximumProtocolBlocks +
+		keyShareRefreshAttemptCoolDownBlocks
+}
+
+// keyShareRefreshAnnouncer represents a component responsible for exchanging
+// readiness announcements for the given key share refresh attempt.
+type keyShareRefreshAnnouncer interface {
+	Announce(
+		ctx context.Context,
+		memberIndex group.MemberIndex,
+		sessionID string,
+	) ([]group.MemberIndex, error)
+}
+
+// keyShareRefreshRetryLoop is a struct that encapsulates the key share
+// refresh retry logic.
+//
+// All signing group members must receive refreshed private key shares as
+// shares produced by the resharing are not compatible with the old ones.
+// That means all members must be ready to start an attempt. However, only
+// an honest threshold subset of members deals their current private key
+// shares in the given attempt. The subset is selected using the same
+// algorithm as the one used to select signing participants so the dealers
+// change between attempts and members misbehaving as dealers are
+// eventually excluded.
+type keyShareRefreshRetryLoop struct {
+	logger log.StandardLogger
+
+	sessionID string
+
+	signingGroupMemberIndex group.MemberIndex
+	signingGroupOperators   chain.Addresses
+
+	groupParameters *GroupParameters
+
+	announcer keyShareRefreshAnnouncer
+
+	attemptCounter    uint
+	attemptStartBlock uint64
+	attemptSeed       int64
+}
+
+func newKeyShareRefreshRetryLoop(
+	logger log.StandardLogger,
+	sessionID string,
+	initialStartBlock uint64,
+	signingGroupMemberIndex group.MemberIndex,
+	signingGroupOperators chain.Addresses,
+	groupParameters *GroupParameters,
+	announcer keyShareRefreshAnnouncer,
+) *keyShareRefreshRetryLoop {
+	// Compute the 8-byte seed needed for the random retry algorithm. We take
+	// the first 8 bytes of the hash of the session ID which is unique for
+	// the given wallet and key share refresh action.
+	sessionIDSha256 := sha256.Sum256([]byte(sessionID))
+	attemptSeed := int64(binary.BigEndian.Uint64(sessionIDSha256[:8]))
+
+	return &keyShareRefreshRetryLoop{
+		logger:                  logger,
+		sessionID:               sessionID,
+		signingGroupMemberIndex: signingGroupMemberIndex,
+		signingGroupOperators:   signingGroupOperators,
+		groupParameters:         groupParameters,
+		announcer:               announcer,
+		attemptCounter:          0,
+		attemptStartBlock:       initialStartBlock,
+		attemptSeed:             attemptSeed,
+	}
+}
+
+// keyShareRefreshAttemptParams represents parameters of a key share refresh
+// attempt.
+type keyShareRefreshAttemptParams struct {
+	number                     uint
+	startBlock                 uint64
+	timeoutBlock               uint64
+	oldCommitteeMembersIndexes []group.MemberIndex
+}
+
+// keyShareRefreshAttemptFn represents a function performing a key share
+// refresh attempt.
+type keyShareRefreshAttemptFn func(
+	*keyShareRefreshAttemptParams,
+) (*resharing.Result, error)
+
+// start begins the key share refresh retry loop using the given attempt
+// function. The retry loop terminates when the resharing result is produced
+// or the ctx parameter is done, whatever comes first.
+func (ksrrl *keyShareRefreshRetryLoop) start(
+	ctx context.Context,
+	waitForBlockFn waitForBlockFn,
+	getCurrentBlockFn getCurrentBlockFn,
+	attemptFn keyShareRefreshAttemptFn,
+) (*resharing.Result, error) {
+	for {
+		ksrrl.attemptCounter++
+
+		// Check the loop stop signal.
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		// Just as in the signing retry loop, assume the worst case that
+		// each previous attempt failed at the end of the protocol.
+		if ksrrl.attemptCounter > 1 {
+			ksrrl.attemptStartBlock = ksrrl.attemptStartBlock +
+				uint64(keyShareRefreshAttemptMaximumBlocks())
+		}
+
+		announcementStartBlock := ksrrl.attemptStartBlock +
+			keyShareRefreshAttemptAnnouncementDelayBlocks
+		announcementEndBlock := announcementStartBlock +
+			keyShareRefreshAttemptAnnouncementActiveBlocks
+
+		currentBlock, err := getCurrentBlockFn()
+		if err != nil {
+			ksrrl.logger.Errorf(
+				"[member:%v] failed to get the current block for attempt [%v]: "+
+					"[%v]; starting next attempt",
+				ksrrl.signingGroupMemberIndex,
+				ksrrl.attemptCounter,
+				err,
+			)
+			continue
+		}
+
+		if announcementEndBlock <= currentBlock {
+			ksrrl.logger.Infof(
+				"[member:%v] skipping attempt [%v]; the current block is [%v] "+
+					"and the end block [%v] for the announcement phase is in the past",
+				ksrrl.signingGroupMemberIndex,
+				ksrrl.attemptCounter,
+				currentBlock,
+				announcementEndBlock,
+			)
+			continue
+		}
+
+		err = waitForBlockFn(ctx, announcementStartBlock)
+		if err != nil {
+			ksrrl.logger.Errorf(
+				"[member:%v] failed waiting for announcement start "+
+					"block [%v] for attempt [%v]: [%v]; starting next attempt",
+				ksrrl.signingGroupMemberIndex,
+				announcementStartBlock,
+				ksrrl.attemptCounter,
+				err,
+			)
+			continue
+		}
+
+		// Set up the announcement phase stop signal.
+		announceCtx, _ := withCancelOnBlock(ctx, announcementEndBlock, waitForBlockFn)
+
+		readyMembersIndexes, err := ksrrl.announcer.Announce(
+			announceCtx,
+			ksrrl.signingGroupMemberIndex,
+			fmt.Sprintf("%v-%v", ksrrl.sessionID, ksrrl.attemptCounter),
+		)
+		if err != nil {
+			ksrrl.logger.Warnf(
+				"[member:%v] announcement for attempt [%v] "+
+					"failed: [%v]; starting next attempt",
+				ksrrl.signingGroupMemberIndex,
+				ksrrl.attemptCounter,
+				err,
+			)
+			continue
+		}
+
+		// Check the loop stop signal again. The announcement took some time
+		// and the context may be done now.
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		unreadyMembersIndexes := announcer.UnreadyMembers(
+			readyMembersIndexes,
+			len(ksrrl.signingGroupOperators),
+		)
+		if len(unreadyMembersIndexes) > 0 {
+			ksrrl.logger.Warnf(
+				"[member:%v] completed announcement phase for attempt [%v] "+
+					"with following members not ready: [%v]; all members "+
+					"must receive refreshed key shares; moving to the "+
+					"next attempt",
+				ksrrl.signingGroupMemberIndex,
+				ksrrl.attemptCounter,
+				unreadyMembersIndexes,
+			)
+			continue
+		}
+
+		oldCommitteeMembersIndexes, err := ksrrl.selectOldCommittee(
+			readyMembersIndexes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot select old committee for attempt [%v]: [%w]",
+				ksrrl.attemptCounter,
+				err,
+			)
+		}
+
+		result, err := attemptFn(&keyShareRefreshAttemptParams{
+			number:                     ksrrl.attemptCounter,
+			startBlock:                 announcementEndBlock,
+			timeoutBlock:               announcementEndBlock + keyShareRefreshAttemptMaximumProtocolBlocks,
+			oldCommitteeMembersIndexes: oldCommitteeMembersIndexes,
+		})
+		if err != nil {
+			ksrrl.logger.Warnf(
+				"[member:%v] failed attempt [%v] with old committee [%v]: "+
+					"[%v]; starting next attempt",
+				ksrrl.signingGroupMemberIndex,
+				ksrrl.attemptCounter,
+				oldCommitteeMembersIndexes,
+				err,
+			)
+			continue
+		}
+
+		return result, nil
+	}
+}
+
+// selectOldCommittee selects members dealing their current private key
+// shares in the given attempt. Members are selected by picking all members
+// controlled by operators qualified for the given attempt by the retry
+// algorithm.
+func (ksrrl *keyShareRefreshRetryLoop) selectOldCommittee(
+	readyMembersIndexes []group.MemberIndex,
+) ([]group.MemberIndex, error) {
+	// The retry algorithm expects that we count retries from 0. Since
+	// the first invocation of the algorithm will be for `attemptCounter == 1`
+	// we need to subtract one while determining the number of the given retry.
+	retryCount := ksrrl.attemptCounter - 1
+
+	var readySigningGroupOperators []chain.Address
+	for _, memberIndex := range readyMembersIndexes {
+		readySigningGroupOperators = append(
+			readySigningGroupOperators,
+			ksrrl.signingGroupOperators[memberIndex-1],
+		)
+	}
+
+	qualifiedOperators, err := retry.EvaluateRetryParticipantsForSigning(
+		readySigningGroupOperators,
+		ksrrl.attemptSeed,
+		retryCount,
+		uint(ksrrl.groupParameters.HonestThreshold),
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"random operator selection failed: [%w]",
+			err,
+		)
+	}
+
+	qualifiedOperatorsSet := chain.Addresses(qualifiedOperators).Set()
+
+	oldCommitteeMembersIndexes := make([]group.MemberIndex, 0)
+	for _, memberIndex := range readyMembersIndexes {
+		operator := ksrrl.signingGroupOperators[memberIndex-1]
+		if qualifiedOperatorsSet[operator] {
+			oldCommitteeMembersIndexes = append(
+				oldCommitteeMembersIndexes,
+				memberIndex,
+			)
+		}
+	}
+
+	return oldCommitteeMembersIndexes, nil
+}
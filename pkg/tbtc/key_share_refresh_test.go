@@ -0,0 +1,331 @@
+package tbtc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+)
+
+func TestKeyShareRefreshAction_Execute(t *testing.T) {
+	walletPrivateKey, err := ecdsa.GenerateKey(tecdsa.Curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherPrivateKey, err := ecdsa.GenerateKey(tecdsa.Curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The action verifies the confirmation signature against the wallet
+	// public key so the wallet must use a key known to the test.
+	executingWallet := createMockSigner(t).wallet
+	executingWallet.publicKey = &walletPrivateKey.PublicKey
+
+	refreshedSigner := createMockSigner(t)
+
+	walletPublicKeyHash := bitcoin.PublicKeyHash(executingWallet.publicKey)
+
+	startBlock := uint64(10)
+	expiryBlock := startBlock + keyShareRefreshProposalValidityBlocks
+	confirmationStartBlock := expiryBlock -
+		keyShareRefreshTimeoutSafetyMarginBlocks -
+		keyShareRefreshAgreementBlocks -
+		keyShareRefreshConfirmationBlocks
+
+	tests := map[string]struct {
+		walletState          WalletState
+		refreshErr           error
+		signingKey           *ecdsa.PrivateKey
+		signingErr           error
+		agreementErr         error
+		replaceErr           error
+		expectedRefreshCalls int
+		expectedSigningCalls int
+		expectedAgreeCalls   int
+		expectedReplaced     []*signer
+		expectedErr          error
+	}{
+		"refresh succeeded": {
+			walletState:          StateLive,
+			signingKey:           walletPrivateKey,
+			expectedRefreshCalls: 1,
+			expectedSigningCalls: 1,
+			expectedAgreeCalls:   1,
+			expectedReplaced:     []*signer{refreshedSigner},
+		},
+		"wallet not live": {
+			walletState:          StateMovingFunds,
+			signingKey:           walletPrivateKey,
+			expectedRefreshCalls: 0,
+			expectedSigningCalls: 0,
+			expectedErr: fmt.Errorf(
+				"wallet is in [MovingFunds] state while key share refresh " +
+					"requires the Live state",
+			),
+		},
+		"refresh failed": {
+			walletState:          StateLive,
+			refreshErr:           fmt.Errorf("timeout"),
+			signingKey:           walletPrivateKey,
+			expectedRefreshCalls: 1,
+			expectedSigningCalls: 0,
+			expectedErr: fmt.Errorf(
+				"key share refresh process errored out: [timeout]",
+			),
+		},
+		"confirmation signing failed": {
+			walletState:          StateLive,
+			signingKey:           walletPrivateKey,
+			signingErr:           fmt.Errorf("timeout"),
+			expectedRefreshCalls: 1,
+			expectedSigningCalls: 1,
+			expectedErr: fmt.Errorf(
+				"refreshed key shares not confirmed; dropping them and " +
+					"keeping the current ones: [cannot sign heartbeat " +
+					"message: [timeout]]",
+			),
+		},
+		"confirmation signature not matching wallet": {
+			walletState:          StateLive,
+			signingKey:           otherPrivateKey,
+			expectedRefreshCalls: 1,
+			expectedSigningCalls: 1,
+			expectedErr: fmt.Errorf(
+				"refreshed key shares not confirmed; dropping them and " +
+					"keeping the current ones: [heartbeat signature does " +
+					"not match the wallet public key]",
+			),
+		},
+		"agreement failed": {
+			walletState:          StateLive,
+			signingKey:           walletPrivateKey,
+			agreementErr:         fmt.Errorf("missing confirmations"),
+			expectedRefreshCalls: 1,
+			expectedSigningCalls: 1,
+			expectedAgreeCalls:   1,
+			expectedErr: fmt.Errorf(
+				"refreshed key shares not confirmed by all members; " +
+					"dropping them and keeping the current ones: " +
+					"[missing confirmations]",
+			),
+		},
+		"replacement failed": {
+			walletState:          StateLive,
+			signingKey:           walletPrivateKey,
+			replaceErr:           fmt.Errorf("disk full"),
+			expectedRefreshCalls: 1,
+			expectedSigningCalls: 1,
+			expectedAgreeCalls:   1,
+			expectedErr: fmt.Errorf(
+				"cannot replace wallet signers: [disk full]",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			hostChain := Connect()
+			hostChain.setWallet(
+				walletPublicKeyHash,
+				&WalletChainData{State: test.walletState},
+			)
+
+			refreshExecutor := &mockKeyShareRefreshExecutor{
+				signers:      []*signer{refreshedSigner},
+				err:          test.refreshErr,
+				agreementErr: test.agreementErr,
+			}
+
+			signingExecutor := &mockKeyShareRefreshSigningExecutor{
+				privateKey: test.signingKey,
+				err:        test.signingErr,
+			}
+
+			var (
+				signingExecutorSigners []*signer
+				replacedSigners        []*signer
+			)
+
+			action := newKeyShareRefreshAction(
+				logger,
+				hostChain,
+				executingWallet,
+				refreshExecutor,
+				func(signers []*signer) (keyShareRefreshSigningExecutor, error) {
+					signingExecutorSigners = signers
+					return signingExecutor, nil
+				},
+				func(wallet wallet, signers []*signer) error {
+					if test.replaceErr != nil {
+						return test.replaceErr
+					}
+
+					replacedSigners = signers
+					return nil
+				},
+				&KeyShareRefreshProposal{},
+				startBlock,
+				expiryBlock,
+				func(ctx context.Context, blockHeight uint64) error {
+					return nil
+				},
+				nil,
+			)
+
+			err := action.execute()
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Errorf(
+					"unexpected error\nexpected: [%v]\nactual:   [%v]",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"refresh calls",
+				test.expectedRefreshCalls,
+				refreshExecutor.calls,
+			)
+
+			if test.expectedRefreshCalls > 0 {
+				testutils.AssertUintsEqual(
+					t,
+					"start block",
+					startBlock,
+					refreshExecutor.requestedStartBlock,
+				)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"signing calls",
+				test.expectedSigningCalls,
+				signingExecutor.calls,
+			)
+
+			if test.expectedSigningCalls > 0 {
+				if !reflect.DeepEqual(
+					[]*signer{refreshedSigner},
+					signingExecutorSigners,
+				) {
+					t.Errorf(
+						"unexpected signing executor signers\n"+
+							"expected: [%v]\nactual:   [%v]",
+						[]*signer{refreshedSigner},
+						signingExecutorSigners,
+					)
+				}
+
+				testutils.AssertUintsEqual(
+					t,
+					"signing start block",
+					confirmationStartBlock,
+					signingExecutor.requestedStartBlock,
+				)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"agree calls",
+				test.expectedAgreeCalls,
+				refreshExecutor.agreeCalls,
+			)
+
+			if test.expectedAgreeCalls > 0 {
+				if !ecdsa.Verify(
+					executingWallet.publicKey,
+					refreshExecutor.agreedMessage.Bytes(),
+					refreshExecutor.agreedSignature.R,
+					refreshExecutor.agreedSignature.S,
+				) {
+					t.Errorf("agreed signature does not match the wallet")
+				}
+			}
+
+			if !reflect.DeepEqual(test.expectedReplaced, replacedSigners) {
+				t.Errorf(
+					"unexpected replaced signers\nexpected: [%v]\nactual:   [%v]",
+					test.expectedReplaced,
+					replacedSigners,
+				)
+			}
+		})
+	}
+}
+
+type mockKeyShareRefreshExecutor struct {
+	signers      []*signer
+	err          error
+	agreementErr error
+
+	calls               int
+	requestedStartBlock uint64
+
+	agreeCalls      int
+	agreedMessage   *big.Int
+	agreedSignature *tecdsa.Signature
+}
+
+func (mksre *mockKeyShareRefreshExecutor) refresh(
+	ctx context.Context,
+	startBlock uint64,
+) ([]*signer, error) {
+	mksre.calls++
+	mksre.requestedStartBlock = startBlock
+
+	if mksre.err != nil {
+		return nil, mksre.err
+	}
+
+	return mksre.signers, nil
+}
+
+func (mksre *mockKeyShareRefreshExecutor) agree(
+	ctx context.Context,
+	message *big.Int,
+	signature *tecdsa.Signature,
+) error {
+	mksre.agreeCalls++
+	mksre.agreedMessage = message
+	mksre.agreedSignature = signature
+
+	return mksre.agreementErr
+}
+
+type mockKeyShareRefreshSigningExecutor struct {
+	privateKey *ecdsa.PrivateKey
+	err        error
+
+	calls               int
+	requestedStartBlock uint64
+}
+
+func (mksrse *mockKeyShareRefreshSigningExecutor) sign(
+	ctx context.Context,
+	message *big.Int,
+	startBlock uint64,
+) (*tecdsa.Signature, *signingActivityReport, uint64, error) {
+	mksrse.calls++
+	mksrse.requestedStartBlock = startBlock
+
+	if mksrse.err != nil {
+		return nil, nil, 0, mksrse.err
+	}
+
+	r, s, err := ecdsa.Sign(rand.Reader, mksrse.privateKey, message.Bytes())
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return &tecdsa.Signature{R: r, S: s}, &signingActivityReport{}, startBlock, nil
+}
@@ -128,6 +128,46 @@ func (sdm *signingDoneMessage) Unmarshal(bytes []byte) error {
 	return nil
 }
 
+// Marshal converts the keyShareRefreshConfirmedMessage to a byte array.
+func (ksrcm *keyShareRefreshConfirmedMessage) Marshal() ([]byte, error) {
+	signatureBytes, err := ksrcm.signature.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(&pb.KeyShareRefreshConfirmedMessage{
+		SenderID:  uint32(ksrcm.senderID),
+		Message:   ksrcm.message.Bytes(),
+		Signature: signatureBytes,
+	})
+}
+
+// Unmarshal converts a byte array back to the keyShareRefreshConfirmedMessage.
+func (ksrcm *keyShareRefreshConfirmedMessage) Unmarshal(bytes []byte) error {
+	pbMsg := pb.KeyShareRefreshConfirmedMessage{}
+	if err := proto.Unmarshal(bytes, &pbMsg); err != nil {
+		return fmt.Errorf(
+			"failed to unmarshal KeyShareRefreshConfirmedMessage: [%v]",
+			err,
+		)
+	}
+
+	if err := validateMemberIndex(pbMsg.SenderID); err != nil {
+		return err
+	}
+
+	signature := &tecdsa.Signature{}
+	if err := signature.Unmarshal(pbMsg.Signature); err != nil {
+		return fmt.Errorf("cannot unmarshal signature: [%v]", err)
+	}
+
+	ksrcm.senderID = group.MemberIndex(pbMsg.SenderID)
+	ksrcm.message = new(big.Int).SetBytes(pbMsg.Message)
+	ksrcm.signature = signature
+
+	return nil
+}
+
 // Marshal converts the coordinationMessage to a byte array.
 func (cm *coordinationMessage) Marshal() ([]byte, error) {
 	proposalBytes, err := cm.proposal.Marshal()
@@ -172,7 +212,7 @@ func (cm *coordinationMessage) Unmarshal(bytes []byte) error {
 	if pbMsg.Proposal == nil {
 		return fmt.Errorf("missing proposal")
 	}
-	proposal, err := unmarshalCoordinationProposal(
+	proposal, err := UnmarshalCoordinationProposal(
 		pbMsg.Proposal.ActionType,
 		pbMsg.Proposal.Payload,
 	)
@@ -204,9 +244,9 @@ func unmarshalWalletPublicKeyHash(bytes []byte) ([20]byte, error) {
 	return walletPublicKeyHash, nil
 }
 
-// unmarshalCoordinationProposal converts a byte array back to the coordination
+// UnmarshalCoordinationProposal converts a byte array back to the coordination
 // proposal.
-func unmarshalCoordinationProposal(actionType uint32, payload []byte) (
+func UnmarshalCoordinationProposal(actionType uint32, payload []byte) (
 	CoordinationProposal,
 	error,
 ) {
@@ -232,6 +272,9 @@ func unmarshalCoordinationProposal(actionType uint32, payload []byte) (
 		ActionRedemption:      &RedemptionProposal{},
 		ActionMovingFunds:     &MovingFundsProposal{},
 		ActionMovedFundsSweep: &MovedFundsSweepProposal{},
+		ActionRbf:             &RbfProposal{},
+		ActionCpfp:            &CpfpProposal{},
+		ActionKeyShareRefresh: &KeyShareRefreshProposal{},
 	}[parsedActionType]
 	if !ok {
 		return nil, fmt.Errorf(
@@ -369,10 +412,17 @@ func (rp *RedemptionProposal) Marshal() ([]byte, error) {
 		redeemersOutputScripts[i] = script
 	}
 
+	redemptionTxFeeShares := make([][]byte, len(rp.RedemptionTxFeeShares))
+	for i, feeShare := range rp.RedemptionTxFeeShares {
+		redemptionTxFeeShares[i] = feeShare.Bytes()
+	}
+
 	return proto.Marshal(
 		&pb.RedemptionProposal{
 			RedeemersOutputScripts: redeemersOutputScripts,
 			RedemptionTxFee:        rp.RedemptionTxFee.Bytes(),
+			RedemptionTxFeeShares:  redemptionTxFeeShares,
+			TransactionShape:       uint32(rp.TransactionShape),
 		},
 	)
 }
@@ -389,8 +439,27 @@ func (rp *RedemptionProposal) Unmarshal(bytes []byte) error {
 		redeemersOutputScripts[i] = script
 	}
 
+	var redemptionTxFeeShares []*big.Int
+	if len(pbMsg.RedemptionTxFeeShares) > 0 {
+		redemptionTxFeeShares = make([]*big.Int, len(pbMsg.RedemptionTxFeeShares))
+		for i, feeShare := range pbMsg.RedemptionTxFeeShares {
+			redemptionTxFeeShares[i] = new(big.Int).SetBytes(feeShare)
+		}
+	}
+
+	transactionShape := RedemptionTransactionShape(pbMsg.TransactionShape)
+	if pbMsg.TransactionShape != uint32(transactionShape) ||
+		!transactionShape.isValid() {
+		return fmt.Errorf(
+			"invalid redemption transaction shape: [%v]",
+			pbMsg.TransactionShape,
+		)
+	}
+
 	rp.RedeemersOutputScripts = redeemersOutputScripts
 	rp.RedemptionTxFee = new(big.Int).SetBytes(pbMsg.RedemptionTxFee)
+	rp.RedemptionTxFeeShares = redemptionTxFeeShares
+	rp.TransactionShape = transactionShape
 
 	return nil
 }
@@ -463,6 +532,107 @@ func (mfsp *MovedFundsSweepProposal) Unmarshal(data []byte) error {
 	return nil
 }
 
+// Marshal converts the rbfProposal to a byte array.
+func (rp *RbfProposal) Marshal() ([]byte, error) {
+	return proto.Marshal(
+		&pb.RbfProposal{
+			TransactionHash: rp.TransactionHash[:],
+			NewFee:          rp.NewFee.Bytes(),
+		})
+}
+
+// Unmarshal converts a byte array back to the rbfProposal.
+func (rp *RbfProposal) Unmarshal(data []byte) error {
+	pbMsg := pb.RbfProposal{}
+	if err := proto.Unmarshal(data, &pbMsg); err != nil {
+		return fmt.Errorf("failed to unmarshal RbfProposal: [%v]", err)
+	}
+
+	if len(pbMsg.TransactionHash) != 32 {
+		return fmt.Errorf(
+			"invalid transaction hash length: [%v]",
+			len(pbMsg.TransactionHash),
+		)
+	}
+
+	copy(rp.TransactionHash[:], pbMsg.TransactionHash)
+	rp.NewFee = new(big.Int).SetBytes(pbMsg.NewFee)
+
+	return nil
+}
+
+// Marshal converts the cpfpProposal to a byte array.
+func (cp *CpfpProposal) Marshal() ([]byte, error) {
+	if cp.ChildProposal == nil {
+		return nil, fmt.Errorf("missing child proposal")
+	}
+
+	childPayload, err := cp.ChildProposal.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal child proposal: [%v]", err)
+	}
+
+	return proto.Marshal(
+		&pb.CpfpProposal{
+			ParentTransactionHash: cp.ParentTransactionHash[:],
+			ChildProposal: &pb.CoordinationProposal{
+				ActionType: uint32(cp.ChildProposal.ActionType()),
+				Payload:    childPayload,
+			},
+		})
+}
+
+// Unmarshal converts a byte array back to the cpfpProposal.
+func (cp *CpfpProposal) Unmarshal(data []byte) error {
+	pbMsg := pb.CpfpProposal{}
+	if err := proto.Unmarshal(data, &pbMsg); err != nil {
+		return fmt.Errorf("failed to unmarshal CpfpProposal: [%v]", err)
+	}
+
+	if len(pbMsg.ParentTransactionHash) != 32 {
+		return fmt.Errorf(
+			"invalid parent transaction hash length: [%v]",
+			len(pbMsg.ParentTransactionHash),
+		)
+	}
+
+	if pbMsg.ChildProposal == nil {
+		return fmt.Errorf("missing child proposal")
+	}
+
+	childActionType := pbMsg.ChildProposal.ActionType
+	if childActionType != uint32(ActionDepositSweep) &&
+		childActionType != uint32(ActionRedemption) {
+		return fmt.Errorf(
+			"invalid child proposal action type: [%v]",
+			childActionType,
+		)
+	}
+
+	childProposal, err := UnmarshalCoordinationProposal(
+		childActionType,
+		pbMsg.ChildProposal.Payload,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal child proposal: [%v]", err)
+	}
+
+	copy(cp.ParentTransactionHash[:], pbMsg.ParentTransactionHash)
+	cp.ChildProposal = childProposal
+
+	return nil
+}
+
+// Marshal converts the keyShareRefreshProposal to a byte array.
+func (ksrp *KeyShareRefreshProposal) Marshal() ([]byte, error) {
+	return []byte{}, nil
+}
+
+// Unmarshal converts a byte array back to the keyShareRefreshProposal.
+func (ksrp *KeyShareRefreshProposal) Unmarshal([]byte) error {
+	return nil
+}
+
 // marshalPublicKey converts an ECDSA public key to a byte
 // array (uncompressed).
 func marshalPublicKey(publicKey *ecdsa.PublicKey) ([]byte, error) {
@@ -4,6 +4,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"encoding/hex"
+	"fmt"
 	"math/big"
 	"reflect"
 	"testing"
@@ -11,10 +12,12 @@ import (
 	"github.com/keep-network/keep-core/pkg/bitcoin"
 
 	fuzz "github.com/google/gofuzz"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/keep-network/keep-core/internal/testutils"
 	"github.com/keep-network/keep-core/pkg/internal/pbutils"
 	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tbtc/gen/pb"
 	"github.com/keep-network/keep-core/pkg/tecdsa"
 )
 
@@ -108,6 +111,61 @@ func TestFuzzSigningDoneMessage_Unmarshaler(t *testing.T) {
 	pbutils.FuzzUnmarshaler(&signingDoneMessage{})
 }
 
+func TestKeyShareRefreshConfirmedMessage_MarshalingRoundtrip(t *testing.T) {
+	msg := &keyShareRefreshConfirmedMessage{
+		senderID: group.MemberIndex(10),
+		message:  big.NewInt(100),
+		signature: &tecdsa.Signature{
+			R:          big.NewInt(200),
+			S:          big.NewInt(300),
+			RecoveryID: 3,
+		},
+	}
+	unmarshaled := &keyShareRefreshConfirmedMessage{}
+
+	err := pbutils.RoundTrip(msg, unmarshaled)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(msg, unmarshaled) {
+		t.Fatalf("unexpected content of unmarshaled message")
+	}
+}
+
+func TestFuzzKeyShareRefreshConfirmedMessage_MarshalingRoundtrip(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var (
+			senderID  group.MemberIndex
+			message   big.Int
+			signature tecdsa.Signature
+		)
+
+		f := fuzz.New().NilChance(0.1).
+			NumElements(0, 512).
+			Funcs(pbutils.FuzzFuncs()...)
+
+		f.Fuzz(&senderID)
+		f.Fuzz(&message)
+		f.Fuzz(&signature)
+
+		confirmedMessage := &keyShareRefreshConfirmedMessage{
+			senderID:  senderID,
+			message:   &message,
+			signature: &signature,
+		}
+
+		_ = pbutils.RoundTrip(
+			confirmedMessage,
+			&keyShareRefreshConfirmedMessage{},
+		)
+	}
+}
+
+func TestFuzzKeyShareRefreshConfirmedMessage_Unmarshaler(t *testing.T) {
+	pbutils.FuzzUnmarshaler(&keyShareRefreshConfirmedMessage{})
+}
+
 func TestCoordinationMessage_MarshalingRoundtrip(t *testing.T) {
 	parseHash := func(hash string) bitcoin.Hash {
 		parsed, err := bitcoin.NewHashFromString(hash, bitcoin.InternalByteOrder)
@@ -184,6 +242,20 @@ func TestCoordinationMessage_MarshalingRoundtrip(t *testing.T) {
 				RedemptionTxFee: big.NewInt(10000),
 			},
 		},
+		"with redemption proposal with fee shares and shape": {
+			proposal: &RedemptionProposal{
+				RedeemersOutputScripts: []bitcoin.Script{
+					parseScript("00148db50eb52063ea9d98b3eac91489a90f738986f6"),
+					parseScript("76a9148db50eb52063ea9d98b3eac91489a90f738986f688ac"),
+				},
+				RedemptionTxFee: big.NewInt(10000),
+				RedemptionTxFeeShares: []*big.Int{
+					big.NewInt(4000),
+					big.NewInt(6000),
+				},
+				TransactionShape: RedemptionChangeLast,
+			},
+		},
 		"with moving funds proposal": {
 			proposal: &MovingFundsProposal{
 				TargetWallets: [][20]byte{
@@ -200,6 +272,46 @@ func TestCoordinationMessage_MarshalingRoundtrip(t *testing.T) {
 				SweepTxFee:               big.NewInt(8000),
 			},
 		},
+		"with rbf proposal": {
+			proposal: &RbfProposal{
+				TransactionHash: parseHash("27ca64c092a959c7edc525ed45e845b1de6a7590d173fd2fad9133c8a779a1e3"),
+				NewFee:          big.NewInt(12000),
+			},
+		},
+		"with cpfp proposal with deposit sweep child": {
+			proposal: &CpfpProposal{
+				ParentTransactionHash: parseHash("27ca64c092a959c7edc525ed45e845b1de6a7590d173fd2fad9133c8a779a1e3"),
+				ChildProposal: &DepositSweepProposal{
+					DepositsKeys: []struct {
+						FundingTxHash      bitcoin.Hash
+						FundingOutputIndex uint32
+					}{
+						{
+							FundingTxHash:      parseHash("709b55bd3da0f5a838125bd0ee20c5bfdd7caba173912d4281cae816b79a201b"),
+							FundingOutputIndex: 0,
+						},
+					},
+					SweepTxFee: big.NewInt(10000),
+					DepositsRevealBlocks: []*big.Int{
+						big.NewInt(100),
+					},
+				},
+			},
+		},
+		"with cpfp proposal with redemption child": {
+			proposal: &CpfpProposal{
+				ParentTransactionHash: parseHash("27ca64c092a959c7edc525ed45e845b1de6a7590d173fd2fad9133c8a779a1e3"),
+				ChildProposal: &RedemptionProposal{
+					RedeemersOutputScripts: []bitcoin.Script{
+						parseScript("00148db50eb52063ea9d98b3eac91489a90f738986f6"),
+					},
+					RedemptionTxFee: big.NewInt(10000),
+				},
+			},
+		},
+		"with key share refresh proposal": {
+			proposal: &KeyShareRefreshProposal{},
+		},
 	}
 
 	walletPublicKeyHash := toByte20("aa768412ceed10bd423c025542ca90071f9fb62d")
@@ -371,6 +483,100 @@ func TestFuzzCoordinationMessage_MarshalingRoundtrip_WithMovedFundsSweepProposal
 	}
 }
 
+func TestFuzzCoordinationMessage_MarshalingRoundtrip_WithRbfProposal(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var (
+			senderID            group.MemberIndex
+			coordinationBlock   uint64
+			walletPublicKeyHash [20]byte
+			proposal            RbfProposal
+		)
+
+		f := fuzz.New().NilChance(0.1).
+			NumElements(0, 512).
+			Funcs(pbutils.FuzzFuncs()...)
+
+		f.Fuzz(&senderID)
+		f.Fuzz(&coordinationBlock)
+		f.Fuzz(&walletPublicKeyHash)
+		f.Fuzz(&proposal)
+
+		coordinationMsg := &coordinationMessage{
+			senderID:            senderID,
+			coordinationBlock:   coordinationBlock,
+			walletPublicKeyHash: walletPublicKeyHash,
+			proposal:            &proposal,
+		}
+
+		_ = pbutils.RoundTrip(coordinationMsg, &coordinationMessage{})
+	}
+}
+
+func TestFuzzCoordinationMessage_MarshalingRoundtrip_WithCpfpProposal(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var (
+			senderID              group.MemberIndex
+			coordinationBlock     uint64
+			walletPublicKeyHash   [20]byte
+			parentTransactionHash bitcoin.Hash
+			childProposal         RedemptionProposal
+		)
+
+		f := fuzz.New().NilChance(0.1).
+			NumElements(0, 512).
+			Funcs(pbutils.FuzzFuncs()...)
+
+		f.Fuzz(&senderID)
+		f.Fuzz(&coordinationBlock)
+		f.Fuzz(&walletPublicKeyHash)
+		f.Fuzz(&parentTransactionHash)
+		f.Fuzz(&childProposal)
+
+		coordinationMsg := &coordinationMessage{
+			senderID:            senderID,
+			coordinationBlock:   coordinationBlock,
+			walletPublicKeyHash: walletPublicKeyHash,
+			proposal: &CpfpProposal{
+				ParentTransactionHash: parentTransactionHash,
+				ChildProposal:         &childProposal,
+			},
+		}
+
+		_ = pbutils.RoundTrip(coordinationMsg, &coordinationMessage{})
+	}
+}
+
+func TestCpfpProposal_Unmarshal_InvalidChild(t *testing.T) {
+	heartbeatPayload, err := (&HeartbeatProposal{}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := proto.Marshal(
+		&pb.CpfpProposal{
+			ParentTransactionHash: make([]byte, 32),
+			ChildProposal: &pb.CoordinationProposal{
+				ActionType: uint32(ActionHeartbeat),
+				Payload:    heartbeatPayload,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = (&CpfpProposal{}).Unmarshal(data)
+
+	expectedErr := fmt.Errorf("invalid child proposal action type: [1]")
+	if !reflect.DeepEqual(expectedErr, err) {
+		t.Errorf(
+			"unexpected error\nexpected: [%v]\nactual:   [%v]",
+			expectedErr,
+			err,
+		)
+	}
+}
+
 func TestFuzzCoordinationMessage_MarshalingRoundtrip_WithNoopProposal(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		var (
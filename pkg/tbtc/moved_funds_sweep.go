@@ -88,6 +88,10 @@ type movedFundsSweepAction struct {
 	movedFundsSweepWallet wallet
 	transactionExecutor   *walletTransactionExecutor
 
+	// audit collects the audit trail of the action. Can be nil, in which
+	// case the action is not audited.
+	audit *walletActionAudit
+
 	proposal                     *MovedFundsSweepProposal
 	proposalProcessingStartBlock uint64
 	proposalExpiryBlock          uint64
@@ -103,16 +107,20 @@ func newMovedFundsSweepAction(
 	btcChain bitcoin.Chain,
 	movedFundsSweepWallet wallet,
 	signingExecutor walletSigningExecutor,
+	transactionTracker *walletTransactionTracker,
 	proposal *MovedFundsSweepProposal,
 	proposalProcessingStartBlock uint64,
 	proposalExpiryBlock uint64,
 	waitForBlockFn waitForBlockFn,
+	audit *walletActionAudit,
 ) *movedFundsSweepAction {
 	transactionExecutor := newWalletTransactionExecutor(
 		btcChain,
 		movedFundsSweepWallet,
 		signingExecutor,
+		transactionTracker,
 		waitForBlockFn,
+		audit,
 	)
 
 	return &movedFundsSweepAction{
@@ -121,6 +129,7 @@ func newMovedFundsSweepAction(
 		btcChain:                         btcChain,
 		movedFundsSweepWallet:            movedFundsSweepWallet,
 		transactionExecutor:              transactionExecutor,
+		audit:                            audit,
 		proposal:                         proposal,
 		proposalProcessingStartBlock:     proposalProcessingStartBlock,
 		proposalExpiryBlock:              proposalExpiryBlock,
@@ -143,6 +152,7 @@ func (mfsa *movedFundsSweepAction) execute() error {
 		mfsa.proposal,
 		mfsa.chain,
 	)
+	mfsa.audit.recordValidation(err)
 	if err != nil {
 		return fmt.Errorf("validate proposal step failed: [%v]", err)
 	}
@@ -230,6 +240,7 @@ func (mfsa *movedFundsSweepAction) execute() error {
 
 	err = mfsa.transactionExecutor.broadcastTransaction(
 		broadcastTxLogger,
+		ActionMovedFundsSweep,
 		movedFundsSweepTx,
 		mfsa.broadcastTimeout,
 		mfsa.broadcastCheckDelay,
@@ -106,12 +106,14 @@ func TestMovedFundsSweepAction_Execute(t *testing.T) {
 				bitcoinChain,
 				wallet,
 				signingExecutor,
+				nil,
 				proposal,
 				proposalProcessingStartBlock,
 				proposalExpiryBlock,
 				func(ctx context.Context, blockHeight uint64) error {
 					return nil
 				},
+				nil,
 			)
 
 			// Modify the default parameters of the action to make
@@ -84,6 +84,10 @@ type movingFundsAction struct {
 	movingFundsWallet   wallet
 	transactionExecutor *walletTransactionExecutor
 
+	// audit collects the audit trail of the action. Can be nil, in which
+	// case the action is not audited.
+	audit *walletActionAudit
+
 	proposal                     *MovingFundsProposal
 	proposalProcessingStartBlock uint64
 	proposalExpiryBlock          uint64
@@ -99,16 +103,20 @@ func newMovingFundsAction(
 	btcChain bitcoin.Chain,
 	movingFundsWallet wallet,
 	signingExecutor walletSigningExecutor,
+	transactionTracker *walletTransactionTracker,
 	proposal *MovingFundsProposal,
 	proposalProcessingStartBlock uint64,
 	proposalExpiryBlock uint64,
 	waitForBlockFn waitForBlockFn,
+	audit *walletActionAudit,
 ) *movingFundsAction {
 	transactionExecutor := newWalletTransactionExecutor(
 		btcChain,
 		movingFundsWallet,
 		signingExecutor,
+		transactionTracker,
 		waitForBlockFn,
+		audit,
 	)
 
 	return &movingFundsAction{
@@ -117,6 +125,7 @@ func newMovingFundsAction(
 		btcChain:                         btcChain,
 		movingFundsWallet:                movingFundsWallet,
 		transactionExecutor:              transactionExecutor,
+		audit:                            audit,
 		proposal:                         proposal,
 		proposalProcessingStartBlock:     proposalProcessingStartBlock,
 		proposalExpiryBlock:              proposalExpiryBlock,
@@ -159,6 +168,7 @@ func (mfa *movingFundsAction) execute() error {
 		mfa.proposal,
 		mfa.chain,
 	)
+	mfa.audit.recordValidation(err)
 	if err != nil {
 		return fmt.Errorf("validate proposal step failed: [%v]", err)
 	}
@@ -184,6 +194,7 @@ func (mfa *movingFundsAction) execute() error {
 		mfa.proposal,
 		mfa.chain,
 	)
+	mfa.audit.recordValidation(err)
 	if err != nil {
 		return fmt.Errorf("validate proposal step failed: [%v]", err)
 	}
@@ -244,6 +255,7 @@ func (mfa *movingFundsAction) execute() error {
 
 	err = mfa.transactionExecutor.broadcastTransaction(
 		broadcastTxLogger,
+		ActionMovingFunds,
 		movingFundsTx,
 		mfa.broadcastTimeout,
 		mfa.broadcastCheckDelay,
@@ -454,7 +466,7 @@ func ValidateMovingFundsSafetyMargin(
 
 	safetyMarginExpiresAt := walletChainData.MovingFundsRequestedAt.Add(safetyMargin)
 
-	if time.Now().Before(safetyMarginExpiresAt) {
+	if CurrentTime(chain).Before(safetyMarginExpiresAt) {
 		return fmt.Errorf("safety margin in force")
 	}
 
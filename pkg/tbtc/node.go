@@ -20,6 +20,7 @@ import (
 	"github.com/keep-network/keep-core/pkg/protocol/announcer"
 	"github.com/keep-network/keep-core/pkg/protocol/group"
 	"github.com/keep-network/keep-core/pkg/protocol/inactivity"
+	"github.com/keep-network/keep-core/pkg/tecdsa/resharing"
 	"github.com/keep-network/keep-core/pkg/tecdsa/signing"
 )
 
@@ -99,6 +100,17 @@ type node struct {
 	// wallet.
 	signingExecutors map[string]*signingExecutor
 
+	keyShareRefreshExecutorsMutex sync.Mutex
+	// keyShareRefreshExecutors is the cache holding key share refresh
+	// executors for specific wallets. The cache key is the uncompressed public
+	// key (with 04 prefix) of the wallet. The keyShareRefreshExecutor
+	// encapsulates the logic of refreshing the wallet's private key shares.
+	//
+	// keyShareRefreshExecutors MUST NOT be used outside this struct. Please
+	// use wallet actions and walletDispatcher to execute an action on an
+	// existing wallet.
+	keyShareRefreshExecutors map[string]*keyShareRefreshExecutor
+
 	coordinationExecutorsMutex sync.Mutex
 	// coordinationExecutors is the cache holding coordination executors for
 	// specific wallets. The cache key is the uncompressed public key
@@ -111,6 +123,24 @@ type node struct {
 	// proposalGenerator is the implementation of the coordination proposal
 	// generator used by the node.
 	proposalGenerator CoordinationProposalGenerator
+
+	// coordinationFaultLedger holds coordination faults observed by the node
+	// for all wallets it controls.
+	coordinationFaultLedger *coordinationFaultLedger
+
+	// walletTransactionTracker keeps track of Bitcoin transactions broadcast
+	// by the node on behalf of the wallets it controls.
+	walletTransactionTracker *walletTransactionTracker
+
+	// walletActionAuditLog keeps the audit trail of wallet actions executed
+	// by the node.
+	walletActionAuditLog *walletActionAuditLog
+
+	refreshedSigningFailuresMutex sync.Mutex
+	// refreshedSigningFailures counts subsequent failed signings of wallets
+	// whose refreshed key shares did not produce any signature yet. The key
+	// is the wallet public key hash.
+	refreshedSigningFailures map[[20]byte]int
 }
 
 func newNode(
@@ -120,6 +150,7 @@ func newNode(
 	netProvider net.Provider,
 	keyStorePersistance persistence.ProtectedHandle,
 	workPersistence persistence.BasicHandle,
+	walletActionAuditPersistence persistence.BasicHandle,
 	scheduler *generator.Scheduler,
 	proposalGenerator CoordinationProposalGenerator,
 	config Config,
@@ -132,6 +163,12 @@ func newNode(
 		return nil, fmt.Errorf("cannot create wallet registry: [%v]", err)
 	}
 
+	walletActionAuditLog := newWalletActionAuditLog(
+		walletActionAuditPersistence,
+		config.WalletActionAuditSegmentSize,
+		config.WalletActionAuditMaxSegments,
+	)
+
 	latch := generator.NewProtocolLatch()
 	scheduler.RegisterProtocol(latch)
 
@@ -146,8 +183,13 @@ func newNode(
 		heartbeatFailureCounter:  newHeartbeatFailureCounter(),
 		signingExecutors:         make(map[string]*signingExecutor),
 		inactivityClaimExecutors: make(map[string]*inactivityClaimExecutor),
+		keyShareRefreshExecutors: make(map[string]*keyShareRefreshExecutor),
 		coordinationExecutors:    make(map[string]*coordinationExecutor),
 		proposalGenerator:        proposalGenerator,
+		coordinationFaultLedger:  newCoordinationFaultLedger(workPersistence),
+		walletTransactionTracker: newWalletTransactionTracker(workPersistence),
+		walletActionAuditLog:     walletActionAuditLog,
+		refreshedSigningFailures: make(map[[20]byte]int),
 	}
 
 	// Archive any wallets that might have been closed or terminated while the
@@ -337,6 +379,7 @@ func (n *node) getSigningExecutor(
 		blockCounter.CurrentBlock,
 		n.waitForBlockHeight,
 		signingAttemptsLimit,
+		n.handleSigningOutcome,
 	)
 
 	n.signingExecutors[executorKey] = executor
@@ -527,6 +570,259 @@ func (n *node) getInactivityClaimExecutor(
 	return executor, true, nil
 }
 
+// getKeyShareRefreshExecutor gets the key share refresh executor responsible
+// for refreshing private key shares of a specific wallet whose part is
+// controlled by this node. The second boolean return value indicates whether
+// the node controls at least one signer for the given wallet.
+func (n *node) getKeyShareRefreshExecutor(
+	walletPublicKey *ecdsa.PublicKey,
+) (*keyShareRefreshExecutor, bool, error) {
+	n.keyShareRefreshExecutorsMutex.Lock()
+	defer n.keyShareRefreshExecutorsMutex.Unlock()
+
+	walletPublicKeyBytes, err := marshalPublicKey(walletPublicKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot marshal wallet public key: [%v]", err)
+	}
+
+	executorKey := hex.EncodeToString(walletPublicKeyBytes)
+
+	if executor, exists := n.keyShareRefreshExecutors[executorKey]; exists {
+		return executor, true, nil
+	}
+
+	executorLogger := logger.With(
+		zap.String("wallet", fmt.Sprintf("0x%x", walletPublicKeyBytes)),
+	)
+
+	signers := n.walletRegistry.getSigners(walletPublicKey)
+	if len(signers) == 0 {
+		// This is not an error because the node simply does not control
+		// the given wallet.
+		return nil, false, nil
+	}
+
+	// All signers belong to one wallet. Take that wallet from the first signer.
+	wallet := signers[0].wallet
+
+	channelName := fmt.Sprintf(
+		"%s-%s-refresh",
+		ProtocolName,
+		hex.EncodeToString(walletPublicKeyBytes),
+	)
+
+	broadcastChannel, err := n.netProvider.BroadcastChannelFor(channelName)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get broadcast channel: [%v]", err)
+	}
+
+	resharing.RegisterUnmarshallers(broadcastChannel)
+	announcer.RegisterUnmarshaller(broadcastChannel)
+	broadcastChannel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &keyShareRefreshConfirmedMessage{}
+	})
+
+	membershipValidator := group.NewMembershipValidator(
+		executorLogger,
+		wallet.signingGroupOperators,
+		n.chain.Signing(),
+	)
+
+	err = broadcastChannel.SetFilter(membershipValidator.IsInGroup)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"could not set filter for channel [%v]: [%v]",
+			broadcastChannel.Name(),
+			err,
+		)
+	}
+
+	blockCounter, err := n.chain.BlockCounter()
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"could not get block counter: [%v]",
+			err,
+		)
+	}
+
+	executorLogger.Infof(
+		"key share refresh executor created; controlling [%v] signers",
+		len(signers),
+	)
+
+	executor := newKeyShareRefreshExecutor(
+		signers,
+		broadcastChannel,
+		membershipValidator,
+		n.groupParameters,
+		n.protocolLatch,
+		generateKeyShareRefreshPreParams,
+		blockCounter.CurrentBlock,
+		n.waitForBlockHeight,
+	)
+
+	n.keyShareRefreshExecutors[executorKey] = executor
+
+	return executor, true, nil
+}
+
+// newRefreshedSignersSigningExecutor creates a signing executor operating on
+// the given signers holding refreshed key shares that are not confirmed yet.
+// The executor is not cached as the signers are not registered in the wallet
+// registry. It uses the broadcast channel and the membership validator of
+// the wallet's signing executor.
+func (n *node) newRefreshedSignersSigningExecutor(
+	signers []*signer,
+) (keyShareRefreshSigningExecutor, error) {
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no signers given")
+	}
+
+	// All signers belong to one wallet. Take the wallet public key from
+	// the first signer.
+	walletSigningExecutor, ok, err := n.getSigningExecutor(
+		signers[0].wallet.publicKey,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get signing executor: [%v]", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("node does not control signers of the wallet")
+	}
+
+	return newSigningExecutor(
+		signers,
+		walletSigningExecutor.broadcastChannel,
+		walletSigningExecutor.membershipValidator,
+		n.groupParameters,
+		n.protocolLatch,
+		walletSigningExecutor.getCurrentBlockFn,
+		walletSigningExecutor.waitForBlockFn,
+		signingAttemptsLimit,
+		// Signatures produced here only confirm refreshed key shares
+		// before they replace the current ones.
+		nil,
+	), nil
+}
+
+// replaceWalletSigners replaces signers of the given wallet with the given
+// ones and invalidates all cached executors that hold the replaced signers.
+// This way, the next action executed by the wallet uses the new signers.
+// The replaced signers are kept by the wallet registry until the new ones
+// produce their first signature; see handleSigningOutcome.
+func (n *node) replaceWalletSigners(wallet wallet, signers []*signer) error {
+	err := n.walletRegistry.replaceSigners(wallet.publicKey, signers)
+	if err != nil {
+		return fmt.Errorf("cannot replace signers in registry: [%v]", err)
+	}
+
+	n.invalidateWalletExecutors(wallet)
+
+	return nil
+}
+
+// invalidateWalletExecutors removes all cached executors of the given
+// wallet that hold its signers.
+func (n *node) invalidateWalletExecutors(wallet wallet) {
+	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
+	if err != nil {
+		// Executors could not be cached for such a wallet either.
+		logger.Errorf("cannot marshal wallet public key: [%v]", err)
+		return
+	}
+
+	executorKey := hex.EncodeToString(walletPublicKeyBytes)
+
+	n.signingExecutorsMutex.Lock()
+	delete(n.signingExecutors, executorKey)
+	n.signingExecutorsMutex.Unlock()
+
+	n.inactivityClaimExecutorMutex.Lock()
+	delete(n.inactivityClaimExecutors, executorKey)
+	n.inactivityClaimExecutorMutex.Unlock()
+
+	n.keyShareRefreshExecutorsMutex.Lock()
+	delete(n.keyShareRefreshExecutors, executorKey)
+	n.keyShareRefreshExecutorsMutex.Unlock()
+}
+
+// handleSigningOutcome handles the outcome of a signing performed by
+// the signing executor of the given wallet. It matters only if signers of
+// the wallet were replaced by a key share refresh and the refreshed signers
+// did not produce any signature yet. Once they produce one, previous
+// signers are dropped and the requested key share refresh is recorded as
+// completed. If they fail to sign keyShareRefreshRollbackSigningFailures
+// times in a row, the wallet is rolled back to the previous signers. Just
+// as signatures, signing failures are seen by all members so all members
+// that switched to refreshed key shares roll back together.
+func (n *node) handleSigningOutcome(wallet wallet, err error) {
+	if !n.walletRegistry.hasPreviousSigners(wallet.publicKey) {
+		return
+	}
+
+	walletPublicKeyHash := bitcoin.PublicKeyHash(wallet.publicKey)
+
+	n.refreshedSigningFailuresMutex.Lock()
+	defer n.refreshedSigningFailuresMutex.Unlock()
+
+	if err == nil {
+		delete(n.refreshedSigningFailures, walletPublicKeyHash)
+
+		err := n.walletRegistry.dropPreviousSigners(wallet.publicKey)
+		if err != nil {
+			logger.Errorf(
+				"cannot drop previous key shares of wallet [0x%x]: [%v]",
+				walletPublicKeyHash,
+				err,
+			)
+			return
+		}
+
+		logger.Infof(
+			"refreshed key shares of wallet [0x%x] produced their first "+
+				"signature; previous key shares dropped",
+			walletPublicKeyHash,
+		)
+
+		return
+	}
+
+	n.refreshedSigningFailures[walletPublicKeyHash]++
+	failures := n.refreshedSigningFailures[walletPublicKeyHash]
+
+	if failures < keyShareRefreshRollbackSigningFailures {
+		logger.Warnf(
+			"refreshed key shares of wallet [0x%x] failed to sign [%v] "+
+				"times in a row; rolling back after [%v] failures",
+			walletPublicKeyHash,
+			failures,
+			keyShareRefreshRollbackSigningFailures,
+		)
+		return
+	}
+
+	err = n.walletRegistry.rollbackSigners(wallet.publicKey)
+	if err != nil {
+		logger.Errorf(
+			"cannot roll back refreshed key shares of wallet [0x%x]: [%v]",
+			walletPublicKeyHash,
+			err,
+		)
+		return
+	}
+
+	delete(n.refreshedSigningFailures, walletPublicKeyHash)
+
+	n.invalidateWalletExecutors(wallet)
+
+	logger.Warnf(
+		"refreshed key shares of wallet [0x%x] failed to sign [%v] times "+
+			"in a row; rolled back to previous key shares",
+		walletPublicKeyHash,
+		failures,
+	)
+}
+
 // handleHeartbeatProposal handles an incoming heartbeat proposal by
 // orchestrating and dispatching an appropriate wallet action.
 func (n *node) handleHeartbeatProposal(
@@ -534,6 +830,7 @@ func (n *node) handleHeartbeatProposal(
 	proposal *HeartbeatProposal,
 	startBlock uint64,
 	expiryBlock uint64,
+	audit *walletActionAudit,
 ) {
 	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
 	if err != nil {
@@ -603,9 +900,10 @@ func (n *node) handleHeartbeatProposal(
 		startBlock,
 		expiryBlock,
 		n.waitForBlockHeight,
+		audit,
 	)
 
-	err = n.walletDispatcher.dispatch(action)
+	err = n.walletDispatcher.dispatch(action, audit)
 	if err != nil {
 		walletActionLogger.Errorf("cannot dispatch wallet action: [%v]", err)
 		return
@@ -621,6 +919,7 @@ func (n *node) handleDepositSweepProposal(
 	proposal *DepositSweepProposal,
 	startBlock uint64,
 	expiryBlock uint64,
+	audit *walletActionAudit,
 ) {
 	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
 	if err != nil {
@@ -667,13 +966,15 @@ func (n *node) handleDepositSweepProposal(
 		n.btcChain,
 		wallet,
 		signingExecutor,
+		n.walletTransactionTracker,
 		proposal,
 		startBlock,
 		expiryBlock,
 		n.waitForBlockHeight,
+		audit,
 	)
 
-	err = n.walletDispatcher.dispatch(action)
+	err = n.walletDispatcher.dispatch(action, audit)
 	if err != nil {
 		walletActionLogger.Errorf("cannot dispatch wallet action: [%v]", err)
 		return
@@ -689,6 +990,7 @@ func (n *node) handleRedemptionProposal(
 	proposal *RedemptionProposal,
 	startBlock uint64,
 	expiryBlock uint64,
+	audit *walletActionAudit,
 ) {
 	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
 	if err != nil {
@@ -735,13 +1037,15 @@ func (n *node) handleRedemptionProposal(
 		n.btcChain,
 		wallet,
 		signingExecutor,
+		n.walletTransactionTracker,
 		proposal,
 		startBlock,
 		expiryBlock,
 		n.waitForBlockHeight,
+		audit,
 	)
 
-	err = n.walletDispatcher.dispatch(action)
+	err = n.walletDispatcher.dispatch(action, audit)
 	if err != nil {
 		walletActionLogger.Errorf("cannot dispatch wallet action: [%v]", err)
 		return
@@ -757,6 +1061,7 @@ func (n *node) handleMovingFundsProposal(
 	proposal *MovingFundsProposal,
 	startBlock uint64,
 	expiryBlock uint64,
+	audit *walletActionAudit,
 ) {
 	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
 	if err != nil {
@@ -803,13 +1108,15 @@ func (n *node) handleMovingFundsProposal(
 		n.btcChain,
 		wallet,
 		signingExecutor,
+		n.walletTransactionTracker,
 		proposal,
 		startBlock,
 		expiryBlock,
 		n.waitForBlockHeight,
+		audit,
 	)
 
-	err = n.walletDispatcher.dispatch(action)
+	err = n.walletDispatcher.dispatch(action, audit)
 	if err != nil {
 		walletActionLogger.Errorf("cannot dispatch wallet action: [%v]", err)
 		return
@@ -825,6 +1132,7 @@ func (n *node) handleMovedFundsSweepProposal(
 	proposal *MovedFundsSweepProposal,
 	startBlock uint64,
 	expiryBlock uint64,
+	audit *walletActionAudit,
 ) {
 	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
 	if err != nil {
@@ -871,13 +1179,240 @@ func (n *node) handleMovedFundsSweepProposal(
 		n.btcChain,
 		wallet,
 		signingExecutor,
+		n.walletTransactionTracker,
 		proposal,
 		startBlock,
 		expiryBlock,
 		n.waitForBlockHeight,
+		audit,
 	)
 
-	err = n.walletDispatcher.dispatch(action)
+	err = n.walletDispatcher.dispatch(action, audit)
+	if err != nil {
+		walletActionLogger.Errorf("cannot dispatch wallet action: [%v]", err)
+		return
+	}
+
+	walletActionLogger.Infof("wallet action dispatched successfully")
+}
+
+// handleRbfProposal handles an incoming RBF proposal by orchestrating and
+// dispatching an appropriate wallet action.
+func (n *node) handleRbfProposal(
+	wallet wallet,
+	proposal *RbfProposal,
+	startBlock uint64,
+	expiryBlock uint64,
+	audit *walletActionAudit,
+) {
+	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
+	if err != nil {
+		logger.Errorf("cannot marshal wallet public key: [%v]", err)
+		return
+	}
+
+	signingExecutor, ok, err := n.getSigningExecutor(wallet.publicKey)
+	if err != nil {
+		logger.Errorf("cannot get signing executor: [%v]", err)
+		return
+	}
+	// This check is actually redundant. We know the node controls some
+	// wallet signers as we just got the wallet from the registry using their
+	// public key hash. However, we are doing it just in case. The API
+	// contract of getSigningExecutor may change one day.
+	if !ok {
+		logger.Infof(
+			"node does not control signers of wallet PKH [0x%x]; "+
+				"ignoring the received RBF proposal",
+			walletPublicKeyBytes,
+		)
+		return
+	}
+
+	logger.Infof(
+		"starting orchestration of the RBF action for wallet "+
+			"[0x%x]; 20-byte public key hash of that wallet is [0x%x]",
+		walletPublicKeyBytes,
+		bitcoin.PublicKeyHash(wallet.publicKey),
+	)
+
+	walletActionLogger := logger.With(
+		zap.String("wallet", fmt.Sprintf("0x%x", walletPublicKeyBytes)),
+		zap.String("action", ActionRbf.String()),
+		zap.Uint64("startBlock", startBlock),
+		zap.Uint64("expiryBlock", expiryBlock),
+	)
+	walletActionLogger.Infof("dispatching wallet action")
+
+	action := newRbfAction(
+		walletActionLogger,
+		n.chain,
+		n.btcChain,
+		wallet,
+		signingExecutor,
+		n.walletTransactionTracker,
+		proposal,
+		startBlock,
+		expiryBlock,
+		n.waitForBlockHeight,
+		audit,
+	)
+
+	err = n.walletDispatcher.dispatch(action, audit)
+	if err != nil {
+		walletActionLogger.Errorf("cannot dispatch wallet action: [%v]", err)
+		return
+	}
+
+	walletActionLogger.Infof("wallet action dispatched successfully")
+}
+
+// handleCpfpProposal handles an incoming CPFP proposal by orchestrating and
+// dispatching an appropriate wallet action.
+func (n *node) handleCpfpProposal(
+	wallet wallet,
+	proposal *CpfpProposal,
+	startBlock uint64,
+	expiryBlock uint64,
+	audit *walletActionAudit,
+) {
+	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
+	if err != nil {
+		logger.Errorf("cannot marshal wallet public key: [%v]", err)
+		return
+	}
+
+	signingExecutor, ok, err := n.getSigningExecutor(wallet.publicKey)
+	if err != nil {
+		logger.Errorf("cannot get signing executor: [%v]", err)
+		return
+	}
+	// This check is actually redundant. We know the node controls some
+	// wallet signers as we just got the wallet from the registry using their
+	// public key hash. However, we are doing it just in case. The API
+	// contract of getSigningExecutor may change one day.
+	if !ok {
+		logger.Infof(
+			"node does not control signers of wallet PKH [0x%x]; "+
+				"ignoring the received CPFP proposal",
+			walletPublicKeyBytes,
+		)
+		return
+	}
+
+	logger.Infof(
+		"starting orchestration of the CPFP action for wallet "+
+			"[0x%x]; 20-byte public key hash of that wallet is [0x%x]",
+		walletPublicKeyBytes,
+		bitcoin.PublicKeyHash(wallet.publicKey),
+	)
+
+	walletActionLogger := logger.With(
+		zap.String("wallet", fmt.Sprintf("0x%x", walletPublicKeyBytes)),
+		zap.String("action", ActionCpfp.String()),
+		zap.Uint64("startBlock", startBlock),
+		zap.Uint64("expiryBlock", expiryBlock),
+	)
+	walletActionLogger.Infof("dispatching wallet action")
+
+	action := newCpfpAction(
+		walletActionLogger,
+		n.chain,
+		n.btcChain,
+		wallet,
+		signingExecutor,
+		n.walletTransactionTracker,
+		proposal,
+		startBlock,
+		expiryBlock,
+		n.waitForBlockHeight,
+		audit,
+	)
+
+	err = n.walletDispatcher.dispatch(action, audit)
+	if err != nil {
+		walletActionLogger.Errorf("cannot dispatch wallet action: [%v]", err)
+		return
+	}
+
+	walletActionLogger.Infof("wallet action dispatched successfully")
+}
+
+// handleKeyShareRefreshProposal handles an incoming key share refresh
+// proposal by orchestrating and dispatching an appropriate wallet action.
+func (n *node) handleKeyShareRefreshProposal(
+	wallet wallet,
+	proposal *KeyShareRefreshProposal,
+	startBlock uint64,
+	expiryBlock uint64,
+	audit *walletActionAudit,
+) {
+	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
+	if err != nil {
+		logger.Errorf("cannot marshal wallet public key: [%v]", err)
+		return
+	}
+
+	if n.walletRegistry.hasPreviousSigners(wallet.publicKey) {
+		err := fmt.Errorf(
+			"refreshed key shares of wallet [0x%x] did not sign anything yet",
+			walletPublicKeyBytes,
+		)
+		logger.Warnf("rejecting key share refresh request: [%v]", err)
+		audit.reject(err)
+		return
+	}
+
+	keyShareRefreshExecutor, ok, err := n.getKeyShareRefreshExecutor(
+		wallet.publicKey,
+	)
+	if err != nil {
+		logger.Errorf("cannot get key share refresh executor: [%v]", err)
+		return
+	}
+	// This check is actually redundant. We know the node controls some
+	// wallet signers as we just got the wallet from the registry using their
+	// public key hash. However, we are doing it just in case. The API
+	// contract of getKeyShareRefreshExecutor may change one day.
+	if !ok {
+		logger.Infof(
+			"node does not control signers of wallet [0x%x]; "+
+				"ignoring the received key share refresh request",
+			walletPublicKeyBytes,
+		)
+		return
+	}
+
+	logger.Infof(
+		"starting orchestration of the key share refresh action for wallet "+
+			"[0x%x]; 20-byte public key hash of that wallet is [0x%x]",
+		walletPublicKeyBytes,
+		bitcoin.PublicKeyHash(wallet.publicKey),
+	)
+
+	walletActionLogger := logger.With(
+		zap.String("wallet", fmt.Sprintf("0x%x", walletPublicKeyBytes)),
+		zap.String("action", ActionKeyShareRefresh.String()),
+		zap.Uint64("startBlock", startBlock),
+		zap.Uint64("expiryBlock", expiryBlock),
+	)
+	walletActionLogger.Infof("dispatching wallet action")
+
+	action := newKeyShareRefreshAction(
+		walletActionLogger,
+		n.chain,
+		wallet,
+		keyShareRefreshExecutor,
+		n.newRefreshedSignersSigningExecutor,
+		n.replaceWalletSigners,
+		proposal,
+		startBlock,
+		expiryBlock,
+		n.waitForBlockHeight,
+		audit,
+	)
+
+	err = n.walletDispatcher.dispatch(action, audit)
 	if err != nil {
 		walletActionLogger.Errorf("cannot dispatch wallet action: [%v]", err)
 		return
@@ -1030,8 +1565,20 @@ func executeCoordinationProcedure(
 func processCoordinationResult(node *node, result *coordinationResult) {
 	logger.Infof("processing coordination result [%s]", result)
 
-	// TODO: In the future, create coordination faults cache and
-	//       record faults from the processed results there.
+	if len(result.faults) > 0 {
+		err := node.coordinationFaultLedger.record(
+			bitcoin.PublicKeyHash(result.wallet.publicKey),
+			result.window,
+			result.faults,
+		)
+		if err != nil {
+			logger.Errorf(
+				"cannot record faults of coordination result [%s]: [%v]",
+				result,
+				err,
+			)
+		}
+	}
 
 	proposedAction := result.proposal.ActionType()
 
@@ -1044,6 +1591,8 @@ func processCoordinationResult(node *node, result *coordinationResult) {
 	startBlock := result.window.endBlock()
 	expiryBlock := startBlock + result.proposal.ValidityBlocks()
 
+	audit := node.walletActionAuditLog.newAudit(result, startBlock, expiryBlock)
+
 	switch proposedAction {
 	case ActionHeartbeat:
 		if proposal, ok := result.proposal.(*HeartbeatProposal); ok {
@@ -1052,6 +1601,7 @@ func processCoordinationResult(node *node, result *coordinationResult) {
 				proposal,
 				startBlock,
 				expiryBlock,
+				audit,
 			)
 		}
 	case ActionDepositSweep:
@@ -1061,6 +1611,7 @@ func processCoordinationResult(node *node, result *coordinationResult) {
 				proposal,
 				startBlock,
 				expiryBlock,
+				audit,
 			)
 		}
 	case ActionRedemption:
@@ -1070,6 +1621,7 @@ func processCoordinationResult(node *node, result *coordinationResult) {
 				proposal,
 				startBlock,
 				expiryBlock,
+				audit,
 			)
 		}
 	case ActionMovingFunds:
@@ -1079,6 +1631,7 @@ func processCoordinationResult(node *node, result *coordinationResult) {
 				proposal,
 				startBlock,
 				expiryBlock,
+				audit,
 			)
 		}
 	case ActionMovedFundsSweep:
@@ -1088,6 +1641,37 @@ func processCoordinationResult(node *node, result *coordinationResult) {
 				proposal,
 				startBlock,
 				expiryBlock,
+				audit,
+			)
+		}
+	case ActionRbf:
+		if proposal, ok := result.proposal.(*RbfProposal); ok {
+			node.handleRbfProposal(
+				result.wallet,
+				proposal,
+				startBlock,
+				expiryBlock,
+				audit,
+			)
+		}
+	case ActionCpfp:
+		if proposal, ok := result.proposal.(*CpfpProposal); ok {
+			node.handleCpfpProposal(
+				result.wallet,
+				proposal,
+				startBlock,
+				expiryBlock,
+				audit,
+			)
+		}
+	case ActionKeyShareRefresh:
+		if proposal, ok := result.proposal.(*KeyShareRefreshProposal); ok {
+			node.handleKeyShareRefreshProposal(
+				result.wallet,
+				proposal,
+				startBlock,
+				expiryBlock,
+				audit,
 			)
 		}
 	default:
@@ -57,6 +57,7 @@ func TestNode_GetSigningExecutor(t *testing.T) {
 		localProvider,
 		keyStorePersistence,
 		&mockPersistenceHandle{},
+		nil,
 		generator.StartScheduler(),
 		&mockCoordinationProposalGenerator{},
 		Config{},
@@ -189,6 +190,7 @@ func TestNode_GetCoordinationExecutor(t *testing.T) {
 		localProvider,
 		keyStorePersistence,
 		&mockPersistenceHandle{},
+		nil,
 		generator.StartScheduler(),
 		&mockCoordinationProposalGenerator{},
 		Config{},
@@ -287,6 +289,129 @@ func TestNode_GetCoordinationExecutor(t *testing.T) {
 	}
 }
 
+func TestNode_HandleSigningOutcome(t *testing.T) {
+	tests := map[string]struct {
+		outcomes                []error
+		expectedPreviousSigners bool
+		expectedRolledBack      bool
+	}{
+		"refreshed signers signed": {
+			outcomes:                []error{fmt.Errorf("timeout"), nil},
+			expectedPreviousSigners: false,
+			expectedRolledBack:      false,
+		},
+		"refreshed signers failed below the rollback limit": {
+			outcomes: []error{
+				fmt.Errorf("timeout"),
+				fmt.Errorf("timeout"),
+			},
+			expectedPreviousSigners: true,
+			expectedRolledBack:      false,
+		},
+		"refreshed signers failed at the rollback limit": {
+			outcomes: []error{
+				fmt.Errorf("timeout"),
+				fmt.Errorf("timeout"),
+				fmt.Errorf("timeout"),
+			},
+			expectedPreviousSigners: false,
+			expectedRolledBack:      true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			originalSigner := createMockSigner(t)
+
+			walletPublicKeyHash := bitcoin.PublicKeyHash(
+				originalSigner.wallet.publicKey,
+			)
+
+			localChain := Connect()
+
+			walletID, err := localChain.CalculateWalletID(
+				originalSigner.wallet.publicKey,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			localChain.setWallet(
+				walletPublicKeyHash,
+				&WalletChainData{
+					EcdsaWalletID: walletID,
+					State:         StateLive,
+				},
+			)
+
+			node, err := newNode(
+				&GroupParameters{
+					GroupSize:       5,
+					GroupQuorum:     4,
+					HonestThreshold: 3,
+				},
+				localChain,
+				newLocalBitcoinChain(),
+				local.Connect(),
+				createMockKeyStorePersistence(t, originalSigner),
+				&mockPersistenceHandle{},
+				nil,
+				generator.StartScheduler(),
+				&mockCoordinationProposalGenerator{},
+				Config{},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(2)
+			if err != nil {
+				t.Fatalf("failed to load test data: [%v]", err)
+			}
+
+			refreshedSigner := newSigner(
+				originalSigner.wallet.publicKey,
+				originalSigner.wallet.signingGroupOperators,
+				originalSigner.signingGroupMemberIndex,
+				tecdsa.NewPrivateKeyShare(testData[1]),
+			)
+
+			err = node.replaceWalletSigners(
+				originalSigner.wallet,
+				[]*signer{refreshedSigner},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, outcome := range test.outcomes {
+				node.handleSigningOutcome(originalSigner.wallet, outcome)
+			}
+
+			testutils.AssertBoolsEqual(
+				t,
+				"previous signers presence",
+				test.expectedPreviousSigners,
+				node.walletRegistry.hasPreviousSigners(
+					originalSigner.wallet.publicKey,
+				),
+			)
+
+			expectedSigners := []*signer{refreshedSigner}
+			if test.expectedRolledBack {
+				expectedSigners = []*signer{originalSigner}
+			}
+
+			if !reflect.DeepEqual(
+				expectedSigners,
+				node.walletRegistry.getSigners(originalSigner.wallet.publicKey),
+			) {
+				t.Errorf("unexpected wallet signers")
+			}
+		})
+	}
+}
+
 func TestNode_RunCoordinationLayer(t *testing.T) {
 	groupParameters := &GroupParameters{
 		GroupSize:       5,
@@ -326,6 +451,7 @@ func TestNode_RunCoordinationLayer(t *testing.T) {
 		localProvider,
 		keyStorePersistence,
 		&mockPersistenceHandle{},
+		nil,
 		generator.StartScheduler(),
 		&mockCoordinationProposalGenerator{},
 		Config{},
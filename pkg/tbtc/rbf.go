@@ -0,0 +1,1105 @@
+package tbtc
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ipfs/go-log/v2"
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+const (
+	// rbfProposalValidityBlocks determines the RBF proposal validity time
+	// expressed in blocks. In other words, this is the worst-case time for
+	// a fee bump during which the wallet is busy and cannot take another
+	// actions. The value of 600 blocks is roughly 2 hours, assuming 12 seconds
+	// per block.
+	rbfProposalValidityBlocks = 600
+	// rbfSigningTimeoutSafetyMarginBlocks determines the duration of the
+	// safety margin that must be preserved between the signing timeout and
+	// the timeout of the entire RBF action. This safety margin prevents
+	// against the case where signing completes late and there is not enough
+	// time to broadcast the replacement transaction properly. In such a case,
+	// wallet signatures may leak and make the wallet subject of fraud
+	// accusations. Usage of the safety margin ensures there is enough time to
+	// perform post-signing steps of the RBF action. The value of 300 blocks
+	// is roughly 1 hour, assuming 12 seconds per block.
+	rbfSigningTimeoutSafetyMarginBlocks = 300
+	// rbfBroadcastTimeout determines the time window for replacement
+	// transaction broadcast. It is guaranteed that at least
+	// rbfSigningTimeoutSafetyMarginBlocks is preserved for the broadcast step.
+	// However, the happy path for the broadcast step is usually quick and few
+	// retries are needed to recover from temporary problems. That said, if the
+	// broadcast step does not succeed in a tight timeframe, there is no point
+	// to retry for the entire possible time window. Hence, the timeout for
+	// broadcast step is set as 25% of the entire time widow determined by
+	// rbfSigningTimeoutSafetyMarginBlocks.
+	rbfBroadcastTimeout = 15 * time.Minute
+	// rbfBroadcastCheckDelay determines the delay that must be preserved
+	// between transaction broadcast and the check that ensures the transaction
+	// is known on the Bitcoin chain. This delay is needed as spreading the
+	// transaction over the Bitcoin network takes time.
+	rbfBroadcastCheckDelay = 1 * time.Minute
+)
+
+const (
+	// RbfStuckTransactionConfirmations determines how many confirmations all
+	// outputs spent by an unconfirmed wallet transaction must have for the
+	// transaction to be considered stuck and eligible for a fee bump. Unlike
+	// the time the transaction was broadcast at, known only to operators that
+	// broadcast it, confirmations are the same for all wallet operators so
+	// they agree on whether the transaction is stuck. The value of 36 blocks
+	// is roughly 6 hours.
+	RbfStuckTransactionConfirmations = 36
+	// RbfIncrementalRelayFeeRate is the incremental relay fee rate, expressed
+	// in sat/vbyte, used by Bitcoin nodes to accept a replacement transaction.
+	// The replacement must pay the fee of the replaced transaction plus this
+	// rate multiplied by the replacement's virtual size. The value of 1
+	// sat/vbyte is the Bitcoin Core default.
+	RbfIncrementalRelayFeeRate = 1
+)
+
+// RbfProposal represents a replace-by-fee proposal issued by a wallet's
+// coordination leader. The proposal asks the wallet to re-sign a stuck
+// transaction, spending the same inputs, with a higher fee.
+//
+// Wallet transactions do not signal BIP-125 replaceability so the replacement
+// transaction relies on the full-RBF relay policy, enabled by default since
+// Bitcoin Core v28.0.
+type RbfProposal struct {
+	TransactionHash bitcoin.Hash
+	NewFee          *big.Int
+}
+
+func (rp *RbfProposal) ActionType() WalletActionType {
+	return ActionRbf
+}
+
+func (rp *RbfProposal) ValidityBlocks() uint64 {
+	return rbfProposalValidityBlocks
+}
+
+// rbfAction is a walletAction implementation handling fee bumps of wallet
+// transactions stuck in the Bitcoin mempool.
+type rbfAction struct {
+	logger   *zap.SugaredLogger
+	chain    Chain
+	btcChain bitcoin.Chain
+
+	rbfWallet           wallet
+	transactionExecutor *walletTransactionExecutor
+	transactionTracker  *walletTransactionTracker
+
+	// audit collects the audit trail of the action. Can be nil, in which
+	// case the action is not audited.
+	audit *walletActionAudit
+
+	proposal                     *RbfProposal
+	proposalProcessingStartBlock uint64
+	proposalExpiryBlock          uint64
+
+	signingTimeoutSafetyMarginBlocks uint64
+	broadcastTimeout                 time.Duration
+	broadcastCheckDelay              time.Duration
+}
+
+func newRbfAction(
+	logger *zap.SugaredLogger,
+	chain Chain,
+	btcChain bitcoin.Chain,
+	rbfWallet wallet,
+	signingExecutor walletSigningExecutor,
+	transactionTracker *walletTransactionTracker,
+	proposal *RbfProposal,
+	proposalProcessingStartBlock uint64,
+	proposalExpiryBlock uint64,
+	waitForBlockFn waitForBlockFn,
+	audit *walletActionAudit,
+) *rbfAction {
+	transactionExecutor := newWalletTransactionExecutor(
+		btcChain,
+		rbfWallet,
+		signingExecutor,
+		transactionTracker,
+		waitForBlockFn,
+		audit,
+	)
+
+	return &rbfAction{
+		logger:                           logger,
+		chain:                            chain,
+		btcChain:                         btcChain,
+		rbfWallet:                        rbfWallet,
+		transactionExecutor:              transactionExecutor,
+		audit:                            audit,
+		transactionTracker:               transactionTracker,
+		proposal:                         proposal,
+		proposalProcessingStartBlock:     proposalProcessingStartBlock,
+		proposalExpiryBlock:              proposalExpiryBlock,
+		signingTimeoutSafetyMarginBlocks: rbfSigningTimeoutSafetyMarginBlocks,
+		broadcastTimeout:                 rbfBroadcastTimeout,
+		broadcastCheckDelay:              rbfBroadcastCheckDelay,
+	}
+}
+
+func (ra *rbfAction) execute() error {
+	validateProposalLogger := ra.logger.With(
+		zap.String("step", "validateProposal"),
+	)
+
+	walletPublicKeyHash := bitcoin.PublicKeyHash(ra.wallet().publicKey)
+
+	candidate, err := ValidateRbfProposal(
+		validateProposalLogger,
+		walletPublicKeyHash,
+		ra.proposal,
+		ra.chain,
+		ra.btcChain,
+	)
+	if err == nil && ra.transactionTracker != nil {
+		// A child transaction spending outputs of the replaced transaction
+		// may have been signed by the wallet without reaching the mempool.
+		// The replacement would invalidate such a child so the node refuses
+		// to sign it if the child is known to its tracker.
+		child, ok := ra.transactionTracker.spendingTransaction(
+			walletPublicKeyHash,
+			ra.proposal.TransactionHash,
+		)
+		if ok {
+			err = fmt.Errorf(
+				"transaction's output is already spent by transaction [%s] "+
+					"signed by the wallet",
+				child.Transaction.Hash().Hex(bitcoin.ReversedByteOrder),
+			)
+		}
+	}
+	ra.audit.recordValidation(err)
+	if err != nil {
+		return fmt.Errorf("validate proposal step failed: [%v]", err)
+	}
+
+	unsignedRbfTx, err := assembleRbfTransaction(
+		ra.chain,
+		ra.btcChain,
+		walletPublicKeyHash,
+		candidate,
+		ra.proposal.NewFee.Int64(),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"error while assembling replacement transaction: [%v]",
+			err,
+		)
+	}
+
+	signTxLogger := ra.logger.With(
+		zap.String("step", "signTransaction"),
+	)
+
+	// Just in case. This should never happen.
+	if ra.proposalExpiryBlock < ra.signingTimeoutSafetyMarginBlocks {
+		return fmt.Errorf("invalid proposal expiry block")
+	}
+
+	rbfTx, err := ra.transactionExecutor.signTransaction(
+		signTxLogger,
+		unsignedRbfTx,
+		ra.proposalProcessingStartBlock,
+		ra.proposalExpiryBlock-ra.signingTimeoutSafetyMarginBlocks,
+	)
+	if err != nil {
+		return fmt.Errorf("sign transaction step failed: [%v]", err)
+	}
+
+	broadcastTxLogger := ra.logger.With(
+		zap.String("step", "broadcastTransaction"),
+		zap.String(
+			"replacedTxHash",
+			ra.proposal.TransactionHash.Hex(bitcoin.ReversedByteOrder),
+		),
+		zap.String("rbfTxHash", rbfTx.Hash().Hex(bitcoin.ReversedByteOrder)),
+	)
+
+	// The replacement is tracked under the action type of the replaced
+	// transaction so it can be fee-bumped again if needed.
+	err = ra.transactionExecutor.broadcastTransaction(
+		broadcastTxLogger,
+		candidate.ActionType,
+		rbfTx,
+		ra.broadcastTimeout,
+		ra.broadcastCheckDelay,
+	)
+	if err != nil {
+		return fmt.Errorf("broadcast transaction step failed: [%v]", err)
+	}
+
+	return nil
+}
+
+func (ra *rbfAction) wallet() wallet {
+	return ra.rbfWallet
+}
+
+func (ra *rbfAction) actionType() WalletActionType {
+	return ActionRbf
+}
+
+// ValidateRbfProposal checks the RBF proposal against the transaction being
+// subject of the fee bump. The transaction is rebuilt from the Bitcoin chain
+// and must be stuck, as determined by FindRbfCandidate with
+// RbfStuckTransactionConfirmations. The proposed fee must fall within the
+// bounds returned by ComputeRbfFeeBounds and, for deposit sweeps, its share
+// incurred by each deposit must fit the deposit's maximum fee. Returns the
+// transaction being replaced if the proposal is valid.
+func ValidateRbfProposal(
+	validateProposalLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+	proposal *RbfProposal,
+	chain BridgeChain,
+	btcChain bitcoin.Chain,
+) (*RbfCandidate, error) {
+	validateProposalLogger.Infof("checking whether the transaction is stuck")
+
+	candidate, err := FindRbfCandidate(
+		walletPublicKeyHash,
+		proposal.TransactionHash,
+		RbfStuckTransactionConfirmations,
+		chain,
+		btcChain,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("transaction cannot be replaced: [%v]", err)
+	}
+
+	validateProposalLogger.Infof("checking the proposed fee")
+
+	details, err := analyzeRbfTransaction(
+		chain,
+		btcChain,
+		walletPublicKeyHash,
+		candidate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot analyze transaction: [%v]", err)
+	}
+
+	minFee, maxFee, err := computeRbfFeeBounds(
+		chain,
+		candidate,
+		details,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compute fee bounds: [%v]", err)
+	}
+
+	if proposal.NewFee == nil || !proposal.NewFee.IsInt64() {
+		return nil, fmt.Errorf("invalid new fee")
+	}
+
+	newFee := proposal.NewFee.Int64()
+	if newFee < minFee || newFee > maxFee {
+		return nil, fmt.Errorf(
+			"new fee [%v] is out of the allowed range [%v, %v]",
+			newFee,
+			minFee,
+			maxFee,
+		)
+	}
+
+	if candidate.ActionType == ActionDepositSweep {
+		if err := details.validateDepositFeeShares(newFee); err != nil {
+			return nil, fmt.Errorf("invalid new fee: [%v]", err)
+		}
+	}
+
+	validateProposalLogger.Infof("RBF proposal is valid")
+
+	return candidate, nil
+}
+
+// RbfCandidate represents a wallet transaction stuck in the Bitcoin mempool
+// that can be replaced by a transaction paying a higher fee.
+type RbfCandidate struct {
+	// Transaction is the stuck transaction as seen in the mempool.
+	Transaction *bitcoin.Transaction
+	// ActionType is the type of the wallet action that produced the
+	// transaction, determined from the transaction's shape. This is either
+	// ActionDepositSweep or ActionRedemption.
+	ActionType WalletActionType
+}
+
+// FindRbfCandidate rebuilds the given wallet transaction from the Bitcoin
+// chain and checks whether it is stuck. The transaction must:
+//   - be unconfirmed, i.e. be present in the mempool,
+//   - spend the wallet's main UTXO registered in the Bridge or, if the wallet
+//     has no main UTXO, spend no wallet's outputs at all, so it is the only
+//     wallet transaction not proven to the Bridge yet,
+//   - spend only outputs that have at least the given number of
+//     confirmations,
+//   - have none of its outputs spent by another mempool transaction, e.g.
+//     a child-pays-for-parent child. Replacing the transaction would
+//     invalidate the child already signed by the wallet.
+//
+// Transactions spending deposits are deposit sweeps while the remaining ones
+// are redemptions. The shape of the transaction is not validated further;
+// this is done when the replacement's fee bounds are computed.
+func FindRbfCandidate(
+	walletPublicKeyHash [20]byte,
+	transactionHash bitcoin.Hash,
+	stuckConfirmations uint,
+	chain BridgeChain,
+	btcChain bitcoin.Chain,
+) (*RbfCandidate, error) {
+	mempoolTransactions, err := btcChain.GetMempoolForPublicKeyHash(
+		walletPublicKeyHash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get mempool transactions: [%v]", err)
+	}
+
+	var transaction *bitcoin.Transaction
+	for _, mempoolTransaction := range mempoolTransactions {
+		if mempoolTransaction.Hash() == transactionHash {
+			transaction = mempoolTransaction
+			break
+		}
+	}
+	if transaction == nil {
+		return nil, fmt.Errorf("transaction is not in the mempool")
+	}
+
+	for _, mempoolTransaction := range mempoolTransactions {
+		for _, input := range mempoolTransaction.Inputs {
+			if input.Outpoint.TransactionHash == transactionHash {
+				return nil, fmt.Errorf(
+					"transaction's output is already spent by "+
+						"transaction [%s]",
+					mempoolTransaction.Hash().Hex(bitcoin.ReversedByteOrder),
+				)
+			}
+		}
+	}
+
+	confirmations, err := btcChain.GetTransactionConfirmations(transactionHash)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot get transaction confirmations: [%v]",
+			err,
+		)
+	}
+
+	if confirmations > 0 {
+		return nil, fmt.Errorf(
+			"transaction is already confirmed [%v] times",
+			confirmations,
+		)
+	}
+
+	walletMainUtxo, err := DetermineWalletMainUtxo(
+		walletPublicKeyHash,
+		chain,
+		btcChain,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot determine wallet's main UTXO: [%v]",
+			err,
+		)
+	}
+
+	if walletMainUtxo != nil {
+		spendsMainUtxo := false
+		for _, input := range transaction.Inputs {
+			if *input.Outpoint == *walletMainUtxo.Outpoint {
+				spendsMainUtxo = true
+				break
+			}
+		}
+
+		if !spendsMainUtxo {
+			return nil, fmt.Errorf(
+				"transaction does not spend wallet's main UTXO",
+			)
+		}
+	}
+
+	walletInputsCount := 0
+	for i, input := range transaction.Inputs {
+		inputConfirmations, err := btcChain.GetTransactionConfirmations(
+			input.Outpoint.TransactionHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get confirmations of transaction spent by "+
+					"input [%v]: [%v]",
+				i,
+				err,
+			)
+		}
+
+		if inputConfirmations < stuckConfirmations {
+			return nil, fmt.Errorf(
+				"output spent by input [%v] is confirmed [%v] times and "+
+					"the transaction is not stuck yet",
+				i,
+				inputConfirmations,
+			)
+		}
+
+		previousTransaction, err := btcChain.GetTransaction(
+			input.Outpoint.TransactionHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get transaction spent by input [%v]: [%v]",
+				i,
+				err,
+			)
+		}
+
+		if int(input.Outpoint.OutputIndex) >= len(previousTransaction.Outputs) {
+			return nil, fmt.Errorf(
+				"output spent by input [%v] does not exist",
+				i,
+			)
+		}
+
+		publicKeyHash, err := bitcoin.ExtractPublicKeyHash(
+			previousTransaction.Outputs[input.Outpoint.OutputIndex].PublicKeyScript,
+		)
+		if err == nil && publicKeyHash == walletPublicKeyHash {
+			walletInputsCount++
+		}
+	}
+
+	if walletMainUtxo == nil && walletInputsCount > 0 {
+		return nil, fmt.Errorf(
+			"transaction spends wallet's outputs but the wallet has no " +
+				"main UTXO",
+		)
+	}
+
+	actionType := ActionRedemption
+	if walletInputsCount < len(transaction.Inputs) {
+		actionType = ActionDepositSweep
+	}
+
+	return &RbfCandidate{
+		Transaction: transaction,
+		ActionType:  actionType,
+	}, nil
+}
+
+// IsRbfReplaceable tells whether the given wallet transaction can be
+// replaced by a transaction paying a higher fee accepted by the Bridge,
+// regardless of whether the transaction is stuck already. Transactions that
+// are not candidates for a replacement, as determined by FindRbfCandidate,
+// or whose fee cannot be bumped within the Bridge's limits are not
+// replaceable.
+func IsRbfReplaceable(
+	walletPublicKeyHash [20]byte,
+	transactionHash bitcoin.Hash,
+	chain BridgeChain,
+	btcChain bitcoin.Chain,
+) bool {
+	candidate, err := FindRbfCandidate(
+		walletPublicKeyHash,
+		transactionHash,
+		0,
+		chain,
+		btcChain,
+	)
+	if err != nil {
+		return false
+	}
+
+	_, ok, err := ComputeRbfFee(
+		chain,
+		btcChain,
+		walletPublicKeyHash,
+		candidate,
+		0,
+	)
+
+	return err == nil && ok
+}
+
+// ComputeRbfFeeBounds computes the minimum and maximum fee of a transaction
+// replacing the given candidate transaction. The minimum fee is the one
+// required by the Bitcoin relay policy, that is, the fee of the replaced
+// transaction plus RbfIncrementalRelayFeeRate multiplied by the transaction's
+// virtual size. The maximum fee is determined by the Bridge's limits for the
+// given action type. For deposit sweeps, this is the maximum fee of each
+// swept deposit. For redemptions, these are the maximum fees of the
+// individual redemption requests, snapshotted by the Bridge upon request
+// creation, and the maximum total fee. Only deposit sweep and redemption
+// transactions can be replaced.
+//
+// Not every fee within the bounds is accepted by the Bridge. For deposit
+// sweeps, the remainder of the fee split is incurred by the last deposit
+// and must fit its maximum fee as well. Use ComputeRbfFee to get a fee
+// accepted by the Bridge.
+func ComputeRbfFeeBounds(
+	chain BridgeChain,
+	btcChain bitcoin.Chain,
+	walletPublicKeyHash [20]byte,
+	candidate *RbfCandidate,
+) (int64, int64, error) {
+	details, err := analyzeRbfTransaction(
+		chain,
+		btcChain,
+		walletPublicKeyHash,
+		candidate,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return computeRbfFeeBounds(chain, candidate, details)
+}
+
+// ComputeRbfFee computes the fee of a transaction replacing the given candidate
+// transaction that is the closest to the given target fee and accepted by
+// the Bridge. The returned boolean flag is false if no such fee exists, i.e.
+// the fee of the transaction cannot be bumped within the Bridge's limits.
+func ComputeRbfFee(
+	chain BridgeChain,
+	btcChain bitcoin.Chain,
+	walletPublicKeyHash [20]byte,
+	candidate *RbfCandidate,
+	targetFee int64,
+) (int64, bool, error) {
+	details, err := analyzeRbfTransaction(
+		chain,
+		btcChain,
+		walletPublicKeyHash,
+		candidate,
+	)
+	if err != nil {
+		return 0, false, err
+	}
+
+	minFee, maxFee, err := computeRbfFeeBounds(
+		chain,
+		candidate,
+		details,
+	)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if maxFee < minFee {
+		return 0, false, nil
+	}
+
+	fee := targetFee
+	if fee < minFee {
+		fee = minFee
+	}
+	if fee > maxFee {
+		fee = maxFee
+	}
+
+	if candidate.ActionType == ActionDepositSweep &&
+		details.validateDepositFeeShares(fee) != nil {
+		// A fee being a multiple of the deposits count is split evenly
+		// with no remainder. Such a fee not exceeding the maximum fee is
+		// accepted for every deposit.
+		depositsCount := int64(len(details.depositRequests))
+
+		fee = (fee / depositsCount) * depositsCount
+		if fee < minFee {
+			fee = ((minFee + depositsCount - 1) / depositsCount) * depositsCount
+		}
+		if fee > maxFee {
+			return 0, false, nil
+		}
+	}
+
+	return fee, true, nil
+}
+
+// computeRbfFeeBounds computes the fee bounds of a transaction replacing
+// the given candidate transaction with the given details. See
+// ComputeRbfFeeBounds for details.
+func computeRbfFeeBounds(
+	chain BridgeChain,
+	candidate *RbfCandidate,
+	details *rbfTransactionDetails,
+) (int64, int64, error) {
+	// The replacement has the same shape as the replaced transaction so their
+	// virtual sizes differ by a few bytes of signatures at most.
+	minFee := details.fee +
+		candidate.Transaction.VirtualSize()*RbfIncrementalRelayFeeRate
+
+	maxFee, err := computeMaxFee(chain, candidate.ActionType, details)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return minFee, maxFee, nil
+}
+
+// computeMaxFee computes the maximum fee the Bridge accepts for a wallet
+// transaction of the given action type with the given details.
+func computeMaxFee(
+	chain BridgeChain,
+	actionType WalletActionType,
+	details *rbfTransactionDetails,
+) (int64, error) {
+	switch actionType {
+	case ActionDepositSweep:
+		// The fee is split evenly over deposits so it can grow until the
+		// share of the deposit with the lowest maximum fee reaches it.
+		maxFee := int64(0)
+		for i, depositTxMaxFee := range details.depositTxMaxFees {
+			if i == 0 || depositTxMaxFee < maxFee {
+				maxFee = depositTxMaxFee
+			}
+		}
+
+		return maxFee * int64(len(details.depositTxMaxFees)), nil
+
+	case ActionRedemption:
+		_, _, _, redemptionTxMaxTotalFee, _, _, _, err :=
+			chain.GetRedemptionParameters()
+		if err != nil {
+			return 0, fmt.Errorf(
+				"cannot get redemption parameters: [%v]",
+				err,
+			)
+		}
+
+		// The fee of each request can be bumped up to the request's own
+		// maximum fee. Requests whose current fee share already reached
+		// their maximum fee do not take part in the bump.
+		maxFee := details.fee
+		for _, headroom := range details.redemptionFeeHeadrooms() {
+			maxFee += headroom
+		}
+
+		if int64(redemptionTxMaxTotalFee) < maxFee {
+			maxFee = int64(redemptionTxMaxTotalFee)
+		}
+
+		return maxFee, nil
+
+	default:
+		return 0, fmt.Errorf(
+			"fees of transactions of action type [%s] cannot be bumped",
+			actionType,
+		)
+	}
+}
+
+// rbfTransactionDetails holds details of a wallet transaction being subject
+// of a fee bump.
+type rbfTransactionDetails struct {
+	// inputs holds UTXOs spent by the transaction's inputs.
+	inputs []*bitcoin.UnspentTransactionOutput
+	// inputsScripts holds locking scripts of UTXOs spent by the
+	// transaction's inputs.
+	inputsScripts []bitcoin.Script
+	// fee is the fee paid by the transaction.
+	fee int64
+	// depositInputsCount is the number of inputs that do not spend the
+	// wallet's own P2PKH/P2WPKH outputs.
+	depositInputsCount int
+	// depositRequests holds deposit requests of deposits swept by inputs
+	// that do not spend the wallet's own outputs, in the order of inputs.
+	// Set only for deposit sweep transactions.
+	depositRequests []*DepositChainRequest
+	// depositTxMaxFees holds maximum fees of deposits from depositRequests,
+	// in the same order. Set only for deposit sweep transactions.
+	depositTxMaxFees []int64
+	// redemptionOutputsIndexes holds indexes of outputs that do not pay to
+	// the wallet's own P2PKH/P2WPKH scripts.
+	redemptionOutputsIndexes []int
+	// redemptionRequests holds pending redemption requests handled by
+	// outputs pointed by redemptionOutputsIndexes, in the same order. Set
+	// only for redemption transactions.
+	redemptionRequests []*RedemptionRequest
+	// redemptionFeeShares holds fee shares incurred by redemptionRequests
+	// in the transaction, in the same order. Set only for redemption
+	// transactions.
+	redemptionFeeShares []int64
+}
+
+// redemptionFeeHeadrooms returns, for each redemption request, the amount
+// by which the request's fee share can still grow without exceeding the
+// request's maximum fee.
+func (rtd *rbfTransactionDetails) redemptionFeeHeadrooms() []int64 {
+	headrooms := make([]int64, len(rtd.redemptionRequests))
+	for i, request := range rtd.redemptionRequests {
+		headroom := int64(request.TxMaxFee) - rtd.redemptionFeeShares[i]
+		if headroom > 0 {
+			headrooms[i] = headroom
+		}
+	}
+
+	return headrooms
+}
+
+// validateDepositFeeShares checks whether the Bridge accepts the given fee
+// of a deposit sweep transaction with the given details. The Bridge splits
+// the fee evenly over deposits, charges the remainder to the last deposit
+// and requires the share of each deposit to fit its maximum fee.
+func (rtd *rbfTransactionDetails) validateDepositFeeShares(fee int64) error {
+	depositsCount := int64(len(rtd.depositRequests))
+	if depositsCount == 0 {
+		return fmt.Errorf("no deposits")
+	}
+
+	for i, depositTxMaxFee := range rtd.depositTxMaxFees {
+		feeShare := fee / depositsCount
+		if i == len(rtd.depositTxMaxFees)-1 {
+			feeShare += fee % depositsCount
+		}
+
+		if feeShare > depositTxMaxFee {
+			return fmt.Errorf(
+				"fee share [%v] of deposit [%v] exceeds its maximum fee [%v]",
+				feeShare,
+				i,
+				depositTxMaxFee,
+			)
+		}
+	}
+
+	return nil
+}
+
+// analyzeRbfTransaction gathers the details of the given candidate transaction
+// required to compute the replacement's fee bounds and assemble it. For
+// deposit sweep transactions, the deposit requests of swept deposits are
+// fetched from the Bridge along with their maximum fees. For redemption
+// transactions, the pending redemption requests handled by the
+// transaction are fetched from the Bridge along with fee shares they incur.
+func analyzeRbfTransaction(
+	chain BridgeChain,
+	btcChain bitcoin.Chain,
+	walletPublicKeyHash [20]byte,
+	candidate *RbfCandidate,
+) (*rbfTransactionDetails, error) {
+	transaction := candidate.Transaction
+
+	isWalletScript := func(script bitcoin.Script) bool {
+		publicKeyHash, err := bitcoin.ExtractPublicKeyHash(script)
+		return err == nil && publicKeyHash == walletPublicKeyHash
+	}
+
+	details := &rbfTransactionDetails{
+		inputs:        make([]*bitcoin.UnspentTransactionOutput, len(transaction.Inputs)),
+		inputsScripts: make([]bitcoin.Script, len(transaction.Inputs)),
+	}
+
+	inputsValue := int64(0)
+	for i, input := range transaction.Inputs {
+		previousTransaction, err := btcChain.GetTransaction(
+			input.Outpoint.TransactionHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get transaction spent by input [%v]: [%v]",
+				i,
+				err,
+			)
+		}
+
+		if int(input.Outpoint.OutputIndex) >= len(previousTransaction.Outputs) {
+			return nil, fmt.Errorf(
+				"output spent by input [%v] does not exist",
+				i,
+			)
+		}
+
+		previousOutput := previousTransaction.Outputs[input.Outpoint.OutputIndex]
+
+		details.inputs[i] = &bitcoin.UnspentTransactionOutput{
+			Outpoint: input.Outpoint,
+			Value:    previousOutput.Value,
+		}
+		details.inputsScripts[i] = previousOutput.PublicKeyScript
+
+		if !isWalletScript(previousOutput.PublicKeyScript) {
+			details.depositInputsCount++
+		}
+
+		inputsValue += previousOutput.Value
+	}
+
+	outputsValue := int64(0)
+	for i, output := range transaction.Outputs {
+		if !isWalletScript(output.PublicKeyScript) {
+			details.redemptionOutputsIndexes = append(
+				details.redemptionOutputsIndexes,
+				i,
+			)
+		}
+
+		outputsValue += output.Value
+	}
+
+	details.fee = inputsValue - outputsValue
+
+	switch candidate.ActionType {
+	case ActionDepositSweep:
+		if details.depositInputsCount == 0 ||
+			len(transaction.Outputs) != 1 ||
+			len(details.redemptionOutputsIndexes) != 0 {
+			return nil, fmt.Errorf("not a deposit sweep transaction")
+		}
+
+		// The Bridge does not snapshot the maximum fee in deposit requests.
+		// The fee share of each deposit is checked against the current
+		// value of the parameter upon the sweep proof.
+		_, _, depositTxMaxFee, _, err := chain.GetDepositParameters()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get deposit parameters: [%v]",
+				err,
+			)
+		}
+
+		for i, input := range transaction.Inputs {
+			if isWalletScript(details.inputsScripts[i]) {
+				continue
+			}
+
+			request, found, err := chain.GetDepositRequest(
+				input.Outpoint.TransactionHash,
+				input.Outpoint.OutputIndex,
+			)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"cannot get deposit request for input [%v]: [%v]",
+					i,
+					err,
+				)
+			}
+			if !found {
+				return nil, fmt.Errorf(
+					"no deposit request for input [%v]",
+					i,
+				)
+			}
+			if request.SweptAt.Unix() > 0 {
+				return nil, fmt.Errorf(
+					"deposit of input [%v] is already swept",
+					i,
+				)
+			}
+
+			details.depositRequests = append(details.depositRequests, request)
+			details.depositTxMaxFees = append(
+				details.depositTxMaxFees,
+				int64(depositTxMaxFee),
+			)
+		}
+	case ActionRedemption:
+		if details.depositInputsCount != 0 ||
+			len(details.redemptionOutputsIndexes) == 0 {
+			return nil, fmt.Errorf("not a redemption transaction")
+		}
+
+		for _, outputIndex := range details.redemptionOutputsIndexes {
+			output := transaction.Outputs[outputIndex]
+
+			request, found, err := chain.GetPendingRedemptionRequest(
+				walletPublicKeyHash,
+				output.PublicKeyScript,
+			)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"cannot get pending redemption request for output [%v]: [%v]",
+					outputIndex,
+					err,
+				)
+			}
+			if !found {
+				return nil, fmt.Errorf(
+					"no pending redemption request for output [%v]",
+					outputIndex,
+				)
+			}
+
+			feeShare := int64(request.RequestedAmount-request.TreasuryFee) -
+				output.Value
+			if feeShare < 0 {
+				return nil, fmt.Errorf(
+					"output [%v] pays more than the redeemable amount",
+					outputIndex,
+				)
+			}
+
+			details.redemptionRequests = append(
+				details.redemptionRequests,
+				request,
+			)
+			details.redemptionFeeShares = append(
+				details.redemptionFeeShares,
+				feeShare,
+			)
+		}
+	}
+
+	return details, nil
+}
+
+// assembleRbfTransaction constructs an unsigned transaction replacing the
+// given candidate transaction. The replacement spends the same inputs and pays
+// to the same scripts as the replaced transaction, in the same order. The fee
+// difference is charged according to the replaced transaction's action type:
+//   - for deposit sweeps, the single sweep output is reduced,
+//   - for redemptions, the redemption outputs are reduced so each request
+//     keeps its fee share from the replaced transaction and the fee increase
+//     is allocated by allocateRbfFeeIncrease, never pushing a request's fee
+//     share over its own maximum fee. The change output is left untouched.
+func assembleRbfTransaction(
+	chain BridgeChain,
+	bitcoinChain bitcoin.Chain,
+	walletPublicKeyHash [20]byte,
+	candidate *RbfCandidate,
+	newFee int64,
+) (*bitcoin.TransactionBuilder, error) {
+	details, err := analyzeRbfTransaction(
+		chain,
+		bitcoinChain,
+		walletPublicKeyHash,
+		candidate,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if newFee <= details.fee {
+		return nil, fmt.Errorf(
+			"new fee [%v] is not greater than the current fee [%v]",
+			newFee,
+			details.fee,
+		)
+	}
+
+	transaction := candidate.Transaction
+
+	builder := bitcoin.NewTransactionBuilder(bitcoinChain)
+
+	for i, input := range transaction.Inputs {
+		switch bitcoin.GetScriptType(details.inputsScripts[i]) {
+		case bitcoin.P2PKHScript, bitcoin.P2WPKHScript:
+			err = builder.AddPublicKeyHashInput(details.inputs[i])
+		case bitcoin.P2SHScript, bitcoin.P2WSHScript:
+			var redeemScript bitcoin.Script
+			redeemScript, err = bitcoin.ExtractRedeemScript(input)
+			if err == nil {
+				err = builder.AddScriptHashInput(details.inputs[i], redeemScript)
+			}
+		default:
+			err = fmt.Errorf("unsupported script type of the spent output")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot add input [%v]: [%v]", i, err)
+		}
+	}
+
+	outputsValues := make([]int64, len(transaction.Outputs))
+	for i, output := range transaction.Outputs {
+		outputsValues[i] = output.Value
+	}
+
+	switch candidate.ActionType {
+	case ActionDepositSweep:
+		outputsValues[0] -= newFee - details.fee
+	case ActionRedemption:
+		feeIncreases, err := allocateRbfFeeIncrease(
+			newFee-details.fee,
+			details.redemptionFeeHeadrooms(),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, outputIndex := range details.redemptionOutputsIndexes {
+			outputsValues[outputIndex] -= feeIncreases[i]
+		}
+	default:
+		return nil, fmt.Errorf(
+			"transactions of action type [%s] cannot be replaced",
+			candidate.ActionType,
+		)
+	}
+
+	for i, output := range transaction.Outputs {
+		if outputsValues[i] <= 0 {
+			return nil, fmt.Errorf(
+				"new fee leaves non-positive value of output [%v]",
+				i,
+			)
+		}
+
+		builder.AddOutput(&bitcoin.TransactionOutput{
+			Value:           outputsValues[i],
+			PublicKeyScript: output.PublicKeyScript,
+		})
+	}
+
+	return builder, nil
+}
+
+// allocateRbfFeeIncrease distributes the fee increase of a replacement
+// redemption transaction over redemption requests proportionally to their
+// fee headrooms, i.e. amounts by which their fee shares can still grow.
+// Shares rounded down leave a remainder that is distributed one satoshi per
+// request, in order, among requests whose headroom is not exhausted yet.
+// This way, no request pays more than its own maximum fee. Returns an error
+// if the fee increase exceeds the total headroom.
+func allocateRbfFeeIncrease(
+	feeIncrease int64,
+	headrooms []int64,
+) ([]int64, error) {
+	totalHeadroom := int64(0)
+	for _, headroom := range headrooms {
+		totalHeadroom += headroom
+	}
+
+	if feeIncrease > totalHeadroom {
+		return nil, fmt.Errorf(
+			"fee increase [%v] exceeds the total fee headroom [%v] "+
+				"of redemption requests",
+			feeIncrease,
+			totalHeadroom,
+		)
+	}
+
+	feeIncreases := make([]int64, len(headrooms))
+	if totalHeadroom == 0 {
+		return feeIncreases, nil
+	}
+
+	allocated := int64(0)
+	for i, headroom := range headrooms {
+		share := new(big.Int).Mul(big.NewInt(feeIncrease), big.NewInt(headroom))
+		share.Div(share, big.NewInt(totalHeadroom))
+
+		feeIncreases[i] = share.Int64()
+		allocated += feeIncreases[i]
+	}
+
+	for remainder := feeIncrease - allocated; remainder > 0; {
+		for i, headroom := range headrooms {
+			if remainder == 0 {
+				break
+			}
+
+			if feeIncreases[i] < headroom {
+				feeIncreases[i]++
+				remainder--
+			}
+		}
+	}
+
+	return feeIncreases, nil
+}
@@ -0,0 +1,887 @@
+package tbtc
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc/internal/test"
+)
+
+func TestAssembleRbfTransaction_DepositSweep(t *testing.T) {
+	scenarios, err := test.LoadDepositSweepTestScenarios()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.Title, func(t *testing.T) {
+			bitcoinChain := newLocalBitcoinChain()
+
+			for _, transaction := range scenario.InputTransactions {
+				err := bitcoinChain.BroadcastTransaction(transaction)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deposits := make([]*Deposit, len(scenario.Deposits))
+			for i, d := range scenario.Deposits {
+				deposits[i] = &Deposit{
+					Utxo:                d.Utxo,
+					Depositor:           d.Depositor,
+					BlindingFactor:      d.BlindingFactor,
+					WalletPublicKeyHash: d.WalletPublicKeyHash,
+					RefundPublicKeyHash: d.RefundPublicKeyHash,
+					RefundLocktime:      d.RefundLocktime,
+					Vault:               d.Vault,
+					ExtraData:           d.ExtraData,
+				}
+			}
+
+			hostChain := Connect()
+			setRbfDepositRequests(hostChain, scenario.Deposits, false)
+
+			newFee := scenario.Fee + 1000
+
+			builder, err := assembleRbfTransaction(
+				hostChain,
+				bitcoinChain,
+				bitcoin.PublicKeyHash(scenario.WalletPublicKey),
+				&RbfCandidate{
+					Transaction: scenario.ExpectedSweepTransaction,
+					ActionType:  ActionDepositSweep,
+				},
+				newFee,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The replacement must be equal to a sweep transaction assembled
+			// from scratch with the new fee.
+			expectedBuilder, err := assembleDepositSweepTransaction(
+				bitcoinChain,
+				scenario.WalletPublicKey,
+				scenario.WalletMainUtxo,
+				deposits,
+				newFee,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertRbfTransaction(
+				t,
+				scenario.WalletPrivateKey,
+				scenario.WalletPublicKey,
+				expectedBuilder,
+				builder,
+			)
+		})
+	}
+}
+
+func TestAssembleRbfTransaction_Redemption(t *testing.T) {
+	scenarios, err := test.LoadRedemptionTestScenarios()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fee headroom of each redemption request. The fee increase equals the
+	// total headroom so each request's fee share grows exactly by this value.
+	const feeHeadroom = 500
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.Title, func(t *testing.T) {
+			bitcoinChain := newLocalBitcoinChain()
+
+			err := bitcoinChain.BroadcastTransaction(scenario.InputTransaction)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			walletPublicKeyHash := bitcoin.PublicKeyHash(scenario.WalletPublicKey)
+
+			hostChain := Connect()
+
+			requests := make([]*RedemptionRequest, len(scenario.RedemptionRequests))
+			newFeeShares := make([]*big.Int, len(scenario.RedemptionRequests))
+			currentFee := int64(0)
+			for i, r := range scenario.RedemptionRequests {
+				requests[i] = &RedemptionRequest{
+					Redeemer:             r.Redeemer,
+					RedeemerOutputScript: r.RedeemerOutputScript,
+					RequestedAmount:      r.RequestedAmount,
+					TreasuryFee:          r.TreasuryFee,
+					TxMaxFee:             uint64(scenario.FeeShares[i] + feeHeadroom),
+					RequestedAt:          r.RequestedAt,
+				}
+
+				hostChain.setPendingRedemptionRequest(
+					walletPublicKeyHash,
+					requests[i],
+				)
+
+				newFeeShares[i] = big.NewInt(scenario.FeeShares[i] + feeHeadroom)
+				currentFee += scenario.FeeShares[i]
+			}
+
+			newFee := currentFee + feeHeadroom*int64(len(requests))
+
+			builder, err := assembleRbfTransaction(
+				hostChain,
+				bitcoinChain,
+				walletPublicKeyHash,
+				&RbfCandidate{
+					Transaction: scenario.ExpectedRedemptionTransaction,
+					ActionType:  ActionRedemption,
+				},
+				newFee,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The replacement must be equal to a redemption transaction
+			// assembled from scratch with the new fee shares.
+			expectedBuilder, err := assembleRedemptionTransaction(
+				bitcoinChain,
+				scenario.WalletPublicKey,
+				scenario.WalletMainUtxo,
+				requests,
+				withRedemptionFeeShares(newFeeShares),
+				RedemptionChangeLast,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertRbfTransaction(
+				t,
+				scenario.WalletPrivateKey,
+				scenario.WalletPublicKey,
+				expectedBuilder,
+				builder,
+			)
+		})
+	}
+}
+
+func TestAllocateRbfFeeIncrease(t *testing.T) {
+	var tests = map[string]struct {
+		feeIncrease          int64
+		headrooms            []int64
+		expectedFeeIncreases []int64
+		expectedErr          error
+	}{
+		"proportional to headrooms": {
+			feeIncrease:          600,
+			headrooms:            []int64{100, 200, 300},
+			expectedFeeIncreases: []int64{100, 200, 300},
+		},
+		"remainder distributed in order": {
+			feeIncrease:          10,
+			headrooms:            []int64{100, 100, 100},
+			expectedFeeIncreases: []int64{4, 3, 3},
+		},
+		"exhausted headroom skipped": {
+			feeIncrease:          301,
+			headrooms:            []int64{0, 200, 101},
+			expectedFeeIncreases: []int64{0, 200, 101},
+		},
+		"fee increase exceeding total headroom": {
+			feeIncrease: 302,
+			headrooms:   []int64{0, 200, 101},
+			expectedErr: fmt.Errorf(
+				"fee increase [302] exceeds the total fee headroom [301] " +
+					"of redemption requests",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			feeIncreases, err := allocateRbfFeeIncrease(
+				test.feeIncrease,
+				test.headrooms,
+			)
+
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Errorf(
+					"unexpected error\nexpected: %v\nactual:   %v",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			if !reflect.DeepEqual(test.expectedFeeIncreases, feeIncreases) {
+				t.Errorf(
+					"unexpected fee increases\nexpected: %v\nactual:   %v",
+					test.expectedFeeIncreases,
+					feeIncreases,
+				)
+			}
+		})
+	}
+}
+
+func TestComputeRbfFeeBounds(t *testing.T) {
+	depositSweepScenarios, err := test.LoadDepositSweepTestScenarios()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redemptionScenarios, err := test.LoadRedemptionTestScenarios()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Witness main UTXO with witness and non-witness deposits.
+	depositSweepScenario := depositSweepScenarios[0]
+	// Multiple redemptions with witness change.
+	redemptionScenario := redemptionScenarios[4]
+
+	depositSweepTx := &RbfCandidate{
+		Transaction: depositSweepScenario.ExpectedSweepTransaction,
+		ActionType:  ActionDepositSweep,
+	}
+	redemptionTx := &RbfCandidate{
+		Transaction: redemptionScenario.ExpectedRedemptionTransaction,
+		ActionType:  ActionRedemption,
+	}
+
+	var tests = map[string]struct {
+		candidate               *RbfCandidate
+		walletPublicKeyHash     [20]byte
+		depositTxMaxFee         uint64
+		depositRequestsSet      bool
+		depositsSwept           bool
+		requestsTxMaxFees       []uint64
+		redemptionTxMaxTotalFee uint64
+		expectedMinFee          int64
+		expectedMaxFee          int64
+		expectedErr             error
+	}{
+		"deposit sweep": {
+			candidate:           depositSweepTx,
+			walletPublicKeyHash: bitcoin.PublicKeyHash(depositSweepScenario.WalletPublicKey),
+			depositTxMaxFee:     5000,
+			depositRequestsSet:  true,
+			expectedMinFee: depositSweepScenario.Fee +
+				depositSweepTx.Transaction.VirtualSize(),
+			expectedMaxFee: 5000 * int64(len(depositSweepScenario.Deposits)),
+		},
+		"deposit sweep with unknown deposits": {
+			candidate:           depositSweepTx,
+			walletPublicKeyHash: bitcoin.PublicKeyHash(depositSweepScenario.WalletPublicKey),
+			depositTxMaxFee:     5000,
+			expectedErr:         fmt.Errorf("no deposit request for input [1]"),
+		},
+		"deposit sweep with swept deposits": {
+			candidate:           depositSweepTx,
+			walletPublicKeyHash: bitcoin.PublicKeyHash(depositSweepScenario.WalletPublicKey),
+			depositTxMaxFee:     5000,
+			depositRequestsSet:  true,
+			depositsSwept:       true,
+			expectedErr:         fmt.Errorf("deposit of input [1] is already swept"),
+		},
+		"redemption with max fee limited by per-request limits": {
+			candidate:           redemptionTx,
+			walletPublicKeyHash: bitcoin.PublicKeyHash(redemptionScenario.WalletPublicKey),
+			// Current fee shares are [1100, 900, 1000, 1400] so only the
+			// first and the last request can pay more.
+			requestsTxMaxFees:       []uint64{2000, 900, 1000, 2000},
+			redemptionTxMaxTotalFee: 10000,
+			expectedMinFee:          4400 + redemptionTx.Transaction.VirtualSize(),
+			expectedMaxFee:          5900,
+		},
+		"redemption with max fee limited by total limit": {
+			candidate:               redemptionTx,
+			walletPublicKeyHash:     bitcoin.PublicKeyHash(redemptionScenario.WalletPublicKey),
+			requestsTxMaxFees:       []uint64{2000, 2000, 2000, 2000},
+			redemptionTxMaxTotalFee: 6002,
+			expectedMinFee:          4400 + redemptionTx.Transaction.VirtualSize(),
+			expectedMaxFee:          6002,
+		},
+		"redemption with no pending requests": {
+			candidate:           redemptionTx,
+			walletPublicKeyHash: bitcoin.PublicKeyHash(redemptionScenario.WalletPublicKey),
+			expectedErr:         fmt.Errorf("no pending redemption request for output [0]"),
+		},
+		"unsupported action type": {
+			candidate: &RbfCandidate{
+				Transaction: depositSweepScenario.ExpectedSweepTransaction,
+				ActionType:  ActionMovingFunds,
+			},
+			walletPublicKeyHash: bitcoin.PublicKeyHash(depositSweepScenario.WalletPublicKey),
+			expectedErr: fmt.Errorf(
+				"fees of transactions of action type [MovingFunds] cannot be bumped",
+			),
+		},
+		"action type mismatch": {
+			candidate: &RbfCandidate{
+				Transaction: depositSweepScenario.ExpectedSweepTransaction,
+				ActionType:  ActionRedemption,
+			},
+			walletPublicKeyHash: bitcoin.PublicKeyHash(depositSweepScenario.WalletPublicKey),
+			expectedErr:         fmt.Errorf("not a redemption transaction"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			bitcoinChain := newLocalBitcoinChain()
+
+			inputTransactions := append(
+				depositSweepScenario.InputTransactions,
+				redemptionScenario.InputTransaction,
+			)
+			for _, transaction := range inputTransactions {
+				err := bitcoinChain.BroadcastTransaction(transaction)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			hostChain := Connect()
+			hostChain.SetDepositParameters(0, 0, test.depositTxMaxFee, 0)
+			if test.depositRequestsSet {
+				setRbfDepositRequests(
+					hostChain,
+					depositSweepScenario.Deposits,
+					test.depositsSwept,
+				)
+			}
+			hostChain.SetRedemptionParameters(
+				0,
+				0,
+				0,
+				test.redemptionTxMaxTotalFee,
+				0,
+				nil,
+				0,
+			)
+
+			for i, txMaxFee := range test.requestsTxMaxFees {
+				r := redemptionScenario.RedemptionRequests[i]
+				hostChain.setPendingRedemptionRequest(
+					test.walletPublicKeyHash,
+					&RedemptionRequest{
+						Redeemer:             r.Redeemer,
+						RedeemerOutputScript: r.RedeemerOutputScript,
+						RequestedAmount:      r.RequestedAmount,
+						TreasuryFee:          r.TreasuryFee,
+						TxMaxFee:             txMaxFee,
+						RequestedAt:          r.RequestedAt,
+					},
+				)
+			}
+
+			minFee, maxFee, err := ComputeRbfFeeBounds(
+				hostChain,
+				bitcoinChain,
+				test.walletPublicKeyHash,
+				test.candidate,
+			)
+
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Errorf(
+					"unexpected error\nexpected: %v\nactual:   %v",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			testutils.AssertIntsEqual(t, "min fee", int(test.expectedMinFee), int(minFee))
+			testutils.AssertIntsEqual(t, "max fee", int(test.expectedMaxFee), int(maxFee))
+		})
+	}
+}
+
+func TestValidateRbfProposal(t *testing.T) {
+	scenarios, err := test.LoadDepositSweepTestScenarios()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenario := scenarios[0]
+	transaction := scenario.ExpectedSweepTransaction
+	walletPublicKeyHash := bitcoin.PublicKeyHash(scenario.WalletPublicKey)
+
+	minFee := scenario.Fee + transaction.VirtualSize()
+	maxFee := int64(5000 * len(scenario.Deposits))
+
+	var tests = map[string]struct {
+		newFee      int64
+		blocks      int
+		confirmed   bool
+		expectedErr error
+	}{
+		"valid proposal": {
+			newFee: minFee,
+			blocks: RbfStuckTransactionConfirmations,
+		},
+		"transaction not stuck yet": {
+			newFee:      minFee,
+			blocks:      RbfStuckTransactionConfirmations - len(scenario.InputTransactions) - 1,
+			expectedErr: fmt.Errorf("the transaction is not stuck yet"),
+		},
+		"transaction confirmed": {
+			newFee:      minFee,
+			blocks:      RbfStuckTransactionConfirmations,
+			confirmed:   true,
+			expectedErr: fmt.Errorf("transaction is not in the mempool"),
+		},
+		"new fee too low": {
+			newFee: minFee - 1,
+			blocks: RbfStuckTransactionConfirmations,
+			expectedErr: fmt.Errorf(
+				"new fee [%v] is out of the allowed range [%v, %v]",
+				minFee-1,
+				minFee,
+				maxFee,
+			),
+		},
+		"new fee too high": {
+			newFee: maxFee + 1,
+			blocks: RbfStuckTransactionConfirmations,
+			expectedErr: fmt.Errorf(
+				"new fee [%v] is out of the allowed range [%v, %v]",
+				maxFee+1,
+				minFee,
+				maxFee,
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			bitcoinChain := newLocalBitcoinChain()
+
+			for _, inputTransaction := range scenario.InputTransactions {
+				err := bitcoinChain.BroadcastTransaction(inputTransaction)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			addRbfBlocks(t, bitcoinChain, test.blocks)
+
+			if test.confirmed {
+				err := bitcoinChain.BroadcastTransaction(transaction)
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				bitcoinChain.mempool = append(bitcoinChain.mempool, transaction)
+			}
+
+			hostChain := Connect()
+			hostChain.setWallet(
+				walletPublicKeyHash,
+				&WalletChainData{
+					MainUtxoHash: hostChain.ComputeMainUtxoHash(
+						scenario.WalletMainUtxo,
+					),
+					State: StateLive,
+				},
+			)
+			hostChain.SetDepositParameters(0, 0, 5000, 0)
+			setRbfDepositRequests(hostChain, scenario.Deposits, false)
+
+			candidate, err := ValidateRbfProposal(
+				&testutils.MockLogger{},
+				walletPublicKeyHash,
+				&RbfProposal{
+					TransactionHash: transaction.Hash(),
+					NewFee:          big.NewInt(test.newFee),
+				},
+				hostChain,
+				bitcoinChain,
+			)
+
+			if test.expectedErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: [%v]", err)
+				}
+
+				expectedCandidate := &RbfCandidate{
+					Transaction: transaction,
+					ActionType:  ActionDepositSweep,
+				}
+				if !reflect.DeepEqual(expectedCandidate, candidate) {
+					t.Errorf(
+						"unexpected candidate\nexpected: %v\nactual:   %v",
+						expectedCandidate,
+						candidate,
+					)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error: [%v]", test.expectedErr)
+			}
+
+			if !strings.Contains(err.Error(), test.expectedErr.Error()) {
+				t.Errorf(
+					"unexpected error\nexpected: %v\nactual:   %v",
+					test.expectedErr,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestFindRbfCandidate(t *testing.T) {
+	transactions := newCpfpTestTransactions(t)
+
+	parentHash := transactions.parentTransaction.Hash()
+
+	// Transaction spending the parent's change.
+	childTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: parentHash,
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 59000, PublicKeyScript: transactions.walletScript},
+		},
+	}
+
+	var tests = map[string]struct {
+		transactionHash   bitcoin.Hash
+		blocks            int
+		additionalMempool []*bitcoin.Transaction
+		expectedCandidate *RbfCandidate
+		expectedErr       error
+	}{
+		"stuck redemption": {
+			transactionHash: parentHash,
+			blocks:          RbfStuckTransactionConfirmations,
+			expectedCandidate: &RbfCandidate{
+				Transaction: transactions.parentTransaction,
+				ActionType:  ActionRedemption,
+			},
+		},
+		"transaction not stuck yet": {
+			transactionHash: parentHash,
+			blocks:          RbfStuckTransactionConfirmations - 2,
+			expectedErr: fmt.Errorf(
+				"output spent by input [0] is confirmed [%v] times and "+
+					"the transaction is not stuck yet",
+				RbfStuckTransactionConfirmations-1,
+			),
+		},
+		"transaction not in the mempool": {
+			transactionHash: bitcoin.Hash{0xbb},
+			blocks:          RbfStuckTransactionConfirmations,
+			expectedErr:     fmt.Errorf("transaction is not in the mempool"),
+		},
+		"transaction not spending the main UTXO": {
+			transactionHash:   childTransaction.Hash(),
+			blocks:            RbfStuckTransactionConfirmations,
+			additionalMempool: []*bitcoin.Transaction{childTransaction},
+			expectedErr: fmt.Errorf(
+				"transaction does not spend wallet's main UTXO",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			hostChain := Connect()
+			bitcoinChain := newLocalBitcoinChain()
+
+			transactions.setup(t, hostChain, bitcoinChain)
+			addRbfBlocks(t, bitcoinChain, test.blocks)
+			bitcoinChain.mempool = append(
+				bitcoinChain.mempool,
+				test.additionalMempool...,
+			)
+
+			candidate, err := FindRbfCandidate(
+				transactions.walletPublicKeyHash,
+				test.transactionHash,
+				RbfStuckTransactionConfirmations,
+				hostChain,
+				bitcoinChain,
+			)
+
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Errorf(
+					"unexpected error\nexpected: %v\nactual:   %v",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			if !reflect.DeepEqual(test.expectedCandidate, candidate) {
+				t.Errorf(
+					"unexpected candidate\nexpected: %v\nactual:   %v",
+					test.expectedCandidate,
+					candidate,
+				)
+			}
+		})
+	}
+}
+
+func TestComputeRbfFee(t *testing.T) {
+	scenarios, err := test.LoadDepositSweepTestScenarios()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenario := scenarios[0]
+	transaction := scenario.ExpectedSweepTransaction
+
+	// The scenario's transaction sweeps two deposits.
+	minFee := scenario.Fee + transaction.VirtualSize()
+
+	var tests = map[string]struct {
+		depositTxMaxFee uint64
+		targetFee       int64
+		expectedFee     int64
+		expectedOk      bool
+	}{
+		"target fee within bounds": {
+			depositTxMaxFee: 5000,
+			targetFee:       minFee + 100,
+			expectedFee:     minFee + 100,
+			expectedOk:      true,
+		},
+		"target fee below minimum fee": {
+			depositTxMaxFee: 5000,
+			targetFee:       minFee - 100,
+			expectedFee:     minFee,
+			expectedOk:      true,
+		},
+		"target fee above maximum fee": {
+			depositTxMaxFee: 5000,
+			targetFee:       20000,
+			expectedFee:     10000,
+			expectedOk:      true,
+		},
+		"maximum fee below minimum fee": {
+			depositTxMaxFee: uint64(minFee/2) - 1,
+			targetFee:       minFee,
+			expectedOk:      false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			bitcoinChain := newLocalBitcoinChain()
+
+			for _, inputTransaction := range scenario.InputTransactions {
+				err := bitcoinChain.BroadcastTransaction(inputTransaction)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			hostChain := Connect()
+			hostChain.SetDepositParameters(0, 0, test.depositTxMaxFee, 0)
+			setRbfDepositRequests(hostChain, scenario.Deposits, false)
+
+			fee, ok, err := ComputeRbfFee(
+				hostChain,
+				bitcoinChain,
+				bitcoin.PublicKeyHash(scenario.WalletPublicKey),
+				&RbfCandidate{
+					Transaction: transaction,
+					ActionType:  ActionDepositSweep,
+				},
+				test.targetFee,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertBoolsEqual(t, "ok flag", test.expectedOk, ok)
+			testutils.AssertIntsEqual(t, "fee", int(test.expectedFee), int(fee))
+		})
+	}
+}
+
+func TestRbfTransactionDetails_ValidateDepositFeeShares(t *testing.T) {
+	var tests = map[string]struct {
+		depositTxMaxFees []int64
+		fee              int64
+		expectedErr      error
+	}{
+		"fee split evenly": {
+			depositTxMaxFees: []int64{1000, 1000, 1000},
+			fee:              3000,
+		},
+		"remainder fits the last deposit's maximum fee": {
+			depositTxMaxFees: []int64{1000, 1000, 1000},
+			fee:              2998,
+		},
+		"remainder exceeds the last deposit's maximum fee": {
+			depositTxMaxFees: []int64{1000, 1000, 1000},
+			fee:              2999,
+			expectedErr: fmt.Errorf(
+				"fee share [1001] of deposit [2] exceeds its maximum fee [1000]",
+			),
+		},
+		"share exceeds a deposit's maximum fee": {
+			depositTxMaxFees: []int64{1000, 900, 1000},
+			fee:              2850,
+			expectedErr: fmt.Errorf(
+				"fee share [950] of deposit [1] exceeds its maximum fee [900]",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			details := &rbfTransactionDetails{
+				depositRequests: make(
+					[]*DepositChainRequest,
+					len(test.depositTxMaxFees),
+				),
+				depositTxMaxFees: test.depositTxMaxFees,
+			}
+
+			err := details.validateDepositFeeShares(test.fee)
+
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Errorf(
+					"unexpected error\nexpected: %v\nactual:   %v",
+					test.expectedErr,
+					err,
+				)
+			}
+		})
+	}
+}
+
+// setRbfDepositRequests sets deposit requests of the given deposits on the
+// given host chain.
+func setRbfDepositRequests(
+	hostChain *localChain,
+	deposits []*test.Deposit,
+	swept bool,
+) {
+	for _, deposit := range deposits {
+		request := &DepositChainRequest{
+			// Set only relevant fields.
+			Depositor: deposit.Depositor,
+			Amount:    uint64(deposit.Utxo.Value),
+			Vault:     deposit.Vault,
+			ExtraData: deposit.ExtraData,
+		}
+		if swept {
+			request.SweptAt = time.Now()
+		}
+
+		hostChain.setDepositRequest(
+			deposit.Utxo.Outpoint.TransactionHash,
+			deposit.Utxo.Outpoint.OutputIndex,
+			request,
+		)
+	}
+}
+
+// addRbfBlocks adds the given number of dummy transactions to the given
+// Bitcoin chain. Each of them increases the number of confirmations of
+// transactions already in the chain by one.
+func addRbfBlocks(t *testing.T, bitcoinChain *localBitcoinChain, count int) {
+	for i := 0; i < count; i++ {
+		err := bitcoinChain.BroadcastTransaction(&bitcoin.Transaction{
+			Version: 1,
+			Inputs: []*bitcoin.TransactionInput{
+				{
+					Outpoint: &bitcoin.TransactionOutpoint{
+						TransactionHash: bitcoin.Hash{0xff},
+						OutputIndex:     uint32(i),
+					},
+					Sequence: 0xffffffff,
+				},
+			},
+			Outputs: []*bitcoin.TransactionOutput{
+				{Value: 1000, PublicKeyScript: []byte{0x51}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertRbfTransaction checks whether the given replacement transaction
+// builder produces the same transaction as the expected builder.
+func assertRbfTransaction(
+	t *testing.T,
+	walletPrivateKey *big.Int,
+	walletPublicKey *ecdsa.PublicKey,
+	expectedBuilder *bitcoin.TransactionBuilder,
+	actualBuilder *bitcoin.TransactionBuilder,
+) {
+	expectedSigHashes, err := expectedBuilder.ComputeSignatureHashes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualSigHashes, err := actualBuilder.ComputeSignatureHashes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"sighash count",
+		len(expectedSigHashes),
+		len(actualSigHashes),
+	)
+
+	for i := range expectedSigHashes {
+		testutils.AssertBigIntsEqual(
+			t,
+			fmt.Sprintf("sighash for input [%v]", i),
+			expectedSigHashes[i],
+			actualSigHashes[i],
+		)
+	}
+
+	privateKey := &ecdsa.PrivateKey{
+		PublicKey: *walletPublicKey,
+		D:         walletPrivateKey,
+	}
+
+	signatures := make([]*bitcoin.SignatureContainer, len(actualSigHashes))
+	for i, sigHash := range actualSigHashes {
+		r, s, err := ecdsa.Sign(rand.Reader, privateKey, sigHash.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signatures[i] = &bitcoin.SignatureContainer{
+			R:         r,
+			S:         s,
+			PublicKey: walletPublicKey,
+		}
+	}
+
+	// Make sure the replacement can be actually signed.
+	if _, err := actualBuilder.AddSignatures(signatures); err != nil {
+		t.Fatal(err)
+	}
+}
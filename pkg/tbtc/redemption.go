@@ -53,6 +53,19 @@ const (
 type RedemptionProposal struct {
 	RedeemersOutputScripts []bitcoin.Script
 	RedemptionTxFee        *big.Int
+	// RedemptionTxFeeShares are optional shares of the redemption transaction
+	// fee incurred by specific requests, ordered in the same way as
+	// RedeemersOutputScripts. If not set, the fee is distributed evenly
+	// over all requests.
+	//
+	// Clients that do not know this field ignore it and split the fee
+	// evenly, building a different transaction than the one signed by
+	// the rest of the group. Leaders must not set the shares until all
+	// operators of the wallet run a client version that knows them. See
+	// tbtcpg.Config.RedemptionFeeSharesEnabled.
+	RedemptionTxFeeShares []*big.Int
+	// TransactionShape is the shape of the redemption transaction.
+	TransactionShape RedemptionTransactionShape
 }
 
 func (rp *RedemptionProposal) ActionType() WalletActionType {
@@ -78,6 +91,21 @@ const (
 	RedemptionChangeLast
 )
 
+func (rts RedemptionTransactionShape) isValid() bool {
+	return rts == RedemptionChangeFirst || rts == RedemptionChangeLast
+}
+
+func (rts RedemptionTransactionShape) String() string {
+	switch rts {
+	case RedemptionChangeFirst:
+		return "ChangeFirst"
+	case RedemptionChangeLast:
+		return "ChangeLast"
+	default:
+		return fmt.Sprintf("Unknown(%d)", uint8(rts))
+	}
+}
+
 // RedemptionRequest represents a tBTC redemption request.
 type RedemptionRequest struct {
 	// Redeemer is the redeemer's address on the host chain.
@@ -108,6 +136,10 @@ type redemptionAction struct {
 	redeemingWallet     wallet
 	transactionExecutor *walletTransactionExecutor
 
+	// audit collects the audit trail of the action. Can be nil, in which
+	// case the action is not audited.
+	audit *walletActionAudit
+
 	proposal                     *RedemptionProposal
 	proposalProcessingStartBlock uint64
 	proposalExpiryBlock          uint64
@@ -126,19 +158,26 @@ func newRedemptionAction(
 	btcChain bitcoin.Chain,
 	redeemingWallet wallet,
 	signingExecutor walletSigningExecutor,
+	transactionTracker *walletTransactionTracker,
 	proposal *RedemptionProposal,
 	proposalProcessingStartBlock uint64,
 	proposalExpiryBlock uint64,
 	waitForBlockFn waitForBlockFn,
+	audit *walletActionAudit,
 ) *redemptionAction {
 	transactionExecutor := newWalletTransactionExecutor(
 		btcChain,
 		redeemingWallet,
 		signingExecutor,
+		transactionTracker,
 		waitForBlockFn,
+		audit,
 	)
 
 	feeDistribution := withRedemptionTotalFee(proposal.RedemptionTxFee.Int64())
+	if len(proposal.RedemptionTxFeeShares) > 0 {
+		feeDistribution = withRedemptionFeeShares(proposal.RedemptionTxFeeShares)
+	}
 
 	return &redemptionAction{
 		logger:                           logger,
@@ -146,6 +185,7 @@ func newRedemptionAction(
 		btcChain:                         btcChain,
 		redeemingWallet:                  redeemingWallet,
 		transactionExecutor:              transactionExecutor,
+		audit:                            audit,
 		proposal:                         proposal,
 		proposalProcessingStartBlock:     proposalProcessingStartBlock,
 		proposalExpiryBlock:              proposalExpiryBlock,
@@ -153,7 +193,7 @@ func newRedemptionAction(
 		broadcastTimeout:                 redemptionBroadcastTimeout,
 		broadcastCheckDelay:              redemptionBroadcastCheckDelay,
 		feeDistribution:                  feeDistribution,
-		transactionShape:                 RedemptionChangeFirst,
+		transactionShape:                 proposal.TransactionShape,
 	}
 }
 
@@ -170,6 +210,7 @@ func (ra *redemptionAction) execute() error {
 		ra.proposal,
 		ra.chain,
 	)
+	ra.audit.recordValidation(err)
 	if err != nil {
 		return fmt.Errorf("validate proposal step failed: [%v]", err)
 	}
@@ -247,6 +288,7 @@ func (ra *redemptionAction) execute() error {
 
 	err = ra.transactionExecutor.broadcastTransaction(
 		broadcastTxLogger,
+		ActionRedemption,
 		redemptionTx,
 		ra.broadcastTimeout,
 		ra.broadcastCheckDelay,
@@ -260,6 +302,11 @@ func (ra *redemptionAction) execute() error {
 
 // ValidateRedemptionProposal checks the redemption proposal with on-chain
 // validation rules.
+//
+// The on-chain validation assumes the fee is split evenly over requests
+// and is always done against the actual fee of the proposal. Optional fee
+// shares are unknown to the on-chain validation so they are additionally
+// checked off-chain.
 func ValidateRedemptionProposal(
 	validateProposalLogger log.StandardLogger,
 	walletPublicKeyHash [20]byte,
@@ -282,17 +329,13 @@ func ValidateRedemptionProposal(
 		) error
 	},
 ) ([]*RedemptionRequest, error) {
-	validateProposalLogger.Infof("calling chain for proposal validation")
-
-	err := chain.ValidateRedemptionProposal(walletPublicKeyHash, proposal)
-	if err != nil {
-		return nil, fmt.Errorf("redemption proposal is invalid: [%v]", err)
+	if !proposal.TransactionShape.isValid() {
+		return nil, fmt.Errorf(
+			"invalid redemption transaction shape: [%v]",
+			proposal.TransactionShape,
+		)
 	}
 
-	validateProposalLogger.Infof(
-		"redemption proposal is valid",
-	)
-
 	requests := make([]*RedemptionRequest, len(proposal.RedeemersOutputScripts))
 	for i, script := range proposal.RedeemersOutputScripts {
 		requestDisplayIndex := fmt.Sprintf(
@@ -322,9 +365,86 @@ func ValidateRedemptionProposal(
 		requests[i] = request
 	}
 
+	validateProposalLogger.Infof("calling chain for proposal validation")
+
+	err := chain.ValidateRedemptionProposal(walletPublicKeyHash, proposal)
+	if err != nil {
+		return nil, fmt.Errorf("redemption proposal is invalid: [%v]", err)
+	}
+
+	validateProposalLogger.Infof(
+		"redemption proposal is valid",
+	)
+
+	if len(proposal.RedemptionTxFeeShares) > 0 {
+		validateProposalLogger.Infof("checking redemption fee shares")
+
+		if err := validateRedemptionTxFeeShares(proposal, requests); err != nil {
+			return nil, fmt.Errorf("invalid redemption fee shares: [%v]", err)
+		}
+	}
+
 	return requests, nil
 }
 
+// validateRedemptionTxFeeShares checks the optional fee shares of the given
+// redemption proposal. Fee shares must be set, non-negative, sum up to the
+// redemption transaction fee and none of them can exceed the maximum fee of
+// the corresponding request. The on-chain validation does not know about fee shares as it
+// assumes the fee is distributed evenly so this check is done off-chain.
+func validateRedemptionTxFeeShares(
+	proposal *RedemptionProposal,
+	requests []*RedemptionRequest,
+) error {
+	if len(proposal.RedemptionTxFeeShares) == 0 {
+		return nil
+	}
+
+	if len(proposal.RedemptionTxFeeShares) != len(requests) {
+		return fmt.Errorf(
+			"fee shares count [%v] does not match requests count [%v]",
+			len(proposal.RedemptionTxFeeShares),
+			len(requests),
+		)
+	}
+
+	totalFeeShares := big.NewInt(0)
+	for i, feeShare := range proposal.RedemptionTxFeeShares {
+		if feeShare == nil {
+			return fmt.Errorf("fee share of request [%v] is not set", i)
+		}
+
+		if feeShare.Sign() < 0 {
+			return fmt.Errorf(
+				"fee share [%v] of request [%v] is negative",
+				feeShare,
+				i,
+			)
+		}
+
+		if feeShare.Cmp(new(big.Int).SetUint64(requests[i].TxMaxFee)) > 0 {
+			return fmt.Errorf(
+				"fee share [%v] of request [%v] exceeds its maximum fee [%v]",
+				feeShare,
+				i,
+				requests[i].TxMaxFee,
+			)
+		}
+
+		totalFeeShares.Add(totalFeeShares, feeShare)
+	}
+
+	if totalFeeShares.Cmp(proposal.RedemptionTxFee) != 0 {
+		return fmt.Errorf(
+			"fee shares sum [%v] does not match the redemption fee [%v]",
+			totalFeeShares,
+			proposal.RedemptionTxFee,
+		)
+	}
+
+	return nil
+}
+
 func (ra *redemptionAction) wallet() wallet {
 	return ra.redeemingWallet
 }
@@ -363,14 +483,29 @@ func withRedemptionTotalFee(totalFee int64) redemptionFeeDistributionFn {
 	}
 }
 
+// withRedemptionFeeShares is a fee distribution function that assigns the
+// given fee shares to subsequent redemption requests.
+func withRedemptionFeeShares(feeShares []*big.Int) redemptionFeeDistributionFn {
+	return func(requests []*RedemptionRequest) []int64 {
+		shares := make([]int64, len(requests))
+		for i := range requests {
+			shares[i] = feeShares[i].Int64()
+		}
+
+		return shares
+	}
+}
+
 // assembleRedemptionTransaction constructs an unsigned redemption Bitcoin
 // transaction.
 //
 // Regarding input arguments, the requests slice must contain at least one element.
-// The fee shares applied to specific requests according to the provided
-// feeDistribution function are not validated in any way so must be chosen with
-// respect to the system limitations. The shape argument is optional - if not
-// provided the RedemptionChangeFirst value is used by default.
+// Each request must have a standard redeemer output script, i.e. P2PKH, P2WPKH,
+// P2SH, P2WSH or P2TR. The fee shares applied to specific requests according
+// to the provided feeDistribution function are only checked to leave
+// a positive redemption output value so must be chosen with respect to the
+// system limitations. The shape argument is optional - if not provided the
+// RedemptionChangeFirst value is used by default.
 //
 // The resulting bitcoin.TransactionBuilder instance holds all the data
 // necessary to sign the transaction and obtain a bitcoin.Transaction instance
@@ -423,6 +558,14 @@ func assembleRedemptionTransaction(
 	// next step and whose position in the transaction output vector depends on
 	// the requested RedemptionTransactionShape.
 	for i, request := range requests {
+		scriptType := bitcoin.GetScriptType(request.RedeemerOutputScript)
+		if scriptType == bitcoin.NonStandardScript {
+			return nil, fmt.Errorf(
+				"redemption request [%v] has non-standard redeemer output script",
+				i,
+			)
+		}
+
 		// The redeemable amount for a redemption request is the difference
 		// between the requested amount and treasury fee computed upon
 		// request creation.
@@ -433,6 +576,16 @@ func assembleRedemptionTransaction(
 		feeShare := feeShares[i]
 		redemptionOutputValue := redeemableAmount - feeShare
 
+		if redemptionOutputValue <= 0 {
+			return nil, fmt.Errorf(
+				"fee share [%v] of redemption request [%v] is not less "+
+					"than its redeemable amount [%v]",
+				feeShare,
+				i,
+				redeemableAmount,
+			)
+		}
+
 		totalFee += feeShare
 		totalRedemptionOutputsValue += redemptionOutputValue
 
@@ -2,7 +2,9 @@ package tbtc
 
 import (
 	"context"
+	"encoding/hex"
 	"math/big"
+	"strings"
 	"testing"
 	"time"
 
@@ -129,12 +131,14 @@ func TestRedemptionAction_Execute(t *testing.T) {
 				bitcoinChain,
 				wallet,
 				signingExecutor,
+				nil,
 				proposal,
 				proposalProcessingStartBlock,
 				proposalExpiryBlock,
 				func(ctx context.Context, blockHeight uint64) error {
 					return nil
 				},
+				nil,
 			)
 
 			// Modify the default parameters of the action to make
@@ -269,6 +273,100 @@ func TestAssembleRedemptionTransaction(t *testing.T) {
 	}
 }
 
+func TestAssembleRedemptionTransaction_RequestValidation(t *testing.T) {
+	fromHex := func(hexString string) []byte {
+		bytes, err := hex.DecodeString(hexString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bytes
+	}
+
+	scenarios, err := test.LoadRedemptionTestScenarios()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenario := scenarios[0]
+
+	var tests = map[string]struct {
+		redeemerOutputScript bitcoin.Script
+		feeShareIncrease     int64
+		expectedErr          string
+	}{
+		"p2tr redeemer output script": {
+			redeemerOutputScript: fromHex(
+				"5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+			),
+		},
+		"non-standard redeemer output script": {
+			redeemerOutputScript: fromHex("6a0474657374"),
+			expectedErr:          "redemption request [0] has non-standard redeemer output script",
+		},
+		"fee share exceeding redeemable amount": {
+			redeemerOutputScript: scenario.RedemptionRequests[0].RedeemerOutputScript,
+			feeShareIncrease: int64(
+				scenario.RedemptionRequests[0].RequestedAmount -
+					scenario.RedemptionRequests[0].TreasuryFee,
+			),
+			expectedErr: "is not less than its redeemable amount",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			bitcoinChain := newLocalBitcoinChain()
+
+			err := bitcoinChain.BroadcastTransaction(scenario.InputTransaction)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			requests := make([]*RedemptionRequest, len(scenario.RedemptionRequests))
+			for i, r := range scenario.RedemptionRequests {
+				requests[i] = &RedemptionRequest{
+					Redeemer:             r.Redeemer,
+					RedeemerOutputScript: r.RedeemerOutputScript,
+					RequestedAmount:      r.RequestedAmount,
+					TreasuryFee:          r.TreasuryFee,
+					TxMaxFee:             r.TxMaxFee,
+					RequestedAt:          r.RequestedAt,
+				}
+			}
+			requests[0].RedeemerOutputScript = test.redeemerOutputScript
+
+			feeDistribution := func(requests []*RedemptionRequest) []int64 {
+				feeShares := append([]int64{}, scenario.FeeShares...)
+				feeShares[0] += test.feeShareIncrease
+				return feeShares
+			}
+
+			_, err = assembleRedemptionTransaction(
+				bitcoinChain,
+				scenario.WalletPublicKey,
+				scenario.WalletMainUtxo,
+				requests,
+				feeDistribution,
+			)
+
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf(
+					"unexpected error\nexpected to contain: %s\nactual: %v",
+					test.expectedErr,
+					err,
+				)
+			}
+		})
+	}
+}
+
 func TestWithRedemptionTotalFee(t *testing.T) {
 	var tests = map[string]struct {
 		totalFee          int64
@@ -305,3 +403,130 @@ func TestWithRedemptionTotalFee(t *testing.T) {
 		})
 	}
 }
+
+func TestWithRedemptionFeeShares(t *testing.T) {
+	requests := make([]*RedemptionRequest, 3)
+
+	feeShares := withRedemptionFeeShares(
+		[]*big.Int{big.NewInt(1000), big.NewInt(3000), big.NewInt(6000)},
+	)(requests)
+
+	expectedFeeShares := []int64{1000, 3000, 6000}
+	if diff := deep.Equal(expectedFeeShares, feeShares); diff != nil {
+		t.Errorf(
+			"unexpected fee shares\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expectedFeeShares,
+			feeShares,
+		)
+	}
+}
+
+func TestValidateRedemptionTxFeeShares(t *testing.T) {
+	requests := []*RedemptionRequest{
+		{TxMaxFee: 2000},
+		{TxMaxFee: 5000},
+	}
+
+	var tests = map[string]struct {
+		totalFee    int64
+		feeShares   []*big.Int
+		expectedErr string
+	}{
+		"no fee shares": {
+			totalFee:  10000,
+			feeShares: nil,
+		},
+		"valid fee shares": {
+			totalFee:  6000,
+			feeShares: []*big.Int{big.NewInt(2000), big.NewInt(4000)},
+		},
+		"fee shares count mismatch": {
+			totalFee:    2000,
+			feeShares:   []*big.Int{big.NewInt(2000)},
+			expectedErr: "fee shares count [1] does not match requests count [2]",
+		},
+		"fee share not set": {
+			totalFee:    6000,
+			feeShares:   []*big.Int{big.NewInt(2000), nil},
+			expectedErr: "fee share of request [1] is not set",
+		},
+		"negative fee share": {
+			totalFee:    6000,
+			feeShares:   []*big.Int{big.NewInt(-1000), big.NewInt(7000)},
+			expectedErr: "fee share [-1000] of request [0] is negative",
+		},
+		"fee share exceeding the maximum fee": {
+			totalFee:    6000,
+			feeShares:   []*big.Int{big.NewInt(2001), big.NewInt(3999)},
+			expectedErr: "fee share [2001] of request [0] exceeds its maximum fee [2000]",
+		},
+		"fee shares not matching the total fee": {
+			totalFee:    6000,
+			feeShares:   []*big.Int{big.NewInt(2000), big.NewInt(3000)},
+			expectedErr: "fee shares sum [5000] does not match the redemption fee [6000]",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := validateRedemptionTxFeeShares(
+				&RedemptionProposal{
+					RedemptionTxFee:       big.NewInt(test.totalFee),
+					RedemptionTxFeeShares: test.feeShares,
+				},
+				requests,
+			)
+
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: [%v]", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"error",
+				test.expectedErr,
+				err.Error(),
+			)
+		})
+	}
+}
+
+func TestRedemptionTransactionShape_String(t *testing.T) {
+	tests := map[string]struct {
+		shape          RedemptionTransactionShape
+		expectedString string
+	}{
+		"change first": {
+			shape:          RedemptionChangeFirst,
+			expectedString: "ChangeFirst",
+		},
+		"change last": {
+			shape:          RedemptionChangeLast,
+			expectedString: "ChangeLast",
+		},
+		"unknown": {
+			shape:          RedemptionTransactionShape(7),
+			expectedString: "Unknown(7)",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			testutils.AssertStringsEqual(
+				t,
+				"shape",
+				test.expectedString,
+				test.shape.String(),
+			)
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"crypto/elliptic"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
 
 	"github.com/keep-network/keep-common/pkg/persistence"
 )
@@ -45,6 +47,10 @@ type walletCacheValue struct {
 	walletID [32]byte
 	// Array of wallet signers controlled by this node.
 	signers []*signer
+	// Array of wallet signers replaced by the last key share refresh. They
+	// are kept until the refreshed signers produce their first signature
+	// so the refresh can be rolled back. Empty if there is no such refresh.
+	previousSigners []*signer
 }
 
 // newWalletRegistry creates a new instance of the walletRegistry.
@@ -56,7 +62,10 @@ func newWalletRegistry(
 
 	// Pre-populate the wallet cache using the wallet storage.
 	walletCache := make(map[string]*walletCacheValue)
-	walletSigners := walletStorage.loadSigners()
+	walletSigners, walletPreviousSigners, err := walletStorage.loadSigners()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load signers: [%w]", err)
+	}
 	if len(walletSigners) > 0 {
 		for walletStorageKey, signers := range walletSigners {
 			// We need to extract the wallet from the signers array. The
@@ -81,6 +90,7 @@ func newWalletRegistry(
 				walletPublicKeyHash: walletPublicKeyHash,
 				walletID:            walletID,
 				signers:             signers,
+				previousSigners:     walletPreviousSigners[walletStorageKey],
 			}
 
 			logger.Infof(
@@ -242,6 +252,220 @@ func (wr *walletRegistry) archiveWallet(
 	return nil
 }
 
+// replaceSigners replaces all signers of the given wallet held by the
+// walletRegistry with the given ones. The new signers must occupy exactly the
+// same signing group seats as the replaced ones. Signers holding refreshed
+// key shares must be confirmed before being passed here; see
+// keyShareRefreshAction. Replaced signers are snapshotted in the underlying
+// persistence layer and kept as previous signers of the wallet until
+// the new signers produce their first signature; see dropPreviousSigners and
+// rollbackSigners. The signers of a wallet cannot be replaced again while
+// the wallet still has previous signers. If any of the new signers cannot
+// be saved, already saved ones are rolled back to the replaced signers.
+func (wr *walletRegistry) replaceSigners(
+	walletPublicKey *ecdsa.PublicKey,
+	signers []*signer,
+) error {
+	wr.mutex.Lock()
+	defer wr.mutex.Unlock()
+
+	walletStorageKey := getWalletStorageKey(walletPublicKey)
+
+	value, ok := wr.walletCache[walletStorageKey]
+	if !ok {
+		return fmt.Errorf("wallet not found in the wallet cache")
+	}
+
+	if len(value.previousSigners) > 0 {
+		return fmt.Errorf(
+			"wallet has refreshed signers that did not sign anything yet",
+		)
+	}
+
+	if len(signers) != len(value.signers) {
+		return fmt.Errorf(
+			"wallet has [%v] signers while [%v] replacements were given",
+			len(value.signers),
+			len(signers),
+		)
+	}
+
+	replacedSigners := make(map[group.MemberIndex]*signer)
+	for _, replacedSigner := range value.signers {
+		replacedSigners[replacedSigner.signingGroupMemberIndex] = replacedSigner
+	}
+
+	for _, signer := range signers {
+		if !signer.wallet.publicKey.Equal(walletPublicKey) {
+			return fmt.Errorf(
+				"signer [%v] belongs to another wallet",
+				signer.signingGroupMemberIndex,
+			)
+		}
+
+		if _, ok := replacedSigners[signer.signingGroupMemberIndex]; !ok {
+			return fmt.Errorf(
+				"wallet has no signer [%v] to replace",
+				signer.signingGroupMemberIndex,
+			)
+		}
+	}
+
+	for _, replacedSigner := range value.signers {
+		err := wr.walletStorage.snapshotSigner(replacedSigner)
+		if err != nil {
+			return fmt.Errorf(
+				"cannot snapshot signer [%v] in the storage: [%w]",
+				replacedSigner.signingGroupMemberIndex,
+				err,
+			)
+		}
+	}
+
+	for i, replacedSigner := range value.signers {
+		err := wr.walletStorage.savePreviousSigner(replacedSigner)
+		if err != nil {
+			wr.walletStorage.clearPreviousSigners(value.signers[:i])
+
+			return fmt.Errorf(
+				"cannot save previous signer [%v] in the storage: [%w]",
+				replacedSigner.signingGroupMemberIndex,
+				err,
+			)
+		}
+	}
+
+	for i, signer := range signers {
+		err := wr.walletStorage.saveSigner(signer)
+		if err != nil {
+			wr.walletStorage.clearPreviousSigners(value.signers)
+
+			// Restore signers that were already overwritten. Otherwise,
+			// the node would hold a mix of old and new key shares of the
+			// wallet after a restart.
+			for _, savedSigner := range signers[:i] {
+				replacedSigner := replacedSigners[savedSigner.signingGroupMemberIndex]
+
+				if restoreErr := wr.walletStorage.saveSigner(
+					replacedSigner,
+				); restoreErr != nil {
+					logger.Errorf(
+						"cannot restore signer [%v] of wallet [%s] in the "+
+							"storage; recover it from the snapshot: [%v]",
+						replacedSigner.signingGroupMemberIndex,
+						walletStorageKey,
+						restoreErr,
+					)
+				}
+			}
+
+			return fmt.Errorf(
+				"cannot save signer [%v] in the storage: [%w]",
+				signer.signingGroupMemberIndex,
+				err,
+			)
+		}
+	}
+
+	value.previousSigners = value.signers
+	value.signers = signers
+
+	return nil
+}
+
+// hasPreviousSigners returns true if the given wallet has signers replaced
+// by a key share refresh whose refreshed signers did not produce any
+// signature yet.
+func (wr *walletRegistry) hasPreviousSigners(
+	walletPublicKey *ecdsa.PublicKey,
+) bool {
+	wr.mutex.Lock()
+	defer wr.mutex.Unlock()
+
+	value, ok := wr.walletCache[getWalletStorageKey(walletPublicKey)]
+
+	return ok && len(value.previousSigners) > 0
+}
+
+// dropPreviousSigners drops previous signers of the given wallet once its
+// refreshed signers produced a signature. Key shares of the previous
+// signers remain available only in the snapshots taken by replaceSigners.
+// Does nothing if the wallet has no previous signers.
+func (wr *walletRegistry) dropPreviousSigners(
+	walletPublicKey *ecdsa.PublicKey,
+) error {
+	wr.mutex.Lock()
+	defer wr.mutex.Unlock()
+
+	value, ok := wr.walletCache[getWalletStorageKey(walletPublicKey)]
+	if !ok {
+		return fmt.Errorf("wallet not found in the wallet cache")
+	}
+
+	if err := wr.walletStorage.clearPreviousSigners(
+		value.previousSigners,
+	); err != nil {
+		return err
+	}
+
+	value.previousSigners = nil
+
+	return nil
+}
+
+// rollbackSigners replaces signers of the given wallet with its previous
+// signers, reverting the last key share refresh. Returns an error if the
+// wallet has no previous signers.
+func (wr *walletRegistry) rollbackSigners(
+	walletPublicKey *ecdsa.PublicKey,
+) error {
+	wr.mutex.Lock()
+	defer wr.mutex.Unlock()
+
+	value, ok := wr.walletCache[getWalletStorageKey(walletPublicKey)]
+	if !ok {
+		return fmt.Errorf("wallet not found in the wallet cache")
+	}
+
+	if len(value.previousSigners) == 0 {
+		return fmt.Errorf("wallet has no previous signers")
+	}
+
+	for _, previousSigner := range value.previousSigners {
+		err := wr.walletStorage.saveSigner(previousSigner)
+		if err != nil {
+			// Previous signers are still kept so the rollback can be
+			// retried. Some of them may be already saved as current ones
+			// though, so a restart before a successful retry loads a mix
+			// of key shares; recover it from the snapshots.
+			return fmt.Errorf(
+				"cannot restore signer [%v] in the storage: [%w]",
+				previousSigner.signingGroupMemberIndex,
+				err,
+			)
+		}
+	}
+
+	if err := wr.walletStorage.clearPreviousSigners(
+		value.previousSigners,
+	); err != nil {
+		logger.Errorf(
+			"cannot clear previous signers of wallet [%s] in the storage: [%v]",
+			getWalletStorageKey(walletPublicKey),
+			err,
+		)
+	}
+
+	value.signers = value.previousSigners
+	value.previousSigners = nil
+
+	return nil
+}
+
+// previousMembershipFilePrefix is the prefix of names of files holding
+// previous signers of a wallet; see walletStorage.savePreviousSigner.
+const previousMembershipFilePrefix = "previous_membership_"
+
 // walletStorage is the component that persists data of the wallets managed
 // by the given node using the underlying persistence layer. It should be
 // used directly only by the walletRegistry.
@@ -280,6 +504,93 @@ func (ws *walletStorage) saveSigner(signer *signer) error {
 	return nil
 }
 
+// savePreviousSigner saves the given signer as a previous signer of its
+// wallet using the underlying persistence layer of the walletStorage.
+// Previous signers are loaded separately from the current ones by
+// loadSigners. This function should not be called from any other place
+// than walletRegistry.
+func (ws *walletStorage) savePreviousSigner(signer *signer) error {
+	signerBytes, err := signer.Marshal()
+	if err != nil {
+		return fmt.Errorf("could not marshal signer: [%w]", err)
+	}
+
+	err = ws.persistence.Save(
+		signerBytes,
+		getWalletStorageKey(signer.wallet.publicKey),
+		fmt.Sprintf(
+			"/%s%v",
+			previousMembershipFilePrefix,
+			signer.signingGroupMemberIndex,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"could not save previous membership using the "+
+				"underlying persistence layer: [%w]",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// clearPreviousSigners clears the given previous signers persisted with
+// savePreviousSigner. The underlying persistence layer does not allow
+// removing data so the signers are overwritten with empty content that is
+// skipped by loadSigners. All signers are cleared even if some of them
+// fail; the first error is returned.
+func (ws *walletStorage) clearPreviousSigners(signers []*signer) error {
+	var firstErr error
+
+	for _, signer := range signers {
+		err := ws.persistence.Save(
+			[]byte{},
+			getWalletStorageKey(signer.wallet.publicKey),
+			fmt.Sprintf(
+				"/%s%v",
+				previousMembershipFilePrefix,
+				signer.signingGroupMemberIndex,
+			),
+		)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf(
+				"could not clear previous membership [%v] using the "+
+					"underlying persistence layer: [%w]",
+				signer.signingGroupMemberIndex,
+				err,
+			)
+		}
+	}
+
+	return firstErr
+}
+
+// snapshotSigner saves a snapshot of the given signer using the underlying
+// persistence layer of the walletStorage. Snapshots are not loaded by
+// loadSigners and serve only as a backup of the signer's data.
+func (ws *walletStorage) snapshotSigner(signer *signer) error {
+	signerBytes, err := signer.Marshal()
+	if err != nil {
+		return fmt.Errorf("could not marshal signer: [%w]", err)
+	}
+
+	err = ws.persistence.Snapshot(
+		signerBytes,
+		getWalletStorageKey(signer.wallet.publicKey),
+		fmt.Sprintf("/membership_%v", signer.signingGroupMemberIndex),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"could not snapshot membership using the "+
+				"underlying persistence layer: [%w]",
+			err,
+		)
+	}
+
+	return nil
+}
+
 // archiveWallet archives the given wallet data in the underlying persistence
 // layer of the walletStorage.
 func (ws *walletStorage) archiveWallet(walletStorageKey string) error {
@@ -296,14 +607,25 @@ func (ws *walletStorage) archiveWallet(walletStorageKey string) error {
 }
 
 // loadSigners loads all signers stored using the underlying persistence layer.
-// This function should not be called from any other place than walletRegistry.
-func (ws *walletStorage) loadSigners() map[string][]*signer {
+// Current signers are returned in the first map and previous signers saved
+// with savePreviousSigner in the second one. An error is returned if any
+// stored entry cannot be read, as starting without some of the signers
+// could make the client silently skip its wallets, for example, when the
+// remote key store is temporarily unavailable. Entries that are read but
+// cannot be unmarshalled are only logged. This function should not be
+// called from any other place than walletRegistry.
+func (ws *walletStorage) loadSigners() (
+	map[string][]*signer,
+	map[string][]*signer,
+	error,
+) {
 	signersByWallet := make(map[string][]*signer)
+	previousSignersByWallet := make(map[string][]*signer)
 
 	descriptorsChan, errorsChan := ws.persistence.ReadAll()
 
 	// Two goroutines read from descriptors and errors channels and either
-	// add the signer to the result map or outputs a log error.
+	// add the signer to the result map or record the read error.
 	// The reason for using two goroutines at the same time - one for
 	// descriptors and one for errors - is that channels do not have to be
 	// buffered, and we do not know in what order the information is written to
@@ -311,17 +633,29 @@ func (ws *walletStorage) loadSigners() map[string][]*signer {
 	var wg sync.WaitGroup
 	wg.Add(2)
 
+	var contentErrors, readErrors []error
+
 	go func() {
 		for descriptor := range descriptorsChan {
 			content, err := descriptor.Content()
 			if err != nil {
-				logger.Errorf(
+				contentErrors = append(contentErrors, fmt.Errorf(
 					"could not get content from file [%v] "+
-						"in directory [%v]: [%v]",
+						"in directory [%v]: [%w]",
 					descriptor.Name(),
 					descriptor.Directory(),
 					err,
-				)
+				))
+				continue
+			}
+
+			isPrevious := strings.HasPrefix(
+				strings.TrimPrefix(descriptor.Name(), "/"),
+				previousMembershipFilePrefix,
+			)
+
+			if isPrevious && len(content) == 0 {
+				// Previous signer cleared by clearPreviousSigners.
 				continue
 			}
 
@@ -339,6 +673,14 @@ func (ws *walletStorage) loadSigners() map[string][]*signer {
 
 			walletStorageKey := getWalletStorageKey(signer.wallet.publicKey)
 
+			if isPrevious {
+				previousSignersByWallet[walletStorageKey] = append(
+					previousSignersByWallet[walletStorageKey],
+					signer,
+				)
+				continue
+			}
+
 			signersByWallet[walletStorageKey] = append(
 				signersByWallet[walletStorageKey],
 				signer,
@@ -350,10 +692,7 @@ func (ws *walletStorage) loadSigners() map[string][]*signer {
 
 	go func() {
 		for err := range errorsChan {
-			logger.Errorf(
-				"could not load signer from disk: [%v]",
-				err,
-			)
+			readErrors = append(readErrors, err)
 		}
 
 		wg.Done()
@@ -361,7 +700,20 @@ func (ws *walletStorage) loadSigners() map[string][]*signer {
 
 	wg.Wait()
 
-	return signersByWallet
+	errs := append(readErrors, contentErrors...)
+	for _, err := range errs {
+		logger.Errorf("could not load signer: [%v]", err)
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf(
+			"could not read [%d] stored signer entries; first error: [%w]",
+			len(errs),
+			errs[0],
+		)
+	}
+
+	return signersByWallet, previousSignersByWallet, nil
 }
 
 // getWalletStorageKey compute the wallet storage key that is used to identify
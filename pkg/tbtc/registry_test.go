@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/internal/tecdsatest"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
 	"github.com/keep-network/keep-core/pkg/tecdsa"
 
 	"github.com/keep-network/keep-common/pkg/persistence"
@@ -288,6 +290,47 @@ func TestWalletRegistry_PrePopulateWalletCache(t *testing.T) {
 	}
 }
 
+func TestWalletRegistry_PrePopulateWalletCache_ReadFailure(t *testing.T) {
+	signer := createMockSigner(t)
+	signerBytes, err := signer.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	persistenceHandle := &mockPersistenceHandle{
+		saved: []persistence.DataDescriptor{
+			&mockDescriptor{
+				name:      "membership_1",
+				directory: "wallet_1",
+				content:   signerBytes,
+			},
+			&mockDescriptor{
+				name:       "membership_2",
+				directory:  "wallet_1",
+				contentErr: fmt.Errorf("unexpected read failure"),
+			},
+		},
+	}
+
+	chain := Connect()
+
+	_, err = newWalletRegistry(
+		persistenceHandle,
+		chain.CalculateWalletID,
+	)
+
+	expectedErr := "cannot load signers: [could not read [1] stored " +
+		"signer entries; first error: [could not get content from file " +
+		"[membership_2] in directory [wallet_1]: [unexpected read failure]]]"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf(
+			"unexpected error\nexpected: %v\nactual:   %v",
+			expectedErr,
+			err,
+		)
+	}
+}
+
 func TestWalletRegistry_GetWalletsPublicKeys(t *testing.T) {
 	persistenceHandle := &mockPersistenceHandle{}
 	chain := Connect()
@@ -402,6 +445,496 @@ func TestWalletRegistry_ArchiveWallet_NotFound(t *testing.T) {
 	}
 }
 
+func TestWalletRegistry_ReplaceSigners(t *testing.T) {
+	persistenceHandle := &mockPersistenceHandle{}
+	chain := Connect()
+
+	walletRegistry, err := newWalletRegistry(
+		persistenceHandle,
+		chain.CalculateWalletID,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalSigner := createMockSigner(t)
+
+	walletStorageKey := getWalletStorageKey(originalSigner.wallet.publicKey)
+
+	err = walletRegistry.registerSigner(originalSigner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(2)
+	if err != nil {
+		t.Fatalf("failed to load test data: [%v]", err)
+	}
+
+	refreshedSigner := newSigner(
+		originalSigner.wallet.publicKey,
+		originalSigner.wallet.signingGroupOperators,
+		originalSigner.signingGroupMemberIndex,
+		tecdsa.NewPrivateKeyShare(testData[1]),
+	)
+
+	err = walletRegistry.replaceSigners(
+		originalSigner.wallet.publicKey,
+		[]*signer{refreshedSigner},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetchedSigners := walletRegistry.getSigners(originalSigner.wallet.publicKey)
+
+	testutils.AssertIntsEqual(
+		t,
+		"fetched wallet signers count",
+		1,
+		len(fetchedSigners),
+	)
+
+	if !reflect.DeepEqual(refreshedSigner, fetchedSigners[0]) {
+		t.Errorf("fetched wallet signer differs from the new one")
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"snapshotted wallet signers count",
+		1,
+		len(persistenceHandle.snapshots),
+	)
+
+	snapshottedSigner := &signer{}
+	err = snapshottedSigner.Unmarshal(persistenceHandle.snapshots[0].(*mockDescriptor).content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(originalSigner, snapshottedSigner) {
+		t.Errorf("snapshotted wallet signer differs from the original one")
+	}
+
+	// Registration of the original signer, save of the original signer as
+	// the previous one, and save of the refreshed signer.
+	testutils.AssertIntsEqual(
+		t,
+		"persisted wallet signers count",
+		3,
+		len(persistenceHandle.saved),
+	)
+
+	testutils.AssertStringsEqual(
+		t,
+		"previous wallet signer file name",
+		"/previous_membership_1",
+		persistenceHandle.saved[1].Name(),
+	)
+
+	previousSigner := &signer{}
+	err = previousSigner.Unmarshal(persistenceHandle.saved[1].(*mockDescriptor).content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(originalSigner, previousSigner) {
+		t.Errorf("previous wallet signer differs from the original one")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"persisted wallet directory",
+		walletStorageKey,
+		persistenceHandle.saved[2].Directory(),
+	)
+	testutils.AssertStringsEqual(
+		t,
+		"persisted wallet file name",
+		persistenceHandle.saved[0].Name(),
+		persistenceHandle.saved[2].Name(),
+	)
+
+	if !walletRegistry.hasPreviousSigners(originalSigner.wallet.publicKey) {
+		t.Errorf("wallet should have previous signers")
+	}
+}
+
+func TestWalletRegistry_ReplaceSigners_RolledBack(t *testing.T) {
+	persistenceHandle := &mockPersistenceHandle{}
+	chain := Connect()
+
+	walletRegistry, err := newWalletRegistry(
+		persistenceHandle,
+		chain.CalculateWalletID,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(4)
+	if err != nil {
+		t.Fatalf("failed to load test data: [%v]", err)
+	}
+
+	originalSigner := createMockSigner(t)
+
+	var originalSigners, refreshedSigners []*signer
+	for i := 0; i < 2; i++ {
+		memberIndex := group.MemberIndex(i + 1)
+
+		originalSigners = append(originalSigners, newSigner(
+			originalSigner.wallet.publicKey,
+			originalSigner.wallet.signingGroupOperators,
+			memberIndex,
+			tecdsa.NewPrivateKeyShare(testData[i]),
+		))
+		refreshedSigners = append(refreshedSigners, newSigner(
+			originalSigner.wallet.publicKey,
+			originalSigner.wallet.signingGroupOperators,
+			memberIndex,
+			tecdsa.NewPrivateKeyShare(testData[i+2]),
+		))
+
+		err = walletRegistry.registerSigner(originalSigners[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	persistenceHandle.failingSaveName = "/membership_2"
+
+	err = walletRegistry.replaceSigners(
+		originalSigner.wallet.publicKey,
+		refreshedSigners,
+	)
+
+	expectedErr := "cannot save signer [2] in the storage: [could not " +
+		"save membership using the underlying persistence layer: " +
+		"[unexpected save failure]]"
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		expectedErr,
+		fmt.Sprintf("%v", err),
+	)
+
+	if !reflect.DeepEqual(
+		originalSigners,
+		walletRegistry.getSigners(originalSigner.wallet.publicKey),
+	) {
+		t.Errorf("wallet signers should not be replaced")
+	}
+
+	// Registration of 2 signers, save of 2 previous signers, save of the
+	// first refreshed signer, clearing of 2 previous signers, and
+	// restoration of the first original signer.
+	testutils.AssertIntsEqual(
+		t,
+		"persisted wallet signers count",
+		8,
+		len(persistenceHandle.saved),
+	)
+
+	for i := 5; i < 7; i++ {
+		testutils.AssertIntsEqual(
+			t,
+			fmt.Sprintf("cleared previous wallet signer [%v] length", i),
+			0,
+			len(persistenceHandle.saved[i].(*mockDescriptor).content),
+		)
+	}
+
+	restoredSigner := &signer{}
+	err = restoredSigner.Unmarshal(persistenceHandle.saved[7].(*mockDescriptor).content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(originalSigners[0], restoredSigner) {
+		t.Errorf("restored wallet signer differs from the original one")
+	}
+
+	if walletRegistry.hasPreviousSigners(originalSigner.wallet.publicKey) {
+		t.Errorf("wallet should not have previous signers")
+	}
+}
+
+func TestWalletRegistry_DropPreviousSigners(t *testing.T) {
+	persistenceHandle := &mockPersistenceHandle{}
+	chain := Connect()
+
+	walletRegistry, err := newWalletRegistry(
+		persistenceHandle,
+		chain.CalculateWalletID,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalSigner := createMockSigner(t)
+
+	err = walletRegistry.registerSigner(originalSigner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(2)
+	if err != nil {
+		t.Fatalf("failed to load test data: [%v]", err)
+	}
+
+	refreshedSigner := newSigner(
+		originalSigner.wallet.publicKey,
+		originalSigner.wallet.signingGroupOperators,
+		originalSigner.signingGroupMemberIndex,
+		tecdsa.NewPrivateKeyShare(testData[1]),
+	)
+
+	err = walletRegistry.replaceSigners(
+		originalSigner.wallet.publicKey,
+		[]*signer{refreshedSigner},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signers cannot be replaced again until the refreshed ones sign.
+	err = walletRegistry.replaceSigners(
+		originalSigner.wallet.publicKey,
+		[]*signer{originalSigner},
+	)
+	expectedErr := fmt.Errorf(
+		"wallet has refreshed signers that did not sign anything yet",
+	)
+	if !reflect.DeepEqual(expectedErr, err) {
+		t.Errorf(
+			"unexpected error\nexpected: [%v]\nactual:   [%v]",
+			expectedErr,
+			err,
+		)
+	}
+
+	err = walletRegistry.dropPreviousSigners(originalSigner.wallet.publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if walletRegistry.hasPreviousSigners(originalSigner.wallet.publicKey) {
+		t.Errorf("wallet should not have previous signers")
+	}
+
+	if !reflect.DeepEqual(
+		[]*signer{refreshedSigner},
+		walletRegistry.getSigners(originalSigner.wallet.publicKey),
+	) {
+		t.Errorf("wallet signers should remain refreshed")
+	}
+
+	lastSaved := persistenceHandle.saved[len(persistenceHandle.saved)-1]
+
+	testutils.AssertStringsEqual(
+		t,
+		"cleared previous wallet signer file name",
+		"/previous_membership_1",
+		lastSaved.Name(),
+	)
+	testutils.AssertIntsEqual(
+		t,
+		"cleared previous wallet signer length",
+		0,
+		len(lastSaved.(*mockDescriptor).content),
+	)
+}
+
+func TestWalletRegistry_RollbackSigners(t *testing.T) {
+	persistenceHandle := &mockPersistenceHandle{}
+	chain := Connect()
+
+	walletRegistry, err := newWalletRegistry(
+		persistenceHandle,
+		chain.CalculateWalletID,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalSigner := createMockSigner(t)
+
+	err = walletRegistry.registerSigner(originalSigner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = walletRegistry.rollbackSigners(originalSigner.wallet.publicKey)
+	expectedErr := fmt.Errorf("wallet has no previous signers")
+	if !reflect.DeepEqual(expectedErr, err) {
+		t.Errorf(
+			"unexpected error\nexpected: [%v]\nactual:   [%v]",
+			expectedErr,
+			err,
+		)
+	}
+
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(2)
+	if err != nil {
+		t.Fatalf("failed to load test data: [%v]", err)
+	}
+
+	refreshedSigner := newSigner(
+		originalSigner.wallet.publicKey,
+		originalSigner.wallet.signingGroupOperators,
+		originalSigner.signingGroupMemberIndex,
+		tecdsa.NewPrivateKeyShare(testData[1]),
+	)
+
+	err = walletRegistry.replaceSigners(
+		originalSigner.wallet.publicKey,
+		[]*signer{refreshedSigner},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = walletRegistry.rollbackSigners(originalSigner.wallet.publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if walletRegistry.hasPreviousSigners(originalSigner.wallet.publicKey) {
+		t.Errorf("wallet should not have previous signers")
+	}
+
+	if !reflect.DeepEqual(
+		[]*signer{originalSigner},
+		walletRegistry.getSigners(originalSigner.wallet.publicKey),
+	) {
+		t.Errorf("wallet signers should be rolled back")
+	}
+
+	// Registration, save of the previous signer, save of the refreshed
+	// signer, restoration of the original signer, and clearing of
+	// the previous signer.
+	testutils.AssertIntsEqual(
+		t,
+		"persisted wallet signers count",
+		5,
+		len(persistenceHandle.saved),
+	)
+
+	restoredSigner := &signer{}
+	err = restoredSigner.Unmarshal(persistenceHandle.saved[3].(*mockDescriptor).content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(originalSigner, restoredSigner) {
+		t.Errorf("restored wallet signer differs from the original one")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"cleared previous wallet signer file name",
+		"/previous_membership_1",
+		persistenceHandle.saved[4].Name(),
+	)
+	testutils.AssertIntsEqual(
+		t,
+		"cleared previous wallet signer length",
+		0,
+		len(persistenceHandle.saved[4].(*mockDescriptor).content),
+	)
+}
+
+func TestWalletRegistry_ReplaceSigners_Rejected(t *testing.T) {
+	originalSigner := createMockSigner(t)
+
+	otherWalletSigner := createMockSigner(t)
+	otherWalletSigner.wallet.publicKey = &ecdsa.PublicKey{
+		Curve: tecdsa.Curve,
+		X:     tecdsa.Curve.Params().Gx,
+		Y:     tecdsa.Curve.Params().Gy,
+	}
+
+	otherSeatSigner := createMockSigner(t)
+	otherSeatSigner.signingGroupMemberIndex = 2
+
+	tests := map[string]struct {
+		walletPublicKey *ecdsa.PublicKey
+		signers         []*signer
+		expectedErr     error
+	}{
+		"unknown wallet": {
+			walletPublicKey: otherWalletSigner.wallet.publicKey,
+			signers:         []*signer{otherWalletSigner},
+			expectedErr:     fmt.Errorf("wallet not found in the wallet cache"),
+		},
+		"signers count mismatch": {
+			walletPublicKey: originalSigner.wallet.publicKey,
+			signers:         []*signer{originalSigner, otherSeatSigner},
+			expectedErr: fmt.Errorf(
+				"wallet has [1] signers while [2] replacements were given",
+			),
+		},
+		"signer of another wallet": {
+			walletPublicKey: originalSigner.wallet.publicKey,
+			signers:         []*signer{otherWalletSigner},
+			expectedErr:     fmt.Errorf("signer [1] belongs to another wallet"),
+		},
+		"signer of another seat": {
+			walletPublicKey: originalSigner.wallet.publicKey,
+			signers:         []*signer{otherSeatSigner},
+			expectedErr:     fmt.Errorf("wallet has no signer [2] to replace"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			persistenceHandle := &mockPersistenceHandle{}
+			chain := Connect()
+
+			walletRegistry, err := newWalletRegistry(
+				persistenceHandle,
+				chain.CalculateWalletID,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = walletRegistry.registerSigner(originalSigner)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = walletRegistry.replaceSigners(
+				test.walletPublicKey,
+				test.signers,
+			)
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Errorf(
+					"unexpected error\nexpected: [%v]\nactual:   [%v]",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"snapshotted wallet signers count",
+				0,
+				len(persistenceHandle.snapshots),
+			)
+
+			if !reflect.DeepEqual(
+				[]*signer{originalSigner},
+				walletRegistry.getSigners(originalSigner.wallet.publicKey),
+			) {
+				t.Errorf("wallet signers should not be replaced")
+			}
+		})
+	}
+}
+
 func TestWalletStorage_SaveSigner(t *testing.T) {
 	persistenceHandle := &mockPersistenceHandle{}
 
@@ -431,6 +964,13 @@ func TestWalletStorage_LoadSigners(t *testing.T) {
 
 	walletStorageKey := getWalletStorageKey(signer.wallet.publicKey)
 
+	previousSigner := createMockSigner(t)
+	previousSigner.signingGroupMemberIndex = 2
+	previousSignerBytes, err := previousSigner.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	persistenceHandle := &mockPersistenceHandle{
 		saved: []persistence.DataDescriptor{
 			&mockDescriptor{
@@ -438,12 +978,25 @@ func TestWalletStorage_LoadSigners(t *testing.T) {
 				directory: "wallet_1",
 				content:   signerBytes,
 			},
+			&mockDescriptor{
+				name:      "/previous_membership_1",
+				directory: "wallet_1",
+				content:   []byte{},
+			},
+			&mockDescriptor{
+				name:      "/previous_membership_2",
+				directory: "wallet_1",
+				content:   previousSignerBytes,
+			},
 		},
 	}
 
 	walletStorage := newWalletStorage(persistenceHandle)
 
-	signersByWallet := walletStorage.loadSigners()
+	signersByWallet, previousSignersByWallet, err := walletStorage.loadSigners()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	testutils.AssertIntsEqual(
 		t,
@@ -462,6 +1015,21 @@ func TestWalletStorage_LoadSigners(t *testing.T) {
 	if !reflect.DeepEqual(signer, signersByWallet[walletStorageKey][0]) {
 		t.Errorf("loaded wallet signer differs from the original one")
 	}
+
+	// The cleared previous signer must be skipped.
+	testutils.AssertIntsEqual(
+		t,
+		"loaded wallet previous signers count",
+		1,
+		len(previousSignersByWallet[walletStorageKey]),
+	)
+
+	if !reflect.DeepEqual(
+		previousSigner,
+		previousSignersByWallet[walletStorageKey][0],
+	) {
+		t.Errorf("loaded previous wallet signer differs from the original one")
+	}
 }
 
 func TestWalletStorage_ArchiveWallet(t *testing.T) {
@@ -499,8 +1067,12 @@ func TestWalletStorage_ArchiveWallet(t *testing.T) {
 }
 
 type mockPersistenceHandle struct {
-	saved    []persistence.DataDescriptor
-	archived []string
+	saved     []persistence.DataDescriptor
+	snapshots []persistence.DataDescriptor
+	archived  []string
+
+	// failingSaveName is the name of a file whose save fails, if set.
+	failingSaveName string
 }
 
 func (mph *mockPersistenceHandle) Save(
@@ -508,6 +1080,10 @@ func (mph *mockPersistenceHandle) Save(
 	directory string,
 	name string,
 ) error {
+	if mph.failingSaveName != "" && name == mph.failingSaveName {
+		return fmt.Errorf("unexpected save failure")
+	}
+
 	mph.saved = append(mph.saved, &mockDescriptor{
 		name:      name,
 		directory: directory,
@@ -522,7 +1098,13 @@ func (mph *mockPersistenceHandle) Snapshot(
 	directory string,
 	name string,
 ) error {
-	panic("not implemented")
+	mph.snapshots = append(mph.snapshots, &mockDescriptor{
+		name:      name,
+		directory: directory,
+		content:   data,
+	})
+
+	return nil
 }
 
 func (mph *mockPersistenceHandle) ReadAll() (
@@ -548,13 +1130,25 @@ func (mph *mockPersistenceHandle) Archive(directory string) error {
 }
 
 func (mph *mockPersistenceHandle) Delete(directory string, name string) error {
-	panic("not implemented")
+	saved := make([]persistence.DataDescriptor, 0)
+	for _, descriptor := range mph.saved {
+		if descriptor.Directory() != directory || descriptor.Name() != name {
+			saved = append(saved, descriptor)
+		}
+	}
+
+	mph.saved = saved
+
+	return nil
 }
 
 type mockDescriptor struct {
 	name      string
 	directory string
 	content   []byte
+
+	// contentErr is the error returned when reading the content, if set.
+	contentErr error
 }
 
 func (md *mockDescriptor) Name() string {
@@ -566,5 +1160,9 @@ func (md *mockDescriptor) Directory() string {
 }
 
 func (md *mockDescriptor) Content() ([]byte, error) {
+	if md.contentErr != nil {
+		return nil, md.contentErr
+	}
+
 	return md.content, nil
 }
@@ -58,8 +58,17 @@ type signingExecutor struct {
 	// be made by a single signer for the given message. Once the attempts
 	// limit is hit the signer gives up.
 	signingAttemptsLimit uint
+
+	// signingOutcomeFn is a function called with the outcome of each
+	// signing performed by the executor. Can be nil.
+	signingOutcomeFn signingOutcomeFn
 }
 
+// signingOutcomeFn represents a function called with the outcome of
+// a signing performed by the signing executor of the given wallet. The error
+// is nil if the signature was produced.
+type signingOutcomeFn func(wallet wallet, err error)
+
 func newSigningExecutor(
 	signers []*signer,
 	broadcastChannel net.BroadcastChannel,
@@ -69,6 +78,7 @@ func newSigningExecutor(
 	getCurrentBlockFn getCurrentBlockFn,
 	waitForBlockFn waitForBlockFn,
 	signingAttemptsLimit uint,
+	signingOutcomeFn signingOutcomeFn,
 ) *signingExecutor {
 	return &signingExecutor{
 		lock:                 semaphore.NewWeighted(1),
@@ -80,25 +90,27 @@ func newSigningExecutor(
 		getCurrentBlockFn:    getCurrentBlockFn,
 		waitForBlockFn:       waitForBlockFn,
 		signingAttemptsLimit: signingAttemptsLimit,
+		signingOutcomeFn:     signingOutcomeFn,
 	}
 }
 
 // signBatch performs the signing process for each message from the given
 // messages batch, one after another. If at least one message cannot be signed,
 // this function returns an error. If all messages were signed successfully,
-// a slice of signatures is returned. Order of the returned signatures matches
-// the order of the messages in the batch, i.e. the first signature corresponds
-// to the first message, and so on.
+// a slice of signatures is returned along with the activity reports of
+// the signing attempts that produced them. Order of the returned signatures
+// and reports matches the order of the messages in the batch, i.e. the first
+// signature corresponds to the first message, and so on.
 func (se *signingExecutor) signBatch(
 	ctx context.Context,
 	messages []*big.Int,
 	startBlock uint64,
-) ([]*tecdsa.Signature, error) {
+) ([]*tecdsa.Signature, []*signingActivityReport, error) {
 	wallet := se.wallet()
 
 	walletPublicKeyBytes, err := marshalPublicKey(wallet.publicKey)
 	if err != nil {
-		return nil, fmt.Errorf("cannot marshal wallet public key: [%v]", err)
+		return nil, nil, fmt.Errorf("cannot marshal wallet public key: [%v]", err)
 	}
 
 	messagesDigests := make([]string, len(messages))
@@ -131,6 +143,7 @@ func (se *signingExecutor) signBatch(
 
 	signingStartBlock := startBlock // start block for the first signing
 	signatures := make([]*tecdsa.Signature, len(messages))
+	activityReports := make([]*signingActivityReport, len(messages))
 	endBlocks := make([]uint64, len(messages))
 
 	for i, message := range messages {
@@ -145,9 +158,13 @@ func (se *signingExecutor) signBatch(
 			signingStartBlock = endBlocks[i-1] + signingBatchInterludeBlocks
 		}
 
-		signature, _, endBlock, err := se.sign(ctx, message, signingStartBlock)
+		signature, activityReport, endBlock, err := se.sign(
+			ctx,
+			message,
+			signingStartBlock,
+		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		signingBatchMessageLogger.Infof(
@@ -157,10 +174,11 @@ func (se *signingExecutor) signBatch(
 		)
 
 		signatures[i] = signature
+		activityReports[i] = activityReport
 		endBlocks[i] = endBlock
 	}
 
-	return signatures, nil
+	return signatures, activityReports, nil
 }
 
 // sign performs the signing process for the given message. The process is
@@ -386,9 +404,18 @@ func (se *signingExecutor) sign(
 	// signer, that means all signers failed and have not produced a signature.
 	select {
 	case outcome := <-signingOutcomeChan:
+		se.reportSigningOutcome(nil)
 		return outcome.signature, outcome.activityReport, outcome.endBlock, nil
 	default:
-		return nil, nil, 0, fmt.Errorf("all signers failed")
+		err := fmt.Errorf("all signers failed")
+		se.reportSigningOutcome(err)
+		return nil, nil, 0, err
+	}
+}
+
+func (se *signingExecutor) reportSigningOutcome(err error) {
+	if se.signingOutcomeFn != nil {
+		se.signingOutcomeFn(se.wallet(), err)
 	}
 }
 
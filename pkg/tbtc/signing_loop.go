@@ -145,6 +145,11 @@ type signingAttemptFn func(*signingAttemptParams) (*signing.Result, uint64, erro
 type signingActivityReport struct {
 	activeMembers   []group.MemberIndex
 	inactiveMembers []group.MemberIndex
+	// attemptNumber is the number of the attempt that produced the signature.
+	attemptNumber uint
+	// excludedMembers holds members excluded from the attempt that produced
+	// the signature.
+	excludedMembers []group.MemberIndex
 }
 
 // signingRetryLoopResult represents the result of the signing retry loop.
@@ -419,6 +424,8 @@ func (srl *signingRetryLoop) start(
 		activityReport := &signingActivityReport{
 			activeMembers:   readyMembersIndexes,
 			inactiveMembers: unreadyMembersIndexes,
+			attemptNumber:   srl.attemptCounter,
+			excludedMembers: excludedMembersIndexes,
 		}
 
 		return &signingRetryLoopResult{
@@ -104,6 +104,8 @@ func TestSigningRetryLoop(t *testing.T) {
 				activityReport: &signingActivityReport{
 					activeMembers:   signingGroupMembersIndexes,
 					inactiveMembers: []group.MemberIndex{},
+					attemptNumber:   1,
+					excludedMembers: []group.MemberIndex{3, 7, 8, 10},
 				},
 				latestEndBlock:      215, // the end block resolved by the done check phase
 				attemptTimeoutBlock: 236, // start block of the first attempt + 30
@@ -158,6 +160,8 @@ func TestSigningRetryLoop(t *testing.T) {
 				activityReport: &signingActivityReport{
 					activeMembers:   []group.MemberIndex{1, 2, 3, 6, 7, 9},
 					inactiveMembers: []group.MemberIndex{4, 5, 8, 10},
+					attemptNumber:   1,
+					excludedMembers: []group.MemberIndex{4, 5, 8, 10},
 				},
 				latestEndBlock:      215, // the end block resolved by the done check phase
 				attemptTimeoutBlock: 236, // start block of the first attempt + 30
@@ -216,6 +220,8 @@ func TestSigningRetryLoop(t *testing.T) {
 				activityReport: &signingActivityReport{
 					activeMembers:   signingGroupMembersIndexes,
 					inactiveMembers: []group.MemberIndex{},
+					attemptNumber:   2,
+					excludedMembers: []group.MemberIndex{1, 2, 5, 9},
 				},
 				latestEndBlock:      260, // the end block resolved by the done check phase
 				attemptTimeoutBlock: 277, // start block of the second attempt + 30
@@ -276,6 +282,8 @@ func TestSigningRetryLoop(t *testing.T) {
 				activityReport: &signingActivityReport{
 					activeMembers:   signingGroupMembersIndexes,
 					inactiveMembers: []group.MemberIndex{},
+					attemptNumber:   2,
+					excludedMembers: []group.MemberIndex{1, 2, 5, 9},
 				},
 				latestEndBlock:      260, // the end block resolved by the done check phase
 				attemptTimeoutBlock: 277, // start block of the second attempt + 30
@@ -336,6 +344,8 @@ func TestSigningRetryLoop(t *testing.T) {
 				activityReport: &signingActivityReport{
 					activeMembers:   signingGroupMembersIndexes,
 					inactiveMembers: []group.MemberIndex{},
+					attemptNumber:   2,
+					excludedMembers: []group.MemberIndex{1, 2, 5, 9},
 				},
 				latestEndBlock:      260, // the end block resolved by the done check phase
 				attemptTimeoutBlock: 277, // start block of the second attempt + 30
@@ -388,6 +398,8 @@ func TestSigningRetryLoop(t *testing.T) {
 				activityReport: &signingActivityReport{
 					activeMembers:   signingGroupMembersIndexes,
 					inactiveMembers: []group.MemberIndex{},
+					attemptNumber:   2,
+					excludedMembers: []group.MemberIndex{1, 2, 5, 9},
 				},
 				latestEndBlock:      260, // the end block resolved by the done check phase
 				attemptTimeoutBlock: 277, // start block of the second attempt + 30
@@ -463,6 +475,8 @@ func TestSigningRetryLoop(t *testing.T) {
 				activityReport: &signingActivityReport{
 					activeMembers:   signingGroupMembersIndexes,
 					inactiveMembers: []group.MemberIndex{},
+					attemptNumber:   2,
+					excludedMembers: []group.MemberIndex{1, 2, 5, 9},
 				},
 				latestEndBlock:      260, // the end block resolved by the done check phase
 				attemptTimeoutBlock: 277, // start block of the second attempt + 30
@@ -572,6 +586,8 @@ func TestSigningRetryLoop(t *testing.T) {
 				activityReport: &signingActivityReport{
 					activeMembers:   signingGroupMembersIndexes,
 					inactiveMembers: []group.MemberIndex{},
+					attemptNumber:   2,
+					excludedMembers: []group.MemberIndex{1, 2, 5, 9},
 				},
 				latestEndBlock:      260, // the end block resolved by the done check phase
 				attemptTimeoutBlock: 277, // start block of the second attempt + 30
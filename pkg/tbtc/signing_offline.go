@@ -0,0 +1,513 @@
+package tbtc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+	"github.com/keep-network/keep-core/pkg/tecdsa/signing"
+)
+
+// OfflineSigningChannelName returns the name of the broadcast channel used
+// to sign offline with the wallet of the given public key hash. All signing
+// group members must use the same channel name.
+func OfflineSigningChannelName(walletPublicKeyHash [20]byte) string {
+	return fmt.Sprintf("%s-offline-%x", ProtocolName, walletPublicKeyHash)
+}
+
+// SignProposalOffline signs the Bitcoin transaction described by the given
+// proposal with the wallet of the given public key hash, using the wallet
+// signers stored in the given key store persistence. It is meant to be used
+// only during incident recovery, when the wallet cannot sign through the
+// peer-to-peer network. The signing protocol messages are exchanged through
+// the given broadcast channel which is supposed to be an out-of-band one,
+// e.g. exchanging messages through files.
+//
+// Before signing anything, the proposal is validated against the host and
+// Bitcoin chains the same way the wallet action executing it would do, and
+// the unsigned transaction is assembled from the on-chain state. Signature
+// hashes are computed from that transaction so only transactions the Bridge
+// accepts can be signed. Each transaction input is signed in a separate
+// signing session whose ID is derived from the given session ID and the
+// input index. Supported proposals are deposit sweep, redemption, moving
+// funds, and moved funds sweep ones.
+//
+// In contrast to the regular signing, there is no retry loop and no block
+// based timeouts. All participating signing group members must use the same
+// proposal, session ID, and set of excluded members, and the signing lasts
+// until all participants complete the protocol or the given context is done.
+func SignProposalOffline(
+	ctx context.Context,
+	hostChain Chain,
+	btcChain bitcoin.Chain,
+	keyStorePersistence persistence.ProtectedHandle,
+	walletPublicKeyHash [20]byte,
+	proposal CoordinationProposal,
+	sessionID string,
+	excludedMembersIndexes []group.MemberIndex,
+	broadcastChannel net.BroadcastChannel,
+) (*bitcoin.Transaction, error) {
+	return signProposalOffline(
+		ctx,
+		hostChain,
+		btcChain,
+		keyStorePersistence,
+		walletPublicKeyHash,
+		proposal,
+		sessionID,
+		excludedMembersIndexes,
+		broadcastChannel,
+		defaultGroupParameters(),
+	)
+}
+
+func signProposalOffline(
+	ctx context.Context,
+	hostChain Chain,
+	btcChain bitcoin.Chain,
+	keyStorePersistence persistence.ProtectedHandle,
+	walletPublicKeyHash [20]byte,
+	proposal CoordinationProposal,
+	sessionID string,
+	excludedMembersIndexes []group.MemberIndex,
+	broadcastChannel net.BroadcastChannel,
+	groupParameters *GroupParameters,
+) (*bitcoin.Transaction, error) {
+	signers, err := loadOfflineSigners(
+		keyStorePersistence,
+		walletPublicKeyHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	walletPublicKey := signers[0].wallet.publicKey
+
+	unsignedTx, err := assembleProposalTransaction(
+		hostChain,
+		btcChain,
+		walletPublicKey,
+		proposal,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sigHashes, err := unsignedTx.ComputeSignatureHashes()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error while computing transaction's sig hashes: [%v]",
+			err,
+		)
+	}
+
+	signatures, err := signOffline(
+		ctx,
+		hostChain.Signing(),
+		signers,
+		sigHashes,
+		sessionID,
+		excludedMembersIndexes,
+		broadcastChannel,
+		groupParameters,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]*bitcoin.SignatureContainer, len(signatures))
+	for i, signature := range signatures {
+		containers[i] = &bitcoin.SignatureContainer{
+			R:         signature.R,
+			S:         signature.S,
+			PublicKey: walletPublicKey,
+		}
+	}
+
+	tx, err := unsignedTx.AddSignatures(containers)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error while applying transaction's signatures: [%v]",
+			err,
+		)
+	}
+
+	return tx, nil
+}
+
+// assembleProposalTransaction validates the given proposal against the host
+// and Bitcoin chains and assembles the unsigned transaction the proposal
+// describes. The steps mirror the ones done by the wallet action executing
+// the given proposal.
+func assembleProposalTransaction(
+	hostChain Chain,
+	btcChain bitcoin.Chain,
+	walletPublicKey *ecdsa.PublicKey,
+	proposal CoordinationProposal,
+) (*bitcoin.TransactionBuilder, error) {
+	walletPublicKeyHash := bitcoin.PublicKeyHash(walletPublicKey)
+
+	validateProposalLogger := logger.With(
+		zap.String("wallet", fmt.Sprintf("0x%x", walletPublicKeyHash)),
+		zap.String("action", proposal.ActionType().String()),
+		zap.String("step", "validateProposal"),
+	)
+
+	walletMainUtxo, err := DetermineWalletMainUtxo(
+		walletPublicKeyHash,
+		hostChain,
+		btcChain,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error while determining wallet's main UTXO: [%v]",
+			err,
+		)
+	}
+
+	switch p := proposal.(type) {
+	case *DepositSweepProposal:
+		validatedDeposits, err := ValidateDepositSweepProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			p,
+			DepositSweepRequiredFundingTxConfirmations,
+			hostChain,
+			btcChain,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("validate proposal step failed: [%v]", err)
+		}
+
+		if err := EnsureWalletSyncedBetweenChains(
+			walletPublicKeyHash,
+			walletMainUtxo,
+			hostChain,
+			btcChain,
+		); err != nil {
+			return nil, fmt.Errorf(
+				"error while ensuring wallet state is synced between "+
+					"BTC and host chain: [%v]",
+				err,
+			)
+		}
+
+		return assembleDepositSweepTransaction(
+			btcChain,
+			walletPublicKey,
+			walletMainUtxo,
+			validatedDeposits,
+			p.SweepTxFee.Int64(),
+		)
+
+	case *RedemptionProposal:
+		validatedRequests, err := ValidateRedemptionProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			p,
+			hostChain,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("validate proposal step failed: [%v]", err)
+		}
+
+		if walletMainUtxo == nil {
+			return nil, fmt.Errorf("redeeming wallet has no main UTXO")
+		}
+
+		if err := EnsureWalletSyncedBetweenChains(
+			walletPublicKeyHash,
+			walletMainUtxo,
+			hostChain,
+			btcChain,
+		); err != nil {
+			return nil, fmt.Errorf(
+				"error while ensuring wallet state is synced between "+
+					"BTC and host chain: [%v]",
+				err,
+			)
+		}
+
+		feeDistribution := withRedemptionTotalFee(p.RedemptionTxFee.Int64())
+		if len(p.RedemptionTxFeeShares) > 0 {
+			feeDistribution = withRedemptionFeeShares(p.RedemptionTxFeeShares)
+		}
+
+		return assembleRedemptionTransaction(
+			btcChain,
+			walletPublicKey,
+			walletMainUtxo,
+			validatedRequests,
+			feeDistribution,
+			p.TransactionShape,
+		)
+
+	case *MovingFundsProposal:
+		if walletMainUtxo == nil {
+			return nil, fmt.Errorf("moving funds wallet has no main UTXO")
+		}
+
+		if err := ValidateMovingFundsProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			walletMainUtxo,
+			p,
+			hostChain,
+		); err != nil {
+			return nil, fmt.Errorf("validate proposal step failed: [%v]", err)
+		}
+
+		if err := EnsureWalletSyncedBetweenChains(
+			walletPublicKeyHash,
+			walletMainUtxo,
+			hostChain,
+			btcChain,
+		); err != nil {
+			return nil, fmt.Errorf(
+				"error while ensuring wallet state is synced between "+
+					"BTC and host chain: [%v]",
+				err,
+			)
+		}
+
+		return assembleMovingFundsTransaction(
+			btcChain,
+			walletMainUtxo,
+			p.TargetWallets,
+			p.MovingFundsTxFee.Int64(),
+		)
+
+	case *MovedFundsSweepProposal:
+		if err := ValidateMovedFundsSweepProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			p,
+			hostChain,
+		); err != nil {
+			return nil, fmt.Errorf("validate proposal step failed: [%v]", err)
+		}
+
+		movedFundsUtxo, err := assembleMovedFundsSweepUtxo(
+			btcChain,
+			p.MovingFundsTxHash,
+			p.MovingFundsTxOutputIndex,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"error while assembling moved funds sweep UTXO: [%v]",
+				err,
+			)
+		}
+
+		if err := EnsureWalletSyncedBetweenChains(
+			walletPublicKeyHash,
+			walletMainUtxo,
+			hostChain,
+			btcChain,
+		); err != nil {
+			return nil, fmt.Errorf(
+				"error while ensuring wallet state is synced between "+
+					"BTC and host chain: [%v]",
+				err,
+			)
+		}
+
+		return assembleMovedFundsSweepTransaction(
+			btcChain,
+			walletPublicKey,
+			movedFundsUtxo,
+			walletMainUtxo,
+			p.SweepTxFee.Int64(),
+		)
+
+	default:
+		return nil, fmt.Errorf(
+			"[%v] proposals cannot be signed offline",
+			proposal.ActionType(),
+		)
+	}
+}
+
+// loadOfflineSigners loads signers of the wallet with the given public key
+// hash from the given key store persistence.
+func loadOfflineSigners(
+	keyStorePersistence persistence.ProtectedHandle,
+	walletPublicKeyHash [20]byte,
+) ([]*signer, error) {
+	signersByWallet, _, err := newWalletStorage(keyStorePersistence).loadSigners()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load signers: [%w]", err)
+	}
+
+	for _, walletSigners := range signersByWallet {
+		if bitcoin.PublicKeyHash(walletSigners[0].wallet.publicKey) ==
+			walletPublicKeyHash {
+			return walletSigners, nil
+		}
+	}
+
+	return nil, fmt.Errorf(
+		"no signers of wallet [0x%x] found in the key store",
+		walletPublicKeyHash,
+	)
+}
+
+// signOffline signs all the given messages with the given wallet signers.
+// Each message is signed in a separate signing session whose ID is the given
+// session ID suffixed with the message index. All sessions run concurrently
+// so members exchange messages of all sessions at once. Returned signatures
+// are in the same order as the messages.
+func signOffline(
+	ctx context.Context,
+	operatorSigning chain.Signing,
+	signers []*signer,
+	messages []*big.Int,
+	sessionID string,
+	excludedMembersIndexes []group.MemberIndex,
+	broadcastChannel net.BroadcastChannel,
+	groupParameters *GroupParameters,
+) ([]*tecdsa.Signature, error) {
+	wallet := signers[0].wallet
+	walletPublicKeyHash := bitcoin.PublicKeyHash(wallet.publicKey)
+
+	excluded := make(map[group.MemberIndex]bool)
+	for _, excludedMemberIndex := range excludedMembersIndexes {
+		if excludedMemberIndex < 1 ||
+			int(excludedMemberIndex) > wallet.groupSize() {
+			return nil, fmt.Errorf(
+				"excluded member index [%v] is out of range",
+				excludedMemberIndex,
+			)
+		}
+
+		excluded[excludedMemberIndex] = true
+	}
+
+	if participants := wallet.groupSize() - len(excluded); participants <
+		groupParameters.HonestThreshold {
+		return nil, fmt.Errorf(
+			"[%v] participating members are fewer than the honest "+
+				"threshold [%v]",
+			participants,
+			groupParameters.HonestThreshold,
+		)
+	}
+
+	walletLogger := logger.With(
+		zap.String("wallet", fmt.Sprintf("0x%x", walletPublicKeyHash)),
+		zap.String("sessionID", sessionID),
+	)
+
+	membershipValidator := group.NewMembershipValidator(
+		walletLogger,
+		wallet.signingGroupOperators,
+		operatorSigning,
+	)
+
+	signing.RegisterUnmarshallers(broadcastChannel)
+
+	err := broadcastChannel.SetFilter(membershipValidator.IsInGroup)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not set filter for channel [%v]: [%v]",
+			broadcastChannel.Name(),
+			err,
+		)
+	}
+
+	wg := sync.WaitGroup{}
+	signaturesMutex := sync.Mutex{}
+	signatures := make([]*tecdsa.Signature, len(messages))
+
+	for i, message := range messages {
+		messageSessionID := fmt.Sprintf("%s-%d", sessionID, i)
+
+		signingLogger := walletLogger.With(
+			zap.String("signedMessage", fmt.Sprintf("0x%x", message)),
+			zap.String("messageSessionID", messageSessionID),
+		)
+
+		for _, currentSigner := range signers {
+			if excluded[currentSigner.signingGroupMemberIndex] {
+				signingLogger.Infof(
+					"[member:%v] member is excluded from offline signing",
+					currentSigner.signingGroupMemberIndex,
+				)
+				continue
+			}
+
+			wg.Add(1)
+
+			go func(index int, message *big.Int, signer *signer) {
+				defer wg.Done()
+
+				signingLogger.Infof(
+					"[member:%v] starting offline signing protocol "+
+						"with [%v] group members (excluded: [%v])",
+					signer.signingGroupMemberIndex,
+					wallet.groupSize()-len(excluded),
+					excludedMembersIndexes,
+				)
+
+				result, err := signing.Execute(
+					ctx,
+					signingLogger,
+					message,
+					messageSessionID,
+					signer.signingGroupMemberIndex,
+					signer.privateKeyShare,
+					wallet.groupSize(),
+					wallet.groupDishonestThreshold(
+						groupParameters.HonestThreshold,
+					),
+					excludedMembersIndexes,
+					broadcastChannel,
+					membershipValidator,
+				)
+				if err != nil {
+					signingLogger.Errorf(
+						"[member:%v] offline signing failed: [%v]",
+						signer.signingGroupMemberIndex,
+						err,
+					)
+					return
+				}
+
+				signingLogger.Infof(
+					"[member:%v] generated signature [%v]",
+					signer.signingGroupMemberIndex,
+					result.Signature,
+				)
+
+				// All signers participating in the same session produce
+				// the same signature so, keep any of them.
+				signaturesMutex.Lock()
+				signatures[index] = result.Signature
+				signaturesMutex.Unlock()
+			}(i, message, currentSigner)
+		}
+	}
+
+	wg.Wait()
+
+	for i, signature := range signatures {
+		if signature == nil {
+			return nil, fmt.Errorf(
+				"all signers failed to sign message [%v]",
+				i,
+			)
+		}
+	}
+
+	return signatures, nil
+}
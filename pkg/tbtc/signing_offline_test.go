@@ -0,0 +1,338 @@
+package tbtc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/chain/local_v1"
+	"github.com/keep-network/keep-core/pkg/internal/tecdsatest"
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/net/offline"
+	"github.com/keep-network/keep-core/pkg/operator"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+)
+
+func TestSignOffline(t *testing.T) {
+	groupParameters := &GroupParameters{
+		GroupSize:       5,
+		GroupQuorum:     4,
+		HonestThreshold: 3,
+	}
+
+	// The first operator controls members 1-3, the second one controls
+	// members 4-5.
+	operatorsMembers := [][]int{{1, 2, 3}, {4, 5}}
+
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(
+		groupParameters.GroupSize,
+	)
+	if err != nil {
+		t.Fatalf("failed to load test data: [%v]", err)
+	}
+
+	operatorsSigning := make([]chain.Signing, len(operatorsMembers))
+	operators := make([]chain.Address, groupParameters.GroupSize)
+	for i, members := range operatorsMembers {
+		operatorPrivateKey, _, err := operator.GenerateKeyPair(
+			local_v1.DefaultCurve,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		operatorsSigning[i] = local_v1.NewSigner(operatorPrivateKey)
+
+		for _, member := range members {
+			operators[member-1] = operatorsSigning[i].Address()
+		}
+	}
+
+	tmpDir := t.TempDir()
+	exportDirs := []string{
+		filepath.Join(tmpDir, "operator-1"),
+		filepath.Join(tmpDir, "operator-2"),
+	}
+
+	messages := []*big.Int{big.NewInt(100), big.NewInt(200)}
+	var walletPublicKey *ecdsa.PublicKey
+
+	type outcome struct {
+		signatures []*tecdsa.Signature
+		err        error
+	}
+	outcomes := make(chan outcome, len(operatorsMembers))
+
+	// The offline signing has no wall-clock limit on its own. A hanging
+	// signing is caught by the test binary timeout which, unlike a short
+	// context timeout, does not fail the test when it runs slowly along
+	// with the whole package.
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	for i, members := range operatorsMembers {
+		var signers []*signer
+		for _, member := range members {
+			privateKeyShare := tecdsa.NewPrivateKeyShare(testData[member-1])
+
+			signers = append(signers, &signer{
+				wallet: wallet{
+					publicKey:             privateKeyShare.PublicKey(),
+					signingGroupOperators: operators,
+				},
+				signingGroupMemberIndex: group.MemberIndex(member),
+				privateKeyShare:         privateKeyShare,
+			})
+		}
+
+		walletPublicKey = signers[0].wallet.publicKey
+		walletPublicKeyHash := bitcoin.PublicKeyHash(walletPublicKey)
+
+		// Operators exchange messages by importing each other's export
+		// directory.
+		channel, err := offline.NewBroadcastChannel(
+			ctx,
+			OfflineSigningChannelName(walletPublicKeyHash),
+			operatorsSigning[i],
+			exportDirs[i],
+			exportDirs[(i+1)%len(exportDirs)],
+			50*time.Millisecond,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		go func(
+			operatorSigning chain.Signing,
+			signers []*signer,
+			channel net.BroadcastChannel,
+		) {
+			signatures, err := signOffline(
+				ctx,
+				operatorSigning,
+				signers,
+				messages,
+				"recovery-1",
+				[]group.MemberIndex{5},
+				channel,
+				groupParameters,
+			)
+			outcomes <- outcome{signatures, err}
+		}(operatorsSigning[i], signers, channel)
+	}
+
+	for range operatorsMembers {
+		outcome := <-outcomes
+		if outcome.err != nil {
+			t.Fatal(outcome.err)
+		}
+
+		testutils.AssertIntsEqual(
+			t,
+			"signatures count",
+			len(messages),
+			len(outcome.signatures),
+		)
+
+		for i, signature := range outcome.signatures {
+			if !ecdsa.Verify(
+				walletPublicKey,
+				messages[i].Bytes(),
+				signature.R,
+				signature.S,
+			) {
+				t.Errorf("invalid signature [%v]: [%+v]", i, signature)
+			}
+		}
+	}
+}
+
+func TestSignOffline_Errors(t *testing.T) {
+	groupParameters := &GroupParameters{
+		GroupSize:       5,
+		GroupQuorum:     4,
+		HonestThreshold: 3,
+	}
+
+	operatorPrivateKey, _, err := operator.GenerateKeyPair(
+		local_v1.DefaultCurve,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operatorSigning := local_v1.NewSigner(operatorPrivateKey)
+
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(
+		groupParameters.GroupSize,
+	)
+	if err != nil {
+		t.Fatalf("failed to load test data: [%v]", err)
+	}
+
+	operators := make([]chain.Address, groupParameters.GroupSize)
+	for i := range operators {
+		operators[i] = operatorSigning.Address()
+	}
+
+	privateKeyShare := tecdsa.NewPrivateKeyShare(testData[0])
+	signers := []*signer{
+		{
+			wallet: wallet{
+				publicKey:             privateKeyShare.PublicKey(),
+				signingGroupOperators: operators,
+			},
+			signingGroupMemberIndex: 1,
+			privateKeyShare:         privateKeyShare,
+		},
+	}
+
+	var tests = map[string]struct {
+		excludedMembersIndexes []group.MemberIndex
+		expectedErr            string
+	}{
+		"excluded member out of range": {
+			excludedMembersIndexes: []group.MemberIndex{6},
+			expectedErr:            "excluded member index [6] is out of range",
+		},
+		"too many excluded members": {
+			excludedMembersIndexes: []group.MemberIndex{1, 2, 3},
+			expectedErr: "[2] participating members are fewer than " +
+				"the honest threshold [3]",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := signOffline(
+				context.Background(),
+				operatorSigning,
+				signers,
+				[]*big.Int{big.NewInt(100)},
+				"recovery-1",
+				test.excludedMembersIndexes,
+				nil,
+				groupParameters,
+			)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"error",
+				test.expectedErr,
+				err.Error(),
+			)
+		})
+	}
+}
+
+func TestLoadOfflineSigners(t *testing.T) {
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(1)
+	if err != nil {
+		t.Fatalf("failed to load test data: [%v]", err)
+	}
+
+	privateKeyShare := tecdsa.NewPrivateKeyShare(testData[0])
+	keyStorePersistence := createMockKeyStorePersistence(t, &signer{
+		wallet: wallet{
+			publicKey:             privateKeyShare.PublicKey(),
+			signingGroupOperators: []chain.Address{"operator-1"},
+		},
+		signingGroupMemberIndex: 1,
+		privateKeyShare:         privateKeyShare,
+	})
+
+	walletPublicKeyHash := bitcoin.PublicKeyHash(privateKeyShare.PublicKey())
+
+	signers, err := loadOfflineSigners(keyStorePersistence, walletPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "signers count", 1, len(signers))
+
+	_, err = loadOfflineSigners(keyStorePersistence, [20]byte{0x01})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"no signers of wallet [0x0100000000000000000000000000000000000000] "+
+			"found in the key store",
+		err.Error(),
+	)
+}
+
+func TestAssembleProposalTransaction_Errors(t *testing.T) {
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(1)
+	if err != nil {
+		t.Fatalf("failed to load test data: [%v]", err)
+	}
+
+	walletPublicKey := tecdsa.NewPrivateKeyShare(testData[0]).PublicKey()
+	walletPublicKeyHash := bitcoin.PublicKeyHash(walletPublicKey)
+
+	var tests = map[string]struct {
+		registerWallet bool
+		proposal       CoordinationProposal
+		expectedErr    string
+	}{
+		"unknown wallet": {
+			registerWallet: false,
+			proposal:       &HeartbeatProposal{},
+			expectedErr: "error while determining wallet's main UTXO: " +
+				"[cannot get on-chain data for wallet: " +
+				"[no wallet for given PKH]]",
+		},
+		"unsupported proposal": {
+			registerWallet: true,
+			proposal:       &HeartbeatProposal{},
+			expectedErr:    "[Heartbeat] proposals cannot be signed offline",
+		},
+		"moving funds without main UTXO": {
+			registerWallet: true,
+			proposal: &MovingFundsProposal{
+				TargetWallets:    [][20]byte{{0x02}},
+				MovingFundsTxFee: big.NewInt(1000),
+			},
+			expectedErr: "moving funds wallet has no main UTXO",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			hostChain := Connect()
+			if test.registerWallet {
+				hostChain.setWallet(walletPublicKeyHash, &WalletChainData{})
+			}
+
+			_, err := assembleProposalTransaction(
+				hostChain,
+				newLocalBitcoinChain(),
+				walletPublicKey,
+				test.proposal,
+			)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			testutils.AssertStringsEqual(
+				t,
+				"error",
+				test.expectedErr,
+				err.Error(),
+			)
+		})
+	}
+}
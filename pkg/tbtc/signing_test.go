@@ -88,11 +88,22 @@ func TestSigningExecutor_SignBatch(t *testing.T) {
 	}
 	startBlock := uint64(0)
 
-	signatures, err := executor.signBatch(ctx, messages, startBlock)
+	signatures, activityReports, err := executor.signBatch(
+		ctx,
+		messages,
+		startBlock,
+	)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	testutils.AssertIntsEqual(
+		t,
+		"activity reports count",
+		len(messages),
+		len(activityReports),
+	)
+
 	walletPublicKey := executor.wallet().publicKey
 
 	for i, signature := range signatures {
@@ -183,6 +194,7 @@ func setupSigningExecutor(t *testing.T) *signingExecutor {
 		localProvider,
 		keyStorePersistence,
 		&mockPersistenceHandle{},
+		nil,
 		generator.StartScheduler(),
 		&mockCoordinationProposalGenerator{},
 		Config{},
@@ -49,6 +49,8 @@ const (
 	DefaultPreParamsGenerationTimeout     = 2 * time.Minute
 	DefaultPreParamsGenerationDelay       = 10 * time.Second
 	DefaultPreParamsGenerationConcurrency = 1
+	DefaultWalletActionAuditSegmentSize   = 1024 * 1024
+	DefaultWalletActionAuditMaxSegments   = 10
 )
 
 var DefaultKeyGenerationConcurrency = runtime.GOMAXPROCS(0)
@@ -65,6 +67,26 @@ type Config struct {
 	PreParamsGenerationConcurrency int
 	// Concurrency level for key-generation for tECDSA.
 	KeyGenerationConcurrency int
+	// URL of the webhook receiving wallet health alerts as JSON-encoded
+	// HTTP POST requests. Empty disables the alerts.
+	WalletHealthWebhookURL string
+	// Size in bytes above which a new segment of the wallet action audit
+	// log is started. Segments are encrypted as a whole so the current
+	// segment is saved again upon each appended entry.
+	WalletActionAuditSegmentSize int
+	// Number of the most recent wallet action audit log segments that are
+	// kept. Older segments are deleted.
+	WalletActionAuditMaxSegments int
+}
+
+// defaultGroupParameters returns the parameters of the wallet signing groups
+// created on the chain.
+func defaultGroupParameters() *GroupParameters {
+	return &GroupParameters{
+		GroupSize:       100,
+		GroupQuorum:     90,
+		HonestThreshold: 51,
+	}
 }
 
 // Initialize kicks off the TBTC by initializing internal state, ensuring
@@ -77,16 +99,13 @@ func Initialize(
 	netProvider net.Provider,
 	keyStorePersistence persistence.ProtectedHandle,
 	workPersistence persistence.BasicHandle,
+	walletActionAuditPersistence persistence.BasicHandle,
 	scheduler *generator.Scheduler,
 	proposalGenerator CoordinationProposalGenerator,
 	config Config,
 	clientInfo *clientinfo.Registry,
 ) error {
-	groupParameters := &GroupParameters{
-		GroupSize:       100,
-		GroupQuorum:     90,
-		HonestThreshold: 51,
-	}
+	groupParameters := defaultGroupParameters()
 
 	node, err := newNode(
 		groupParameters,
@@ -95,6 +114,7 @@ func Initialize(
 		netProvider,
 		keyStorePersistence,
 		workPersistence,
+		walletActionAuditPersistence,
 		scheduler,
 		proposalGenerator,
 		config,
@@ -110,6 +130,17 @@ func Initialize(
 
 	deduplicator := newDeduplicator()
 
+	walletHealthMonitor := newWalletHealthMonitor(
+		chain,
+		btcChain,
+		node.walletRegistry,
+		node.walletTransactionTracker,
+		node.heartbeatFailureCounter,
+		newWalletHealthAlerter(config.WalletHealthWebhookURL),
+	)
+
+	go walletHealthMonitor.run(ctx)
+
 	if clientInfo != nil {
 		// only if client info endpoint is configured
 		clientInfo.ObserveApplicationSource(
@@ -120,6 +151,24 @@ func Initialize(
 				},
 			},
 		)
+
+		clientInfo.ObserveApplicationSource(
+			"tbtc",
+			walletHealthMonitor.metricsSources(),
+		)
+
+		// Diagnostics sources are registered per application so all
+		// tbtc diagnostics must be exposed by a single source.
+		clientInfo.RegisterApplicationSource(
+			"tbtc",
+			func() clientinfo.ApplicationInfo {
+				info := node.coordinationFaultLedger.diagnostics()
+				for key, value := range walletHealthMonitor.diagnostics() {
+					info[key] = value
+				}
+				return info
+			},
+		)
 	}
 
 	err = sortition.MonitorPool(
@@ -0,0 +1,304 @@
+package tbtc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+const (
+	// walletTransactionsDirectory is the name of the work persistence
+	// directory holding wallet transactions tracked by the node.
+	walletTransactionsDirectory = "wallet_transactions"
+	// walletTransactionTrackingPeriod determines how long a broadcast
+	// wallet transaction is tracked. Transactions that are not confirmed
+	// within this period are most likely evicted from mempools and there
+	// is no point to keep them any longer.
+	walletTransactionTrackingPeriod = 14 * 24 * time.Hour
+)
+
+// TrackedTransaction represents a wallet transaction broadcast by the node.
+type TrackedTransaction struct {
+	// Transaction is the signed wallet transaction.
+	Transaction *bitcoin.Transaction
+	// ActionType is the type of the wallet action that produced the
+	// transaction. If the transaction replaced another one, this is the
+	// action type of the replaced transaction.
+	ActionType WalletActionType
+	// BroadcastAt is the time the transaction was broadcast at.
+	BroadcastAt time.Time
+}
+
+// trackedTransactionRecord is the persisted form of TrackedTransaction.
+type trackedTransactionRecord struct {
+	Transaction string           `json:"transaction"`
+	ActionType  WalletActionType `json:"actionType"`
+	BroadcastAt time.Time        `json:"broadcastAt"`
+}
+
+// walletTransactionTracker keeps track of wallet transactions broadcast by
+// the node. Tracked transactions are used by the wallet health monitor to
+// detect transactions that are not proven in the Bridge for too long. All
+// functions of the tracker are safe for concurrent use.
+type walletTransactionTracker struct {
+	mutex sync.Mutex
+
+	persistence persistence.BasicHandle
+
+	// transactions holds tracked transactions of specific wallets. The map
+	// key is the hex-encoded 20-byte public key hash of the wallet.
+	transactions map[string][]*TrackedTransaction
+}
+
+// newWalletTransactionTracker creates a new wallet transaction tracker and
+// loads the transactions kept in the given persistence.
+func newWalletTransactionTracker(
+	persistence persistence.BasicHandle,
+) *walletTransactionTracker {
+	wtt := &walletTransactionTracker{
+		persistence:  persistence,
+		transactions: make(map[string][]*TrackedTransaction),
+	}
+
+	wtt.load()
+
+	return wtt
+}
+
+// load reads all tracked transactions from the persistence.
+func (wtt *walletTransactionTracker) load() {
+	descriptorsChan, errorsChan := wtt.persistence.ReadAll()
+
+	// Two goroutines read from descriptors and errors channels and either
+	// add the transactions to the tracker or log an error. The reason for
+	// using two goroutines at the same time - one for descriptors and one for
+	// errors - is that channels do not have to be buffered, and we do not
+	// know in what order the information is written to channels.
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for descriptor := range descriptorsChan {
+			if descriptor.Directory() != walletTransactionsDirectory {
+				continue
+			}
+
+			content, err := descriptor.Content()
+			if err != nil {
+				logger.Errorf(
+					"cannot read wallet transactions from file [%s]: [%v]",
+					descriptor.Name(),
+					err,
+				)
+				continue
+			}
+
+			transactions, err := unmarshalTrackedTransactions(content)
+			if err != nil {
+				logger.Errorf(
+					"cannot unmarshal wallet transactions from file [%s]: [%v]",
+					descriptor.Name(),
+					err,
+				)
+				continue
+			}
+
+			wtt.transactions[descriptor.Name()] = transactions
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for err := range errorsChan {
+			logger.Errorf(
+				"cannot load wallet transactions from disk: [%v]",
+				err,
+			)
+		}
+	}()
+
+	wg.Wait()
+}
+
+// track starts tracking the given wallet transaction. Tracked transactions
+// spending any of the outpoints spent by the given transaction are considered
+// replaced and are no longer tracked. Transactions older than the tracking
+// period are pruned.
+func (wtt *walletTransactionTracker) track(
+	walletPublicKeyHash [20]byte,
+	actionType WalletActionType,
+	transaction *bitcoin.Transaction,
+	broadcastAt time.Time,
+) error {
+	wtt.mutex.Lock()
+	defer wtt.mutex.Unlock()
+
+	key := hex.EncodeToString(walletPublicKeyHash[:])
+
+	spentOutpoints := make(map[bitcoin.TransactionOutpoint]bool)
+	for _, input := range transaction.Inputs {
+		spentOutpoints[*input.Outpoint] = true
+	}
+
+	transactions := []*TrackedTransaction{
+		{
+			Transaction: transaction,
+			ActionType:  actionType,
+			BroadcastAt: broadcastAt,
+		},
+	}
+
+	for _, tracked := range wtt.transactions[key] {
+		if broadcastAt.Sub(tracked.BroadcastAt) > walletTransactionTrackingPeriod {
+			continue
+		}
+
+		conflicting := false
+		for _, input := range tracked.Transaction.Inputs {
+			if spentOutpoints[*input.Outpoint] {
+				conflicting = true
+				break
+			}
+		}
+
+		if !conflicting {
+			transactions = append(transactions, tracked)
+		}
+	}
+
+	return wtt.save(key, transactions)
+}
+
+// transaction returns the tracked wallet transaction with the given hash.
+// The returned boolean flag indicates whether the transaction is tracked.
+func (wtt *walletTransactionTracker) transaction(
+	walletPublicKeyHash [20]byte,
+	transactionHash bitcoin.Hash,
+) (*TrackedTransaction, bool) {
+	wtt.mutex.Lock()
+	defer wtt.mutex.Unlock()
+
+	key := hex.EncodeToString(walletPublicKeyHash[:])
+
+	for _, tracked := range wtt.transactions[key] {
+		if tracked.Transaction.Hash() == transactionHash {
+			return tracked, true
+		}
+	}
+
+	return nil, false
+}
+
+// spendingTransaction returns the tracked wallet transaction spending any
+// output of the transaction with the given hash. The returned boolean flag
+// indicates whether such a transaction is tracked.
+func (wtt *walletTransactionTracker) spendingTransaction(
+	walletPublicKeyHash [20]byte,
+	transactionHash bitcoin.Hash,
+) (*TrackedTransaction, bool) {
+	wtt.mutex.Lock()
+	defer wtt.mutex.Unlock()
+
+	key := hex.EncodeToString(walletPublicKeyHash[:])
+
+	for _, tracked := range wtt.transactions[key] {
+		for _, input := range tracked.Transaction.Inputs {
+			if input.Outpoint.TransactionHash == transactionHash {
+				return tracked, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// walletTransactions returns all tracked transactions of the given wallet,
+// sorted by the broadcast time in the ascending order.
+func (wtt *walletTransactionTracker) walletTransactions(
+	walletPublicKeyHash [20]byte,
+) []*TrackedTransaction {
+	wtt.mutex.Lock()
+	defer wtt.mutex.Unlock()
+
+	key := hex.EncodeToString(walletPublicKeyHash[:])
+
+	transactions := make([]*TrackedTransaction, len(wtt.transactions[key]))
+	copy(transactions, wtt.transactions[key])
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].BroadcastAt.Before(transactions[j].BroadcastAt)
+	})
+
+	return transactions
+}
+
+// save stores the given transactions of the given wallet both in memory and
+// the persistence. Must be called with the tracker's mutex held.
+func (wtt *walletTransactionTracker) save(
+	key string,
+	transactions []*TrackedTransaction,
+) error {
+	wtt.transactions[key] = transactions
+
+	records := make([]*trackedTransactionRecord, len(transactions))
+	for i, tracked := range transactions {
+		records[i] = &trackedTransactionRecord{
+			Transaction: hex.EncodeToString(tracked.Transaction.Serialize()),
+			ActionType:  tracked.ActionType,
+			BroadcastAt: tracked.BroadcastAt,
+		}
+	}
+
+	content, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("cannot marshal wallet transactions: [%w]", err)
+	}
+
+	if err := wtt.persistence.Save(
+		content,
+		walletTransactionsDirectory,
+		key,
+	); err != nil {
+		return fmt.Errorf("cannot save wallet transactions: [%w]", err)
+	}
+
+	return nil
+}
+
+func unmarshalTrackedTransactions(content []byte) ([]*TrackedTransaction, error) {
+	var records []*trackedTransactionRecord
+	if err := json.Unmarshal(content, &records); err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*TrackedTransaction, len(records))
+	for i, record := range records {
+		transactionBytes, err := hex.DecodeString(record.Transaction)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode transaction: [%v]", err)
+		}
+
+		transaction := new(bitcoin.Transaction)
+		if err := transaction.Deserialize(transactionBytes); err != nil {
+			return nil, fmt.Errorf("cannot deserialize transaction: [%v]", err)
+		}
+
+		transactions[i] = &TrackedTransaction{
+			Transaction: transaction,
+			ActionType:  record.ActionType,
+			BroadcastAt: record.BroadcastAt,
+		}
+	}
+
+	return transactions, nil
+}
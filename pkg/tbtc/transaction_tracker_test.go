@@ -0,0 +1,131 @@
+package tbtc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+func TestWalletTransactionTracker(t *testing.T) {
+	persistenceHandle := &mockPersistenceHandle{}
+	tracker := newWalletTransactionTracker(persistenceHandle)
+
+	walletPublicKeyHash := [20]byte{1}
+
+	newTransaction := func(spentHash byte, outputValue int64) *bitcoin.Transaction {
+		return &bitcoin.Transaction{
+			Version: 1,
+			Inputs: []*bitcoin.TransactionInput{
+				{
+					Outpoint: &bitcoin.TransactionOutpoint{
+						TransactionHash: bitcoin.Hash{spentHash},
+						OutputIndex:     0,
+					},
+					Sequence: 0xffffffff,
+				},
+			},
+			Outputs: []*bitcoin.TransactionOutput{
+				{
+					Value:           outputValue,
+					PublicKeyScript: []byte{0x00, 0x14},
+				},
+			},
+		}
+	}
+
+	broadcastAt := time.Unix(1700000000, 0).UTC()
+
+	sweepTx := newTransaction(1, 1000)
+	redemptionTx := newTransaction(2, 2000)
+	// Spends the same outpoint as sweepTx.
+	replacementTx := newTransaction(1, 900)
+
+	err := tracker.track(walletPublicKeyHash, ActionRedemption, redemptionTx, broadcastAt.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tracker.track(walletPublicKeyHash, ActionDepositSweep, sweepTx, broadcastAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions := tracker.walletTransactions(walletPublicKeyHash)
+	testutils.AssertIntsEqual(t, "transactions count", 2, len(transactions))
+	// Transactions should be sorted by the broadcast time.
+	testutils.AssertStringsEqual(
+		t,
+		"first transaction",
+		sweepTx.Hash().String(),
+		transactions[0].Transaction.Hash().String(),
+	)
+
+	err = tracker.track(walletPublicKeyHash, ActionDepositSweep, replacementTx, broadcastAt.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := tracker.transaction(walletPublicKeyHash, sweepTx.Hash()); ok {
+		t.Errorf("replaced transaction should not be tracked")
+	}
+
+	tracked, ok := tracker.transaction(walletPublicKeyHash, replacementTx.Hash())
+	if !ok {
+		t.Fatal("replacement transaction should be tracked")
+	}
+	testutils.AssertStringsEqual(
+		t,
+		"action type",
+		ActionDepositSweep.String(),
+		tracked.ActionType.String(),
+	)
+
+	// A fresh tracker should load the same state from the persistence.
+	loadedTracker := newWalletTransactionTracker(persistenceHandle)
+	summarize := func(transactions []*TrackedTransaction) []string {
+		summary := make([]string, len(transactions))
+		for i, tracked := range transactions {
+			summary[i] = fmt.Sprintf(
+				"%s:%s:%d",
+				tracked.Transaction.Hash(),
+				tracked.ActionType,
+				tracked.BroadcastAt.Unix(),
+			)
+		}
+		return summary
+	}
+	expectedTransactions := summarize(tracker.walletTransactions(walletPublicKeyHash))
+	loadedTransactions := summarize(loadedTracker.walletTransactions(walletPublicKeyHash))
+	if !reflect.DeepEqual(expectedTransactions, loadedTransactions) {
+		t.Errorf(
+			"unexpected loaded transactions\nexpected: %v\nactual:   %v",
+			expectedTransactions,
+			loadedTransactions,
+		)
+	}
+
+	// Tracking a transaction after the tracking period prunes old ones.
+	err = tracker.track(
+		walletPublicKeyHash,
+		ActionRedemption,
+		redemptionTx,
+		broadcastAt.Add(walletTransactionTrackingPeriod+3*time.Hour),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The replacement transaction was broadcast more than the tracking
+	// period ago so only the redemption transaction should remain.
+	transactions = tracker.walletTransactions(walletPublicKeyHash)
+	testutils.AssertIntsEqual(t, "transactions count", 1, len(transactions))
+	testutils.AssertStringsEqual(
+		t,
+		"remaining transaction",
+		redemptionTx.Hash().String(),
+		transactions[0].Transaction.Hash().String(),
+	)
+}
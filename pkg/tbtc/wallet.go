@@ -31,6 +31,9 @@ const (
 	ActionRedemption
 	ActionMovingFunds
 	ActionMovedFundsSweep
+	ActionRbf
+	ActionCpfp
+	ActionKeyShareRefresh
 )
 
 // ParseWalletActionType parses the given value into a WalletActionType.
@@ -48,6 +51,12 @@ func ParseWalletActionType(value uint8) (WalletActionType, error) {
 		return ActionMovingFunds, nil
 	case 5:
 		return ActionMovedFundsSweep, nil
+	case 6:
+		return ActionRbf, nil
+	case 7:
+		return ActionCpfp, nil
+	case 8:
+		return ActionKeyShareRefresh, nil
 	default:
 		return 0, fmt.Errorf("unknown wallet action type [%v]", value)
 	}
@@ -67,6 +76,12 @@ func (wat WalletActionType) String() string {
 		return "MovingFunds"
 	case ActionMovedFundsSweep:
 		return "MovedFundsSweep"
+	case ActionRbf:
+		return "Rbf"
+	case ActionCpfp:
+		return "Cpfp"
+	case ActionKeyShareRefresh:
+		return "KeyShareRefresh"
 	default:
 		panic("unknown wallet action type")
 	}
@@ -137,13 +152,20 @@ func newWalletDispatcher() *walletDispatcher {
 
 // dispatch sends the given walletAction for execution. If the wallet is
 // already busy, an errWalletBusy error is returned and the action is ignored.
-func (wd *walletDispatcher) dispatch(action walletAction) error {
+// The outcome of the action is recorded in the given audit. The audit can be
+// nil, in which case the action is not audited.
+func (wd *walletDispatcher) dispatch(
+	action walletAction,
+	audit *walletActionAudit,
+) error {
 	wd.actionsMutex.Lock()
 	defer wd.actionsMutex.Unlock()
 
 	walletPublicKeyBytes, err := marshalPublicKey(action.wallet().publicKey)
 	if err != nil {
-		return fmt.Errorf("cannot marshal wallet public key: [%v]", err)
+		err = fmt.Errorf("cannot marshal wallet public key: [%v]", err)
+		audit.reject(err)
+		return err
 	}
 
 	walletActionLogger := logger.With(
@@ -154,6 +176,7 @@ func (wd *walletDispatcher) dispatch(action walletAction) error {
 	key := hex.EncodeToString(walletPublicKeyBytes)
 
 	if _, ok := wd.actions[key]; ok {
+		audit.reject(errWalletBusy)
 		return errWalletBusy
 	}
 
@@ -169,6 +192,7 @@ func (wd *walletDispatcher) dispatch(action walletAction) error {
 		walletActionLogger.Infof("starting action execution")
 
 		err := action.execute()
+		audit.finish(err)
 		if err != nil {
 			walletActionLogger.Errorf(
 				"action execution terminated with error: [%v]",
@@ -190,7 +214,7 @@ type walletSigningExecutor interface {
 		ctx context.Context,
 		messages []*big.Int,
 		startBlock uint64,
-	) ([]*tecdsa.Signature, error)
+	) ([]*tecdsa.Signature, []*signingActivityReport, error)
 }
 
 // walletTransactionExecutor is a component allowing to sign and broadcast
@@ -201,20 +225,32 @@ type walletTransactionExecutor struct {
 	executingWallet wallet
 	signingExecutor walletSigningExecutor
 
+	// transactionTracker keeps track of broadcast wallet transactions.
+	// Can be nil, in which case broadcast transactions are not tracked.
+	transactionTracker *walletTransactionTracker
+
 	waitForBlockFn waitForBlockFn
+
+	// audit collects the audit trail of the action the executor is used by.
+	// Can be nil, in which case signing and broadcast are not audited.
+	audit *walletActionAudit
 }
 
 func newWalletTransactionExecutor(
 	btcChain bitcoin.Chain,
 	executingWallet wallet,
 	signingExecutor walletSigningExecutor,
+	transactionTracker *walletTransactionTracker,
 	waitForBlockFn waitForBlockFn,
+	audit *walletActionAudit,
 ) *walletTransactionExecutor {
 	return &walletTransactionExecutor{
-		btcChain:        btcChain,
-		executingWallet: executingWallet,
-		signingExecutor: signingExecutor,
-		waitForBlockFn:  waitForBlockFn,
+		btcChain:           btcChain,
+		executingWallet:    executingWallet,
+		signingExecutor:    signingExecutor,
+		transactionTracker: transactionTracker,
+		waitForBlockFn:     waitForBlockFn,
+		audit:              audit,
 	}
 }
 
@@ -246,11 +282,12 @@ func (wte *walletTransactionExecutor) signTransaction(
 	)
 	defer cancelSigningCtx()
 
-	signatures, err := wte.signingExecutor.signBatch(
+	signatures, activityReports, err := wte.signingExecutor.signBatch(
 		signingCtx,
 		sigHashes,
 		signingStartBlock,
 	)
+	wte.audit.recordSigning(sigHashes, activityReports, err)
 	if err != nil {
 		return nil, fmt.Errorf(
 			"error while signing transaction's sig hashes: [%v]",
@@ -279,18 +316,27 @@ func (wte *walletTransactionExecutor) signTransaction(
 
 	signTxLogger.Infof("transaction created successfully")
 
+	wte.audit.recordTransaction(tx.Hash())
+
 	return tx, nil
 }
 
 // broadcastTransaction broadcasts a signed Bitcoin transaction until
 // the transaction lands in the Bitcoin mempool or the provided timeout
-// is hit, whichever comes first.
+// is hit, whichever comes first. Once the transaction is known on the
+// Bitcoin chain, it is tracked as a transaction of the given action type
+// so it can be fee-bumped if it gets stuck in the mempool.
 func (wte *walletTransactionExecutor) broadcastTransaction(
 	broadcastTxLogger log.StandardLogger,
+	actionType WalletActionType,
 	tx *bitcoin.Transaction,
 	timeout time.Duration,
 	checkDelay time.Duration,
-) error {
+) (broadcastErr error) {
+	defer func() {
+		wte.audit.recordBroadcast(broadcastErr)
+	}()
+
 	txHash := tx.Hash()
 
 	broadcastCtx, cancelBroadcastCtx := context.WithTimeout(
@@ -351,6 +397,29 @@ func (wte *walletTransactionExecutor) broadcastTransaction(
 			}
 
 			broadcastTxLogger.Infof("transaction is known on Bitcoin chain")
+
+			if wte.transactionTracker != nil {
+				walletPublicKeyHash := bitcoin.PublicKeyHash(
+					wte.executingWallet.publicKey,
+				)
+
+				err := wte.transactionTracker.track(
+					walletPublicKeyHash,
+					actionType,
+					tx,
+					time.Now(),
+				)
+				if err != nil {
+					// Tracking is not critical for the broadcast itself;
+					// the only consequence is that the transaction cannot
+					// be fee-bumped by this node.
+					broadcastTxLogger.Warnf(
+						"cannot track the transaction: [%v]",
+						err,
+					)
+				}
+			}
+
 			return nil
 		}
 	}
@@ -0,0 +1,560 @@
+package tbtc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+)
+
+const (
+	// WalletActionAuditDirectory is the name of the work persistence
+	// directory holding segments of the wallet action audit log.
+	WalletActionAuditDirectory = "wallet_action_audit"
+
+	// walletActionAuditSegmentsDirectory is the directory of the audit log
+	// persistence holding the segments.
+	walletActionAuditSegmentsDirectory = "segments"
+
+	// walletActionAuditSegmentExtension is the file name extension of
+	// the audit log segments.
+	walletActionAuditSegmentExtension = ".jsonl"
+)
+
+// Possible outcomes of an audited wallet action.
+const (
+	WalletActionOutcomeSucceeded = "succeeded"
+	WalletActionOutcomeFailed    = "failed"
+	WalletActionOutcomeRejected  = "rejected"
+)
+
+// WalletActionAuditStep is the result of a single step of a wallet action.
+type WalletActionAuditStep struct {
+	Succeeded bool   `json:"succeeded"`
+	Error     string `json:"error,omitempty"`
+}
+
+func newWalletActionAuditStep(err error) *WalletActionAuditStep {
+	if err != nil {
+		return &WalletActionAuditStep{Succeeded: false, Error: err.Error()}
+	}
+
+	return &WalletActionAuditStep{Succeeded: true}
+}
+
+// WalletActionAuditSigning describes the successful signing attempt of
+// a single message signed during a wallet action.
+type WalletActionAuditSigning struct {
+	Message         string              `json:"message"`
+	AttemptNumber   uint                `json:"attemptNumber"`
+	ExcludedMembers []group.MemberIndex `json:"excludedMembers"`
+	InactiveMembers []group.MemberIndex `json:"inactiveMembers"`
+}
+
+// WalletActionAuditEntry is a single entry of the wallet action audit log.
+// Steps the action did not reach are left empty.
+type WalletActionAuditEntry struct {
+	Wallet            string          `json:"wallet"`
+	Action            string          `json:"action"`
+	Proposal          json.RawMessage `json:"proposal"`
+	CoordinationBlock uint64          `json:"coordinationBlock"`
+	Leader            string          `json:"leader"`
+	StartBlock        uint64          `json:"startBlock"`
+	ExpiryBlock       uint64          `json:"expiryBlock"`
+	DispatchedAt      time.Time       `json:"dispatchedAt"`
+	FinishedAt        time.Time       `json:"finishedAt"`
+
+	Validation      *WalletActionAuditStep      `json:"validation,omitempty"`
+	Signing         *WalletActionAuditStep      `json:"signing,omitempty"`
+	SigningAttempts []*WalletActionAuditSigning `json:"signingAttempts,omitempty"`
+	TransactionHash string                      `json:"transactionHash,omitempty"`
+	Broadcast       *WalletActionAuditStep      `json:"broadcast,omitempty"`
+
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// walletActionAudit collects the audit trail of a single wallet action and
+// appends it to the audit log once the action is finished. All functions
+// are safe for concurrent use and do nothing on a nil receiver so wallet
+// actions can run without being audited.
+type walletActionAudit struct {
+	mutex sync.Mutex
+
+	log   *walletActionAuditLog
+	entry *WalletActionAuditEntry
+}
+
+// recordValidation records the outcome of the proposal validation.
+func (waa *walletActionAudit) recordValidation(err error) {
+	if waa == nil {
+		return
+	}
+
+	waa.mutex.Lock()
+	defer waa.mutex.Unlock()
+
+	waa.entry.Validation = newWalletActionAuditStep(err)
+}
+
+// recordSigning records the outcome of signing the given messages along
+// with the activity reports of the successful signing attempts.
+func (waa *walletActionAudit) recordSigning(
+	messages []*big.Int,
+	activityReports []*signingActivityReport,
+	err error,
+) {
+	if waa == nil {
+		return
+	}
+
+	waa.mutex.Lock()
+	defer waa.mutex.Unlock()
+
+	waa.entry.Signing = newWalletActionAuditStep(err)
+
+	for i, report := range activityReports {
+		if i >= len(messages) || report == nil {
+			break
+		}
+
+		waa.entry.SigningAttempts = append(
+			waa.entry.SigningAttempts,
+			&WalletActionAuditSigning{
+				Message:         fmt.Sprintf("0x%x", messages[i]),
+				AttemptNumber:   report.attemptNumber,
+				ExcludedMembers: report.excludedMembers,
+				InactiveMembers: report.inactiveMembers,
+			},
+		)
+	}
+}
+
+// recordTransaction records the hash of the Bitcoin transaction produced
+// by the action.
+func (waa *walletActionAudit) recordTransaction(transactionHash bitcoin.Hash) {
+	if waa == nil {
+		return
+	}
+
+	waa.mutex.Lock()
+	defer waa.mutex.Unlock()
+
+	waa.entry.TransactionHash = transactionHash.Hex(bitcoin.ReversedByteOrder)
+}
+
+// recordBroadcast records the outcome of the transaction broadcast.
+func (waa *walletActionAudit) recordBroadcast(err error) {
+	if waa == nil {
+		return
+	}
+
+	waa.mutex.Lock()
+	defer waa.mutex.Unlock()
+
+	waa.entry.Broadcast = newWalletActionAuditStep(err)
+}
+
+// reject finishes the audit of an action that was not dispatched for
+// execution.
+func (waa *walletActionAudit) reject(err error) {
+	waa.complete(WalletActionOutcomeRejected, err)
+}
+
+// finish finishes the audit of an executed action.
+func (waa *walletActionAudit) finish(err error) {
+	if err != nil {
+		waa.complete(WalletActionOutcomeFailed, err)
+	} else {
+		waa.complete(WalletActionOutcomeSucceeded, nil)
+	}
+}
+
+func (waa *walletActionAudit) complete(outcome string, err error) {
+	if waa == nil {
+		return
+	}
+
+	waa.mutex.Lock()
+	defer waa.mutex.Unlock()
+
+	waa.entry.FinishedAt = time.Now()
+	waa.entry.Outcome = outcome
+	if err != nil {
+		waa.entry.Error = err.Error()
+	}
+
+	if err := waa.log.append(waa.entry); err != nil {
+		logger.Errorf(
+			"cannot append [%s] action of wallet [%s] to the audit log: [%v]",
+			waa.entry.Action,
+			waa.entry.Wallet,
+			err,
+		)
+	}
+}
+
+// walletActionAuditLog is an append-only log of wallet actions executed by
+// the node. Entries are appended as JSON lines to segments kept in the work
+// persistence. Once the current segment exceeds the configured size, a new
+// segment is started and the oldest segments above the configured count are
+// deleted. All functions of the log are safe for concurrent use.
+type walletActionAuditLog struct {
+	mutex sync.Mutex
+
+	persistence persistence.BasicHandle
+	segmentSize int
+	maxSegments int
+
+	// segments holds sequence numbers of the audit log segments, in the
+	// chronological order.
+	segments []uint64
+	// currentSegment holds the content of the most recent segment. The
+	// persistence encrypts segments as a whole so the entire segment is
+	// saved again upon each append.
+	currentSegment []byte
+}
+
+// newWalletActionAuditLog creates a new wallet action audit log kept in
+// the given persistence. Returns nil if the persistence is nil, so wallet
+// actions are not audited.
+func newWalletActionAuditLog(
+	persistence persistence.BasicHandle,
+	segmentSize int,
+	maxSegments int,
+) *walletActionAuditLog {
+	if persistence == nil {
+		return nil
+	}
+
+	wal := &walletActionAuditLog{
+		persistence: persistence,
+		segmentSize: segmentSize,
+		maxSegments: maxSegments,
+		segments:    make([]uint64, 0),
+	}
+
+	segments, err := readWalletActionAuditSegments(persistence)
+	if err != nil {
+		logger.Errorf("cannot load wallet action audit log: [%v]", err)
+	}
+
+	for _, segment := range segments {
+		wal.segments = append(wal.segments, segment.sequenceNumber)
+	}
+
+	if count := len(segments); count > 0 {
+		content, err := segments[count-1].descriptor.Content()
+		if err != nil {
+			// The segment may have been left incomplete by a crash. Do not
+			// overwrite it so the entries it holds can still be recovered.
+			logger.Errorf(
+				"cannot read the current audit log segment: [%v]",
+				err,
+			)
+			wal.startSegment()
+		} else {
+			wal.currentSegment = content
+		}
+	}
+
+	return wal
+}
+
+// newAudit starts the audit of a wallet action resulting from the given
+// coordination result. Returns nil if the log is nil.
+func (wal *walletActionAuditLog) newAudit(
+	result *coordinationResult,
+	startBlock uint64,
+	expiryBlock uint64,
+) *walletActionAudit {
+	if wal == nil {
+		return nil
+	}
+
+	proposal, err := json.Marshal(result.proposal)
+	if err != nil {
+		logger.Errorf("cannot marshal proposal for the audit log: [%v]", err)
+		proposal = []byte("null")
+	}
+
+	return &walletActionAudit{
+		log: wal,
+		entry: &WalletActionAuditEntry{
+			Wallet: fmt.Sprintf(
+				"0x%x",
+				bitcoin.PublicKeyHash(result.wallet.publicKey),
+			),
+			Action:            result.proposal.ActionType().String(),
+			Proposal:          proposal,
+			CoordinationBlock: result.window.coordinationBlock,
+			Leader:            result.leader.String(),
+			StartBlock:        startBlock,
+			ExpiryBlock:       expiryBlock,
+			DispatchedAt:      time.Now(),
+		},
+	}
+}
+
+// append appends the given entry to the audit log. A new segment is
+// started if the entry would make the current segment exceed the segment
+// size. Oldest segments above the limit are deleted.
+func (wal *walletActionAuditLog) append(entry *WalletActionAuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("cannot marshal audit entry: [%w]", err)
+	}
+	line = append(line, '\n')
+
+	wal.mutex.Lock()
+	defer wal.mutex.Unlock()
+
+	if len(wal.segments) == 0 ||
+		(len(wal.currentSegment) > 0 &&
+			len(wal.currentSegment)+len(line) > wal.segmentSize) {
+		wal.startSegment()
+	}
+
+	content := make([]byte, 0, len(wal.currentSegment)+len(line))
+	content = append(content, wal.currentSegment...)
+	content = append(content, line...)
+
+	if err := wal.persistence.Save(
+		content,
+		walletActionAuditSegmentsDirectory,
+		walletActionAuditSegmentName(wal.segments[len(wal.segments)-1]),
+	); err != nil {
+		return fmt.Errorf("cannot save audit log segment: [%w]", err)
+	}
+
+	wal.currentSegment = content
+
+	return nil
+}
+
+// startSegment starts a new segment of the audit log and deletes the oldest
+// segments above the limit.
+func (wal *walletActionAuditLog) startSegment() {
+	sequenceNumber := uint64(0)
+	if count := len(wal.segments); count > 0 {
+		sequenceNumber = wal.segments[count-1] + 1
+	}
+
+	wal.segments = append(wal.segments, sequenceNumber)
+	wal.currentSegment = nil
+
+	for wal.maxSegments > 0 && len(wal.segments) > wal.maxSegments {
+		if err := wal.persistence.Delete(
+			walletActionAuditSegmentsDirectory,
+			walletActionAuditSegmentName(wal.segments[0]),
+		); err != nil && !os.IsNotExist(err) {
+			logger.Warnf(
+				"cannot delete audit log segment [%v]: [%v]",
+				wal.segments[0],
+				err,
+			)
+		}
+
+		wal.segments = wal.segments[1:]
+	}
+}
+
+func walletActionAuditSegmentName(sequenceNumber uint64) string {
+	return fmt.Sprintf(
+		"%020d%s",
+		sequenceNumber,
+		walletActionAuditSegmentExtension,
+	)
+}
+
+// WalletActionAuditFilter narrows down entries returned from the wallet
+// action audit log. Zero values of fields match all entries.
+type WalletActionAuditFilter struct {
+	// WalletPublicKeyHash is the 20-byte public key hash of the wallet.
+	WalletPublicKeyHash *[20]byte
+	// Action is the name of the wallet action type, e.g. `DepositSweep`.
+	Action string
+	// Since excludes entries of actions dispatched before the given time.
+	Since time.Time
+	// Limit is the maximum number of the most recent entries to return.
+	Limit int
+}
+
+func (waf *WalletActionAuditFilter) matches(entry *WalletActionAuditEntry) bool {
+	if waf.WalletPublicKeyHash != nil &&
+		entry.Wallet != fmt.Sprintf("0x%x", *waf.WalletPublicKeyHash) {
+		return false
+	}
+
+	if waf.Action != "" && !strings.EqualFold(entry.Action, waf.Action) {
+		return false
+	}
+
+	if !waf.Since.IsZero() && entry.DispatchedAt.Before(waf.Since) {
+		return false
+	}
+
+	return true
+}
+
+// ReadWalletActionAudit reads entries of the wallet action audit log kept in
+// the given persistence that match the given filter. Segments are read
+// from the most recent one so reading stops as soon as the limit is
+// reached. Entries are returned in the chronological order. Segments and
+// lines that cannot be read, e.g. ones left incomplete by a crash, are
+// skipped.
+func ReadWalletActionAudit(
+	persistence persistence.RWHandle,
+	filter *WalletActionAuditFilter,
+) ([]*WalletActionAuditEntry, error) {
+	if filter == nil {
+		filter = &WalletActionAuditFilter{}
+	}
+
+	segments, err := readWalletActionAuditSegments(persistence)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries are collected from the most recent one and reversed at the end.
+	entries := make([]*WalletActionAuditEntry, 0)
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		name := segments[i].descriptor.Name()
+
+		content, err := segments[i].descriptor.Content()
+		if err != nil {
+			// The segment may have been deleted or is being saved by
+			// the running client.
+			logger.Warnf(
+				"skipping audit log segment [%s] that cannot be read: [%v]",
+				name,
+				err,
+			)
+			continue
+		}
+
+		lines := bytes.Split(bytes.TrimSpace(content), []byte{'\n'})
+		for j := len(lines) - 1; j >= 0; j-- {
+			if len(lines[j]) == 0 {
+				continue
+			}
+
+			entry := &WalletActionAuditEntry{}
+			if err := json.Unmarshal(lines[j], entry); err != nil {
+				logger.Warnf(
+					"skipping malformed entry [%v] of audit log segment [%s]: [%v]",
+					j,
+					name,
+					err,
+				)
+				continue
+			}
+
+			if !filter.matches(entry) {
+				continue
+			}
+
+			entries = append(entries, entry)
+
+			if filter.Limit > 0 && len(entries) == filter.Limit {
+				reverseWalletActionAuditEntries(entries)
+				return entries, nil
+			}
+		}
+	}
+
+	reverseWalletActionAuditEntries(entries)
+	return entries, nil
+}
+
+func reverseWalletActionAuditEntries(entries []*WalletActionAuditEntry) {
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+}
+
+// walletActionAuditSegment is a segment of the audit log kept in
+// the persistence.
+type walletActionAuditSegment struct {
+	sequenceNumber uint64
+	descriptor     persistence.DataDescriptor
+}
+
+// readWalletActionAuditSegments returns all audit log segments kept in
+// the given persistence, in the chronological order. Files that are not
+// audit log segments are ignored. The content of segments is not read.
+func readWalletActionAuditSegments(
+	persistence persistence.RWHandle,
+) ([]*walletActionAuditSegment, error) {
+	descriptorsChan, errorsChan := persistence.ReadAll()
+
+	segments := make([]*walletActionAuditSegment, 0)
+	var readErr error
+
+	// Two goroutines read from descriptors and errors channels. The reason
+	// for using two goroutines at the same time - one for descriptors and
+	// one for errors - is that channels do not have to be buffered, and we
+	// do not know in what order the information is written to channels.
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for descriptor := range descriptorsChan {
+			name := descriptor.Name()
+			if descriptor.Directory() != walletActionAuditSegmentsDirectory ||
+				!strings.HasSuffix(name, walletActionAuditSegmentExtension) {
+				continue
+			}
+
+			sequenceNumber, err := strconv.ParseUint(
+				strings.TrimSuffix(name, walletActionAuditSegmentExtension),
+				10,
+				64,
+			)
+			if err != nil {
+				continue
+			}
+
+			segments = append(segments, &walletActionAuditSegment{
+				sequenceNumber: sequenceNumber,
+				descriptor:     descriptor,
+			})
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for err := range errorsChan {
+			if readErr == nil {
+				readErr = err
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if readErr != nil {
+		return nil, fmt.Errorf("cannot read audit log segments: [%w]", readErr)
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].sequenceNumber < segments[j].sequenceNumber
+	})
+
+	return segments, nil
+}
@@ -0,0 +1,556 @@
+package tbtc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-common/pkg/persistence"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+)
+
+func TestWalletActionAudit(t *testing.T) {
+	auditPersistence := newTestWalletActionAuditPersistence(t)
+	auditLog := newWalletActionAuditLog(
+		auditPersistence,
+		DefaultWalletActionAuditSegmentSize,
+		DefaultWalletActionAuditMaxSegments,
+	)
+
+	executingWallet := generateWallet(big.NewInt(100))
+
+	result := &coordinationResult{
+		wallet: executingWallet,
+		window: newCoordinationWindow(900),
+		leader: chain.Address("0xLeader"),
+		proposal: &DepositSweepProposal{
+			SweepTxFee: big.NewInt(1000),
+		},
+	}
+
+	audit := auditLog.newAudit(result, 980, 1100)
+
+	audit.recordValidation(nil)
+	audit.recordSigning(
+		[]*big.Int{big.NewInt(255), big.NewInt(256)},
+		[]*signingActivityReport{
+			{
+				activeMembers:   []group.MemberIndex{1, 2, 3},
+				inactiveMembers: []group.MemberIndex{4},
+				attemptNumber:   1,
+				excludedMembers: []group.MemberIndex{4},
+			},
+			{
+				activeMembers:   []group.MemberIndex{1, 2, 3, 4},
+				inactiveMembers: []group.MemberIndex{},
+				attemptNumber:   2,
+				excludedMembers: []group.MemberIndex{3},
+			},
+		},
+		nil,
+	)
+	audit.recordTransaction(bitcoin.Hash{1})
+	audit.recordBroadcast(fmt.Errorf("broadcast timeout exceeded"))
+	audit.finish(fmt.Errorf("broadcast transaction step failed"))
+
+	entries, err := ReadWalletActionAudit(auditPersistence, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertIntsEqual(t, "entries count", 1, len(entries))
+
+	entry := entries[0]
+
+	testutils.AssertStringsEqual(
+		t,
+		"wallet",
+		fmt.Sprintf("0x%x", bitcoin.PublicKeyHash(executingWallet.publicKey)),
+		entry.Wallet,
+	)
+	testutils.AssertStringsEqual(
+		t,
+		"action",
+		ActionDepositSweep.String(),
+		entry.Action,
+	)
+	testutils.AssertUintsEqual(t, "coordination block", 900, entry.CoordinationBlock)
+	testutils.AssertStringsEqual(t, "leader", "0xLeader", entry.Leader)
+	testutils.AssertUintsEqual(t, "start block", 980, entry.StartBlock)
+	testutils.AssertUintsEqual(t, "expiry block", 1100, entry.ExpiryBlock)
+
+	expectedValidation := &WalletActionAuditStep{Succeeded: true}
+	if !reflect.DeepEqual(expectedValidation, entry.Validation) {
+		t.Errorf(
+			"unexpected validation\nexpected: [%+v]\nactual:   [%+v]",
+			expectedValidation,
+			entry.Validation,
+		)
+	}
+
+	expectedSigningAttempts := []*WalletActionAuditSigning{
+		{
+			Message:         "0xff",
+			AttemptNumber:   1,
+			ExcludedMembers: []group.MemberIndex{4},
+			InactiveMembers: []group.MemberIndex{4},
+		},
+		{
+			Message:         "0x100",
+			AttemptNumber:   2,
+			ExcludedMembers: []group.MemberIndex{3},
+			InactiveMembers: []group.MemberIndex{},
+		},
+	}
+	if !reflect.DeepEqual(expectedSigningAttempts, entry.SigningAttempts) {
+		t.Errorf(
+			"unexpected signing attempts\nexpected: [%+v]\nactual:   [%+v]",
+			expectedSigningAttempts,
+			entry.SigningAttempts,
+		)
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"transaction hash",
+		bitcoin.Hash{1}.Hex(bitcoin.ReversedByteOrder),
+		entry.TransactionHash,
+	)
+
+	expectedBroadcast := &WalletActionAuditStep{
+		Succeeded: false,
+		Error:     "broadcast timeout exceeded",
+	}
+	if !reflect.DeepEqual(expectedBroadcast, entry.Broadcast) {
+		t.Errorf(
+			"unexpected broadcast\nexpected: [%+v]\nactual:   [%+v]",
+			expectedBroadcast,
+			entry.Broadcast,
+		)
+	}
+
+	testutils.AssertStringsEqual(
+		t,
+		"outcome",
+		WalletActionOutcomeFailed,
+		entry.Outcome,
+	)
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"broadcast transaction step failed",
+		entry.Error,
+	)
+}
+
+func TestWalletActionAudit_Nil(t *testing.T) {
+	var auditLog *walletActionAuditLog
+
+	audit := auditLog.newAudit(
+		&coordinationResult{proposal: &NoopProposal{}},
+		0,
+		0,
+	)
+
+	// None of the calls should panic.
+	audit.recordValidation(nil)
+	audit.recordSigning(nil, nil, nil)
+	audit.recordTransaction(bitcoin.Hash{})
+	audit.recordBroadcast(nil)
+	audit.reject(errWalletBusy)
+	audit.finish(nil)
+}
+
+func TestWalletActionAuditLog_Segments(t *testing.T) {
+	auditPersistence := newTestWalletActionAuditPersistence(t)
+
+	newEntry := func(i int) *WalletActionAuditEntry {
+		return &WalletActionAuditEntry{
+			Wallet:  "0x01",
+			Action:  ActionRedemption.String(),
+			Outcome: WalletActionOutcomeSucceeded,
+			Error:   fmt.Sprintf("entry %v", i),
+		}
+	}
+
+	line, err := json.Marshal(newEntry(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each segment fits two entries and three segments are kept.
+	newAuditLog := func() *walletActionAuditLog {
+		return newWalletActionAuditLog(auditPersistence, 2*(len(line)+1), 3)
+	}
+
+	auditLog := newAuditLog()
+	for i := 0; i < 3; i++ {
+		if err := auditLog.append(newEntry(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Reload the log to make sure appending continues the current segment.
+	auditLog = newAuditLog()
+	for i := 3; i < 9; i++ {
+		if err := auditLog.append(newEntry(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	segments, err := readWalletActionAuditSegments(auditPersistence)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sequenceNumbers := make([]uint64, len(segments))
+	for i, segment := range segments {
+		sequenceNumbers[i] = segment.sequenceNumber
+	}
+
+	// Entries 0-1, 2-3, 4-5, 6-7 and 8 were written to five segments and
+	// the two oldest segments were deleted.
+	expectedSequenceNumbers := []uint64{2, 3, 4}
+	if !reflect.DeepEqual(expectedSequenceNumbers, sequenceNumbers) {
+		t.Errorf(
+			"unexpected segments\nexpected: [%v]\nactual:   [%v]",
+			expectedSequenceNumbers,
+			sequenceNumbers,
+		)
+	}
+
+	for _, segment := range segments {
+		content, err := segment.descriptor.Content()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(content) > 2*(len(line)+1) {
+			t.Errorf(
+				"segment [%v] exceeds the segment size: [%v] bytes",
+				segment.sequenceNumber,
+				len(content),
+			)
+		}
+	}
+
+	entries, err := ReadWalletActionAudit(auditPersistence, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualEntries := make([]string, len(entries))
+	for i, entry := range entries {
+		actualEntries[i] = entry.Error
+	}
+	expectedEntries := []string{
+		"entry 4",
+		"entry 5",
+		"entry 6",
+		"entry 7",
+		"entry 8",
+	}
+	if !reflect.DeepEqual(expectedEntries, actualEntries) {
+		t.Errorf(
+			"unexpected entries\nexpected: [%v]\nactual:   [%v]",
+			expectedEntries,
+			actualEntries,
+		)
+	}
+}
+
+func TestWalletActionAuditLog_Encrypted(t *testing.T) {
+	auditDirectory := t.TempDir()
+	auditLog := newWalletActionAuditLog(
+		newTestWalletActionAuditPersistenceAt(t, auditDirectory),
+		DefaultWalletActionAuditSegmentSize,
+		DefaultWalletActionAuditMaxSegments,
+	)
+
+	err := auditLog.append(&WalletActionAuditEntry{Error: "confidential"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(
+		filepath.Join(
+			auditDirectory,
+			walletActionAuditSegmentsDirectory,
+			walletActionAuditSegmentName(0),
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(content, []byte("confidential")) {
+		t.Errorf("audit log segment is not encrypted")
+	}
+}
+
+func TestReadWalletActionAudit_IncompleteSegment(t *testing.T) {
+	auditDirectory := t.TempDir()
+	auditPersistence := newTestWalletActionAuditPersistenceAt(t, auditDirectory)
+
+	newAuditLog := func() *walletActionAuditLog {
+		return newWalletActionAuditLog(
+			auditPersistence,
+			DefaultWalletActionAuditSegmentSize,
+			DefaultWalletActionAuditMaxSegments,
+		)
+	}
+
+	auditLog := newAuditLog()
+	if err := auditLog.append(&WalletActionAuditEntry{Error: "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a segment save interrupted by a crash.
+	segmentPath := filepath.Join(
+		auditDirectory,
+		walletActionAuditSegmentsDirectory,
+		walletActionAuditSegmentName(0),
+	)
+	content, err := os.ReadFile(segmentPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(segmentPath, content[:len(content)/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the log to make sure the next entry is not saved over
+	// the incomplete segment.
+	auditLog = newAuditLog()
+	if err := auditLog.append(&WalletActionAuditEntry{Error: "second"}); err != nil {
+		t.Fatal(err)
+	}
+
+	incompleteContent, err := os.ReadFile(segmentPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content[:len(content)/2], incompleteContent) {
+		t.Errorf("incomplete segment has been overwritten")
+	}
+
+	entries, err := ReadWalletActionAudit(auditPersistence, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualEntries := make([]string, len(entries))
+	for i, entry := range entries {
+		actualEntries[i] = entry.Error
+	}
+	expectedEntries := []string{"second"}
+	if !reflect.DeepEqual(expectedEntries, actualEntries) {
+		t.Errorf(
+			"unexpected entries\nexpected: [%v]\nactual:   [%v]",
+			expectedEntries,
+			actualEntries,
+		)
+	}
+}
+
+func TestReadWalletActionAudit_Filter(t *testing.T) {
+	auditPersistence := newTestWalletActionAuditPersistence(t)
+	// Use tiny segments so each entry is kept in its own segment and
+	// the filter is applied across segments.
+	auditLog := newWalletActionAuditLog(
+		auditPersistence,
+		1,
+		DefaultWalletActionAuditMaxSegments,
+	)
+
+	dispatchedAt := time.Unix(1700000000, 0).UTC()
+
+	entries := []*WalletActionAuditEntry{
+		{
+			Wallet:       "0x0101010101010101010101010101010101010101",
+			Action:       ActionDepositSweep.String(),
+			DispatchedAt: dispatchedAt,
+			Error:        "first",
+		},
+		{
+			Wallet:       "0x0202020202020202020202020202020202020202",
+			Action:       ActionRedemption.String(),
+			DispatchedAt: dispatchedAt.Add(time.Hour),
+			Error:        "second",
+		},
+		{
+			Wallet:       "0x0101010101010101010101010101010101010101",
+			Action:       ActionRedemption.String(),
+			DispatchedAt: dispatchedAt.Add(2 * time.Hour),
+			Error:        "third",
+		},
+	}
+
+	for _, entry := range entries {
+		if err := auditLog.append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	walletPublicKeyHash := [20]byte{
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	}
+
+	var tests = map[string]struct {
+		filter          *WalletActionAuditFilter
+		expectedEntries []string
+	}{
+		"no filter": {
+			filter:          nil,
+			expectedEntries: []string{"first", "second", "third"},
+		},
+		"wallet": {
+			filter: &WalletActionAuditFilter{
+				WalletPublicKeyHash: &walletPublicKeyHash,
+			},
+			expectedEntries: []string{"first", "third"},
+		},
+		"action": {
+			filter: &WalletActionAuditFilter{
+				Action: "redemption",
+			},
+			expectedEntries: []string{"second", "third"},
+		},
+		"since": {
+			filter: &WalletActionAuditFilter{
+				Since: dispatchedAt.Add(time.Hour),
+			},
+			expectedEntries: []string{"second", "third"},
+		},
+		"limit": {
+			filter: &WalletActionAuditFilter{
+				Limit: 2,
+			},
+			expectedEntries: []string{"second", "third"},
+		},
+		"wallet and limit": {
+			filter: &WalletActionAuditFilter{
+				WalletPublicKeyHash: &walletPublicKeyHash,
+				Limit:               1,
+			},
+			expectedEntries: []string{"third"},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			entries, err := ReadWalletActionAudit(auditPersistence, test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actualEntries := make([]string, len(entries))
+			for i, entry := range entries {
+				actualEntries[i] = entry.Error
+			}
+
+			if !reflect.DeepEqual(test.expectedEntries, actualEntries) {
+				t.Errorf(
+					"unexpected entries\nexpected: [%v]\nactual:   [%v]",
+					test.expectedEntries,
+					actualEntries,
+				)
+			}
+		})
+	}
+}
+
+func TestWalletDispatcher_Audit(t *testing.T) {
+	auditPersistence := newTestWalletActionAuditPersistence(t)
+	auditLog := newWalletActionAuditLog(
+		auditPersistence,
+		DefaultWalletActionAuditSegmentSize,
+		DefaultWalletActionAuditMaxSegments,
+	)
+
+	executingWallet := generateWallet(big.NewInt(100))
+
+	result := &coordinationResult{
+		wallet:   executingWallet,
+		window:   newCoordinationWindow(900),
+		leader:   chain.Address("0xLeader"),
+		proposal: &HeartbeatProposal{},
+	}
+
+	walletDispatcher := newWalletDispatcher()
+
+	done := make(chan struct{})
+	busyAction := &mockWalletAction{
+		executeFn: func() error {
+			<-done
+			return nil
+		},
+		actionWallet: executingWallet,
+	}
+
+	err := walletDispatcher.dispatch(
+		busyAction,
+		auditLog.newAudit(result, 980, 1100),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = walletDispatcher.dispatch(
+		busyAction,
+		auditLog.newAudit(result, 980, 1100),
+	)
+	testutils.AssertErrorsSame(t, errWalletBusy, err)
+
+	close(done)
+
+	// Give some time to complete the first action.
+	time.Sleep(1 * time.Second)
+
+	entries, err := ReadWalletActionAudit(auditPersistence, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualOutcomes := make([]string, len(entries))
+	for i, entry := range entries {
+		actualOutcomes[i] = entry.Outcome
+	}
+	expectedOutcomes := []string{
+		WalletActionOutcomeRejected,
+		WalletActionOutcomeSucceeded,
+	}
+	if !reflect.DeepEqual(expectedOutcomes, actualOutcomes) {
+		t.Errorf(
+			"unexpected outcomes\nexpected: [%v]\nactual:   [%v]",
+			expectedOutcomes,
+			actualOutcomes,
+		)
+	}
+}
+
+func newTestWalletActionAuditPersistence(t *testing.T) persistence.BasicHandle {
+	return newTestWalletActionAuditPersistenceAt(t, t.TempDir())
+}
+
+func newTestWalletActionAuditPersistenceAt(
+	t *testing.T,
+	directory string,
+) persistence.BasicHandle {
+	diskHandle, err := persistence.NewBasicDiskHandle(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return persistence.NewEncryptedBasicPersistence(diskHandle, "password")
+}
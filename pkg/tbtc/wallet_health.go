@@ -0,0 +1,896 @@
+package tbtc
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/clientinfo"
+)
+
+const (
+	// walletHealthCheckPeriod determines how often the wallet health monitor
+	// evaluates the health of wallets controlled by the node.
+	walletHealthCheckPeriod = 10 * time.Minute
+	// walletHealthUnprovenTransactionMaxAge is the time after which a wallet
+	// transaction that is not yet proven in the Bridge is reported as an
+	// issue. SPV proofs are normally submitted within a few hours.
+	walletHealthUnprovenTransactionMaxAge = 12 * time.Hour
+	// walletHealthRedemptionTimeoutMargin determines how close to their
+	// timeout pending redemption requests must be to be reported.
+	walletHealthRedemptionTimeoutMargin = 24 * time.Hour
+	// walletHealthMovingFundsDeadlineMargin determines how close to the
+	// moving funds timeout a wallet must be to be reported.
+	walletHealthMovingFundsDeadlineMargin = 24 * time.Hour
+	// walletHealthMaxAgeMargin determines how close to the maximum age
+	// a live wallet must be to be reported.
+	walletHealthMaxAgeMargin = 7 * 24 * time.Hour
+	// walletHealthAlertTimeout is the timeout of a single webhook alert
+	// delivery.
+	walletHealthAlertTimeout = 10 * time.Second
+)
+
+// walletHealthSignal identifies a single signal evaluated by the wallet
+// health monitor.
+type walletHealthSignal string
+
+const (
+	signalMainUtxoSync           walletHealthSignal = "main_utxo_sync"
+	signalUnprovenTransactions   walletHealthSignal = "unproven_transactions"
+	signalRedemptionsNearTimeout walletHealthSignal = "redemptions_near_timeout"
+	signalHeartbeatFailures      walletHealthSignal = "heartbeat_failures"
+	signalMovingFundsDeadline    walletHealthSignal = "moving_funds_deadline"
+	signalMaxAge                 walletHealthSignal = "max_age"
+)
+
+// walletHealth is the result of a single health evaluation of a wallet.
+type walletHealth struct {
+	walletPublicKeyHash [20]byte
+	checkedAt           time.Time
+
+	state                       WalletState
+	mainUtxoSynced              bool
+	unprovenTransactions        int
+	oldestUnprovenTransactionAt time.Time
+	redemptionsNearTimeout      int
+	heartbeatFailures           uint
+	// movingFundsDeadline is zero if the wallet is not moving funds.
+	movingFundsDeadline time.Time
+	maxAgeReachedAt     time.Time
+
+	// issues holds problems detected for the given signals.
+	issues map[walletHealthSignal]string
+	// failedChecks holds errors of signals that could not be evaluated.
+	failedChecks map[walletHealthSignal]error
+}
+
+// healthy returns true if no issues were detected for the wallet.
+func (wh *walletHealth) healthy() bool {
+	return len(wh.issues) == 0
+}
+
+// redemptionScan holds results of past scans of redemption requested events
+// of a wallet so each evaluation scans only blocks mined since the last one.
+type redemptionScan struct {
+	// lastBlock is the last block covered by the scans.
+	lastBlock uint64
+	// requestBlocks holds the block of the latest redemption request
+	// for each hex-encoded redeemer output script.
+	requestBlocks map[string]uint64
+}
+
+// walletHealthMonitor periodically evaluates the health of all wallets
+// controlled by the node. Results are exposed as metrics and diagnostics
+// and changes of detected issues are optionally sent as webhook alerts.
+// All functions of the monitor are safe for concurrent use.
+type walletHealthMonitor struct {
+	chain                   Chain
+	btcChain                bitcoin.Chain
+	walletRegistry          *walletRegistry
+	transactionTracker      *walletTransactionTracker
+	heartbeatFailureCounter *heartbeatFailureCounter
+	// alerter is nil if webhook alerts are disabled.
+	alerter *walletHealthAlerter
+
+	mutex sync.Mutex
+	// reports holds the latest health evaluation of wallets. The map key
+	// is the hex-encoded 20-byte public key hash of the wallet.
+	reports map[string]*walletHealth
+	// alerted holds issues of wallets that were alerted about and are not
+	// yet resolved. The map key is the same as in reports.
+	alerted map[string]map[walletHealthSignal]bool
+	// redemptionScans holds redemption requested events scans of wallets.
+	// The map key is the same as in reports.
+	redemptionScans map[string]*redemptionScan
+}
+
+func newWalletHealthMonitor(
+	chain Chain,
+	btcChain bitcoin.Chain,
+	walletRegistry *walletRegistry,
+	transactionTracker *walletTransactionTracker,
+	heartbeatFailureCounter *heartbeatFailureCounter,
+	alerter *walletHealthAlerter,
+) *walletHealthMonitor {
+	return &walletHealthMonitor{
+		chain:                   chain,
+		btcChain:                btcChain,
+		walletRegistry:          walletRegistry,
+		transactionTracker:      transactionTracker,
+		heartbeatFailureCounter: heartbeatFailureCounter,
+		alerter:                 alerter,
+		reports:                 make(map[string]*walletHealth),
+		alerted:                 make(map[string]map[walletHealthSignal]bool),
+		redemptionScans:         make(map[string]*redemptionScan),
+	}
+}
+
+// run starts the periodic health evaluation of wallets. It blocks until
+// the given context is done.
+func (whm *walletHealthMonitor) run(ctx context.Context) {
+	ticker := time.NewTicker(walletHealthCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		whm.checkWallets(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkWallets evaluates the health of all wallets controlled by the node,
+// stores the results and sends alerts about changed issues.
+func (whm *walletHealthMonitor) checkWallets(now time.Time) {
+	walletsPublicKeys := whm.walletRegistry.getWalletsPublicKeys()
+
+	currentWallets := make(map[string]bool)
+
+	for _, walletPublicKey := range walletsPublicKeys {
+		health := whm.evaluateWallet(walletPublicKey, now)
+
+		key := hex.EncodeToString(health.walletPublicKeyHash[:])
+		currentWallets[key] = true
+
+		walletLogger := logger.With(
+			zap.String("wallet", fmt.Sprintf("0x%x", health.walletPublicKeyHash)),
+		)
+
+		for signal, err := range health.failedChecks {
+			walletLogger.Warnf(
+				"cannot evaluate wallet health signal [%s]: [%v]",
+				signal,
+				err,
+			)
+		}
+
+		for _, signal := range sortedWalletHealthSignals(health.issues) {
+			walletLogger.Warnf(
+				"wallet health issue [%s]: [%s]",
+				signal,
+				health.issues[signal],
+			)
+		}
+
+		whm.mutex.Lock()
+		whm.reports[key] = health
+		whm.mutex.Unlock()
+
+		whm.sendAlerts(key, health)
+	}
+
+	// Drop reports of wallets no longer controlled by the node, e.g.
+	// archived ones.
+	whm.mutex.Lock()
+	for key := range whm.reports {
+		if !currentWallets[key] {
+			delete(whm.reports, key)
+			delete(whm.alerted, key)
+			delete(whm.redemptionScans, key)
+		}
+	}
+	whm.mutex.Unlock()
+}
+
+// evaluateWallet evaluates all health signals of the given wallet at the
+// given time. Signals that cannot be evaluated are recorded as failed
+// checks and do not produce issues.
+func (whm *walletHealthMonitor) evaluateWallet(
+	walletPublicKey *ecdsa.PublicKey,
+	now time.Time,
+) *walletHealth {
+	walletPublicKeyHash := bitcoin.PublicKeyHash(walletPublicKey)
+
+	health := &walletHealth{
+		walletPublicKeyHash: walletPublicKeyHash,
+		checkedAt:           now,
+		issues:              make(map[walletHealthSignal]string),
+		failedChecks:        make(map[walletHealthSignal]error),
+	}
+
+	whm.evaluateHeartbeatFailures(health, walletPublicKey)
+
+	walletChainData, err := whm.chain.GetWallet(walletPublicKeyHash)
+	if err != nil {
+		err = fmt.Errorf("cannot get wallet's chain data: [%w]", err)
+		health.failedChecks[signalMainUtxoSync] = err
+		health.failedChecks[signalUnprovenTransactions] = err
+		health.failedChecks[signalRedemptionsNearTimeout] = err
+		health.failedChecks[signalMovingFundsDeadline] = err
+		health.failedChecks[signalMaxAge] = err
+		return health
+	}
+
+	health.state = walletChainData.State
+
+	whm.evaluateMainUtxo(health)
+	whm.evaluateRedemptions(health)
+	whm.evaluateMovingFundsDeadline(health, walletChainData)
+	whm.evaluateMaxAge(health, walletChainData)
+
+	return health
+}
+
+// evaluateMainUtxo checks whether the wallet's main UTXO is synced between
+// the Bitcoin chain and the Bridge and counts wallet transactions that are
+// not yet proven in the Bridge.
+func (whm *walletHealthMonitor) evaluateMainUtxo(health *walletHealth) {
+	mainUtxo, err := DetermineWalletMainUtxo(
+		health.walletPublicKeyHash,
+		whm.chain,
+		whm.btcChain,
+	)
+	if err != nil {
+		err = fmt.Errorf("cannot determine wallet's main UTXO: [%w]", err)
+		health.failedChecks[signalMainUtxoSync] = err
+		health.failedChecks[signalUnprovenTransactions] = err
+		return
+	}
+
+	err = EnsureWalletSyncedBetweenChains(
+		health.walletPublicKeyHash,
+		mainUtxo,
+		whm.chain,
+		whm.btcChain,
+	)
+	if err != nil {
+		health.issues[signalMainUtxoSync] = fmt.Sprintf(
+			"wallet is not synced between chains: [%v]",
+			err,
+		)
+	} else {
+		health.mainUtxoSynced = true
+	}
+
+	unproven := unprovenWalletTransactions(
+		whm.transactionTracker.walletTransactions(health.walletPublicKeyHash),
+		mainUtxo,
+	)
+
+	health.unprovenTransactions = len(unproven)
+	if len(unproven) > 0 {
+		oldest := unproven[0]
+		health.oldestUnprovenTransactionAt = oldest.BroadcastAt
+
+		if age := health.checkedAt.Sub(oldest.BroadcastAt); age >
+			walletHealthUnprovenTransactionMaxAge {
+			health.issues[signalUnprovenTransactions] = fmt.Sprintf(
+				"transaction [%s] broadcast [%v] ago is not proven yet; "+
+					"[%v] unproven transactions in total",
+				oldest.Transaction.Hash().Hex(bitcoin.ReversedByteOrder),
+				age.Truncate(time.Second),
+				len(unproven),
+			)
+		}
+	}
+}
+
+// evaluateRedemptions counts pending redemption requests of the wallet that
+// are close to their timeout or already timed out. Redemption requested
+// events are scanned only from the block following the last scanned one.
+func (whm *walletHealthMonitor) evaluateRedemptions(health *walletHealth) {
+	_, _, _, _, redemptionTimeout, _, _, err := whm.chain.GetRedemptionParameters()
+	if err != nil {
+		health.failedChecks[signalRedemptionsNearTimeout] = fmt.Errorf(
+			"cannot get redemption parameters: [%w]",
+			err,
+		)
+		return
+	}
+
+	timeout := time.Duration(redemptionTimeout) * time.Second
+
+	// Requests created before that point are no longer pending as they
+	// were either handled or timed out and reported as such.
+	startBlock, err := whm.chain.GetBlockNumberByTimestamp(
+		uint64(health.checkedAt.Add(-timeout).Unix()),
+	)
+	if err != nil {
+		health.failedChecks[signalRedemptionsNearTimeout] = fmt.Errorf(
+			"cannot get redemption lookup start block: [%w]",
+			err,
+		)
+		return
+	}
+
+	scan, err := whm.scanRedemptions(health.walletPublicKeyHash, startBlock)
+	if err != nil {
+		health.failedChecks[signalRedemptionsNearTimeout] = err
+		return
+	}
+
+	earliestDeadline := time.Time{}
+
+	for script := range scan.requestBlocks {
+		redeemerOutputScript, err := hex.DecodeString(script)
+		if err != nil {
+			health.failedChecks[signalRedemptionsNearTimeout] = fmt.Errorf(
+				"cannot decode redeemer output script [0x%s]: [%w]",
+				script,
+				err,
+			)
+			return
+		}
+
+		request, found, err := whm.chain.GetPendingRedemptionRequest(
+			health.walletPublicKeyHash,
+			redeemerOutputScript,
+		)
+		if err != nil {
+			health.failedChecks[signalRedemptionsNearTimeout] = fmt.Errorf(
+				"cannot get pending redemption request for "+
+					"redeemer output script [0x%s]: [%w]",
+				script,
+				err,
+			)
+			return
+		}
+
+		if !found {
+			continue
+		}
+
+		deadline := request.RequestedAt.Add(timeout)
+		if deadline.Sub(health.checkedAt) > walletHealthRedemptionTimeoutMargin {
+			continue
+		}
+
+		health.redemptionsNearTimeout++
+
+		if earliestDeadline.IsZero() || deadline.Before(earliestDeadline) {
+			earliestDeadline = deadline
+		}
+	}
+
+	if health.redemptionsNearTimeout > 0 {
+		health.issues[signalRedemptionsNearTimeout] = fmt.Sprintf(
+			"[%v] pending redemption requests time out within [%v]; "+
+				"the earliest at [%s]",
+			health.redemptionsNearTimeout,
+			walletHealthRedemptionTimeoutMargin,
+			earliestDeadline.UTC().Format(time.RFC3339),
+		)
+	}
+}
+
+// scanRedemptions updates the scan of redemption requested events of the
+// given wallet with events emitted since the last scanned block, or since
+// the given start block if the wallet was not scanned yet. Requests emitted
+// before the start block are dropped from the scan.
+func (whm *walletHealthMonitor) scanRedemptions(
+	walletPublicKeyHash [20]byte,
+	startBlock uint64,
+) (*redemptionScan, error) {
+	key := hex.EncodeToString(walletPublicKeyHash[:])
+
+	blockCounter, err := whm.chain.BlockCounter()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get block counter: [%w]", err)
+	}
+
+	currentBlock, err := blockCounter.CurrentBlock()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get current block: [%w]", err)
+	}
+
+	whm.mutex.Lock()
+	previousScan, ok := whm.redemptionScans[key]
+	whm.mutex.Unlock()
+
+	scan := &redemptionScan{
+		lastBlock:     currentBlock,
+		requestBlocks: make(map[string]uint64),
+	}
+
+	fromBlock := startBlock
+	if ok {
+		for script, block := range previousScan.requestBlocks {
+			if block >= startBlock {
+				scan.requestBlocks[script] = block
+			}
+		}
+
+		if previousScan.lastBlock >= fromBlock {
+			fromBlock = previousScan.lastBlock + 1
+		}
+	}
+
+	if fromBlock <= currentBlock {
+		events, err := whm.chain.PastRedemptionRequestedEvents(
+			&RedemptionRequestedEventFilter{
+				StartBlock:          fromBlock,
+				EndBlock:            &currentBlock,
+				WalletPublicKeyHash: [][20]byte{walletPublicKeyHash},
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get past redemption requested events: [%w]",
+				err,
+			)
+		}
+
+		for _, event := range events {
+			script := hex.EncodeToString(event.RedeemerOutputScript)
+			if event.BlockNumber >= scan.requestBlocks[script] {
+				scan.requestBlocks[script] = event.BlockNumber
+			}
+		}
+	} else {
+		// Nothing new to scan; keep the last scanned block as is.
+		scan.lastBlock = fromBlock - 1
+	}
+
+	whm.mutex.Lock()
+	whm.redemptionScans[key] = scan
+	whm.mutex.Unlock()
+
+	return scan, nil
+}
+
+// evaluateHeartbeatFailures checks the number of consecutive heartbeat
+// failures of the wallet.
+func (whm *walletHealthMonitor) evaluateHeartbeatFailures(
+	health *walletHealth,
+	walletPublicKey *ecdsa.PublicKey,
+) {
+	walletPublicKeyBytes, err := marshalPublicKey(walletPublicKey)
+	if err != nil {
+		health.failedChecks[signalHeartbeatFailures] = fmt.Errorf(
+			"cannot marshal wallet public key: [%w]",
+			err,
+		)
+		return
+	}
+
+	health.heartbeatFailures = whm.heartbeatFailureCounter.get(
+		hex.EncodeToString(walletPublicKeyBytes),
+	)
+
+	if health.heartbeatFailures >= heartbeatConsecutiveFailureThreshold {
+		health.issues[signalHeartbeatFailures] = fmt.Sprintf(
+			"[%v] consecutive heartbeat failures",
+			health.heartbeatFailures,
+		)
+	}
+}
+
+// evaluateMovingFundsDeadline checks how much time is left until the moving
+// funds timeout of a wallet that is moving funds.
+func (whm *walletHealthMonitor) evaluateMovingFundsDeadline(
+	health *walletHealth,
+	walletChainData *WalletChainData,
+) {
+	if walletChainData.State != StateMovingFunds {
+		return
+	}
+
+	_, _, _, movingFundsTimeout, _, _, _, _, _, _, _, err :=
+		whm.chain.GetMovingFundsParameters()
+	if err != nil {
+		health.failedChecks[signalMovingFundsDeadline] = fmt.Errorf(
+			"cannot get moving funds parameters: [%w]",
+			err,
+		)
+		return
+	}
+
+	health.movingFundsDeadline = walletChainData.MovingFundsRequestedAt.Add(
+		time.Duration(movingFundsTimeout) * time.Second,
+	)
+
+	if health.movingFundsDeadline.Sub(health.checkedAt) <=
+		walletHealthMovingFundsDeadlineMargin {
+		health.issues[signalMovingFundsDeadline] = fmt.Sprintf(
+			"moving funds times out at [%s]",
+			health.movingFundsDeadline.UTC().Format(time.RFC3339),
+		)
+	}
+}
+
+// evaluateMaxAge checks the age of a live wallet against the maximum wallet
+// age after which the wallet should move its funds and be closed.
+func (whm *walletHealthMonitor) evaluateMaxAge(
+	health *walletHealth,
+	walletChainData *WalletChainData,
+) {
+	_, _, _, _, maxAge, _, _, err := whm.chain.GetWalletParameters()
+	if err != nil {
+		health.failedChecks[signalMaxAge] = fmt.Errorf(
+			"cannot get wallet parameters: [%w]",
+			err,
+		)
+		return
+	}
+
+	health.maxAgeReachedAt = walletChainData.CreatedAt.Add(
+		time.Duration(maxAge) * time.Second,
+	)
+
+	if walletChainData.State != StateLive {
+		return
+	}
+
+	if health.maxAgeReachedAt.Sub(health.checkedAt) <= walletHealthMaxAgeMargin {
+		health.issues[signalMaxAge] = fmt.Sprintf(
+			"wallet created at [%s] reaches the maximum age at [%s]",
+			walletChainData.CreatedAt.UTC().Format(time.RFC3339),
+			health.maxAgeReachedAt.UTC().Format(time.RFC3339),
+		)
+	}
+}
+
+// sendAlerts sends webhook alerts about issues of the given wallet that
+// appeared or were resolved since the last evaluation. Signals that could
+// not be evaluated keep their previous alert state. The alert state of
+// a signal changes only once the alert is delivered so undelivered alerts
+// are sent again on the next evaluation.
+func (whm *walletHealthMonitor) sendAlerts(key string, health *walletHealth) {
+	if whm.alerter == nil {
+		return
+	}
+
+	whm.mutex.Lock()
+	alerted := whm.alerted[key]
+
+	alerts := make([]*walletHealthAlert, 0)
+
+	for _, signal := range sortedWalletHealthSignals(health.issues) {
+		if alerted[signal] {
+			continue
+		}
+
+		alerts = append(alerts, newWalletHealthAlert(
+			health,
+			signal,
+			health.issues[signal],
+			false,
+		))
+	}
+
+	for _, signal := range sortedWalletHealthSignals(alerted) {
+		if _, failed := health.failedChecks[signal]; failed {
+			continue
+		}
+
+		if _, present := health.issues[signal]; present {
+			continue
+		}
+
+		alerts = append(alerts, newWalletHealthAlert(
+			health,
+			signal,
+			"issue resolved",
+			true,
+		))
+	}
+	whm.mutex.Unlock()
+
+	for _, alert := range alerts {
+		if err := whm.alerter.send(alert); err != nil {
+			logger.Errorf(
+				"cannot send wallet health alert [%s] for wallet [%s]: [%v]",
+				alert.Signal,
+				alert.Wallet,
+				err,
+			)
+			continue
+		}
+
+		whm.mutex.Lock()
+		alerted, ok := whm.alerted[key]
+		if !ok {
+			alerted = make(map[walletHealthSignal]bool)
+			whm.alerted[key] = alerted
+		}
+
+		signal := walletHealthSignal(alert.Signal)
+		if alert.Resolved {
+			delete(alerted, signal)
+		} else {
+			alerted[signal] = true
+		}
+		whm.mutex.Unlock()
+	}
+}
+
+// metricsSources returns the sources of aggregated wallet health metrics.
+func (whm *walletHealthMonitor) metricsSources() map[string]clientinfo.Source {
+	countWallets := func(predicate func(*walletHealth) bool) clientinfo.Source {
+		return func() float64 {
+			whm.mutex.Lock()
+			defer whm.mutex.Unlock()
+
+			count := 0
+			for _, health := range whm.reports {
+				if predicate(health) {
+					count++
+				}
+			}
+
+			return float64(count)
+		}
+	}
+
+	withIssue := func(signal walletHealthSignal) clientinfo.Source {
+		return countWallets(func(health *walletHealth) bool {
+			_, ok := health.issues[signal]
+			return ok
+		})
+	}
+
+	return map[string]clientinfo.Source{
+		"wallet_health_monitored_wallets": countWallets(
+			func(*walletHealth) bool { return true },
+		),
+		"wallet_health_unhealthy_wallets": countWallets(
+			func(health *walletHealth) bool { return !health.healthy() },
+		),
+		"wallet_health_unsynced_wallets": withIssue(signalMainUtxoSync),
+		"wallet_health_unproven_transactions": func() float64 {
+			whm.mutex.Lock()
+			defer whm.mutex.Unlock()
+
+			total := 0
+			for _, health := range whm.reports {
+				total += health.unprovenTransactions
+			}
+
+			return float64(total)
+		},
+		"wallet_health_redemptions_near_timeout": func() float64 {
+			whm.mutex.Lock()
+			defer whm.mutex.Unlock()
+
+			total := 0
+			for _, health := range whm.reports {
+				total += health.redemptionsNearTimeout
+			}
+
+			return float64(total)
+		},
+		"wallet_health_heartbeat_failures_max": func() float64 {
+			whm.mutex.Lock()
+			defer whm.mutex.Unlock()
+
+			max := uint(0)
+			for _, health := range whm.reports {
+				if health.heartbeatFailures > max {
+					max = health.heartbeatFailures
+				}
+			}
+
+			return float64(max)
+		},
+		"wallet_health_moving_funds_deadline_near_wallets": withIssue(
+			signalMovingFundsDeadline,
+		),
+		"wallet_health_max_age_near_wallets": withIssue(signalMaxAge),
+	}
+}
+
+// diagnostics returns the latest health evaluation of all wallets.
+func (whm *walletHealthMonitor) diagnostics() clientinfo.ApplicationInfo {
+	whm.mutex.Lock()
+	defer whm.mutex.Unlock()
+
+	type walletInfo struct {
+		State                       string            `json:"state"`
+		CheckedAt                   int64             `json:"checked_at"`
+		MainUtxoSynced              bool              `json:"main_utxo_synced"`
+		UnprovenTransactions        int               `json:"unproven_transactions"`
+		OldestUnprovenTransactionAt int64             `json:"oldest_unproven_transaction_at,omitempty"`
+		RedemptionsNearTimeout      int               `json:"redemptions_near_timeout"`
+		HeartbeatFailures           uint              `json:"heartbeat_failures"`
+		MovingFundsDeadline         int64             `json:"moving_funds_deadline,omitempty"`
+		MaxAgeReachedAt             int64             `json:"max_age_reached_at,omitempty"`
+		Issues                      map[string]string `json:"issues"`
+		FailedChecks                map[string]string `json:"failed_checks"`
+	}
+
+	unixOrZero := func(t time.Time) int64 {
+		if t.IsZero() {
+			return 0
+		}
+		return t.Unix()
+	}
+
+	wallets := make(map[string]walletInfo)
+
+	for key, health := range whm.reports {
+		info := walletInfo{
+			State:                       health.state.String(),
+			CheckedAt:                   health.checkedAt.Unix(),
+			MainUtxoSynced:              health.mainUtxoSynced,
+			UnprovenTransactions:        health.unprovenTransactions,
+			OldestUnprovenTransactionAt: unixOrZero(health.oldestUnprovenTransactionAt),
+			RedemptionsNearTimeout:      health.redemptionsNearTimeout,
+			HeartbeatFailures:           health.heartbeatFailures,
+			MovingFundsDeadline:         unixOrZero(health.movingFundsDeadline),
+			MaxAgeReachedAt:             unixOrZero(health.maxAgeReachedAt),
+			Issues:                      make(map[string]string),
+			FailedChecks:                make(map[string]string),
+		}
+
+		for signal, issue := range health.issues {
+			info.Issues[string(signal)] = issue
+		}
+
+		for signal, err := range health.failedChecks {
+			info.FailedChecks[string(signal)] = err.Error()
+		}
+
+		wallets["0x"+key] = info
+	}
+
+	return clientinfo.ApplicationInfo{
+		"wallet_health": wallets,
+	}
+}
+
+// unprovenWalletTransactions returns tracked wallet transactions that are
+// not yet proven in the Bridge, i.e. spend the wallet's current main UTXO
+// or outputs of other unproven transactions. Transactions are expected to
+// be sorted by the broadcast time in the ascending order. If the wallet
+// has no main UTXO, all tracked transactions are considered unproven.
+func unprovenWalletTransactions(
+	transactions []*TrackedTransaction,
+	mainUtxo *bitcoin.UnspentTransactionOutput,
+) []*TrackedTransaction {
+	if mainUtxo == nil {
+		return transactions
+	}
+
+	unprovenOutpoints := map[bitcoin.TransactionOutpoint]bool{
+		*mainUtxo.Outpoint: true,
+	}
+
+	unproven := make([]*TrackedTransaction, 0)
+
+	for _, tracked := range transactions {
+		spendsUnproven := false
+		for _, input := range tracked.Transaction.Inputs {
+			if unprovenOutpoints[*input.Outpoint] {
+				spendsUnproven = true
+				break
+			}
+		}
+
+		if !spendsUnproven {
+			continue
+		}
+
+		unproven = append(unproven, tracked)
+
+		transactionHash := tracked.Transaction.Hash()
+		for outputIndex := range tracked.Transaction.Outputs {
+			unprovenOutpoints[bitcoin.TransactionOutpoint{
+				TransactionHash: transactionHash,
+				OutputIndex:     uint32(outputIndex),
+			}] = true
+		}
+	}
+
+	return unproven
+}
+
+// sortedWalletHealthSignals returns keys of the given map in the
+// alphabetical order.
+func sortedWalletHealthSignals[V any](
+	signals map[walletHealthSignal]V,
+) []walletHealthSignal {
+	sorted := make([]walletHealthSignal, 0, len(signals))
+	for signal := range signals {
+		sorted = append(sorted, signal)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted
+}
+
+// walletHealthAlert is the payload of a wallet health webhook alert.
+type walletHealthAlert struct {
+	Wallet    string `json:"wallet"`
+	Signal    string `json:"signal"`
+	Message   string `json:"message"`
+	Resolved  bool   `json:"resolved"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func newWalletHealthAlert(
+	health *walletHealth,
+	signal walletHealthSignal,
+	message string,
+	resolved bool,
+) *walletHealthAlert {
+	return &walletHealthAlert{
+		Wallet:    fmt.Sprintf("0x%x", health.walletPublicKeyHash),
+		Signal:    string(signal),
+		Message:   message,
+		Resolved:  resolved,
+		Timestamp: health.checkedAt.Unix(),
+	}
+}
+
+// walletHealthAlerter delivers wallet health alerts to a webhook as
+// JSON-encoded HTTP POST requests.
+type walletHealthAlerter struct {
+	url    string
+	client *http.Client
+}
+
+// newWalletHealthAlerter creates a new alerter for the given webhook URL.
+// Returns nil if the URL is empty which means alerts are disabled.
+func newWalletHealthAlerter(url string) *walletHealthAlerter {
+	if url == "" {
+		return nil
+	}
+
+	return &walletHealthAlerter{
+		url:    url,
+		client: &http.Client{Timeout: walletHealthAlertTimeout},
+	}
+}
+
+// send delivers the given alert to the webhook.
+func (wha *walletHealthAlerter) send(alert *walletHealthAlert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("cannot marshal alert: [%w]", err)
+	}
+
+	response, err := wha.client.Post(
+		wha.url,
+		"application/json",
+		bytes.NewReader(payload),
+	)
+	if err != nil {
+		return fmt.Errorf("cannot post alert: [%w]", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf(
+			"unexpected webhook response status [%v]",
+			response.StatusCode,
+		)
+	}
+
+	return nil
+}
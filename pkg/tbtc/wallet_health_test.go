@@ -0,0 +1,642 @@
+package tbtc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+)
+
+func TestWalletHealthMonitor_EvaluateWallet_MovingFunds(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	localChain := Connect()
+	walletPublicKey := createMockSigner(t).wallet.publicKey
+	walletPublicKeyHash := bitcoin.PublicKeyHash(walletPublicKey)
+
+	localChain.setWallet(walletPublicKeyHash, &WalletChainData{
+		State:                  StateMovingFunds,
+		CreatedAt:              now.Add(-200 * 24 * time.Hour),
+		MovingFundsRequestedAt: now.Add(-6 * 24 * time.Hour),
+	})
+	localChain.setWalletParameters(0, 0, 0, 0, 180*24*60*60, 0, 0)
+	localChain.SetMovingFundsParameters(
+		0, 0, 0, 7*24*60*60, nil, 0, 0, 0, 0, nil, 0,
+	)
+
+	redemptionTimeout := 5 * 24 * time.Hour
+	localChain.SetRedemptionParameters(
+		0, 0, 0, 0, uint32(redemptionTimeout.Seconds()), nil, 0,
+	)
+	localChain.setBlockNumberByTimestamp(
+		uint64(now.Add(-redemptionTimeout).Unix()),
+		1000,
+	)
+	blockCounter := &mockBlockCounter{currentBlock: 1100}
+	localChain.blockCounter = blockCounter
+
+	nearTimeoutScript := bitcoin.Script{0x01}
+	farFromTimeoutScript := bitcoin.Script{0x02}
+	handledScript := bitcoin.Script{0x03}
+
+	endBlock := uint64(1100)
+	err := localChain.setPastRedemptionRequestedEvents(
+		&RedemptionRequestedEventFilter{
+			StartBlock:          1000,
+			EndBlock:            &endBlock,
+			WalletPublicKeyHash: [][20]byte{walletPublicKeyHash},
+		},
+		[]*RedemptionRequestedEvent{
+			{RedeemerOutputScript: nearTimeoutScript, BlockNumber: 1001},
+			{RedeemerOutputScript: farFromTimeoutScript, BlockNumber: 1050},
+			{RedeemerOutputScript: handledScript, BlockNumber: 1060},
+			{RedeemerOutputScript: nearTimeoutScript, BlockNumber: 1070},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	localChain.setPendingRedemptionRequest(walletPublicKeyHash, &RedemptionRequest{
+		RedeemerOutputScript: nearTimeoutScript,
+		RequestedAt:          now.Add(-redemptionTimeout + 12*time.Hour),
+	})
+	localChain.setPendingRedemptionRequest(walletPublicKeyHash, &RedemptionRequest{
+		RedeemerOutputScript: farFromTimeoutScript,
+		RequestedAt:          now.Add(-24 * time.Hour),
+	})
+
+	// The wallet has no main UTXO so any tracked transaction is unproven.
+	tracker := newWalletTransactionTracker(&mockPersistenceHandle{})
+	err = tracker.track(
+		walletPublicKeyHash,
+		ActionDepositSweep,
+		newWalletHealthTestTransaction(bitcoin.Hash{0x01}),
+		now.Add(-13*time.Hour),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walletPublicKeyBytes, err := marshalPublicKey(walletPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failureCounter := newHeartbeatFailureCounter()
+	for i := 0; i < heartbeatConsecutiveFailureThreshold; i++ {
+		failureCounter.increment(hex.EncodeToString(walletPublicKeyBytes))
+	}
+
+	monitor := newWalletHealthMonitor(
+		localChain,
+		newLocalBitcoinChain(),
+		nil,
+		tracker,
+		failureCounter,
+		nil,
+	)
+
+	health := monitor.evaluateWallet(walletPublicKey, now)
+
+	if len(health.failedChecks) > 0 {
+		t.Fatalf("unexpected failed checks: [%v]", health.failedChecks)
+	}
+
+	testutils.AssertBoolsEqual(t, "main UTXO synced", true, health.mainUtxoSynced)
+	testutils.AssertIntsEqual(
+		t,
+		"unproven transactions",
+		1,
+		health.unprovenTransactions,
+	)
+	testutils.AssertIntsEqual(
+		t,
+		"redemptions near timeout",
+		1,
+		health.redemptionsNearTimeout,
+	)
+	testutils.AssertUintsEqual(
+		t,
+		"heartbeat failures",
+		heartbeatConsecutiveFailureThreshold,
+		uint64(health.heartbeatFailures),
+	)
+	testutils.AssertStringsEqual(
+		t,
+		"moving funds deadline",
+		now.Add(24*time.Hour).String(),
+		health.movingFundsDeadline.String(),
+	)
+
+	// The wallet exceeded the maximum age but is already moving funds so
+	// it is not reported.
+	assertWalletHealthIssues(
+		t,
+		[]walletHealthSignal{
+			signalHeartbeatFailures,
+			signalMovingFundsDeadline,
+			signalRedemptionsNearTimeout,
+			signalUnprovenTransactions,
+		},
+		health,
+	)
+
+	// The next evaluation scans only blocks mined since the last one.
+	nextCheck := now.Add(walletHealthCheckPeriod)
+	localChain.setBlockNumberByTimestamp(
+		uint64(nextCheck.Add(-redemptionTimeout).Unix()),
+		1010,
+	)
+	blockCounter.setCurrentBlock(1150)
+
+	newScript := bitcoin.Script{0x04}
+	nextEndBlock := uint64(1150)
+	err = localChain.setPastRedemptionRequestedEvents(
+		&RedemptionRequestedEventFilter{
+			StartBlock:          1101,
+			EndBlock:            &nextEndBlock,
+			WalletPublicKeyHash: [][20]byte{walletPublicKeyHash},
+		},
+		[]*RedemptionRequestedEvent{
+			{RedeemerOutputScript: newScript, BlockNumber: 1120},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	localChain.setPendingRedemptionRequest(walletPublicKeyHash, &RedemptionRequest{
+		RedeemerOutputScript: newScript,
+		RequestedAt:          nextCheck.Add(-redemptionTimeout + time.Hour),
+	})
+
+	health = monitor.evaluateWallet(walletPublicKey, nextCheck)
+
+	if err, failed := health.failedChecks[signalRedemptionsNearTimeout]; failed {
+		t.Fatalf("unexpected failed check: [%v]", err)
+	}
+
+	testutils.AssertIntsEqual(
+		t,
+		"redemptions near timeout",
+		2,
+		health.redemptionsNearTimeout,
+	)
+}
+
+func TestWalletHealthMonitor_EvaluateWallet_MaxAge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	localChain := Connect()
+	walletPublicKey := createMockSigner(t).wallet.publicKey
+	walletPublicKeyHash := bitcoin.PublicKeyHash(walletPublicKey)
+
+	localChain.setWallet(walletPublicKeyHash, &WalletChainData{
+		State:     StateLive,
+		CreatedAt: now.Add(-175 * 24 * time.Hour),
+	})
+	localChain.setWalletParameters(0, 0, 0, 0, 180*24*60*60, 0, 0)
+	localChain.SetRedemptionParameters(0, 0, 0, 0, 0, nil, 0)
+	localChain.setBlockNumberByTimestamp(uint64(now.Unix()), 1000)
+	localChain.blockCounter = &mockBlockCounter{currentBlock: 1000}
+
+	endBlock := uint64(1000)
+	err := localChain.setPastRedemptionRequestedEvents(
+		&RedemptionRequestedEventFilter{
+			StartBlock:          1000,
+			EndBlock:            &endBlock,
+			WalletPublicKeyHash: [][20]byte{walletPublicKeyHash},
+		},
+		[]*RedemptionRequestedEvent{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := newWalletHealthMonitor(
+		localChain,
+		newLocalBitcoinChain(),
+		nil,
+		newWalletTransactionTracker(&mockPersistenceHandle{}),
+		newHeartbeatFailureCounter(),
+		nil,
+	)
+
+	health := monitor.evaluateWallet(walletPublicKey, now)
+
+	if len(health.failedChecks) > 0 {
+		t.Fatalf("unexpected failed checks: [%v]", health.failedChecks)
+	}
+
+	assertWalletHealthIssues(
+		t,
+		[]walletHealthSignal{signalMaxAge},
+		health,
+	)
+}
+
+func TestWalletHealthMonitor_EvaluateWallet_ChainDataUnavailable(t *testing.T) {
+	walletPublicKey := createMockSigner(t).wallet.publicKey
+
+	monitor := newWalletHealthMonitor(
+		Connect(),
+		newLocalBitcoinChain(),
+		nil,
+		newWalletTransactionTracker(&mockPersistenceHandle{}),
+		newHeartbeatFailureCounter(),
+		nil,
+	)
+
+	health := monitor.evaluateWallet(walletPublicKey, time.Now())
+
+	assertWalletHealthIssues(t, []walletHealthSignal{}, health)
+
+	// All signals except heartbeat failures depend on the chain data.
+	testutils.AssertIntsEqual(t, "failed checks", 5, len(health.failedChecks))
+	if _, ok := health.failedChecks[signalHeartbeatFailures]; ok {
+		t.Errorf("heartbeat failures should be evaluated")
+	}
+}
+
+func TestUnprovenWalletTransactions(t *testing.T) {
+	mainUtxoTransactionHash := bitcoin.Hash{0x01}
+
+	// Proven transaction whose output became the main UTXO.
+	provenTransaction := newWalletHealthTestTransaction(bitcoin.Hash{0x00})
+	// Spends the main UTXO.
+	firstTransaction := newWalletHealthTestTransaction(mainUtxoTransactionHash)
+	// Spends the output of the first transaction.
+	secondTransaction := newWalletHealthTestTransaction(firstTransaction.Hash())
+	// Unrelated to the main UTXO.
+	unrelatedTransaction := newWalletHealthTestTransaction(bitcoin.Hash{0x02})
+
+	transactions := make([]*TrackedTransaction, 0)
+	for _, transaction := range []*bitcoin.Transaction{
+		provenTransaction,
+		firstTransaction,
+		unrelatedTransaction,
+		secondTransaction,
+	} {
+		transactions = append(transactions, &TrackedTransaction{
+			Transaction: transaction,
+		})
+	}
+
+	var tests = map[string]struct {
+		mainUtxo         *bitcoin.UnspentTransactionOutput
+		expectedUnproven []*bitcoin.Transaction
+	}{
+		"wallet with main UTXO": {
+			mainUtxo: &bitcoin.UnspentTransactionOutput{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: mainUtxoTransactionHash,
+					OutputIndex:     0,
+				},
+			},
+			expectedUnproven: []*bitcoin.Transaction{
+				firstTransaction,
+				secondTransaction,
+			},
+		},
+		"wallet without main UTXO": {
+			mainUtxo: nil,
+			expectedUnproven: []*bitcoin.Transaction{
+				provenTransaction,
+				firstTransaction,
+				unrelatedTransaction,
+				secondTransaction,
+			},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			unproven := unprovenWalletTransactions(transactions, test.mainUtxo)
+
+			expectedHashes := make([]string, 0)
+			for _, transaction := range test.expectedUnproven {
+				expectedHashes = append(expectedHashes, transaction.Hash().String())
+			}
+
+			actualHashes := make([]string, 0)
+			for _, tracked := range unproven {
+				actualHashes = append(actualHashes, tracked.Transaction.Hash().String())
+			}
+
+			if !reflect.DeepEqual(expectedHashes, actualHashes) {
+				t.Errorf(
+					"unexpected unproven transactions\n"+
+						"expected: [%v]\nactual:   [%v]",
+					expectedHashes,
+					actualHashes,
+				)
+			}
+		})
+	}
+}
+
+func TestWalletHealthMonitor_SendAlerts(t *testing.T) {
+	var mutex sync.Mutex
+	alerts := make([]*walletHealthAlert, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			alert := &walletHealthAlert{}
+			if err := json.NewDecoder(request.Body).Decode(alert); err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			mutex.Lock()
+			alerts = append(alerts, alert)
+			mutex.Unlock()
+
+			writer.WriteHeader(http.StatusNoContent)
+		},
+	))
+	defer server.Close()
+
+	monitor := newWalletHealthMonitor(
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		newWalletHealthAlerter(server.URL),
+	)
+
+	newHealth := func(
+		issues map[walletHealthSignal]string,
+		failedChecks map[walletHealthSignal]error,
+	) *walletHealth {
+		return &walletHealth{
+			walletPublicKeyHash: [20]byte{0xaa},
+			checkedAt:           time.Unix(1700000000, 0),
+			issues:              issues,
+			failedChecks:        failedChecks,
+		}
+	}
+
+	// Consumes alerts received so far and returns them in the
+	// `<signal>:<resolved>` form.
+	takeAlerts := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		result := make([]string, 0)
+		for _, alert := range alerts {
+			testutils.AssertStringsEqual(
+				t,
+				"alerted wallet",
+				"0xaa00000000000000000000000000000000000000",
+				alert.Wallet,
+			)
+
+			status := "raised"
+			if alert.Resolved {
+				status = "resolved"
+			}
+
+			result = append(result, alert.Signal+":"+status)
+		}
+		alerts = alerts[:0]
+
+		return result
+	}
+
+	key := "aa00000000000000000000000000000000000000"
+
+	monitor.sendAlerts(key, newHealth(
+		map[walletHealthSignal]string{
+			signalHeartbeatFailures: "3 failures",
+			signalMainUtxoSync:      "not synced",
+		},
+		map[walletHealthSignal]error{},
+	))
+	assertAlerts(
+		t,
+		[]string{"heartbeat_failures:raised", "main_utxo_sync:raised"},
+		takeAlerts(),
+	)
+
+	// Issues that were already alerted should not be repeated.
+	monitor.sendAlerts(key, newHealth(
+		map[walletHealthSignal]string{
+			signalHeartbeatFailures: "4 failures",
+			signalMainUtxoSync:      "not synced",
+		},
+		map[walletHealthSignal]error{},
+	))
+	assertAlerts(t, []string{}, takeAlerts())
+
+	// Issues whose signals could not be evaluated should not be resolved.
+	monitor.sendAlerts(key, newHealth(
+		map[walletHealthSignal]string{},
+		map[walletHealthSignal]error{
+			signalMainUtxoSync: errWalletBusy,
+		},
+	))
+	assertAlerts(t, []string{"heartbeat_failures:resolved"}, takeAlerts())
+
+	monitor.sendAlerts(key, newHealth(
+		map[walletHealthSignal]string{},
+		map[walletHealthSignal]error{},
+	))
+	assertAlerts(t, []string{"main_utxo_sync:resolved"}, takeAlerts())
+}
+
+func TestWalletHealthMonitor_SendAlerts_Undelivered(t *testing.T) {
+	var mutex sync.Mutex
+	failing := true
+	delivered := make([]string, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if failing {
+				writer.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			alert := &walletHealthAlert{}
+			if err := json.NewDecoder(request.Body).Decode(alert); err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			delivered = append(
+				delivered,
+				fmt.Sprintf("%s:%v", alert.Signal, alert.Resolved),
+			)
+
+			writer.WriteHeader(http.StatusNoContent)
+		},
+	))
+	defer server.Close()
+
+	setFailing := func(value bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		failing = value
+	}
+
+	takeDelivered := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		result := delivered
+		delivered = make([]string, 0)
+		return result
+	}
+
+	monitor := newWalletHealthMonitor(
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		newWalletHealthAlerter(server.URL),
+	)
+
+	key := "aa00000000000000000000000000000000000000"
+	withIssue := &walletHealth{
+		walletPublicKeyHash: [20]byte{0xaa},
+		issues: map[walletHealthSignal]string{
+			signalMainUtxoSync: "not synced",
+		},
+		failedChecks: map[walletHealthSignal]error{},
+	}
+	withoutIssue := &walletHealth{
+		walletPublicKeyHash: [20]byte{0xaa},
+		issues:              map[walletHealthSignal]string{},
+		failedChecks:        map[walletHealthSignal]error{},
+	}
+
+	// An undelivered alert is sent again on the next evaluation.
+	monitor.sendAlerts(key, withIssue)
+	assertAlerts(t, []string{}, takeDelivered())
+
+	setFailing(false)
+	monitor.sendAlerts(key, withIssue)
+	assertAlerts(t, []string{"main_utxo_sync:false"}, takeDelivered())
+
+	// An undelivered resolution is sent again on the next evaluation.
+	setFailing(true)
+	monitor.sendAlerts(key, withoutIssue)
+	assertAlerts(t, []string{}, takeDelivered())
+
+	setFailing(false)
+	monitor.sendAlerts(key, withoutIssue)
+	assertAlerts(t, []string{"main_utxo_sync:true"}, takeDelivered())
+
+	monitor.sendAlerts(key, withoutIssue)
+	assertAlerts(t, []string{}, takeDelivered())
+}
+
+func TestNewWalletHealthAlerter_Disabled(t *testing.T) {
+	if alerter := newWalletHealthAlerter(""); alerter != nil {
+		t.Errorf("alerter should be disabled")
+	}
+}
+
+func newWalletHealthTestTransaction(spentHash bitcoin.Hash) *bitcoin.Transaction {
+	return &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: spentHash,
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{
+				Value:           1000,
+				PublicKeyScript: []byte{0x00, 0x14},
+			},
+		},
+	}
+}
+
+func assertWalletHealthIssues(
+	t *testing.T,
+	expectedSignals []walletHealthSignal,
+	health *walletHealth,
+) {
+	actualSignals := sortedWalletHealthSignals(health.issues)
+
+	if len(expectedSignals) == 0 && len(actualSignals) == 0 {
+		return
+	}
+
+	if !reflect.DeepEqual(expectedSignals, actualSignals) {
+		t.Errorf(
+			"unexpected issues\nexpected: [%v]\nactual:   [%v]\nissues: [%v]",
+			expectedSignals,
+			actualSignals,
+			health.issues,
+		)
+	}
+}
+
+func assertAlerts(t *testing.T, expected []string, actual []string) {
+	if len(expected) == 0 && len(actual) == 0 {
+		return
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf(
+			"unexpected alerts\nexpected: [%v]\nactual:   [%v]",
+			expected,
+			actual,
+		)
+	}
+}
+
+type mockBlockCounter struct {
+	mutex        sync.Mutex
+	currentBlock uint64
+}
+
+func (mbc *mockBlockCounter) WaitForBlockHeight(blockNumber uint64) error {
+	panic("unsupported")
+}
+
+func (mbc *mockBlockCounter) BlockHeightWaiter(blockNumber uint64) (
+	<-chan uint64,
+	error,
+) {
+	panic("unsupported")
+}
+
+func (mbc *mockBlockCounter) CurrentBlock() (uint64, error) {
+	mbc.mutex.Lock()
+	defer mbc.mutex.Unlock()
+
+	return mbc.currentBlock, nil
+}
+
+func (mbc *mockBlockCounter) setCurrentBlock(block uint64) {
+	mbc.mutex.Lock()
+	defer mbc.mutex.Unlock()
+
+	mbc.currentBlock = block
+}
+
+func (mbc *mockBlockCounter) WatchBlocks(ctx context.Context) <-chan uint64 {
+	panic("unsupported")
+}
@@ -51,9 +51,21 @@ func TestParseWalletActionType(t *testing.T) {
 			value:          5,
 			expectedAction: ActionMovedFundsSweep,
 		},
+		"rbf": {
+			value:          6,
+			expectedAction: ActionRbf,
+		},
+		"cpfp": {
+			value:          7,
+			expectedAction: ActionCpfp,
+		},
+		"key share refresh": {
+			value:          8,
+			expectedAction: ActionKeyShareRefresh,
+		},
 		"unknown": {
-			value:       6,
-			expectedErr: fmt.Errorf("unknown wallet action type [6]"),
+			value:       9,
+			expectedErr: fmt.Errorf("unknown wallet action type [9]"),
 		},
 	}
 
@@ -123,31 +135,31 @@ func TestWalletDispatcher_Dispatch(t *testing.T) {
 	}
 
 	// Dispatch Action 1 for Wallet 1.
-	err := walletDispatcher.dispatch(wallet1Action1)
+	err := walletDispatcher.dispatch(wallet1Action1, nil)
 	if err != nil {
 		t.Errorf("unexpected error: [%v]", err)
 	}
 
 	// Another Action 1 for Wallet 2.
-	err = walletDispatcher.dispatch(wallet2Action1)
+	err = walletDispatcher.dispatch(wallet2Action1, nil)
 	if err != nil {
 		t.Errorf("unexpected error: [%v]", err)
 	}
 
 	// Try to dispatch Action 1 for Wallet 1 again.
-	err = walletDispatcher.dispatch(wallet1Action1)
+	err = walletDispatcher.dispatch(wallet1Action1, nil)
 	testutils.AssertErrorsSame(t, errWalletBusy, err)
 
 	// Try to dispatch Action 1 for Wallet 2 again.
-	err = walletDispatcher.dispatch(wallet2Action1)
+	err = walletDispatcher.dispatch(wallet2Action1, nil)
 	testutils.AssertErrorsSame(t, errWalletBusy, err)
 
 	// Try to dispatch Action 2 for Wallet 1.
-	err = walletDispatcher.dispatch(wallet1Action2)
+	err = walletDispatcher.dispatch(wallet1Action2, nil)
 	testutils.AssertErrorsSame(t, errWalletBusy, err)
 
 	// Try to dispatch Action 2 for Wallet 2.
-	err = walletDispatcher.dispatch(wallet2Action2)
+	err = walletDispatcher.dispatch(wallet2Action2, nil)
 	testutils.AssertErrorsSame(t, errWalletBusy, err)
 
 	// Complete dispatched actions.
@@ -158,13 +170,13 @@ func TestWalletDispatcher_Dispatch(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	// Dispatch Action 2 for Wallet 1.
-	err = walletDispatcher.dispatch(wallet1Action2)
+	err = walletDispatcher.dispatch(wallet1Action2, nil)
 	if err != nil {
 		t.Errorf("unexpected error: [%v]", err)
 	}
 
 	// Dispatch Action 2 for Wallet 2.
-	err = walletDispatcher.dispatch(wallet2Action2)
+	err = walletDispatcher.dispatch(wallet2Action2, nil)
 	if err != nil {
 		t.Errorf("unexpected error: [%v]", err)
 	}
@@ -431,7 +443,7 @@ func (mwse *mockWalletSigningExecutor) signBatch(
 	ctx context.Context,
 	messages []*big.Int,
 	startBlock uint64,
-) ([]*tecdsa.Signature, error) {
+) ([]*tecdsa.Signature, []*signingActivityReport, error) {
 	mwse.signaturesMutex.Lock()
 	defer mwse.signaturesMutex.Unlock()
 
@@ -439,10 +451,10 @@ func (mwse *mockWalletSigningExecutor) signBatch(
 
 	signatures, ok := mwse.signatures[key]
 	if !ok {
-		return nil, fmt.Errorf("signing error")
+		return nil, nil, fmt.Errorf("signing error")
 	}
 
-	return signatures, nil
+	return signatures, nil, nil
 }
 
 func (mwse *mockWalletSigningExecutor) setSignatures(
@@ -1,6 +1,7 @@
 package tbtcpg
 
 import (
+	"bytes"
 	"fmt"
 	"sync"
 
@@ -13,6 +14,7 @@ type LocalBitcoinChain struct {
 	transactions              map[bitcoin.Hash]*bitcoin.Transaction
 	transactionsConfirmations map[bitcoin.Hash]uint
 	satPerVByteFeeEstimation  map[uint32]int64
+	mempool                   []*bitcoin.Transaction
 }
 
 func NewLocalBitcoinChain() *LocalBitcoinChain {
@@ -102,13 +104,78 @@ func (lbc *LocalBitcoinChain) GetTransactionsForPublicKeyHash(
 func (lbc *LocalBitcoinChain) GetTxHashesForPublicKeyHash(
 	publicKeyHash [20]byte,
 ) ([]bitcoin.Hash, error) {
-	panic("unsupported")
+	lbc.mutex.Lock()
+	defer lbc.mutex.Unlock()
+
+	matchingTxHashes := make([]bitcoin.Hash, 0)
+
+	for transactionHash, transaction := range lbc.transactions {
+		paysToWallet, err := paysToPublicKeyHash(transaction, publicKeyHash)
+		if err != nil {
+			return nil, err
+		}
+
+		if paysToWallet {
+			matchingTxHashes = append(matchingTxHashes, transactionHash)
+		}
+	}
+
+	return matchingTxHashes, nil
 }
 
 func (lbc *LocalBitcoinChain) GetMempoolForPublicKeyHash(
 	publicKeyHash [20]byte,
 ) ([]*bitcoin.Transaction, error) {
-	panic("unsupported")
+	lbc.mutex.Lock()
+	defer lbc.mutex.Unlock()
+
+	matchingTransactions := make([]*bitcoin.Transaction, 0)
+
+	for _, transaction := range lbc.mempool {
+		paysToWallet, err := paysToPublicKeyHash(transaction, publicKeyHash)
+		if err != nil {
+			return nil, err
+		}
+
+		if paysToWallet {
+			matchingTransactions = append(matchingTransactions, transaction)
+		}
+	}
+
+	return matchingTransactions, nil
+}
+
+func (lbc *LocalBitcoinChain) AddMempoolTransaction(
+	transaction *bitcoin.Transaction,
+) {
+	lbc.mutex.Lock()
+	defer lbc.mutex.Unlock()
+
+	lbc.mempool = append(lbc.mempool, transaction)
+}
+
+func paysToPublicKeyHash(
+	transaction *bitcoin.Transaction,
+	publicKeyHash [20]byte,
+) (bool, error) {
+	p2pkh, err := bitcoin.PayToPublicKeyHash(publicKeyHash)
+	if err != nil {
+		return false, err
+	}
+
+	p2wpkh, err := bitcoin.PayToWitnessPublicKeyHash(publicKeyHash)
+	if err != nil {
+		return false, err
+	}
+
+	for _, output := range transaction.Outputs {
+		script := output.PublicKeyScript
+		if bytes.Equal(script, p2pkh) || bytes.Equal(script, p2wpkh) {
+			return true, nil
+		}
+	}
+
+	return false, nil
 }
 
 func (lbc *LocalBitcoinChain) GetUtxosForPublicKeyHash(
@@ -23,19 +23,6 @@ type Chain interface {
 		filter *tbtc.NewWalletRegisteredEventFilter,
 	) ([]*tbtc.NewWalletRegisteredEvent, error)
 
-	// GetWalletParameters gets the current value of parameters relevant to
-	// wallet.
-	GetWalletParameters() (
-		creationPeriod uint32,
-		creationMinBtcBalance uint64,
-		creationMaxBtcBalance uint64,
-		closureMinBtcBalance uint64,
-		maxAge uint32,
-		maxBtcTransfer uint64,
-		closingPeriod uint32,
-		err error,
-	)
-
 	// GetLiveWalletsCount gets the current count of live wallets.
 	GetLiveWalletsCount() (uint32, error)
 
@@ -43,24 +30,6 @@ type Chain interface {
 	// which is a unique identifier for a deposit on-chain.
 	BuildDepositKey(fundingTxHash bitcoin.Hash, fundingOutputIndex uint32) *big.Int
 
-	// GetDepositParameters gets the current value of parameters relevant
-	// for the depositing process.
-	GetDepositParameters() (
-		dustThreshold uint64,
-		treasuryFeeDivisor uint64,
-		txMaxFee uint64,
-		revealAheadPeriod uint32,
-		err error,
-	)
-
-	// PastRedemptionRequestedEvents fetches past redemption requested events according
-	// to the provided filter or unfiltered if the filter is nil. Returned
-	// events are sorted by the block number in the ascending order, i.e. the
-	// latest event is at the end of the slice.
-	PastRedemptionRequestedEvents(
-		filter *tbtc.RedemptionRequestedEventFilter,
-	) ([]*tbtc.RedemptionRequestedEvent, error)
-
 	// BuildRedemptionKey calculates a redemption key for the given redemption
 	// request which is an identifier for a redemption at the given time
 	// on-chain.
@@ -69,19 +38,6 @@ type Chain interface {
 		redeemerOutputScript bitcoin.Script,
 	) (*big.Int, error)
 
-	// GetRedemptionParameters gets the current value of parameters relevant
-	// for the redemption process.
-	GetRedemptionParameters() (
-		dustThreshold uint64,
-		treasuryFeeDivisor uint64,
-		txMaxFee uint64,
-		txMaxTotalFee uint64,
-		timeout uint32,
-		timeoutSlashingAmount *big.Int,
-		timeoutNotifierRewardMultiplier uint32,
-		err error,
-	)
-
 	// GetRedemptionMaxSize gets the maximum number of redemption requests that
 	// can be a part of a redemption sweep proposal.
 	GetRedemptionMaxSize() (uint16, error)
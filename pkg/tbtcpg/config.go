@@ -0,0 +1,70 @@
+package tbtcpg
+
+import (
+	"time"
+
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+// Config holds configurable properties of the proposal generator.
+type Config struct {
+	// DepositSweepConfirmationTarget is the number of Bitcoin blocks within
+	// which deposit sweep transactions are supposed to be confirmed.
+	DepositSweepConfirmationTarget uint32
+	// RedemptionConfirmationTarget is the number of Bitcoin blocks within
+	// which redemption transactions are supposed to be confirmed.
+	RedemptionConfirmationTarget uint32
+	// MovingFundsConfirmationTarget is the number of Bitcoin blocks within
+	// which moving funds transactions are supposed to be confirmed.
+	MovingFundsConfirmationTarget uint32
+	// MovedFundsSweepConfirmationTarget is the number of Bitcoin blocks
+	// within which moved funds sweep transactions are supposed to be
+	// confirmed.
+	MovedFundsSweepConfirmationTarget uint32
+	// FeeBumpConfirmationTarget is the number of Bitcoin blocks within which
+	// stuck transactions are supposed to be confirmed after a replace-by-fee
+	// or child-pays-for-parent fee bump.
+	FeeBumpConfirmationTarget uint32
+	// DepositSweepMaxFeeShare is the maximum share of the total swept amount
+	// the deposit sweep transaction fee can consume for the sweep to be
+	// considered economical. Non-urgent sweeps above this share are deferred.
+	// If not set, DefaultDepositSweepMaxFeeShare is used.
+	DepositSweepMaxFeeShare float64
+	// DepositSweepMaxWait is the maximum time a deposit should wait for
+	// a sweep. Once exceeded, the sweep is proposed regardless of its
+	// economics. If not set, DefaultDepositSweepMaxWait is used.
+	DepositSweepMaxWait time.Duration
+	// DepositRefundSafetyMargin is the minimum time that must remain until
+	// the deposit refund locktime for the deposit to be swept. It should
+	// match the margin enforced by the WalletProposalValidator contract.
+	// If not set, DefaultDepositRefundSafetyMargin is used.
+	DepositRefundSafetyMargin time.Duration
+	// RedemptionFeeSharesEnabled determines whether redemption proposals
+	// split the fee over requests proportionally to their maximum fees.
+	// Clients that do not support fee shares split the fee evenly and
+	// build a different transaction so the option must be enabled only
+	// once all operators of the wallet run a client version supporting
+	// them. If disabled, the fee is split evenly.
+	RedemptionFeeSharesEnabled bool
+}
+
+// ConfirmationTargets returns confirmation targets of specific action types.
+// Action types whose targets are not set are omitted.
+func (c *Config) ConfirmationTargets() map[tbtc.WalletActionType]uint32 {
+	targets := map[tbtc.WalletActionType]uint32{
+		tbtc.ActionDepositSweep:    c.DepositSweepConfirmationTarget,
+		tbtc.ActionRedemption:      c.RedemptionConfirmationTarget,
+		tbtc.ActionMovingFunds:     c.MovingFundsConfirmationTarget,
+		tbtc.ActionMovedFundsSweep: c.MovedFundsSweepConfirmationTarget,
+		tbtc.ActionRbf:             c.FeeBumpConfirmationTarget,
+		tbtc.ActionCpfp:            c.FeeBumpConfirmationTarget,
+	}
+
+	for actionType, target := range targets {
+		if target == 0 {
+			delete(targets, actionType)
+		}
+	}
+
+	return targets
+}
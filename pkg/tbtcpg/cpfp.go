@@ -0,0 +1,498 @@
+package tbtcpg
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ipfs/go-log/v2"
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+// CpfpTask is a task that may produce a child-pays-for-parent proposal for
+// a wallet transaction stuck in the Bitcoin mempool. The child transaction
+// handles pending redemption requests or, if there are none, sweeps
+// deposits, spending the change of the stuck parent transaction.
+type CpfpTask struct {
+	chain        Chain
+	btcChain     bitcoin.Chain
+	feeEstimator FeeEstimator
+
+	redemptionTask   *RedemptionTask
+	depositSweepTask *DepositSweepTask
+}
+
+func NewCpfpTask(
+	chain Chain,
+	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
+	config *Config,
+) *CpfpTask {
+	return &CpfpTask{
+		chain:        chain,
+		btcChain:     btcChain,
+		feeEstimator: feeEstimator,
+		redemptionTask: NewRedemptionTask(
+			chain,
+			btcChain,
+			feeEstimator,
+			config.RedemptionFeeSharesEnabled,
+		),
+		depositSweepTask: NewDepositSweepTask(chain, btcChain, feeEstimator, config),
+	}
+}
+
+func (ct *CpfpTask) Run(request *tbtc.CoordinationProposalRequest) (
+	tbtc.CoordinationProposal,
+	bool,
+	error,
+) {
+	walletPublicKeyHash := request.WalletPublicKeyHash
+
+	taskLogger := logger.With(
+		zap.String("task", ct.ActionType().String()),
+		zap.String("walletPKH", fmt.Sprintf("0x%x", walletPublicKeyHash)),
+	)
+
+	parent, ok, err := ct.findStuckParent(taskLogger, walletPublicKeyHash)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot find stuck parent transaction: [%w]",
+			err,
+		)
+	}
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	child, ok, err := ct.findChildCandidate(
+		taskLogger,
+		walletPublicKeyHash,
+		parent,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot find child transaction content: [%w]",
+			err,
+		)
+	}
+
+	if !ok {
+		taskLogger.Info(
+			"no redemption requests or deposits to process in the child " +
+				"transaction",
+		)
+		return nil, false, nil
+	}
+
+	proposal, ok, err := ct.proposeCpfp(
+		taskLogger,
+		walletPublicKeyHash,
+		parent,
+		child,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot prepare CPFP proposal: [%w]",
+			err,
+		)
+	}
+
+	return proposal, ok, nil
+}
+
+func (ct *CpfpTask) ActionType() tbtc.WalletActionType {
+	return tbtc.ActionCpfp
+}
+
+// findStuckParent finds the mempool transaction spending the wallet's main
+// UTXO and checks whether it is stuck, i.e. pays a lower fee than the one
+// estimated for its size and the fee bump confirmation target. The returned
+// boolean flag is false if there is no such transaction, it cannot be sped up
+// by a child transaction or it can still be replaced by fee.
+func (ct *CpfpTask) findStuckParent(
+	taskLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+) (*tbtc.CpfpParent, bool, error) {
+	mempoolTransactions, err := ct.btcChain.GetMempoolForPublicKeyHash(
+		walletPublicKeyHash,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot get mempool transactions: [%w]",
+			err,
+		)
+	}
+
+	if len(mempoolTransactions) == 0 {
+		return nil, false, nil
+	}
+
+	walletMainUtxo, err := tbtc.DetermineWalletMainUtxo(
+		walletPublicKeyHash,
+		ct.chain,
+		ct.btcChain,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot determine wallet's main UTXO: [%w]",
+			err,
+		)
+	}
+
+	if walletMainUtxo == nil {
+		return nil, false, nil
+	}
+
+	var parentTransaction *bitcoin.Transaction
+	for _, mempoolTransaction := range mempoolTransactions {
+		for _, input := range mempoolTransaction.Inputs {
+			if *input.Outpoint == *walletMainUtxo.Outpoint {
+				parentTransaction = mempoolTransaction
+			}
+		}
+	}
+
+	if parentTransaction == nil {
+		return nil, false, nil
+	}
+
+	parentHash := parentTransaction.Hash()
+
+	parent, err := tbtc.AnalyzeCpfpParent(
+		walletPublicKeyHash,
+		parentHash,
+		ct.chain,
+		ct.btcChain,
+	)
+	if err != nil {
+		taskLogger.Infof(
+			"transaction [%s] cannot be sped up by a child transaction: [%v]",
+			parentHash.Hex(bitcoin.ReversedByteOrder),
+			err,
+		)
+		return nil, false, nil
+	}
+
+	if tbtc.IsRbfReplaceable(
+		walletPublicKeyHash,
+		parentHash,
+		ct.chain,
+		ct.btcChain,
+	) {
+		taskLogger.Infof(
+			"transaction [%s] can still be replaced by fee",
+			parentHash.Hex(bitcoin.ReversedByteOrder),
+		)
+		return nil, false, nil
+	}
+
+	targetFee, err := ct.feeEstimator.EstimateFee(
+		ct.ActionType(),
+		parent.Transaction.VirtualSize(),
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot estimate fee: [%w]", err)
+	}
+
+	if parent.Fee >= targetFee {
+		taskLogger.Infof(
+			"transaction [%s] fee [%v] is not lower than the estimated fee [%v]",
+			parentHash.Hex(bitcoin.ReversedByteOrder),
+			parent.Fee,
+			targetFee,
+		)
+		return nil, false, nil
+	}
+
+	return parent, true, nil
+}
+
+// cpfpChildCandidate holds the content of a CPFP child transaction along
+// with the Bridge's limits of its fee.
+type cpfpChildCandidate struct {
+	// proposal is the child proposal with no fee set.
+	proposal tbtc.CoordinationProposal
+	// itemsCount is the number of redemption requests or deposits the
+	// child transaction handles.
+	itemsCount int64
+	// itemMaxFee is the maximum fee share of a single redemption request or
+	// deposit accepted by the Bridge.
+	itemMaxFee int64
+	// totalMaxFee is the maximum total fee accepted by the Bridge.
+	totalMaxFee int64
+}
+
+// findChildCandidate finds the content of a child transaction of the given
+// parent. Pending redemption requests are preferred over deposits. Requests
+// handled and deposits swept by the parent are omitted as they are still
+// pending in the Bridge until the parent is proven. The returned boolean
+// flag is false if there is nothing the child transaction could process.
+func (ct *CpfpTask) findChildCandidate(
+	taskLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+	parent *tbtc.CpfpParent,
+) (*cpfpChildCandidate, bool, error) {
+	redemptionMaxSize, err := ct.chain.GetRedemptionMaxSize()
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot get redemption max size: [%w]",
+			err,
+		)
+	}
+
+	pendingRedemptionsScripts, err := ct.redemptionTask.FindPendingRedemptions(
+		taskLogger,
+		walletPublicKeyHash,
+		redemptionMaxSize,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var (
+		redeemersOutputScripts []bitcoin.Script
+		requestMaxFee          uint64
+	)
+	for _, script := range pendingRedemptionsScripts {
+		handledByParent := false
+		for _, output := range parent.Transaction.Outputs {
+			if bytes.Equal(output.PublicKeyScript, script) {
+				handledByParent = true
+				break
+			}
+		}
+		if handledByParent {
+			continue
+		}
+
+		request, found, err := ct.chain.GetPendingRedemptionRequest(
+			walletPublicKeyHash,
+			script,
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf(
+				"cannot get pending redemption request: [%w]",
+				err,
+			)
+		}
+		if !found {
+			continue
+		}
+
+		if len(redeemersOutputScripts) == 0 ||
+			request.TxMaxFee < requestMaxFee {
+			requestMaxFee = request.TxMaxFee
+		}
+
+		redeemersOutputScripts = append(redeemersOutputScripts, script)
+	}
+
+	if len(redeemersOutputScripts) > 0 {
+		_, _, _, txMaxTotalFee, _, _, _, err := ct.chain.GetRedemptionParameters()
+		if err != nil {
+			return nil, false, fmt.Errorf(
+				"cannot get redemption parameters: [%w]",
+				err,
+			)
+		}
+
+		return &cpfpChildCandidate{
+			proposal: &tbtc.RedemptionProposal{
+				RedeemersOutputScripts: redeemersOutputScripts,
+			},
+			itemsCount:  int64(len(redeemersOutputScripts)),
+			itemMaxFee:  int64(requestMaxFee),
+			totalMaxFee: int64(txMaxTotalFee),
+		}, true, nil
+	}
+
+	depositSweepMaxSize, err := ct.chain.GetDepositSweepMaxSize()
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot get deposit sweep max size: [%w]",
+			err,
+		)
+	}
+
+	depositsToSweep, err := ct.depositSweepTask.FindDepositsToSweep(
+		taskLogger,
+		walletPublicKeyHash,
+		depositSweepMaxSize,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var deposits []*DepositReference
+	for _, deposit := range depositsToSweep {
+		sweptByParent := false
+		for _, input := range parent.Transaction.Inputs {
+			if input.Outpoint.TransactionHash == deposit.FundingTxHash &&
+				input.Outpoint.OutputIndex == deposit.FundingOutputIndex {
+				sweptByParent = true
+				break
+			}
+		}
+		if sweptByParent {
+			continue
+		}
+
+		deposits = append(deposits, deposit)
+	}
+
+	if len(deposits) == 0 {
+		return nil, false, nil
+	}
+
+	_, _, depositTxMaxFee, _, err := ct.chain.GetDepositParameters()
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot get deposit parameters: [%w]",
+			err,
+		)
+	}
+
+	return &cpfpChildCandidate{
+		proposal:    newDepositSweepProposal(deposits, 0),
+		itemsCount:  int64(len(deposits)),
+		itemMaxFee:  int64(depositTxMaxFee),
+		totalMaxFee: int64(len(deposits)) * int64(depositTxMaxFee),
+	}, true, nil
+}
+
+// proposeCpfp returns a CPFP proposal for the given stuck parent transaction
+// and the given child transaction content. The child fee is estimated so
+// the parent and the child together pay the fee estimated for their total
+// size and the fee bump confirmation target. The child fee is capped by the
+// Bridge's limits. The returned boolean flag is false if the capped child
+// fee does not exceed the parent's fee rate so the child would not speed
+// the parent up, or is lower than the minimum returned by
+// tbtc.ComputeCpfpMinChildFee so the proposal would not be accepted.
+func (ct *CpfpTask) proposeCpfp(
+	taskLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+	parent *tbtc.CpfpParent,
+	child *cpfpChildCandidate,
+) (*tbtc.CpfpProposal, bool, error) {
+	parentVirtualSize := parent.Transaction.VirtualSize()
+
+	childVirtualSize, err := tbtc.EstimateCpfpChildVirtualSize(child.proposal)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot estimate child virtual size: [%w]",
+			err,
+		)
+	}
+
+	packageFee, err := ct.feeEstimator.EstimateFee(
+		ct.ActionType(),
+		parentVirtualSize+childVirtualSize,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot estimate fee: [%w]", err)
+	}
+
+	childFee := computeCpfpChildFee(
+		packageFee-parent.Fee,
+		child.itemsCount,
+		child.itemMaxFee,
+		child.totalMaxFee,
+	)
+
+	if childFee*parentVirtualSize <= parent.Fee*childVirtualSize {
+		taskLogger.Infof(
+			"child fee [%v] capped by the Bridge's limits does not exceed "+
+				"the parent's fee rate",
+			childFee,
+		)
+		return nil, false, nil
+	}
+
+	minChildFee, err := tbtc.ComputeCpfpMinChildFee(
+		ct.btcChain,
+		parent,
+		childVirtualSize,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot compute minimum child fee: [%w]",
+			err,
+		)
+	}
+
+	if childFee < minChildFee {
+		taskLogger.Infof(
+			"child fee [%v] capped by the Bridge's limits is lower than "+
+				"the minimum child fee [%v] accepted for the package",
+			childFee,
+			minChildFee,
+		)
+		return nil, false, nil
+	}
+
+	taskLogger.Infof("child transaction fee: [%d]", childFee)
+
+	switch childProposal := child.proposal.(type) {
+	case *tbtc.DepositSweepProposal:
+		childProposal.SweepTxFee = big.NewInt(childFee)
+	case *tbtc.RedemptionProposal:
+		childProposal.RedemptionTxFee = big.NewInt(childFee)
+	}
+
+	proposal := &tbtc.CpfpProposal{
+		ParentTransactionHash: parent.Transaction.Hash(),
+		ChildProposal:         child.proposal,
+	}
+
+	taskLogger.Infof("validating the CPFP proposal")
+
+	if err := tbtc.ValidateCpfpProposal(
+		taskLogger,
+		walletPublicKeyHash,
+		proposal,
+		tbtc.DepositSweepRequiredFundingTxConfirmations,
+		ct.chain,
+		ct.btcChain,
+	); err != nil {
+		return nil, false, fmt.Errorf(
+			"failed to verify CPFP proposal: [%w]",
+			err,
+		)
+	}
+
+	return proposal, true, nil
+}
+
+// computeCpfpChildFee caps the given target fee of a child transaction
+// handling the given count of redemption requests or deposits by the
+// Bridge's limits. The Bridge splits the fee evenly over the items and
+// charges the remainder to the last one so, if the remainder pushes the
+// last item's share over the maximum fee, the fee is rounded down to
+// a multiple of the items count.
+func computeCpfpChildFee(
+	targetFee int64,
+	itemsCount int64,
+	itemMaxFee int64,
+	totalMaxFee int64,
+) int64 {
+	fee := targetFee
+
+	if fee > totalMaxFee {
+		fee = totalMaxFee
+	}
+
+	if fee > itemsCount*itemMaxFee {
+		fee = itemsCount * itemMaxFee
+	}
+
+	if fee/itemsCount+fee%itemsCount > itemMaxFee {
+		fee = (fee / itemsCount) * itemsCount
+	}
+
+	return fee
+}
@@ -0,0 +1,410 @@
+package tbtcpg
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+// cpfpTestTransactions holds transactions shared by CPFP tests. The parent
+// is a redemption transaction spending the wallet's main UTXO, paying
+// 1000 satoshi of fee and returning the change to the wallet.
+type cpfpTestTransactions struct {
+	walletPublicKeyHash [20]byte
+	walletScript        bitcoin.Script
+	mainUtxo            *bitcoin.UnspentTransactionOutput
+	mainUtxoTransaction *bitcoin.Transaction
+	parentTransaction   *bitcoin.Transaction
+}
+
+func newCpfpTestTransactions(t *testing.T) *cpfpTestTransactions {
+	walletPublicKeyHash := [20]byte{1, 2, 3}
+
+	walletScript, err := bitcoin.PayToWitnessPublicKeyHash(walletPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redeemerScript, err := bitcoin.PayToWitnessPublicKeyHash([20]byte{4, 5, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mainUtxoTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: bitcoin.Hash{0xaa},
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 100000, PublicKeyScript: walletScript},
+		},
+	}
+
+	mainUtxo := &bitcoin.UnspentTransactionOutput{
+		Outpoint: &bitcoin.TransactionOutpoint{
+			TransactionHash: mainUtxoTransaction.Hash(),
+			OutputIndex:     0,
+		},
+		Value: 100000,
+	}
+
+	parentTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: mainUtxo.Outpoint,
+				Witness: [][]byte{
+					bytes.Repeat([]byte{0x30}, 72),
+					bytes.Repeat([]byte{0x02}, 33),
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 60000, PublicKeyScript: walletScript},
+			{Value: 39000, PublicKeyScript: redeemerScript},
+		},
+	}
+
+	return &cpfpTestTransactions{
+		walletPublicKeyHash: walletPublicKeyHash,
+		walletScript:        walletScript,
+		mainUtxo:            mainUtxo,
+		mainUtxoTransaction: mainUtxoTransaction,
+		parentTransaction:   parentTransaction,
+	}
+}
+
+// setup registers the transactions on the given chains. The parent
+// transaction is put into the mempool and the main UTXO is registered
+// in the Bridge.
+func (ctt *cpfpTestTransactions) setup(
+	tbtcChain *LocalChain,
+	btcChain *LocalBitcoinChain,
+) {
+	btcChain.SetTransaction(
+		ctt.mainUtxoTransaction.Hash(),
+		ctt.mainUtxoTransaction,
+	)
+	btcChain.AddMempoolTransaction(ctt.parentTransaction)
+
+	tbtcChain.SetWallet(
+		ctt.walletPublicKeyHash,
+		&tbtc.WalletChainData{
+			MainUtxoHash: tbtcChain.ComputeMainUtxoHash(ctt.mainUtxo),
+			State:        tbtc.StateLive,
+		},
+	)
+}
+
+func TestCpfpTask_Run_NoProposal(t *testing.T) {
+	transactions := newCpfpTestTransactions(t)
+
+	otherRedeemerScript, err := bitcoin.PayToWitnessPublicKeyHash(
+		[20]byte{7, 8, 9},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		emptyMempool   bool
+		replaceable    bool
+		satPerVByteFee int64
+	}{
+		"no transaction in the mempool": {
+			emptyMempool:   true,
+			satPerVByteFee: 20,
+		},
+		"transaction paying the estimated fee": {
+			satPerVByteFee: 1,
+		},
+		"transaction that can still be replaced by fee": {
+			replaceable:    true,
+			satPerVByteFee: 20,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tbtcChain := NewLocalChain()
+			btcChain := NewLocalBitcoinChain()
+
+			if !test.emptyMempool {
+				transactions.setup(tbtcChain, btcChain)
+			}
+
+			if test.replaceable {
+				// The request handled by the parent can pay more so the
+				// parent's fee can be bumped within the Bridge's limits.
+				// The other request could be handled by a child.
+				tbtcChain.SetRedemptionParameters(0, 0, 0, 10000, 0, nil, 0)
+				for _, script := range []bitcoin.Script{
+					transactions.parentTransaction.Outputs[1].PublicKeyScript,
+					otherRedeemerScript,
+				} {
+					tbtcChain.SetPendingRedemptionRequest(
+						transactions.walletPublicKeyHash,
+						&tbtc.RedemptionRequest{
+							RedeemerOutputScript: script,
+							RequestedAmount:      40000,
+							TxMaxFee:             5000,
+						},
+					)
+				}
+				btcChain.SetTransactionConfirmations(
+					transactions.mainUtxoTransaction.Hash(),
+					1,
+				)
+				btcChain.SetTransactionConfirmations(
+					transactions.parentTransaction.Hash(),
+					0,
+				)
+			}
+
+			btcChain.SetEstimateSatPerVByteFee(1, test.satPerVByteFee)
+
+			task := NewCpfpTask(
+				tbtcChain,
+				btcChain,
+				NewFeeEstimator(btcChain, nil),
+				&Config{},
+			)
+
+			proposal, ok, err := task.Run(&tbtc.CoordinationProposalRequest{
+				WalletPublicKeyHash: transactions.walletPublicKeyHash,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok {
+				t.Fatalf("unexpected proposal: [%v]", proposal)
+			}
+		})
+	}
+}
+
+func TestCpfpTask_ProposeCpfp(t *testing.T) {
+	transactions := newCpfpTestTransactions(t)
+
+	redeemerScript, err := bitcoin.PayToWitnessPublicKeyHash([20]byte{7, 8, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parentVirtualSize := transactions.parentTransaction.VirtualSize()
+
+	childVirtualSize, err := tbtc.EstimateCpfpChildVirtualSize(
+		&tbtc.RedemptionProposal{
+			RedeemersOutputScripts: []bitcoin.Script{redeemerScript},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	estimatedChildFee := 20*(parentVirtualSize+childVirtualSize) - 1000
+
+	var tests = map[string]struct {
+		requestTxMaxFee       uint64
+		satPerVByteFee        int64
+		packageSatPerVByteFee int64
+		expectedChildFee      int64
+	}{
+		"child with estimated fee": {
+			requestTxMaxFee:  10000,
+			satPerVByteFee:   20,
+			expectedChildFee: estimatedChildFee,
+		},
+		"child with maximum fee": {
+			requestTxMaxFee:  3000,
+			satPerVByteFee:   100,
+			expectedChildFee: 3000,
+		},
+		"child whose maximum fee does not outbid the parent": {
+			requestTxMaxFee:  100,
+			satPerVByteFee:   20,
+			expectedChildFee: 0,
+		},
+		"child whose maximum fee does not pay the package's minimum fee": {
+			requestTxMaxFee:       3000,
+			satPerVByteFee:        100,
+			packageSatPerVByteFee: 20,
+			expectedChildFee:      0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tbtcChain := NewLocalChain()
+			tbtcChain.SetRedemptionParameters(0, 0, 0, 10000, 0, nil, 0)
+			tbtcChain.SetPendingRedemptionRequest(
+				transactions.walletPublicKeyHash,
+				&tbtc.RedemptionRequest{
+					RedeemerOutputScript: redeemerScript,
+					RequestedAmount:      40000,
+					TxMaxFee:             test.requestTxMaxFee,
+				},
+			)
+
+			btcChain := NewLocalBitcoinChain()
+			transactions.setup(tbtcChain, btcChain)
+			btcChain.SetEstimateSatPerVByteFee(1, test.satPerVByteFee)
+
+			packageSatPerVByteFee := test.packageSatPerVByteFee
+			if packageSatPerVByteFee == 0 {
+				packageSatPerVByteFee = 1
+			}
+			btcChain.SetEstimateSatPerVByteFee(
+				tbtc.CpfpPackageConfirmationTarget,
+				packageSatPerVByteFee,
+			)
+
+			var expectedProposal *tbtc.CpfpProposal
+			if test.expectedChildFee != 0 {
+				childProposal := &tbtc.RedemptionProposal{
+					RedeemersOutputScripts: []bitcoin.Script{redeemerScript},
+					RedemptionTxFee:        big.NewInt(test.expectedChildFee),
+				}
+
+				err := tbtcChain.SetRedemptionProposalValidationResult(
+					transactions.walletPublicKeyHash,
+					childProposal,
+					true,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				expectedProposal = &tbtc.CpfpProposal{
+					ParentTransactionHash: transactions.parentTransaction.Hash(),
+					ChildProposal:         childProposal,
+				}
+			}
+
+			task := NewCpfpTask(
+				tbtcChain,
+				btcChain,
+				NewFeeEstimator(btcChain, nil),
+				&Config{},
+			)
+
+			parent, err := tbtc.AnalyzeCpfpParent(
+				transactions.walletPublicKeyHash,
+				transactions.parentTransaction.Hash(),
+				tbtcChain,
+				btcChain,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proposal, ok, err := task.proposeCpfp(
+				&testutils.MockLogger{},
+				transactions.walletPublicKeyHash,
+				parent,
+				&cpfpChildCandidate{
+					proposal: &tbtc.RedemptionProposal{
+						RedeemersOutputScripts: []bitcoin.Script{
+							redeemerScript,
+						},
+					},
+					itemsCount:  1,
+					itemMaxFee:  int64(test.requestTxMaxFee),
+					totalMaxFee: 10000,
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if expectedProposal == nil {
+				if ok {
+					t.Fatalf("unexpected proposal: [%v]", proposal)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatal("expected proposal")
+			}
+
+			if !reflect.DeepEqual(expectedProposal, proposal) {
+				t.Errorf(
+					"unexpected proposal\nexpected: %v\nactual:   %v",
+					expectedProposal,
+					proposal,
+				)
+			}
+		})
+	}
+}
+
+func TestComputeCpfpChildFee(t *testing.T) {
+	var tests = map[string]struct {
+		targetFee   int64
+		itemsCount  int64
+		itemMaxFee  int64
+		totalMaxFee int64
+		expectedFee int64
+	}{
+		"fee within limits": {
+			targetFee:   2500,
+			itemsCount:  2,
+			itemMaxFee:  2000,
+			totalMaxFee: 10000,
+			expectedFee: 2500,
+		},
+		"fee capped by the total max fee": {
+			targetFee:   5000,
+			itemsCount:  2,
+			itemMaxFee:  3000,
+			totalMaxFee: 4000,
+			expectedFee: 4000,
+		},
+		"fee capped by the item max fee": {
+			targetFee:   8000,
+			itemsCount:  3,
+			itemMaxFee:  2000,
+			totalMaxFee: 10000,
+			expectedFee: 6000,
+		},
+		"fee rounded down so the last share fits the item max fee": {
+			targetFee:   5999,
+			itemsCount:  3,
+			itemMaxFee:  2000,
+			totalMaxFee: 10000,
+			expectedFee: 5997,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			fee := computeCpfpChildFee(
+				test.targetFee,
+				test.itemsCount,
+				test.itemMaxFee,
+				test.totalMaxFee,
+			)
+
+			testutils.AssertIntsEqual(
+				t,
+				"child fee",
+				int(test.expectedFee),
+				int(fee),
+			)
+		})
+	}
+}
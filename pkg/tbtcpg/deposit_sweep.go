@@ -1,6 +1,7 @@
 package tbtcpg
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math"
 	"math/big"
@@ -21,17 +22,44 @@ const depositScriptByteSize = 126
 
 // DepositSweepTask is a task that may produce a deposit sweep proposal.
 type DepositSweepTask struct {
-	chain    Chain
-	btcChain bitcoin.Chain
+	chain              Chain
+	btcChain           bitcoin.Chain
+	feeEstimator       FeeEstimator
+	maxFeeShare        float64
+	maxWait            time.Duration
+	refundSafetyMargin time.Duration
 }
 
+// NewDepositSweepTask creates a new deposit sweep task. Deposit sweep
+// limits not set in the given config fall back to their defaults.
 func NewDepositSweepTask(
 	chain Chain,
 	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
+	config *Config,
 ) *DepositSweepTask {
+	maxFeeShare := config.DepositSweepMaxFeeShare
+	if maxFeeShare == 0 {
+		maxFeeShare = DefaultDepositSweepMaxFeeShare
+	}
+
+	maxWait := config.DepositSweepMaxWait
+	if maxWait == 0 {
+		maxWait = DefaultDepositSweepMaxWait
+	}
+
+	refundSafetyMargin := config.DepositRefundSafetyMargin
+	if refundSafetyMargin == 0 {
+		refundSafetyMargin = DefaultDepositRefundSafetyMargin
+	}
+
 	return &DepositSweepTask{
-		chain:    chain,
-		btcChain: btcChain,
+		chain:              chain,
+		btcChain:           btcChain,
+		feeEstimator:       feeEstimator,
+		maxFeeShare:        maxFeeShare,
+		maxWait:            maxWait,
+		refundSafetyMargin: refundSafetyMargin,
 	}
 }
 
@@ -47,36 +75,26 @@ func (dst *DepositSweepTask) Run(request *tbtc.CoordinationProposalRequest) (
 		zap.String("walletPKH", fmt.Sprintf("0x%x", walletPublicKeyHash)),
 	)
 
-	depositSweepMaxSize, err := dst.chain.GetDepositSweepMaxSize()
+	plan, err := dst.PlanDepositsSweep(taskLogger, walletPublicKeyHash)
 	if err != nil {
 		return nil, false, fmt.Errorf(
-			"failed to get deposit sweep max size: [%w]",
+			"cannot plan deposits sweep: [%w]",
 			err,
 		)
 	}
 
-	deposits, err := dst.FindDepositsToSweep(
-		taskLogger,
-		walletPublicKeyHash,
-		depositSweepMaxSize,
-	)
-	if err != nil {
-		return nil, false, fmt.Errorf(
-			"cannot find deposits to sweep: [%w]",
-			err,
-		)
-	}
+	taskLogger.Infof("deposit sweep plan:\n%s", plan)
 
-	if len(deposits) == 0 {
-		taskLogger.Info("no deposits to sweep")
+	if !plan.Sweep {
+		taskLogger.Infof("not sweeping deposits: [%s]", plan.Reason)
 		return nil, false, nil
 	}
 
 	proposal, err := dst.ProposeDepositsSweep(
 		taskLogger,
 		walletPublicKeyHash,
-		deposits,
-		0,
+		plan.DepositsReferences(),
+		plan.TotalFee,
 	)
 	if err != nil {
 		return nil, false, fmt.Errorf(
@@ -108,6 +126,16 @@ type Deposit struct {
 	IsSwept             bool
 	AmountBtc           float64
 	Confirmations       uint
+
+	// Amount is the deposit amount in satoshi.
+	Amount uint64
+	// TreasuryFee is the treasury fee in satoshi, taken upon deposit sweep.
+	TreasuryFee uint64
+	// RevealedAt is the time the deposit was revealed to the Bridge.
+	RevealedAt time.Time
+	// RefundLocktime is the time after which the depositor can claim
+	// the deposit back.
+	RefundLocktime time.Time
 }
 
 // FindDeposits finds deposits according to the given criteria.
@@ -179,7 +207,7 @@ func findDeposits(
 	}
 
 	// Capture time now for computations.
-	timeNow := time.Now()
+	timeNow := tbtc.CurrentTime(chain)
 
 	result := make([]*Deposit, 0, resultSliceCapacity)
 	for _, event := range depositRevealedEvents {
@@ -253,6 +281,13 @@ func findDeposits(
 				IsSwept:             isSwept,
 				AmountBtc:           convertSatToBtc(float64(depositRequest.Amount)),
 				Confirmations:       confirmations,
+				Amount:              depositRequest.Amount,
+				TreasuryFee:         depositRequest.TreasuryFee,
+				RevealedAt:          depositRequest.RevealedAt,
+				RefundLocktime: time.Unix(
+					int64(binary.LittleEndian.Uint32(event.RefundLocktime[:])),
+					0,
+				),
 			},
 		)
 	}
@@ -351,7 +386,7 @@ func (dst *DepositSweepTask) ProposeDepositsSweep(
 		}
 
 		estimatedFee, _, err := estimateDepositsSweepFee(
-			dst.btcChain,
+			dst.feeEstimator,
 			len(deposits),
 			perDepositMaxFee,
 		)
@@ -364,6 +399,30 @@ func (dst *DepositSweepTask) ProposeDepositsSweep(
 
 	taskLogger.Infof("sweep transaction fee: [%d]", fee)
 
+	proposal := newDepositSweepProposal(deposits, fee)
+
+	taskLogger.Infof("validating the deposit sweep proposal")
+
+	if _, err := tbtc.ValidateDepositSweepProposal(
+		taskLogger,
+		walletPublicKeyHash,
+		proposal,
+		tbtc.DepositSweepRequiredFundingTxConfirmations,
+		dst.chain,
+		dst.btcChain,
+	); err != nil {
+		return nil, fmt.Errorf("failed to verify deposit sweep proposal: %v", err)
+	}
+
+	return proposal, nil
+}
+
+// newDepositSweepProposal builds a deposit sweep proposal sweeping the given
+// deposits with the given fee.
+func newDepositSweepProposal(
+	deposits []*DepositReference,
+	fee int64,
+) *tbtc.DepositSweepProposal {
 	depositsKeys := make([]struct {
 		FundingTxHash      bitcoin.Hash
 		FundingOutputIndex uint32
@@ -382,26 +441,11 @@ func (dst *DepositSweepTask) ProposeDepositsSweep(
 		depositsRevealBlocks[i] = big.NewInt(int64(deposit.RevealBlock))
 	}
 
-	proposal := &tbtc.DepositSweepProposal{
+	return &tbtc.DepositSweepProposal{
 		DepositsKeys:         depositsKeys,
 		SweepTxFee:           big.NewInt(fee),
 		DepositsRevealBlocks: depositsRevealBlocks,
 	}
-
-	taskLogger.Infof("validating the deposit sweep proposal")
-
-	if _, err := tbtc.ValidateDepositSweepProposal(
-		taskLogger,
-		walletPublicKeyHash,
-		proposal,
-		tbtc.DepositSweepRequiredFundingTxConfirmations,
-		dst.chain,
-		dst.btcChain,
-	); err != nil {
-		return nil, fmt.Errorf("failed to verify deposit sweep proposal: %v", err)
-	}
-
-	return proposal, nil
 }
 
 // EstimateDepositsSweepFee computes the total fee for the Bitcoin deposits
@@ -425,7 +469,7 @@ func (dst *DepositSweepTask) ProposeDepositsSweep(
 // contract, an error is returned as result.
 func EstimateDepositsSweepFee(
 	chain Chain,
-	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
 	depositsCount int,
 ) (
 	map[int]struct {
@@ -460,7 +504,7 @@ func EstimateDepositsSweepFee(
 
 	for _, depositsCountKey := range depositsCountKeys {
 		totalFee, satPerVByteFee, err := estimateDepositsSweepFee(
-			btcChain,
+			feeEstimator,
 			depositsCountKey,
 			perDepositMaxFee,
 		)
@@ -485,9 +529,37 @@ func EstimateDepositsSweepFee(
 }
 
 func estimateDepositsSweepFee(
-	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
 	depositsCount int,
 	perDepositMaxFee uint64,
+) (int64, int64, error) {
+	totalFee, transactionSize, err := computeDepositsSweepFee(
+		feeEstimator,
+		depositsCount,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Compute the maximum possible total fee for the entire sweep transaction.
+	totalMaxFee := uint64(depositsCount) * perDepositMaxFee
+
+	if uint64(totalFee) > totalMaxFee {
+		return 0, 0, fmt.Errorf("estimated fee exceeds the maximum fee")
+	}
+
+	// Compute the actual sat/vbyte fee for informational purposes.
+	satPerVByteFee := math.Round(float64(totalFee) / float64(transactionSize))
+
+	return totalFee, int64(satPerVByteFee), nil
+}
+
+// computeDepositsSweepFee computes the total fee and the virtual size of
+// the Bitcoin deposits sweep transaction for the given depositsCount. It does
+// not check the fee against the maximum fee allowed by the Bridge.
+func computeDepositsSweepFee(
+	feeEstimator FeeEstimator,
+	depositsCount int,
 ) (int64, int64, error) {
 	transactionSize, err := bitcoin.NewTransactionSizeEstimator().
 		// 1 P2WPKH main UTXO input.
@@ -501,24 +573,15 @@ func estimateDepositsSweepFee(
 		return 0, 0, fmt.Errorf("cannot estimate transaction virtual size: [%v]", err)
 	}
 
-	feeEstimator := bitcoin.NewTransactionFeeEstimator(btcChain)
-
-	totalFee, err := feeEstimator.EstimateFee(transactionSize)
+	totalFee, err := feeEstimator.EstimateFee(
+		tbtc.ActionDepositSweep,
+		transactionSize,
+	)
 	if err != nil {
 		return 0, 0, fmt.Errorf("cannot estimate transaction fee: [%v]", err)
 	}
 
-	// Compute the maximum possible total fee for the entire sweep transaction.
-	totalMaxFee := uint64(depositsCount) * perDepositMaxFee
-
-	if uint64(totalFee) > totalMaxFee {
-		return 0, 0, fmt.Errorf("estimated fee exceeds the maximum fee")
-	}
-
-	// Compute the actual sat/vbyte fee for informational purposes.
-	satPerVByteFee := math.Round(float64(totalFee) / float64(transactionSize))
-
-	return totalFee, int64(satPerVByteFee), nil
+	return totalFee, transactionSize, nil
 }
 
 func convertSatToBtc(sats float64) float64 {
@@ -0,0 +1,392 @@
+package tbtcpg
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-log/v2"
+
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+const (
+	// DefaultDepositRefundSafetyMargin is a default minimum time that must
+	// remain until the deposit refund locktime for the deposit to be
+	// sweepable. This value mirrors the DEPOSIT_REFUND_SAFETY_MARGIN constant
+	// of the WalletProposalValidator contract which rejects sweep proposals
+	// with deposits closer to their refund locktime.
+	DefaultDepositRefundSafetyMargin = 24 * time.Hour
+
+	// DefaultDepositSweepMaxFeeShare is a default maximum share of the total
+	// swept amount the sweep transaction fee can consume for the sweep to be
+	// considered economical. Non-urgent sweeps above this share are deferred
+	// until more deposits accumulate, fee rates drop, or deposits wait for
+	// too long.
+	DefaultDepositSweepMaxFeeShare = 0.01
+
+	// DefaultDepositSweepMaxWait is a default maximum time a deposit should
+	// wait for a sweep. Once the oldest deposit waits longer, the sweep is
+	// proposed regardless of its economics.
+	DefaultDepositSweepMaxWait = 24 * time.Hour
+)
+
+// DepositSweepExclusion represents a deposit left out of the deposit sweep
+// plan along with the reason.
+type DepositSweepExclusion struct {
+	Deposit *Deposit
+	Reason  string
+}
+
+// DepositSweepPlan is the outcome of deposit sweep planning. It holds the
+// decision whether deposits should be swept now, the deposits chosen for
+// the sweep and the reasoning behind all choices.
+type DepositSweepPlan struct {
+	// Sweep determines whether the sweep should be proposed now.
+	Sweep bool
+	// Reason explains the decision.
+	Reason string
+	// Included are deposits chosen for the sweep, most urgent first.
+	Included []*Deposit
+	// Excluded are deposits left out of the sweep.
+	Excluded []*DepositSweepExclusion
+	// TotalFee is the estimated fee of the sweep transaction, in satoshi.
+	TotalFee int64
+	// SatPerVByteFee is the estimated fee rate of the sweep transaction.
+	SatPerVByteFee int64
+	// FeeShare is the share of the total swept amount consumed by the fee.
+	FeeShare float64
+}
+
+// DepositsReferences returns references of deposits chosen for the sweep.
+func (dsp *DepositSweepPlan) DepositsReferences() []*DepositReference {
+	references := make([]*DepositReference, len(dsp.Included))
+	for i, deposit := range dsp.Included {
+		reference := deposit.DepositReference
+		references[i] = &reference
+	}
+
+	return references
+}
+
+// String returns a human-readable report of the plan.
+func (dsp *DepositSweepPlan) String() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "sweep: [%t]; reason: [%s]\n", dsp.Sweep, dsp.Reason)
+	fmt.Fprintf(
+		&builder,
+		"total fee: [%d] sat; fee rate: [%d] sat/vbyte; fee share: [%.4f%%]\n",
+		dsp.TotalFee,
+		dsp.SatPerVByteFee,
+		dsp.FeeShare*100,
+	)
+
+	for _, deposit := range dsp.Included {
+		fmt.Fprintf(
+			&builder,
+			"included deposit [%s]: amount [%d] sat, revealed at [%s], "+
+				"refund locktime [%s]\n",
+			deposit.DepositKey,
+			deposit.Amount,
+			deposit.RevealedAt.UTC().Format(time.RFC3339),
+			deposit.RefundLocktime.UTC().Format(time.RFC3339),
+		)
+	}
+
+	for _, exclusion := range dsp.Excluded {
+		fmt.Fprintf(
+			&builder,
+			"excluded deposit [%s]: %s\n",
+			exclusion.Deposit.DepositKey,
+			exclusion.Reason,
+		)
+	}
+
+	return builder.String()
+}
+
+// depositSweepParameters holds chain parameters and configured limits
+// relevant for deposit sweep planning.
+type depositSweepParameters struct {
+	maxSize            uint16
+	dustThreshold      uint64
+	txMaxFee           uint64
+	revealAheadPeriod  time.Duration
+	maxFeeShare        float64
+	maxWait            time.Duration
+	refundSafetyMargin time.Duration
+}
+
+// sweepDeadline returns the time after which the given deposit can no
+// longer be swept.
+func (dsp *depositSweepParameters) sweepDeadline(deposit *Deposit) time.Time {
+	return deposit.RefundLocktime.Add(-dsp.refundSafetyMargin)
+}
+
+// PlanDepositsSweep decides whether deposits of the given wallet should be
+// swept now and which of them should be included in the sweep. The decision
+// weighs the current fee rates against the swept value, the urgency of
+// deposits approaching their refund locktime, dust limits and the per-deposit
+// fee budget. The returned plan explains all choices.
+func (dst *DepositSweepTask) PlanDepositsSweep(
+	taskLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+) (*DepositSweepPlan, error) {
+	if walletPublicKeyHash == [20]byte{} {
+		return nil, fmt.Errorf("wallet public key hash is required")
+	}
+
+	maxSize, err := dst.chain.GetDepositSweepMaxSize()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get deposit sweep max size: [%w]",
+			err,
+		)
+	}
+
+	dustThreshold, _, txMaxFee, revealAheadPeriod, err :=
+		dst.chain.GetDepositParameters()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get deposit parameters: [%w]", err)
+	}
+
+	// Take all unswept deposits into account as the most urgent ones may
+	// not be the oldest ones.
+	deposits, err := findDeposits(
+		taskLogger,
+		dst.chain,
+		dst.btcChain,
+		walletPublicKeyHash,
+		0,
+		true,
+		true,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find deposits to sweep: [%w]", err)
+	}
+
+	return planDepositsSweep(
+		deposits,
+		&depositSweepParameters{
+			maxSize:            maxSize,
+			dustThreshold:      dustThreshold,
+			txMaxFee:           txMaxFee,
+			revealAheadPeriod:  time.Duration(revealAheadPeriod) * time.Second,
+			maxFeeShare:        dst.maxFeeShare,
+			maxWait:            dst.maxWait,
+			refundSafetyMargin: dst.refundSafetyMargin,
+		},
+		func(depositsCount int) (int64, int64, error) {
+			return computeDepositsSweepFee(dst.feeEstimator, depositsCount)
+		},
+		tbtc.CurrentTime(dst.chain),
+	)
+}
+
+// planDepositsSweep builds a deposit sweep plan for the given sweepable
+// deposits. The estimateFeeFn returns the total fee and the virtual size of
+// a sweep transaction with the given number of deposits.
+//
+// Deposits are chosen as follows:
+//   - deposits too close to their refund locktime are excluded as the
+//     WalletProposalValidator would reject them,
+//   - deposits whose remaining sweep time is shorter than the reveal ahead
+//     period are urgent and go first, ordered by their sweep deadline,
+//   - remaining deposits follow, oldest first,
+//   - deposits above the maximum sweep size are excluded,
+//   - deposits whose value after fees would drop below the dust threshold
+//     are excluded and the fee is re-estimated for the remaining ones.
+//
+// The sweep is proposed if any included deposit is urgent, the batch is
+// full, the oldest deposit waits longer than the maximum wait, or the fee
+// consumes no more than the maximum fee share of the swept amount. The sweep
+// is never proposed if the fee share incurred by any deposit exceeds the
+// per-deposit maximum fee.
+func planDepositsSweep(
+	deposits []*Deposit,
+	parameters *depositSweepParameters,
+	estimateFeeFn func(depositsCount int) (int64, int64, error),
+	now time.Time,
+) (*DepositSweepPlan, error) {
+	plan := &DepositSweepPlan{}
+
+	exclude := func(deposit *Deposit, reason string, args ...interface{}) {
+		plan.Excluded = append(
+			plan.Excluded,
+			&DepositSweepExclusion{
+				Deposit: deposit,
+				Reason:  fmt.Sprintf(reason, args...),
+			},
+		)
+	}
+
+	isUrgent := func(deposit *Deposit) bool {
+		return parameters.sweepDeadline(deposit).Sub(now) < parameters.revealAheadPeriod
+	}
+
+	candidates := make([]*Deposit, 0, len(deposits))
+	for _, deposit := range deposits {
+		if !now.Before(parameters.sweepDeadline(deposit)) {
+			exclude(
+				deposit,
+				"refund locktime [%s] is too close to sweep the deposit",
+				deposit.RefundLocktime.UTC().Format(time.RFC3339),
+			)
+			continue
+		}
+
+		candidates = append(candidates, deposit)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iUrgent, jUrgent := isUrgent(candidates[i]), isUrgent(candidates[j])
+		if iUrgent != jUrgent {
+			return iUrgent
+		}
+
+		if iUrgent {
+			return parameters.sweepDeadline(candidates[i]).Before(
+				parameters.sweepDeadline(candidates[j]),
+			)
+		}
+
+		return candidates[i].RevealBlock < candidates[j].RevealBlock
+	})
+
+	if len(candidates) > int(parameters.maxSize) {
+		for _, deposit := range candidates[parameters.maxSize:] {
+			exclude(
+				deposit,
+				"sweep batch is full; maximum size is [%d]",
+				parameters.maxSize,
+			)
+		}
+		candidates = candidates[:parameters.maxSize]
+	}
+
+	var transactionSize int64
+	var depositFeeShare uint64
+	for {
+		if len(candidates) == 0 {
+			plan.Sweep = false
+			plan.Reason = "no deposits eligible for sweep"
+			return plan, nil
+		}
+
+		totalFee, size, err := estimateFeeFn(len(candidates))
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot estimate fee for [%d] deposits: [%v]",
+				len(candidates),
+				err,
+			)
+		}
+
+		plan.TotalFee = totalFee
+		transactionSize = size
+
+		// The Bridge splits the fee evenly across deposits and charges
+		// the remainder to the last one. Use that worst-case share.
+		depositFeeShare = uint64(totalFee)/uint64(len(candidates)) +
+			uint64(totalFee)%uint64(len(candidates))
+
+		remaining := make([]*Deposit, 0, len(candidates))
+		for _, deposit := range candidates {
+			fees := deposit.TreasuryFee + depositFeeShare
+			if deposit.Amount < fees ||
+				deposit.Amount-fees < parameters.dustThreshold {
+				exclude(
+					deposit,
+					"value after fees would be below the dust threshold "+
+						"[%d] sat; amount [%d] sat, treasury fee [%d] sat, "+
+						"sweep fee share [%d] sat",
+					parameters.dustThreshold,
+					deposit.Amount,
+					deposit.TreasuryFee,
+					depositFeeShare,
+				)
+				continue
+			}
+
+			remaining = append(remaining, deposit)
+		}
+
+		if len(remaining) == len(candidates) {
+			break
+		}
+
+		candidates = remaining
+	}
+
+	plan.Included = candidates
+	plan.SatPerVByteFee = int64(
+		math.Round(float64(plan.TotalFee) / float64(transactionSize)),
+	)
+
+	var totalAmount uint64
+	oldestRevealedAt := now
+	for _, deposit := range candidates {
+		totalAmount += deposit.Amount
+		if deposit.RevealedAt.Before(oldestRevealedAt) {
+			oldestRevealedAt = deposit.RevealedAt
+		}
+	}
+	plan.FeeShare = float64(plan.TotalFee) / float64(totalAmount)
+
+	// The Bridge checks the share of each deposit against the maximum fee
+	// separately so the worst-case share must fit it.
+	if depositFeeShare > parameters.txMaxFee {
+		plan.Sweep = false
+		plan.Reason = fmt.Sprintf(
+			"fee share [%d] sat of a deposit exceeds the maximum fee "+
+				"[%d] sat per deposit; estimated fee is [%d] sat for [%d] "+
+				"deposits",
+			depositFeeShare,
+			parameters.txMaxFee,
+			plan.TotalFee,
+			len(candidates),
+		)
+		return plan, nil
+	}
+
+	plan.Sweep = true
+
+	switch {
+	case isUrgent(candidates[0]):
+		plan.Reason = fmt.Sprintf(
+			"deposit [%s] must be swept before [%s]",
+			candidates[0].DepositKey,
+			parameters.sweepDeadline(candidates[0]).UTC().Format(time.RFC3339),
+		)
+	case len(candidates) == int(parameters.maxSize):
+		plan.Reason = fmt.Sprintf(
+			"sweep batch is full with [%d] deposits",
+			len(candidates),
+		)
+	case now.Sub(oldestRevealedAt) >= parameters.maxWait:
+		plan.Reason = fmt.Sprintf(
+			"oldest deposit waits for [%s] which exceeds [%s]",
+			now.Sub(oldestRevealedAt).Round(time.Second),
+			parameters.maxWait,
+		)
+	case plan.FeeShare <= parameters.maxFeeShare:
+		plan.Reason = fmt.Sprintf(
+			"fee share [%.4f%%] does not exceed [%.4f%%]",
+			plan.FeeShare*100,
+			parameters.maxFeeShare*100,
+		)
+	default:
+		plan.Sweep = false
+		plan.Reason = fmt.Sprintf(
+			"fee share [%.4f%%] exceeds [%.4f%%]; waiting for more "+
+				"deposits or lower fees",
+			plan.FeeShare*100,
+			parameters.maxFeeShare*100,
+		)
+	}
+
+	return plan, nil
+}
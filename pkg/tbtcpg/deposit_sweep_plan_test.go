@@ -0,0 +1,332 @@
+package tbtcpg
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+)
+
+func TestPlanDepositsSweep(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	// Creates a deposit with the given key, amount, age and time left until
+	// the refund locktime. The reveal block follows the age so older
+	// deposits have lower reveal blocks.
+	newDeposit := func(
+		key string,
+		amount uint64,
+		age time.Duration,
+		locktimeIn time.Duration,
+	) *Deposit {
+		return &Deposit{
+			DepositReference: DepositReference{
+				RevealBlock: uint64(1000000 - age/time.Minute),
+			},
+			DepositKey:     key,
+			Amount:         amount,
+			RevealedAt:     now.Add(-age),
+			RefundLocktime: now.Add(locktimeIn),
+		}
+	}
+
+	defaultParameters := &depositSweepParameters{
+		maxSize:            3,
+		dustThreshold:      1000000,
+		txMaxFee:           100000,
+		revealAheadPeriod:  15 * 24 * time.Hour,
+		maxFeeShare:        DefaultDepositSweepMaxFeeShare,
+		maxWait:            DefaultDepositSweepMaxWait,
+		refundSafetyMargin: DefaultDepositRefundSafetyMargin,
+	}
+
+	customParameters := &depositSweepParameters{
+		maxSize:            3,
+		dustThreshold:      1000000,
+		txMaxFee:           100000,
+		revealAheadPeriod:  15 * 24 * time.Hour,
+		maxFeeShare:        0.02,
+		maxWait:            12 * time.Hour,
+		refundSafetyMargin: 48 * time.Hour,
+	}
+
+	// Fee of 10000 satoshi per deposit, size of 100 vbytes per deposit.
+	perDepositFee := func(depositsCount int) (int64, int64, error) {
+		return int64(depositsCount) * 10000, int64(depositsCount) * 100, nil
+	}
+
+	var tests = map[string]struct {
+		deposits      []*Deposit
+		parameters    *depositSweepParameters
+		estimateFeeFn func(depositsCount int) (int64, int64, error)
+
+		expectedSweep    bool
+		expectedReason   string
+		expectedIncluded []string
+		expectedExcluded []string
+		expectedTotalFee int64
+		expectedErr      error
+	}{
+		"no deposits": {
+			deposits:       []*Deposit{},
+			parameters:     defaultParameters,
+			estimateFeeFn:  perDepositFee,
+			expectedSweep:  false,
+			expectedReason: "no deposits eligible for sweep",
+		},
+		"economical sweep": {
+			deposits: []*Deposit{
+				newDeposit("young", 2000000, time.Hour, 90*24*time.Hour),
+				newDeposit("old", 3000000, 2*time.Hour, 90*24*time.Hour),
+			},
+			parameters:       defaultParameters,
+			estimateFeeFn:    perDepositFee,
+			expectedSweep:    true,
+			expectedReason:   "fee share [0.4000%] does not exceed [1.0000%]",
+			expectedIncluded: []string{"old", "young"},
+			expectedTotalFee: 20000,
+		},
+		"uneconomical sweep deferred": {
+			deposits: []*Deposit{
+				newDeposit("small", 1500000, time.Hour, 90*24*time.Hour),
+			},
+			parameters: defaultParameters,
+			estimateFeeFn: func(depositsCount int) (int64, int64, error) {
+				return 20000, 200, nil
+			},
+			expectedSweep: false,
+			expectedReason: "fee share [1.3333%] exceeds [1.0000%]; " +
+				"waiting for more deposits or lower fees",
+			expectedIncluded: []string{"small"},
+			expectedTotalFee: 20000,
+		},
+		"uneconomical sweep of long waiting deposit": {
+			deposits: []*Deposit{
+				newDeposit("small", 1500000, 25*time.Hour, 90*24*time.Hour),
+			},
+			parameters: defaultParameters,
+			estimateFeeFn: func(depositsCount int) (int64, int64, error) {
+				return 20000, 200, nil
+			},
+			expectedSweep: true,
+			expectedReason: "oldest deposit waits for [25h0m0s] which " +
+				"exceeds [24h0m0s]",
+			expectedIncluded: []string{"small"},
+			expectedTotalFee: 20000,
+		},
+		"sweep economical under custom maximum fee share": {
+			deposits: []*Deposit{
+				newDeposit("small", 1500000, time.Hour, 90*24*time.Hour),
+			},
+			parameters: customParameters,
+			estimateFeeFn: func(depositsCount int) (int64, int64, error) {
+				return 20000, 200, nil
+			},
+			expectedSweep:    true,
+			expectedReason:   "fee share [1.3333%] does not exceed [2.0000%]",
+			expectedIncluded: []string{"small"},
+			expectedTotalFee: 20000,
+		},
+		"uneconomical sweep of deposit waiting longer than custom maximum wait": {
+			deposits: []*Deposit{
+				newDeposit("small", 1500000, 13*time.Hour, 90*24*time.Hour),
+			},
+			parameters: customParameters,
+			estimateFeeFn: func(depositsCount int) (int64, int64, error) {
+				return 40000, 200, nil
+			},
+			expectedSweep: true,
+			expectedReason: "oldest deposit waits for [13h0m0s] which " +
+				"exceeds [12h0m0s]",
+			expectedIncluded: []string{"small"},
+			expectedTotalFee: 40000,
+		},
+		"deposit past custom refund safety margin excluded": {
+			deposits: []*Deposit{
+				newDeposit("expiring", 2000000, time.Hour, 47*time.Hour),
+			},
+			parameters:       customParameters,
+			estimateFeeFn:    perDepositFee,
+			expectedSweep:    false,
+			expectedReason:   "no deposits eligible for sweep",
+			expectedExcluded: []string{"expiring"},
+		},
+		"uneconomical sweep of urgent deposit": {
+			deposits: []*Deposit{
+				newDeposit("small", 1500000, time.Hour, 10*24*time.Hour),
+			},
+			parameters: defaultParameters,
+			estimateFeeFn: func(depositsCount int) (int64, int64, error) {
+				return 20000, 200, nil
+			},
+			expectedSweep:    true,
+			expectedReason:   "deposit [small] must be swept before [2023-11-23T22:13:20Z]",
+			expectedIncluded: []string{"small"},
+			expectedTotalFee: 20000,
+		},
+		"full batch with urgent deposits first": {
+			deposits: []*Deposit{
+				newDeposit("oldest", 2000000, 4*time.Hour, 90*24*time.Hour),
+				newDeposit("old", 2000000, 3*time.Hour, 90*24*time.Hour),
+				newDeposit("urgent-later", 2000000, time.Hour, 12*24*time.Hour),
+				newDeposit("urgent-sooner", 2000000, 2*time.Hour, 10*24*time.Hour),
+			},
+			parameters:    defaultParameters,
+			estimateFeeFn: perDepositFee,
+			expectedSweep: true,
+			expectedReason: "deposit [urgent-sooner] must be swept before " +
+				"[2023-11-23T22:13:20Z]",
+			expectedIncluded: []string{"urgent-sooner", "urgent-later", "oldest"},
+			expectedExcluded: []string{"old"},
+			expectedTotalFee: 30000,
+		},
+		"full batch": {
+			deposits: []*Deposit{
+				newDeposit("first", 1100000, 3*time.Hour, 90*24*time.Hour),
+				newDeposit("second", 1100000, 2*time.Hour, 90*24*time.Hour),
+				newDeposit("third", 1100000, time.Hour, 90*24*time.Hour),
+			},
+			parameters:       defaultParameters,
+			estimateFeeFn:    perDepositFee,
+			expectedSweep:    true,
+			expectedReason:   "sweep batch is full with [3] deposits",
+			expectedIncluded: []string{"first", "second", "third"},
+			expectedTotalFee: 30000,
+		},
+		"deposits past refund safety margin and below dust excluded": {
+			deposits: []*Deposit{
+				newDeposit("expiring", 2000000, time.Hour, 23*time.Hour),
+				newDeposit("dust", 1005000, 2*time.Hour, 90*24*time.Hour),
+				newDeposit("regular", 2000000, 3*time.Hour, 90*24*time.Hour),
+			},
+			parameters:       defaultParameters,
+			estimateFeeFn:    perDepositFee,
+			expectedSweep:    true,
+			expectedReason:   "fee share [0.5000%] does not exceed [1.0000%]",
+			expectedIncluded: []string{"regular"},
+			expectedExcluded: []string{"expiring", "dust"},
+			expectedTotalFee: 10000,
+		},
+		"fee exceeds the maximum fee": {
+			deposits: []*Deposit{
+				newDeposit("regular", 20000000, time.Hour, 90*24*time.Hour),
+			},
+			parameters: defaultParameters,
+			estimateFeeFn: func(depositsCount int) (int64, int64, error) {
+				return 150000, 200, nil
+			},
+			expectedSweep: false,
+			expectedReason: "fee share [150000] sat of a deposit exceeds the " +
+				"maximum fee [100000] sat per deposit; estimated fee is " +
+				"[150000] sat for [1] deposits",
+			expectedIncluded: []string{"regular"},
+			expectedTotalFee: 150000,
+		},
+		"fee remainder exceeds the maximum fee": {
+			deposits: []*Deposit{
+				newDeposit("first", 20000000, 3*time.Hour, 90*24*time.Hour),
+				newDeposit("second", 20000000, 2*time.Hour, 90*24*time.Hour),
+				newDeposit("third", 20000000, time.Hour, 90*24*time.Hour),
+			},
+			parameters: defaultParameters,
+			estimateFeeFn: func(depositsCount int) (int64, int64, error) {
+				return 299999, 300, nil
+			},
+			expectedSweep: false,
+			expectedReason: "fee share [100001] sat of a deposit exceeds the " +
+				"maximum fee [100000] sat per deposit; estimated fee is " +
+				"[299999] sat for [3] deposits",
+			expectedIncluded: []string{"first", "second", "third"},
+			expectedTotalFee: 299999,
+		},
+		"fee estimation error": {
+			deposits: []*Deposit{
+				newDeposit("regular", 2000000, time.Hour, 90*24*time.Hour),
+			},
+			parameters: defaultParameters,
+			estimateFeeFn: func(depositsCount int) (int64, int64, error) {
+				return 0, 0, fmt.Errorf("unavailable")
+			},
+			expectedErr: fmt.Errorf(
+				"cannot estimate fee for [1] deposits: [unavailable]",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			plan, err := planDepositsSweep(
+				test.deposits,
+				test.parameters,
+				test.estimateFeeFn,
+				now,
+			)
+
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Fatalf(
+					"unexpected error\nexpected: [%v]\nactual:   [%v]",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			if err != nil {
+				return
+			}
+
+			testutils.AssertBoolsEqual(
+				t,
+				"sweep decision",
+				test.expectedSweep,
+				plan.Sweep,
+			)
+			testutils.AssertStringsEqual(
+				t,
+				"reason",
+				test.expectedReason,
+				plan.Reason,
+			)
+			testutils.AssertIntsEqual(
+				t,
+				"total fee",
+				int(test.expectedTotalFee),
+				int(plan.TotalFee),
+			)
+
+			actualIncluded := make([]string, 0)
+			for _, deposit := range plan.Included {
+				actualIncluded = append(actualIncluded, deposit.DepositKey)
+			}
+			if len(test.expectedIncluded) > 0 || len(actualIncluded) > 0 {
+				if !reflect.DeepEqual(test.expectedIncluded, actualIncluded) {
+					t.Errorf(
+						"unexpected included deposits\n"+
+							"expected: [%v]\nactual:   [%v]",
+						test.expectedIncluded,
+						actualIncluded,
+					)
+				}
+			}
+
+			actualExcluded := make([]string, 0)
+			for _, exclusion := range plan.Excluded {
+				actualExcluded = append(
+					actualExcluded,
+					exclusion.Deposit.DepositKey,
+				)
+			}
+			if len(test.expectedExcluded) > 0 || len(actualExcluded) > 0 {
+				if !reflect.DeepEqual(test.expectedExcluded, actualExcluded) {
+					t.Errorf(
+						"unexpected excluded deposits\n"+
+							"expected: [%v]\nactual:   [%v]",
+						test.expectedExcluded,
+						actualExcluded,
+					)
+				}
+			}
+		})
+	}
+}
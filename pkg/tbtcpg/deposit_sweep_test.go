@@ -61,7 +61,12 @@ func TestDepositSweepTask_FindDepositsToSweep(t *testing.T) {
 				}
 			}
 
-			task := tbtcpg.NewDepositSweepTask(tbtcChain, btcChain)
+			task := tbtcpg.NewDepositSweepTask(
+				tbtcChain,
+				btcChain,
+				tbtcpg.NewFeeEstimator(btcChain, nil),
+				&tbtcpg.Config{},
+			)
 
 			// Test execution.
 			actualDeposits, err := task.FindDepositsToSweep(
@@ -147,7 +152,12 @@ func TestDepositSweepTask_ProposeDepositsSweep(t *testing.T) {
 
 			btcChain.SetEstimateSatPerVByteFee(1, scenario.EstimateSatPerVByteFee)
 
-			task := tbtcpg.NewDepositSweepTask(tbtcChain, btcChain)
+			task := tbtcpg.NewDepositSweepTask(
+				tbtcChain,
+				btcChain,
+				tbtcpg.NewFeeEstimator(btcChain, nil),
+				&tbtcpg.Config{},
+			)
 
 			// Test execution.
 			proposal, err := task.ProposeDepositsSweep(
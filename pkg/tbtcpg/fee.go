@@ -0,0 +1,57 @@
+package tbtcpg
+
+import (
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+// DefaultConfirmationTarget is a default number of Bitcoin blocks within
+// which proposed wallet transactions are supposed to be confirmed.
+const DefaultConfirmationTarget = 1
+
+// FeeEstimator is an interface used by proposal tasks to estimate fees
+// of wallet transactions.
+type FeeEstimator interface {
+	// EstimateFee estimates the total fee for a transaction of the given
+	// virtual size, produced by the wallet action of the given type.
+	EstimateFee(
+		actionType tbtc.WalletActionType,
+		transactionVirtualSize int64,
+	) (int64, error)
+}
+
+// actionFeeEstimator is a FeeEstimator implementation that estimates fees
+// for confirmation targets configured per action type.
+type actionFeeEstimator struct {
+	source              bitcoin.FeeRateSource
+	confirmationTargets map[tbtc.WalletActionType]uint32
+}
+
+// NewFeeEstimator creates a new fee estimator using the given fee rate
+// source. Transactions of specific action types are supposed to be confirmed
+// within the given confirmation targets, expressed in Bitcoin blocks.
+// Action types without a configured target use DefaultConfirmationTarget.
+func NewFeeEstimator(
+	source bitcoin.FeeRateSource,
+	confirmationTargets map[tbtc.WalletActionType]uint32,
+) FeeEstimator {
+	return &actionFeeEstimator{
+		source:              source,
+		confirmationTargets: confirmationTargets,
+	}
+}
+
+func (afe *actionFeeEstimator) EstimateFee(
+	actionType tbtc.WalletActionType,
+	transactionVirtualSize int64,
+) (int64, error) {
+	confirmationTarget, ok := afe.confirmationTargets[actionType]
+	if !ok || confirmationTarget == 0 {
+		confirmationTarget = DefaultConfirmationTarget
+	}
+
+	return bitcoin.NewTransactionFeeEstimator(afe.source).EstimateFee(
+		transactionVirtualSize,
+		confirmationTarget,
+	)
+}
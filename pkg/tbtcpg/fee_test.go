@@ -0,0 +1,79 @@
+package tbtcpg_test
+
+import (
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+	"github.com/keep-network/keep-core/pkg/tbtcpg"
+)
+
+func TestFeeEstimator_EstimateFee(t *testing.T) {
+	btcChain := tbtcpg.NewLocalBitcoinChain()
+	btcChain.SetEstimateSatPerVByteFee(1, 20)
+	btcChain.SetEstimateSatPerVByteFee(6, 5)
+
+	feeEstimator := tbtcpg.NewFeeEstimator(
+		btcChain,
+		map[tbtc.WalletActionType]uint32{
+			tbtc.ActionDepositSweep: 6,
+		},
+	)
+
+	var tests = map[string]struct {
+		actionType  tbtc.WalletActionType
+		expectedFee int64
+	}{
+		"configured confirmation target": {
+			actionType:  tbtc.ActionDepositSweep,
+			expectedFee: 1000,
+		},
+		"default confirmation target": {
+			actionType:  tbtc.ActionRedemption,
+			expectedFee: 4000,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actualFee, err := feeEstimator.EstimateFee(test.actionType, 200)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"fee",
+				int(test.expectedFee),
+				int(actualFee),
+			)
+		})
+	}
+}
+
+func TestConfig_ConfirmationTargets(t *testing.T) {
+	config := &tbtcpg.Config{
+		DepositSweepConfirmationTarget: 6,
+		RedemptionConfirmationTarget:   2,
+		FeeBumpConfirmationTarget:      1,
+	}
+
+	targets := config.ConfirmationTargets()
+
+	testutils.AssertIntsEqual(t, "targets count", 4, len(targets))
+
+	expectedTargets := map[tbtc.WalletActionType]uint32{
+		tbtc.ActionDepositSweep: 6,
+		tbtc.ActionRedemption:   2,
+		tbtc.ActionRbf:          1,
+		tbtc.ActionCpfp:         1,
+	}
+	for actionType, expectedTarget := range expectedTargets {
+		testutils.AssertIntsEqual(
+			t,
+			actionType.String()+" target",
+			int(expectedTarget),
+			int(targets[actionType]),
+		)
+	}
+}
@@ -0,0 +1,54 @@
+package tbtcpg
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+// KeyShareRefreshTask is a task that may produce a key share refresh proposal.
+type KeyShareRefreshTask struct {
+	chain Chain
+}
+
+func NewKeyShareRefreshTask(chain Chain) *KeyShareRefreshTask {
+	return &KeyShareRefreshTask{
+		chain: chain,
+	}
+}
+
+func (ksrt *KeyShareRefreshTask) Run(request *tbtc.CoordinationProposalRequest) (
+	tbtc.CoordinationProposal,
+	bool,
+	error,
+) {
+	walletPublicKeyHash := request.WalletPublicKeyHash
+
+	taskLogger := logger.With(
+		zap.String("task", ksrt.ActionType().String()),
+		zap.String("walletPKH", fmt.Sprintf("0x%x", walletPublicKeyHash)),
+	)
+
+	walletChainData, err := ksrt.chain.GetWallet(walletPublicKeyHash)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot get wallet's chain data: [%w]",
+			err,
+		)
+	}
+
+	// Refreshing key shares of a wallet that is about to be closed does not
+	// make sense. Such a wallet should rather move its funds.
+	if walletChainData.State != tbtc.StateLive {
+		taskLogger.Infof("wallet not in Live state")
+		return nil, false, nil
+	}
+
+	return &tbtc.KeyShareRefreshProposal{}, true, nil
+}
+
+func (ksrt *KeyShareRefreshTask) ActionType() tbtc.WalletActionType {
+	return tbtc.ActionKeyShareRefresh
+}
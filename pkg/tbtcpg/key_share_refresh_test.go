@@ -0,0 +1,80 @@
+package tbtcpg
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+func TestKeyShareRefreshTask_Run(t *testing.T) {
+	walletPublicKeyHash := [20]byte{0x01, 0x02}
+
+	tests := map[string]struct {
+		walletChainData  *tbtc.WalletChainData
+		expectedProposal tbtc.CoordinationProposal
+		expectedOk       bool
+		expectedErr      error
+	}{
+		"live wallet": {
+			walletChainData:  &tbtc.WalletChainData{State: tbtc.StateLive},
+			expectedProposal: &tbtc.KeyShareRefreshProposal{},
+			expectedOk:       true,
+			expectedErr:      nil,
+		},
+		"moving funds wallet": {
+			walletChainData:  &tbtc.WalletChainData{State: tbtc.StateMovingFunds},
+			expectedProposal: nil,
+			expectedOk:       false,
+			expectedErr:      nil,
+		},
+		"unknown wallet": {
+			walletChainData:  nil,
+			expectedProposal: nil,
+			expectedOk:       false,
+			expectedErr: fmt.Errorf(
+				"cannot get wallet's chain data: [%w]",
+				fmt.Errorf("wallet chain data not found"),
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tbtcChain := NewLocalChain()
+
+			if test.walletChainData != nil {
+				tbtcChain.SetWallet(walletPublicKeyHash, test.walletChainData)
+			}
+
+			task := NewKeyShareRefreshTask(tbtcChain)
+
+			proposal, ok, err := task.Run(
+				&tbtc.CoordinationProposalRequest{
+					// Set only relevant fields.
+					WalletPublicKeyHash: walletPublicKeyHash,
+				},
+			)
+
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Errorf(
+					"unexpected error\nexpected: [%v]\nactual:   [%v]",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			testutils.AssertBoolsEqual(t, "boolean flag", test.expectedOk, ok)
+
+			if !reflect.DeepEqual(test.expectedProposal, proposal) {
+				t.Errorf(
+					"unexpected proposal\nexpected: [%v]\nactual:   [%v]",
+					test.expectedProposal,
+					proposal,
+				)
+			}
+		})
+	}
+}
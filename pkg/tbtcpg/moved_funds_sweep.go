@@ -30,17 +30,20 @@ const MovedFundsSweepLookBackBlocks = uint64(216000)
 
 // MovedFundsSweepTask is a task that may produce a moved funds sweep proposal.
 type MovedFundsSweepTask struct {
-	chain    Chain
-	btcChain bitcoin.Chain
+	chain        Chain
+	btcChain     bitcoin.Chain
+	feeEstimator FeeEstimator
 }
 
 func NewMovedFundsSweepTask(
 	chain Chain,
 	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
 ) *MovedFundsSweepTask {
 	return &MovedFundsSweepTask{
-		chain:    chain,
-		btcChain: btcChain,
+		chain:        chain,
+		btcChain:     btcChain,
+		feeEstimator: feeEstimator,
 	}
 }
 
@@ -333,7 +336,7 @@ func (mfst *MovedFundsSweepTask) ProposeMovedFundsSweep(
 		}
 
 		estimatedFee, err := EstimateMovedFundsSweepFee(
-			mfst.btcChain,
+			mfst.feeEstimator,
 			hasMainUtxo,
 			sweepTxMaxTotalFee,
 		)
@@ -376,7 +379,7 @@ func (mfst *MovedFundsSweepTask) ProposeMovedFundsSweep(
 // that merges the received main UTXO from the source wallets with the current
 // wallet's main UTXO.
 func EstimateMovedFundsSweepFee(
-	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
 	hasMainUtxo bool,
 	sweepTxMaxTotalFee uint64,
 ) (int64, error) {
@@ -400,9 +403,10 @@ func EstimateMovedFundsSweepFee(
 		)
 	}
 
-	feeEstimator := bitcoin.NewTransactionFeeEstimator(btcChain)
-
-	totalFee, err := feeEstimator.EstimateFee(transactionSize)
+	totalFee, err := feeEstimator.EstimateFee(
+		tbtc.ActionMovedFundsSweep,
+		transactionSize,
+	)
 	if err != nil {
 		return 0, fmt.Errorf("cannot estimate transaction fee: [%v]", err)
 	}
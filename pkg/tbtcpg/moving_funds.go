@@ -53,17 +53,20 @@ const MovingFundsCommitmentLookBackBlocks = uint64(216000)
 
 // MovingFundsTask is a task that may produce a moving funds proposal.
 type MovingFundsTask struct {
-	chain    Chain
-	btcChain bitcoin.Chain
+	chain        Chain
+	btcChain     bitcoin.Chain
+	feeEstimator FeeEstimator
 }
 
 func NewMovingFundsTask(
 	chain Chain,
 	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
 ) *MovingFundsTask {
 	return &MovingFundsTask{
-		chain:    chain,
-		btcChain: btcChain,
+		chain:        chain,
+		btcChain:     btcChain,
+		feeEstimator: feeEstimator,
 	}
 }
 
@@ -583,7 +586,7 @@ func (mft *MovingFundsTask) ProposeMovingFunds(
 		}
 
 		estimatedFee, err := EstimateMovingFundsFee(
-			mft.btcChain,
+			mft.feeEstimator,
 			len(targetWallets),
 			txMaxTotalFee,
 		)
@@ -629,7 +632,7 @@ func (mft *MovingFundsTask) ActionType() tbtc.WalletActionType {
 // EstimateMovingFundsFee estimates fee for the moving funds transaction that
 // moves funds from the source wallet to target wallets.
 func EstimateMovingFundsFee(
-	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
 	targetWalletsCount int,
 	txMaxTotalFee uint64,
 ) (int64, error) {
@@ -645,9 +648,10 @@ func EstimateMovingFundsFee(
 		)
 	}
 
-	feeEstimator := bitcoin.NewTransactionFeeEstimator(btcChain)
-
-	totalFee, err := feeEstimator.EstimateFee(transactionSize)
+	totalFee, err := feeEstimator.EstimateFee(
+		tbtc.ActionMovingFunds,
+		transactionSize,
+	)
 	if err != nil {
 		return 0, fmt.Errorf("cannot estimate transaction fee: [%v]", err)
 	}
@@ -150,7 +150,7 @@ func TestMovingFundsAction_FindTargetWallets_CommitmentNotSubmittedYet(t *testin
 				)
 			}
 
-			task := tbtcpg.NewMovingFundsTask(tbtcChain, nil)
+			task := tbtcpg.NewMovingFundsTask(tbtcChain, nil, nil)
 
 			// Always simulate the moving funds commitment has not been
 			// submitted yet.
@@ -287,7 +287,7 @@ func TestMovingFundsAction_FindTargetWallets_CommitmentAlreadySubmitted(t *testi
 				t.Fatal(err)
 			}
 
-			task := tbtcpg.NewMovingFundsTask(tbtcChain, nil)
+			task := tbtcpg.NewMovingFundsTask(tbtcChain, nil, nil)
 
 			// Live wallets count and wallet's balance don't matter, as we are
 			// retrieving target wallets from an already submitted commitment.
@@ -366,7 +366,7 @@ func TestMovingFundsAction_GetWalletMembersInfo(t *testing.T) {
 		t.Run(testName, func(t *testing.T) {
 			tbtcChain := tbtcpg.NewLocalChain()
 
-			task := tbtcpg.NewMovingFundsTask(tbtcChain, nil)
+			task := tbtcpg.NewMovingFundsTask(tbtcChain, nil, nil)
 
 			walletOperators := []chain.Address{}
 			for _, operatorInfo := range test.walletOperators {
@@ -469,7 +469,7 @@ func TestMovingFundsAction_SubmitMovingFundsCommitment(t *testing.T) {
 			blockCounter.SetCurrentBlock(currentBlock)
 			tbtcChain.SetBlockCounter(blockCounter)
 
-			task := tbtcpg.NewMovingFundsTask(tbtcChain, nil)
+			task := tbtcpg.NewMovingFundsTask(tbtcChain, nil, nil)
 
 			err := task.SubmitMovingFundsCommitment(
 				&testutils.MockLogger{},
@@ -627,7 +627,11 @@ func TestMovingFundsAction_ProposeMovingFunds(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			task := tbtcpg.NewMovingFundsTask(tbtcChain, btcChain)
+			task := tbtcpg.NewMovingFundsTask(
+				tbtcChain,
+				btcChain,
+				tbtcpg.NewFeeEstimator(btcChain, nil),
+			)
 
 			proposal, err := task.ProposeMovingFunds(
 				&testutils.MockLogger{},
@@ -673,7 +677,7 @@ func TestEstimateMovingFundsFee(t *testing.T) {
 			targetWalletsCount := 4
 
 			actualFee, err := tbtcpg.EstimateMovingFundsFee(
-				btcChain,
+				tbtcpg.NewFeeEstimator(btcChain, nil),
 				targetWalletsCount,
 				test.txMaxTotalFee,
 			)
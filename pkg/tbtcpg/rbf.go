@@ -0,0 +1,159 @@
+package tbtcpg
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ipfs/go-log/v2"
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+// rbfStuckTransactionMarginConfirmations is the margin added to the
+// tbtc.RbfStuckTransactionConfirmations before a transaction is proposed for
+// a fee bump. The margin gives operators whose Bitcoin nodes are a few blocks
+// behind the leader's one the chance to consider the transaction stuck as
+// well.
+const rbfStuckTransactionMarginConfirmations = 2
+
+// RbfTask is a task that may produce a replace-by-fee proposal for a wallet
+// transaction stuck in the Bitcoin mempool.
+type RbfTask struct {
+	chain        Chain
+	btcChain     bitcoin.Chain
+	feeEstimator FeeEstimator
+}
+
+func NewRbfTask(
+	chain Chain,
+	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
+) *RbfTask {
+	return &RbfTask{
+		chain:        chain,
+		btcChain:     btcChain,
+		feeEstimator: feeEstimator,
+	}
+}
+
+func (rt *RbfTask) Run(request *tbtc.CoordinationProposalRequest) (
+	tbtc.CoordinationProposal,
+	bool,
+	error,
+) {
+	walletPublicKeyHash := request.WalletPublicKeyHash
+
+	taskLogger := logger.With(
+		zap.String("task", rt.ActionType().String()),
+		zap.String("walletPKH", fmt.Sprintf("0x%x", walletPublicKeyHash)),
+	)
+
+	mempoolTransactions, err := rt.btcChain.GetMempoolForPublicKeyHash(
+		walletPublicKeyHash,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"cannot get mempool transactions: [%w]",
+			err,
+		)
+	}
+
+	for _, mempoolTransaction := range mempoolTransactions {
+		transactionHash := mempoolTransaction.Hash()
+
+		transactionLogger := taskLogger.With(
+			zap.String(
+				"txHash",
+				transactionHash.Hex(bitcoin.ReversedByteOrder),
+			),
+		)
+
+		candidate, err := tbtc.FindRbfCandidate(
+			walletPublicKeyHash,
+			transactionHash,
+			tbtc.RbfStuckTransactionConfirmations+
+				rbfStuckTransactionMarginConfirmations,
+			rt.chain,
+			rt.btcChain,
+		)
+		if err != nil {
+			transactionLogger.Infof(
+				"transaction cannot be replaced: [%v]",
+				err,
+			)
+			continue
+		}
+
+		proposal, ok, err := rt.ProposeRbf(
+			transactionLogger,
+			walletPublicKeyHash,
+			candidate,
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf(
+				"cannot prepare RBF proposal: [%w]",
+				err,
+			)
+		}
+
+		if ok {
+			return proposal, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// ProposeRbf returns a replace-by-fee proposal for the given stuck
+// transaction. The new fee is estimated for the transaction to be confirmed
+// within the next block and is capped by the Bridge's limits. The returned
+// boolean flag is false if the fee cannot be bumped within these limits.
+func (rt *RbfTask) ProposeRbf(
+	taskLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+	candidate *tbtc.RbfCandidate,
+) (*tbtc.RbfProposal, bool, error) {
+	estimatedFee, err := rt.feeEstimator.EstimateFee(
+		rt.ActionType(),
+		candidate.Transaction.VirtualSize(),
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot estimate fee: [%w]", err)
+	}
+
+	newFee, ok, err := tbtc.ComputeRbfFee(
+		rt.chain,
+		rt.btcChain,
+		walletPublicKeyHash,
+		candidate,
+		estimatedFee,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot compute new fee: [%w]", err)
+	}
+
+	if !ok {
+		taskLogger.Warnf(
+			"transaction is stuck but its fee cannot be bumped " +
+				"within the Bridge's limits",
+		)
+		return nil, false, nil
+	}
+
+	taskLogger.Infof(
+		"proposing fee bump to [%v] satoshi; estimated fee is [%v] satoshi",
+		newFee,
+		estimatedFee,
+	)
+
+	return &tbtc.RbfProposal{
+		TransactionHash: candidate.Transaction.Hash(),
+		NewFee:          big.NewInt(newFee),
+	}, true, nil
+}
+
+func (rt *RbfTask) ActionType() tbtc.WalletActionType {
+	return tbtc.ActionRbf
+}
@@ -0,0 +1,201 @@
+package tbtcpg
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+func TestRbfTask_Run(t *testing.T) {
+	walletPublicKeyHash := [20]byte{1, 2, 3}
+
+	walletScript, err := bitcoin.PayToWitnessPublicKeyHash(walletPublicKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redeemerScript, err := bitcoin.PayToWitnessPublicKeyHash([20]byte{4, 5, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mainUtxoTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: bitcoin.Hash{0xaa},
+					OutputIndex:     0,
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 100000, PublicKeyScript: walletScript},
+		},
+	}
+
+	// Redemption transaction paying 1000 satoshi of fee.
+	redemptionTransaction := &bitcoin.Transaction{
+		Version: 1,
+		Inputs: []*bitcoin.TransactionInput{
+			{
+				Outpoint: &bitcoin.TransactionOutpoint{
+					TransactionHash: mainUtxoTransaction.Hash(),
+					OutputIndex:     0,
+				},
+				Witness: [][]byte{
+					bytes.Repeat([]byte{0x30}, 72),
+					bytes.Repeat([]byte{0x02}, 33),
+				},
+				Sequence: 0xffffffff,
+			},
+		},
+		Outputs: []*bitcoin.TransactionOutput{
+			{Value: 60000, PublicKeyScript: walletScript},
+			{Value: 39000, PublicKeyScript: redeemerScript},
+		},
+	}
+
+	mainUtxo := &bitcoin.UnspentTransactionOutput{
+		Outpoint: &bitcoin.TransactionOutpoint{
+			TransactionHash: mainUtxoTransaction.Hash(),
+			OutputIndex:     0,
+		},
+		Value: 100000,
+	}
+
+	virtualSize := redemptionTransaction.VirtualSize()
+	minFee := 1000 + virtualSize
+
+	stuckConfirmations := uint(
+		tbtc.RbfStuckTransactionConfirmations +
+			rbfStuckTransactionMarginConfirmations,
+	)
+
+	var tests = map[string]struct {
+		mainUtxoConfirmations uint
+		confirmations         uint
+		requestTxMaxFee       uint64
+		satPerVByteFee        int64
+		expectedProposal      tbtc.CoordinationProposal
+	}{
+		"stuck transaction with estimated fee": {
+			mainUtxoConfirmations: stuckConfirmations,
+			requestTxMaxFee:       5000,
+			satPerVByteFee:        20,
+			expectedProposal: &tbtc.RbfProposal{
+				TransactionHash: redemptionTransaction.Hash(),
+				NewFee:          big.NewInt(20 * virtualSize),
+			},
+		},
+		"stuck transaction with minimum fee": {
+			mainUtxoConfirmations: stuckConfirmations,
+			requestTxMaxFee:       5000,
+			satPerVByteFee:        1,
+			expectedProposal: &tbtc.RbfProposal{
+				TransactionHash: redemptionTransaction.Hash(),
+				NewFee:          big.NewInt(minFee),
+			},
+		},
+		"stuck transaction with maximum fee": {
+			mainUtxoConfirmations: stuckConfirmations,
+			requestTxMaxFee:       5000,
+			satPerVByteFee:        100,
+			expectedProposal: &tbtc.RbfProposal{
+				TransactionHash: redemptionTransaction.Hash(),
+				NewFee:          big.NewInt(5000),
+			},
+		},
+		"stuck transaction whose fee cannot be bumped": {
+			mainUtxoConfirmations: stuckConfirmations,
+			requestTxMaxFee:       1000,
+			satPerVByteFee:        20,
+			expectedProposal:      nil,
+		},
+		"transaction not stuck yet": {
+			mainUtxoConfirmations: stuckConfirmations - 1,
+			requestTxMaxFee:       5000,
+			satPerVByteFee:        20,
+			expectedProposal:      nil,
+		},
+		"confirmed transaction": {
+			mainUtxoConfirmations: stuckConfirmations,
+			confirmations:         1,
+			requestTxMaxFee:       5000,
+			satPerVByteFee:        20,
+			expectedProposal:      nil,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tbtcChain := NewLocalChain()
+			tbtcChain.SetWallet(
+				walletPublicKeyHash,
+				&tbtc.WalletChainData{
+					MainUtxoHash: tbtcChain.ComputeMainUtxoHash(mainUtxo),
+					State:        tbtc.StateLive,
+				},
+			)
+			tbtcChain.SetRedemptionParameters(0, 0, 0, 10000, 0, nil, 0)
+			tbtcChain.SetPendingRedemptionRequest(
+				walletPublicKeyHash,
+				&tbtc.RedemptionRequest{
+					RedeemerOutputScript: redeemerScript,
+					RequestedAmount:      40000,
+					TxMaxFee:             test.requestTxMaxFee,
+				},
+			)
+
+			btcChain := NewLocalBitcoinChain()
+			btcChain.SetTransaction(mainUtxoTransaction.Hash(), mainUtxoTransaction)
+			btcChain.SetTransactionConfirmations(
+				mainUtxoTransaction.Hash(),
+				test.mainUtxoConfirmations,
+			)
+			btcChain.AddMempoolTransaction(redemptionTransaction)
+			btcChain.SetTransactionConfirmations(
+				redemptionTransaction.Hash(),
+				test.confirmations,
+			)
+			btcChain.SetEstimateSatPerVByteFee(1, test.satPerVByteFee)
+
+			task := NewRbfTask(
+				tbtcChain,
+				btcChain,
+				NewFeeEstimator(btcChain, nil),
+			)
+
+			proposal, ok, err := task.Run(&tbtc.CoordinationProposalRequest{
+				WalletPublicKeyHash: walletPublicKeyHash,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.expectedProposal == nil {
+				if ok {
+					t.Fatalf("unexpected proposal: [%v]", proposal)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatal("expected proposal")
+			}
+
+			if !reflect.DeepEqual(test.expectedProposal, proposal) {
+				t.Errorf(
+					"unexpected proposal\nexpected: %v\nactual:   %v",
+					test.expectedProposal,
+					proposal,
+				)
+			}
+		})
+	}
+}
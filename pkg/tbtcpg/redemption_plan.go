@@ -0,0 +1,515 @@
+package tbtcpg
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-log/v2"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+const (
+	// redemptionProofChangeCheckGas approximates the gas the Bridge spends
+	// on comparing a single output of the redemption transaction with the
+	// wallet's P2PKH and P2WPKH scripts while looking for the change during
+	// the redemption proof. Once the change is found, subsequent outputs are
+	// no longer compared.
+	redemptionProofChangeCheckGas = 60
+	// mainUtxoNonZeroIndexGas is the extra calldata gas of each subsequent
+	// proof referencing the wallet's main UTXO whose output index is not
+	// zero. The index is ABI-encoded as a 32-byte word and a non-zero byte
+	// costs 12 gas more than a zero one.
+	mainUtxoNonZeroIndexGas = 12
+)
+
+// RedemptionDeferral represents a redemption request deferred to a later
+// redemption along with the reason.
+type RedemptionDeferral struct {
+	Request *RedemptionRequest
+	Reason  string
+}
+
+// RedemptionPlan is the outcome of redemption planning. It holds requests
+// chosen for the redemption, their fee shares and the reasoning behind all
+// choices.
+type RedemptionPlan struct {
+	// Included are requests chosen for the redemption, closest to the
+	// redemption timeout first.
+	Included []*RedemptionRequest
+	// Deferred are requests left for a later redemption.
+	Deferred []*RedemptionDeferral
+	// TotalFee is the estimated fee of the redemption transaction,
+	// in satoshi.
+	TotalFee int64
+	// FeeShares are shares of the total fee incurred by included requests,
+	// ordered in the same way as included requests.
+	FeeShares []int64
+	// TransactionShape is the redemption transaction shape with the lower
+	// estimated SPV proof cost.
+	TransactionShape tbtc.RedemptionTransactionShape
+	// ProofGas maps transaction shapes to the estimated gas cost of proving
+	// the redemption transaction and referencing its change as the wallet's
+	// main UTXO.
+	ProofGas map[tbtc.RedemptionTransactionShape]uint64
+}
+
+// RedeemersOutputScripts returns redeemer output scripts of requests chosen
+// for the redemption.
+func (rp *RedemptionPlan) RedeemersOutputScripts() []bitcoin.Script {
+	scripts := make([]bitcoin.Script, len(rp.Included))
+	for i, request := range rp.Included {
+		scripts[i] = request.RedeemerOutputScript
+	}
+
+	return scripts
+}
+
+// String returns a human-readable report of the plan.
+func (rp *RedemptionPlan) String() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(
+		&builder,
+		"total fee: [%d] sat; transaction shape: [%s]; proof gas: "+
+			"[ChangeFirst: %d, ChangeLast: %d]\n",
+		rp.TotalFee,
+		rp.TransactionShape,
+		rp.ProofGas[tbtc.RedemptionChangeFirst],
+		rp.ProofGas[tbtc.RedemptionChangeLast],
+	)
+
+	for i, request := range rp.Included {
+		fmt.Fprintf(
+			&builder,
+			"included request [%s]: requested at [%s], fee share [%d] sat, "+
+				"max fee [%d] sat\n",
+			request.RedemptionKey,
+			request.RequestedAt.UTC().Format(time.RFC3339),
+			rp.FeeShares[i],
+			request.TxMaxFee,
+		)
+	}
+
+	for _, deferral := range rp.Deferred {
+		fmt.Fprintf(
+			&builder,
+			"deferred request [%s]: %s\n",
+			deferral.Request.RedemptionKey,
+			deferral.Reason,
+		)
+	}
+
+	return builder.String()
+}
+
+// redemptionPlanParameters holds parameters relevant for redemption
+// planning.
+type redemptionPlanParameters struct {
+	maxSize       uint16
+	txMaxTotalFee uint64
+	// mainUtxoValue is the value of the wallet main UTXO, in satoshi.
+	mainUtxoValue int64
+	// feeSharesEnabled determines whether the fee is allocated
+	// proportionally to maximum fees of requests or split evenly.
+	feeSharesEnabled bool
+}
+
+// PlanRedemption decides which pending redemption requests of the given
+// wallet should be handled by the next redemption. Requests closest to the
+// redemption timeout take priority. Requests that do not fit the batch or
+// the fee limits are deferred to a later redemption. The returned plan
+// explains all choices.
+func (rt *RedemptionTask) PlanRedemption(
+	taskLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+) (*RedemptionPlan, error) {
+	if walletPublicKeyHash == [20]byte{} {
+		return nil, fmt.Errorf("wallet public key hash is required")
+	}
+
+	maxSize, err := rt.chain.GetRedemptionMaxSize()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get redemption max size: [%w]",
+			err,
+		)
+	}
+
+	_, _, _, txMaxTotalFee, _, _, _, err := rt.chain.GetRedemptionParameters()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get redemption parameters: [%w]",
+			err,
+		)
+	}
+
+	walletMainUtxo, err := tbtc.DetermineWalletMainUtxo(
+		walletPublicKeyHash,
+		rt.chain,
+		rt.btcChain,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot determine wallet's main UTXO: [%w]",
+			err,
+		)
+	}
+
+	if walletMainUtxo == nil {
+		taskLogger.Info("wallet has no main UTXO; nothing to redeem from")
+		return &RedemptionPlan{}, nil
+	}
+
+	// Take all eligible requests into account; the planner limits the batch
+	// size on its own.
+	requests, err := rt.fetchPendingRedemptions(
+		taskLogger,
+		walletPublicKeyHash,
+		0,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return planRedemption(
+		requests,
+		&redemptionPlanParameters{
+			maxSize:          maxSize,
+			txMaxTotalFee:    txMaxTotalFee,
+			mainUtxoValue:    walletMainUtxo.Value,
+			feeSharesEnabled: rt.feeSharesEnabled,
+		},
+		func(scripts []bitcoin.Script) (int64, error) {
+			return EstimateRedemptionFee(rt.feeEstimator, scripts)
+		},
+	)
+}
+
+// ProposeRedemptionPlan returns a redemption proposal built upon the given
+// redemption plan.
+func (rt *RedemptionTask) ProposeRedemptionPlan(
+	taskLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+	plan *RedemptionPlan,
+) (*tbtc.RedemptionProposal, error) {
+	if len(plan.Included) == 0 {
+		return nil, fmt.Errorf("redemptions list is empty")
+	}
+
+	taskLogger.Infof("preparing a redemption proposal")
+
+	proposal := &tbtc.RedemptionProposal{
+		RedeemersOutputScripts: plan.RedeemersOutputScripts(),
+		RedemptionTxFee:        big.NewInt(plan.TotalFee),
+		TransactionShape:       plan.TransactionShape,
+	}
+
+	// Without fee shares, the fee is split evenly which is exactly what
+	// the plan assumed.
+	if rt.feeSharesEnabled {
+		proposal.RedemptionTxFeeShares = make([]*big.Int, len(plan.FeeShares))
+		for i, feeShare := range plan.FeeShares {
+			proposal.RedemptionTxFeeShares[i] = big.NewInt(feeShare)
+		}
+	}
+
+	if err := rt.validateProposal(
+		taskLogger,
+		walletPublicKeyHash,
+		proposal,
+	); err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+// planRedemption builds a redemption plan for the given pending requests.
+// The estimateFeeFn returns the total fee of a redemption transaction paying
+// the given redeemer output scripts.
+//
+// Requests are ordered by the time left until their redemption timeout and
+// the ones above the maximum redemption size are deferred. Then, the fee is
+// estimated and requests are deferred, until the remaining ones satisfy all
+// of the following:
+//   - the total fee does not exceed the maximum total fee; the request
+//     with the most time left until the timeout is deferred first,
+//   - the fee split evenly, as assumed by the on-chain proposal validation,
+//     does not exceed the maximum fee of any request,
+//   - the fee share of each request is lower than its redeemable amount.
+//
+// If fee shares are enabled, the fee is allocated proportionally to the
+// maximum fees of requests so requests accepting higher fees pay more, yet
+// none pays more than its maximum fee. Otherwise, the fee is split evenly.
+// Finally, the transaction shape with the lower estimated SPV proof cost
+// is chosen.
+func planRedemption(
+	requests []*RedemptionRequest,
+	parameters *redemptionPlanParameters,
+	estimateFeeFn func(scripts []bitcoin.Script) (int64, error),
+) (*RedemptionPlan, error) {
+	plan := &RedemptionPlan{}
+
+	deferRequest := func(
+		request *RedemptionRequest,
+		reason string,
+		args ...interface{},
+	) {
+		plan.Deferred = append(
+			plan.Deferred,
+			&RedemptionDeferral{
+				Request: request,
+				Reason:  fmt.Sprintf(reason, args...),
+			},
+		)
+	}
+
+	// All requests share the same timeout so the ones requested earlier
+	// are closer to their timeout.
+	candidates := make([]*RedemptionRequest, len(requests))
+	copy(candidates, requests)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].RequestedAt.Before(candidates[j].RequestedAt)
+	})
+
+	if len(candidates) > int(parameters.maxSize) {
+		for _, request := range candidates[parameters.maxSize:] {
+			deferRequest(
+				request,
+				"redemption batch is full; maximum size is [%d]",
+				parameters.maxSize,
+			)
+		}
+		candidates = candidates[:parameters.maxSize]
+	}
+
+	for len(candidates) > 0 {
+		scripts := make([]bitcoin.Script, len(candidates))
+		for i, request := range candidates {
+			scripts[i] = request.RedeemerOutputScript
+		}
+
+		totalFee, err := estimateFeeFn(scripts)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot estimate fee for [%d] requests: [%v]",
+				len(candidates),
+				err,
+			)
+		}
+
+		// The fee grows with the number of outputs so dropping the request
+		// with the most time left lowers the total fee.
+		if uint64(totalFee) > parameters.txMaxTotalFee {
+			last := candidates[len(candidates)-1]
+			deferRequest(
+				last,
+				"total fee [%d] sat for [%d] requests exceeds the maximum "+
+					"total fee [%d] sat",
+				totalFee,
+				len(candidates),
+				parameters.txMaxTotalFee,
+			)
+			candidates = candidates[:len(candidates)-1]
+			continue
+		}
+
+		// The on-chain proposal validation checks the fee split evenly
+		// against maximum fees of requests, regardless of fee shares.
+		evenFeeShares := splitRedemptionFee(totalFee, candidates)
+
+		remaining := make([]*RedemptionRequest, 0, len(candidates))
+		for i, request := range candidates {
+			feeShare := evenFeeShares[i]
+			if uint64(feeShare) > request.TxMaxFee {
+				deferRequest(
+					request,
+					"even fee share [%d] sat exceeds the maximum fee "+
+						"[%d] sat",
+					feeShare,
+					request.TxMaxFee,
+				)
+				continue
+			}
+
+			remaining = append(remaining, request)
+		}
+
+		if len(remaining) != len(candidates) {
+			candidates = remaining
+			continue
+		}
+
+		feeShares := evenFeeShares
+		if parameters.feeSharesEnabled {
+			feeShares = allocateRedemptionFee(totalFee, candidates)
+		}
+
+		remaining = make([]*RedemptionRequest, 0, len(candidates))
+		for i, request := range candidates {
+			redeemableAmount := int64(
+				request.RequestedAmount - request.TreasuryFee,
+			)
+			if feeShares[i] >= redeemableAmount {
+				deferRequest(
+					request,
+					"fee share [%d] sat is not lower than the redeemable "+
+						"amount [%d] sat",
+					feeShares[i],
+					redeemableAmount,
+				)
+				continue
+			}
+
+			remaining = append(remaining, request)
+		}
+
+		if len(remaining) != len(candidates) {
+			candidates = remaining
+			continue
+		}
+
+		plan.Included = candidates
+		plan.TotalFee = totalFee
+		plan.FeeShares = feeShares
+		break
+	}
+
+	if len(plan.Included) == 0 {
+		return plan, nil
+	}
+
+	totalRedeemableAmount := int64(0)
+	for _, request := range plan.Included {
+		totalRedeemableAmount += int64(
+			request.RequestedAmount - request.TreasuryFee,
+		)
+	}
+	// The transaction fee is covered by redeemers so the change is the
+	// main UTXO value minus the total redeemable amount.
+	hasChange := parameters.mainUtxoValue > totalRedeemableAmount
+
+	plan.ProofGas = map[tbtc.RedemptionTransactionShape]uint64{
+		tbtc.RedemptionChangeFirst: estimateRedemptionProofGas(
+			tbtc.RedemptionChangeFirst,
+			len(plan.Included),
+			hasChange,
+		),
+		tbtc.RedemptionChangeLast: estimateRedemptionProofGas(
+			tbtc.RedemptionChangeLast,
+			len(plan.Included),
+			hasChange,
+		),
+	}
+
+	plan.TransactionShape = tbtc.RedemptionChangeFirst
+	if plan.ProofGas[tbtc.RedemptionChangeLast] <
+		plan.ProofGas[tbtc.RedemptionChangeFirst] {
+		plan.TransactionShape = tbtc.RedemptionChangeLast
+	}
+
+	return plan, nil
+}
+
+// estimateRedemptionProofGas estimates the part of the SPV proof cost that
+// depends on the shape of a redemption transaction with the given number of
+// redemption outputs. While processing the proof, the Bridge compares
+// outputs with the wallet's scripts until it finds the change so the change
+// being the first output saves comparisons of all other outputs. Moreover,
+// every subsequent proof spending the change references it as the wallet's
+// main UTXO so a non-zero output index makes each of them pay slightly more
+// for calldata. Costs independent of the shape, such as the transaction
+// bytes and the Merkle proof, are omitted.
+func estimateRedemptionProofGas(
+	shape tbtc.RedemptionTransactionShape,
+	redemptionOutputsCount int,
+	hasChange bool,
+) uint64 {
+	if !hasChange {
+		return uint64(redemptionOutputsCount) * redemptionProofChangeCheckGas
+	}
+
+	switch shape {
+	case tbtc.RedemptionChangeLast:
+		return uint64(redemptionOutputsCount+1)*redemptionProofChangeCheckGas +
+			mainUtxoNonZeroIndexGas
+	default:
+		return redemptionProofChangeCheckGas
+	}
+}
+
+// splitRedemptionFee splits the total fee evenly over the given requests,
+// the same way as the wallet does if the proposal carries no fee shares.
+// The remainder is added to the share of the last request.
+func splitRedemptionFee(
+	totalFee int64,
+	requests []*RedemptionRequest,
+) []int64 {
+	feeShares := make([]int64, len(requests))
+	for i := range requests {
+		feeShares[i] = totalFee / int64(len(requests))
+	}
+	feeShares[len(feeShares)-1] += totalFee % int64(len(requests))
+
+	return feeShares
+}
+
+// allocateRedemptionFee distributes the total fee over the given requests
+// proportionally to their maximum fees. The total fee must not exceed the
+// sum of maximum fees. Shares rounded down leave a remainder that is
+// distributed one satoshi per request, in order, among requests whose
+// share is still below their maximum fee.
+func allocateRedemptionFee(
+	totalFee int64,
+	requests []*RedemptionRequest,
+) []int64 {
+	totalMaxFee := big.NewInt(0)
+	for _, request := range requests {
+		totalMaxFee.Add(totalMaxFee, new(big.Int).SetUint64(request.TxMaxFee))
+	}
+
+	feeShares := make([]int64, len(requests))
+	if totalMaxFee.Sign() == 0 {
+		return feeShares
+	}
+
+	allocated := int64(0)
+	for i, request := range requests {
+		feeShare := new(big.Int).Mul(
+			big.NewInt(totalFee),
+			new(big.Int).SetUint64(request.TxMaxFee),
+		)
+		feeShare.Div(feeShare, totalMaxFee)
+
+		feeShares[i] = feeShare.Int64()
+		allocated += feeShares[i]
+	}
+
+	for remainder := totalFee - allocated; remainder > 0; {
+		progress := false
+		for i, request := range requests {
+			if remainder == 0 {
+				break
+			}
+
+			if uint64(feeShares[i]) < request.TxMaxFee {
+				feeShares[i]++
+				remainder--
+				progress = true
+			}
+		}
+
+		// Should never happen as the total fee does not exceed the sum
+		// of maximum fees.
+		if !progress {
+			break
+		}
+	}
+
+	return feeShares
+}
@@ -0,0 +1,339 @@
+package tbtcpg
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+func TestPlanRedemption(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	// Creates a request with the given key, age, requested amount and
+	// maximum fee. The redeemer output script is unique per key.
+	newRequest := func(
+		key string,
+		age time.Duration,
+		requestedAmount uint64,
+		txMaxFee uint64,
+	) *RedemptionRequest {
+		return &RedemptionRequest{
+			RedemptionKey:        key,
+			RedeemerOutputScript: bitcoin.Script(key),
+			RequestedAt:          now.Add(-age),
+			RequestedAmount:      requestedAmount,
+			TreasuryFee:          1000,
+			TxMaxFee:             txMaxFee,
+		}
+	}
+
+	defaultParameters := &redemptionPlanParameters{
+		maxSize:          3,
+		txMaxTotalFee:    50000,
+		mainUtxoValue:    100000000,
+		feeSharesEnabled: true,
+	}
+
+	// Fee of 3000 satoshi per request.
+	perRequestFee := func(scripts []bitcoin.Script) (int64, error) {
+		return int64(len(scripts)) * 3000, nil
+	}
+
+	var tests = map[string]struct {
+		requests      []*RedemptionRequest
+		parameters    *redemptionPlanParameters
+		estimateFeeFn func(scripts []bitcoin.Script) (int64, error)
+
+		expectedIncluded  []string
+		expectedFeeShares []int64
+		expectedDeferred  []string
+		expectedTotalFee  int64
+		expectedErr       error
+	}{
+		"no requests": {
+			requests:      []*RedemptionRequest{},
+			parameters:    defaultParameters,
+			estimateFeeFn: perRequestFee,
+		},
+		"fee allocated proportionally to maximum fees": {
+			requests: []*RedemptionRequest{
+				newRequest("young", time.Hour, 1000000, 10000),
+				newRequest("old", 2*time.Hour, 1000000, 5000),
+			},
+			parameters:        defaultParameters,
+			estimateFeeFn:     perRequestFee,
+			expectedIncluded:  []string{"old", "young"},
+			expectedFeeShares: []int64{2000, 4000},
+			expectedTotalFee:  6000,
+		},
+		"fee split evenly when fee shares are disabled": {
+			requests: []*RedemptionRequest{
+				newRequest("young", time.Hour, 1000000, 10000),
+				newRequest("old", 2*time.Hour, 1000000, 5000),
+			},
+			parameters: &redemptionPlanParameters{
+				maxSize:       3,
+				txMaxTotalFee: 50000,
+				mainUtxoValue: 100000000,
+			},
+			estimateFeeFn: func(scripts []bitcoin.Script) (int64, error) {
+				return 6001, nil
+			},
+			expectedIncluded:  []string{"old", "young"},
+			expectedFeeShares: []int64{3000, 3001},
+			expectedTotalFee:  6001,
+		},
+		// The on-chain proposal validation checks the even fee split even
+		// if the fee shares of all requests fit their maximum fees.
+		"request with maximum fee below even fee split deferred": {
+			requests: []*RedemptionRequest{
+				newRequest("cheap", 2*time.Hour, 1000000, 2000),
+				newRequest("regular", time.Hour, 1000000, 10000),
+			},
+			parameters:        defaultParameters,
+			estimateFeeFn:     perRequestFee,
+			expectedIncluded:  []string{"regular"},
+			expectedFeeShares: []int64{3000},
+			expectedDeferred:  []string{"cheap"},
+			expectedTotalFee:  3000,
+		},
+		"fee remainder allocated within maximum fees": {
+			requests: []*RedemptionRequest{
+				newRequest("first", 3*time.Hour, 1000000, 4),
+				newRequest("second", 2*time.Hour, 1000000, 4),
+				newRequest("third", time.Hour, 1000000, 5),
+			},
+			parameters: defaultParameters,
+			estimateFeeFn: func(scripts []bitcoin.Script) (int64, error) {
+				return 10, nil
+			},
+			expectedIncluded:  []string{"first", "second", "third"},
+			expectedFeeShares: []int64{4, 3, 3},
+			expectedTotalFee:  10,
+		},
+		"requests closest to the timeout prioritized in full batch": {
+			requests: []*RedemptionRequest{
+				newRequest("newest", time.Hour, 1000000, 10000),
+				newRequest("oldest", 4*time.Hour, 1000000, 10000),
+				newRequest("older", 3*time.Hour, 1000000, 10000),
+				newRequest("old", 2*time.Hour, 1000000, 10000),
+			},
+			parameters:        defaultParameters,
+			estimateFeeFn:     perRequestFee,
+			expectedIncluded:  []string{"oldest", "older", "old"},
+			expectedFeeShares: []int64{3000, 3000, 3000},
+			expectedDeferred:  []string{"newest"},
+			expectedTotalFee:  9000,
+		},
+		"request with too low maximum fee deferred": {
+			requests: []*RedemptionRequest{
+				newRequest("cheap", 2*time.Hour, 1000000, 1000),
+				newRequest("regular", time.Hour, 1000000, 4000),
+			},
+			parameters:        defaultParameters,
+			estimateFeeFn:     perRequestFee,
+			expectedIncluded:  []string{"regular"},
+			expectedFeeShares: []int64{3000},
+			expectedDeferred:  []string{"cheap"},
+			expectedTotalFee:  3000,
+		},
+		"requests deferred until total fee fits": {
+			requests: []*RedemptionRequest{
+				newRequest("old", 2*time.Hour, 1000000, 10000),
+				newRequest("young", time.Hour, 1000000, 10000),
+			},
+			parameters: &redemptionPlanParameters{
+				maxSize:          3,
+				txMaxTotalFee:    5000,
+				mainUtxoValue:    100000000,
+				feeSharesEnabled: true,
+			},
+			estimateFeeFn:     perRequestFee,
+			expectedIncluded:  []string{"old"},
+			expectedFeeShares: []int64{3000},
+			expectedDeferred:  []string{"young"},
+			expectedTotalFee:  3000,
+		},
+		"request with fee share above redeemable amount deferred": {
+			requests: []*RedemptionRequest{
+				newRequest("dust", 2*time.Hour, 3500, 10000),
+				newRequest("regular", time.Hour, 1000000, 10000),
+			},
+			parameters:        defaultParameters,
+			estimateFeeFn:     perRequestFee,
+			expectedIncluded:  []string{"regular"},
+			expectedFeeShares: []int64{3000},
+			expectedDeferred:  []string{"dust"},
+			expectedTotalFee:  3000,
+		},
+		"all requests redeemed with no change": {
+			requests: []*RedemptionRequest{
+				newRequest("first", 2*time.Hour, 50000, 10000),
+				newRequest("second", time.Hour, 50000, 10000),
+			},
+			parameters: &redemptionPlanParameters{
+				maxSize:          3,
+				txMaxTotalFee:    50000,
+				mainUtxoValue:    98000,
+				feeSharesEnabled: true,
+			},
+			estimateFeeFn:     perRequestFee,
+			expectedIncluded:  []string{"first", "second"},
+			expectedFeeShares: []int64{3000, 3000},
+			expectedTotalFee:  6000,
+		},
+		"fee estimation error": {
+			requests: []*RedemptionRequest{
+				newRequest("regular", time.Hour, 1000000, 10000),
+			},
+			parameters: defaultParameters,
+			estimateFeeFn: func(scripts []bitcoin.Script) (int64, error) {
+				return 0, fmt.Errorf("unavailable")
+			},
+			expectedErr: fmt.Errorf(
+				"cannot estimate fee for [1] requests: [unavailable]",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			plan, err := planRedemption(
+				test.requests,
+				test.parameters,
+				test.estimateFeeFn,
+			)
+
+			if !reflect.DeepEqual(test.expectedErr, err) {
+				t.Fatalf(
+					"unexpected error\nexpected: [%v]\nactual:   [%v]",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			if err != nil {
+				return
+			}
+
+			actualIncluded := make([]string, 0)
+			for _, request := range plan.Included {
+				actualIncluded = append(actualIncluded, request.RedemptionKey)
+			}
+			assertStringSlicesEqual(
+				t,
+				"included requests",
+				test.expectedIncluded,
+				actualIncluded,
+			)
+
+			actualDeferred := make([]string, 0)
+			for _, deferral := range plan.Deferred {
+				actualDeferred = append(
+					actualDeferred,
+					deferral.Request.RedemptionKey,
+				)
+			}
+			assertStringSlicesEqual(
+				t,
+				"deferred requests",
+				test.expectedDeferred,
+				actualDeferred,
+			)
+
+			if len(test.expectedFeeShares) > 0 || len(plan.FeeShares) > 0 {
+				if !reflect.DeepEqual(test.expectedFeeShares, plan.FeeShares) {
+					t.Errorf(
+						"unexpected fee shares\nexpected: [%v]\nactual:   [%v]",
+						test.expectedFeeShares,
+						plan.FeeShares,
+					)
+				}
+			}
+
+			testutils.AssertIntsEqual(
+				t,
+				"total fee",
+				int(test.expectedTotalFee),
+				int(plan.TotalFee),
+			)
+
+			// Putting the change first is never more expensive to prove.
+			testutils.AssertStringsEqual(
+				t,
+				"transaction shape",
+				tbtc.RedemptionChangeFirst.String(),
+				plan.TransactionShape.String(),
+			)
+		})
+	}
+}
+
+func TestEstimateRedemptionProofGas(t *testing.T) {
+	var tests = map[string]struct {
+		shape                  tbtc.RedemptionTransactionShape
+		redemptionOutputsCount int
+		hasChange              bool
+		expectedGas            uint64
+	}{
+		"change first": {
+			shape:                  tbtc.RedemptionChangeFirst,
+			redemptionOutputsCount: 3,
+			hasChange:              true,
+			expectedGas:            redemptionProofChangeCheckGas,
+		},
+		"change last": {
+			shape:                  tbtc.RedemptionChangeLast,
+			redemptionOutputsCount: 3,
+			hasChange:              true,
+			expectedGas: 4*redemptionProofChangeCheckGas +
+				mainUtxoNonZeroIndexGas,
+		},
+		"no change": {
+			shape:                  tbtc.RedemptionChangeLast,
+			redemptionOutputsCount: 3,
+			hasChange:              false,
+			expectedGas:            3 * redemptionProofChangeCheckGas,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			testutils.AssertUintsEqual(
+				t,
+				"proof gas",
+				test.expectedGas,
+				estimateRedemptionProofGas(
+					test.shape,
+					test.redemptionOutputsCount,
+					test.hasChange,
+				),
+			)
+		})
+	}
+}
+
+func assertStringSlicesEqual(
+	t *testing.T,
+	description string,
+	expected []string,
+	actual []string,
+) {
+	if len(expected) == 0 && len(actual) == 0 {
+		return
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf(
+			"unexpected %s\nexpected: [%v]\nactual:   [%v]",
+			description,
+			expected,
+			actual,
+		)
+	}
+}
@@ -16,17 +16,25 @@ import (
 
 // RedemptionTask is a task that may produce a redemption proposal.
 type RedemptionTask struct {
-	chain    Chain
-	btcChain bitcoin.Chain
+	chain        Chain
+	btcChain     bitcoin.Chain
+	feeEstimator FeeEstimator
+	// feeSharesEnabled determines whether proposals carry fee shares of
+	// specific requests. If not, the fee is split evenly.
+	feeSharesEnabled bool
 }
 
 func NewRedemptionTask(
 	chain Chain,
 	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
+	feeSharesEnabled bool,
 ) *RedemptionTask {
 	return &RedemptionTask{
-		chain:    chain,
-		btcChain: btcChain,
+		chain:            chain,
+		btcChain:         btcChain,
+		feeEstimator:     feeEstimator,
+		feeSharesEnabled: feeSharesEnabled,
 	}
 }
 
@@ -42,36 +50,25 @@ func (rt *RedemptionTask) Run(request *tbtc.CoordinationProposalRequest) (
 		zap.String("walletPKH", fmt.Sprintf("0x%x", walletPublicKeyHash)),
 	)
 
-	redemptionMaxSize, err := rt.chain.GetRedemptionMaxSize()
+	plan, err := rt.PlanRedemption(taskLogger, walletPublicKeyHash)
 	if err != nil {
 		return nil, false, fmt.Errorf(
-			"failed to get redemption max size: [%w]",
+			"cannot plan redemption: [%w]",
 			err,
 		)
 	}
 
-	redeemersOutputScripts, err := rt.FindPendingRedemptions(
-		taskLogger,
-		walletPublicKeyHash,
-		redemptionMaxSize,
-	)
-	if err != nil {
-		return nil, false, fmt.Errorf(
-			"cannot find pending redemption requests: [%w]",
-			err,
-		)
-	}
+	taskLogger.Infof("redemption plan:\n%s", plan)
 
-	if len(redeemersOutputScripts) == 0 {
-		taskLogger.Info("no pending redemption requests")
+	if len(plan.Included) == 0 {
+		taskLogger.Info("no redemption requests to process")
 		return nil, false, nil
 	}
 
-	proposal, err := rt.ProposeRedemption(
+	proposal, err := rt.ProposeRedemptionPlan(
 		taskLogger,
 		walletPublicKeyHash,
-		redeemersOutputScripts,
-		0,
+		plan,
 	)
 	if err != nil {
 		return nil, false, fmt.Errorf(
@@ -94,6 +91,8 @@ type RedemptionRequest struct {
 	RedeemerOutputScript bitcoin.Script
 	RequestedAt          time.Time
 	RequestedAmount      uint64
+	TreasuryFee          uint64
+	TxMaxFee             uint64
 }
 
 // FindPendingRedemptions finds pending redemptions requests for the
@@ -116,6 +115,40 @@ func (rt *RedemptionTask) FindPendingRedemptions(
 		maxNumberOfRequests,
 	)
 
+	pendingRedemptions, err := rt.fetchPendingRedemptions(
+		taskLogger,
+		walletPublicKeyHash,
+		maxNumberOfRequests,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	taskLogger.Infof("found [%d] redemption requests", len(pendingRedemptions))
+
+	result := make([]bitcoin.Script, 0)
+
+	for _, pendingRedemption := range pendingRedemptions {
+		taskLogger.Infof(
+			"redemption request [%s] - requested at: [%s]",
+			pendingRedemption.RedemptionKey,
+			pendingRedemption.RequestedAt,
+		)
+
+		result = append(result, pendingRedemption.RedeemerOutputScript)
+	}
+
+	return result, nil
+}
+
+// fetchPendingRedemptions finds at most maxNumberOfRequests pending redemption
+// requests of the given wallet that are eligible for processing, oldest
+// first. If maxNumberOfRequests is zero, all eligible requests are returned.
+func (rt *RedemptionTask) fetchPendingRedemptions(
+	taskLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+	maxNumberOfRequests uint16,
+) ([]*RedemptionRequest, error) {
 	blockCounter, err := rt.chain.BlockCounter()
 	if err != nil {
 		return nil, fmt.Errorf(
@@ -163,21 +196,7 @@ func (rt *RedemptionTask) FindPendingRedemptions(
 		return nil, fmt.Errorf("cannot get pending redemptions: [%w]", err)
 	}
 
-	taskLogger.Infof("found [%d] redemption requests", len(pendingRedemptions))
-
-	result := make([]bitcoin.Script, 0)
-
-	for _, pendingRedemption := range pendingRedemptions {
-		taskLogger.Infof(
-			"redemption request [%s] - requested at: [%s]",
-			pendingRedemption.RedemptionKey,
-			pendingRedemption.RequestedAt,
-		)
-
-		result = append(result, pendingRedemption.RedeemerOutputScript)
-	}
-
-	return result, nil
+	return pendingRedemptions, nil
 }
 
 // ProposeRedemption returns a redemption proposal.
@@ -201,7 +220,7 @@ func (rt *RedemptionTask) ProposeRedemption(
 		taskLogger.Infof("estimating redemption transaction fee")
 
 		estimatedFee, err := EstimateRedemptionFee(
-			rt.btcChain,
+			rt.feeEstimator,
 			redeemersOutputScripts,
 		)
 		if err != nil {
@@ -221,6 +240,22 @@ func (rt *RedemptionTask) ProposeRedemption(
 		RedemptionTxFee:        big.NewInt(fee),
 	}
 
+	if err := rt.validateProposal(
+		taskLogger,
+		walletPublicKeyHash,
+		proposal,
+	); err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+func (rt *RedemptionTask) validateProposal(
+	taskLogger log.StandardLogger,
+	walletPublicKeyHash [20]byte,
+	proposal *tbtc.RedemptionProposal,
+) error {
 	taskLogger.Infof("validating the redemption proposal")
 
 	if _, err := tbtc.ValidateRedemptionProposal(
@@ -229,10 +264,10 @@ func (rt *RedemptionTask) ProposeRedemption(
 		proposal,
 		rt.chain,
 	); err != nil {
-		return nil, fmt.Errorf("failed to verify redemption proposal: %v", err)
+		return fmt.Errorf("failed to verify redemption proposal: %v", err)
 	}
 
-	return proposal, nil
+	return nil
 }
 
 func findPendingRedemptions(
@@ -350,6 +385,8 @@ redemptionRequestedLoop:
 				RedeemerOutputScript: event.RedeemerOutputScript,
 				RequestedAt:          pendingRedemption.RequestedAt,
 				RequestedAmount:      pendingRedemption.RequestedAmount,
+				TreasuryFee:          pendingRedemption.TreasuryFee,
+				TxMaxFee:             pendingRedemption.TxMaxFee,
 			},
 		)
 	}
@@ -367,7 +404,7 @@ redemptionRequestedLoop:
 	)
 
 	// Capture time now for computations.
-	timeNow := time.Now()
+	timeNow := tbtc.CurrentTime(chain)
 
 	// Only redemption requests in range:
 	// [now - requestTimeout, now - minAge]
@@ -447,7 +484,7 @@ redemptionRequestedLoop:
 // EstimateRedemptionFee estimates fee for the redemption transaction that pays
 // the provided redeemers output scripts.
 func EstimateRedemptionFee(
-	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
 	redeemersOutputScripts []bitcoin.Script,
 ) (int64, error) {
 	sizeEstimator := bitcoin.NewTransactionSizeEstimator().
@@ -466,6 +503,8 @@ func EstimateRedemptionFee(
 			sizeEstimator.AddScriptHashOutputs(1, false)
 		case bitcoin.P2WSHScript:
 			sizeEstimator.AddScriptHashOutputs(1, true)
+		case bitcoin.P2TRScript:
+			sizeEstimator.AddTaprootOutputs(1)
 		default:
 			return 0, fmt.Errorf("non-standard redeemer output script type")
 		}
@@ -476,9 +515,10 @@ func EstimateRedemptionFee(
 		return 0, fmt.Errorf("cannot estimate transaction virtual size: [%v]", err)
 	}
 
-	feeEstimator := bitcoin.NewTransactionFeeEstimator(btcChain)
-
-	totalFee, err := feeEstimator.EstimateFee(transactionSize)
+	totalFee, err := feeEstimator.EstimateFee(
+		tbtc.ActionRedemption,
+		transactionSize,
+	)
 	if err != nil {
 		return 0, fmt.Errorf("cannot estimate transaction fee: [%v]", err)
 	}
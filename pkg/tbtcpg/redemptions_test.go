@@ -2,6 +2,7 @@ package tbtcpg_test
 
 import (
 	"encoding/hex"
+	"fmt"
 	"math/big"
 	"testing"
 
@@ -34,7 +35,10 @@ func TestEstimateRedemptionFee(t *testing.T) {
 		fromHex("0020ef0b4d985752aa5ef6243e4c6f6bebc2a007e7d671ef27d4b1d0db8dcc93bc1c"), // P2WSH
 	}
 
-	actualFee, err := tbtcpg.EstimateRedemptionFee(btcChain, redeemersOutputScripts)
+	actualFee, err := tbtcpg.EstimateRedemptionFee(
+		tbtcpg.NewFeeEstimator(btcChain, nil),
+		redeemersOutputScripts,
+	)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -43,6 +47,29 @@ func TestEstimateRedemptionFee(t *testing.T) {
 	testutils.AssertIntsEqual(t, "fee", expectedFee, int(actualFee))
 }
 
+func TestEstimateRedemptionFee_Taproot(t *testing.T) {
+	redeemerOutputScript, err := hex.DecodeString(
+		"5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	btcChain := tbtcpg.NewLocalBitcoinChain()
+	btcChain.SetEstimateSatPerVByteFee(1, 16)
+
+	actualFee, err := tbtcpg.EstimateRedemptionFee(
+		tbtcpg.NewFeeEstimator(btcChain, nil),
+		[]bitcoin.Script{redeemerOutputScript},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedFee := 2448 // transactionVirtualSize * satPerVByteFee = 153 * 16 = 2448
+	testutils.AssertIntsEqual(t, "fee", expectedFee, int(actualFee))
+}
+
 func TestRedemptionAction_FindPendingRedemptions(t *testing.T) {
 	scenarios, err := test.LoadFindPendingRedemptionsTestScenario()
 	if err != nil {
@@ -115,7 +142,7 @@ func TestRedemptionAction_FindPendingRedemptions(t *testing.T) {
 				)
 			}
 
-			task := tbtcpg.NewRedemptionTask(tbtcChain, nil)
+			task := tbtcpg.NewRedemptionTask(tbtcChain, nil, nil, false)
 
 			redeemersOutputScripts, err := task.FindPendingRedemptions(
 				&testutils.MockLogger{},
@@ -198,7 +225,12 @@ func TestRedemptionAction_ProposeRedemption(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			task := tbtcpg.NewRedemptionTask(tbtcChain, btcChain)
+			task := tbtcpg.NewRedemptionTask(
+				tbtcChain,
+				btcChain,
+				tbtcpg.NewFeeEstimator(btcChain, nil),
+				false,
+			)
 
 			proposal, err := task.ProposeRedemption(
 				&testutils.MockLogger{},
@@ -216,3 +248,126 @@ func TestRedemptionAction_ProposeRedemption(t *testing.T) {
 		})
 	}
 }
+
+func TestRedemptionTask_ProposeRedemptionPlan(t *testing.T) {
+	fromHex := func(hexString string) []byte {
+		bytes, err := hex.DecodeString(hexString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bytes
+	}
+
+	var walletPublicKeyHash [20]byte
+
+	firstScript := fromHex("00140000000000000000000000000000000000000001")
+	secondScript := fromHex("00140000000000000000000000000000000000000002")
+
+	var tests = map[string]struct {
+		feeSharesEnabled      bool
+		shape                 tbtc.RedemptionTransactionShape
+		expectedFeeSharesSize int
+	}{
+		"fee shares enabled": {
+			feeSharesEnabled:      true,
+			shape:                 tbtc.RedemptionChangeFirst,
+			expectedFeeSharesSize: 2,
+		},
+		// Clients not supporting fee shares split the fee evenly so the
+		// proposal must not carry them.
+		"fee shares disabled": {
+			feeSharesEnabled:      false,
+			shape:                 tbtc.RedemptionChangeLast,
+			expectedFeeSharesSize: 0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			plan := &tbtcpg.RedemptionPlan{
+				Included: []*tbtcpg.RedemptionRequest{
+					{
+						RedeemerOutputScript: firstScript,
+						TxMaxFee:             5000,
+					},
+					{
+						RedeemerOutputScript: secondScript,
+						TxMaxFee:             10000,
+					},
+				},
+				TotalFee:         6000,
+				FeeShares:        []int64{2000, 4000},
+				TransactionShape: test.shape,
+			}
+
+			tbtcChain := tbtcpg.NewLocalChain()
+
+			for _, request := range plan.Included {
+				tbtcChain.SetPendingRedemptionRequest(
+					walletPublicKeyHash,
+					&tbtc.RedemptionRequest{
+						RedeemerOutputScript: request.RedeemerOutputScript,
+						TxMaxFee:             request.TxMaxFee,
+					},
+				)
+			}
+
+			// The on-chain validation must be done against the actual fee,
+			// regardless of fee shares.
+			err := tbtcChain.SetRedemptionProposalValidationResult(
+				walletPublicKeyHash,
+				&tbtc.RedemptionProposal{
+					RedeemersOutputScripts: plan.RedeemersOutputScripts(),
+					RedemptionTxFee:        big.NewInt(plan.TotalFee),
+				},
+				true,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			task := tbtcpg.NewRedemptionTask(
+				tbtcChain,
+				nil,
+				nil,
+				test.feeSharesEnabled,
+			)
+
+			proposal, err := task.ProposeRedemptionPlan(
+				&testutils.MockLogger{},
+				walletPublicKeyHash,
+				plan,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertBigIntsEqual(
+				t,
+				"redemption fee",
+				big.NewInt(plan.TotalFee),
+				proposal.RedemptionTxFee,
+			)
+			testutils.AssertIntsEqual(
+				t,
+				"fee shares count",
+				test.expectedFeeSharesSize,
+				len(proposal.RedemptionTxFeeShares),
+			)
+			for i, feeShare := range proposal.RedemptionTxFeeShares {
+				testutils.AssertBigIntsEqual(
+					t,
+					fmt.Sprintf("fee share [%v]", i),
+					big.NewInt(plan.FeeShares[i]),
+					feeShare,
+				)
+			}
+			testutils.AssertStringsEqual(
+				t,
+				"transaction shape",
+				test.shape.String(),
+				proposal.TransactionShape.String(),
+			)
+		})
+	}
+}
@@ -0,0 +1,468 @@
+package tbtcpg
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+var (
+	// ErrSimulationChainWrite is the error returned by the simulation chain
+	// for calls that would modify the chain state.
+	ErrSimulationChainWrite = fmt.Errorf(
+		"chain state cannot be modified during simulation",
+	)
+
+	// ErrValidationNotSimulated is the error returned as the validation
+	// result of proposals whose validation depends on the state of the
+	// wallet's node, e.g. transactions tracked by the node.
+	ErrValidationNotSimulated = fmt.Errorf(
+		"proposal validation is not supported in simulation",
+	)
+)
+
+// SimulationChain represents the interface that the proposal simulation
+// expects to interact with the anchoring blockchain on.
+type SimulationChain interface {
+	// GetBlockHashByNumber gets the block hash for the given block number.
+	GetBlockHashByNumber(blockNumber uint64) ([32]byte, error)
+
+	// GetBlockTimeByNumber gets the timestamp of the given block number.
+	GetBlockTimeByNumber(blockNumber uint64) (time.Time, error)
+
+	// ChainAtBlock returns a handle of the chain whose calls reading the
+	// chain state return the state as of the given block. Reading the state
+	// of old blocks requires an archive node.
+	ChainAtBlock(blockNumber uint64) (Chain, error)
+}
+
+// ProposalSimulation is the result of the proposal generation simulated for
+// a single coordination window.
+type ProposalSimulation struct {
+	// CoordinationBlock is the first block of the coordination window.
+	CoordinationBlock uint64
+	// ActionsChecklist is the list of actions checked by the coordination
+	// leader in the coordination window.
+	ActionsChecklist []tbtc.WalletActionType
+	// Proposal is the generated proposal. Nil if the generation failed.
+	Proposal tbtc.CoordinationProposal
+	// EstimatedFee is the fee, in satoshi, of the Bitcoin transaction
+	// produced by the proposed action. Nil if the action does not produce
+	// a Bitcoin transaction.
+	EstimatedFee *big.Int
+	// GenerationError is the error returned by the proposal generator.
+	GenerationError error
+	// ValidationError is the error returned by the proposal validation.
+	// Nil if the proposal is valid.
+	ValidationError error
+}
+
+// SimulateProposals runs the proposal generator for the given wallet and all
+// coordination windows starting within the given block range, and validates
+// the generated proposals. The simulation never signs nor broadcasts
+// anything and rejects all calls that would modify the chain state.
+//
+// Each window is simulated against the chain state as of its coordination
+// block: chain calls are pinned to that block, past chain events are capped
+// at it and the time used to determine the age of deposits and redemption
+// requests is the timestamp of that block. The Bitcoin chain and the fee
+// estimator are read as of now, so confirmations of Bitcoin transactions
+// and estimated fees of older windows are an approximation.
+func SimulateProposals(
+	chain SimulationChain,
+	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
+	config *Config,
+	walletPublicKeyHash [20]byte,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*ProposalSimulation, error) {
+	coordinationBlocks := tbtc.CoordinationBlocks(startBlock, endBlock)
+	if len(coordinationBlocks) == 0 {
+		return nil, fmt.Errorf(
+			"no coordination windows between blocks [%v] and [%v]",
+			startBlock,
+			endBlock,
+		)
+	}
+
+	simulations := make([]*ProposalSimulation, len(coordinationBlocks))
+
+	for i, coordinationBlock := range coordinationBlocks {
+		coordinationTime, err := chain.GetBlockTimeByNumber(coordinationBlock)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get time of coordination block [%v]: [%w]",
+				coordinationBlock,
+				err,
+			)
+		}
+
+		chainAtBlock, err := chain.ChainAtBlock(coordinationBlock)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get chain state at coordination block [%v]: [%w]",
+				coordinationBlock,
+				err,
+			)
+		}
+
+		simulations[i] = simulateProposal(
+			newSimulationChain(
+				chainAtBlock,
+				coordinationBlock,
+				coordinationTime,
+			),
+			btcChain,
+			feeEstimator,
+			config,
+			walletPublicKeyHash,
+			coordinationBlock,
+			chain.GetBlockHashByNumber,
+		)
+	}
+
+	return simulations, nil
+}
+
+func simulateProposal(
+	chain Chain,
+	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
+	config *Config,
+	walletPublicKeyHash [20]byte,
+	coordinationBlock uint64,
+	getBlockHashByNumberFn func(blockNumber uint64) ([32]byte, error),
+) *ProposalSimulation {
+	simulationLogger := logger.With(
+		zap.String("walletPKH", fmt.Sprintf("0x%x", walletPublicKeyHash)),
+		zap.Uint64("coordinationBlock", coordinationBlock),
+	)
+
+	simulation := &ProposalSimulation{
+		CoordinationBlock: coordinationBlock,
+	}
+
+	actionsChecklist, err := tbtc.CoordinationActionsChecklist(
+		walletPublicKeyHash,
+		coordinationBlock,
+		getBlockHashByNumberFn,
+	)
+	if err != nil {
+		simulation.GenerationError = fmt.Errorf(
+			"cannot determine actions checklist: [%w]",
+			err,
+		)
+		return simulation
+	}
+
+	simulation.ActionsChecklist = actionsChecklist
+
+	generator := NewProposalGenerator(
+		chain,
+		btcChain,
+		feeEstimator,
+		config,
+	)
+
+	proposal, err := generator.Generate(
+		&tbtc.CoordinationProposalRequest{
+			WalletPublicKeyHash: walletPublicKeyHash,
+			ActionsChecklist:    actionsChecklist,
+		},
+	)
+	if err != nil {
+		simulation.GenerationError = err
+		return simulation
+	}
+
+	simulation.Proposal = proposal
+	simulation.EstimatedFee = proposalFee(proposal)
+	simulation.ValidationError = validateSimulatedProposal(
+		simulationLogger,
+		chain,
+		btcChain,
+		walletPublicKeyHash,
+		proposal,
+	)
+
+	return simulation
+}
+
+// proposalFee returns the Bitcoin transaction fee of the given proposal or
+// nil if the proposal does not produce a Bitcoin transaction.
+func proposalFee(proposal tbtc.CoordinationProposal) *big.Int {
+	switch p := proposal.(type) {
+	case *tbtc.DepositSweepProposal:
+		return p.SweepTxFee
+	case *tbtc.RedemptionProposal:
+		return p.RedemptionTxFee
+	case *tbtc.MovingFundsProposal:
+		return p.MovingFundsTxFee
+	case *tbtc.MovedFundsSweepProposal:
+		return p.SweepTxFee
+	case *tbtc.RbfProposal:
+		return p.NewFee
+	case *tbtc.CpfpProposal:
+		return proposalFee(p.ChildProposal)
+	default:
+		return nil
+	}
+}
+
+// validateSimulatedProposal validates the given proposal the same way the
+// wallet's node does before executing it.
+func validateSimulatedProposal(
+	validateProposalLogger *zap.SugaredLogger,
+	chain Chain,
+	btcChain bitcoin.Chain,
+	walletPublicKeyHash [20]byte,
+	proposal tbtc.CoordinationProposal,
+) error {
+	switch p := proposal.(type) {
+	case *tbtc.DepositSweepProposal:
+		_, err := tbtc.ValidateDepositSweepProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			p,
+			tbtc.DepositSweepRequiredFundingTxConfirmations,
+			chain,
+			btcChain,
+		)
+		return err
+	case *tbtc.RedemptionProposal:
+		_, err := tbtc.ValidateRedemptionProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			p,
+			chain,
+		)
+		return err
+	case *tbtc.MovingFundsProposal:
+		walletMainUtxo, err := tbtc.DetermineWalletMainUtxo(
+			walletPublicKeyHash,
+			chain,
+			btcChain,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"error while determining wallet's main UTXO: [%w]",
+				err,
+			)
+		}
+
+		return tbtc.ValidateMovingFundsProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			walletMainUtxo,
+			p,
+			chain,
+		)
+	case *tbtc.MovedFundsSweepProposal:
+		return tbtc.ValidateMovedFundsSweepProposal(
+			validateProposalLogger,
+			walletPublicKeyHash,
+			p,
+			chain,
+		)
+	case *tbtc.HeartbeatProposal:
+		return chain.ValidateHeartbeatProposal(walletPublicKeyHash, p)
+	case *tbtc.KeyShareRefreshProposal:
+		walletChainData, err := chain.GetWallet(walletPublicKeyHash)
+		if err != nil {
+			return fmt.Errorf("cannot get wallet's chain data: [%w]", err)
+		}
+
+		if walletChainData.State != tbtc.StateLive {
+			return fmt.Errorf(
+				"wallet is in [%v] state while key share refresh requires "+
+					"the Live state",
+				walletChainData.State,
+			)
+		}
+
+		return nil
+	case *tbtc.RbfProposal, *tbtc.CpfpProposal:
+		return ErrValidationNotSimulated
+	default:
+		return nil
+	}
+}
+
+// simulationChain is a read-only Chain implementation used to simulate
+// the proposal generation for a coordination window. It wraps a chain handle
+// pinned to the coordination block of the window, caps the current block and
+// the range of past events at the coordination block and reports the time of
+// the coordination block as the current time.
+// All other calls are passed to the underlying chain, except the ones that
+// would modify the chain state which are rejected.
+type simulationChain struct {
+	Chain
+
+	coordinationBlock uint64
+	coordinationTime  time.Time
+}
+
+func newSimulationChain(
+	chain Chain,
+	coordinationBlock uint64,
+	coordinationTime time.Time,
+) *simulationChain {
+	return &simulationChain{
+		Chain:             chain,
+		coordinationBlock: coordinationBlock,
+		coordinationTime:  coordinationTime,
+	}
+}
+
+// CurrentTime returns the time of the coordination block.
+func (sc *simulationChain) CurrentTime() time.Time {
+	return sc.coordinationTime
+}
+
+// BlockCounter returns the block counter of the underlying chain whose current
+// block is the coordination block.
+func (sc *simulationChain) BlockCounter() (chain.BlockCounter, error) {
+	blockCounter, err := sc.Chain.BlockCounter()
+	if err != nil {
+		return nil, err
+	}
+
+	return &simulationBlockCounter{
+		BlockCounter:      blockCounter,
+		coordinationBlock: sc.coordinationBlock,
+	}, nil
+}
+
+// capEndBlock caps the given end block of an events filter at the
+// coordination block. The second return value is false if the events range
+// starts after the coordination block, i.e. there are no events to return.
+func (sc *simulationChain) capEndBlock(
+	startBlock uint64,
+	endBlock *uint64,
+) (*uint64, bool) {
+	if startBlock > sc.coordinationBlock {
+		return nil, false
+	}
+
+	if endBlock != nil && *endBlock < sc.coordinationBlock {
+		return endBlock, true
+	}
+
+	cappedEndBlock := sc.coordinationBlock
+	return &cappedEndBlock, true
+}
+
+func (sc *simulationChain) PastDepositRevealedEvents(
+	filter *tbtc.DepositRevealedEventFilter,
+) ([]*tbtc.DepositRevealedEvent, error) {
+	cappedFilter := &tbtc.DepositRevealedEventFilter{}
+	if filter != nil {
+		*cappedFilter = *filter
+	}
+
+	endBlock, ok := sc.capEndBlock(cappedFilter.StartBlock, cappedFilter.EndBlock)
+	if !ok {
+		return []*tbtc.DepositRevealedEvent{}, nil
+	}
+	cappedFilter.EndBlock = endBlock
+
+	return sc.Chain.PastDepositRevealedEvents(cappedFilter)
+}
+
+func (sc *simulationChain) PastRedemptionRequestedEvents(
+	filter *tbtc.RedemptionRequestedEventFilter,
+) ([]*tbtc.RedemptionRequestedEvent, error) {
+	cappedFilter := &tbtc.RedemptionRequestedEventFilter{}
+	if filter != nil {
+		*cappedFilter = *filter
+	}
+
+	endBlock, ok := sc.capEndBlock(cappedFilter.StartBlock, cappedFilter.EndBlock)
+	if !ok {
+		return []*tbtc.RedemptionRequestedEvent{}, nil
+	}
+	cappedFilter.EndBlock = endBlock
+
+	return sc.Chain.PastRedemptionRequestedEvents(cappedFilter)
+}
+
+func (sc *simulationChain) PastNewWalletRegisteredEvents(
+	filter *tbtc.NewWalletRegisteredEventFilter,
+) ([]*tbtc.NewWalletRegisteredEvent, error) {
+	cappedFilter := &tbtc.NewWalletRegisteredEventFilter{}
+	if filter != nil {
+		*cappedFilter = *filter
+	}
+
+	endBlock, ok := sc.capEndBlock(cappedFilter.StartBlock, cappedFilter.EndBlock)
+	if !ok {
+		return []*tbtc.NewWalletRegisteredEvent{}, nil
+	}
+	cappedFilter.EndBlock = endBlock
+
+	return sc.Chain.PastNewWalletRegisteredEvents(cappedFilter)
+}
+
+func (sc *simulationChain) PastMovingFundsCommitmentSubmittedEvents(
+	filter *tbtc.MovingFundsCommitmentSubmittedEventFilter,
+) ([]*tbtc.MovingFundsCommitmentSubmittedEvent, error) {
+	cappedFilter := &tbtc.MovingFundsCommitmentSubmittedEventFilter{}
+	if filter != nil {
+		*cappedFilter = *filter
+	}
+
+	endBlock, ok := sc.capEndBlock(cappedFilter.StartBlock, cappedFilter.EndBlock)
+	if !ok {
+		return []*tbtc.MovingFundsCommitmentSubmittedEvent{}, nil
+	}
+	cappedFilter.EndBlock = endBlock
+
+	return sc.Chain.PastMovingFundsCommitmentSubmittedEvents(cappedFilter)
+}
+
+func (sc *simulationChain) PastMovingFundsCompletedEvents(
+	filter *tbtc.MovingFundsCompletedEventFilter,
+) ([]*tbtc.MovingFundsCompletedEvent, error) {
+	cappedFilter := &tbtc.MovingFundsCompletedEventFilter{}
+	if filter != nil {
+		*cappedFilter = *filter
+	}
+
+	endBlock, ok := sc.capEndBlock(cappedFilter.StartBlock, cappedFilter.EndBlock)
+	if !ok {
+		return []*tbtc.MovingFundsCompletedEvent{}, nil
+	}
+	cappedFilter.EndBlock = endBlock
+
+	return sc.Chain.PastMovingFundsCompletedEvents(cappedFilter)
+}
+
+// SubmitMovingFundsCommitment always returns ErrSimulationChainWrite.
+func (sc *simulationChain) SubmitMovingFundsCommitment(
+	walletPublicKeyHash [20]byte,
+	walletMainUTXO bitcoin.UnspentTransactionOutput,
+	walletMembersIDs []uint32,
+	walletMemberIndex uint32,
+	targetWallets [][20]byte,
+) error {
+	return ErrSimulationChainWrite
+}
+
+// simulationBlockCounter is a block counter whose current block is the
+// coordination block of the simulated window.
+type simulationBlockCounter struct {
+	chain.BlockCounter
+
+	coordinationBlock uint64
+}
+
+func (sbc *simulationBlockCounter) CurrentBlock() (uint64, error) {
+	return sbc.coordinationBlock, nil
+}
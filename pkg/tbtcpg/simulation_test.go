@@ -0,0 +1,277 @@
+package tbtcpg
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/bitcoin"
+	"github.com/keep-network/keep-core/pkg/tbtc"
+)
+
+// filterRecordingChain is a LocalChain recording filters of redemption
+// requested events it was asked for.
+type filterRecordingChain struct {
+	*LocalChain
+
+	redemptionRequestedEventFilters []*tbtc.RedemptionRequestedEventFilter
+}
+
+func (frc *filterRecordingChain) PastRedemptionRequestedEvents(
+	filter *tbtc.RedemptionRequestedEventFilter,
+) ([]*tbtc.RedemptionRequestedEvent, error) {
+	frc.redemptionRequestedEventFilters = append(
+		frc.redemptionRequestedEventFilters,
+		filter,
+	)
+
+	return []*tbtc.RedemptionRequestedEvent{}, nil
+}
+
+func TestSimulationChain_PastEvents(t *testing.T) {
+	coordinationBlock := uint64(1800)
+
+	uint64Ptr := func(value uint64) *uint64 {
+		return &value
+	}
+
+	var tests = map[string]struct {
+		filter          *tbtc.RedemptionRequestedEventFilter
+		expectedFilters []*tbtc.RedemptionRequestedEventFilter
+	}{
+		"nil filter": {
+			filter: nil,
+			expectedFilters: []*tbtc.RedemptionRequestedEventFilter{
+				{EndBlock: uint64Ptr(1800)},
+			},
+		},
+		"filter without end block": {
+			filter: &tbtc.RedemptionRequestedEventFilter{
+				StartBlock:          100,
+				WalletPublicKeyHash: [][20]byte{{1}},
+			},
+			expectedFilters: []*tbtc.RedemptionRequestedEventFilter{
+				{
+					StartBlock:          100,
+					EndBlock:            uint64Ptr(1800),
+					WalletPublicKeyHash: [][20]byte{{1}},
+				},
+			},
+		},
+		"filter ending before the coordination block": {
+			filter: &tbtc.RedemptionRequestedEventFilter{
+				StartBlock: 100,
+				EndBlock:   uint64Ptr(1000),
+			},
+			expectedFilters: []*tbtc.RedemptionRequestedEventFilter{
+				{
+					StartBlock: 100,
+					EndBlock:   uint64Ptr(1000),
+				},
+			},
+		},
+		"filter ending after the coordination block": {
+			filter: &tbtc.RedemptionRequestedEventFilter{
+				StartBlock: 100,
+				EndBlock:   uint64Ptr(5000),
+			},
+			expectedFilters: []*tbtc.RedemptionRequestedEventFilter{
+				{
+					StartBlock: 100,
+					EndBlock:   uint64Ptr(1800),
+				},
+			},
+		},
+		"filter starting after the coordination block": {
+			filter: &tbtc.RedemptionRequestedEventFilter{
+				StartBlock: 2000,
+			},
+			expectedFilters: nil,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			recordingChain := &filterRecordingChain{LocalChain: NewLocalChain()}
+
+			simulationChain := newSimulationChain(
+				recordingChain,
+				coordinationBlock,
+				time.Unix(0, 0),
+			)
+
+			events, err := simulationChain.PastRedemptionRequestedEvents(
+				test.filter,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertIntsEqual(t, "events count", 0, len(events))
+
+			if !reflect.DeepEqual(
+				test.expectedFilters,
+				recordingChain.redemptionRequestedEventFilters,
+			) {
+				t.Errorf(
+					"unexpected filters\nexpected: [%+v]\nactual:   [%+v]",
+					test.expectedFilters,
+					recordingChain.redemptionRequestedEventFilters,
+				)
+			}
+		})
+	}
+}
+
+func TestSimulationChain_BlockCounter(t *testing.T) {
+	localChain := NewLocalChain()
+
+	blockCounter := NewMockBlockCounter()
+	blockCounter.SetCurrentBlock(5000)
+	localChain.SetBlockCounter(blockCounter)
+
+	simulationChain := newSimulationChain(localChain, 1800, time.Unix(0, 0))
+
+	simulationBlockCounter, err := simulationChain.BlockCounter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	currentBlock, err := simulationBlockCounter.CurrentBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertUintsEqual(t, "current block", 1800, currentBlock)
+}
+
+func TestSimulationChain_SubmitMovingFundsCommitment(t *testing.T) {
+	localChain := NewLocalChain()
+
+	simulationChain := newSimulationChain(localChain, 1800, time.Unix(0, 0))
+
+	err := simulationChain.SubmitMovingFundsCommitment(
+		[20]byte{1},
+		bitcoin.UnspentTransactionOutput{},
+		[]uint32{1, 2, 3},
+		1,
+		[][20]byte{{2}},
+	)
+	testutils.AssertErrorsSame(t, ErrSimulationChainWrite, err)
+
+	testutils.AssertIntsEqual(
+		t,
+		"moving funds commitment submissions count",
+		0,
+		len(localChain.GetMovingFundsSubmissions()),
+	)
+}
+
+func TestSimulationChain_CurrentTime(t *testing.T) {
+	coordinationTime := time.Unix(1700000000, 0)
+
+	simulationChain := newSimulationChain(
+		NewLocalChain(),
+		1800,
+		coordinationTime,
+	)
+
+	testutils.AssertBoolsEqual(
+		t,
+		"current time",
+		true,
+		coordinationTime.Equal(tbtc.CurrentTime(simulationChain)),
+	)
+}
+
+// archiveLocalChain is a LocalChain implementing the SimulationChain
+// interface.
+type archiveLocalChain struct {
+	*LocalChain
+}
+
+func (alc *archiveLocalChain) GetBlockHashByNumber(
+	blockNumber uint64,
+) ([32]byte, error) {
+	return [32]byte{}, fmt.Errorf("not implemented")
+}
+
+func (alc *archiveLocalChain) GetBlockTimeByNumber(
+	blockNumber uint64,
+) (time.Time, error) {
+	return time.Time{}, fmt.Errorf("not implemented")
+}
+
+func (alc *archiveLocalChain) ChainAtBlock(
+	blockNumber uint64,
+) (Chain, error) {
+	return alc.LocalChain, nil
+}
+
+func TestSimulateProposals_NoCoordinationWindows(t *testing.T) {
+	_, err := SimulateProposals(
+		&archiveLocalChain{NewLocalChain()},
+		nil,
+		nil,
+		&Config{},
+		[20]byte{1},
+		901,
+		1799,
+	)
+
+	expectedErr := fmt.Errorf(
+		"no coordination windows between blocks [901] and [1799]",
+	)
+	if !reflect.DeepEqual(expectedErr, err) {
+		t.Errorf(
+			"unexpected error\nexpected: [%v]\nactual:   [%v]",
+			expectedErr,
+			err,
+		)
+	}
+}
+
+func TestProposalFee(t *testing.T) {
+	var tests = map[string]struct {
+		proposal    tbtc.CoordinationProposal
+		expectedFee *big.Int
+	}{
+		"deposit sweep": {
+			proposal:    &tbtc.DepositSweepProposal{SweepTxFee: big.NewInt(1000)},
+			expectedFee: big.NewInt(1000),
+		},
+		"redemption": {
+			proposal:    &tbtc.RedemptionProposal{RedemptionTxFee: big.NewInt(2000)},
+			expectedFee: big.NewInt(2000),
+		},
+		"moving funds": {
+			proposal:    &tbtc.MovingFundsProposal{MovingFundsTxFee: big.NewInt(3000)},
+			expectedFee: big.NewInt(3000),
+		},
+		"heartbeat": {
+			proposal:    &tbtc.HeartbeatProposal{},
+			expectedFee: nil,
+		},
+		"noop": {
+			proposal:    &tbtc.NoopProposal{},
+			expectedFee: nil,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			fee := proposalFee(test.proposal)
+
+			if !reflect.DeepEqual(test.expectedFee, fee) {
+				t.Errorf(
+					"unexpected fee\nexpected: [%v]\nactual:   [%v]",
+					test.expectedFee,
+					fee,
+				)
+			}
+		})
+	}
+}
@@ -35,13 +35,23 @@ type ProposalGenerator struct {
 func NewProposalGenerator(
 	chain Chain,
 	btcChain bitcoin.Chain,
+	feeEstimator FeeEstimator,
+	config *Config,
 ) *ProposalGenerator {
 	tasks := []ProposalTask{
-		NewDepositSweepTask(chain, btcChain),
-		NewRedemptionTask(chain, btcChain),
+		NewDepositSweepTask(chain, btcChain, feeEstimator, config),
+		NewRedemptionTask(
+			chain,
+			btcChain,
+			feeEstimator,
+			config.RedemptionFeeSharesEnabled,
+		),
 		NewHeartbeatTask(chain),
-		NewMovingFundsTask(chain, btcChain),
-		NewMovedFundsSweepTask(chain, btcChain),
+		NewMovingFundsTask(chain, btcChain, feeEstimator),
+		NewMovedFundsSweepTask(chain, btcChain, feeEstimator),
+		NewRbfTask(chain, btcChain, feeEstimator),
+		NewCpfpTask(chain, btcChain, feeEstimator, config),
+		NewKeyShareRefreshTask(chain),
 	}
 
 	return &ProposalGenerator{
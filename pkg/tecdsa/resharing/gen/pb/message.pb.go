@@ -0,0 +1,810 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.7.1
+// source: pkg/tecdsa/resharing/gen/pb/message.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EphemeralPublicKeyMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SenderID            uint32            `protobuf:"varint,1,opt,name=senderID,proto3" json:"senderID,omitempty"`
+	EphemeralPublicKeys map[uint32][]byte `protobuf:"bytes,2,rep,name=ephemeralPublicKeys,proto3" json:"ephemeralPublicKeys,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	SessionID           string            `protobuf:"bytes,3,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+}
+
+func (x *EphemeralPublicKeyMessage) Reset() {
+	*x = EphemeralPublicKeyMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EphemeralPublicKeyMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EphemeralPublicKeyMessage) ProtoMessage() {}
+
+func (x *EphemeralPublicKeyMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EphemeralPublicKeyMessage.ProtoReflect.Descriptor instead.
+func (*EphemeralPublicKeyMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EphemeralPublicKeyMessage) GetSenderID() uint32 {
+	if x != nil {
+		return x.SenderID
+	}
+	return 0
+}
+
+func (x *EphemeralPublicKeyMessage) GetEphemeralPublicKeys() map[uint32][]byte {
+	if x != nil {
+		return x.EphemeralPublicKeys
+	}
+	return nil
+}
+
+func (x *EphemeralPublicKeyMessage) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+type TSSRoundOneMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SenderID         uint32 `protobuf:"varint,1,opt,name=senderID,proto3" json:"senderID,omitempty"`
+	BroadcastPayload []byte `protobuf:"bytes,2,opt,name=broadcastPayload,proto3" json:"broadcastPayload,omitempty"`
+	SessionID        string `protobuf:"bytes,3,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+}
+
+func (x *TSSRoundOneMessage) Reset() {
+	*x = TSSRoundOneMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TSSRoundOneMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TSSRoundOneMessage) ProtoMessage() {}
+
+func (x *TSSRoundOneMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TSSRoundOneMessage.ProtoReflect.Descriptor instead.
+func (*TSSRoundOneMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TSSRoundOneMessage) GetSenderID() uint32 {
+	if x != nil {
+		return x.SenderID
+	}
+	return 0
+}
+
+func (x *TSSRoundOneMessage) GetBroadcastPayload() []byte {
+	if x != nil {
+		return x.BroadcastPayload
+	}
+	return nil
+}
+
+func (x *TSSRoundOneMessage) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+type TSSRoundTwoMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SenderID         uint32 `protobuf:"varint,1,opt,name=senderID,proto3" json:"senderID,omitempty"`
+	BroadcastPayload []byte `protobuf:"bytes,2,opt,name=broadcastPayload,proto3" json:"broadcastPayload,omitempty"`
+	AckPayload       []byte `protobuf:"bytes,3,opt,name=ackPayload,proto3" json:"ackPayload,omitempty"`
+	SessionID        string `protobuf:"bytes,4,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+}
+
+func (x *TSSRoundTwoMessage) Reset() {
+	*x = TSSRoundTwoMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TSSRoundTwoMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TSSRoundTwoMessage) ProtoMessage() {}
+
+func (x *TSSRoundTwoMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TSSRoundTwoMessage.ProtoReflect.Descriptor instead.
+func (*TSSRoundTwoMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TSSRoundTwoMessage) GetSenderID() uint32 {
+	if x != nil {
+		return x.SenderID
+	}
+	return 0
+}
+
+func (x *TSSRoundTwoMessage) GetBroadcastPayload() []byte {
+	if x != nil {
+		return x.BroadcastPayload
+	}
+	return nil
+}
+
+func (x *TSSRoundTwoMessage) GetAckPayload() []byte {
+	if x != nil {
+		return x.AckPayload
+	}
+	return nil
+}
+
+func (x *TSSRoundTwoMessage) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+type TSSRoundThreeMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SenderID         uint32            `protobuf:"varint,1,opt,name=senderID,proto3" json:"senderID,omitempty"`
+	BroadcastPayload []byte            `protobuf:"bytes,2,opt,name=broadcastPayload,proto3" json:"broadcastPayload,omitempty"`
+	PeersPayload     map[uint32][]byte `protobuf:"bytes,3,rep,name=peersPayload,proto3" json:"peersPayload,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	SessionID        string            `protobuf:"bytes,4,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+}
+
+func (x *TSSRoundThreeMessage) Reset() {
+	*x = TSSRoundThreeMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TSSRoundThreeMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TSSRoundThreeMessage) ProtoMessage() {}
+
+func (x *TSSRoundThreeMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TSSRoundThreeMessage.ProtoReflect.Descriptor instead.
+func (*TSSRoundThreeMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TSSRoundThreeMessage) GetSenderID() uint32 {
+	if x != nil {
+		return x.SenderID
+	}
+	return 0
+}
+
+func (x *TSSRoundThreeMessage) GetBroadcastPayload() []byte {
+	if x != nil {
+		return x.BroadcastPayload
+	}
+	return nil
+}
+
+func (x *TSSRoundThreeMessage) GetPeersPayload() map[uint32][]byte {
+	if x != nil {
+		return x.PeersPayload
+	}
+	return nil
+}
+
+func (x *TSSRoundThreeMessage) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+type TSSRoundFourMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SenderID         uint32            `protobuf:"varint,1,opt,name=senderID,proto3" json:"senderID,omitempty"`
+	BroadcastPayload []byte            `protobuf:"bytes,2,opt,name=broadcastPayload,proto3" json:"broadcastPayload,omitempty"`
+	PeersPayload     map[uint32][]byte `protobuf:"bytes,3,rep,name=peersPayload,proto3" json:"peersPayload,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	SessionID        string            `protobuf:"bytes,4,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+}
+
+func (x *TSSRoundFourMessage) Reset() {
+	*x = TSSRoundFourMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TSSRoundFourMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TSSRoundFourMessage) ProtoMessage() {}
+
+func (x *TSSRoundFourMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TSSRoundFourMessage.ProtoReflect.Descriptor instead.
+func (*TSSRoundFourMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TSSRoundFourMessage) GetSenderID() uint32 {
+	if x != nil {
+		return x.SenderID
+	}
+	return 0
+}
+
+func (x *TSSRoundFourMessage) GetBroadcastPayload() []byte {
+	if x != nil {
+		return x.BroadcastPayload
+	}
+	return nil
+}
+
+func (x *TSSRoundFourMessage) GetPeersPayload() map[uint32][]byte {
+	if x != nil {
+		return x.PeersPayload
+	}
+	return nil
+}
+
+func (x *TSSRoundFourMessage) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+type TSSRoundFiveMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SenderID         uint32 `protobuf:"varint,1,opt,name=senderID,proto3" json:"senderID,omitempty"`
+	BroadcastPayload []byte `protobuf:"bytes,2,opt,name=broadcastPayload,proto3" json:"broadcastPayload,omitempty"`
+	SessionID        string `protobuf:"bytes,3,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+}
+
+func (x *TSSRoundFiveMessage) Reset() {
+	*x = TSSRoundFiveMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TSSRoundFiveMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TSSRoundFiveMessage) ProtoMessage() {}
+
+func (x *TSSRoundFiveMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TSSRoundFiveMessage.ProtoReflect.Descriptor instead.
+func (*TSSRoundFiveMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TSSRoundFiveMessage) GetSenderID() uint32 {
+	if x != nil {
+		return x.SenderID
+	}
+	return 0
+}
+
+func (x *TSSRoundFiveMessage) GetBroadcastPayload() []byte {
+	if x != nil {
+		return x.BroadcastPayload
+	}
+	return nil
+}
+
+func (x *TSSRoundFiveMessage) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+type ResultPreparedMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SenderID   uint32 `protobuf:"varint,1,opt,name=senderID,proto3" json:"senderID,omitempty"`
+	ResultHash []byte `protobuf:"bytes,2,opt,name=resultHash,proto3" json:"resultHash,omitempty"`
+	SessionID  string `protobuf:"bytes,3,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+}
+
+func (x *ResultPreparedMessage) Reset() {
+	*x = ResultPreparedMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResultPreparedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultPreparedMessage) ProtoMessage() {}
+
+func (x *ResultPreparedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultPreparedMessage.ProtoReflect.Descriptor instead.
+func (*ResultPreparedMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ResultPreparedMessage) GetSenderID() uint32 {
+	if x != nil {
+		return x.SenderID
+	}
+	return 0
+}
+
+func (x *ResultPreparedMessage) GetResultHash() []byte {
+	if x != nil {
+		return x.ResultHash
+	}
+	return nil
+}
+
+func (x *ResultPreparedMessage) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+type ResultCommittedMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SenderID   uint32 `protobuf:"varint,1,opt,name=senderID,proto3" json:"senderID,omitempty"`
+	ResultHash []byte `protobuf:"bytes,2,opt,name=resultHash,proto3" json:"resultHash,omitempty"`
+	SessionID  string `protobuf:"bytes,3,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+}
+
+func (x *ResultCommittedMessage) Reset() {
+	*x = ResultCommittedMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResultCommittedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultCommittedMessage) ProtoMessage() {}
+
+func (x *ResultCommittedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultCommittedMessage.ProtoReflect.Descriptor instead.
+func (*ResultCommittedMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ResultCommittedMessage) GetSenderID() uint32 {
+	if x != nil {
+		return x.SenderID
+	}
+	return 0
+}
+
+func (x *ResultCommittedMessage) GetResultHash() []byte {
+	if x != nil {
+		return x.ResultHash
+	}
+	return nil
+}
+
+func (x *ResultCommittedMessage) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+var File_pkg_tecdsa_resharing_gen_pb_message_proto protoreflect.FileDescriptor
+
+var file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDesc = []byte{
+	0x0a, 0x29, 0x70, 0x6b, 0x67, 0x2f, 0x74, 0x65, 0x63, 0x64, 0x73, 0x61, 0x2f, 0x72, 0x65, 0x73,
+	0x68, 0x61, 0x72, 0x69, 0x6e, 0x67, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x70, 0x62, 0x2f, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x72, 0x65, 0x73,
+	0x68, 0x61, 0x72, 0x69, 0x6e, 0x67, 0x22, 0x8e, 0x02, 0x0a, 0x19, 0x45, 0x70, 0x68, 0x65, 0x6d,
+	0x65, 0x72, 0x61, 0x6c, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44,
+	0x12, 0x6f, 0x0a, 0x13, 0x65, 0x70, 0x68, 0x65, 0x6d, 0x65, 0x72, 0x61, 0x6c, 0x50, 0x75, 0x62,
+	0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x3d, 0x2e,
+	0x72, 0x65, 0x73, 0x68, 0x61, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x45, 0x70, 0x68, 0x65, 0x6d, 0x65,
+	0x72, 0x61, 0x6c, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x2e, 0x45, 0x70, 0x68, 0x65, 0x6d, 0x65, 0x72, 0x61, 0x6c, 0x50, 0x75, 0x62,
+	0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x13, 0x65, 0x70,
+	0x68, 0x65, 0x6d, 0x65, 0x72, 0x61, 0x6c, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79,
+	0x73, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x1a,
+	0x46, 0x0a, 0x18, 0x45, 0x70, 0x68, 0x65, 0x6d, 0x65, 0x72, 0x61, 0x6c, 0x50, 0x75, 0x62, 0x6c,
+	0x69, 0x63, 0x4b, 0x65, 0x79, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x7a, 0x0a, 0x12, 0x54, 0x53, 0x53, 0x52, 0x6f,
+	0x75, 0x6e, 0x64, 0x4f, 0x6e, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x12, 0x2a, 0x0a, 0x10, 0x62, 0x72, 0x6f,
+	0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x10, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x49, 0x44, 0x22, 0x9a, 0x01, 0x0a, 0x12, 0x54, 0x53, 0x53, 0x52, 0x6f, 0x75, 0x6e, 0x64,
+	0x54, 0x77, 0x6f, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65,
+	0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x73, 0x65,
+	0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x12, 0x2a, 0x0a, 0x10, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63,
+	0x61, 0x73, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x10, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x61, 0x63, 0x6b, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x61, 0x63, 0x6b, 0x50, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44,
+	0x22, 0x94, 0x02, 0x0a, 0x14, 0x54, 0x53, 0x53, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x54, 0x68, 0x72,
+	0x65, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x6e,
+	0x64, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x73, 0x65, 0x6e,
+	0x64, 0x65, 0x72, 0x49, 0x44, 0x12, 0x2a, 0x0a, 0x10, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61,
+	0x73, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x10, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x12, 0x55, 0x0a, 0x0c, 0x70, 0x65, 0x65, 0x72, 0x73, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x31, 0x2e, 0x72, 0x65, 0x73, 0x68, 0x61, 0x72,
+	0x69, 0x6e, 0x67, 0x2e, 0x54, 0x53, 0x53, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x54, 0x68, 0x72, 0x65,
+	0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x73, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x70, 0x65, 0x65, 0x72,
+	0x73, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x1a, 0x3f, 0x0a, 0x11, 0x50, 0x65, 0x65, 0x72, 0x73, 0x50,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x92, 0x02, 0x0a, 0x13, 0x54, 0x53, 0x53, 0x52,
+	0x6f, 0x75, 0x6e, 0x64, 0x46, 0x6f, 0x75, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x12, 0x2a, 0x0a, 0x10, 0x62,
+	0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x10, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74,
+	0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x54, 0x0a, 0x0c, 0x70, 0x65, 0x65, 0x72, 0x73,
+	0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x30, 0x2e,
+	0x72, 0x65, 0x73, 0x68, 0x61, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x53, 0x53, 0x52, 0x6f, 0x75,
+	0x6e, 0x64, 0x46, 0x6f, 0x75, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x50, 0x65,
+	0x65, 0x72, 0x73, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
+	0x0c, 0x70, 0x65, 0x65, 0x72, 0x73, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1c, 0x0a,
+	0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x1a, 0x3f, 0x0a, 0x11, 0x50,
+	0x65, 0x65, 0x72, 0x73, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x7b, 0x0a, 0x13,
+	0x54, 0x53, 0x53, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x46, 0x69, 0x76, 0x65, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x12,
+	0x2a, 0x0a, 0x10, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x50, 0x61, 0x79, 0x6c,
+	0x6f, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x10, 0x62, 0x72, 0x6f, 0x61, 0x64,
+	0x63, 0x61, 0x73, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x22, 0x71, 0x0a, 0x15, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x44, 0x12, 0x1e,
+	0x0a, 0x0a, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x48, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x0a, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1c,
+	0x0a, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x22, 0x72, 0x0a, 0x16,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72,
+	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72,
+	0x49, 0x44, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x48, 0x61, 0x73, 0x68,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x48, 0x61,
+	0x73, 0x68, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44,
+	0x42, 0x06, 0x5a, 0x04, 0x2e, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescOnce sync.Once
+	file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescData = file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDesc
+)
+
+func file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescGZIP() []byte {
+	file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescOnce.Do(func() {
+		file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescData)
+	})
+	return file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDescData
+}
+
+var file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_pkg_tecdsa_resharing_gen_pb_message_proto_goTypes = []interface{}{
+	(*EphemeralPublicKeyMessage)(nil), // 0: resharing.EphemeralPublicKeyMessage
+	(*TSSRoundOneMessage)(nil),        // 1: resharing.TSSRoundOneMessage
+	(*TSSRoundTwoMessage)(nil),        // 2: resharing.TSSRoundTwoMessage
+	(*TSSRoundThreeMessage)(nil),      // 3: resharing.TSSRoundThreeMessage
+	(*TSSRoundFourMessage)(nil),       // 4: resharing.TSSRoundFourMessage
+	(*TSSRoundFiveMessage)(nil),       // 5: resharing.TSSRoundFiveMessage
+	(*ResultPreparedMessage)(nil),     // 6: resharing.ResultPreparedMessage
+	(*ResultCommittedMessage)(nil),    // 7: resharing.ResultCommittedMessage
+	nil,                               // 8: resharing.EphemeralPublicKeyMessage.EphemeralPublicKeysEntry
+	nil,                               // 9: resharing.TSSRoundThreeMessage.PeersPayloadEntry
+	nil,                               // 10: resharing.TSSRoundFourMessage.PeersPayloadEntry
+}
+var file_pkg_tecdsa_resharing_gen_pb_message_proto_depIdxs = []int32{
+	8,  // 0: resharing.EphemeralPublicKeyMessage.ephemeralPublicKeys:type_name -> resharing.EphemeralPublicKeyMessage.EphemeralPublicKeysEntry
+	9,  // 1: resharing.TSSRoundThreeMessage.peersPayload:type_name -> resharing.TSSRoundThreeMessage.PeersPayloadEntry
+	10, // 2: resharing.TSSRoundFourMessage.peersPayload:type_name -> resharing.TSSRoundFourMessage.PeersPayloadEntry
+	3,  // [3:3] is the sub-list for method output_type
+	3,  // [3:3] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_pkg_tecdsa_resharing_gen_pb_message_proto_init() }
+func file_pkg_tecdsa_resharing_gen_pb_message_proto_init() {
+	if File_pkg_tecdsa_resharing_gen_pb_message_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EphemeralPublicKeyMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TSSRoundOneMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TSSRoundTwoMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TSSRoundThreeMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TSSRoundFourMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TSSRoundFiveMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResultPreparedMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResultCommittedMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_pkg_tecdsa_resharing_gen_pb_message_proto_goTypes,
+		DependencyIndexes: file_pkg_tecdsa_resharing_gen_pb_message_proto_depIdxs,
+		MessageInfos:      file_pkg_tecdsa_resharing_gen_pb_message_proto_msgTypes,
+	}.Build()
+	File_pkg_tecdsa_resharing_gen_pb_message_proto = out.File
+	file_pkg_tecdsa_resharing_gen_pb_message_proto_rawDesc = nil
+	file_pkg_tecdsa_resharing_gen_pb_message_proto_goTypes = nil
+	file_pkg_tecdsa_resharing_gen_pb_message_proto_depIdxs = nil
+}
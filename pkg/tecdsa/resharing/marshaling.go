@@ -0,0 +1,357 @@
+package resharing
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/keep-network/keep-core/pkg/crypto/ephemeral"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa/resharing/gen/pb"
+)
+
+// Marshal converts this ephemeralPublicKeyMessage to a byte array suitable for
+// network communication.
+func (epkm *ephemeralPublicKeyMessage) Marshal() ([]byte, error) {
+	ephemeralPublicKeys, err := marshalPublicKeyMap(epkm.ephemeralPublicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(&pb.EphemeralPublicKeyMessage{
+		SenderID:            uint32(epkm.senderID),
+		EphemeralPublicKeys: ephemeralPublicKeys,
+		SessionID:           epkm.sessionID,
+	})
+}
+
+// Unmarshal converts a byte array produced by Marshal to
+// an ephemeralPublicKeyMessage
+func (epkm *ephemeralPublicKeyMessage) Unmarshal(bytes []byte) error {
+	pbMsg := pb.EphemeralPublicKeyMessage{}
+	if err := proto.Unmarshal(bytes, &pbMsg); err != nil {
+		return err
+	}
+
+	if err := validateMemberIndex(pbMsg.SenderID); err != nil {
+		return err
+	}
+	epkm.senderID = group.MemberIndex(pbMsg.SenderID)
+
+	ephemeralPublicKeys, err := unmarshalPublicKeyMap(pbMsg.EphemeralPublicKeys)
+	if err != nil {
+		return err
+	}
+
+	epkm.ephemeralPublicKeys = ephemeralPublicKeys
+	epkm.sessionID = pbMsg.SessionID
+
+	return nil
+}
+
+// Marshal converts this tssRoundOneMessage to a byte array suitable for
+// network communication.
+func (trom *tssRoundOneMessage) Marshal() ([]byte, error) {
+	return proto.Marshal(&pb.TSSRoundOneMessage{
+		SenderID:         uint32(trom.senderID),
+		BroadcastPayload: trom.broadcastPayload,
+		SessionID:        trom.sessionID,
+	})
+}
+
+// Unmarshal converts a byte array produced by Marshal to a tssRoundOneMessage.
+func (trom *tssRoundOneMessage) Unmarshal(bytes []byte) error {
+	pbMsg := pb.TSSRoundOneMessage{}
+	if err := proto.Unmarshal(bytes, &pbMsg); err != nil {
+		return err
+	}
+
+	if err := validateMemberIndex(pbMsg.SenderID); err != nil {
+		return err
+	}
+
+	trom.senderID = group.MemberIndex(pbMsg.SenderID)
+	trom.broadcastPayload = pbMsg.BroadcastPayload
+	trom.sessionID = pbMsg.SessionID
+
+	return nil
+}
+
+// Marshal converts this tssRoundTwoMessage to a byte array suitable for
+// network communication.
+func (trtm *tssRoundTwoMessage) Marshal() ([]byte, error) {
+	return proto.Marshal(&pb.TSSRoundTwoMessage{
+		SenderID:         uint32(trtm.senderID),
+		BroadcastPayload: trtm.broadcastPayload,
+		AckPayload:       trtm.ackPayload,
+		SessionID:        trtm.sessionID,
+	})
+}
+
+// Unmarshal converts a byte array produced by Marshal to a tssRoundTwoMessage.
+func (trtm *tssRoundTwoMessage) Unmarshal(bytes []byte) error {
+	pbMsg := pb.TSSRoundTwoMessage{}
+	if err := proto.Unmarshal(bytes, &pbMsg); err != nil {
+		return err
+	}
+
+	if err := validateMemberIndex(pbMsg.SenderID); err != nil {
+		return err
+	}
+
+	trtm.senderID = group.MemberIndex(pbMsg.SenderID)
+	trtm.broadcastPayload = pbMsg.BroadcastPayload
+	trtm.ackPayload = pbMsg.AckPayload
+	trtm.sessionID = pbMsg.SessionID
+
+	return nil
+}
+
+// Marshal converts this tssRoundThreeMessage to a byte array suitable for
+// network communication.
+func (trtm *tssRoundThreeMessage) Marshal() ([]byte, error) {
+	return proto.Marshal(&pb.TSSRoundThreeMessage{
+		SenderID:         uint32(trtm.senderID),
+		BroadcastPayload: trtm.broadcastPayload,
+		PeersPayload:     marshalPeersPayload(trtm.peersPayload),
+		SessionID:        trtm.sessionID,
+	})
+}
+
+// Unmarshal converts a byte array produced by Marshal to a tssRoundThreeMessage.
+func (trtm *tssRoundThreeMessage) Unmarshal(bytes []byte) error {
+	pbMsg := pb.TSSRoundThreeMessage{}
+	if err := proto.Unmarshal(bytes, &pbMsg); err != nil {
+		return err
+	}
+
+	if err := validateMemberIndex(pbMsg.SenderID); err != nil {
+		return err
+	}
+
+	peersPayload, err := unmarshalPeersPayload(pbMsg.PeersPayload)
+	if err != nil {
+		return err
+	}
+
+	trtm.senderID = group.MemberIndex(pbMsg.SenderID)
+	trtm.broadcastPayload = pbMsg.BroadcastPayload
+	trtm.peersPayload = peersPayload
+	trtm.sessionID = pbMsg.SessionID
+
+	return nil
+}
+
+// Marshal converts this tssRoundFourMessage to a byte array suitable for
+// network communication.
+func (trfm *tssRoundFourMessage) Marshal() ([]byte, error) {
+	return proto.Marshal(&pb.TSSRoundFourMessage{
+		SenderID:         uint32(trfm.senderID),
+		BroadcastPayload: trfm.broadcastPayload,
+		PeersPayload:     marshalPeersPayload(trfm.peersPayload),
+		SessionID:        trfm.sessionID,
+	})
+}
+
+// Unmarshal converts a byte array produced by Marshal to a tssRoundFourMessage.
+func (trfm *tssRoundFourMessage) Unmarshal(bytes []byte) error {
+	pbMsg := pb.TSSRoundFourMessage{}
+	if err := proto.Unmarshal(bytes, &pbMsg); err != nil {
+		return err
+	}
+
+	if err := validateMemberIndex(pbMsg.SenderID); err != nil {
+		return err
+	}
+
+	peersPayload, err := unmarshalPeersPayload(pbMsg.PeersPayload)
+	if err != nil {
+		return err
+	}
+
+	trfm.senderID = group.MemberIndex(pbMsg.SenderID)
+	trfm.broadcastPayload = pbMsg.BroadcastPayload
+	trfm.peersPayload = peersPayload
+	trfm.sessionID = pbMsg.SessionID
+
+	return nil
+}
+
+// Marshal converts this tssRoundFiveMessage to a byte array suitable for
+// network communication.
+func (trfm *tssRoundFiveMessage) Marshal() ([]byte, error) {
+	return proto.Marshal(&pb.TSSRoundFiveMessage{
+		SenderID:         uint32(trfm.senderID),
+		BroadcastPayload: trfm.broadcastPayload,
+		SessionID:        trfm.sessionID,
+	})
+}
+
+// Unmarshal converts a byte array produced by Marshal to a tssRoundFiveMessage.
+func (trfm *tssRoundFiveMessage) Unmarshal(bytes []byte) error {
+	pbMsg := pb.TSSRoundFiveMessage{}
+	if err := proto.Unmarshal(bytes, &pbMsg); err != nil {
+		return err
+	}
+
+	if err := validateMemberIndex(pbMsg.SenderID); err != nil {
+		return err
+	}
+
+	trfm.senderID = group.MemberIndex(pbMsg.SenderID)
+	trfm.broadcastPayload = pbMsg.BroadcastPayload
+	trfm.sessionID = pbMsg.SessionID
+
+	return nil
+}
+
+// Marshal converts this resultPreparedMessage to a byte array suitable for
+// network communication.
+func (rpm *resultPreparedMessage) Marshal() ([]byte, error) {
+	return proto.Marshal(&pb.ResultPreparedMessage{
+		SenderID:   uint32(rpm.senderID),
+		ResultHash: rpm.resultHash[:],
+		SessionID:  rpm.sessionID,
+	})
+}
+
+// Unmarshal converts a byte array produced by Marshal to a
+// resultPreparedMessage.
+func (rpm *resultPreparedMessage) Unmarshal(bytes []byte) error {
+	pbMsg := pb.ResultPreparedMessage{}
+	if err := proto.Unmarshal(bytes, &pbMsg); err != nil {
+		return err
+	}
+
+	if err := validateMemberIndex(pbMsg.SenderID); err != nil {
+		return err
+	}
+
+	resultHash, err := unmarshalResultHash(pbMsg.ResultHash)
+	if err != nil {
+		return err
+	}
+
+	rpm.senderID = group.MemberIndex(pbMsg.SenderID)
+	rpm.resultHash = resultHash
+	rpm.sessionID = pbMsg.SessionID
+
+	return nil
+}
+
+// Marshal converts this resultCommittedMessage to a byte array suitable for
+// network communication.
+func (rcm *resultCommittedMessage) Marshal() ([]byte, error) {
+	return proto.Marshal(&pb.ResultCommittedMessage{
+		SenderID:   uint32(rcm.senderID),
+		ResultHash: rcm.resultHash[:],
+		SessionID:  rcm.sessionID,
+	})
+}
+
+// Unmarshal converts a byte array produced by Marshal to a
+// resultCommittedMessage.
+func (rcm *resultCommittedMessage) Unmarshal(bytes []byte) error {
+	pbMsg := pb.ResultCommittedMessage{}
+	if err := proto.Unmarshal(bytes, &pbMsg); err != nil {
+		return err
+	}
+
+	if err := validateMemberIndex(pbMsg.SenderID); err != nil {
+		return err
+	}
+
+	resultHash, err := unmarshalResultHash(pbMsg.ResultHash)
+	if err != nil {
+		return err
+	}
+
+	rcm.senderID = group.MemberIndex(pbMsg.SenderID)
+	rcm.resultHash = resultHash
+	rcm.sessionID = pbMsg.SessionID
+
+	return nil
+}
+
+func validateMemberIndex(protoIndex uint32) error {
+	// Protobuf does not have uint8 type, so we are using uint32. When
+	// unmarshalling message, we need to make sure we do not overflow.
+	if protoIndex > group.MaxMemberIndex {
+		return fmt.Errorf("invalid member index value: [%v]", protoIndex)
+	}
+	return nil
+}
+
+func unmarshalResultHash(resultHash []byte) ([sha256.Size]byte, error) {
+	var unmarshalled [sha256.Size]byte
+
+	if len(resultHash) != len(unmarshalled) {
+		return unmarshalled, fmt.Errorf(
+			"invalid result hash length: [%v]",
+			len(resultHash),
+		)
+	}
+
+	copy(unmarshalled[:], resultHash)
+
+	return unmarshalled, nil
+}
+
+func marshalPeersPayload(
+	peersPayload map[group.MemberIndex][]byte,
+) map[uint32][]byte {
+	marshalled := make(map[uint32][]byte, len(peersPayload))
+	for receiverID, payload := range peersPayload {
+		marshalled[uint32(receiverID)] = payload
+	}
+	return marshalled
+}
+
+func unmarshalPeersPayload(
+	peersPayload map[uint32][]byte,
+) (map[group.MemberIndex][]byte, error) {
+	unmarshalled := make(map[group.MemberIndex][]byte, len(peersPayload))
+	for receiverID, payload := range peersPayload {
+		if err := validateMemberIndex(receiverID); err != nil {
+			return nil, err
+		}
+
+		unmarshalled[group.MemberIndex(receiverID)] = payload
+	}
+	return unmarshalled, nil
+}
+
+func marshalPublicKeyMap(
+	publicKeys map[group.MemberIndex]*ephemeral.PublicKey,
+) (map[uint32][]byte, error) {
+	marshalled := make(map[uint32][]byte, len(publicKeys))
+	for id, publicKey := range publicKeys {
+		if publicKey == nil {
+			return nil, fmt.Errorf("nil public key for member [%v]", id)
+		}
+
+		marshalled[uint32(id)] = publicKey.Marshal()
+	}
+	return marshalled, nil
+}
+
+func unmarshalPublicKeyMap(
+	publicKeys map[uint32][]byte,
+) (map[group.MemberIndex]*ephemeral.PublicKey, error) {
+	var unmarshalled = make(map[group.MemberIndex]*ephemeral.PublicKey, len(publicKeys))
+	for memberID, publicKeyBytes := range publicKeys {
+		if err := validateMemberIndex(memberID); err != nil {
+			return nil, err
+		}
+
+		publicKey, err := ephemeral.UnmarshalPublicKey(publicKeyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("could not unmarshal public key [%v]", err)
+		}
+
+		unmarshalled[group.MemberIndex(memberID)] = publicKey
+	}
+
+	return unmarshalled, nil
+}
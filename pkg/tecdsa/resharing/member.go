@@ -0,0 +1,453 @@
+package resharing
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/bnb-chain/tss-lib/ecdsa/keygen"
+	"github.com/bnb-chain/tss-lib/ecdsa/resharing"
+	"github.com/bnb-chain/tss-lib/tss"
+	"github.com/ipfs/go-log/v2"
+	"golang.org/x/exp/slices"
+
+	"github.com/keep-network/keep-core/pkg/crypto/ephemeral"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+	"github.com/keep-network/keep-core/pkg/tecdsa/common"
+)
+
+// Member represents a resharing protocol member.
+//
+// Each member takes part in the TSS resharing as a member of the new
+// committee receiving the refreshed private key share. The new committee
+// consists of all group members. Additionally, members chosen as dealers
+// take part in the TSS resharing as members of the old committee holding
+// the current private key shares. The old committee consists of at least
+// honest threshold group members.
+type member struct {
+	// Logger used to produce log messages.
+	logger log.StandardLogger
+	// id of this group member.
+	id group.MemberIndex
+	// Group to which this member belongs.
+	group *group.Group
+	// Validator allowing to check public key and member index against
+	// group members
+	membershipValidator *group.MembershipValidator
+	// Identifier of the particular resharing session this member is part of.
+	sessionID string
+	// Indexes of group members forming the old committee, i.e. dealing
+	// their current private key shares.
+	oldCommitteeMembersIndexes []group.MemberIndex
+	// Current tECDSA private key share of the member.
+	privateKeyShare *tecdsa.PrivateKeyShare
+	// TSS pre-parameters used by the member in the new committee.
+	tssPreParams *keygen.LocalPreParams
+	// Instance of the member identity converter for the old committee.
+	oldIdentityConverter *identityConverter
+	// Instance of the member identity converter for the new committee.
+	newIdentityConverter *identityConverter
+}
+
+// newMember creates a new member in an initial state
+func newMember(
+	logger log.StandardLogger,
+	memberID group.MemberIndex,
+	groupSize,
+	dishonestThreshold int,
+	membershipValidator *group.MembershipValidator,
+	sessionID string,
+	oldCommitteeMembersIndexes []group.MemberIndex,
+	privateKeyShare *tecdsa.PrivateKeyShare,
+	tssPreParams *keygen.LocalPreParams,
+) *member {
+	oldKeys := privateKeyShare.Data().Ks
+
+	return &member{
+		logger:                     logger,
+		id:                         memberID,
+		group:                      group.NewGroup(dishonestThreshold, groupSize),
+		membershipValidator:        membershipValidator,
+		sessionID:                  sessionID,
+		oldCommitteeMembersIndexes: oldCommitteeMembersIndexes,
+		privateKeyShare:            privateKeyShare,
+		tssPreParams:               tssPreParams,
+		oldIdentityConverter:       &identityConverter{keys: oldKeys},
+		newIdentityConverter:       &identityConverter{keys: newCommitteeKeys(oldKeys)},
+	}
+}
+
+// newCommitteeKeys derives TSS party ID keys of the new committee members
+// from the keys of the old committee members. The TSS resharing requires
+// both committees to have disjoint keys so each old key is shifted by the
+// span of all old keys. This preserves the order of keys and, in result,
+// the mapping between group member indexes and TSS party IDs.
+func newCommitteeKeys(oldKeys []*big.Int) []*big.Int {
+	if len(oldKeys) == 0 {
+		return nil
+	}
+
+	minKey, maxKey := oldKeys[0], oldKeys[0]
+	for _, key := range oldKeys {
+		if key.Cmp(minKey) < 0 {
+			minKey = key
+		}
+		if key.Cmp(maxKey) > 0 {
+			maxKey = key
+		}
+	}
+
+	shift := new(big.Int).Sub(maxKey, minKey)
+	shift.Add(shift, big.NewInt(1))
+
+	newKeys := make([]*big.Int, len(oldKeys))
+	for i, key := range oldKeys {
+		newKeys[i] = new(big.Int).Add(key, shift)
+	}
+
+	return newKeys
+}
+
+// shouldAcceptMessage indicates whether the given member should accept
+// a message from the given sender.
+func (m *member) shouldAcceptMessage(
+	senderID group.MemberIndex,
+	senderPublicKey []byte,
+) bool {
+	isMessageFromSelf := senderID == m.id
+	isSenderValid := m.membershipValidator.IsValidMembership(
+		senderID,
+		senderPublicKey,
+	)
+	isSenderAccepted := m.group.IsOperating(senderID)
+
+	return !isMessageFromSelf && isSenderValid && isSenderAccepted
+}
+
+// isOldCommitteeMember indicates whether the given member belongs to the
+// old committee dealing their current private key shares.
+func (m *member) isOldCommitteeMember(memberIndex group.MemberIndex) bool {
+	return slices.Contains(m.oldCommitteeMembersIndexes, memberIndex)
+}
+
+// otherOldCommitteeMembersCount returns the number of old committee members
+// other than the given member.
+func (m *member) otherOldCommitteeMembersCount() int {
+	count := len(m.oldCommitteeMembersIndexes)
+	if m.isOldCommitteeMember(m.id) {
+		count--
+	}
+
+	return count
+}
+
+// initializeEphemeralKeysGeneration performs a transition of a member state
+// from the initial state to the first phase of the protocol.
+func (m *member) initializeEphemeralKeysGeneration() *ephemeralKeyPairGeneratingMember {
+	return &ephemeralKeyPairGeneratingMember{
+		member:            m,
+		ephemeralKeyPairs: make(map[group.MemberIndex]*ephemeral.KeyPair),
+	}
+}
+
+// ephemeralKeyPairGeneratingMember represents one member in a resharing group
+// performing ephemeral key pair generation. It has a full list of `memberIndexes`
+// that belong to its threshold group.
+type ephemeralKeyPairGeneratingMember struct {
+	*member
+
+	// Ephemeral key pairs used to create symmetric keys,
+	// generated individually for each other group member.
+	ephemeralKeyPairs map[group.MemberIndex]*ephemeral.KeyPair
+}
+
+// initializeSymmetricKeyGeneration performs a transition of the member state
+// to the next phase. It returns a member instance ready to execute the
+// next phase of the protocol.
+func (ekpgm *ephemeralKeyPairGeneratingMember) initializeSymmetricKeyGeneration() *symmetricKeyGeneratingMember {
+	return &symmetricKeyGeneratingMember{
+		ephemeralKeyPairGeneratingMember: ekpgm,
+		symmetricKeys:                    make(map[group.MemberIndex]ephemeral.SymmetricKey),
+	}
+}
+
+// symmetricKeyGeneratingMember represents one member in a resharing group
+// performing ephemeral symmetric key generation.
+type symmetricKeyGeneratingMember struct {
+	*ephemeralKeyPairGeneratingMember
+
+	// Symmetric keys used to encrypt confidential information,
+	// generated individually for each other group member by ECDH'ing the
+	// broadcasted ephemeral public key intended for this member and the
+	// ephemeral private key generated for the other member.
+	symmetricKeys map[group.MemberIndex]ephemeral.SymmetricKey
+}
+
+// initializeTssRoundOne returns a member to perform next protocol operations.
+func (skgm *symmetricKeyGeneratingMember) initializeTssRoundOne() *tssRoundOneMember {
+	oldTssPartyID, oldGroupTssPartiesIDs := common.GenerateTssPartiesIDs(
+		skgm.id,
+		skgm.oldCommitteeMembersIndexes,
+		skgm.oldIdentityConverter,
+	)
+	newTssPartyID, newGroupTssPartiesIDs := common.GenerateTssPartiesIDs(
+		skgm.id,
+		skgm.group.MemberIndexes(),
+		skgm.newIdentityConverter,
+	)
+
+	sortedOldGroupTssPartiesIDs := tss.SortPartyIDs(oldGroupTssPartiesIDs)
+
+	oldPeerContext := tss.NewPeerContext(sortedOldGroupTssPartiesIDs)
+	newPeerContext := tss.NewPeerContext(tss.SortPartyIDs(newGroupTssPartiesIDs))
+
+	threshold := skgm.group.HonestThreshold() - 1
+
+	newResharingParameters := func(partyID *tss.PartyID) *tss.ReSharingParameters {
+		return tss.NewReSharingParameters(
+			tecdsa.Curve,
+			oldPeerContext,
+			newPeerContext,
+			partyID,
+			len(oldGroupTssPartiesIDs),
+			threshold,
+			len(newGroupTssPartiesIDs),
+			threshold,
+		)
+	}
+
+	newTssParameters := newResharingParameters(newTssPartyID)
+
+	// Both local parties use the same outgoing messages channel. The channel
+	// must be able to hold all messages produced by both parties in a single
+	// round, that is, at most N P2P messages and one broadcast message.
+	tssOutgoingMessagesChan := make(
+		chan tss.Message,
+		2*(len(newGroupTssPartiesIDs)+1),
+	)
+	// Both parties publish their results at the end of the protocol so the
+	// result channels must be buffered to not block the parties.
+	oldTssResultChan := make(chan keygen.LocalPartySaveData, 1)
+	newTssResultChan := make(chan keygen.LocalPartySaveData, 1)
+
+	// Only members of the old committee deal their current private key
+	// shares so only they run the old committee party.
+	var oldTssParty tss.Party
+	if skgm.isOldCommitteeMember(skgm.id) {
+		// The old committee party zeroes its private key share at the end
+		// of the protocol. Pass a copy of the share to keep the one held
+		// by the member intact. The share must be also narrowed down to
+		// the old committee as the dealt share is computed using Lagrange
+		// coefficients of the old committee members.
+		oldTssKey := skgm.privateKeyShare.Data()
+		oldTssKey.Xi = new(big.Int).Set(oldTssKey.Xi)
+		oldTssKey = keygen.BuildLocalSaveDataSubset(
+			oldTssKey,
+			sortedOldGroupTssPartiesIDs,
+		)
+
+		oldTssParty = resharing.NewLocalParty(
+			newResharingParameters(oldTssPartyID),
+			oldTssKey,
+			tssOutgoingMessagesChan,
+			oldTssResultChan,
+		)
+	}
+
+	newTssKey := keygen.NewLocalPartySaveData(len(newGroupTssPartiesIDs))
+	newTssKey.LocalPreParams = *skgm.tssPreParams
+
+	newTssParty := resharing.NewLocalParty(
+		newTssParameters,
+		newTssKey,
+		tssOutgoingMessagesChan,
+		newTssResultChan,
+	)
+
+	return &tssRoundOneMember{
+		symmetricKeyGeneratingMember: skgm,
+		oldTssParty:                  oldTssParty,
+		newTssParty:                  newTssParty,
+		tssParameters:                newTssParameters,
+		tssOutgoingMessagesChan:      tssOutgoingMessagesChan,
+		oldTssResultChan:             oldTssResultChan,
+		newTssResultChan:             newTssResultChan,
+	}
+}
+
+// tssRoundOneMember represents one member in a resharing group performing the
+// first round of the TSS resharing.
+type tssRoundOneMember struct {
+	*symmetricKeyGeneratingMember
+
+	// TSS party of this member acting in the old committee. Nil if the
+	// member does not belong to the old committee.
+	oldTssParty tss.Party
+	// TSS party of this member acting in the new committee.
+	newTssParty tss.Party
+	// TSS parameters holding sorted parties IDs of both committees.
+	tssParameters           *tss.ReSharingParameters
+	tssOutgoingMessagesChan <-chan tss.Message
+	oldTssResultChan        <-chan keygen.LocalPartySaveData
+	newTssResultChan        <-chan keygen.LocalPartySaveData
+}
+
+// initializeTssRoundTwo returns a member to perform next protocol operations.
+func (trom *tssRoundOneMember) initializeTssRoundTwo() *tssRoundTwoMember {
+	return &tssRoundTwoMember{
+		tssRoundOneMember: trom,
+	}
+}
+
+// tssRoundTwoMember represents one member in a resharing group performing the
+// second round of the TSS resharing.
+type tssRoundTwoMember struct {
+	*tssRoundOneMember
+}
+
+// initializeTssRoundThree returns a member to perform next protocol operations.
+func (trtm *tssRoundTwoMember) initializeTssRoundThree() *tssRoundThreeMember {
+	return &tssRoundThreeMember{
+		tssRoundTwoMember: trtm,
+	}
+}
+
+// tssRoundThreeMember represents one member in a resharing group performing
+// the third round of the TSS resharing.
+type tssRoundThreeMember struct {
+	*tssRoundTwoMember
+}
+
+// initializeTssRoundFour returns a member to perform next protocol operations.
+func (trtm *tssRoundThreeMember) initializeTssRoundFour() *tssRoundFourMember {
+	return &tssRoundFourMember{
+		tssRoundThreeMember: trtm,
+	}
+}
+
+// tssRoundFourMember represents one member in a resharing group performing
+// the fourth round of the TSS resharing.
+type tssRoundFourMember struct {
+	*tssRoundThreeMember
+}
+
+// initializeTssRoundFive returns a member to perform next protocol operations.
+func (trfm *tssRoundFourMember) initializeTssRoundFive() *tssRoundFiveMember {
+	return &tssRoundFiveMember{
+		tssRoundFourMember: trfm,
+	}
+}
+
+// tssRoundFiveMember represents one member in a resharing group performing
+// the fifth round of the TSS resharing.
+type tssRoundFiveMember struct {
+	*tssRoundFourMember
+}
+
+// initializeFinalization returns a member to perform next protocol operations.
+func (trfm *tssRoundFiveMember) initializeFinalization() *finalizingMember {
+	return &finalizingMember{
+		tssRoundFiveMember: trfm,
+	}
+}
+
+// finalizingMember represents one member of the given group, after it
+// completed the TSS part of the resharing process.
+//
+// Computes a result candidate in this phase of the protocol.
+type finalizingMember struct {
+	*tssRoundFiveMember
+
+	tssResult *keygen.LocalPartySaveData
+}
+
+// initializeResultPreparation returns a member to perform next protocol
+// operations.
+func (fm *finalizingMember) initializeResultPreparation() *resultPreparingMember {
+	return &resultPreparingMember{
+		finalizingMember: fm,
+	}
+}
+
+// resultPreparingMember represents one member in a resharing group announcing
+// it computed the refreshed private key share.
+type resultPreparingMember struct {
+	*finalizingMember
+
+	// Hash of the public part of the refreshed private key share computed
+	// by this member.
+	resultHash [sha256.Size]byte
+}
+
+// initializeResultCommitment returns a member to perform next protocol
+// operations.
+func (rpm *resultPreparingMember) initializeResultCommitment() *resultCommittingMember {
+	return &resultCommittingMember{
+		resultPreparingMember: rpm,
+	}
+}
+
+// resultCommittingMember represents one member in a resharing group
+// committing to the refreshed private key share once all other members
+// announced they computed the same result.
+type resultCommittingMember struct {
+	*resultPreparingMember
+}
+
+// initializeCommitted returns a member to perform next protocol operations.
+func (rcm *resultCommittingMember) initializeCommitted() *committedMember {
+	return &committedMember{
+		resultCommittingMember: rcm,
+	}
+}
+
+// committedMember represents one member of the given group, after it
+// completed the resharing process and all group members committed to
+// the refreshed private key shares.
+type committedMember struct {
+	*resultCommittingMember
+}
+
+// Result is a successful computation of the refreshed tECDSA private key
+// share.
+func (cm *committedMember) Result() *Result {
+	return &Result{
+		PrivateKeyShare: tecdsa.NewPrivateKeyShare(*cm.tssResult),
+	}
+}
+
+// identityConverter implements the common.IdentityConverter for tECDSA
+// resharing. It does the conversion using the predefined keys list of
+// the given committee.
+type identityConverter struct {
+	keys []*big.Int
+}
+
+func (ic *identityConverter) MemberIndexToTssPartyID(
+	memberIndex group.MemberIndex,
+) *tss.PartyID {
+	partyIDKey := ic.MemberIndexToTssPartyIDKey(memberIndex)
+
+	return tss.NewPartyID(
+		partyIDKey.Text(10),
+		fmt.Sprintf("member-%v", memberIndex),
+		partyIDKey,
+	)
+}
+
+func (ic *identityConverter) MemberIndexToTssPartyIDKey(
+	memberIndex group.MemberIndex,
+) *big.Int {
+	return ic.keys[memberIndex-1]
+}
+
+func (ic *identityConverter) TssPartyIDToMemberIndex(
+	partyID *tss.PartyID,
+) group.MemberIndex {
+	index := slices.IndexFunc(ic.keys, func(key *big.Int) bool {
+		return key.Cmp(partyID.KeyInt()) == 0
+	})
+
+	return group.MemberIndex(index + 1)
+}
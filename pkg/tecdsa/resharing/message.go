@@ -0,0 +1,234 @@
+package resharing
+
+import (
+	"crypto/sha256"
+
+	"github.com/keep-network/keep-core/pkg/crypto/ephemeral"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+)
+
+const messageTypePrefix = "tecdsa_resharing/"
+
+// message holds common traits of all resharing protocol messages.
+type message interface {
+	// SenderID returns protocol-level identifier of the message sender.
+	SenderID() group.MemberIndex
+	// SessionID returns the session identifier of the message.
+	SessionID() string
+	// Type returns the exact type of the message.
+	Type() string
+}
+
+// ephemeralPublicKeyMessage is a message payload that carries the sender's
+// ephemeral public keys generated for all other group members.
+//
+// The receiver performs ECDH on a sender's ephemeral public key intended for
+// the receiver and on the receiver's private ephemeral key, creating a symmetric
+// key used for encrypting a conversation between the sender and the receiver.
+type ephemeralPublicKeyMessage struct {
+	senderID group.MemberIndex
+
+	ephemeralPublicKeys map[group.MemberIndex]*ephemeral.PublicKey
+	sessionID           string
+}
+
+// SenderID returns protocol-level identifier of the message sender.
+func (epkm *ephemeralPublicKeyMessage) SenderID() group.MemberIndex {
+	return epkm.senderID
+}
+
+// SessionID returns the session identifier of the message.
+func (epkm *ephemeralPublicKeyMessage) SessionID() string {
+	return epkm.sessionID
+}
+
+// Type returns a string describing an ephemeralPublicKeyMessage type for
+// marshaling purposes.
+func (epkm *ephemeralPublicKeyMessage) Type() string {
+	return messageTypePrefix + "ephemeral_public_key_message"
+}
+
+// tssRoundOneMessage is a message payload that carries the sender's
+// TSS round one components produced by the sender's old committee party.
+type tssRoundOneMessage struct {
+	senderID group.MemberIndex
+
+	broadcastPayload []byte
+	sessionID        string
+}
+
+// SenderID returns protocol-level identifier of the message sender.
+func (trom *tssRoundOneMessage) SenderID() group.MemberIndex {
+	return trom.senderID
+}
+
+// SessionID returns the session identifier of the message.
+func (trom *tssRoundOneMessage) SessionID() string {
+	return trom.sessionID
+}
+
+// Type returns a string describing a tssRoundOneMessage type for
+// marshaling purposes.
+func (trom *tssRoundOneMessage) Type() string {
+	return messageTypePrefix + "tss_round_one_message"
+}
+
+// tssRoundTwoMessage is a message payload that carries the sender's
+// TSS round two components produced by the sender's new committee party.
+// The broadcast payload is intended for the new committee while the ACK
+// payload is intended for the old committee.
+type tssRoundTwoMessage struct {
+	senderID group.MemberIndex
+
+	broadcastPayload []byte
+	ackPayload       []byte
+	sessionID        string
+}
+
+// SenderID returns protocol-level identifier of the message sender.
+func (trtm *tssRoundTwoMessage) SenderID() group.MemberIndex {
+	return trtm.senderID
+}
+
+// SessionID returns the session identifier of the message.
+func (trtm *tssRoundTwoMessage) SessionID() string {
+	return trtm.sessionID
+}
+
+// Type returns a string describing a tssRoundTwoMessage type for
+// marshaling purposes.
+func (trtm *tssRoundTwoMessage) Type() string {
+	return messageTypePrefix + "tss_round_two_message"
+}
+
+// tssRoundThreeMessage is a message payload that carries the sender's
+// TSS round three components produced by the sender's old committee party.
+// The peers payload holds the new key shares of the receivers.
+type tssRoundThreeMessage struct {
+	senderID group.MemberIndex
+
+	broadcastPayload []byte
+	peersPayload     map[group.MemberIndex][]byte
+	sessionID        string
+}
+
+// SenderID returns protocol-level identifier of the message sender.
+func (trtm *tssRoundThreeMessage) SenderID() group.MemberIndex {
+	return trtm.senderID
+}
+
+// SessionID returns the session identifier of the message.
+func (trtm *tssRoundThreeMessage) SessionID() string {
+	return trtm.sessionID
+}
+
+// Type returns a string describing a tssRoundThreeMessage type for
+// marshaling purposes.
+func (trtm *tssRoundThreeMessage) Type() string {
+	return messageTypePrefix + "tss_round_three_message"
+}
+
+// tssRoundFourMessage is a message payload that carries the sender's
+// TSS round four components produced by the sender's new committee party.
+type tssRoundFourMessage struct {
+	senderID group.MemberIndex
+
+	broadcastPayload []byte
+	peersPayload     map[group.MemberIndex][]byte
+	sessionID        string
+}
+
+// SenderID returns protocol-level identifier of the message sender.
+func (trfm *tssRoundFourMessage) SenderID() group.MemberIndex {
+	return trfm.senderID
+}
+
+// SessionID returns the session identifier of the message.
+func (trfm *tssRoundFourMessage) SessionID() string {
+	return trfm.sessionID
+}
+
+// Type returns a string describing a tssRoundFourMessage type for
+// marshaling purposes.
+func (trfm *tssRoundFourMessage) Type() string {
+	return messageTypePrefix + "tss_round_four_message"
+}
+
+// tssRoundFiveMessage is a message payload that carries the sender's
+// TSS round five components produced by the sender's new committee party.
+type tssRoundFiveMessage struct {
+	senderID group.MemberIndex
+
+	broadcastPayload []byte
+	sessionID        string
+}
+
+// SenderID returns protocol-level identifier of the message sender.
+func (trfm *tssRoundFiveMessage) SenderID() group.MemberIndex {
+	return trfm.senderID
+}
+
+// SessionID returns the session identifier of the message.
+func (trfm *tssRoundFiveMessage) SessionID() string {
+	return trfm.sessionID
+}
+
+// Type returns a string describing a tssRoundFiveMessage type for
+// marshaling purposes.
+func (trfm *tssRoundFiveMessage) Type() string {
+	return messageTypePrefix + "tss_round_five_message"
+}
+
+// resultPreparedMessage is a message payload that carries the hash of the
+// public part of the refreshed private key share computed by the sender.
+// The sender announces this way it is ready to commit to the refreshed
+// private key share.
+type resultPreparedMessage struct {
+	senderID group.MemberIndex
+
+	resultHash [sha256.Size]byte
+	sessionID  string
+}
+
+// SenderID returns protocol-level identifier of the message sender.
+func (rpm *resultPreparedMessage) SenderID() group.MemberIndex {
+	return rpm.senderID
+}
+
+// SessionID returns the session identifier of the message.
+func (rpm *resultPreparedMessage) SessionID() string {
+	return rpm.sessionID
+}
+
+// Type returns a string describing a resultPreparedMessage type for
+// marshaling purposes.
+func (rpm *resultPreparedMessage) Type() string {
+	return messageTypePrefix + "result_prepared_message"
+}
+
+// resultCommittedMessage is a message payload that carries the hash of the
+// public part of the refreshed private key share the sender committed to.
+// The sender sends it once it learnt all other members prepared the same
+// result.
+type resultCommittedMessage struct {
+	senderID group.MemberIndex
+
+	resultHash [sha256.Size]byte
+	sessionID  string
+}
+
+// SenderID returns protocol-level identifier of the message sender.
+func (rcm *resultCommittedMessage) SenderID() group.MemberIndex {
+	return rcm.senderID
+}
+
+// SessionID returns the session identifier of the message.
+func (rcm *resultCommittedMessage) SessionID() string {
+	return rcm.sessionID
+}
+
+// Type returns a string describing a resultCommittedMessage type for
+// marshaling purposes.
+func (rcm *resultCommittedMessage) Type() string {
+	return messageTypePrefix + "result_committed_message"
+}
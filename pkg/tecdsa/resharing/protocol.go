@@ -0,0 +1,842 @@
+package resharing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/bnb-chain/tss-lib/ecdsa/keygen"
+	"github.com/bnb-chain/tss-lib/tss"
+
+	"github.com/keep-network/keep-core/pkg/crypto/ephemeral"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+	"github.com/keep-network/keep-core/pkg/tecdsa/common"
+)
+
+// generateEphemeralKeyPair takes the group member list and generates an
+// ephemeral ECDH keypair for every other group member. Generated public
+// ephemeral keys are broadcasted within the group.
+func (ekpgm *ephemeralKeyPairGeneratingMember) generateEphemeralKeyPair() (
+	*ephemeralPublicKeyMessage,
+	error,
+) {
+	ephemeralKeys := make(map[group.MemberIndex]*ephemeral.PublicKey)
+
+	// Calculate ephemeral key pair for every other group member
+	for _, member := range ekpgm.group.MemberIndexes() {
+		if member == ekpgm.id {
+			// don’t actually generate a key with ourselves
+			continue
+		}
+
+		ephemeralKeyPair, err := ephemeral.GenerateKeyPair()
+		if err != nil {
+			return nil, err
+		}
+
+		// save the generated ephemeral key to our state
+		ekpgm.ephemeralKeyPairs[member] = ephemeralKeyPair
+
+		// store the public key to the map for the message
+		ephemeralKeys[member] = ephemeralKeyPair.PublicKey
+	}
+
+	return &ephemeralPublicKeyMessage{
+		senderID:            ekpgm.id,
+		ephemeralPublicKeys: ephemeralKeys,
+		sessionID:           ekpgm.sessionID,
+	}, nil
+}
+
+// generateSymmetricKeys attempts to generate symmetric keys for all remote group
+// members via ECDH. It generates this symmetric key for each remote group member
+// by doing an ECDH between the ephemeral private key generated for a remote
+// group member, and the public key for this member, generated and broadcasted by
+// the remote group member.
+func (skgm *symmetricKeyGeneratingMember) generateSymmetricKeys(
+	ephemeralPubKeyMessages []*ephemeralPublicKeyMessage,
+) error {
+	for _, ephemeralPubKeyMessage := range ephemeralPubKeyMessages {
+		otherMember := ephemeralPubKeyMessage.senderID
+
+		if !skgm.isValidEphemeralPublicKeyMessage(ephemeralPubKeyMessage) {
+			return fmt.Errorf(
+				"member [%v] sent invalid ephemeral public key message",
+				otherMember,
+			)
+		}
+
+		// Find the ephemeral key pair generated by this group member for
+		// the other group member.
+		ephemeralKeyPair, ok := skgm.ephemeralKeyPairs[otherMember]
+		if !ok {
+			return fmt.Errorf(
+				"ephemeral key pair does not exist for member [%v]",
+				otherMember,
+			)
+		}
+
+		// Get the ephemeral private key generated by this group member for
+		// the other group member.
+		thisMemberEphemeralPrivateKey := ephemeralKeyPair.PrivateKey
+
+		// Get the ephemeral public key broadcasted by the other group member,
+		// which was intended for this group member.
+		otherMemberEphemeralPublicKey :=
+			ephemeralPubKeyMessage.ephemeralPublicKeys[skgm.id]
+
+		// Create symmetric key for the current group member and the other
+		// group member by ECDH'ing the public and private key.
+		symmetricKey := thisMemberEphemeralPrivateKey.Ecdh(
+			otherMemberEphemeralPublicKey,
+		)
+		skgm.symmetricKeys[otherMember] = symmetricKey
+	}
+
+	return nil
+}
+
+// isValidEphemeralPublicKeyMessage validates a given EphemeralPublicKeyMessage.
+// Message is considered valid if it contains ephemeral public keys for
+// all other group members.
+func (skgm *symmetricKeyGeneratingMember) isValidEphemeralPublicKeyMessage(
+	message *ephemeralPublicKeyMessage,
+) bool {
+	for _, memberID := range skgm.group.MemberIndexes() {
+		if memberID == message.senderID {
+			// Message contains ephemeral public keys only for other group members
+			continue
+		}
+
+		if _, ok := message.ephemeralPublicKeys[memberID]; !ok {
+			skgm.logger.Warnf(
+				"[member:%v] ephemeral public key message from member [%v] "+
+					"does not contain public key for member [%v]",
+				skgm.id,
+				message.senderID,
+				memberID,
+			)
+			return false
+		}
+	}
+
+	return true
+}
+
+// tssRoundOne starts the TSS process by executing its first round. The
+// outcome of that round is a message containing TSS round one components.
+// Only members of the old committee produce the message; nil message is
+// returned for other members.
+func (trom *tssRoundOneMember) tssRoundOne(
+	ctx context.Context,
+) (*tssRoundOneMessage, error) {
+	if err := trom.newTssParty.Start(); err != nil {
+		return nil, fmt.Errorf(
+			"failed to start new committee TSS round one: [%v]",
+			err,
+		)
+	}
+
+	if trom.oldTssParty == nil {
+		return nil, nil
+	}
+
+	if err := trom.oldTssParty.Start(); err != nil {
+		return nil, fmt.Errorf(
+			"failed to start old committee TSS round one: [%v]",
+			err,
+		)
+	}
+
+	// We expect 1 broadcast message produced by the old committee party.
+	tssMessages, err := trom.receiveTssMessages(ctx, 1)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"TSS round one outgoing messages were not generated: [%w]",
+			err,
+		)
+	}
+
+	broadcastPayload, peersPayload, err := common.AggregateTssMessages(
+		tssMessages,
+		trom.symmetricKeys,
+		trom.newIdentityConverter,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot aggregate TSS round one outgoing messages: [%w]",
+			err,
+		)
+	}
+
+	ok := len(broadcastPayload) > 0 && len(peersPayload) == 0
+	if !ok {
+		return nil, fmt.Errorf("cannot produce a proper TSS round one message")
+	}
+
+	return &tssRoundOneMessage{
+		senderID:         trom.id,
+		broadcastPayload: broadcastPayload,
+		sessionID:        trom.sessionID,
+	}, nil
+}
+
+// tssRoundTwo performs the second round of the TSS process. The outcome of
+// that round is a message containing TSS round two components.
+func (trtm *tssRoundTwoMember) tssRoundTwo(
+	ctx context.Context,
+	tssRoundOneMessages []*tssRoundOneMessage,
+) (*tssRoundTwoMessage, error) {
+	// Round one messages are produced by the old committee and are
+	// intended for the new committee only.
+	for _, tssRoundOneMessage := range tssRoundOneMessages {
+		senderID := tssRoundOneMessage.SenderID()
+
+		_, tssErr := trtm.newTssParty.UpdateFromBytes(
+			tssRoundOneMessage.broadcastPayload,
+			trtm.resolveOldTssPartyID(senderID),
+			true,
+		)
+		if tssErr != nil {
+			return nil, fmt.Errorf(
+				"cannot update using TSS round one message "+
+					"from member [%v]: [%v]",
+				senderID,
+				tssErr,
+			)
+		}
+	}
+
+	// We expect 2 broadcast messages produced by the new committee party.
+	// The first one is intended for the new committee and the second one
+	// is an acknowledgment intended for the old committee.
+	tssMessages, err := trtm.receiveTssMessages(ctx, 2)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"TSS round two outgoing messages were not generated: [%w]",
+			err,
+		)
+	}
+
+	var broadcastPayload, ackPayload []byte
+	for _, tssMessage := range tssMessages {
+		tssMessageBytes, tssMessageRouting, err := tssMessage.WireBytes()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to encode TSS round two message: [%v]",
+				err,
+			)
+		}
+
+		if tssMessageRouting.IsToOldCommittee {
+			ackPayload = tssMessageBytes
+		} else {
+			broadcastPayload = tssMessageBytes
+		}
+	}
+
+	ok := len(broadcastPayload) > 0 && len(ackPayload) > 0
+	if !ok {
+		return nil, fmt.Errorf("cannot produce a proper TSS round two message")
+	}
+
+	return &tssRoundTwoMessage{
+		senderID:         trtm.id,
+		broadcastPayload: broadcastPayload,
+		ackPayload:       ackPayload,
+		sessionID:        trtm.sessionID,
+	}, nil
+}
+
+// tssRoundThree performs the third round of the TSS process. The outcome of
+// that round is a message containing TSS round three components. Only
+// members of the old committee produce the message; nil message is
+// returned for other members.
+func (trtm *tssRoundThreeMember) tssRoundThree(
+	ctx context.Context,
+	tssRoundTwoMessages []*tssRoundTwoMessage,
+) (*tssRoundThreeMessage, error) {
+	// Round two messages are produced by the new committee. The broadcast
+	// part is intended for the new committee while the acknowledgment part
+	// is intended for the old committee.
+	for _, tssRoundTwoMessage := range tssRoundTwoMessages {
+		senderID := tssRoundTwoMessage.SenderID()
+		senderTssPartyID := trtm.resolveNewTssPartyID(senderID)
+
+		_, tssErr := trtm.newTssParty.UpdateFromBytes(
+			tssRoundTwoMessage.broadcastPayload,
+			senderTssPartyID,
+			true,
+		)
+		if tssErr != nil {
+			return nil, fmt.Errorf(
+				"cannot update using the broadcast part of the "+
+					"TSS round two message from member [%v]: [%v]",
+				senderID,
+				tssErr,
+			)
+		}
+
+		if trtm.oldTssParty == nil {
+			continue
+		}
+
+		_, tssErr = trtm.oldTssParty.UpdateFromBytes(
+			tssRoundTwoMessage.ackPayload,
+			senderTssPartyID,
+			true,
+		)
+		if tssErr != nil {
+			return nil, fmt.Errorf(
+				"cannot update using the acknowledgment part of the "+
+					"TSS round two message from member [%v]: [%v]",
+				senderID,
+				tssErr,
+			)
+		}
+	}
+
+	// Round three is executed by the old committee only.
+	if trtm.oldTssParty == nil {
+		return nil, nil
+	}
+
+	// We expect N P2P messages (where N is the group size) and 1 broadcast
+	// message produced by the old committee party. One of the P2P messages
+	// is intended for this member's new committee party.
+	tssMessages, err := trtm.receiveTssMessages(
+		ctx,
+		len(trtm.group.MemberIndexes())+1,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"TSS round three outgoing messages were not generated: [%w]",
+			err,
+		)
+	}
+
+	broadcastPayload, peersPayload, err := common.AggregateTssMessages(
+		tssMessages,
+		trtm.symmetricKeys,
+		trtm.newIdentityConverter,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot aggregate TSS round three outgoing messages: [%w]",
+			err,
+		)
+	}
+
+	ok := len(broadcastPayload) > 0 &&
+		len(peersPayload) == len(trtm.group.MemberIndexes())-1
+	if !ok {
+		return nil, fmt.Errorf("cannot produce a proper TSS round three message")
+	}
+
+	return &tssRoundThreeMessage{
+		senderID:         trtm.id,
+		broadcastPayload: broadcastPayload,
+		peersPayload:     peersPayload,
+		sessionID:        trtm.sessionID,
+	}, nil
+}
+
+// tssRoundFour performs the fourth round of the TSS process. The outcome of
+// that round is a message containing TSS round four components.
+func (trfm *tssRoundFourMember) tssRoundFour(
+	ctx context.Context,
+	tssRoundThreeMessages []*tssRoundThreeMessage,
+) (*tssRoundFourMessage, error) {
+	// Round three messages are produced by the old committee and are
+	// intended for the new committee only.
+	for _, tssRoundThreeMessage := range tssRoundThreeMessages {
+		senderID := tssRoundThreeMessage.SenderID()
+		senderTssPartyID := trfm.resolveOldTssPartyID(senderID)
+
+		_, tssErr := trfm.newTssParty.UpdateFromBytes(
+			tssRoundThreeMessage.broadcastPayload,
+			senderTssPartyID,
+			true,
+		)
+		if tssErr != nil {
+			return nil, fmt.Errorf(
+				"cannot update using the broadcast part of the "+
+					"TSS round three message from member [%v]: [%v]",
+				senderID,
+				tssErr,
+			)
+		}
+
+		peerPayload, err := trfm.decryptPeerPayload(
+			senderID,
+			tssRoundThreeMessage.peersPayload,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get P2P part of the TSS round three message "+
+					"from member [%v]: [%w]",
+				senderID,
+				err,
+			)
+		}
+
+		_, tssErr = trfm.newTssParty.UpdateFromBytes(
+			peerPayload,
+			senderTssPartyID,
+			false,
+		)
+		if tssErr != nil {
+			return nil, fmt.Errorf(
+				"cannot update using the P2P part of the TSS round "+
+					"three message from member [%v]: [%v]",
+				senderID,
+				tssErr,
+			)
+		}
+	}
+
+	// We expect N P2P messages (where N is the group size) and 1 broadcast
+	// message produced by the new committee party. One of the P2P messages
+	// is addressed to the new committee party itself.
+	tssMessages, err := trfm.receiveTssMessages(
+		ctx,
+		len(trfm.group.MemberIndexes())+1,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"TSS round four outgoing messages were not generated: [%w]",
+			err,
+		)
+	}
+
+	broadcastPayload, peersPayload, err := common.AggregateTssMessages(
+		tssMessages,
+		trfm.symmetricKeys,
+		trfm.newIdentityConverter,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot aggregate TSS round four outgoing messages: [%w]",
+			err,
+		)
+	}
+
+	ok := len(broadcastPayload) > 0 &&
+		len(peersPayload) == len(trfm.group.MemberIndexes())-1
+	if !ok {
+		return nil, fmt.Errorf("cannot produce a proper TSS round four message")
+	}
+
+	return &tssRoundFourMessage{
+		senderID:         trfm.id,
+		broadcastPayload: broadcastPayload,
+		peersPayload:     peersPayload,
+		sessionID:        trfm.sessionID,
+	}, nil
+}
+
+// tssRoundFive performs the fifth round of the TSS process. The outcome of
+// that round is a message containing TSS round five components.
+func (trfm *tssRoundFiveMember) tssRoundFive(
+	ctx context.Context,
+	tssRoundFourMessages []*tssRoundFourMessage,
+) (*tssRoundFiveMessage, error) {
+	// Round four messages are produced by the new committee. The broadcast
+	// part is intended for both committees while the P2P part is intended
+	// for the new committee only.
+	for _, tssRoundFourMessage := range tssRoundFourMessages {
+		senderID := tssRoundFourMessage.SenderID()
+		senderTssPartyID := trfm.resolveNewTssPartyID(senderID)
+
+		peerPayload, err := trfm.decryptPeerPayload(
+			senderID,
+			tssRoundFourMessage.peersPayload,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot get P2P part of the TSS round four message "+
+					"from member [%v]: [%w]",
+				senderID,
+				err,
+			)
+		}
+
+		_, tssErr := trfm.newTssParty.UpdateFromBytes(
+			peerPayload,
+			senderTssPartyID,
+			false,
+		)
+		if tssErr != nil {
+			return nil, fmt.Errorf(
+				"cannot update using the P2P part of the TSS round "+
+					"four message from member [%v]: [%v]",
+				senderID,
+				tssErr,
+			)
+		}
+
+		if err := trfm.updateBothTssParties(
+			tssRoundFourMessage.broadcastPayload,
+			senderTssPartyID,
+		); err != nil {
+			return nil, fmt.Errorf(
+				"cannot update using the broadcast part of the "+
+					"TSS round four message from member [%v]: [%w]",
+				senderID,
+				err,
+			)
+		}
+	}
+
+	// We expect 1 broadcast message produced by the new committee party.
+	tssMessages, err := trfm.receiveTssMessages(ctx, 1)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"TSS round five outgoing messages were not generated: [%w]",
+			err,
+		)
+	}
+
+	tssMessageBytes, _, err := tssMessages[0].WireBytes()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to encode TSS round five message: [%v]",
+			err,
+		)
+	}
+
+	return &tssRoundFiveMessage{
+		senderID:         trfm.id,
+		broadcastPayload: tssMessageBytes,
+		sessionID:        trfm.sessionID,
+	}, nil
+}
+
+// tssFinalize finalizes the TSS process by producing a result.
+func (fm *finalizingMember) tssFinalize(
+	ctx context.Context,
+	tssRoundFiveMessages []*tssRoundFiveMessage,
+) error {
+	// Use messages from round five to update both local parties and get
+	// the result.
+	for _, tssRoundFiveMessage := range tssRoundFiveMessages {
+		senderID := tssRoundFiveMessage.SenderID()
+
+		if err := fm.updateBothTssParties(
+			tssRoundFiveMessage.broadcastPayload,
+			fm.resolveNewTssPartyID(senderID),
+		); err != nil {
+			return fmt.Errorf(
+				"cannot update using TSS round five message "+
+					"from member [%v]: [%w]",
+				senderID,
+				err,
+			)
+		}
+	}
+
+	select {
+	case tssResult := <-fm.newTssResultChan:
+		// The TSS resharing verifies the refreshed shares against the
+		// group public key but make sure the result is consistent with
+		// the private key share held so far anyway.
+		if !tecdsa.NewPrivateKeyShare(tssResult).PublicKey().Equal(
+			fm.privateKeyShare.PublicKey(),
+		) {
+			return fmt.Errorf(
+				"refreshed private key share does not " +
+					"correspond to the group public key",
+			)
+		}
+
+		fm.tssResult = &tssResult
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf(
+			"TSS result was not generated on time",
+		)
+	}
+}
+
+// prepareResult computes the hash of the public part of the refreshed
+// private key share and produces a message announcing it to other members.
+func (rpm *resultPreparingMember) prepareResult() *resultPreparedMessage {
+	rpm.resultHash = computeResultHash(rpm.tssResult)
+
+	return &resultPreparedMessage{
+		senderID:   rpm.id,
+		resultHash: rpm.resultHash,
+		sessionID:  rpm.sessionID,
+	}
+}
+
+// commitResult makes sure all other members computed the same refreshed
+// public key shares as this member and produces a message committing this
+// member to the refreshed private key share.
+func (rcm *resultCommittingMember) commitResult(
+	resultPreparedMessages []*resultPreparedMessage,
+) (*resultCommittedMessage, error) {
+	for _, resultPreparedMessage := range resultPreparedMessages {
+		if resultPreparedMessage.resultHash != rcm.resultHash {
+			return nil, fmt.Errorf(
+				"member [%v] prepared a different result",
+				resultPreparedMessage.senderID,
+			)
+		}
+	}
+
+	return &resultCommittedMessage{
+		senderID:   rcm.id,
+		resultHash: rcm.resultHash,
+		sessionID:  rcm.sessionID,
+	}, nil
+}
+
+// verifyCommitments makes sure all other members committed to the same
+// refreshed public key shares as this member.
+func (cm *committedMember) verifyCommitments(
+	resultCommittedMessages []*resultCommittedMessage,
+) error {
+	for _, resultCommittedMessage := range resultCommittedMessages {
+		if resultCommittedMessage.resultHash != cm.resultHash {
+			return fmt.Errorf(
+				"member [%v] committed to a different result",
+				resultCommittedMessage.senderID,
+			)
+		}
+	}
+
+	return nil
+}
+
+// computeResultHash computes the hash of the public part of the given
+// refreshed private key share, that is, the group public key, the TSS
+// party IDs keys, and the public key shares of all members. This part
+// must be the same for all members.
+func computeResultHash(tssResult *keygen.LocalPartySaveData) [sha256.Size]byte {
+	hash := sha256.New()
+
+	writePoint := func(x, y *big.Int) {
+		hash.Write(x.FillBytes(make([]byte, 32)))
+		hash.Write(y.FillBytes(make([]byte, 32)))
+	}
+
+	writePoint(tssResult.ECDSAPub.X(), tssResult.ECDSAPub.Y())
+
+	for _, key := range tssResult.Ks {
+		// Keys are not bounded by the curve order so prefix them with
+		// their length to keep the encoding unambiguous.
+		keyBytes := key.Bytes()
+		hash.Write(binary.BigEndian.AppendUint64(nil, uint64(len(keyBytes))))
+		hash.Write(keyBytes)
+	}
+
+	for _, publicKeyShare := range tssResult.BigXj {
+		writePoint(publicKeyShare.X(), publicKeyShare.Y())
+	}
+
+	var result [sha256.Size]byte
+	copy(result[:], hash.Sum(nil))
+	return result
+}
+
+// receiveTssMessages listens for the given count of TSS messages produced
+// by both local TSS parties. Messages exchanged between the local TSS
+// parties are delivered directly and are not returned. Returned messages
+// should be sent to other group members.
+func (trom *tssRoundOneMember) receiveTssMessages(
+	ctx context.Context,
+	expectedCount int,
+) ([]tss.Message, error) {
+	var tssMessages []tss.Message
+
+	for i := 0; i < expectedCount; i++ {
+		select {
+		case tssMessage := <-trom.tssOutgoingMessagesChan:
+			isRemote, err := trom.deliverLocalTssMessage(tssMessage)
+			if err != nil {
+				return nil, err
+			}
+
+			if isRemote {
+				tssMessages = append(tssMessages, tssMessage)
+			}
+		case <-ctx.Done():
+			return nil, fmt.Errorf(
+				"received [%v] out of [%v] expected messages",
+				i,
+				expectedCount,
+			)
+		}
+	}
+
+	return tssMessages, nil
+}
+
+// deliverLocalTssMessage delivers the given TSS message produced by one of
+// the local TSS parties to the other local TSS party, if the other party is
+// one of the message receivers. Returns true if the message should be also
+// sent to other group members.
+func (trom *tssRoundOneMember) deliverLocalTssMessage(
+	tssMessage tss.Message,
+) (bool, error) {
+	tssMessageBytes, tssMessageRouting, err := tssMessage.WireBytes()
+	if err != nil {
+		return false, fmt.Errorf("failed to unpack TSS message: [%v]", err)
+	}
+
+	newTssPartyID := trom.newTssParty.PartyID()
+
+	var oldTssPartyID *tss.PartyID
+	isFromOldTssParty := false
+	if trom.oldTssParty != nil {
+		oldTssPartyID = trom.oldTssParty.PartyID()
+		isFromOldTssParty = tssMessageRouting.From.KeyInt().Cmp(
+			oldTssPartyID.KeyInt(),
+		) == 0
+	}
+
+	if !tssMessageRouting.IsBroadcast {
+		if len(tssMessageRouting.To) != 1 {
+			return false, fmt.Errorf("multi-receiver TSS P2P message detected")
+		}
+
+		isToNewTssParty := tssMessageRouting.To[0].KeyInt().Cmp(
+			newTssPartyID.KeyInt(),
+		) == 0
+		if !isToNewTssParty {
+			return true, nil
+		}
+
+		// The new committee party stores the P2P message addressed to
+		// itself on its own so such a message can be dropped.
+		if !isFromOldTssParty {
+			return false, nil
+		}
+
+		if _, tssErr := trom.newTssParty.UpdateFromBytes(
+			tssMessageBytes,
+			oldTssPartyID,
+			false,
+		); tssErr != nil {
+			return false, fmt.Errorf(
+				"cannot deliver local TSS P2P message: [%v]",
+				tssErr,
+			)
+		}
+
+		return false, nil
+	}
+
+	// Broadcast messages of the old committee are always intended for the
+	// new committee. Broadcast messages of the new committee are intended
+	// for the old committee only if explicitly marked.
+	if isFromOldTssParty {
+		if _, tssErr := trom.newTssParty.UpdateFromBytes(
+			tssMessageBytes,
+			oldTssPartyID,
+			true,
+		); tssErr != nil {
+			return false, fmt.Errorf(
+				"cannot deliver local TSS broadcast message: [%v]",
+				tssErr,
+			)
+		}
+	} else if trom.oldTssParty != nil &&
+		(tssMessageRouting.IsToOldCommittee ||
+			tssMessageRouting.IsToOldAndNewCommittees) {
+		if _, tssErr := trom.oldTssParty.UpdateFromBytes(
+			tssMessageBytes,
+			newTssPartyID,
+			true,
+		); tssErr != nil {
+			return false, fmt.Errorf(
+				"cannot deliver local TSS broadcast message: [%v]",
+				tssErr,
+			)
+		}
+	}
+
+	return true, nil
+}
+
+// updateBothTssParties updates both local TSS parties using the given
+// broadcast payload produced by a member of the new committee. The old
+// committee party is updated only if this member belongs to the old
+// committee.
+func (trom *tssRoundOneMember) updateBothTssParties(
+	broadcastPayload []byte,
+	senderTssPartyID *tss.PartyID,
+) error {
+	if trom.oldTssParty != nil {
+		if _, tssErr := trom.oldTssParty.UpdateFromBytes(
+			broadcastPayload,
+			senderTssPartyID,
+			true,
+		); tssErr != nil {
+			return fmt.Errorf(
+				"cannot update old committee party: [%v]",
+				tssErr,
+			)
+		}
+	}
+
+	if _, tssErr := trom.newTssParty.UpdateFromBytes(
+		broadcastPayload,
+		senderTssPartyID,
+		true,
+	); tssErr != nil {
+		return fmt.Errorf("cannot update new committee party: [%v]", tssErr)
+	}
+
+	return nil
+}
+
+// decryptPeerPayload decrypts the P2P payload intended for this member
+// using the symmetric key shared with the given sender.
+func (trom *tssRoundOneMember) decryptPeerPayload(
+	senderID group.MemberIndex,
+	peersPayload map[group.MemberIndex][]byte,
+) ([]byte, error) {
+	// Check if the sender produced a P2P payload for this member.
+	encryptedPeerPayload, ok := peersPayload[trom.id]
+	if !ok {
+		return nil, fmt.Errorf("no P2P payload for this member")
+	}
+	// Get the symmetric key with the sender. If the symmetric key
+	// cannot be found, something awful happened.
+	symmetricKey, ok := trom.symmetricKeys[senderID]
+	if !ok {
+		return nil, fmt.Errorf("cannot get symmetric key with the sender")
+	}
+
+	peerPayload, err := symmetricKey.Decrypt(encryptedPeerPayload)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt P2P payload: [%v]", err)
+	}
+
+	return peerPayload, nil
+}
+
+// resolveOldTssPartyID resolves the sorted TSS party ID of the given member
+// acting in the old committee.
+func (trom *tssRoundOneMember) resolveOldTssPartyID(
+	memberIndex group.MemberIndex,
+) *tss.PartyID {
+	return trom.tssParameters.OldParties().IDs().FindByKey(
+		trom.oldIdentityConverter.MemberIndexToTssPartyIDKey(memberIndex),
+	)
+}
+
+// resolveNewTssPartyID resolves the sorted TSS party ID of the given member
+// acting in the new committee.
+func (trom *tssRoundOneMember) resolveNewTssPartyID(
+	memberIndex group.MemberIndex,
+) *tss.PartyID {
+	return trom.tssParameters.NewParties().IDs().FindByKey(
+		trom.newIdentityConverter.MemberIndexToTssPartyIDKey(memberIndex),
+	)
+}
@@ -0,0 +1,702 @@
+package resharing
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keep-network/keep-core/internal/testutils"
+	"github.com/keep-network/keep-core/pkg/crypto/ephemeral"
+	"github.com/keep-network/keep-core/pkg/internal/tecdsatest"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+)
+
+const (
+	groupSize          = 5
+	dishonestThreshold = 2
+	sessionID          = "session-1"
+)
+
+func TestResharing(t *testing.T) {
+	var tests = map[string]struct {
+		oldCommitteeMembersIndexes []group.MemberIndex
+	}{
+		"all members in the old committee": {
+			oldCommitteeMembersIndexes: []group.MemberIndex{1, 2, 3, 4, 5},
+		},
+		"honest threshold members in the old committee": {
+			oldCommitteeMembersIndexes: []group.MemberIndex{2, 4, 5},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			members, err := initializeEphemeralKeyPairGeneratingMembersGroup(
+				dishonestThreshold,
+				groupSize,
+				test.oldCommitteeMembersIndexes,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			committedMembers, err := runResharing(members)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertResharingResult(t, committedMembers)
+		})
+	}
+}
+
+func assertResharingResult(t *testing.T, committedMembers []*committedMember) {
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(groupSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldPrivateKeyShares := make([]*tecdsa.PrivateKeyShare, groupSize)
+	newPrivateKeyShares := make([]*tecdsa.PrivateKeyShare, groupSize)
+	for i, member := range committedMembers {
+		oldPrivateKeyShares[i] = tecdsa.NewPrivateKeyShare(testData[i])
+		newPrivateKeyShares[i] = member.Result().PrivateKeyShare
+	}
+
+	for i := range newPrivateKeyShares {
+		oldShare := oldPrivateKeyShares[i]
+		newShare := newPrivateKeyShares[i]
+
+		if !newShare.PublicKey().Equal(oldShare.PublicKey()) {
+			t.Errorf("unexpected public key of member [%v] share", i+1)
+		}
+
+		if newShare.Data().Xi.Cmp(oldShare.Data().Xi) == 0 {
+			t.Errorf("share of member [%v] has not been refreshed", i+1)
+		}
+
+		// The input share held by the member must stay intact.
+		testutils.AssertBigIntsEqual(
+			t,
+			fmt.Sprintf("input share of member [%v]", i+1),
+			oldShare.Data().Xi,
+			committedMembers[i].privateKeyShare.Data().Xi,
+		)
+
+		// The member index must map to the same TSS party in the new
+		// share as in the old one.
+		testutils.AssertBigIntsEqual(
+			t,
+			fmt.Sprintf("share ID of member [%v]", i+1),
+			newShare.Data().Ks[i],
+			newShare.Data().ShareID,
+		)
+	}
+
+	// Any honest majority of the refreshed shares must reconstruct the same
+	// secret as the old shares.
+	oldSecret := reconstructSecret(oldPrivateKeyShares[:3])
+	testutils.AssertBigIntsEqual(
+		t,
+		"secret reconstructed from refreshed shares",
+		oldSecret,
+		reconstructSecret(newPrivateKeyShares[:3]),
+	)
+	testutils.AssertBigIntsEqual(
+		t,
+		"secret reconstructed from refreshed shares",
+		oldSecret,
+		reconstructSecret(newPrivateKeyShares[2:]),
+	)
+}
+
+func TestValidateOldCommittee(t *testing.T) {
+	var tests = map[string]struct {
+		oldCommitteeMembersIndexes []group.MemberIndex
+		expectedErr                string
+	}{
+		"valid old committee": {
+			oldCommitteeMembersIndexes: []group.MemberIndex{1, 3, 5},
+		},
+		"old committee below honest threshold": {
+			oldCommitteeMembersIndexes: []group.MemberIndex{1, 3},
+			expectedErr: "old committee has [2] members while " +
+				"honest threshold is [3]",
+		},
+		"member out of the group": {
+			oldCommitteeMembersIndexes: []group.MemberIndex{1, 3, 6},
+			expectedErr:                "member [6] is out of the group",
+		},
+		"duplicated member": {
+			oldCommitteeMembersIndexes: []group.MemberIndex{1, 3, 3},
+			expectedErr:                "member [3] is duplicated",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := validateOldCommittee(
+				test.oldCommitteeMembersIndexes,
+				groupSize,
+				dishonestThreshold,
+			)
+
+			actualErr := ""
+			if err != nil {
+				actualErr = err.Error()
+			}
+
+			testutils.AssertStringsEqual(t, "error", test.expectedErr, actualErr)
+		})
+	}
+}
+
+func TestCommitResult_DifferentResultPrepared(t *testing.T) {
+	member := &resultCommittingMember{
+		resultPreparingMember: &resultPreparingMember{
+			finalizingMember: &finalizingMember{
+				tssRoundFiveMember: &tssRoundFiveMember{
+					tssRoundFourMember: &tssRoundFourMember{
+						tssRoundThreeMember: &tssRoundThreeMember{
+							tssRoundTwoMember: &tssRoundTwoMember{
+								tssRoundOneMember: &tssRoundOneMember{
+									symmetricKeyGeneratingMember: &symmetricKeyGeneratingMember{
+										ephemeralKeyPairGeneratingMember: &ephemeralKeyPairGeneratingMember{
+											member: &member{
+												id:        1,
+												sessionID: sessionID,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			resultHash: [32]byte{0x01},
+		},
+	}
+
+	receivedMessages := []*resultPreparedMessage{
+		{senderID: 2, resultHash: [32]byte{0x01}, sessionID: sessionID},
+		{senderID: 3, resultHash: [32]byte{0x02}, sessionID: sessionID},
+		{senderID: 4, resultHash: [32]byte{0x01}, sessionID: sessionID},
+	}
+
+	_, err := member.commitResult(receivedMessages)
+
+	testutils.AssertStringsEqual(
+		t,
+		"error",
+		"member [3] prepared a different result",
+		fmt.Sprintf("%v", err),
+	)
+}
+
+func TestReceiveTssMessages_Timeout(t *testing.T) {
+	members, err := initializeTssRoundOneMembersGroup(
+		dishonestThreshold,
+		groupSize,
+		[]group.MemberIndex{1, 2, 3, 4, 5},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	member := members[0]
+
+	// Parties are not started so no outgoing messages are produced.
+	ctx, cancelCtx := context.WithTimeout(
+		context.Background(),
+		100*time.Millisecond,
+	)
+	defer cancelCtx()
+
+	_, err = member.receiveTssMessages(ctx, 1)
+
+	expectedErr := "received [0] out of [1] expected messages"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf(
+			"unexpected error\nexpected: [%v]\nactual:   [%v]",
+			expectedErr,
+			err,
+		)
+	}
+}
+
+func TestTssRoundFour_IncomingMessageCorrupted_UndecryptablePeerPayload(t *testing.T) {
+	members, err := initializeEphemeralKeyPairGeneratingMembersGroup(
+		dishonestThreshold,
+		groupSize,
+		[]group.MemberIndex{1, 2, 3, 4, 5},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tssRoundFourMembers, tssRoundThreeMessages, err := runResharingUntilRoundFour(
+		members,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	member := tssRoundFourMembers[0]
+
+	var receivedMessages []*tssRoundThreeMessage
+	for _, message := range tssRoundThreeMessages {
+		if message.senderID == member.id {
+			continue
+		}
+
+		corruptedPeersPayload := make(map[group.MemberIndex][]byte)
+		for receiverID, payload := range message.peersPayload {
+			corruptedPeersPayload[receiverID] = payload
+		}
+		corruptedPeersPayload[member.id] = []byte{0x00}
+
+		receivedMessages = append(receivedMessages, &tssRoundThreeMessage{
+			senderID:         message.senderID,
+			broadcastPayload: message.broadcastPayload,
+			peersPayload:     corruptedPeersPayload,
+			sessionID:        message.sessionID,
+		})
+	}
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelCtx()
+
+	_, err = member.tssRoundFour(ctx, receivedMessages)
+
+	expectedErr := "cannot get P2P part of the TSS round three message " +
+		"from member [2]: [cannot decrypt P2P payload"
+	if err == nil || !strings.HasPrefix(err.Error(), expectedErr) {
+		t.Errorf(
+			"unexpected error\nexpected prefix: [%v]\nactual:          [%v]",
+			expectedErr,
+			err,
+		)
+	}
+}
+
+func TestNewCommitteeKeys(t *testing.T) {
+	oldKeys := []*big.Int{
+		big.NewInt(201),
+		big.NewInt(202),
+		big.NewInt(203),
+		big.NewInt(204),
+		big.NewInt(205),
+	}
+
+	newKeys := newCommitteeKeys(oldKeys)
+
+	testutils.AssertIntsEqual(t, "new keys count", len(oldKeys), len(newKeys))
+
+	for i, newKey := range newKeys {
+		testutils.AssertBigIntsEqual(
+			t,
+			fmt.Sprintf("new key [%v]", i),
+			big.NewInt(int64(206+i)),
+			newKey,
+		)
+	}
+}
+
+// reconstructSecret reconstructs the secret from the given shares using
+// Lagrange interpolation at zero.
+func reconstructSecret(shares []*tecdsa.PrivateKeyShare) *big.Int {
+	order := tecdsa.Curve.Params().N
+
+	secret := big.NewInt(0)
+	for i, share := range shares {
+		xi := share.Data().ShareID
+
+		numerator, denominator := big.NewInt(1), big.NewInt(1)
+		for j, otherShare := range shares {
+			if i == j {
+				continue
+			}
+
+			xj := otherShare.Data().ShareID
+
+			numerator.Mul(numerator, xj)
+			numerator.Mod(numerator, order)
+
+			difference := new(big.Int).Sub(xj, xi)
+			denominator.Mul(denominator, difference)
+			denominator.Mod(denominator, order)
+		}
+
+		coefficient := new(big.Int).ModInverse(denominator, order)
+		coefficient.Mul(coefficient, numerator)
+
+		term := new(big.Int).Mul(coefficient, share.Data().Xi)
+		secret.Add(secret, term)
+		secret.Mod(secret, order)
+	}
+
+	return secret
+}
+
+func runResharing(
+	members []*ephemeralKeyPairGeneratingMember,
+) ([]*committedMember, error) {
+	resultPreparingMembers, resultPreparedMessages, err :=
+		runResharingUntilResultCommitment(members)
+	if err != nil {
+		return nil, err
+	}
+
+	var resultCommittedMessages []*resultCommittedMessage
+	var committedMembers []*committedMember
+	for _, member := range resultPreparingMembers {
+		resultCommittingMember := member.initializeResultCommitment()
+
+		message, err := resultCommittingMember.commitResult(
+			filterReceivedMessages(resultPreparedMessages, member.id),
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot commit result for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+
+		resultCommittedMessages = append(resultCommittedMessages, message)
+		committedMembers = append(
+			committedMembers,
+			resultCommittingMember.initializeCommitted(),
+		)
+	}
+
+	for _, member := range committedMembers {
+		err := member.verifyCommitments(
+			filterReceivedMessages(resultCommittedMessages, member.id),
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot verify commitments for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+	}
+
+	return committedMembers, nil
+}
+
+func runResharingUntilResultCommitment(
+	members []*ephemeralKeyPairGeneratingMember,
+) ([]*resultPreparingMember, []*resultPreparedMessage, error) {
+	tssRoundFourMembers, tssRoundThreeMessages, err :=
+		runResharingUntilRoundFour(members)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tssRoundFourMessages []*tssRoundFourMessage
+	var tssRoundFiveMembers []*tssRoundFiveMember
+	for _, member := range tssRoundFourMembers {
+		ctx, cancelCtx := context.WithTimeout(
+			context.Background(),
+			60*time.Second,
+		)
+
+		message, err := member.tssRoundFour(
+			ctx,
+			filterReceivedMessages(tssRoundThreeMessages, member.id),
+		)
+		cancelCtx()
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"cannot do TSS round four for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+
+		tssRoundFourMessages = append(tssRoundFourMessages, message)
+		tssRoundFiveMembers = append(
+			tssRoundFiveMembers,
+			member.initializeTssRoundFive(),
+		)
+	}
+
+	var tssRoundFiveMessages []*tssRoundFiveMessage
+	var finalizingMembers []*finalizingMember
+	for _, member := range tssRoundFiveMembers {
+		ctx, cancelCtx := context.WithTimeout(
+			context.Background(),
+			60*time.Second,
+		)
+
+		message, err := member.tssRoundFive(
+			ctx,
+			filterReceivedMessages(tssRoundFourMessages, member.id),
+		)
+		cancelCtx()
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"cannot do TSS round five for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+
+		tssRoundFiveMessages = append(tssRoundFiveMessages, message)
+		finalizingMembers = append(
+			finalizingMembers,
+			member.initializeFinalization(),
+		)
+	}
+
+	var resultPreparedMessages []*resultPreparedMessage
+	var resultPreparingMembers []*resultPreparingMember
+	for _, member := range finalizingMembers {
+		ctx, cancelCtx := context.WithTimeout(
+			context.Background(),
+			60*time.Second,
+		)
+
+		err := member.tssFinalize(
+			ctx,
+			filterReceivedMessages(tssRoundFiveMessages, member.id),
+		)
+		cancelCtx()
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"cannot finalize for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+
+		resultPreparingMember := member.initializeResultPreparation()
+
+		resultPreparedMessages = append(
+			resultPreparedMessages,
+			resultPreparingMember.prepareResult(),
+		)
+		resultPreparingMembers = append(
+			resultPreparingMembers,
+			resultPreparingMember,
+		)
+	}
+
+	return resultPreparingMembers, resultPreparedMessages, nil
+}
+
+func runResharingUntilRoundFour(
+	members []*ephemeralKeyPairGeneratingMember,
+) ([]*tssRoundFourMember, []*tssRoundThreeMessage, error) {
+	var ephemeralPublicKeyMessages []*ephemeralPublicKeyMessage
+	for _, member := range members {
+		message, err := member.generateEphemeralKeyPair()
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"cannot generate ephemeral key pair for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+
+		ephemeralPublicKeyMessages = append(ephemeralPublicKeyMessages, message)
+	}
+
+	var tssRoundOneMembers []*tssRoundOneMember
+	for _, member := range members {
+		symmetricKeyGeneratingMember := member.initializeSymmetricKeyGeneration()
+
+		err := symmetricKeyGeneratingMember.generateSymmetricKeys(
+			filterReceivedMessages(ephemeralPublicKeyMessages, member.id),
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"cannot generate symmetric keys for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+
+		tssRoundOneMembers = append(
+			tssRoundOneMembers,
+			symmetricKeyGeneratingMember.initializeTssRoundOne(),
+		)
+	}
+
+	var tssRoundOneMessages []*tssRoundOneMessage
+	var tssRoundTwoMembers []*tssRoundTwoMember
+	for _, member := range tssRoundOneMembers {
+		ctx, cancelCtx := context.WithTimeout(
+			context.Background(),
+			60*time.Second,
+		)
+
+		message, err := member.tssRoundOne(ctx)
+		cancelCtx()
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"cannot do TSS round one for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+
+		// Members outside the old committee do not produce round one
+		// messages.
+		if message != nil {
+			tssRoundOneMessages = append(tssRoundOneMessages, message)
+		}
+		tssRoundTwoMembers = append(
+			tssRoundTwoMembers,
+			member.initializeTssRoundTwo(),
+		)
+	}
+
+	var tssRoundTwoMessages []*tssRoundTwoMessage
+	var tssRoundThreeMembers []*tssRoundThreeMember
+	for _, member := range tssRoundTwoMembers {
+		ctx, cancelCtx := context.WithTimeout(
+			context.Background(),
+			60*time.Second,
+		)
+
+		message, err := member.tssRoundTwo(
+			ctx,
+			filterReceivedMessages(tssRoundOneMessages, member.id),
+		)
+		cancelCtx()
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"cannot do TSS round two for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+
+		tssRoundTwoMessages = append(tssRoundTwoMessages, message)
+		tssRoundThreeMembers = append(
+			tssRoundThreeMembers,
+			member.initializeTssRoundThree(),
+		)
+	}
+
+	var tssRoundThreeMessages []*tssRoundThreeMessage
+	var tssRoundFourMembers []*tssRoundFourMember
+	for _, member := range tssRoundThreeMembers {
+		ctx, cancelCtx := context.WithTimeout(
+			context.Background(),
+			60*time.Second,
+		)
+
+		message, err := member.tssRoundThree(
+			ctx,
+			filterReceivedMessages(tssRoundTwoMessages, member.id),
+		)
+		cancelCtx()
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"cannot do TSS round three for member [%v]: [%v]",
+				member.id,
+				err,
+			)
+		}
+
+		// Members outside the old committee do not produce round three
+		// messages.
+		if message != nil {
+			tssRoundThreeMessages = append(tssRoundThreeMessages, message)
+		}
+		tssRoundFourMembers = append(
+			tssRoundFourMembers,
+			member.initializeTssRoundFour(),
+		)
+	}
+
+	return tssRoundFourMembers, tssRoundThreeMessages, nil
+}
+
+func filterReceivedMessages[T message](
+	messages []T,
+	receiverID group.MemberIndex,
+) []T {
+	var result []T
+	for _, message := range messages {
+		if message.SenderID() != receiverID {
+			result = append(result, message)
+		}
+	}
+	return result
+}
+
+func initializeEphemeralKeyPairGeneratingMembersGroup(
+	dishonestThreshold int,
+	groupSize int,
+	oldCommitteeMembersIndexes []group.MemberIndex,
+) ([]*ephemeralKeyPairGeneratingMember, error) {
+	resharingGroup := group.NewGroup(dishonestThreshold, groupSize)
+
+	testData, err := tecdsatest.LoadPrivateKeyShareTestFixtures(groupSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load test data: [%v]", err)
+	}
+
+	var members []*ephemeralKeyPairGeneratingMember
+	for i := 1; i <= groupSize; i++ {
+		id := group.MemberIndex(i)
+		oldKeys := testData[i-1].Ks
+		// Reuse pre-parameters of the fixtures to not generate them
+		// in tests.
+		tssPreParams := testData[i-1].LocalPreParams
+
+		members = append(members, &ephemeralKeyPairGeneratingMember{
+			member: &member{
+				logger:                     &testutils.MockLogger{},
+				id:                         id,
+				group:                      resharingGroup,
+				sessionID:                  sessionID,
+				oldCommitteeMembersIndexes: oldCommitteeMembersIndexes,
+				privateKeyShare:            tecdsa.NewPrivateKeyShare(testData[i-1]),
+				tssPreParams:               &tssPreParams,
+				oldIdentityConverter:       &identityConverter{keys: oldKeys},
+				newIdentityConverter: &identityConverter{
+					keys: newCommitteeKeys(oldKeys),
+				},
+			},
+			ephemeralKeyPairs: make(map[group.MemberIndex]*ephemeral.KeyPair),
+		})
+	}
+
+	return members, nil
+}
+
+func initializeTssRoundOneMembersGroup(
+	dishonestThreshold int,
+	groupSize int,
+	oldCommitteeMembersIndexes []group.MemberIndex,
+) ([]*tssRoundOneMember, error) {
+	members, err := initializeEphemeralKeyPairGeneratingMembersGroup(
+		dishonestThreshold,
+		groupSize,
+		oldCommitteeMembersIndexes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var tssRoundOneMembers []*tssRoundOneMember
+	for _, member := range members {
+		tssRoundOneMembers = append(
+			tssRoundOneMembers,
+			member.initializeSymmetricKeyGeneration().initializeTssRoundOne(),
+		)
+	}
+
+	return tssRoundOneMembers, nil
+}
@@ -0,0 +1,160 @@
+package resharing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bnb-chain/tss-lib/ecdsa/keygen"
+	"github.com/ipfs/go-log/v2"
+
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/protocol/state"
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+)
+
+// Execute runs the tECDSA resharing protocol, given a broadcast channel to
+// mediate with, a member index to use in the group, indexes of members
+// forming the old committee, the current private key share, TSS
+// pre-parameters, and dishonest threshold. The protocol produces a refreshed
+// private key share corresponding to the same group public key. Private key
+// shares produced by the protocol are not compatible with shares produced
+// before so all group members must replace their shares.
+//
+// Only members of the old committee deal their current private key shares.
+// The old committee must consist of at least honest threshold group members.
+// All group members must receive refreshed private key shares so all group
+// members must participate in the resharing. The protocol ends with
+// a two-phase commit and the result is returned only if all group members
+// confirmed they computed the same refreshed public key shares and
+// committed to them.
+//
+// The commit is not atomic across the group: a member that misses some
+// commitments before the context is done returns an error while other
+// members return the result. The caller must not replace the current
+// private key share with the refreshed one until at least honest threshold
+// group members proved they hold refreshed shares, e.g. by signing with them.
+func Execute(
+	ctx context.Context,
+	logger log.StandardLogger,
+	sessionID string,
+	memberIndex group.MemberIndex,
+	oldCommitteeMembersIndexes []group.MemberIndex,
+	privateKeyShare *tecdsa.PrivateKeyShare,
+	tssPreParams *keygen.LocalPreParams,
+	groupSize int,
+	dishonestThreshold int,
+	channel net.BroadcastChannel,
+	membershipValidator *group.MembershipValidator,
+) (*Result, error) {
+	logger.Debugf("[member:%v] initializing member", memberIndex)
+
+	if len(privateKeyShare.Data().Ks) != groupSize {
+		return nil, fmt.Errorf(
+			"private key share holds [%v] parties while group size is [%v]",
+			len(privateKeyShare.Data().Ks),
+			groupSize,
+		)
+	}
+
+	if err := validateOldCommittee(
+		oldCommitteeMembersIndexes,
+		groupSize,
+		dishonestThreshold,
+	); err != nil {
+		return nil, fmt.Errorf("invalid old committee: [%w]", err)
+	}
+
+	member := newMember(
+		logger,
+		memberIndex,
+		groupSize,
+		dishonestThreshold,
+		membershipValidator,
+		sessionID,
+		oldCommitteeMembersIndexes,
+		privateKeyShare,
+		tssPreParams,
+	)
+
+	initialState := &ephemeralKeyPairGenerationState{
+		BaseAsyncState: state.NewBaseAsyncState(),
+		channel:        channel,
+		member:         member.initializeEphemeralKeysGeneration(),
+	}
+
+	stateMachine := state.NewAsyncMachine(logger, ctx, channel, initialState)
+
+	lastState, err := stateMachine.Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	committedState, ok := lastState.(*committedState)
+	if !ok {
+		return nil, fmt.Errorf("execution ended on state: %T", lastState)
+	}
+
+	return committedState.result(), nil
+}
+
+// validateOldCommittee checks whether the given old committee consists of
+// at least honest threshold unique members of the group.
+func validateOldCommittee(
+	oldCommitteeMembersIndexes []group.MemberIndex,
+	groupSize int,
+	dishonestThreshold int,
+) error {
+	honestThreshold := groupSize - dishonestThreshold
+	if len(oldCommitteeMembersIndexes) < honestThreshold {
+		return fmt.Errorf(
+			"old committee has [%v] members while honest threshold is [%v]",
+			len(oldCommitteeMembersIndexes),
+			honestThreshold,
+		)
+	}
+
+	seen := make(map[group.MemberIndex]bool)
+	for _, memberIndex := range oldCommitteeMembersIndexes {
+		if memberIndex < 1 || int(memberIndex) > groupSize {
+			return fmt.Errorf("member [%v] is out of the group", memberIndex)
+		}
+
+		if seen[memberIndex] {
+			return fmt.Errorf("member [%v] is duplicated", memberIndex)
+		}
+		seen[memberIndex] = true
+	}
+
+	return nil
+}
+
+// RegisterUnmarshallers initializes the given broadcast channel to be able to
+// perform resharing protocol interactions by registering all the required
+// protocol message unmarshallers.
+func RegisterUnmarshallers(channel net.BroadcastChannel) {
+	channel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &ephemeralPublicKeyMessage{}
+	})
+	channel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &tssRoundOneMessage{}
+	})
+	channel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &tssRoundTwoMessage{}
+	})
+	channel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &tssRoundThreeMessage{}
+	})
+	channel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &tssRoundFourMessage{}
+	})
+	channel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &tssRoundFiveMessage{}
+	})
+	channel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &resultPreparedMessage{}
+	})
+	channel.SetUnmarshaler(func() net.TaggedUnmarshaler {
+		return &resultCommittedMessage{}
+	})
+}
@@ -0,0 +1,14 @@
+package resharing
+
+import (
+	"github.com/keep-network/keep-core/pkg/tecdsa"
+)
+
+// Result of the tECDSA resharing protocol.
+type Result struct {
+	// PrivateKeyShare is the refreshed tECDSA private key share produced as
+	// result of the tECDSA resharing process. The share corresponds to the
+	// same group public key as the share used as protocol input. The share
+	// must be confirmed before it replaces the current one.
+	PrivateKeyShare *tecdsa.PrivateKeyShare
+}
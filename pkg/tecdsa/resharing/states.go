@@ -0,0 +1,649 @@
+package resharing
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/protocol/group"
+	"github.com/keep-network/keep-core/pkg/protocol/state"
+)
+
+// ephemeralKeyPairGenerationState is the state during which members broadcast
+// public ephemeral keys generated for other members of the group.
+// `ephemeralPublicKeyMessage`s are valid in this state.
+type ephemeralKeyPairGenerationState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *ephemeralKeyPairGeneratingMember
+}
+
+func (ekpgs *ephemeralKeyPairGenerationState) Initiate(ctx context.Context) error {
+	message, err := ekpgs.member.generateEphemeralKeyPair()
+	if err != nil {
+		return err
+	}
+
+	if err := ekpgs.channel.Send(ctx, message, net.BackoffRetransmissionStrategy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ekpgs *ephemeralKeyPairGenerationState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if ekpgs.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && ekpgs.member.sessionID == protocolMessage.SessionID() {
+			ekpgs.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (ekpgs *ephemeralKeyPairGenerationState) CanTransition() bool {
+	messagingDone := len(receivedMessages[*ephemeralPublicKeyMessage](ekpgs.BaseAsyncState)) ==
+		len(ekpgs.member.group.OperatingMemberIndexes())-1
+
+	return messagingDone
+}
+
+func (ekpgs *ephemeralKeyPairGenerationState) Next() (state.AsyncState, error) {
+	return &symmetricKeyGenerationState{
+		BaseAsyncState: ekpgs.BaseAsyncState,
+		channel:        ekpgs.channel,
+		member:         ekpgs.member.initializeSymmetricKeyGeneration(),
+	}, nil
+}
+
+func (ekpgs *ephemeralKeyPairGenerationState) MemberIndex() group.MemberIndex {
+	return ekpgs.member.id
+}
+
+// symmetricKeyGenerationState is the state during which members compute
+// symmetric keys from the previously exchanged ephemeral public keys.
+// No messages are valid in this state.
+type symmetricKeyGenerationState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *symmetricKeyGeneratingMember
+}
+
+func (skgs *symmetricKeyGenerationState) Initiate(ctx context.Context) error {
+	return skgs.member.generateSymmetricKeys(
+		receivedMessages[*ephemeralPublicKeyMessage](skgs.BaseAsyncState),
+	)
+}
+
+func (skgs *symmetricKeyGenerationState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if skgs.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && skgs.member.sessionID == protocolMessage.SessionID() {
+			skgs.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (skgs *symmetricKeyGenerationState) CanTransition() bool {
+	return true
+}
+
+func (skgs *symmetricKeyGenerationState) Next() (state.AsyncState, error) {
+	return &tssRoundOneState{
+		BaseAsyncState: skgs.BaseAsyncState,
+		channel:        skgs.channel,
+		member:         skgs.member.initializeTssRoundOne(),
+	}, nil
+}
+
+func (skgs *symmetricKeyGenerationState) MemberIndex() group.MemberIndex {
+	return skgs.member.id
+}
+
+// tssRoundOneState is the state during which members of the old committee
+// broadcast TSS round one messages.
+// `tssRoundOneMessage`s are valid in this state.
+type tssRoundOneState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *tssRoundOneMember
+}
+
+func (tros *tssRoundOneState) Initiate(ctx context.Context) error {
+	message, err := tros.member.tssRoundOne(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Members outside the old committee do not produce round one messages.
+	if message == nil {
+		return nil
+	}
+
+	if err := tros.channel.Send(ctx, message, net.BackoffRetransmissionStrategy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tros *tssRoundOneState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if tros.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && tros.member.sessionID == protocolMessage.SessionID() {
+			tros.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (tros *tssRoundOneState) CanTransition() bool {
+	messagingDone := len(oldCommitteeMessages(
+		tros.member.member,
+		receivedMessages[*tssRoundOneMessage](tros.BaseAsyncState),
+	)) == tros.member.otherOldCommitteeMembersCount()
+
+	return messagingDone
+}
+
+func (tros *tssRoundOneState) Next() (state.AsyncState, error) {
+	return &tssRoundTwoState{
+		BaseAsyncState: tros.BaseAsyncState,
+		channel:        tros.channel,
+		member:         tros.member.initializeTssRoundTwo(),
+	}, nil
+}
+
+func (tros *tssRoundOneState) MemberIndex() group.MemberIndex {
+	return tros.member.id
+}
+
+// tssRoundTwoState is the state during which members broadcast TSS
+// round two messages.
+// `tssRoundTwoMessage`s are valid in this state.
+type tssRoundTwoState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *tssRoundTwoMember
+}
+
+func (trts *tssRoundTwoState) Initiate(ctx context.Context) error {
+	message, err := trts.member.tssRoundTwo(
+		ctx,
+		oldCommitteeMessages(
+			trts.member.member,
+			receivedMessages[*tssRoundOneMessage](trts.BaseAsyncState),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := trts.channel.Send(ctx, message, net.BackoffRetransmissionStrategy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (trts *tssRoundTwoState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if trts.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && trts.member.sessionID == protocolMessage.SessionID() {
+			trts.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (trts *tssRoundTwoState) CanTransition() bool {
+	messagingDone := len(receivedMessages[*tssRoundTwoMessage](trts.BaseAsyncState)) ==
+		len(trts.member.group.OperatingMemberIndexes())-1
+
+	return messagingDone
+}
+
+func (trts *tssRoundTwoState) Next() (state.AsyncState, error) {
+	return &tssRoundThreeState{
+		BaseAsyncState: trts.BaseAsyncState,
+		channel:        trts.channel,
+		member:         trts.member.initializeTssRoundThree(),
+	}, nil
+}
+
+func (trts *tssRoundTwoState) MemberIndex() group.MemberIndex {
+	return trts.member.id
+}
+
+// tssRoundThreeState is the state during which members of the old committee
+// broadcast TSS round three messages.
+// `tssRoundThreeMessage`s are valid in this state.
+type tssRoundThreeState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *tssRoundThreeMember
+}
+
+func (trths *tssRoundThreeState) Initiate(ctx context.Context) error {
+	message, err := trths.member.tssRoundThree(
+		ctx,
+		receivedMessages[*tssRoundTwoMessage](trths.BaseAsyncState),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Members outside the old committee do not produce round three messages.
+	if message == nil {
+		return nil
+	}
+
+	if err := trths.channel.Send(ctx, message, net.BackoffRetransmissionStrategy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (trths *tssRoundThreeState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if trths.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && trths.member.sessionID == protocolMessage.SessionID() {
+			trths.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (trths *tssRoundThreeState) CanTransition() bool {
+	messagingDone := len(oldCommitteeMessages(
+		trths.member.member,
+		receivedMessages[*tssRoundThreeMessage](trths.BaseAsyncState),
+	)) == trths.member.otherOldCommitteeMembersCount()
+
+	return messagingDone
+}
+
+func (trths *tssRoundThreeState) Next() (state.AsyncState, error) {
+	return &tssRoundFourState{
+		BaseAsyncState: trths.BaseAsyncState,
+		channel:        trths.channel,
+		member:         trths.member.initializeTssRoundFour(),
+	}, nil
+}
+
+func (trths *tssRoundThreeState) MemberIndex() group.MemberIndex {
+	return trths.member.id
+}
+
+// tssRoundFourState is the state during which members broadcast TSS
+// round four messages.
+// `tssRoundFourMessage`s are valid in this state.
+type tssRoundFourState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *tssRoundFourMember
+}
+
+func (trfs *tssRoundFourState) Initiate(ctx context.Context) error {
+	message, err := trfs.member.tssRoundFour(
+		ctx,
+		oldCommitteeMessages(
+			trfs.member.member,
+			receivedMessages[*tssRoundThreeMessage](trfs.BaseAsyncState),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := trfs.channel.Send(ctx, message, net.BackoffRetransmissionStrategy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (trfs *tssRoundFourState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if trfs.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && trfs.member.sessionID == protocolMessage.SessionID() {
+			trfs.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (trfs *tssRoundFourState) CanTransition() bool {
+	messagingDone := len(receivedMessages[*tssRoundFourMessage](trfs.BaseAsyncState)) ==
+		len(trfs.member.group.OperatingMemberIndexes())-1
+
+	return messagingDone
+}
+
+func (trfs *tssRoundFourState) Next() (state.AsyncState, error) {
+	return &tssRoundFiveState{
+		BaseAsyncState: trfs.BaseAsyncState,
+		channel:        trfs.channel,
+		member:         trfs.member.initializeTssRoundFive(),
+	}, nil
+}
+
+func (trfs *tssRoundFourState) MemberIndex() group.MemberIndex {
+	return trfs.member.id
+}
+
+// tssRoundFiveState is the state during which members broadcast TSS
+// round five messages.
+// `tssRoundFiveMessage`s are valid in this state.
+type tssRoundFiveState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *tssRoundFiveMember
+}
+
+func (trfis *tssRoundFiveState) Initiate(ctx context.Context) error {
+	message, err := trfis.member.tssRoundFive(
+		ctx,
+		receivedMessages[*tssRoundFourMessage](trfis.BaseAsyncState),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := trfis.channel.Send(ctx, message, net.BackoffRetransmissionStrategy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (trfis *tssRoundFiveState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if trfis.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && trfis.member.sessionID == protocolMessage.SessionID() {
+			trfis.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (trfis *tssRoundFiveState) CanTransition() bool {
+	messagingDone := len(receivedMessages[*tssRoundFiveMessage](trfis.BaseAsyncState)) ==
+		len(trfis.member.group.OperatingMemberIndexes())-1
+
+	return messagingDone
+}
+
+func (trfis *tssRoundFiveState) Next() (state.AsyncState, error) {
+	return &finalizationState{
+		BaseAsyncState: trfis.BaseAsyncState,
+		channel:        trfis.channel,
+		member:         trfis.member.initializeFinalization(),
+	}, nil
+}
+
+func (trfis *tssRoundFiveState) MemberIndex() group.MemberIndex {
+	return trfis.member.id
+}
+
+// finalizationState is the state during which members complete the TSS
+// part of the resharing protocol and compute refreshed private key shares.
+// No messages are valid in this state.
+type finalizationState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *finalizingMember
+}
+
+func (fs *finalizationState) Initiate(ctx context.Context) error {
+	err := fs.member.tssFinalize(
+		ctx,
+		receivedMessages[*tssRoundFiveMessage](fs.BaseAsyncState),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (fs *finalizationState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if fs.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && fs.member.sessionID == protocolMessage.SessionID() {
+			fs.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (fs *finalizationState) CanTransition() bool {
+	return true
+}
+
+func (fs *finalizationState) Next() (state.AsyncState, error) {
+	return &resultPreparationState{
+		BaseAsyncState: fs.BaseAsyncState,
+		channel:        fs.channel,
+		member:         fs.member.initializeResultPreparation(),
+	}, nil
+}
+
+func (fs *finalizationState) MemberIndex() group.MemberIndex {
+	return fs.member.id
+}
+
+// resultPreparationState is the state during which members broadcast hashes
+// of the public parts of their refreshed private key shares, announcing they
+// are ready to commit to them. This is the first phase of the two-phase
+// commit of the resharing result.
+// `resultPreparedMessage`s are valid in this state.
+type resultPreparationState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *resultPreparingMember
+}
+
+func (rps *resultPreparationState) Initiate(ctx context.Context) error {
+	message := rps.member.prepareResult()
+
+	if err := rps.channel.Send(ctx, message, net.BackoffRetransmissionStrategy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (rps *resultPreparationState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if rps.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && rps.member.sessionID == protocolMessage.SessionID() {
+			rps.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (rps *resultPreparationState) CanTransition() bool {
+	messagingDone := len(receivedMessages[*resultPreparedMessage](rps.BaseAsyncState)) ==
+		len(rps.member.group.OperatingMemberIndexes())-1
+
+	return messagingDone
+}
+
+func (rps *resultPreparationState) Next() (state.AsyncState, error) {
+	return &resultCommitmentState{
+		BaseAsyncState: rps.BaseAsyncState,
+		channel:        rps.channel,
+		member:         rps.member.initializeResultCommitment(),
+	}, nil
+}
+
+func (rps *resultPreparationState) MemberIndex() group.MemberIndex {
+	return rps.member.id
+}
+
+// resultCommitmentState is the state during which members make sure all
+// other members prepared the same result and broadcast their commitment
+// to it. This is the second phase of the two-phase commit of the resharing
+// result.
+// `resultCommittedMessage`s are valid in this state.
+type resultCommitmentState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *resultCommittingMember
+}
+
+func (rcs *resultCommitmentState) Initiate(ctx context.Context) error {
+	message, err := rcs.member.commitResult(
+		receivedMessages[*resultPreparedMessage](rcs.BaseAsyncState),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := rcs.channel.Send(ctx, message, net.BackoffRetransmissionStrategy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (rcs *resultCommitmentState) Receive(netMessage net.Message) error {
+	if protocolMessage, ok := netMessage.Payload().(message); ok {
+		if rcs.member.shouldAcceptMessage(
+			protocolMessage.SenderID(),
+			netMessage.SenderPublicKey(),
+		) && rcs.member.sessionID == protocolMessage.SessionID() {
+			rcs.ReceiveToHistory(netMessage)
+		}
+	}
+
+	return nil
+}
+
+func (rcs *resultCommitmentState) CanTransition() bool {
+	messagingDone := len(receivedMessages[*resultCommittedMessage](rcs.BaseAsyncState)) ==
+		len(rcs.member.group.OperatingMemberIndexes())-1
+
+	return messagingDone
+}
+
+func (rcs *resultCommitmentState) Next() (state.AsyncState, error) {
+	return &committedState{
+		BaseAsyncState: rcs.BaseAsyncState,
+		channel:        rcs.channel,
+		member:         rcs.member.initializeCommitted(),
+	}, nil
+}
+
+func (rcs *resultCommitmentState) MemberIndex() group.MemberIndex {
+	return rcs.member.id
+}
+
+// committedState is the last state of the resharing protocol - in this
+// state, all members committed to the refreshed private key shares.
+// No messages are valid in this state.
+//
+// State prepares a result that is returned to the caller.
+type committedState struct {
+	*state.BaseAsyncState
+
+	channel net.BroadcastChannel
+	member  *committedMember
+}
+
+func (cs *committedState) Initiate(ctx context.Context) error {
+	return cs.member.verifyCommitments(
+		receivedMessages[*resultCommittedMessage](cs.BaseAsyncState),
+	)
+}
+
+func (cs *committedState) Receive(net.Message) error {
+	return nil
+}
+
+func (cs *committedState) CanTransition() bool {
+	return true
+}
+
+func (cs *committedState) Next() (state.AsyncState, error) {
+	return nil, nil
+}
+
+func (cs *committedState) MemberIndex() group.MemberIndex {
+	return cs.member.id
+}
+
+func (cs *committedState) result() *Result {
+	return cs.member.Result()
+}
+
+// receivedMessages returns all messages of type T that have been received
+// and validated so far. Returned messages are deduplicated so there is a
+// guarantee that only one message of the given type is returned for the
+// given sender.
+func receivedMessages[T message](base *state.BaseAsyncState) []T {
+	var messageTemplate T
+
+	payloads := state.ExtractMessagesPayloads[T](base, messageTemplate.Type())
+
+	return state.DeduplicateMessagesPayloads(
+		payloads,
+		func(message T) string {
+			return strconv.Itoa(int(message.SenderID()))
+		},
+	)
+}
+
+// oldCommitteeMessages filters out the given messages that were not sent by
+// members of the old committee.
+func oldCommitteeMessages[T message](m *member, messages []T) []T {
+	var filtered []T
+	for _, message := range messages {
+		if m.isOldCommitteeMember(message.SenderID()) {
+			filtered = append(filtered, message)
+		}
+	}
+
+	return filtered
+}